@@ -34,6 +34,21 @@ type ResourceContextAdapter interface {
 	NewResourceContext() ResourceContextObject
 }
 
+// ResourceContextNameAdapter is an optional interface of ResourceContextAdapter to customize
+// the name of ResourceContext used by xset, e.g., prefixing it by tenant. defaultName is
+// spec.ScaleStrategy.Context if set, otherwise the name of xset. Empty result falls back to defaultName.
+type ResourceContextNameAdapter interface {
+	GetResourceContextName(xset XSetObject, defaultName string) string
+}
+
+// ResourceContextProjectionAdapter is an optional interface of ResourceContextAdapter to project
+// selected ContextDetail data onto the created target as annotations, so that workloads and sidecars
+// can read their allocation metadata without querying ResourceContext.
+type ResourceContextProjectionAdapter interface {
+	// GetProjectedContextKeys returns a map from the key in ContextDetail.Data to the annotation key on target.
+	GetProjectedContextKeys() map[string]string
+}
+
 // ResourceContextKeyEnum defines the key of resource context
 type ResourceContextKeyEnum int
 
@@ -47,6 +62,13 @@ const (
 	EnumScaleInContextDataKey
 	EnumReplaceNewTargetIDContextDataKey
 	EnumReplaceOriginTargetIDContextDataKey
+
+	// Optional keys are not counted in EnumContextKeyNum, and fall back to default ones if not provided
+	EnumNodeNameContextDataKey
+	EnumZoneContextDataKey
+	EnumPostCreateContextDataKey
+	EnumPostDeleteContextDataKey
+	EnumSubsetContextDataKey
 )
 
 // ResourceContextSpec defines the desired state of ResourceContext
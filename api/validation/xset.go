@@ -0,0 +1,146 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package validation
+
+import (
+	"maps"
+	"time"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// DefaultXSetSelector sets selector of XSet to match labels of its template if selector is empty, since an empty
+// selector selects all targets in namespace. It is expected to be called by defaulting webhook of consumers.
+func DefaultXSetSelector(spec *api.XSetSpec, templateLabels map[string]string) {
+	if !IsSelectorEmpty(spec.Selector) || len(templateLabels) == 0 {
+		return
+	}
+	spec.Selector = &metav1.LabelSelector{MatchLabels: maps.Clone(templateLabels)}
+}
+
+// ValidateXSetSelector validates that selector of XSet is not empty, is valid, and selects targets created from
+// template with templateLabels, otherwise targets are orphaned once created and created again endlessly. fldPath is
+// the path of spec, e.g., field.NewPath("spec").
+func ValidateXSetSelector(spec *api.XSetSpec, templateLabels map[string]string, fldPath *field.Path) field.ErrorList {
+	selectorPath := fldPath.Child("selector")
+	if IsSelectorEmpty(spec.Selector) {
+		return field.ErrorList{field.Required(selectorPath, "empty selector selects all targets in namespace")}
+	}
+	if allErrs := metav1validation.ValidateLabelSelector(spec.Selector, selectorPath); len(allErrs) > 0 {
+		return allErrs
+	}
+	selector, err := metav1.LabelSelectorAsSelector(spec.Selector)
+	if err != nil {
+		return field.ErrorList{field.Invalid(selectorPath, spec.Selector, err.Error())}
+	}
+	if !selector.Matches(labels.Set(templateLabels)) {
+		return field.ErrorList{field.Invalid(selectorPath, selector.String(), "selector does not match labels of template")}
+	}
+	return nil
+}
+
+// ValidateXSetSelectorUpdate validates that selector of XSet is immutable, since targets created before are no longer
+// selected and managed once selector changes. Setting selector of XSet created with an empty one is allowed, so that
+// legacy XSets are defaulted by DefaultXSetSelector. fldPath is the path of spec, e.g., field.NewPath("spec").
+func ValidateXSetSelectorUpdate(newSpec, oldSpec *api.XSetSpec, fldPath *field.Path) field.ErrorList {
+	if IsSelectorEmpty(oldSpec.Selector) || apiequality.Semantic.DeepEqual(newSpec.Selector, oldSpec.Selector) {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(fldPath.Child("selector"), newSpec.Selector, "field is immutable")}
+}
+
+// IsSelectorEmpty checks whether selector selects all objects
+func IsSelectorEmpty(selector *metav1.LabelSelector) bool {
+	return selector == nil || (len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0)
+}
+
+// MaintenanceWindowStartLayout is the layout of start of MaintenanceWindow
+const MaintenanceWindowStartLayout = "15:04"
+
+// ValidateMaintenanceWindows validates days, start, duration and time zone of maintenance windows. fldPath is the
+// path of windows, e.g., field.NewPath("spec", "maintenanceWindows").
+func ValidateMaintenanceWindows(windows []api.MaintenanceWindow, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, window := range windows {
+		idxPath := fldPath.Index(i)
+		for j, day := range window.Days {
+			if _, ok := ParseWeekday(day); !ok {
+				allErrs = append(allErrs, field.NotSupported(idxPath.Child("days").Index(j), day, weekdayNames()))
+			}
+		}
+		if _, err := time.Parse(MaintenanceWindowStartLayout, window.Start); err != nil {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("start"), window.Start, "must be in format of "+MaintenanceWindowStartLayout))
+		}
+		if window.Duration.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("duration"), window.Duration.String(), "must be positive"))
+		}
+		if _, err := time.LoadLocation(window.TimeZone); err != nil {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("timeZone"), window.TimeZone, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// ParseWeekday parses day of week in its English name, e.g., "Saturday"
+func ParseWeekday(day string) (time.Weekday, bool) {
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if weekday.String() == day {
+			return weekday, true
+		}
+	}
+	return time.Sunday, false
+}
+
+func weekdayNames() []string {
+	names := make([]string, 0, 7)
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		names = append(names, weekday.String())
+	}
+	return names
+}
+
+// ValidateRevisionRatios validates that revisions pinned by ratios are unique, and their percents are within [0, 100]
+// in total. fldPath is the path of ratios, e.g., field.NewPath("spec", "updateStrategy", "rollingUpdate",
+// "byRevisionRatios").
+func ValidateRevisionRatios(ratios *api.ByRevisionRatios, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	revisions := map[string]struct{}{}
+	total := int32(0)
+	for i, ratio := range ratios.Revisions {
+		idxPath := fldPath.Child("revisions").Index(i)
+		if ratio.Revision == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("revision"), ""))
+		} else if _, exist := revisions[ratio.Revision]; exist {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("revision"), ratio.Revision))
+		}
+		revisions[ratio.Revision] = struct{}{}
+		if ratio.Percent < 0 || ratio.Percent > 100 {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("percent"), ratio.Percent, "must be within [0, 100]"))
+		}
+		total += ratio.Percent
+	}
+	if total > 100 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("revisions"), total, "percents must not exceed 100 in total"))
+	}
+	return allErrs
+}
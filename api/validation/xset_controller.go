@@ -39,11 +39,32 @@ func ValidateXSetController(xSetController api.XSetController) error {
 	return errors.Join(
 		validateMeta(xSetController.XSetMeta()),
 		validateMeta(xSetController.XMeta()),
-		validateFinalizerName(xSetController.FinalizerName()),
+		validateFinalizer(xSetController),
 		validateXSetLabelAnnotationManager(xSetController),
 	)
 }
 
+// validateFinalizer validates FinalizerName, which is allowed to be empty in finalizer-less mode. Without finalizer,
+// XSet is removed by api server at once when deleted, so that the trade-offs are:
+//   - targets and subresources are deleted by garbage collection via ownerReferences, regardless of
+//     RetainPvcWhenXSetDeleted, and no Terminating condition or event is reported;
+//   - IDs are released from ResourceContext in best effort by janitor, which only knows the context named by XSet
+//     itself or ResourceContextNameAdapter, so that IDs in a shared context pool are leaked until reclaimed manually;
+//   - target protection finalizers can never be removed, so TargetProtectionFinalizerAdapter is not allowed.
+func validateFinalizer(xSetController api.XSetController) error {
+	adapter, ok := xSetController.(api.FinalizerlessAdapter)
+	if !ok || !adapter.SkipXSetFinalizer() {
+		return validateFinalizerName(xSetController.FinalizerName())
+	}
+	if _, ok := xSetController.(api.TargetProtectionFinalizerAdapter); ok {
+		return errors.New("TargetProtectionFinalizerAdapter is not allowed in finalizer-less mode")
+	}
+	if name := xSetController.FinalizerName(); name != "" {
+		return validateFinalizerName(name)
+	}
+	return nil
+}
+
 func validateFinalizerName(name string) error {
 	msg := validation.IsQualifiedName(name)
 	if len(msg) > 0 {
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package validation
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+func TestXSetSelector(t *testing.T) {
+	fldPath := field.NewPath("spec")
+	templateLabels := map[string]string{"app": "foo"}
+
+	spec := &api.XSetSpec{}
+	if errs := ValidateXSetSelector(spec, templateLabels, fldPath); len(errs) != 1 || errs[0].Type != field.ErrorTypeRequired {
+		t.Errorf("expect empty selector required, got %v", errs)
+	}
+	DefaultXSetSelector(spec, templateLabels)
+	if errs := ValidateXSetSelector(spec, templateLabels, fldPath); len(errs) != 0 {
+		t.Errorf("expect defaulted selector valid, got %v", errs)
+	}
+	templateLabels["app"] = "bar"
+	if spec.Selector.MatchLabels["app"] != "foo" {
+		t.Errorf("defaulted selector should not share labels with template")
+	}
+	if errs := ValidateXSetSelector(spec, templateLabels, fldPath); len(errs) != 1 || errs[0].Field != "spec.selector" {
+		t.Errorf("expect selector not matching template invalid, got %v", errs)
+	}
+
+	// selector is immutable once set, and legacy XSets with empty selector can be defaulted
+	changed := &api.XSetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "bar"}}}
+	if errs := ValidateXSetSelectorUpdate(changed, spec, fldPath); len(errs) != 1 {
+		t.Errorf("expect changed selector invalid, got %v", errs)
+	}
+	if errs := ValidateXSetSelectorUpdate(spec, spec.DeepCopy(), fldPath); len(errs) != 0 {
+		t.Errorf("expect unchanged selector valid, got %v", errs)
+	}
+	if errs := ValidateXSetSelectorUpdate(spec, &api.XSetSpec{}, fldPath); len(errs) != 0 {
+		t.Errorf("expect selector defaulted on update valid, got %v", errs)
+	}
+}
+
+func TestValidateMaintenanceWindows(t *testing.T) {
+	fldPath := field.NewPath("spec", "maintenanceWindows")
+	windows := []api.MaintenanceWindow{
+		{Days: []string{"Saturday", "Sunday"}, Start: "22:00", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "Asia/Shanghai"},
+		{Start: "02:30", Duration: metav1.Duration{Duration: 30 * time.Minute}},
+	}
+	if errs := ValidateMaintenanceWindows(windows, fldPath); len(errs) != 0 {
+		t.Errorf("expect windows valid, got %v", errs)
+	}
+
+	invalid := []api.MaintenanceWindow{{Days: []string{"Sat"}, Start: "10pm", TimeZone: "Mars/Olympus"}}
+	errs := ValidateMaintenanceWindows(invalid, fldPath)
+	var fields []string
+	for _, err := range errs {
+		fields = append(fields, err.Field)
+	}
+	want := []string{"spec.maintenanceWindows[0].days[0]", "spec.maintenanceWindows[0].start", "spec.maintenanceWindows[0].duration", "spec.maintenanceWindows[0].timeZone"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("got invalid fields %v, want %v", fields, want)
+	}
+}
+
+func TestValidateRevisionRatios(t *testing.T) {
+	fldPath := field.NewPath("spec", "updateStrategy", "rollingUpdate", "byRevisionRatios")
+	ratios := &api.ByRevisionRatios{Revisions: []api.RevisionRatio{{Revision: "foo-1", Percent: 80}, {Revision: "foo-2", Percent: 10}}}
+	if errs := ValidateRevisionRatios(ratios, fldPath); len(errs) != 0 {
+		t.Errorf("expect ratios valid, got %v", errs)
+	}
+
+	invalid := &api.ByRevisionRatios{Revisions: []api.RevisionRatio{{Revision: "foo-1", Percent: 90}, {Revision: "foo-1", Percent: 20}, {Percent: -1}}}
+	errs := ValidateRevisionRatios(invalid, fldPath)
+	var fields []string
+	for _, err := range errs {
+		fields = append(fields, err.Field)
+	}
+	prefix := "spec.updateStrategy.rollingUpdate.byRevisionRatios.revisions"
+	want := []string{prefix + "[1].revision", prefix + "[2].revision", prefix + "[2].percent", prefix}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("got invalid fields %v, want %v", fields, want)
+	}
+}
@@ -17,10 +17,154 @@
 package api
 
 import (
+	"maps"
+
 	appsv1alpha1 "kusionstack.io/kube-api/apps/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// XSetResyncAnnotationKey is the annotation on XSet to force a full resync, e.g., with a timestamp as value. Each new
+// value drops cache expectations of XSet, lists targets from api server and dumps sync decisions to log once.
+const XSetResyncAnnotationKey = "xset.kusionstack.io/resync"
+
+// ResourceContextOwnerKindLabelKey is the label on ResourceContexts recording the kind of XSets owning their IDs. IDs
+// of deleted XSets in labelled ResourceContexts are released periodically in finalizer-less mode.
+const ResourceContextOwnerKindLabelKey = "xset.kusionstack.io/owner-kind"
+
+// XSetReplicasManagedByAnnotationKey is the annotation on XSet marking spec.replicas as managed by an autoscaler,
+// e.g., "hpa" or "keda" as value. GitOps tools are expected to ignore spec.replicas of XSets with it, so that they do
+// not fight with the autoscaler.
+const XSetReplicasManagedByAnnotationKey = "xset.kusionstack.io/replicas-managed-by"
+
+// XSetMinReplicasAnnotationKey and XSetMaxReplicasAnnotationKey are the annotations on XSet bounding spec.replicas,
+// which only take effect with XSetReplicasManagedByAnnotationKey. Replicas out of bounds, e.g., reverted by GitOps
+// tools, are clamped into them during reconcile, so that targets are not scaled beyond what the autoscaler allows.
+const (
+	XSetMinReplicasAnnotationKey = "xset.kusionstack.io/min-replicas"
+	XSetMaxReplicasAnnotationKey = "xset.kusionstack.io/max-replicas"
+)
+
+// XSetWriteQPSAnnotationKey and XSetWriteBurstAnnotationKey are the annotations on XSet limiting the rate of targets
+// created and deleted for scaling, so that a huge XSet does not exhaust rate limits of the client shared by all XSets.
+// Targets beyond the limit are left to following reconciles. Burst defaults to QPS rounded up.
+const (
+	XSetWriteQPSAnnotationKey   = "xset.kusionstack.io/write-qps"
+	XSetWriteBurstAnnotationKey = "xset.kusionstack.io/write-burst"
+)
+
+// XSetIDCleanDryRunAnnotationKey is the annotation on XSet with "true" as value to audit reclamation of instance IDs
+// which are owned by XSet but used by no target, e.g., in a context pool shared by several XSets. IDs to reclaim are
+// only reported by ResourceContextCleanDryRun events, and left in ResourceContext, until the annotation is removed.
+const XSetIDCleanDryRunAnnotationKey = "xset.kusionstack.io/id-clean-dry-run"
+
+// XSetSuspendAnnotationKey is the annotation on XSet with "true" as value to suspend its reconciliation, which works
+// like spec.paused but is settable by operators without write access to spec, e.g., when spec is synced by GitOps.
+// Targets are neither created, deleted nor updated, and status is left as is except the Suspended condition, until
+// the annotation is removed. Deletion of XSet is not suspended.
+const XSetSuspendAnnotationKey = "xset.kusionstack.io/suspend"
+
+// IsXSetSuspended checks whether reconciliation of XSet is suspended by XSetSuspendAnnotationKey
+func IsXSetSuspended(xset client.Object) bool {
+	return xset.GetAnnotations()[XSetSuspendAnnotationKey] == "true"
+}
+
+// XSetResumeRolloutAnnotationKey is the annotation on XSet with the name of revision as value to resume its rollout
+// paused automatically by WarningEventPauseAdapter. Rollout of the revision is not paused again once resumed.
+const XSetResumeRolloutAnnotationKey = "xset.kusionstack.io/resume-rollout"
+
+// XSetMigrationAnnotationKey is the annotation on XSet recording the stage of migration adopting targets of a legacy
+// workload, e.g., a CollaSet, which is recorded in XSetMigrationSourceAnnotationKey as <kind>/<name>. XSet is not
+// reconciled until the stage is XSetMigrationCompleted unless it is deleted, so that it never acts on targets still
+// being adopted.
+const (
+	XSetMigrationAnnotationKey       = "xset.kusionstack.io/migration"
+	XSetMigrationSourceAnnotationKey = "xset.kusionstack.io/migration-source"
+)
+
+// XSetMigrationStage is the stage of migration recorded in XSetMigrationAnnotationKey
+type XSetMigrationStage string
+
+const (
+	// XSetMigrationPrepared indicates the legacy workload is paused, and nothing is adopted yet
+	XSetMigrationPrepared XSetMigrationStage = "Prepared"
+	// XSetMigrationAdopted indicates targets, subresources, revisions and instance IDs are adopted by XSet
+	XSetMigrationAdopted XSetMigrationStage = "Adopted"
+	// XSetMigrationCompleted indicates XSet takes over targets, and the paused legacy workload can be deleted
+	XSetMigrationCompleted XSetMigrationStage = "Completed"
+)
+
+// IsXSetMigrating checks whether XSet is adopting targets of a legacy workload
+func IsXSetMigrating(xset client.Object) bool {
+	stage, ok := xset.GetAnnotations()[XSetMigrationAnnotationKey]
+	return ok && XSetMigrationStage(stage) != XSetMigrationCompleted
+}
+
+// TargetLastActionAnnotationKey is the annotation on targets recording the last action taken on them by XSet
+// controller, in form of <action>@<RFC3339 timestamp>, for post-incident analysis.
+const TargetLastActionAnnotationKey = "xset.kusionstack.io/last-action"
+
+// PodServiceReadyReadinessGate is the readinessGate condition type set by traffic controllers once Pod target is
+// serving traffic, which can be injected by ReadinessGateAdapter.
+const PodServiceReadyReadinessGate = "pod.kusionstack.io/service-ready"
+
+// TargetPendingDeletionLabelKey is the default key of XPendingDeletionLabelKey.
+const TargetPendingDeletionLabelKey = "xset.kusionstack.io/pending-deletion"
+
+// TargetDeletionApprovedAnnotationKey is the annotation set by external approvers on targets pending deletion to
+// approve it, with "true" as value.
+const TargetDeletionApprovedAnnotationKey = "xset.kusionstack.io/deletion-approved"
+
+// TargetAppliedPatchesAnnotationKey is the annotation on targets recording template patches applied by XSet
+// controller, in form of <name>:<hash> joined by commas, so that each patch is applied once.
+const TargetAppliedPatchesAnnotationKey = "xset.kusionstack.io/applied-patches"
+
+// TargetQuarantinedLabelKey is the default key of XQuarantinedLabelKey.
+const TargetQuarantinedLabelKey = "xset.kusionstack.io/quarantined"
+
+// TargetSubsetLabelKey is the label on targets recording the subset they belong to, as SubsetAdapter requires
+const TargetSubsetLabelKey = "xset.kusionstack.io/subset"
+
+// TargetEvictionBlockedAnnotationKey is the annotation on Pod targets recording when their eviction was first blocked,
+// e.g., by PodDisruptionBudget, in RFC3339, which is used to fall back as PodEvictionAdapter requires.
+const TargetEvictionBlockedAnnotationKey = "xset.kusionstack.io/eviction-blocked-since"
+
+// DefaultEvictionRequestAnnotationKeys are annotations on targets requesting eviction by descheduler, which are used
+// by DeschedulingReplaceAdapter if no keys are returned.
+var DefaultEvictionRequestAnnotationKeys = []string{
+	"descheduler.alpha.kubernetes.io/request-evict-only",
+	"descheduler.alpha.kubernetes.io/eviction-in-progress",
+}
+
+// DefaultPreemptionTaintKeys are taints on nodes going away soon, i.e., spot interruption notices and scale-down
+// candidates of cluster-autoscaler and karpenter, which are used by PreemptionAwareAdapter if no keys are returned.
+var DefaultPreemptionTaintKeys = []string{
+	"ToBeDeletedByClusterAutoscaler",
+	"DeletionCandidateOfClusterAutoscaler",
+	"karpenter.sh/disruption",
+	"aws-node-termination-handler/spot-itn",
+	"cloud.google.com/impending-node-termination",
+}
+
+// TargetAction is the action taken on target recorded in TargetLastActionAnnotationKey
+type TargetAction string
+
+const (
+	// TargetActionCreatedForScale indicates target is created for scaling out
+	TargetActionCreatedForScale TargetAction = "created-for-scale"
+	// TargetActionRecreatedForUpdate indicates target is recreated for update by recreate
+	TargetActionRecreatedForUpdate TargetAction = "recreated-for-update"
+	// TargetActionRecreated indicates target is recreated for an instance ID whose target is gone
+	TargetActionRecreated TargetAction = "recreated"
+	// TargetActionCreatedForReplace indicates target is created to replace an origin target
+	TargetActionCreatedForReplace TargetAction = "created-for-replace"
+	// TargetActionReplacing indicates target is being replaced by a new target
+	TargetActionReplacing TargetAction = "replacing"
+	// TargetActionChosenForUpdate indicates target is chosen to update
+	TargetActionChosenForUpdate TargetAction = "chosen-for-update"
+	// TargetActionChosenForScaleIn indicates target is chosen to scale in
+	TargetActionChosenForScaleIn TargetAction = "chosen-for-scale-in"
+)
+
 type XSetLabelAnnotationEnum int
 
 const EnumXSetLabelAnnotationsNum = int(wellKnownCount)
@@ -94,6 +238,15 @@ const (
 	// SubResourcePvcTemplateHashLabelKey is used to attach hash of pvc template to pvc subresource
 	SubResourcePvcTemplateHashLabelKey
 
+	// XPendingDeletionLabelKey is set on targets waiting for deletion approval, with the unix seconds when approval is
+	// requested as value, and is removed once the deletion is canceled.
+	XPendingDeletionLabelKey
+
+	// XQuarantinedLabelKey is set on targets quarantined by operators or CrashLoopRemediationAdapter, with the unix
+	// seconds when target is quarantined as value. Quarantined targets are kept for debugging out of replicas, so that
+	// replacements are created in their place, and are neither updated nor scaled in until deleted by TargetToDelete.
+	XQuarantinedLabelKey
+
 	// wellKnownCount is the number of XSetLabelAnnotationEnum
 	wellKnownCount
 )
@@ -127,11 +280,21 @@ var defaultXSetLabelAnnotationManager = map[XSetLabelAnnotationEnum]string{
 	XExcludeIndicationLabelKey:         appsv1alpha1.PodExcludeIndicationLabelKey,
 	SubResourcePvcTemplateLabelKey:     appsv1alpha1.PvcTemplateLabelKey,
 	SubResourcePvcTemplateHashLabelKey: appsv1alpha1.PvcTemplateHashLabelKey,
+	XPendingDeletionLabelKey:           TargetPendingDeletionLabelKey,
+	XQuarantinedLabelKey:               TargetQuarantinedLabelKey,
 }
 
+// NewXSetLabelAnnotationManager returns the manager with keys in m, where keys not provided fall back to defaults
 func NewXSetLabelAnnotationManager(m map[XSetLabelAnnotationEnum]string) XSetLabelAnnotationManager {
 	if m == nil {
 		m = defaultXSetLabelAnnotationManager
+	} else {
+		m = maps.Clone(m)
+		for key, value := range defaultXSetLabelAnnotationManager {
+			if _, ok := m[key]; !ok {
+				m[key] = value
+			}
+		}
 	}
 	return &xSetLabelAnnotationManager{
 		labelMap: m,
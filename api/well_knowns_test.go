@@ -0,0 +1,40 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"testing"
+)
+
+func TestNewXSetLabelAnnotationManager(t *testing.T) {
+	// keys not provided by custom managers fall back to defaults
+	mgr := NewXSetLabelAnnotationManager(map[XSetLabelAnnotationEnum]string{XInstanceIdLabelKey: "custom/instance-id"})
+	if got := mgr.Value(XInstanceIdLabelKey); got != "custom/instance-id" {
+		t.Errorf("Value(XInstanceIdLabelKey) = %q, want custom key", got)
+	}
+	for key := XSetLabelAnnotationEnum(0); key < wellKnownCount; key++ {
+		if key == XInstanceIdLabelKey {
+			continue
+		}
+		if got, want := mgr.Value(key), defaultXSetLabelAnnotationManager[key]; got != want {
+			t.Errorf("Value(%d) = %q, want default %q", key, got, want)
+		}
+	}
+	if got := NewXSetLabelAnnotationManager(nil).Value(XPendingDeletionLabelKey); got != TargetPendingDeletionLabelKey {
+		t.Errorf("Value(XPendingDeletionLabelKey) = %q, want %q", got, TargetPendingDeletionLabelKey)
+	}
+}
@@ -18,10 +18,15 @@ package api
 
 import (
 	"context"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 )
@@ -46,7 +51,68 @@ type XSetController interface {
 	// 		- ResourceContextAdapterGetter
 	// 		- LabelAnnotationManagerGetter
 	// 		- SubResourcePvcAdapter
+	// 		- SubResourceConfigAdapter
+	// 		- SubResourceAdapter
+	// 		- PvcRetentionPolicyAdapter
+	// 		- PvcSnapshotAdapter
+	// 		- PvcTemplateChangePolicyAdapter
+	// 		- PvcPreProvisionAdapter
+	// 		- StorageClassMigrationAdapter
+	// 		- PvcSizeOverrideAdapter
+	// 		- ReplacePvcPolicyAdapter
+	// 		- PvcExpansionAdapter
+	// 		- FinalizerlessAdapter
+	// 		- PriorityQueueAdapter
+	// 		- ExpectationAdapter
+	// 		- RequeueJitterAdapter
+	// 		- RateLimiterAdapter
+	// 		- NamespaceScopeAdapter
+	// 		- WatchProvider
 	// 		- DecorationAdapter
+	// 		- MultiDecorationAdapter
+	// 		- TargetAdoptionAdapter
+	// 		- TargetNamingAdapter
+	// 		- InstanceIDAdapter
+	// 		- AvailabilityAdapter
+	// 		- TargetListChunkAdapter
+	// 		- DeletionConcurrencyAdapter
+	// 		- DryRunCreateAdapter
+	// 		- TargetDeletionPolicyAdapter
+	// 		- TargetProtectionFinalizerAdapter
+	// 		- CompanionAdapter
+	// 		- TemplateDefaulter
+	// 		- StickyNodeAdapter
+	// 		- ZonePlacementAdapter
+	// 		- TerminatingTargetsAdapter
+	// 		- InstanceStatusesAdapter
+	// 		- ReadinessGateAdapter
+	// 		- PreCreateHookAdapter
+	// 		- PostCreateHookAdapter
+	// 		- PostDeleteHookAdapter
+	// 		- DeletionApprovalAdapter
+	// 		- TemplatePatchAdapter
+	// 		- ResourceContextWriteDebounceAdapter
+	// 		- CacheTransformAdapter
+	// 		- SyncSkipAdapter
+	// 		- RolloutAnalysisAdapter
+	// 		- HeadlessServiceAdapter
+	// 		- InPlaceResizeAdapter
+	// 		- PreemptionAwareAdapter
+	// 		- PodEvictionAdapter
+	// 		- DeschedulingReplaceAdapter
+	// 		- StatelessAdapter
+	// 		- RevisionDataAdapter
+	// 		- SubsetAdapter
+	// 		- CreationPriorityAdapter
+	// 		- FieldManagerAdapter
+	// 		- DriftDetectionAdapter
+	// 		- RevisionHashExclusionAdapter
+	// 		- TemplateRefAdapter
+	// 		- WarningEventPauseAdapter
+	// 		- CrashLoopRemediationAdapter
+	// 		- QuarantineAdapter
+	// 		- StuckTerminatingAdapter
+	// 		- NodeFailureReplaceAdapter
 }
 
 type XSetObject client.Object
@@ -66,6 +132,8 @@ type XOperation interface {
 	CheckReadyTime(object client.Object) (bool, *metav1.Time)
 	CheckAvailable(object client.Object) bool
 	CheckInactive(object client.Object) bool
+	// GetXOpsPriority returns ops priority of target. It is called concurrently for targets of large XSets, so it must
+	// be safe for concurrent use.
 	GetXOpsPriority(ctx context.Context, c client.Client, object client.Object) (*OpsPriority, error)
 }
 
@@ -88,7 +156,10 @@ type LabelAnnotationManagerGetter interface {
 // SubResourcePvcAdapter is used to manage pvc subresource for X, which are declared on XSet, e.g., spec.volumeClaimTemplate.
 // Once adapter is implemented, XSetController will automatically manage pvc: (1) create pvcs from GetXSetPvcTemplate for each
 // X object and attach theses pvcs with same instance-id, (2) upgrade pvcs and recreate X object pvcs when PvcTemplateChanged,
-// (3) retain pvcs when XSet is deleted or scaledIn according to RetainPvcWhenXSetDeleted and RetainPvcWhenXSetScaled.
+// (3) retain pvcs when XSet is deleted or scaledIn according to RetainPvcWhenXSetDeleted and RetainPvcWhenXSetScaled,
+// or PvcRetentionPolicyAdapter if implemented. Pvc templates are checked against targets of large XSets concurrently, so
+// the adapter, along with PvcTemplateChangePolicyAdapter, PvcSizeOverrideAdapter and PvcExpansionAdapter, must be safe
+// for concurrent use.
 type SubResourcePvcAdapter interface {
 	// RetainPvcWhenXSetDeleted returns true if pvc should be retained when XSet is deleted.
 	RetainPvcWhenXSetDeleted(object XSetObject) bool
@@ -104,9 +175,157 @@ type SubResourcePvcAdapter interface {
 	SetXSpecVolumes(object client.Object, pvcs []corev1.Volume)
 }
 
+// SubResourceConfigAdapter is used to manage per-instance ConfigMaps and Secrets for X, which are rendered from templates
+// declared on XSet. Values of ConfigMap data and Secret stringData are rendered as go templates with the instance ID and
+// context data, e.g., "{{ .ID }}" and "{{ .Data.Zone }}". Once adapter is implemented, XSetController creates them named
+// "<xset>-<template>-<id>" before X object is created, and deletes them after the instance ID is reclaimed. Secrets are
+// only listed and watched as metadata, so that no cluster-wide informer caches contents of Secrets.
+type SubResourceConfigAdapter interface {
+	// GetXSetConfigMapTemplates returns ConfigMap templates from XSet object.
+	GetXSetConfigMapTemplates(object XSetObject) []corev1.ConfigMap
+	// GetXSetSecretTemplates returns Secret templates from XSet object.
+	GetXSetSecretTemplates(object XSetObject) []corev1.Secret
+}
+
+// SubResourceAdapter is used to register arbitrary per-instance objects owned by XSet, e.g., certificates, service
+// accounts or CRs. Subresources are keyed by instance ID: created before target is created, adopted if left orphaned,
+// and deleted once the instance ID is reclaimed by scaling in. Subresources without name are named "<xset>-<kind>-<id>".
+type SubResourceAdapter interface {
+	// SubResourceMetas returns the type metas of subresources
+	SubResourceMetas() []metav1.TypeMeta
+	// NewSubResourceFrom returns the subresource of kind meta for the instance, nil means no subresource of this kind is needed
+	NewSubResourceFrom(object XSetObject, meta metav1.TypeMeta, contextDetail *ContextDetail) (client.Object, error)
+}
+
+// PvcRetentionPolicyAdapter is used along with SubResourcePvcAdapter to decide pvc retention by a StatefulSet-style
+// retention policy. If a non-nil policy is returned, it takes precedence over RetainPvcWhenXSetDeleted and RetainPvcWhenXSetScaled.
+type PvcRetentionPolicyAdapter interface {
+	// GetPvcRetentionPolicy returns the pvc retention policy of XSet.
+	GetPvcRetentionPolicy(object XSetObject) *PersistentVolumeClaimRetentionPolicy
+}
+
+// PvcSnapshotAdapter is used along with SubResourcePvcAdapter to create VolumeSnapshot of pvcs before they are deleted
+// by scaling in or replacing, which gives an undo path to recover data of deleted targets. It requires VolumeSnapshot
+// CRDs (snapshot.storage.k8s.io/v1) installed in cluster.
+type PvcSnapshotAdapter interface {
+	// GetPvcSnapshotPolicy returns the pvc snapshot policy of XSet, nil means no snapshot is created.
+	GetPvcSnapshotPolicy(object XSetObject) *PvcSnapshotPolicy
+}
+
+// PvcTemplateChangePolicyAdapter is used along with SubResourcePvcAdapter to decide what happens to existing pvcs when
+// pvc template changes. RecreateWithTarget is used if not implemented or empty policy returned.
+type PvcTemplateChangePolicyAdapter interface {
+	// GetPvcTemplateChangePolicy returns the pvc template change policy of XSet.
+	GetPvcTemplateChangePolicy(object XSetObject) PvcTemplateChangePolicyType
+}
+
+// PvcPreProvisionAdapter is used along with SubResourcePvcAdapter to wait for pvcs to be Bound before target is created,
+// so that slow provisioning storage does not leave targets Pending. Target is created anyway once timeout is exceeded,
+// and pvcs of StorageClass with WaitForFirstConsumer binding mode are not waited for.
+type PvcPreProvisionAdapter interface {
+	// GetPvcBoundTimeout returns how long to wait for pvcs to be Bound, zero means not to wait.
+	GetPvcBoundTimeout(object XSetObject) time.Duration
+}
+
+// StorageClassMigrationAdapter is used along with SubResourcePvcAdapter to migrate targets to the new StorageClass when
+// storageClassName of pvc template changes, regardless of PvcTemplateChangePolicyAdapter. Targets are recreated within
+// the budget of update strategy, with new pvcs re-provisioned or restored from VolumeSnapshots of old pvcs.
+type StorageClassMigrationAdapter interface {
+	// GetStorageClassMigration returns the StorageClass migration of XSet, nil means no migration.
+	GetStorageClassMigration(object XSetObject) *StorageClassMigration
+}
+
+// PvcSizeOverrideAdapter is used along with SubResourcePvcAdapter to override storage request of pvcs for specific
+// instance IDs, e.g., one large leader with small followers in a single XSet. Pvcs of an instance are labeled with hash
+// of the template sized for it, so changing the size of an instance expands its pvcs if PvcExpansionAdapter is enabled,
+// and is handled as a pvc template change of the instance otherwise.
+type PvcSizeOverrideAdapter interface {
+	// GetPvcSizeOverride returns storage request of pvc from template for instance id, nil means using the template's.
+	GetPvcSizeOverride(object XSetObject, id int, templateName string) *resource.Quantity
+}
+
+// ReplacePvcPolicyAdapter is used along with SubResourcePvcAdapter to decide whether the new target keeps pvcs of the
+// origin target or provisions fresh ones when replacing. With Reattach policy, the new target mounts the same volumes
+// while the origin target is still running, so volumes should be attachable by both, e.g., ReadWriteMany volumes, or
+// local volumes along with StickyNodeAdapter.
+type ReplacePvcPolicyAdapter interface {
+	// GetReplacePvcPolicy returns the replace pvc policy of XSet, empty means Reprovision.
+	GetReplacePvcPolicy(object XSetObject) ReplacePvcPolicyType
+}
+
+// PvcExpansionAdapter is used along with SubResourcePvcAdapter to expand existing pvcs in place when only the storage
+// request of pvc template grows, instead of recreating targets with new pvcs. It requires StorageClass of pvc to allow
+// volume expansion.
+type PvcExpansionAdapter interface {
+	// ExpandPvcOnTemplateChange returns true if pvcs should be expanded in place.
+	ExpandPvcOnTemplateChange(object XSetObject) bool
+}
+
+// FinalizerlessAdapter is used on platforms which forbid controller-managed finalizers on tenant CRs. Once adapter is
+// implemented and returns true, FinalizerName is never added to XSets, and cleanup after XSet deletion is best effort:
+// targets and subresources are deleted by garbage collection via ownerReferences, and IDs of the deleted XSet are
+// released from its ResourceContext when XSet is found not exist, and from ResourceContexts labelled by
+// ResourceContextOwnerKindLabelKey by a janitor every FullSyncInterval. See ValidateXSetController for trade-offs.
+type FinalizerlessAdapter interface {
+	// SkipXSetFinalizer returns true if XSet controller should not manage finalizer on XSets.
+	SkipXSetFinalizer() bool
+}
+
+// PriorityQueueAdapter is used to reconcile urgent XSets first when there are thousands of XSets. Once adapter is
+// implemented and returns true, XSets being created, deleted, with spec changed or resync requested are enqueued at
+// once, while periodic resyncs and status updates of steady-state XSets are paced at LowPriorityEnqueueQPS, so that
+// urgent XSets are not queued behind a flood of no-op reconciles.
+type PriorityQueueAdapter interface {
+	// UsePriorityQueue returns true if low priority events of XSets are paced before enqueued.
+	UsePriorityQueue() bool
+}
+
+// ExpectationAdapter is used to tune how XSet controller waits for informer cache to catch up with its own writes.
+// Unsatisfied expectations are dropped once timeout is exceeded, so that a lost event does not stall reconciling.
+type ExpectationAdapter interface {
+	// GetExpectationTimeout returns how long unsatisfied expectations are waited for, zero means the default.
+	GetExpectationTimeout() time.Duration
+	// GetExpectationRequeueDelay returns the delay to requeue when expectations are unsatisfied, zero means the default.
+	GetExpectationRequeueDelay() time.Duration
+}
+
+// RequeueJitterAdapter is used to tune jitter added to requeue durations, e.g., expectation requeue delay and update
+// intervals, so that a large number of XSets created at the same time do not reconcile in synchronized bursts.
+type RequeueJitterAdapter interface {
+	// GetRequeueJitterFactor returns the max factor of requeue duration added as jitter, non-positive value disables jitter.
+	GetRequeueJitterFactor() float64
+}
+
+// RateLimiterAdapter is used to customize rate limiting of XSet controller workqueue, since the default exponential
+// backoff may either hammer API server or delay convergence for different fleet sizes.
+type RateLimiterAdapter interface {
+	// GetRateLimiter returns the rate limiter of workqueue, e.g., workqueue.NewItemExponentialFailureRateLimiter with
+	// capped max delay. Nil means using the default one.
+	GetRateLimiter() workqueue.RateLimiter
+}
+
+// NamespaceScopeAdapter is used to run XSet controller per tenant namespace instead of cluster-wide. Once adapter is
+// implemented and returns namespaces, events of namespaced objects in other namespaces are dropped by all watches,
+// including the ones registered by adapters, and XSets in other namespaces are never reconciled. Since cache is owned
+// by manager, it must be built by xset.NewNamespacedCache, otherwise SetUpWithManager fails.
+type NamespaceScopeAdapter interface {
+	// GetWatchNamespaces returns namespaces XSet controller is restricted to, empty means cluster-wide.
+	GetWatchNamespaces() []string
+}
+
+// WatchProvider is used to register additional watches on XSet controller during SetUpWithManager, so that changes of
+// objects managed by adapters, e.g., subresources or hook providers, trigger reconciling of the related XSets. It can be
+// implemented by XSetController, and is also implemented by built-in subresource controls.
+type WatchProvider interface {
+	// RegisterWatches registers watches with event handlers mapping objects to XSets.
+	RegisterWatches(c controller.Controller) error
+}
+
 // DecorationAdapter is used to manage decoration for XSet. Decoration should be a workload to manage patcher on X target.
 // Once adapter is implemented, XSetController will (1) watch for decoration change, (2) patch effective decorations on
-// X target when creating, (3) manage decoration update when decoration changed.
+// X target when creating, (3) manage decoration update when decoration changed. Revisions of targets are resolved
+// concurrently for large XSets, so GetTargetCurrentDecorationRevisions, GetTargetUpdatedDecorationRevisions and
+// IsTargetDecorationChanged must be safe for concurrent use.
 type DecorationAdapter interface {
 	// WatchDecoration allows controller to watch decoration change.
 	WatchDecoration(c controller.Controller) error
@@ -121,3 +340,598 @@ type DecorationAdapter interface {
 	// IsTargetDecorationChanged returns true if decoration on target is changed.
 	IsTargetDecorationChanged(currentRevision, updatedRevision string) (bool, error)
 }
+
+// MultiDecorationAdapter is used to compose several decoration kinds for XSet, e.g., sidecar and config decorators.
+// Each DecorationAdapter has its own watch, patcher and ownerReference reclaim on deletion, and their revisions are
+// recorded on target together. Once implemented, it takes precedence over DecorationAdapter of XSetController.
+type MultiDecorationAdapter interface {
+	// GetDecorationAdapters returns adapters of all decoration kinds, in the order their patchers are applied.
+	GetDecorationAdapters() []DecorationAdapter
+}
+
+// TargetAdoptionAdapter is used to enable ReplicaSet-style adoption. Once adapter is implemented and returns true,
+// XSetController will adopt targets which match XSet selector but have no controller, and assign instance IDs to them.
+// Targets orphaned by XSet (excluded) are never adopted automatically.
+type TargetAdoptionAdapter interface {
+	// AdoptOrphanedTargets returns true if orphaned targets matching selector should be adopted by XSet.
+	AdoptOrphanedTargets(object XSetObject) bool
+}
+
+// TargetNamingAdapter is used to name targets deterministically, e.g., <xset>-<instanceID>, to enable stable identities
+// similar to StatefulSet. Once adapter is implemented and names targets of an XSet, targets of the XSet with the same
+// name are not allowed to exist at the same time, same as TargetNamingSuffixPolicyPersistentSequence.
+type TargetNamingAdapter interface {
+	// GetTargetName returns the name of target with instance ID. Empty name falls back to NamingStrategy.
+	GetTargetName(object XSetObject, id int) string
+}
+
+// InstanceIDLookup looks up instance ID from target, and returns false if not found.
+type InstanceIDLookup func(target client.Object) (int, bool)
+
+// InstanceIDAdapter is used to resolve instance ID for targets without instance ID label, e.g., pre-existing objects
+// to adopt which encode their ordinal differently. Resolved ID is persisted as instance ID label on target.
+type InstanceIDAdapter interface {
+	// GetInstanceIDLookups returns lookups tried in order when instance ID label is missing.
+	GetInstanceIDLookups(object XSetObject) []InstanceIDLookup
+}
+
+// AvailabilityAdapter is used to define availability beyond readiness, e.g., serving traffic for a while or a custom
+// status condition. Once adapter is implemented, it takes the place of CheckAvailable when calculating availableReplicas
+// and deciding whether targets are available during update, replace and scale in.
+type AvailabilityAdapter interface {
+	// CheckTargetAvailable returns whether target is available. If target is expected to become available after a
+	// while, e.g., warming up, recheckAfter is returned to requeue XSet. spec.minReadySeconds is applied on top of it.
+	CheckTargetAvailable(object client.Object) (available bool, recheckAfter *time.Duration)
+}
+
+// TargetListChunkAdapter is used to list targets in chunks for XSets with a large number of targets. Targets are
+// normally listed from cache by owner index; once adapter is implemented and returns a positive size, resyncs that
+// list targets in namespace of XSet from api server go page by page with continue tokens, and only targets owned by
+// XSet are kept out of each page.
+type TargetListChunkAdapter interface {
+	// GetTargetListChunkSize returns the max number of targets in one List call, zero means listing without chunks.
+	GetTargetListChunkSize(object XSetObject) int64
+}
+
+// DeletionConcurrencyAdapter is used to bound the number of targets deleted in parallel when scaling in or deleting XSet.
+type DeletionConcurrencyAdapter interface {
+	// GetMaxConcurrentDeletions returns the max number of concurrent deletions, non-positive value means the default.
+	GetMaxConcurrentDeletions() int
+}
+
+// DryRunCreateAdapter is used to issue a dry-run creation before creating targets, so that Invalid/Forbidden
+// errors are caught before PVCs are created for them. Scaling out dry-runs a target of updated revision before
+// allocating IDs, so that such errors neither consume IDs nor fail their contexts.
+type DryRunCreateAdapter interface {
+	// DryRunCreateTargets returns true if target creation should be dry-run first
+	DryRunCreateTargets(object XSetObject) bool
+}
+
+// TargetDeletionPolicyAdapter is used to decide whether targets are deleted or orphaned when XSet is deleted, e.g.,
+// from a spec field or an annotation. Deleting XSet with orphan propagation policy always orphans targets.
+type TargetDeletionPolicyAdapter interface {
+	// GetTargetDeletionPolicy returns the target deletion policy of XSet, empty means Delete.
+	GetTargetDeletionPolicy(object XSetObject) TargetDeletionPolicyType
+}
+
+// TargetProtectionFinalizerAdapter is used to add a protection finalizer on created targets, so that target deletion
+// is held until XSet controller acknowledges it and removes the finalizer.
+type TargetProtectionFinalizerAdapter interface {
+	// GetTargetProtectionFinalizer returns the finalizer to protect targets, empty means no protection.
+	GetTargetProtectionFinalizer(object XSetObject) string
+}
+
+// CompanionAdapter is used to manage companion objects of other kinds along with targets, e.g., a companion CR per Pod.
+// Companion objects are keyed by the instance ID of target, created when target exists and deleted when no target
+// with the same instance ID exists.
+type CompanionAdapter interface {
+	// CompanionMetas returns the type metas of companion objects
+	CompanionMetas() []metav1.TypeMeta
+	// NewCompanionFrom returns the companion object of kind meta for target, nil means no companion of this kind is needed
+	NewCompanionFrom(object XSetObject, meta metav1.TypeMeta, target client.Object, id int) (client.Object, error)
+}
+
+// TemplateDefaulter is used to inject computed fields, e.g., resource defaults or tenant labels, into the rendered
+// target before it is created, without a separate mutating webhook for the target kind.
+type TemplateDefaulter interface {
+	// DefaultTarget mutates the rendered target in place
+	DefaultTarget(object XSetObject, target client.Object) error
+}
+
+// StickyNodeAdapter is used to record the node a Pod target landed on in its ContextDetail, and inject node affinity
+// to the recorded node when the target is recreated, e.g., for workloads using local PVs.
+type StickyNodeAdapter interface {
+	// StickToRecordedNode returns true if targets should be recreated on the recorded node
+	StickToRecordedNode(object XSetObject) bool
+}
+
+// ZonePlacementAdapter is used to place instance IDs evenly across zones. The zone of each ID is recorded in
+// its ContextDetail, and injected into Pod targets as required node affinity, merged with the affinity of template.
+type ZonePlacementAdapter interface {
+	// GetZonePlacement returns the node label key of zone and the zones to place targets in.
+	// Empty topologyKey defaults to topology.kubernetes.io/zone, and empty zones disables zone placement.
+	GetZonePlacement(object XSetObject) (topologyKey string, zones []string)
+}
+
+// TerminatingTargetsAdapter is used to exclude terminating targets from scaling and updating, so that replica
+// count does not oscillate while many targets are slowly terminating.
+type TerminatingTargetsAdapter interface {
+	// ExcludeTerminatingTargets returns true if targets with deletion timestamp are excluded from scaling and updating
+	ExcludeTerminatingTargets(object XSetObject) bool
+}
+
+// InstanceStatusesAdapter is used to report the observed state of each target in status.instanceStatuses, so that
+// clients built on XSet do not need to join targets, labels and ResourceContexts by themselves.
+type InstanceStatusesAdapter interface {
+	// EnableInstanceStatuses returns true if status.instanceStatuses is reported
+	EnableInstanceStatuses(object XSetObject) bool
+	// GetMaxInstanceStatuses returns the max size of status.instanceStatuses, non-positive value means the default.
+	GetMaxInstanceStatuses(object XSetObject) int
+}
+
+// ReadinessGateAdapter is used to inject readinessGates into Pod targets at creation, so that external controllers,
+// e.g., traffic controllers, participate in rollout gating by setting the conditions. Once adapter is implemented,
+// Pod targets are only available when all their readinessGate conditions are True, in addition to CheckAvailable or
+// AvailabilityAdapter.
+type ReadinessGateAdapter interface {
+	// GetReadinessGates returns condition types injected as readinessGates, e.g., PodServiceReadyReadinessGate
+	GetReadinessGates(object XSetObject) []corev1.PodConditionType
+}
+
+// PreCreateHookAdapter is used to mutate the fully rendered target with the ContextDetail of its instance ID right
+// before it is created, for per-instance mutations driven by allocation state, e.g., hostname, env with ordinal or
+// shard assignment, rather than static templates.
+type PreCreateHookAdapter interface {
+	// PreCreate mutates target in place. If error is returned, target is not created and creation is retried.
+	PreCreate(ctx context.Context, object XSetObject, target client.Object, contextDetail *ContextDetail) error
+}
+
+// PostCreateHookAdapter is used to register instances in external systems, e.g., service discovery or license
+// servers, once targets exist. Hook is called in background out of the reconcile path for each new target, and
+// results are recorded in ContextDetail and counted by XSetStatus.PostCreatePendingReplicas. Failures are retried
+// with exponential backoff and surfaced as events, without blocking the sync.
+type PostCreateHookAdapter interface {
+	// PostCreate is called once for each target created for the instance ID, until it succeeds. Arguments are copies
+	// which may be read concurrently with reconciles, and changes on them are discarded.
+	PostCreate(ctx context.Context, object XSetObject, target client.Object, contextDetail *ContextDetail) error
+}
+
+// PostDeleteHookAdapter is used to deregister instances and clean external state once targets are fully deleted.
+// Hook is called in background after the target is gone from api server and before its instance ID is reclaimed or
+// reused, and the ID is held until hook succeeds. Failures are retried with exponential backoff tracked in
+// ContextDetail.
+type PostDeleteHookAdapter interface {
+	// PostDelete is called once for each deleted target of the instance ID, until it succeeds. Arguments are copies
+	// which may be read concurrently with reconciles, and changes on them are discarded.
+	PostDelete(ctx context.Context, object XSetObject, targetUID types.UID, contextDetail *ContextDetail) error
+}
+
+// DeletionApprovalAdapter is used to integrate change control into the delete path. Before deleting a target for
+// scaling in, replacing or recreate update, XSet sets XPendingDeletionLabelKey on it and waits until an external
+// approver sets TargetDeletionApprovedAnnotationKey to "true", or timeout expires. The label is removed once the
+// deletion is canceled.
+type DeletionApprovalAdapter interface {
+	// GetDeletionApproval returns whether deletion requires approval, and timeout after which deletion is approved
+	// automatically. Non-positive timeout means waiting for approval indefinitely.
+	GetDeletionApproval(object XSetObject) (required bool, timeout time.Duration)
+}
+
+// TemplatePatchType is the type of template patch
+type TemplatePatchType string
+
+const (
+	// JSONPatchType is RFC 6902 JSON Patch
+	JSONPatchType TemplatePatchType = "JSONPatch"
+	// MergePatchType is RFC 7386 JSON Merge Patch
+	MergePatchType TemplatePatchType = "MergePatch"
+	// StrategicMergePatchType is Kubernetes strategic merge patch, which is only supported for typed targets
+	StrategicMergePatchType TemplatePatchType = "StrategicMergePatch"
+)
+
+// TemplatePatch is a patch applied to targets, named to record whether it has been applied
+type TemplatePatch struct {
+	Name  string
+	Type  TemplatePatchType
+	Patch []byte
+	// InstanceIDs selects instances the patch is applied to, as comma separated IDs or ID ranges, e.g., "0" or
+	// "0,3-5". Empty selects all instances.
+	InstanceIDs string
+}
+
+// TemplatePatchAdapter is used to patch targets with typed patches, in addition to GetXSetTemplatePatcher. Patches
+// are applied in order on creation and on existing targets, and recorded in TargetAppliedPatchesAnnotationKey, so
+// that non-idempotent patches, e.g., JSON Patch appending to a list, are not applied again until they are changed.
+// Patches with InstanceIDs only apply to selected instances, e.g., to give ID 0 more memory or pin ID 3 to a node
+// pool, and are expected to follow patches for all instances.
+type TemplatePatchAdapter interface {
+	// GetTemplatePatches returns patches applied to targets of XSet
+	GetTemplatePatches(object XSetObject) []TemplatePatch
+}
+
+// ResourceContextWriteDebounceAdapter is used to coalesce ResourceContext writes of XSet, e.g., when HPA flaps, so that
+// the context shared by pool co-owners is not rewritten dozens of times per minute. Writes which only release IDs are
+// deferred until window since the last write expires, while IDs are allocated and context data is changed at once.
+// Released IDs are held by XSet until written, and are released again by the next sync after controller restarts.
+type ResourceContextWriteDebounceAdapter interface {
+	// GetResourceContextWriteDebounceWindow returns the min interval between writes only releasing IDs, 0 disables it
+	GetResourceContextWriteDebounceWindow(object XSetObject) time.Duration
+}
+
+// CacheTransformAdapter is used to trim targets and pvcs kept in informer cache to cut controller memory on large
+// fleets. Only managedFields are trimmed, which API server keeps when cached objects are updated without them. It
+// requires informers supporting SetTransform, i.e., client-go v0.24+, otherwise SetUpWithManager fails.
+type CacheTransformAdapter interface {
+	// StripManagedFields returns true to drop managedFields of cached targets and pvcs
+	StripManagedFields() bool
+}
+
+// SyncSkipAdapter is used to opt in skipping sync of XSets which are fully rolled out and whose targets are steady, so
+// that reconciles triggered by status changes of targets only recalculate status. XSets are still fully synced
+// periodically. Controllers opting in assert that none of their adapters acts on changes of targets other than those
+// reflected in XSet generation, sync indication labels of targets or status, while adapters known to do so, e.g.,
+// StickyNodeAdapter, disable skipping regardless.
+type SyncSkipAdapter interface {
+	// EnableSyncSkip returns true if sync of XSet is skipped while nothing is to be synced
+	EnableSyncSkip(object XSetObject) bool
+}
+
+// AnalysisVerdict is the verdict of analysis on targets of updated revision
+type AnalysisVerdict string
+
+const (
+	// AnalysisVerdictProceed lets rollout proceed to update more targets
+	AnalysisVerdictProceed AnalysisVerdict = "Proceed"
+	// AnalysisVerdictPause holds rollout, targets not yet updating are kept in their revisions
+	AnalysisVerdictPause AnalysisVerdict = "Pause"
+	// AnalysisVerdictRollback aborts updated revision, and rolls back updated targets to current revision
+	AnalysisVerdictRollback AnalysisVerdict = "Rollback"
+)
+
+// AnalysisResult is the result of analysis on targets of updated revision
+type AnalysisResult struct {
+	Verdict AnalysisVerdict
+	// Message is reported in RolloutAnalysis condition
+	Message string
+	// RequeueAfter is the duration after which analysis is done again if rollout is paused, 0 means no requeue
+	RequeueAfter time.Duration
+}
+
+// RolloutAnalysisAdapter is used to gate rollout steps by analysis, e.g., on metrics of canary targets, like
+// Argo Rollouts without depending on it. Once targets of updated revision exist, e.g., the canary step of partition,
+// Analyze is called before more targets are updated, and its verdict decides whether rollout proceeds, pauses, or
+// rolls back. Adapter is called on each reconcile during rollout, so that it is expected to cache running analysis.
+type RolloutAnalysisAdapter interface {
+	// Analyze returns the verdict on targets of updated revision. If error is returned, rollout is paused.
+	Analyze(ctx context.Context, object XSetObject, updatedRevision *appsv1.ControllerRevision, updatedTargets []client.Object) (*AnalysisResult, error)
+}
+
+// HeadlessService describes the governing headless Service of XSet
+type HeadlessService struct {
+	// Name is the name of Service, which defaults to the name of XSet
+	Name string
+	// Ports are the ports of Service
+	Ports []corev1.ServicePort
+	// PublishNotReadyAddresses publishes DNS records of Pods before they are ready, e.g., for peer discovery
+	PublishNotReadyAddresses bool
+}
+
+// HeadlessServiceAdapter is used to manage a governing headless Service of XSet with Pod targets, and set hostname and
+// subdomain of Pods from their instance IDs, so that Pods get stable DNS names like StatefulSet, in form of
+// <xset>-<id>.<service>.<namespace>.svc.<cluster-domain>. Service selects Pods by matchLabels of XSet selector, and is
+// deleted along with XSet by garbage collection. Services are read from informer cache and watched for changes of the
+// ones controlled by XSets, which requires permissions to list and watch services in namespaces of NamespaceScopeAdapter,
+// or cluster-wide if not implemented.
+type HeadlessServiceAdapter interface {
+	// GetHeadlessService returns the governing headless Service of XSet, nil disables it
+	GetHeadlessService(object XSetObject) *HeadlessService
+}
+
+// InPlaceResizeAdapter is used to resize cpu and memory of containers of Pod targets in place on clusters with
+// InPlacePodVerticalScaling enabled, instead of recreating them, when only these resources are changed in template and
+// QoS class is kept. Resize is applied by the resize subresource of Pod, or Pod spec on clusters before it, and
+// kubelet restarts containers as their resizePolicy requires. Targets are updated by the update policy of XSet if
+// disabled, and recreated if resize is rejected by api server, e.g., the feature gate is off, after which other targets
+// of XSet are not resized for a while.
+// It takes no effect on Recreate and Replace update policies.
+type InPlaceResizeAdapter interface {
+	// EnableInPlaceResize returns true if InPlacePodVerticalScaling is enabled on the cluster
+	EnableInPlaceResize(object XSetObject) bool
+}
+
+// PreemptionAwareAdapter is used to prefer Pod targets on nodes going away soon, e.g., with spot interruption taints
+// or marked as scale-down candidates by cluster-autoscaler, as victims of scaling in and as the first targets to
+// update, so that voluntary disruptions are absorbed by targets which were going away anyway. Nodes of targets are
+// read from informer cache, which requires permissions to list and watch nodes.
+type PreemptionAwareAdapter interface {
+	// GetPreemptionTaintKeys returns keys of taints marking nodes going away, empty means DefaultPreemptionTaintKeys
+	GetPreemptionTaintKeys(object XSetObject) []string
+}
+
+// EvictionFallbackPolicyType decides what happens when eviction of Pod target is persistently blocked
+type EvictionFallbackPolicyType string
+
+const (
+	// EvictionFallbackRetry keeps retrying eviction until it is allowed
+	EvictionFallbackRetry EvictionFallbackPolicyType = "Retry"
+	// EvictionFallbackDelete deletes Pod target directly once eviction has been blocked for fallback timeout
+	EvictionFallbackDelete EvictionFallbackPolicyType = "Delete"
+)
+
+// PodEviction describes how Pod targets are evicted
+type PodEviction struct {
+	// FallbackPolicy decides what happens when eviction is persistently blocked, empty means Retry
+	FallbackPolicy EvictionFallbackPolicyType
+	// FallbackTimeout is how long eviction is blocked before falling back, non-positive value means the default
+	FallbackTimeout time.Duration
+}
+
+// PodEvictionAdapter is used to delete Pod targets by the eviction subresource instead of direct deletion when scaling
+// in and updating by recreate, so that PodDisruptionBudgets are respected. Blocked evictions are retried by requeue,
+// and recorded by TargetEvictionBlockedAnnotationKey on targets to fall back as PodEviction requires.
+type PodEvictionAdapter interface {
+	// GetPodEviction returns how Pod targets of XSet are evicted, nil means deleting them directly
+	GetPodEviction(object XSetObject) *PodEviction
+}
+
+// DeschedulingReplace describes how eviction requests on targets are translated into replace
+type DeschedulingReplace struct {
+	// AnnotationKeys are annotations on targets requesting eviction, empty means DefaultEvictionRequestAnnotationKeys
+	AnnotationKeys []string
+	// MaxReplacing is the max number of targets being replaced at the same time, non-positive value means 1
+	MaxReplacing int
+}
+
+// DeschedulingReplaceAdapter is used to cooperate with descheduler or remediation controllers, which request eviction
+// of targets by annotations, e.g., descheduler with evictions in background. Targets requested are replaced by XSet
+// with the replace indication label instead, so that new targets are available before origin ones are deleted, and at
+// most MaxReplacing targets are replaced at the same time, including those replaced for other reasons. Evictions out
+// of band are expected to be held, e.g., by PodDisruptionBudgets or evict-only mode of descheduler.
+type DeschedulingReplaceAdapter interface {
+	// GetDeschedulingReplace returns how eviction requests on targets of XSet are replaced, nil disables it
+	GetDeschedulingReplace(object XSetObject) *DeschedulingReplace
+}
+
+// StatelessAdapter is used by controllers whose targets need no stable IDs, e.g., purely stateless fleets. Once adapter
+// is implemented and returns true, ResourceContext is never created for XSets, and instance IDs, revisions and replace
+// pairs are derived from labels of targets only, which saves an API object and its writes per XSet. Context pool set by
+// spec.scaleStrategy.context is not supported, and data recorded only in contexts, e.g., PostDelete hook progress, is
+// lost across controller restarts.
+type StatelessAdapter interface {
+	// SkipResourceContext returns true if XSet controller should not manage ResourceContext for XSets.
+	SkipResourceContext() bool
+}
+
+// RevisionDataAdapter is used to customize data recorded in ControllerRevisions of XSet, from which revision hash is
+// computed and revisions are compared, so that semantically irrelevant template churn does not lead to a new revision
+// and a rollout, e.g., by excluding annotations from template, or does lead to one, e.g., by including versions of
+// external configs. Data is still decoded by GetXObjectFromRevision, so fields excluded from data are expected to be
+// applied to targets by GetXSetTemplatePatcher, and fields included are expected to be ignored by decoding. Changing
+// data of unchanged XSets leads to a new revision and a rollout once controller is upgraded.
+type RevisionDataAdapter interface {
+	// GetRevisionData returns data recorded in revision of XSet, given patch returned by GetXSetPatch
+	GetRevisionData(object XSetObject, patch []byte) ([]byte, error)
+}
+
+// Subset is a named group of instances of XSet, e.g., in a zone or a node pool
+type Subset struct {
+	Name string
+	// Replicas is the number of instances in subset, either absolute or a percentage of spec.replicas rounded down.
+	// Subsets without replicas evenly share the replicas left by others.
+	Replicas *intstr.IntOrString
+	// Patches are applied to targets in subset after patches of TemplatePatchAdapter
+	Patches []TemplatePatch
+}
+
+// SubsetAdapter is used to split instances of XSet into subsets with their own share of replicas and template patches,
+// e.g., to run a fleet across zones or node pools under a single XSet. The subset of each ID is recorded in its
+// ContextDetail, so that IDs are partitioned per subset, and on targets by TargetSubsetLabelKey. IDs allocated for
+// scaling out are assigned to subsets furthest below their replicas, and targets in subsets furthest above theirs are
+// scaled in first, while existing targets are not moved across subsets when shares change.
+type SubsetAdapter interface {
+	// GetSubsets returns subsets of XSet in order, empty subsets disables it
+	GetSubsets(object XSetObject) []Subset
+}
+
+// CreationPriorityAdapter is used to create targets of important instances first, e.g., leaders or a subset serving
+// most traffic, both during initial scale-out and recovery after mass failure. Among IDs without targets, those of
+// higher priority are chosen first, and each sync only creates targets of the highest priority among IDs to create,
+// so that instances of lower priority are created by following syncs and never consume quota before them.
+type CreationPriorityAdapter interface {
+	// GetCreationPriority returns priority of creating target of instance ID, which belongs to subset if SubsetAdapter
+	// is implemented. Higher priority is created first, and IDs of the same priority are created in order.
+	GetCreationPriority(object XSetObject, id int, subset string) int32
+}
+
+// FieldManagerAdapter is used to attribute writes of XSet controller, e.g., on platforms running several controllers
+// over the same objects, so that managedFields record which controller changed a field and server-side apply conflict
+// rules can be written around them. The field manager is set on every create, update and patch of targets, PVCs,
+// subresources, ResourceContexts and XSets, unless the write sets its own one.
+type FieldManagerAdapter interface {
+	// GetFieldManager returns field manager of writes, empty means the default one derived from user agent
+	GetFieldManager() string
+}
+
+// DriftRemediation is what XSet controller does to targets drifted from the template of their revision
+type DriftRemediation string
+
+const (
+	// DriftRemediationReport only reports drifted targets by events and DriftDetected condition
+	DriftRemediationReport DriftRemediation = "Report"
+	// DriftRemediationPatchBack patches drifted fields of targets back to the template
+	DriftRemediationPatchBack DriftRemediation = "PatchBack"
+	// DriftRemediationRecreate deletes drifted targets, which are then created from the template again
+	DriftRemediationRecreate DriftRemediation = "Recreate"
+)
+
+// DriftDetectionPolicy is how targets of XSet are checked and remediated for drifts
+type DriftDetectionPolicy struct {
+	// IgnorePaths are JSONPaths of fields not regarded as drifted, e.g., those set by injectors, like
+	// ".spec.containers[*].env" or ".metadata.annotations['sidecar.istio.io/status']". A path ignores fields under it
+	// as well, and "[*]" matches any index of list or key of map.
+	IgnorePaths []string
+	// Remediation defaults to DriftRemediationReport
+	Remediation DriftRemediation
+	// MaxUnavailable is the max number of targets unavailable, including those missing, for drifted targets to be
+	// recreated by DriftRemediationRecreate, non-positive value means 1. Drifted targets not available are never
+	// recreated, since recreating them does not restore availability.
+	MaxUnavailable int
+}
+
+// DriftDetectionAdapter is used to detect out-of-band mutations of targets, e.g., by kubectl edit, against the template
+// rendered from their revision with template patches applied. Only labels, annotations and fields rendered in template
+// are compared, so that fields defaulted by api server or added by others are not drifts. Targets being updated, scaled
+// in or replaced are not checked, and sync is never skipped as long as drift detection is enabled. Targets are checked
+// periodically rather than on every reconcile, unless XSet changes or drifted targets are being remediated.
+type DriftDetectionAdapter interface {
+	// GetDriftDetectionPolicy returns policy of XSet, nil disables drift detection
+	GetDriftDetectionPolicy(object XSetObject) *DriftDetectionPolicy
+}
+
+// RevisionHashExclusionAdapter is used to exclude labels and annotations of template from revisions, e.g., those
+// irrelevant to rollout and injected by tooling, so that touching them neither creates a revision nor starts a rollout.
+// Keys are removed from metadata of template at spec.template of patch returned by GetXSetPatch, before data is
+// customized by RevisionDataAdapter. Targets are created with values of excluded keys in the current template instead of
+// those in revision, and existing targets are not updated when they change, nor are they drifts for DriftDetectionAdapter.
+type RevisionHashExclusionAdapter interface {
+	// GetRevisionHashExcludedKeys returns keys of labels and annotations of template to exclude, in which a key ending
+	// with "*" excludes all keys with the prefix, e.g., "argocd.argoproj.io/*"
+	GetRevisionHashExcludedKeys(object XSetObject) []string
+}
+
+// TemplateRefAdapter is used to enable spec.templateRef of XSet, by returning kinds of objects allowed to be referenced
+// as template, e.g., ConfigMap or a template CR. Objects of these kinds are watched and cached, and XSets referencing
+// one are reconciled once it changes. Referenced template replaces spec.template in patch returned by GetXSetPatch,
+// so that it is recorded in revisions and decoded by GetXObjectFromRevision as an inlined one.
+type TemplateRefAdapter interface {
+	// GetTemplateRefKinds returns kinds allowed to be referenced as template
+	GetTemplateRefKinds() []metav1.TypeMeta
+}
+
+// WarningEventPolicy is the threshold of Warning events from targets of updated revision to pause rollout
+type WarningEventPolicy struct {
+	// Threshold is the number of Warning events within Window to pause rollout, counting repeated occurrences, which
+	// are prorated by time for events first seen before Window
+	Threshold int32
+	// Window is the duration before now in which events are last seen to be counted
+	Window time.Duration
+	// Reasons are the reasons of events to count, e.g., BackOff and Failed, empty means all reasons
+	Reasons []string
+}
+
+// WarningEventPauseAdapter is used as a safety valve of rollout, which pauses it once targets of updated revision emit
+// Warning events, e.g., crash loops or image pull failures, beyond threshold within window. Events are listed from
+// api server rather than cache while targets are pending to update. Rollout is kept paused with RolloutAutoPaused
+// condition until resumed by XSetResumeRolloutAnnotationKey or template changes.
+type WarningEventPauseAdapter interface {
+	// GetWarningEventPolicy returns the policy to pause rollout, nil disables pausing
+	GetWarningEventPolicy(object XSetObject) *WarningEventPolicy
+}
+
+// CrashLoopRemediation is what XSet controller does to targets which repeatedly fail
+type CrashLoopRemediation string
+
+const (
+	// CrashLoopRemediationRecreate deletes failing targets, which are then created with the same instance ID again
+	CrashLoopRemediationRecreate CrashLoopRemediation = "Recreate"
+	// CrashLoopRemediationReplace replaces failing targets by new ones, which are created before they are deleted
+	CrashLoopRemediationReplace CrashLoopRemediation = "Replace"
+	// CrashLoopRemediationQuarantine labels failing targets with XQuarantinedLabelKey and keeps them for debugging,
+	// while replacements are created in their place
+	CrashLoopRemediationQuarantine CrashLoopRemediation = "Quarantine"
+)
+
+// CrashLoopRemediationPolicy is how targets which repeatedly fail are remediated
+type CrashLoopRemediationPolicy struct {
+	// FailureThreshold is the number of failures, e.g., restarts of containers, to remediate target, and targets in
+	// failed phase are remediated regardless of it. Defaults to 1 if not positive.
+	FailureThreshold int32
+	// Remediation defaults to CrashLoopRemediationRecreate
+	Remediation CrashLoopRemediation
+	// MaxReplacing is the max number of targets starting at the same time, i.e., not available and not failing, for
+	// failing targets to be replaced or quarantined, non-positive value means 1. Replacements of targets remediated
+	// before and targets replaced for other reasons are counted as well, so that a revision failing everywhere is not
+	// replaced at once.
+	MaxReplacing int
+}
+
+// CrashLoopRemediationAdapter is used to remediate targets which repeatedly fail, e.g., Pods in CrashLoopBackOff or
+// Failed phase. Remediations of an instance ID are backed off exponentially and recorded in status.remediations until
+// revision changes, and recreating targets is held out of maintenance windows like other disruptive actions. Targets
+// being updated, scaled in or replaced are not remediated.
+type CrashLoopRemediationAdapter interface {
+	// GetCrashLoopRemediationPolicy returns policy of XSet, nil disables remediation
+	GetCrashLoopRemediationPolicy(object XSetObject) *CrashLoopRemediationPolicy
+	// GetTargetFailures returns the number of failures of target, e.g., sum of restart counts of containers, and
+	// whether target is in failed phase
+	GetTargetFailures(target client.Object) (failures int32, failed bool)
+}
+
+// QuarantineAdapter is used to take targets quarantined by XQuarantinedLabelKey out of service, so that operators keep
+// a misbehaving target alive for debugging while it serves no traffic. Targets are patched once they are quarantined,
+// and are not brought back to service if the label is removed.
+type QuarantineAdapter interface {
+	// QuarantineTarget mutates target to take it out of service, e.g., by removing labels selected by Services or
+	// setting PreparingDeleteLabel for traffic controllers, and returns false if target is already out of service
+	QuarantineTarget(target client.Object) bool
+}
+
+// StuckTerminatingIDPolicy is what happens to instance IDs of targets stuck in terminating
+type StuckTerminatingIDPolicy string
+
+const (
+	// StuckTerminatingRetainID keeps instance ID reserved by stuck target until it is gone, and its replacement is
+	// created with another ID
+	StuckTerminatingRetainID StuckTerminatingIDPolicy = "Retain"
+	// StuckTerminatingReleaseID releases instance ID of stuck target to its replacement, e.g., to keep ID-bound PVCs.
+	// With deterministic naming, the replacement is named after the ID and created only once stuck target is gone.
+	// ID is released only once stuck target is force deleted, i.e., with ForceDelete, and is retained until then,
+	// since processes of stuck target may still be writing to ReadWriteOnce volumes bound to the ID.
+	StuckTerminatingReleaseID StuckTerminatingIDPolicy = "Release"
+)
+
+// StuckTerminatingPolicy is how targets stuck in terminating are handled
+type StuckTerminatingPolicy struct {
+	// Threshold is the duration after deletionTimestamp, i.e., the end of grace period, beyond which target is stuck.
+	// Defaults to DefaultStuckTerminatingThreshold if not positive.
+	Threshold time.Duration
+	// ForceDelete deletes stuck targets again with grace period 0, which operators opt in once it is safe, e.g.,
+	// nodes of targets are confirmed gone, since processes may still be running on partitioned nodes
+	ForceDelete bool
+	// IDPolicy defaults to StuckTerminatingRetainID, and StuckTerminatingReleaseID takes effect with ForceDelete only
+	IDPolicy StuckTerminatingIDPolicy
+}
+
+// DefaultStuckTerminatingThreshold is the default threshold of StuckTerminatingPolicy
+const DefaultStuckTerminatingThreshold = 10 * time.Minute
+
+// StuckTerminatingAdapter is used to handle targets stuck in terminating, e.g., Pods on nodes gone whose kubelet never
+// confirms deletion. Stuck targets are reported in TargetsStuckTerminating condition, and are counted in neither
+// replicas nor scaling and updating, so that replacements are created and rollout is not blocked indefinitely.
+type StuckTerminatingAdapter interface {
+	// GetStuckTerminatingPolicy returns policy of XSet, nil disables handling of stuck targets
+	GetStuckTerminatingPolicy(object XSetObject) *StuckTerminatingPolicy
+}
+
+// DefaultNodeFailureTolerationPeriod is the default toleration period of NodeFailureReplace
+const DefaultNodeFailureTolerationPeriod = 5 * time.Minute
+
+// NodeFailureReplace describes how targets on failed nodes are replaced
+type NodeFailureReplace struct {
+	// TolerationPeriod is how long node stays NotReady or unreachable before its targets are replaced, so that short
+	// blips of kubelet do not churn targets. Defaults to DefaultNodeFailureTolerationPeriod if not positive.
+	TolerationPeriod time.Duration
+	// MaxReplacing is the max number of targets being replaced at the same time, non-positive value means 1
+	MaxReplacing int
+}
+
+// NodeFailureReplaceAdapter is used to replace Pod targets bound to nodes which are NotReady or unreachable beyond
+// toleration period, e.g., on-prem clusters without node auto-repair. Nodes are watched, and XSets are reconciled once
+// readiness of nodes of their targets changes. Note that it caches all nodes of the cluster in the manager, which
+// requires permissions to list and watch nodes. Targets are replaced with the replace indication label, at most
+// MaxReplacing at the same time including those replaced for other reasons, and targets still ready are replaced only
+// if PodDisruptionBudgets selecting them allow disruptions. PodDisruptionBudgets are read from API server without
+// cache when needed, which requires permission to list them.
+type NodeFailureReplaceAdapter interface {
+	// GetNodeFailureReplace returns how targets on failed nodes are replaced, nil disables it
+	GetNodeFailureReplace(object XSetObject) *NodeFailureReplace
+}
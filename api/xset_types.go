@@ -20,6 +20,7 @@ package api
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type XSetConditionType string
@@ -28,6 +29,37 @@ const (
 	XSetScale       XSetConditionType = "Scale"
 	XSetUpdate      XSetConditionType = "Update"
 	XSetTerminating XSetConditionType = "Terminating"
+	// XSetPoolExhausted indicates that the context pool reaches its capacity and no more ID can be allocated
+	XSetPoolExhausted XSetConditionType = "PoolExhausted"
+	// XSetPvcExpansion indicates that pvcs are being expanded to the storage request of pvc template
+	XSetPvcExpansion XSetConditionType = "PvcExpansion"
+	// XSetPvcDeletionBlocked indicates that deletion of pvcs is blocked by third-party finalizers
+	XSetPvcDeletionBlocked XSetConditionType = "PvcDeletionBlocked"
+	// XSetVolumesReady indicates that all pvcs of XSet are Bound
+	XSetVolumesReady XSetConditionType = "VolumesReady"
+	// XSetAvailable indicates that XSet has at least minAvailable replicas available for minReadySeconds
+	XSetAvailable XSetConditionType = "Available"
+	// XSetProgressing indicates that XSet is scaling or rolling out targets to updated revision, or has completed
+	XSetProgressing XSetConditionType = "Progressing"
+	// XSetReplicaFailure indicates that XSet keeps failing to create or delete targets
+	XSetReplicaFailure XSetConditionType = "ReplicaFailure"
+	// XSetSelectorConsistent indicates that status.selector exposed to autoscalers is valid and selects targets
+	// created from the updated revision
+	XSetSelectorConsistent XSetConditionType = "SelectorConsistent"
+	// XSetRolloutAnalysis indicates the verdict of RolloutAnalysisAdapter on targets of updated revision
+	XSetRolloutAnalysis XSetConditionType = "RolloutAnalysis"
+	// XSetWaitingForMaintenanceWindow indicates that disruptive actions are held until the next maintenance window
+	XSetWaitingForMaintenanceWindow XSetConditionType = "WaitingForMaintenanceWindow"
+	// XSetDriftDetected indicates that targets are drifted from the template of their revision, see DriftDetectionAdapter
+	XSetDriftDetected XSetConditionType = "DriftDetected"
+	// XSetSuspended indicates that reconciliation of XSet is suspended by XSetSuspendAnnotationKey
+	XSetSuspended XSetConditionType = "Suspended"
+	// XSetRolloutAutoPaused indicates that rollout of updated revision is paused automatically on Warning events of its
+	// targets, see WarningEventPauseAdapter
+	XSetRolloutAutoPaused XSetConditionType = "RolloutAutoPaused"
+	// XSetTargetsStuckTerminating indicates that targets stay terminating beyond threshold, e.g., on nodes gone, see
+	// StuckTerminatingAdapter
+	XSetTargetsStuckTerminating XSetConditionType = "TargetsStuckTerminating"
 )
 
 type XSetSpec struct {
@@ -42,6 +74,18 @@ type XSetSpec struct {
 	// +optional
 	Replicas *int32 `json:"replicas,omitempty"`
 
+	// MinReadySeconds is the minimum number of seconds for which a newly ready target should be ready
+	// to be counted as available in status, in addition to CheckAvailable or AvailabilityAdapter.
+	// Defaults to 0 (target will be considered available as soon as CheckAvailable returns true).
+	// +optional
+	MinReadySeconds int32 `json:"minReadySeconds,omitempty"`
+
+	// MinAvailable is the number or percentage of desired replicas which should be available for the
+	// Available condition to be True. Percentage is rounded up.
+	// Defaults to 100%.
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
 	// Selector is a label query over targets that should match the replica count.
 	// It must match the target template's labels.
 	Selector *metav1.LabelSelector `json:"selector,omitempty"`
@@ -60,10 +104,64 @@ type XSetSpec struct {
 	// +optional
 	NamingStrategy *NamingStrategy `json:"namingStrategy,omitempty"`
 
+	// SpreadStrategy indicates how Pod targets are spread across topology domains.
+	// It only takes effect on Pod targets at creation.
+	// +optional
+	SpreadStrategy *SpreadStrategy `json:"spreadStrategy,omitempty"`
+
 	// Indicate the number of histories to be conserved
 	// If unspecified, defaults to 20
 	// +optional
 	HistoryLimit int32 `json:"historyLimit,omitempty"`
+
+	// TemplateRef references an object in namespace of XSet holding the target template, e.g., a ConfigMap shared by
+	// many XSets, which replaces the template inlined in XSet. Changes of referenced template lead to new revisions.
+	// +optional
+	TemplateRef *TemplateReference `json:"templateRef,omitempty"`
+
+	// MaintenanceWindows restricts disruptive actions, i.e., scaling in, recreate updates and deleting targets
+	// replaced, to these time windows. Out of them, targets are only created or updated in place.
+	// Empty means disruptive actions are always allowed.
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+}
+
+// TemplateReference references an object holding the target template.
+type TemplateReference struct {
+	// APIVersion of referenced object. Defaults to v1.
+	// +optional
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// Kind of referenced object, which must be one of kinds returned by TemplateRefAdapter. Defaults to ConfigMap.
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Name of referenced object in namespace of XSet.
+	Name string `json:"name"`
+
+	// Key is the key of data holding template in JSON or YAML for ConfigMap, or the dot-separated path of field holding
+	// template for other kinds. Defaults to "template" for ConfigMap, and "spec.template" for others.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// MaintenanceWindow is a time window recurring on days of week.
+type MaintenanceWindow struct {
+	// Days are the days of week on which window starts, e.g., "Saturday".
+	// Empty means every day.
+	// +optional
+	Days []string `json:"days,omitempty"`
+
+	// Start is the time of day when window starts, in format of "15:04".
+	Start string `json:"start"`
+
+	// Duration is how long window lasts after it starts, which may span days.
+	Duration metav1.Duration `json:"duration"`
+
+	// TimeZone is the IANA name of time zone of Start, e.g., "Asia/Shanghai".
+	// Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
 }
 
 type ByPartition struct {
@@ -75,6 +173,40 @@ type ByPartition struct {
 
 type ByLabel struct{}
 
+// ByRevisionRatios keeps targets in several revisions by ratios as a steady state, e.g., 90% in a stable revision and
+// 10% in an experimental one, which is maintained through scaling instead of being rolled out.
+type ByRevisionRatios struct {
+	// Revisions pins percents of replicas to revisions of XSet. Replicas not pinned run the updated revision.
+	// +optional
+	Revisions []RevisionRatio `json:"revisions,omitempty"`
+}
+
+// RevisionRatio pins a percent of replicas to a revision.
+type RevisionRatio struct {
+	// Revision is the name of ControllerRevision of XSet.
+	Revision string `json:"revision"`
+
+	// Percent of replicas running Revision, rounded down.
+	Percent int32 `json:"percent"`
+}
+
+// GetRevisionReplicas returns number of replicas desired in each revision, in which replicas not pinned run updated
+// revision. Replicas pinned beyond 100% are ignored.
+func (in *ByRevisionRatios) GetRevisionReplicas(replicas int32, updatedRevision string) map[string]int32 {
+	revisionReplicas := map[string]int32{}
+	remaining := replicas
+	for _, ratio := range in.Revisions {
+		if ratio.Revision == updatedRevision {
+			continue
+		}
+		count := min(max(replicas*ratio.Percent/100, 0), remaining)
+		revisionReplicas[ratio.Revision] += count
+		remaining -= count
+	}
+	revisionReplicas[updatedRevision] += remaining
+	return revisionReplicas
+}
+
 // RollingUpdateStrategy is used to communicate parameter for rolling update.
 type RollingUpdateStrategy struct {
 	// ByPartition indicates the update progress is controlled by partition value.
@@ -84,6 +216,10 @@ type RollingUpdateStrategy struct {
 	// ByLabel indicates the update progress is controlled by attaching target label.
 	// +optional
 	ByLabel *ByLabel `json:"byLabel,omitempty"`
+
+	// ByRevisionRatios indicates targets are kept in revisions by ratios.
+	// +optional
+	ByRevisionRatios *ByRevisionRatios `json:"byRevisionRatios,omitempty"`
 }
 
 type UpdateStrategy struct {
@@ -98,6 +234,17 @@ type UpdateStrategy struct {
 	// OperationDelaySeconds indicates how many seconds it should delay before operating update.
 	// +optional
 	OperationDelaySeconds *int32 `json:"operationDelaySeconds,omitempty"`
+
+	// TerminationGracePeriodSeconds overrides the grace period of targets deleted by recreate update.
+	// Nil means using the grace period of target.
+	// +optional
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
+	// ReplaceTerminationGracePeriodSeconds overrides the grace period of origin targets deleted after replaced,
+	// either by replace update or replace indication label.
+	// Nil means using the grace period of target.
+	// +optional
+	ReplaceTerminationGracePeriodSeconds *int64 `json:"replaceTerminationGracePeriodSeconds,omitempty"`
 }
 
 type ScaleStrategy struct {
@@ -108,6 +255,12 @@ type ScaleStrategy struct {
 	// +optional
 	Context string `json:"context,omitempty"`
 
+	// ContextCapacity limits the total number of IDs in the Context pool.
+	// Once reached, XSet stops allocating IDs from the pool instead of growing it.
+	// Nil means no limit.
+	// +optional
+	ContextCapacity *int32 `json:"contextCapacity,omitempty"`
+
 	// TargetToExclude indicates the syncContext which will be orphaned by XSet.
 	// +optional
 	TargetToExclude []string `json:"targetToExclude,omitempty"`
@@ -123,6 +276,11 @@ type ScaleStrategy struct {
 	// OperationDelaySeconds indicates how many seconds it should delay before operating scale.
 	// +optional
 	OperationDelaySeconds *int32 `json:"operationDelaySeconds,omitempty"`
+
+	// TerminationGracePeriodSeconds overrides the grace period of targets deleted by scaling in.
+	// Nil means using the grace period of target.
+	// +optional
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
 }
 
 // TargetNamingSuffixPolicy indicates how a new pod name suffix part is generated.
@@ -144,6 +302,126 @@ type NamingStrategy struct {
 	TargetNamingSuffixPolicy TargetNamingSuffixPolicy `json:"TargetNamingSuffixPolicy,omitempty"`
 }
 
+// SpreadStrategyType indicates how spreading is injected into Pod targets.
+type SpreadStrategyType string
+
+const (
+	// SpreadStrategyAntiAffinity injects pod anti-affinity against targets of the same XSet.
+	SpreadStrategyAntiAffinity SpreadStrategyType = "AntiAffinity"
+	// SpreadStrategyTopologySpread injects topologySpreadConstraints over targets of the same XSet.
+	SpreadStrategyTopologySpread SpreadStrategyType = "TopologySpread"
+)
+
+type SpreadStrategy struct {
+	// Type indicates whether to inject pod anti-affinity or topologySpreadConstraints.
+	Type SpreadStrategyType `json:"type,omitempty"`
+
+	// TopologyKeys indicates the topology domains to spread targets across.
+	// Defaults to kubernetes.io/hostname.
+	// +optional
+	TopologyKeys []string `json:"topologyKeys,omitempty"`
+
+	// Required indicates the spreading is a hard requirement for scheduling, otherwise it is preferred.
+	// +optional
+	Required bool `json:"required,omitempty"`
+
+	// MaxSkew is the max skew of topologySpreadConstraints. Defaults to 1.
+	// +optional
+	MaxSkew *int32 `json:"maxSkew,omitempty"`
+}
+
+// PersistentVolumeClaimRetentionPolicyType indicates whether pvcs are retained or deleted.
+type PersistentVolumeClaimRetentionPolicyType string
+
+const (
+	// RetainPersistentVolumeClaimRetentionPolicyType retains pvcs after targets are deleted.
+	RetainPersistentVolumeClaimRetentionPolicyType PersistentVolumeClaimRetentionPolicyType = "Retain"
+	// DeletePersistentVolumeClaimRetentionPolicyType deletes pvcs along with targets.
+	DeletePersistentVolumeClaimRetentionPolicyType PersistentVolumeClaimRetentionPolicyType = "Delete"
+)
+
+// PersistentVolumeClaimRetentionPolicy describes the lifecycle of pvcs created from pvc templates, like StatefulSet.
+type PersistentVolumeClaimRetentionPolicy struct {
+	// WhenDeleted specifies what happens to pvcs when XSet is deleted. Defaults to Retain.
+	// +optional
+	WhenDeleted PersistentVolumeClaimRetentionPolicyType `json:"whenDeleted,omitempty"`
+
+	// WhenScaled specifies what happens to pvcs when XSet is scaled in. Defaults to Retain.
+	// +optional
+	WhenScaled PersistentVolumeClaimRetentionPolicyType `json:"whenScaled,omitempty"`
+}
+
+// PvcTemplateChangePolicyType indicates what happens to existing pvcs when pvc template changes.
+type PvcTemplateChangePolicyType string
+
+const (
+	// RecreateWithTargetPvcTemplateChangePolicyType recreates targets whose pvcs are provisioned from old pvc template,
+	// with new pvcs re-provisioned. Old pvcs are deleted unless retained when scaled. It is the default policy.
+	RecreateWithTargetPvcTemplateChangePolicyType PvcTemplateChangePolicyType = "RecreateWithTarget"
+	// RecreateOnDeletePvcTemplateChangePolicyType does not update targets for pvc template change, new pvcs are
+	// re-provisioned only when targets are recreated for other reasons.
+	RecreateOnDeletePvcTemplateChangePolicyType PvcTemplateChangePolicyType = "RecreateOnDelete"
+	// IgnorePvcTemplateChangePolicyType never re-provisions existing pvcs, recreated targets keep using them.
+	// Pvc template change only takes effect on new instances.
+	IgnorePvcTemplateChangePolicyType PvcTemplateChangePolicyType = "Ignore"
+)
+
+// TargetDeletionPolicyType indicates what happens to targets owned by XSet when XSet is deleted.
+type TargetDeletionPolicyType string
+
+const (
+	// DeleteTargetDeletionPolicyType deletes targets gracefully before XSet is deleted. It is the default policy.
+	DeleteTargetDeletionPolicyType TargetDeletionPolicyType = "Delete"
+	// OrphanTargetDeletionPolicyType leaves targets running, with ownerReferences and control labels of XSet stripped,
+	// so that they can be adopted by another XSet.
+	OrphanTargetDeletionPolicyType TargetDeletionPolicyType = "Orphan"
+)
+
+// ReplacePvcPolicyType indicates what pvcs the new target uses when replacing a target.
+type ReplacePvcPolicyType string
+
+const (
+	// ReprovisionReplacePvcPolicyType provisions fresh pvcs for the new target. It is the default policy.
+	ReprovisionReplacePvcPolicyType ReplacePvcPolicyType = "Reprovision"
+	// ReattachReplacePvcPolicyType rebinds pvcs of the origin target to the new target.
+	ReattachReplacePvcPolicyType ReplacePvcPolicyType = "Reattach"
+)
+
+// StorageClassMigrationType indicates how data is migrated to pvcs on the new StorageClass.
+type StorageClassMigrationType string
+
+const (
+	// SnapshotStorageClassMigrationType restores new pvcs from VolumeSnapshots of old pvcs.
+	SnapshotStorageClassMigrationType StorageClassMigrationType = "Snapshot"
+	// ReprovisionStorageClassMigrationType provisions new pvcs without data.
+	ReprovisionStorageClassMigrationType StorageClassMigrationType = "Reprovision"
+)
+
+// StorageClassMigration describes how targets are migrated when storageClassName of pvc template changes.
+type StorageClassMigration struct {
+	// Type is how data is migrated to pvcs on the new StorageClass. Defaults to Reprovision.
+	// +optional
+	Type StorageClassMigrationType `json:"type,omitempty"`
+
+	// VolumeSnapshotClassName is the name of VolumeSnapshotClass used to snapshot old pvcs for Snapshot type.
+	// Default VolumeSnapshotClass is used if not set.
+	// +optional
+	VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
+}
+
+// PvcSnapshotPolicy describes how pvcs are snapshotted before they are deleted by scaling in or replacing.
+type PvcSnapshotPolicy struct {
+	// VolumeSnapshotClassName is the name of VolumeSnapshotClass used to create snapshots.
+	// Default VolumeSnapshotClass is used if not set.
+	// +optional
+	VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
+
+	// TTL is how long snapshots are retained after they are created. Snapshots are retained until
+	// deleted manually if not set.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+}
+
 // UpdateStrategyType is a string enumeration type that enumerates
 // all possible ways we can update a Target when updating application
 type UpdateStrategyType string
@@ -177,6 +455,17 @@ type XSetStatus struct {
 	// +optional
 	UpdatedRevision string `json:"updatedRevision,omitempty"`
 
+	// AbortedRevision, if not empty, indicates the updated revision rolled back by RolloutAnalysisAdapter. Targets
+	// are rolled back to, and kept in, CurrentRevision until template changes to another revision.
+	// +optional
+	AbortedRevision string `json:"abortedRevision,omitempty"`
+
+	// AutoPausedRevision, if not empty, indicates the updated revision whose rollout is paused automatically by
+	// WarningEventPauseAdapter. No more targets are updated to it until resumed by XSetResumeRolloutAnnotationKey or
+	// template changes to another revision.
+	// +optional
+	AutoPausedRevision string `json:"autoPausedRevision,omitempty"`
+
 	// Count of hash collisions for the XSet. The XSet controller
 	// uses this field as a collision avoidance mechanism when it needs to
 	// create the name for the newest ControllerRevision.
@@ -203,7 +492,8 @@ type XSetStatus struct {
 	// +optional
 	OperatingReplicas int32 `json:"operatingReplicas,omitempty"`
 
-	// TerminatingReplicas indicates the number of targets with deletionTimestamp.
+	// TerminatingReplicas indicates the number of owned targets with deletionTimestamp, including inactive ones.
+	// Terminating targets are not counted in Replicas unless naming is deterministic.
 	// +optional
 	TerminatingReplicas int32 `json:"terminatingReplicas,omitempty"`
 
@@ -220,11 +510,182 @@ type XSetStatus struct {
 	// +optional
 	UpdatedAvailableReplicas int32 `json:"updatedAvailableReplicas,omitempty"`
 
+	// QuarantinedReplicas indicates the number of targets quarantined by XQuarantinedLabelKey, which are not counted
+	// in other replicas.
+	// +optional
+	QuarantinedReplicas int32 `json:"quarantinedReplicas,omitempty"`
+
+	// PostCreatePendingReplicas indicates the number of targets whose PostCreate hook has not succeeded yet, see
+	// PostCreateHookAdapter.
+	// +optional
+	PostCreatePendingReplicas int32 `json:"postCreatePendingReplicas,omitempty"`
+
+	// RevisionReplicas indicates the number of replicas in each revision, which is only reported if revisions are
+	// pinned by ByRevisionRatios.
+	// +optional
+	RevisionReplicas []RevisionReplicas `json:"revisionReplicas,omitempty"`
+
+	// BoundPvcCount indicates the number of pvcs in Bound phase.
+	// +optional
+	BoundPvcCount int32 `json:"boundPvcCount,omitempty"`
+
+	// PendingPvcCount indicates the number of pvcs in Pending phase.
+	// +optional
+	PendingPvcCount int32 `json:"pendingPvcCount,omitempty"`
+
+	// LostPvcCount indicates the number of pvcs in Lost phase, whose bound volumes are missing.
+	// +optional
+	LostPvcCount int32 `json:"lostPvcCount,omitempty"`
+
+	// InstanceStatuses lists the observed state of targets ordered by instance ID, which is only reported
+	// if InstanceStatusesAdapter is implemented and enabled.
+	// +optional
+	InstanceStatuses []InstanceStatus `json:"instanceStatuses,omitempty"`
+
+	// InstanceStatusesTruncated indicates that InstanceStatuses is truncated to the max size.
+	// +optional
+	InstanceStatusesTruncated bool `json:"instanceStatusesTruncated,omitempty"`
+
+	// FailedCreations records instance IDs which keep failing to create targets. Retries of these IDs are backed
+	// off exponentially, and records are removed once targets are created or IDs are released.
+	// +optional
+	FailedCreations []CreationFailure `json:"failedCreations,omitempty"`
+
+	// Remediations records instance IDs whose targets are remediated for repeated failures. Remediations of these IDs
+	// are backed off exponentially, and records are reset once revision changes or removed once IDs are released.
+	// +optional
+	Remediations []TargetRemediation `json:"remediations,omitempty"`
+
+	// Selector is the label selector of targets in string form, which is exposed to autoscalers like HPA and KEDA
+	// by scale subresource with labelSelectorPath .status.selector.
+	// +optional
+	Selector string `json:"selector,omitempty"`
+
 	// Represents the latest available observations of a XSet's current state.
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// CreationFailureReason is the class of error which fails creating target
+type CreationFailureReason string
+
+const (
+	// CreationFailureQuotaExceeded indicates that creation is rejected by ResourceQuota
+	CreationFailureQuotaExceeded CreationFailureReason = "QuotaExceeded"
+	// CreationFailureForbidden indicates that creation is rejected by admission, e.g., PodSecurity or webhooks
+	CreationFailureForbidden CreationFailureReason = "Forbidden"
+	// CreationFailureInvalidTemplate indicates that target generated from template is invalid
+	CreationFailureInvalidTemplate CreationFailureReason = "InvalidTemplate"
+	// CreationFailureAlreadyExists indicates that target with the same name already exists
+	CreationFailureAlreadyExists CreationFailureReason = "AlreadyExists"
+	// CreationFailureUnknown indicates other errors, e.g., failures of creating pvcs or api server unavailable
+	CreationFailureUnknown CreationFailureReason = "Unknown"
+)
+
+// CreationFailure records failures of creating target for an instance ID.
+type CreationFailure struct {
+	// ID is the instance ID failing to create target.
+	ID int `json:"id"`
+
+	// Revision is the revision of target failing to create.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Reason is the class of the last error.
+	Reason CreationFailureReason `json:"reason"`
+
+	// Message is the last error message.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Count is the number of consecutive failures.
+	Count int32 `json:"count"`
+
+	// FirstFailureTime is the time of the first consecutive failure.
+	FirstFailureTime metav1.Time `json:"firstFailureTime"`
+
+	// LastFailureTime is the time of the last failure.
+	LastFailureTime metav1.Time `json:"lastFailureTime"`
+}
+
+// TargetRemediation records remediations of targets for an instance ID which repeatedly fail.
+type TargetRemediation struct {
+	// ID is the instance ID whose targets are remediated.
+	ID int `json:"id"`
+
+	// Revision is the revision of target remediated.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Remediation is the last remediation applied.
+	Remediation CrashLoopRemediation `json:"remediation"`
+
+	// Message is the reason of the last remediation.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Count is the number of remediations in the revision.
+	Count int32 `json:"count"`
+
+	// LastRemediationTime is the time of the last remediation.
+	LastRemediationTime metav1.Time `json:"lastRemediationTime"`
+}
+
+// InstancePhase is a brief summary of the state of target in its lifecycle
+type InstancePhase string
+
+const (
+	// InstancePhasePending indicates that target is not scheduled yet
+	InstancePhasePending InstancePhase = "Pending"
+	// InstancePhaseRunning indicates that target is scheduled and not during ops lifecycle
+	InstancePhaseRunning InstancePhase = "Running"
+	// InstancePhaseOperating indicates that target is during scale in or update ops lifecycle
+	InstancePhaseOperating InstancePhase = "Operating"
+	// InstancePhaseTerminating indicates that target has deletion timestamp
+	InstancePhaseTerminating InstancePhase = "Terminating"
+	// InstancePhaseQuarantined indicates that target is quarantined and kept out of replicas
+	InstancePhaseQuarantined InstancePhase = "Quarantined"
+)
+
+// RevisionReplicas is the number of replicas in a revision.
+type RevisionReplicas struct {
+	// Revision is the name of ControllerRevision of XSet.
+	Revision string `json:"revision"`
+
+	// Replicas is the number of targets in Revision.
+	Replicas int32 `json:"replicas"`
+
+	// DesiredReplicas is the number of targets desired in Revision by ratios.
+	DesiredReplicas int32 `json:"desiredReplicas"`
+
+	// AvailableReplicas is the number of available targets in Revision.
+	AvailableReplicas int32 `json:"availableReplicas"`
+}
+
+// InstanceStatus is the observed state of a target with instance ID.
+type InstanceStatus struct {
+	// ID is the instance ID of target.
+	ID int `json:"id"`
+
+	// TargetName is the name of target.
+	TargetName string `json:"targetName"`
+
+	// Revision is the controller revision of target.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Phase is a brief summary of the state of target.
+	// +optional
+	Phase InstancePhase `json:"phase,omitempty"`
+
+	// Ready indicates whether target is ready.
+	Ready bool `json:"ready"`
+
+	// NodeName is the node that Pod target is scheduled to.
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+}
+
 // OpsPriority is used to store the ops priority of a target
 type OpsPriority struct {
 	// PriorityClass is the priority class of the target
@@ -22,6 +22,7 @@ package api
 
 import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -59,6 +60,149 @@ func (in *ByPartition) DeepCopy() *ByPartition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByRevisionRatios) DeepCopyInto(out *ByRevisionRatios) {
+	*out = *in
+	if in.Revisions != nil {
+		in, out := &in.Revisions, &out.Revisions
+		*out = make([]RevisionRatio, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByRevisionRatios.
+func (in *ByRevisionRatios) DeepCopy() *ByRevisionRatios {
+	if in == nil {
+		return nil
+	}
+	out := new(ByRevisionRatios)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CreationFailure) DeepCopyInto(out *CreationFailure) {
+	*out = *in
+	in.FirstFailureTime.DeepCopyInto(&out.FirstFailureTime)
+	in.LastFailureTime.DeepCopyInto(&out.LastFailureTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CreationFailure.
+func (in *CreationFailure) DeepCopy() *CreationFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(CreationFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceStatus) DeepCopyInto(out *InstanceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceStatus.
+func (in *InstanceStatus) DeepCopy() *InstanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PersistentVolumeClaimRetentionPolicy) DeepCopyInto(out *PersistentVolumeClaimRetentionPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PersistentVolumeClaimRetentionPolicy.
+func (in *PersistentVolumeClaimRetentionPolicy) DeepCopy() *PersistentVolumeClaimRetentionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PersistentVolumeClaimRetentionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PvcSnapshotPolicy) DeepCopyInto(out *PvcSnapshotPolicy) {
+	*out = *in
+	if in.VolumeSnapshotClassName != nil {
+		in, out := &in.VolumeSnapshotClassName, &out.VolumeSnapshotClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PvcSnapshotPolicy.
+func (in *PvcSnapshotPolicy) DeepCopy() *PvcSnapshotPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PvcSnapshotPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RevisionRatio) DeepCopyInto(out *RevisionRatio) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RevisionRatio.
+func (in *RevisionRatio) DeepCopy() *RevisionRatio {
+	if in == nil {
+		return nil
+	}
+	out := new(RevisionRatio)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RevisionReplicas) DeepCopyInto(out *RevisionReplicas) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RevisionReplicas.
+func (in *RevisionReplicas) DeepCopy() *RevisionReplicas {
+	if in == nil {
+		return nil
+	}
+	out := new(RevisionReplicas)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RollingUpdateStrategy) DeepCopyInto(out *RollingUpdateStrategy) {
 	*out = *in
@@ -72,6 +216,11 @@ func (in *RollingUpdateStrategy) DeepCopyInto(out *RollingUpdateStrategy) {
 		*out = new(ByLabel)
 		**out = **in
 	}
+	if in.ByRevisionRatios != nil {
+		in, out := &in.ByRevisionRatios, &out.ByRevisionRatios
+		*out = new(ByRevisionRatios)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateStrategy.
@@ -87,6 +236,11 @@ func (in *RollingUpdateStrategy) DeepCopy() *RollingUpdateStrategy {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScaleStrategy) DeepCopyInto(out *ScaleStrategy) {
 	*out = *in
+	if in.ContextCapacity != nil {
+		in, out := &in.ContextCapacity, &out.ContextCapacity
+		*out = new(int32)
+		**out = **in
+	}
 	if in.TargetToExclude != nil {
 		in, out := &in.TargetToExclude, &out.TargetToExclude
 		*out = make([]string, len(*in))
@@ -107,6 +261,11 @@ func (in *ScaleStrategy) DeepCopyInto(out *ScaleStrategy) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleStrategy.
@@ -119,6 +278,82 @@ func (in *ScaleStrategy) DeepCopy() *ScaleStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpreadStrategy) DeepCopyInto(out *SpreadStrategy) {
+	*out = *in
+	if in.TopologyKeys != nil {
+		in, out := &in.TopologyKeys, &out.TopologyKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxSkew != nil {
+		in, out := &in.MaxSkew, &out.MaxSkew
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpreadStrategy.
+func (in *SpreadStrategy) DeepCopy() *SpreadStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(SpreadStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageClassMigration) DeepCopyInto(out *StorageClassMigration) {
+	*out = *in
+	if in.VolumeSnapshotClassName != nil {
+		in, out := &in.VolumeSnapshotClassName, &out.VolumeSnapshotClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageClassMigration.
+func (in *StorageClassMigration) DeepCopy() *StorageClassMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageClassMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetRemediation) DeepCopyInto(out *TargetRemediation) {
+	*out = *in
+	in.LastRemediationTime.DeepCopyInto(&out.LastRemediationTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetRemediation.
+func (in *TargetRemediation) DeepCopy() *TargetRemediation {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetRemediation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateReference) DeepCopyInto(out *TemplateReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateReference.
+func (in *TemplateReference) DeepCopy() *TemplateReference {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UpdateStrategy) DeepCopyInto(out *UpdateStrategy) {
 	*out = *in
@@ -132,6 +367,16 @@ func (in *UpdateStrategy) DeepCopyInto(out *UpdateStrategy) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ReplaceTerminationGracePeriodSeconds != nil {
+		in, out := &in.ReplaceTerminationGracePeriodSeconds, &out.ReplaceTerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateStrategy.
@@ -152,6 +397,11 @@ func (in *XSetSpec) DeepCopyInto(out *XSetSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
 	if in.Selector != nil {
 		in, out := &in.Selector, &out.Selector
 		*out = new(v1.LabelSelector)
@@ -159,6 +409,23 @@ func (in *XSetSpec) DeepCopyInto(out *XSetSpec) {
 	}
 	in.UpdateStrategy.DeepCopyInto(&out.UpdateStrategy)
 	in.ScaleStrategy.DeepCopyInto(&out.ScaleStrategy)
+	if in.SpreadStrategy != nil {
+		in, out := &in.SpreadStrategy, &out.SpreadStrategy
+		*out = new(SpreadStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TemplateRef != nil {
+		in, out := &in.TemplateRef, &out.TemplateRef
+		*out = new(TemplateReference)
+		**out = **in
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XSetSpec.
@@ -186,6 +453,30 @@ func (in *XSetStatus) DeepCopyInto(out *XSetStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.RevisionReplicas != nil {
+		in, out := &in.RevisionReplicas, &out.RevisionReplicas
+		*out = make([]RevisionReplicas, len(*in))
+		copy(*out, *in)
+	}
+	if in.InstanceStatuses != nil {
+		in, out := &in.InstanceStatuses, &out.InstanceStatuses
+		*out = make([]InstanceStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailedCreations != nil {
+		in, out := &in.FailedCreations, &out.FailedCreations
+		*out = make([]CreationFailure, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Remediations != nil {
+		in, out := &in.Remediations, &out.Remediations
+		*out = make([]TargetRemediation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XSetStatus.
@@ -0,0 +1,88 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/subresources"
+)
+
+// StripManagedFieldsTransform is an informer transform dropping managedFields from cached objects. It can also be used
+// as cache transform of manager. Other fields, e.g., last-applied-configuration annotation, are not dropped, since
+// they are removed from API server by updates of cached objects.
+func StripManagedFieldsTransform(in interface{}) (interface{}, error) {
+	// objects of other types, e.g., DeletedFinalStateUnknown, are kept as they are
+	obj, err := meta.Accessor(in)
+	if err != nil {
+		return in, nil
+	}
+	obj.SetManagedFields(nil)
+	return in, nil
+}
+
+// installCacheTransforms installs informer transforms to trim targets and pvcs in cache, which should be done before
+// the informers are started by manager.
+func installCacheTransforms(mgr ctrl.Manager, xsetController api.XSetController) error {
+	adapter, ok := xsetController.(api.CacheTransformAdapter)
+	if !ok {
+		return nil
+	}
+	if !adapter.StripManagedFields() {
+		return nil
+	}
+
+	objects := []client.Object{xsetController.NewXObject()}
+	if _, enabled := subresources.GetSubresourcePvcAdapter(xsetController); enabled {
+		objects = append(objects, &corev1.PersistentVolumeClaim{})
+	}
+	for _, obj := range objects {
+		informer, err := mgr.GetCache().GetInformer(context.TODO(), obj)
+		if err != nil {
+			return fmt.Errorf("failed to get informer of %T: %w", obj, err)
+		}
+		if err := setInformerTransform(informer, StripManagedFieldsTransform); err != nil {
+			return fmt.Errorf("failed to set transform of %T informer: %w", obj, err)
+		}
+	}
+	return nil
+}
+
+// setInformerTransform calls SetTransform of informer, which takes a named func type only present in client-go v0.24+,
+// so it is resolved by reflection to keep compatible with the client-go in use. Informers of older client-go, e.g.,
+// v0.22 this module builds against, are rejected.
+func setInformerTransform(informer interface{}, transform func(interface{}) (interface{}, error)) error {
+	method := reflect.ValueOf(informer).MethodByName("SetTransform")
+	if !method.IsValid() || method.Type().NumIn() != 1 || !reflect.TypeOf(transform).ConvertibleTo(method.Type().In(0)) {
+		return fmt.Errorf("informer %T does not support SetTransform, CacheTransformAdapter requires client-go v0.24+", informer)
+	}
+	out := method.Call([]reflect.Value{reflect.ValueOf(transform).Convert(method.Type().In(0))})
+	if len(out) == 1 && !out[0].IsNil() {
+		if err, ok := out[0].Interface().(error); ok {
+			return err
+		}
+	}
+	return nil
+}
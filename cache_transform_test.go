@@ -0,0 +1,78 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// transformFunc mirrors the named TransformFunc of client-go v0.24+
+type transformFunc func(interface{}) (interface{}, error)
+
+type transformInformer struct {
+	toolscache.SharedIndexInformer
+	transform transformFunc
+}
+
+func (i *transformInformer) SetTransform(transform transformFunc) error {
+	i.transform = transform
+	return nil
+}
+
+func TestStripManagedFieldsTransform(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		Annotations:   map[string]string{corev1.LastAppliedConfigAnnotation: "{}"},
+	}}
+	out, err := StripManagedFieldsTransform(pod)
+	if err != nil {
+		t.Fatalf("StripManagedFieldsTransform() = %v", err)
+	}
+	if got := out.(*corev1.Pod); got.ManagedFields != nil || got.Annotations[corev1.LastAppliedConfigAnnotation] != "{}" {
+		t.Errorf("StripManagedFieldsTransform() = %v, want managedFields dropped and annotations kept", got.ObjectMeta)
+	}
+
+	tombstone := toolscache.DeletedFinalStateUnknown{Key: "default/foo", Obj: pod}
+	if out, err := StripManagedFieldsTransform(tombstone); err != nil || out != tombstone {
+		t.Errorf("StripManagedFieldsTransform(tombstone) = %v, %v, want it kept", out, err)
+	}
+}
+
+func TestSetInformerTransform(t *testing.T) {
+	informer := toolscache.NewSharedIndexInformer(&toolscache.ListWatch{}, &corev1.Pod{}, 0, toolscache.Indexers{})
+	if err := setInformerTransform(informer, StripManagedFieldsTransform); err == nil ||
+		!strings.Contains(err.Error(), "client-go v0.24+") {
+		t.Errorf("setInformerTransform() on client-go informer = %v, want rejected", err)
+	}
+
+	supported := &transformInformer{SharedIndexInformer: informer}
+	if err := setInformerTransform(supported, StripManagedFieldsTransform); err != nil {
+		t.Fatalf("setInformerTransform() = %v", err)
+	}
+	if supported.transform == nil {
+		t.Fatalf("setInformerTransform() does not set transform")
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}}}}
+	if _, err := supported.transform(pod); err != nil || pod.ManagedFields != nil {
+		t.Errorf("transform set = %v, managedFields %v, want dropped", err, pod.ManagedFields)
+	}
+}
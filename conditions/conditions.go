@@ -0,0 +1,217 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package conditions provides helpers to read and write conditions in XSetStatus, and reasons of conditions set by
+// XSet controllers, so that adapters and tooling manipulate conditions consistently with XSet controllers.
+package conditions
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// Reasons of Available condition
+const (
+	ReasonMinimumReplicasAvailable   = "MinimumReplicasAvailable"
+	ReasonMinimumReplicasUnavailable = "MinimumReplicasUnavailable"
+)
+
+// Reasons of Progressing condition
+const (
+	ReasonRolloutComplete  = "RolloutComplete"
+	ReasonReplicasScaling  = "ReplicasScaling"
+	ReasonReplicasUpdating = "ReplicasUpdating"
+	ReasonRolloutStalled   = "RolloutStalled"
+	ReasonPaused           = "Paused"
+	ReasonSuspended        = "Suspended"
+)
+
+// Reasons of Suspended condition
+const (
+	ReasonSuspendedByAnnotation = "SuspendedByAnnotation"
+)
+
+// Reasons of WaitingForMaintenanceWindow condition
+const (
+	ReasonOutOfMaintenanceWindow   = "OutOfMaintenanceWindow"
+	ReasonInvalidMaintenanceWindow = "InvalidMaintenanceWindow"
+)
+
+// Reasons of DriftDetected condition
+const (
+	ReasonTargetsDrifted = "TargetsDrifted"
+	ReasonNoDrift        = "NoDrift"
+)
+
+// Reasons of RolloutAutoPaused condition
+const (
+	ReasonWarningEventsExceeded = "WarningEventsExceeded"
+	ReasonResumedByAnnotation   = "ResumedByAnnotation"
+)
+
+// Reasons of TargetsStuckTerminating condition
+const (
+	ReasonTargetsStuckTerminating  = "TargetsStuckTerminating"
+	ReasonNoTargetStuckTerminating = "NoTargetStuckTerminating"
+)
+
+// Reasons of ReplicaFailure condition
+const (
+	ReasonFailedCreate = "FailedCreate"
+	ReasonFailedDelete = "FailedDelete"
+)
+
+// Reasons of Scale and Update conditions
+const (
+	ReasonScaled         = "Scaled"
+	ReasonScaleOutFailed = "ScaleOutFailed"
+	ReasonScaleInFailed  = "ScaleInFailed"
+	ReasonUpdated        = "Updated"
+	ReasonUpdateFailed   = "UpdateFailed"
+)
+
+// Reasons of SelectorConsistent condition
+const (
+	ReasonSelectorConsistent  = "SelectorConsistent"
+	ReasonSelectorEmpty       = "SelectorEmpty"
+	ReasonSelectorInvalid     = "SelectorInvalid"
+	ReasonSelectorChanged     = "SelectorChanged"
+	ReasonTemplateNotSelected = "TemplateNotSelected"
+)
+
+// Reasons of RolloutAnalysis condition
+const (
+	ReasonAnalysisProceeding = "AnalysisProceeding"
+	ReasonAnalysisPaused     = "AnalysisPaused"
+	ReasonAnalysisFailed     = "AnalysisFailed"
+	ReasonAnalysisRolledBack = "AnalysisRolledBack"
+)
+
+// Reasons of PvcExpansion, VolumesReady, PvcDeletionBlocked and PoolExhausted conditions
+const (
+	ReasonExpanding           = "Expanding"
+	ReasonExpandFailed        = "ExpandFailed"
+	ReasonExpansionNotAllowed = "ExpansionNotAllowed"
+	ReasonVolumesReady        = "VolumesReady"
+	ReasonPvcLost             = "PvcLost"
+	ReasonPvcPending          = "PvcPending"
+	ReasonFinalizersPresent   = "FinalizersPresent"
+	ReasonPoolExhausted       = "PoolExhausted"
+)
+
+// Reasons of Terminating condition
+const (
+	ReasonDeleted                      = "Deleted"
+	ReasonReclaimSubResourcesFailed    = "ReclaimSubResourcesFailed"
+	ReasonReclaimOwnerReferencesFailed = "ReclaimOwnerReferencesFailed"
+	ReasonOrphanTargetsFailed          = "OrphanTargetsFailed"
+	ReasonReclaimTargetsDeletionFailed = "ReclaimTargetsDeletionFailed"
+	ReasonReclaimingTargetsDeletion    = "ReclaimingTargetsDeletion"
+	ReasonReclaimResourceContext       = "ReclaimResourceContext"
+)
+
+// Set sets condition of conditionType in status with observedGeneration. LastTransitionTime is only changed along
+// with status of condition, so that it records when condition last transited rather than when it was last written.
+func Set(status *api.XSetStatus, conditionType api.XSetConditionType, condStatus metav1.ConditionStatus, reason, message string, generation int64) {
+	if status.Conditions == nil {
+		status.Conditions = []metav1.Condition{}
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               string(conditionType),
+		Status:             condStatus,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// MessageUpdatePeriodBackOff is the min interval to update message of a condition set by SetFromError whose status and
+// reason are unchanged
+const MessageUpdatePeriodBackOff = 30 * time.Second
+
+// SetFromError sets condition of conditionType in status true if err is nil, otherwise false. Unlike Set,
+// lastTransitionTime changes along with reason as well, and message of an unchanged condition is updated at most every
+// MessageUpdatePeriodBackOff, so that status is not rewritten on every reconcile by errors with varying messages.
+func SetFromError(status *api.XSetStatus, conditionType api.XSetConditionType, err error, reason, message string) {
+	condStatus := metav1.ConditionTrue
+	if err != nil {
+		condStatus = metav1.ConditionFalse
+	}
+
+	if cond := Get(status, conditionType); cond != nil && cond.Status == condStatus && cond.Reason == reason {
+		if cond.Message == message || time.Since(cond.LastTransitionTime.Time) < MessageUpdatePeriodBackOff {
+			return
+		}
+	}
+
+	cond := metav1.Condition{
+		Type:               string(conditionType),
+		Status:             condStatus,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == cond.Type {
+			status.Conditions[i] = cond
+			return
+		}
+	}
+	status.Conditions = append(status.Conditions, cond)
+}
+
+// Get returns condition of conditionType in status, or nil if not found
+func Get(status *api.XSetStatus, conditionType api.XSetConditionType) *metav1.Condition {
+	return meta.FindStatusCondition(status.Conditions, string(conditionType))
+}
+
+// FindAndCompare returns condition of conditionType in status, and whether it is found with condStatus and reason.
+// Empty reason matches any reason.
+func FindAndCompare(status *api.XSetStatus, conditionType api.XSetConditionType, condStatus metav1.ConditionStatus, reason string) (*metav1.Condition, bool) {
+	cond := Get(status, conditionType)
+	if cond == nil {
+		return nil, false
+	}
+	return cond, cond.Status == condStatus && (reason == "" || cond.Reason == reason)
+}
+
+// IsTrue checks whether condition of conditionType in status is true
+func IsTrue(status *api.XSetStatus, conditionType api.XSetConditionType) bool {
+	_, matched := FindAndCompare(status, conditionType, metav1.ConditionTrue, "")
+	return matched
+}
+
+// IsFalse checks whether condition of conditionType in status is false
+func IsFalse(status *api.XSetStatus, conditionType api.XSetConditionType) bool {
+	_, matched := FindAndCompare(status, conditionType, metav1.ConditionFalse, "")
+	return matched
+}
+
+// IsObserved checks whether condition of conditionType in status is set for generation or a later one, conditions
+// set without observedGeneration are never observed
+func IsObserved(status *api.XSetStatus, conditionType api.XSetConditionType, generation int64) bool {
+	cond := Get(status, conditionType)
+	return cond != nil && cond.ObservedGeneration >= generation && cond.ObservedGeneration > 0
+}
+
+// Remove removes condition of conditionType from status
+func Remove(status *api.XSetStatus, conditionType api.XSetConditionType) {
+	meta.RemoveStatusCondition(&status.Conditions, string(conditionType))
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conditions
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+func TestSet(t *testing.T) {
+	status := &api.XSetStatus{}
+	Set(status, api.XSetAvailable, metav1.ConditionFalse, ReasonMinimumReplicasUnavailable, "1 of 3 replicas available", 1)
+	cond, matched := FindAndCompare(status, api.XSetAvailable, metav1.ConditionFalse, ReasonMinimumReplicasUnavailable)
+	if !matched || cond.ObservedGeneration != 1 || !IsFalse(status, api.XSetAvailable) {
+		t.Fatalf("got condition %v, want false with reason %s", cond, ReasonMinimumReplicasUnavailable)
+	}
+
+	// lastTransitionTime is kept if status is unchanged
+	transitionTime := metav1.NewTime(time.Now().Add(-time.Hour))
+	cond.LastTransitionTime = transitionTime
+	Set(status, api.XSetAvailable, metav1.ConditionFalse, ReasonMinimumReplicasUnavailable, "2 of 3 replicas available", 2)
+	if cond = Get(status, api.XSetAvailable); !cond.LastTransitionTime.Equal(&transitionTime) || cond.Message != "2 of 3 replicas available" {
+		t.Errorf("expect lastTransitionTime kept and message updated, got %v", cond)
+	}
+	if !IsObserved(status, api.XSetAvailable, 2) || IsObserved(status, api.XSetAvailable, 3) {
+		t.Errorf("expect condition observed for generation 2 only")
+	}
+
+	Set(status, api.XSetAvailable, metav1.ConditionTrue, ReasonMinimumReplicasAvailable, "3 of 3 replicas available", 2)
+	if cond = Get(status, api.XSetAvailable); cond.LastTransitionTime.Equal(&transitionTime) || !IsTrue(status, api.XSetAvailable) {
+		t.Errorf("expect lastTransitionTime changed along with status, got %v", cond)
+	}
+	if _, matched := FindAndCompare(status, api.XSetAvailable, metav1.ConditionTrue, ReasonMinimumReplicasUnavailable); matched {
+		t.Errorf("expect condition with another reason not matched")
+	}
+
+	Remove(status, api.XSetAvailable)
+	if cond, matched := FindAndCompare(status, api.XSetAvailable, metav1.ConditionTrue, ""); cond != nil || matched {
+		t.Errorf("expect condition removed, got %v", cond)
+	}
+}
+
+func TestSetFromError(t *testing.T) {
+	status := &api.XSetStatus{}
+	Set(status, api.XSetAvailable, metav1.ConditionTrue, ReasonMinimumReplicasAvailable, "", 1)
+	SetFromError(status, api.XSetScale, errors.New("quota exceeded"), ReasonScaleOutFailed, "quota exceeded")
+	if _, matched := FindAndCompare(status, api.XSetScale, metav1.ConditionFalse, ReasonScaleOutFailed); !matched {
+		t.Fatalf("expect condition false on error, got %v", Get(status, api.XSetScale))
+	}
+
+	// message of an unchanged condition is updated with backoff
+	SetFromError(status, api.XSetScale, errors.New("quota exceeded again"), ReasonScaleOutFailed, "quota exceeded again")
+	if cond := Get(status, api.XSetScale); cond.Message != "quota exceeded" {
+		t.Errorf("expect message kept within backoff, got %v", cond)
+	}
+	transitionTime := metav1.NewTime(time.Now().Add(-MessageUpdatePeriodBackOff))
+	Get(status, api.XSetScale).LastTransitionTime = transitionTime
+	SetFromError(status, api.XSetScale, errors.New("quota exceeded again"), ReasonScaleOutFailed, "quota exceeded again")
+	if cond := Get(status, api.XSetScale); cond.Message != "quota exceeded again" {
+		t.Errorf("expect message updated after backoff, got %v", cond)
+	}
+
+	// lastTransitionTime changes along with reason, and condition is updated in place
+	transitionTime = metav1.NewTime(time.Now().Add(-time.Hour))
+	Get(status, api.XSetScale).LastTransitionTime = transitionTime
+	SetFromError(status, api.XSetScale, errors.New("conflict"), ReasonScaleInFailed, "conflict")
+	if cond := Get(status, api.XSetScale); cond.Reason != ReasonScaleInFailed || cond.LastTransitionTime.Equal(&transitionTime) {
+		t.Errorf("expect lastTransitionTime changed along with reason, got %v", cond)
+	}
+	SetFromError(status, api.XSetScale, nil, ReasonScaled, "")
+	if len(status.Conditions) != 2 || status.Conditions[1].Type != string(api.XSetScale) || !IsTrue(status, api.XSetScale) {
+		t.Errorf("expect condition true in place without error, got %v", status.Conditions)
+	}
+}
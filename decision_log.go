@@ -0,0 +1,49 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"github.com/go-logr/logr"
+	"k8s.io/utils/ptr"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/synccontrols"
+)
+
+// logSyncDecision logs what the reconcile decided in one structured line at V(1), so that decisions of controller can
+// be reconstructed from logs.
+func logSyncDecision(logger logr.Logger, spec *api.XSetSpec, syncContext *synccontrols.SyncContext, status *api.XSetStatus, syncErr error) {
+	decisions := &syncContext.Decisions
+	keysAndValues := []interface{}{
+		"desiredReplicas", ptr.Deref(spec.Replicas, 0),
+		"replicas", status.Replicas,
+		"updatedReplicas", status.UpdatedReplicas,
+		"terminatingReplicas", status.TerminatingReplicas,
+		"currentRevision", status.CurrentRevision,
+		"updatedRevision", status.UpdatedRevision,
+		"createIDs", decisions.CreateIDs,
+		"deleteIDs", decisions.DeleteIDs,
+		"updateIDs", decisions.UpdateIDs,
+	}
+	if decisions.RequeueAfter != nil {
+		keysAndValues = append(keysAndValues, "requeueReason", decisions.RequeueReason, "requeueAfter", decisions.RequeueAfter.String())
+	}
+	if syncErr != nil {
+		keysAndValues = append(keysAndValues, "error", syncErr.Error())
+	}
+	logger.V(1).Info("sync decision", keysAndValues...)
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kusionstack.io/kube-utils/controller/expectations"
+)
+
+const (
+	expectCreation = "Creation"
+	expectDeletion = "Deletion"
+	expectUpdation = "Updation"
+)
+
+var _ expectations.CacheExpectationsInterface = &expectationTracker{}
+
+// expectationTracker records expectations of each XSet on top of CacheExpectations, which does not tell what is
+// pending. Records of an XSet key are cleared once its expectations are satisfied or deleted, so that records of an
+// unsatisfied key are what the controller has been waiting for since.
+type expectationTracker struct {
+	expectations.CacheExpectationsInterface
+
+	mu      sync.Mutex
+	records map[string]map[expectationRecordKey]*expectationRecord
+}
+
+type expectationRecordKey struct {
+	operation string
+	gvk       schema.GroupVersionKind
+	namespace string
+	name      string
+}
+
+type expectationRecord struct {
+	Operation       string    `json:"operation"`
+	Kind            string    `json:"kind"`
+	Namespace       string    `json:"namespace"`
+	Name            string    `json:"name"`
+	ResourceVersion string    `json:"resourceVersion,omitempty"`
+	Since           time.Time `json:"since"`
+}
+
+func newExpectationTracker(cacheExpectations expectations.CacheExpectationsInterface) *expectationTracker {
+	return &expectationTracker{
+		CacheExpectationsInterface: cacheExpectations,
+		records:                    map[string]map[expectationRecordKey]*expectationRecord{},
+	}
+}
+
+func (t *expectationTracker) ExpectCreation(key string, gvk schema.GroupVersionKind, namespace, name string) error {
+	if err := t.CacheExpectationsInterface.ExpectCreation(key, gvk, namespace, name); err != nil {
+		return err
+	}
+	t.record(key, expectCreation, gvk, namespace, name, "")
+	return nil
+}
+
+func (t *expectationTracker) ExpectDeletion(key string, gvk schema.GroupVersionKind, namespace, name string) error {
+	if err := t.CacheExpectationsInterface.ExpectDeletion(key, gvk, namespace, name); err != nil {
+		return err
+	}
+	t.record(key, expectDeletion, gvk, namespace, name, "")
+	return nil
+}
+
+func (t *expectationTracker) ExpectUpdation(key string, gvk schema.GroupVersionKind, namespace, name, resourceVersion string) error {
+	if err := t.CacheExpectationsInterface.ExpectUpdation(key, gvk, namespace, name, resourceVersion); err != nil {
+		return err
+	}
+	t.record(key, expectUpdation, gvk, namespace, name, resourceVersion)
+	return nil
+}
+
+func (t *expectationTracker) SatisfiedExpectations(key string) bool {
+	satisfied := t.CacheExpectationsInterface.SatisfiedExpectations(key)
+	if satisfied {
+		t.clear(key)
+	}
+	return satisfied
+}
+
+func (t *expectationTracker) DeleteExpectations(key string) {
+	t.CacheExpectationsInterface.DeleteExpectations(key)
+	t.clear(key)
+}
+
+// Pending returns expectations recorded for key since its expectations were last satisfied, oldest first.
+func (t *expectationTracker) Pending(key string) []expectationRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	records := make([]expectationRecord, 0, len(t.records[key]))
+	for _, record := range t.records[key] {
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Since.Before(records[j].Since)
+	})
+	return records
+}
+
+// ServeHTTP responds pending expectations of the XSet given by query parameter key, e.g., key=namespace/name,
+// or pending expectations of all XSets without key.
+func (t *expectationTracker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	result := map[string][]expectationRecord{}
+	if key := req.URL.Query().Get("key"); key != "" {
+		result[key] = t.Pending(key)
+	} else {
+		t.mu.Lock()
+		keys := make([]string, 0, len(t.records))
+		for key := range t.records {
+			keys = append(keys, key)
+		}
+		t.mu.Unlock()
+		for _, key := range keys {
+			result[key] = t.Pending(key)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (t *expectationTracker) record(key, operation string, gvk schema.GroupVersionKind, namespace, name, resourceVersion string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	records, ok := t.records[key]
+	if !ok {
+		records = map[expectationRecordKey]*expectationRecord{}
+		t.records[key] = records
+	}
+	recordKey := expectationRecordKey{operation: operation, gvk: gvk, namespace: namespace, name: name}
+	if record, ok := records[recordKey]; ok {
+		record.ResourceVersion = resourceVersion
+		return
+	}
+	records[recordKey] = &expectationRecord{
+		Operation:       operation,
+		Kind:            gvk.Kind,
+		Namespace:       namespace,
+		Name:            name,
+		ResourceVersion: resourceVersion,
+		Since:           time.Now(),
+	}
+}
+
+func (t *expectationTracker) clear(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.records, key)
+}
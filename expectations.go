@@ -0,0 +1,237 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"kusionstack.io/kube-utils/controller/expectations"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// expectationVerifyInterval is the interval to verify expectations against api server, once they are not observed
+	// in cache. An expectation is dropped if api server shows it is not going to be observed, e.g., the object is gone.
+	expectationVerifyInterval = 5 * time.Second
+	// expectationMaxVerifyFailures is the number of consecutive failures to verify an expectation against api server,
+	// after which the expectation is dropped
+	expectationMaxVerifyFailures = 3
+	// maxVerificationsPerCheck is the max number of expectations verified against api server in one check, so that
+	// reconcile is not blocked by reading all pending objects from api server
+	maxVerificationsPerCheck = 5
+)
+
+var _ expectations.CacheExpectationsInterface = &resourceVersionExpectations{}
+
+// resourceVersionExpectations are cache expectations fulfilled by comparing objects in cache with what is expected:
+//   - creation is satisfied once object exists in cache and is not terminating, so that a stale object with the same
+//     name does not satisfy it;
+//   - updation is satisfied once cached resourceVersion is the expected one, or is changed from the one cached when
+//     expected, so that later updates by others do not starve it. Resource versions are opaque and never ordered;
+//   - deletion is satisfied once object is gone from cache or is terminating.
+//
+// Expectations not observed in cache are verified against api server periodically, and dropped if api server
+// shows they are not going to be observed, e.g., the created or updated object is deleted in the meantime, if they
+// keep failing to be verified, or once timeout expires, which is the expectation timeout of the reconciler.
+type resourceVersionExpectations struct {
+	cache     client.Reader
+	apiReader client.Reader
+	scheme    *runtime.Scheme
+	// timeout is the duration after which an expectation is dropped even if it is never observed, so that XSet is not
+	// blocked forever by cache or api server failures
+	timeout time.Duration
+
+	mu    sync.Mutex
+	items map[string]map[expectationRecordKey]*resourceVersionExpectation
+}
+
+type resourceVersionExpectation struct {
+	resourceVersion string
+	// staleResourceVersion is the resourceVersion in cache when updation is expected, if it is not the expected one
+	staleResourceVersion string
+	since                time.Time
+	lastVerified         time.Time
+	verifyFailures       int
+}
+
+func newResourceVersionExpectations(cache, apiReader client.Reader, scheme *runtime.Scheme, timeout time.Duration) *resourceVersionExpectations {
+	return &resourceVersionExpectations{
+		cache:     cache,
+		apiReader: apiReader,
+		scheme:    scheme,
+		timeout:   timeout,
+		items:     map[string]map[expectationRecordKey]*resourceVersionExpectation{},
+	}
+}
+
+func (e *resourceVersionExpectations) ExpectCreation(key string, gvk schema.GroupVersionKind, namespace, name string) error {
+	e.expect(key, expectationRecordKey{operation: expectCreation, gvk: gvk, namespace: namespace, name: name}, "")
+	return nil
+}
+
+func (e *resourceVersionExpectations) ExpectDeletion(key string, gvk schema.GroupVersionKind, namespace, name string) error {
+	e.expect(key, expectationRecordKey{operation: expectDeletion, gvk: gvk, namespace: namespace, name: name}, "")
+	return nil
+}
+
+func (e *resourceVersionExpectations) ExpectUpdation(key string, gvk schema.GroupVersionKind, namespace, name, resourceVersion string) error {
+	e.expect(key, expectationRecordKey{operation: expectUpdation, gvk: gvk, namespace: namespace, name: name}, resourceVersion)
+	return nil
+}
+
+func (e *resourceVersionExpectations) SatisfiedExpectations(key string) bool {
+	e.mu.Lock()
+	pending := make(map[expectationRecordKey]*resourceVersionExpectation, len(e.items[key]))
+	for recordKey, item := range e.items[key] {
+		pending[recordKey] = item
+	}
+	e.mu.Unlock()
+
+	satisfied := map[expectationRecordKey]*resourceVersionExpectation{}
+	verifications := 0
+	for recordKey, item := range pending {
+		if e.observed(recordKey, item, &verifications) {
+			satisfied[recordKey] = item
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	items := e.items[key]
+	for recordKey, item := range satisfied {
+		// keep expectation renewed in the meantime
+		if items[recordKey] == item {
+			delete(items, recordKey)
+		}
+	}
+	if len(items) == 0 {
+		delete(e.items, key)
+		return true
+	}
+	return false
+}
+
+func (e *resourceVersionExpectations) DeleteExpectations(key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.items, key)
+}
+
+func (e *resourceVersionExpectations) expect(key string, recordKey expectationRecordKey, resourceVersion string) {
+	now := time.Now()
+	item := &resourceVersionExpectation{resourceVersion: resourceVersion, since: now, lastVerified: now}
+	if recordKey.operation == expectUpdation && resourceVersion != "" {
+		obj := e.newObject(recordKey.gvk)
+		if err := e.cache.Get(context.Background(), types.NamespacedName{Namespace: recordKey.namespace, Name: recordKey.name}, obj); err == nil &&
+			obj.GetResourceVersion() != resourceVersion {
+			item.staleResourceVersion = obj.GetResourceVersion()
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	items, ok := e.items[key]
+	if !ok {
+		items = map[expectationRecordKey]*resourceVersionExpectation{}
+		e.items[key] = items
+	}
+	items[recordKey] = item
+}
+
+// observed checks whether expectation is observed in cache, or is not going to be observed. verifications counts
+// expectations verified against api server in this check.
+func (e *resourceVersionExpectations) observed(recordKey expectationRecordKey, item *resourceVersionExpectation, verifications *int) bool {
+	if satisfied, _ := e.satisfiedBy(e.cache, recordKey, item); satisfied {
+		return true
+	}
+	if time.Since(item.since) >= e.timeout {
+		return true
+	}
+	if e.apiReader == nil || *verifications >= maxVerificationsPerCheck {
+		return false
+	}
+	e.mu.Lock()
+	verify := time.Since(item.lastVerified) >= expectationVerifyInterval
+	if verify {
+		item.lastVerified = time.Now()
+	}
+	e.mu.Unlock()
+	if !verify {
+		return false
+	}
+
+	*verifications++
+	satisfied, err := e.satisfiedBy(e.apiReader, recordKey, item)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err != nil {
+		item.verifyFailures++
+		return item.verifyFailures >= expectationMaxVerifyFailures
+	}
+	item.verifyFailures = 0
+	return satisfied
+}
+
+func (e *resourceVersionExpectations) satisfiedBy(reader client.Reader, recordKey expectationRecordKey, item *resourceVersionExpectation) (bool, error) {
+	obj := e.newObject(recordKey.gvk)
+	err := reader.Get(context.Background(), types.NamespacedName{Namespace: recordKey.namespace, Name: recordKey.name}, obj)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		// creation or updation is not going to be observed once api server shows the object is gone
+		return recordKey.operation == expectDeletion || reader == e.apiReader, nil
+	}
+
+	switch recordKey.operation {
+	case expectCreation:
+		return obj.GetDeletionTimestamp() == nil, nil
+	case expectUpdation:
+		return isResourceVersionObserved(obj.GetResourceVersion(), item), nil
+	default:
+		return obj.GetDeletionTimestamp() != nil, nil
+	}
+}
+
+func (e *resourceVersionExpectations) newObject(gvk schema.GroupVersionKind) client.Object {
+	if e.scheme != nil {
+		if obj, err := e.scheme.New(gvk); err == nil {
+			if clientObj, ok := obj.(client.Object); ok {
+				return clientObj
+			}
+		}
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	return obj
+}
+
+// isResourceVersionObserved checks whether resourceVersion is the expected one, or is changed from the stale one
+// cached when updation is expected
+func isResourceVersionObserved(resourceVersion string, item *resourceVersionExpectation) bool {
+	if item.resourceVersion == "" || resourceVersion == item.resourceVersion {
+		return true
+	}
+	return item.staleResourceVersion != "" && resourceVersion != item.staleResourceVersion
+}
@@ -0,0 +1,189 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var podGVK = corev1.SchemeGroupVersion.WithKind("Pod")
+
+// failingReader fails to get any object, and counts calls
+type failingReader struct {
+	client.Reader
+	calls int
+}
+
+func (r *failingReader) Get(context.Context, client.ObjectKey, client.Object) error {
+	r.calls++
+	return errors.New("api server unavailable")
+}
+
+func newExpectationPod(name, resourceVersion string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, ResourceVersion: resourceVersion}}
+}
+
+// ageExpectations makes expectations of key due to verify, and expected since the given duration ago
+func ageExpectations(e *resourceVersionExpectations, key string, since time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, item := range e.items[key] {
+		item.since = time.Now().Add(-since)
+		item.lastVerified = time.Now().Add(-expectationVerifyInterval)
+	}
+}
+
+func TestResourceVersionExpectationsSatisfied(t *testing.T) {
+	cache := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(newExpectationPod("foo-0", "10")).Build()
+	e := newResourceVersionExpectations(cache, nil, clientgoscheme.Scheme, DefaultExpectationTimeout)
+	key := "default/foo"
+
+	// creation is satisfied once object is in cache
+	_ = e.ExpectCreation(key, podGVK, "default", "foo-1")
+	if e.SatisfiedExpectations(key) {
+		t.Fatal("creation is satisfied before object is cached")
+	}
+	if err := cache.Create(context.TODO(), newExpectationPod("foo-1", "")); err != nil {
+		t.Fatal(err)
+	}
+	if !e.SatisfiedExpectations(key) {
+		t.Fatal("creation is not satisfied after object is cached")
+	}
+
+	// updation is satisfied once cache is changed from the stale resourceVersion
+	_ = e.ExpectUpdation(key, podGVK, "default", "foo-0", "11")
+	if e.SatisfiedExpectations(key) {
+		t.Fatal("updation is satisfied by stale cache")
+	}
+	pod := &corev1.Pod{}
+	_ = cache.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "foo-0"}, pod)
+	if err := cache.Update(context.TODO(), pod); err != nil {
+		t.Fatal(err)
+	}
+	if !e.SatisfiedExpectations(key) {
+		t.Fatalf("updation is not satisfied by resourceVersion %s", pod.ResourceVersion)
+	}
+
+	// deletion is satisfied once object is gone
+	_ = e.ExpectDeletion(key, podGVK, "default", "foo-0")
+	if e.SatisfiedExpectations(key) {
+		t.Fatal("deletion is satisfied before object is gone")
+	}
+	if err := cache.Delete(context.TODO(), pod); err != nil {
+		t.Fatal(err)
+	}
+	if !e.SatisfiedExpectations(key) {
+		t.Fatal("deletion is not satisfied after object is gone")
+	}
+}
+
+func TestIsResourceVersionObserved(t *testing.T) {
+	tests := []struct {
+		cached, expected, stale string
+		want                    bool
+	}{
+		{cached: "11", expected: "11", stale: "10", want: true},
+		{cached: "10", expected: "11", stale: "10", want: false},
+		// resourceVersions are opaque, and any change from the stale one is observed
+		{cached: "12", expected: "11", stale: "10", want: true},
+		{cached: "9", expected: "11", stale: "10", want: true},
+		{cached: "a", expected: "b", stale: "a", want: false},
+		{cached: "c", expected: "b", stale: "a", want: true},
+		{cached: "10", expected: "11", want: false},
+		{cached: "10", expected: "", want: true},
+	}
+	for _, tt := range tests {
+		item := &resourceVersionExpectation{resourceVersion: tt.expected, staleResourceVersion: tt.stale}
+		if got := isResourceVersionObserved(tt.cached, item); got != tt.want {
+			t.Errorf("isResourceVersionObserved(%q) expecting %q from %q = %v, want %v", tt.cached, tt.expected, tt.stale, got, tt.want)
+		}
+	}
+}
+
+func TestResourceVersionExpectationsExpire(t *testing.T) {
+	cache := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+	e := newResourceVersionExpectations(cache, nil, clientgoscheme.Scheme, time.Minute)
+	key := "default/foo"
+
+	_ = e.ExpectCreation(key, podGVK, "default", "foo-0")
+	ageExpectations(e, key, time.Minute/2)
+	if e.SatisfiedExpectations(key) {
+		t.Fatal("expectation expires before timeout")
+	}
+	ageExpectations(e, key, time.Minute)
+	if !e.SatisfiedExpectations(key) {
+		t.Fatal("expectation does not expire after configured timeout")
+	}
+}
+
+func TestResourceVersionExpectationsVerify(t *testing.T) {
+	cache := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(newExpectationPod("foo-0", "10")).Build()
+	apiReader := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+	e := newResourceVersionExpectations(cache, apiReader, clientgoscheme.Scheme, DefaultExpectationTimeout)
+	key := "default/foo"
+
+	// updation is dropped once api server shows object is gone, but not before verify interval
+	_ = e.ExpectUpdation(key, podGVK, "default", "foo-0", "11")
+	if e.SatisfiedExpectations(key) {
+		t.Fatal("updation is verified before verify interval")
+	}
+	ageExpectations(e, key, 0)
+	if !e.SatisfiedExpectations(key) {
+		t.Fatal("updation is not dropped after object is gone from api server")
+	}
+}
+
+func TestResourceVersionExpectationsVerifyFailures(t *testing.T) {
+	cache := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+	apiReader := &failingReader{}
+	e := newResourceVersionExpectations(cache, apiReader, clientgoscheme.Scheme, DefaultExpectationTimeout)
+	key := "default/foo"
+
+	// expectation is dropped once it keeps failing to be verified
+	_ = e.ExpectCreation(key, podGVK, "default", "foo-0")
+	for i := 1; i < expectationMaxVerifyFailures; i++ {
+		ageExpectations(e, key, 0)
+		if e.SatisfiedExpectations(key) {
+			t.Fatalf("expectation is dropped after %d verify failure(s)", i)
+		}
+	}
+	ageExpectations(e, key, 0)
+	if !e.SatisfiedExpectations(key) {
+		t.Fatal("expectation is not dropped after max verify failures")
+	}
+
+	// api server is read for limited expectations in each check
+	apiReader.calls = 0
+	for i := 0; i < 2*maxVerificationsPerCheck; i++ {
+		_ = e.ExpectCreation(key, podGVK, "default", fmt.Sprintf("foo-%d", i))
+	}
+	ageExpectations(e, key, 0)
+	e.SatisfiedExpectations(key)
+	if apiReader.calls != maxVerificationsPerCheck {
+		t.Errorf("api server is read %d time(s) in a check, want %d", apiReader.calls, maxVerificationsPerCheck)
+	}
+}
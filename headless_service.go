@@ -0,0 +1,39 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// watchHeadlessServices watches Services if HeadlessServiceAdapter is implemented, and enqueues XSets controlling
+// them, so that governing headless Services changed or deleted are synced back. Services are read from the same
+// cache, which is restricted to namespaces of NamespaceScopeAdapter if implemented.
+func watchHeadlessServices(c controller.Controller, xsetController api.XSetController) error {
+	if _, ok := xsetController.(api.HeadlessServiceAdapter); !ok {
+		return nil
+	}
+	return c.Watch(&source.Kind{Type: &corev1.Service{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    xsetController.NewXSetObject(),
+	})
+}
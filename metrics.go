@@ -0,0 +1,32 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// suspendedXSets reports XSets suspended by annotation, whose series is deleted once XSet is resumed or deleted
+var suspendedXSets = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "xset_suspended",
+	Help: "Whether reconciliation of XSet is suspended by annotation, 1 if suspended",
+}, []string{"controller", "namespace", "name"})
+
+func init() {
+	metrics.Registry.MustRegister(suspendedXSets)
+}
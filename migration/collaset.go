@@ -0,0 +1,467 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/resourcecontexts"
+)
+
+// ResourceContextBackupAnnotationKey is the annotation on ResourceContext shared by CollaSet and XSet, recording
+// contexts of CollaSet replaced by Adopt, which are restored by Rollback.
+const ResourceContextBackupAnnotationKey = "xset.kusionstack.io/migration-backup"
+
+// DefaultCollaSetContextKeys are keys in ResourceContext of CollaSet, which are translated into keys of XSet
+var DefaultCollaSetContextKeys = map[string]api.ResourceContextKeyEnum{
+	"Owner":                  api.EnumOwnerContextKey,
+	"Revision":               api.EnumRevisionContextDataKey,
+	"PodDecorationRevisions": api.EnumTargetDecorationRevisionKey,
+	"PodJustCreate":          api.EnumJustCreateContextDataKey,
+	"PodRecreateUpdate":      api.EnumRecreateUpdateContextDataKey,
+	"ScaleIn":                api.EnumScaleInContextDataKey,
+	"ReplaceNewPodID":        api.EnumReplaceNewTargetIDContextDataKey,
+	"ReplaceOriginPodID":     api.EnumReplaceOriginTargetIDContextDataKey,
+}
+
+// migratedLabels are labels on targets and PVCs copied from keys of CollaSet to those of XSet if they differ, so that
+// instance IDs and PVC templates are recognized by XSet with a custom XSetLabelAnnotationManager
+var migratedLabels = []api.XSetLabelAnnotationEnum{
+	api.ControlledByXSetLabel,
+	api.XInstanceIdLabelKey,
+	api.SubResourcePvcTemplateLabelKey,
+	api.SubResourcePvcTemplateHashLabelKey,
+}
+
+// CollaSetMigrator migrates Pods of a CollaSet to an XSet in the same namespace by stages, which are recorded in
+// XSetMigrationAnnotationKey on XSet:
+//   - Prepare pauses CollaSet, and marks XSet so that it is not reconciled until migration completes;
+//   - Adopt moves Pods, PVCs and ControllerRevisions controlled by CollaSet under XSet, and copies instance IDs of
+//     CollaSet in ResourceContext to XSet, so that Pods keep their instance IDs and revisions;
+//   - Complete hands Pods over to XSet, and the paused CollaSet owning nothing is left to be deleted by users.
+//
+// Rollback reverts Adopt and Prepare at any stage before Complete. Every stage can be retried on failure. Pods are
+// not updated after cutover only if revisions of XSet record the same data as those of CollaSet, e.g., GetXSetPatch
+// returns patch of Pod template in the same form, so that adopted revisions are reused by XSet.
+type CollaSetMigrator struct {
+	client                 client.Client
+	xsetController         api.XSetController
+	resourceContextAdapter api.ResourceContextAdapter
+	xsetLabelMgr           api.XSetLabelAnnotationManager
+	collaSetLabelMgr       api.XSetLabelAnnotationManager
+
+	// CollaSetContextKeys maps keys in ResourceContext of CollaSet to keys of XSet, and keys not in it are kept as
+	// they are. It defaults to DefaultCollaSetContextKeys.
+	CollaSetContextKeys map[string]api.ResourceContextKeyEnum
+	// PauseCollaSet pauses or resumes CollaSet, which defaults to patching spec.paused
+	PauseCollaSet func(ctx context.Context, c client.Client, collaSet client.Object, paused bool) error
+}
+
+func NewCollaSetMigrator(c client.Client, xsetController api.XSetController) *CollaSetMigrator {
+	return &CollaSetMigrator{
+		client:                 c,
+		xsetController:         xsetController,
+		resourceContextAdapter: resourcecontexts.GetResourceContextAdapter(xsetController),
+		xsetLabelMgr:           api.GetXSetLabelAnnotationManager(xsetController),
+		collaSetLabelMgr:       api.NewXSetLabelAnnotationManager(nil),
+		CollaSetContextKeys:    DefaultCollaSetContextKeys,
+		PauseCollaSet:          pauseBySpec,
+	}
+}
+
+// Prepare pauses collaSet, and marks xset as migrating from it. xset is expected to be created in the namespace of
+// collaSet with a selector selecting all Pods of collaSet.
+func (m *CollaSetMigrator) Prepare(ctx context.Context, collaSet client.Object, xset api.XSetObject) error {
+	if collaSet.GetNamespace() != xset.GetNamespace() {
+		return fmt.Errorf("xset %s/%s is not in namespace of collaset %s", xset.GetNamespace(), xset.GetName(), collaSet.GetNamespace())
+	}
+	source, err := m.getSource(collaSet)
+	if err != nil {
+		return err
+	}
+	stage, migrating := xset.GetAnnotations()[api.XSetMigrationAnnotationKey]
+	if migrating && (stage != string(api.XSetMigrationPrepared) || xset.GetAnnotations()[api.XSetMigrationSourceAnnotationKey] != source) {
+		return fmt.Errorf("xset %s/%s is already in migration stage %s from %s", xset.GetNamespace(), xset.GetName(),
+			stage, xset.GetAnnotations()[api.XSetMigrationSourceAnnotationKey])
+	}
+	// mark xset before pausing collaset, so that neither of them acts on Pods once collaset is paused
+	if err := m.setStage(ctx, xset, source, api.XSetMigrationPrepared); err != nil {
+		return err
+	}
+	if err := m.PauseCollaSet(ctx, m.client, collaSet, true); err != nil {
+		return fmt.Errorf("fail to pause collaset %s/%s: %w", collaSet.GetNamespace(), collaSet.GetName(), err)
+	}
+	return nil
+}
+
+// Adopt moves instance IDs, ControllerRevisions, PVCs and Pods of collaSet under xset in order
+func (m *CollaSetMigrator) Adopt(ctx context.Context, collaSet client.Object, xset api.XSetObject) error {
+	if err := m.checkStage(collaSet, xset, api.XSetMigrationPrepared, api.XSetMigrationAdopted); err != nil {
+		return err
+	}
+	if err := m.adoptResourceContext(ctx, collaSet, xset); err != nil {
+		return err
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(m.xsetController.GetXSetSpec(xset).Selector)
+	if err != nil {
+		return fmt.Errorf("fail to parse selector of xset %s/%s: %w", xset.GetNamespace(), xset.GetName(), err)
+	}
+	targets, err := m.listControlled(ctx, m.xsetController.NewXObjectList(), collaSet)
+	if err != nil {
+		return err
+	}
+	// targets not selected by xset would be orphaned by it, and they are checked before anything is moved
+	for _, target := range targets {
+		if !selector.Matches(labels.Set(target.GetLabels())) {
+			return fmt.Errorf("target %s of collaset %s/%s is not selected by xset selector %s", target.GetName(),
+				collaSet.GetNamespace(), collaSet.GetName(), selector.String())
+		}
+	}
+
+	revisions, err := m.listControlled(ctx, &appsv1.ControllerRevisionList{}, collaSet)
+	if err != nil {
+		return err
+	}
+	pvcs, err := m.listControlled(ctx, &corev1.PersistentVolumeClaimList{}, collaSet)
+	if err != nil {
+		return err
+	}
+	for _, obj := range append(append(revisions, pvcs...), targets...) {
+		if err := m.transferController(ctx, obj, xset, m.copyLabels); err != nil {
+			return err
+		}
+	}
+	return m.setStage(ctx, xset, xset.GetAnnotations()[api.XSetMigrationSourceAnnotationKey], api.XSetMigrationAdopted)
+}
+
+// Complete marks migration completed, from which xset is reconciled and takes over Pods of collaSet. collaSet is
+// left paused, and can be deleted once it owns nothing.
+func (m *CollaSetMigrator) Complete(ctx context.Context, collaSet client.Object, xset api.XSetObject) error {
+	if err := m.checkStage(collaSet, xset, api.XSetMigrationAdopted, api.XSetMigrationCompleted); err != nil {
+		return err
+	}
+	return m.setStage(ctx, xset, xset.GetAnnotations()[api.XSetMigrationSourceAnnotationKey], api.XSetMigrationCompleted)
+}
+
+// Rollback moves everything adopted by xset back to collaSet, resumes collaSet and unmarks xset. xset is expected to
+// be deleted afterward, which owns nothing migrated.
+func (m *CollaSetMigrator) Rollback(ctx context.Context, collaSet client.Object, xset api.XSetObject) error {
+	if err := m.checkStage(collaSet, xset, api.XSetMigrationPrepared, api.XSetMigrationAdopted); err != nil {
+		return err
+	}
+
+	targets, err := m.listControlled(ctx, m.xsetController.NewXObjectList(), xset)
+	if err != nil {
+		return err
+	}
+	pvcs, err := m.listControlled(ctx, &corev1.PersistentVolumeClaimList{}, xset)
+	if err != nil {
+		return err
+	}
+	revisions, err := m.listControlled(ctx, &appsv1.ControllerRevisionList{}, xset)
+	if err != nil {
+		return err
+	}
+	for _, obj := range append(append(targets, pvcs...), revisions...) {
+		if err := m.transferController(ctx, obj, collaSet, m.removeCopiedLabels); err != nil {
+			return err
+		}
+	}
+	if err := m.rollbackResourceContext(ctx, xset); err != nil {
+		return err
+	}
+
+	if err := m.PauseCollaSet(ctx, m.client, collaSet, false); err != nil {
+		return fmt.Errorf("fail to resume collaset %s/%s: %w", collaSet.GetNamespace(), collaSet.GetName(), err)
+	}
+	patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf(`{"metadata":{"annotations":{"%s":null,"%s":null}}}`,
+		api.XSetMigrationAnnotationKey, api.XSetMigrationSourceAnnotationKey)))
+	return m.client.Patch(ctx, xset, patch)
+}
+
+func (m *CollaSetMigrator) getSource(collaSet client.Object) (string, error) {
+	gvk, err := apiutil.GVKForObject(collaSet, m.client.Scheme())
+	if err != nil {
+		return "", fmt.Errorf("fail to get kind of collaset %s/%s: %w", collaSet.GetNamespace(), collaSet.GetName(), err)
+	}
+	return gvk.Kind + "/" + collaSet.GetName(), nil
+}
+
+// checkStage checks that xset is migrating from collaSet in one of stages
+func (m *CollaSetMigrator) checkStage(collaSet client.Object, xset api.XSetObject, stages ...api.XSetMigrationStage) error {
+	source, err := m.getSource(collaSet)
+	if err != nil {
+		return err
+	}
+	annotations := xset.GetAnnotations()
+	if annotations[api.XSetMigrationSourceAnnotationKey] != source {
+		return fmt.Errorf("xset %s/%s is not migrating from %s", xset.GetNamespace(), xset.GetName(), source)
+	}
+	for _, stage := range stages {
+		if annotations[api.XSetMigrationAnnotationKey] == string(stage) {
+			return nil
+		}
+	}
+	return fmt.Errorf("xset %s/%s is in migration stage %q, expect one of %v", xset.GetNamespace(), xset.GetName(),
+		annotations[api.XSetMigrationAnnotationKey], stages)
+}
+
+func (m *CollaSetMigrator) setStage(ctx context.Context, xset api.XSetObject, source string, stage api.XSetMigrationStage) error {
+	patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf(`{"metadata":{"annotations":{"%s":"%s","%s":"%s"}}}`,
+		api.XSetMigrationAnnotationKey, stage, api.XSetMigrationSourceAnnotationKey, source)))
+	if err := m.client.Patch(ctx, xset, patch); err != nil {
+		return fmt.Errorf("fail to set migration stage %s of xset %s/%s: %w", stage, xset.GetNamespace(), xset.GetName(), err)
+	}
+	return nil
+}
+
+// listControlled lists objects in namespace of owner whose controller is owner
+func (m *CollaSetMigrator) listControlled(ctx context.Context, list client.ObjectList, owner client.Object) ([]client.Object, error) {
+	if err := m.client.List(ctx, list, client.InNamespace(owner.GetNamespace())); err != nil {
+		return nil, fmt.Errorf("fail to list %T in namespace %s: %w", list, owner.GetNamespace(), err)
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+	var controlled []client.Object
+	for _, item := range items {
+		obj, ok := item.(client.Object)
+		if !ok {
+			continue
+		}
+		if ref := metav1.GetControllerOf(obj); ref != nil && ref.UID == owner.GetUID() {
+			controlled = append(controlled, obj)
+		}
+	}
+	return controlled, nil
+}
+
+// transferController replaces controller reference of obj with one to owner, and mutates its labels
+func (m *CollaSetMigrator) transferController(ctx context.Context, obj, owner client.Object, mutateLabels func(client.Object)) error {
+	gvk, err := apiutil.GVKForObject(owner, m.client.Scheme())
+	if err != nil {
+		return err
+	}
+	original := obj.DeepCopyObject().(client.Object)
+	ownerRefs := []metav1.OwnerReference{*metav1.NewControllerRef(owner, gvk)}
+	for _, ref := range obj.GetOwnerReferences() {
+		if !ptr.Deref(ref.Controller, false) {
+			ownerRefs = append(ownerRefs, ref)
+		}
+	}
+	obj.SetOwnerReferences(ownerRefs)
+	mutateLabels(obj)
+	if err := m.client.Patch(ctx, obj, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+		return fmt.Errorf("fail to transfer %s/%s to %s %s: %w", obj.GetNamespace(), obj.GetName(), gvk.Kind, owner.GetName(), err)
+	}
+	return nil
+}
+
+func (m *CollaSetMigrator) copyLabels(obj client.Object) {
+	for _, enum := range migratedLabels {
+		if key := m.xsetLabelMgr.Value(enum); key != m.collaSetLabelMgr.Value(enum) {
+			if value, ok := m.collaSetLabelMgr.Get(obj, enum); ok {
+				m.xsetLabelMgr.Set(obj, enum, value)
+			}
+		}
+	}
+}
+
+func (m *CollaSetMigrator) removeCopiedLabels(obj client.Object) {
+	for _, enum := range migratedLabels {
+		if key := m.xsetLabelMgr.Value(enum); key != m.collaSetLabelMgr.Value(enum) {
+			m.xsetLabelMgr.Delete(obj, enum)
+		}
+	}
+}
+
+// adoptResourceContext copies contexts owned by collaSet into ResourceContext of xset with keys of xset. Contexts of
+// collaSet are replaced and backed up if both of them use the same ResourceContext, otherwise left as they are.
+func (m *CollaSetMigrator) adoptResourceContext(ctx context.Context, collaSet client.Object, xset api.XSetObject) error {
+	collaSetAdapter := &resourcecontexts.DefaultResourceContextAdapter{}
+	collaSetContext := collaSetAdapter.NewResourceContext()
+	key := types.NamespacedName{Namespace: collaSet.GetNamespace(), Name: getCollaSetContextName(collaSet)}
+	if err := m.client.Get(ctx, key, collaSetContext); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("fail to get ResourceContext %s of collaset: %w", key, err)
+	}
+	var owned, adopted []api.ContextDetail
+	for _, detail := range collaSetAdapter.GetResourceContextSpec(collaSetContext).Contexts {
+		if detail.Data[m.collaSetOwnerKey()] == collaSet.GetName() {
+			owned = append(owned, detail)
+			adopted = append(adopted, m.translateContext(detail, xset.GetName()))
+		}
+	}
+
+	xsetContext, exist, err := m.getXSetContext(ctx, xset)
+	if err != nil {
+		return err
+	}
+	shared := exist && xsetContext.GetName() == collaSetContext.GetName() &&
+		m.resourceContextAdapter.ResourceContextMeta() == collaSetAdapter.ResourceContextMeta()
+	if shared {
+		if _, backedUp := xsetContext.GetAnnotations()[ResourceContextBackupAnnotationKey]; !backedUp && len(owned) > 0 {
+			backup, err := json.Marshal(owned)
+			if err != nil {
+				return err
+			}
+			setAnnotation(xsetContext, ResourceContextBackupAnnotationKey, string(backup))
+		}
+	}
+
+	spec := m.resourceContextAdapter.GetResourceContextSpec(xsetContext)
+	var contexts []api.ContextDetail
+	for _, detail := range spec.Contexts {
+		if m.isOwnedBy(&detail, xset.GetName()) || (shared && detail.Data[m.collaSetOwnerKey()] == collaSet.GetName()) {
+			continue
+		}
+		contexts = append(contexts, detail)
+	}
+	spec.Contexts = append(contexts, adopted...)
+	m.resourceContextAdapter.SetResourceContextSpec(spec, xsetContext)
+	if !exist {
+		err = m.client.Create(ctx, xsetContext)
+	} else {
+		err = m.client.Update(ctx, xsetContext)
+	}
+	if err != nil {
+		return fmt.Errorf("fail to write ResourceContext %s/%s of xset: %w", xsetContext.GetNamespace(), xsetContext.GetName(), err)
+	}
+	return nil
+}
+
+// rollbackResourceContext removes contexts owned by xset, and restores contexts of collaSet backed up by Adopt
+func (m *CollaSetMigrator) rollbackResourceContext(ctx context.Context, xset api.XSetObject) error {
+	xsetContext, exist, err := m.getXSetContext(ctx, xset)
+	if err != nil || !exist {
+		return err
+	}
+	spec := m.resourceContextAdapter.GetResourceContextSpec(xsetContext)
+	var contexts []api.ContextDetail
+	for _, detail := range spec.Contexts {
+		if !m.isOwnedBy(&detail, xset.GetName()) {
+			contexts = append(contexts, detail)
+		}
+	}
+	if backup, ok := xsetContext.GetAnnotations()[ResourceContextBackupAnnotationKey]; ok {
+		var restored []api.ContextDetail
+		if err := json.Unmarshal([]byte(backup), &restored); err != nil {
+			return fmt.Errorf("fail to parse backup of ResourceContext %s/%s: %w", xsetContext.GetNamespace(), xsetContext.GetName(), err)
+		}
+		contexts = append(contexts, restored...)
+		annotations := xsetContext.GetAnnotations()
+		delete(annotations, ResourceContextBackupAnnotationKey)
+		xsetContext.SetAnnotations(annotations)
+	}
+
+	if len(contexts) == 0 {
+		err = m.client.Delete(ctx, xsetContext)
+	} else {
+		spec.Contexts = contexts
+		m.resourceContextAdapter.SetResourceContextSpec(spec, xsetContext)
+		err = m.client.Update(ctx, xsetContext)
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("fail to roll back ResourceContext %s/%s: %w", xsetContext.GetNamespace(), xsetContext.GetName(), err)
+	}
+	return nil
+}
+
+func (m *CollaSetMigrator) getXSetContext(ctx context.Context, xset api.XSetObject) (api.ResourceContextObject, bool, error) {
+	xsetContext := m.resourceContextAdapter.NewResourceContext()
+	name := resourcecontexts.GetResourceContextName(m.xsetController, m.resourceContextAdapter, xset)
+	if err := m.client.Get(ctx, types.NamespacedName{Namespace: xset.GetNamespace(), Name: name}, xsetContext); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, false, fmt.Errorf("fail to get ResourceContext %s/%s of xset: %w", xset.GetNamespace(), name, err)
+		}
+		xsetContext.SetNamespace(xset.GetNamespace())
+		xsetContext.SetName(name)
+		return xsetContext, false, nil
+	}
+	return xsetContext, true, nil
+}
+
+// translateContext returns context of collaSet with keys of xset, owned by xset
+func (m *CollaSetMigrator) translateContext(detail api.ContextDetail, xsetName string) api.ContextDetail {
+	keys := resourcecontexts.GetResourceContextKeys(m.resourceContextAdapter)
+	translated := api.ContextDetail{ID: detail.ID, Data: map[string]string{}}
+	for key, value := range detail.Data {
+		if enum, ok := m.CollaSetContextKeys[key]; ok {
+			key = keys[enum]
+		}
+		translated.Data[key] = value
+	}
+	translated.Data[keys[api.EnumOwnerContextKey]] = xsetName
+	return translated
+}
+
+func (m *CollaSetMigrator) isOwnedBy(detail *api.ContextDetail, xsetName string) bool {
+	return detail.Contains(resourcecontexts.GetResourceContextKeys(m.resourceContextAdapter)[api.EnumOwnerContextKey], xsetName)
+}
+
+func (m *CollaSetMigrator) collaSetOwnerKey() string {
+	for key, enum := range m.CollaSetContextKeys {
+		if enum == api.EnumOwnerContextKey {
+			return key
+		}
+	}
+	return "Owner"
+}
+
+// getCollaSetContextName returns spec.scaleStrategy.context of collaSet if set, otherwise its name
+func getCollaSetContextName(collaSet client.Object) string {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(collaSet)
+	if err == nil {
+		if name, found, _ := unstructured.NestedString(obj, "spec", "scaleStrategy", "context"); found && name != "" {
+			return name
+		}
+	}
+	return collaSet.GetName()
+}
+
+func pauseBySpec(ctx context.Context, c client.Client, collaSet client.Object, paused bool) error {
+	return c.Patch(ctx, collaSet, client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf(`{"spec":{"paused":%t}}`, paused))))
+}
+
+func setAnnotation(obj client.Object, key, value string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[key] = value
+	obj.SetAnnotations(annotations)
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migration
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	appsv1alpha1 "kusionstack.io/kube-api/apps/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+type migrationController struct {
+	api.XSetController
+}
+
+func (c *migrationController) GetXSetSpec(api.XSetObject) *api.XSetSpec {
+	return &api.XSetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}}}
+}
+
+func (c *migrationController) NewXObjectList() client.ObjectList { return &corev1.PodList{} }
+
+func TestCollaSetMigrator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = appsv1alpha1.AddToScheme(scheme)
+
+	// Deployment stands for CollaSet, both of which are paused by spec.paused
+	collaSet := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", UID: "collaset-uid"}}
+	xset := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-xset", UID: "xset-uid"}}
+	collaSetRef := *metav1.NewControllerRef(collaSet, appsv1.SchemeGroupVersion.WithKind("Deployment"))
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "default", Name: "foo-0", OwnerReferences: []metav1.OwnerReference{collaSetRef},
+		Labels: map[string]string{"app": "foo", appsv1alpha1.PodInstanceIDLabelKey: "0", appsv1.ControllerRevisionHashLabelKey: "foo-rev"},
+	}}
+	revision := &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-rev", OwnerReferences: []metav1.OwnerReference{collaSetRef}}}
+	collaSetContext := &appsv1alpha1.ResourceContext{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"},
+		Spec: appsv1alpha1.ResourceContextSpec{Contexts: []appsv1alpha1.ContextDetail{
+			{ID: 0, Data: map[string]string{"Owner": "foo", "Revision": "foo-rev", "ReplaceNewPodID": "1"}},
+			{ID: 5, Data: map[string]string{"Owner": "bar"}},
+		}},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(collaSet, xset, pod, revision, collaSetContext).Build()
+	ctx := context.TODO()
+	m := NewCollaSetMigrator(c, &migrationController{})
+
+	if err := m.Adopt(ctx, collaSet, xset); err == nil {
+		t.Fatalf("expect Adopt to fail before Prepare")
+	}
+	if err := m.Prepare(ctx, collaSet, xset); err != nil {
+		t.Fatalf("Prepare() = %v", err)
+	}
+	if _ = c.Get(ctx, client.ObjectKeyFromObject(collaSet), collaSet); !collaSet.Spec.Paused || !api.IsXSetMigrating(xset) {
+		t.Fatalf("expect collaset paused and xset migrating after Prepare")
+	}
+
+	if err := m.Adopt(ctx, collaSet, xset); err != nil {
+		t.Fatalf("Adopt() = %v", err)
+	}
+	_ = c.Get(ctx, client.ObjectKeyFromObject(pod), pod)
+	_ = c.Get(ctx, client.ObjectKeyFromObject(revision), revision)
+	if metav1.GetControllerOf(pod).UID != xset.UID || metav1.GetControllerOf(revision).UID != xset.UID {
+		t.Errorf("expect pod and revision controlled by xset after Adopt")
+	}
+	xsetContext := &appsv1alpha1.ResourceContext{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "foo-xset"}, xsetContext); err != nil {
+		t.Fatalf("fail to get ResourceContext of xset: %v", err)
+	}
+	want := []appsv1alpha1.ContextDetail{{ID: 0, Data: map[string]string{"Owner": "foo-xset", "Revision": "foo-rev", "ReplaceNewTargetID": "1"}}}
+	if !reflect.DeepEqual(xsetContext.Spec.Contexts, want) {
+		t.Errorf("got contexts of xset %v, want %v", xsetContext.Spec.Contexts, want)
+	}
+
+	// roll back to collaset before completed
+	if err := m.Rollback(ctx, collaSet, xset); err != nil {
+		t.Fatalf("Rollback() = %v", err)
+	}
+	_ = c.Get(ctx, client.ObjectKeyFromObject(pod), pod)
+	_ = c.Get(ctx, client.ObjectKeyFromObject(collaSet), collaSet)
+	if metav1.GetControllerOf(pod).UID != collaSet.UID || collaSet.Spec.Paused || api.IsXSetMigrating(xset) {
+		t.Errorf("expect pod controlled by resumed collaset and xset not migrating after Rollback")
+	}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "foo-xset"}, xsetContext); err == nil {
+		t.Errorf("expect ResourceContext of xset deleted after Rollback")
+	}
+
+	// migrate again and complete
+	for _, stage := range []func(context.Context, client.Object, api.XSetObject) error{m.Prepare, m.Adopt, m.Complete} {
+		if err := stage(ctx, collaSet, xset); err != nil {
+			t.Fatalf("fail to migrate: %v", err)
+		}
+	}
+	if api.IsXSetMigrating(xset) {
+		t.Errorf("expect xset reconciled after Complete")
+	}
+	if err := m.Rollback(ctx, collaSet, xset); err == nil {
+		t.Errorf("expect Rollback to fail after Complete")
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// getWatchNamespaces returns namespaces requested by NamespaceScopeAdapter, nil means cluster-wide
+func getWatchNamespaces(xsetController api.XSetController) sets.String {
+	adapter, ok := xsetController.(api.NamespaceScopeAdapter)
+	if !ok {
+		return nil
+	}
+	namespaces := adapter.GetWatchNamespaces()
+	if len(namespaces) == 0 {
+		return nil
+	}
+	return sets.NewString(namespaces...)
+}
+
+// NewNamespacedCache returns the func building cache of manager restricted to namespaces of NamespaceScopeAdapter,
+// e.g., ctrl.Options{NewCache: NewNamespacedCache(xsetController)}, so that informers only list and watch objects in
+// the namespaces. It is required by SetUpWithManager once the adapter returns namespaces.
+func NewNamespacedCache(xsetController api.XSetController) cache.NewCacheFunc {
+	namespaces := getWatchNamespaces(xsetController)
+	if namespaces.Len() == 0 {
+		return cache.New
+	}
+	newCache := cache.MultiNamespacedCacheBuilder(namespaces.List())
+	return func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+		c, err := newCache(config, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &namespacedCache{Cache: c, namespaces: namespaces}, nil
+	}
+}
+
+// namespacedCache marks cache built by NewNamespacedCache with its namespaces
+type namespacedCache struct {
+	cache.Cache
+	namespaces sets.String
+}
+
+// checkNamespacedCache returns error if namespaces are required but cache is not restricted to them by
+// NewNamespacedCache, since informers of a cluster-wide cache list and watch all namespaces.
+func checkNamespacedCache(c cache.Cache, namespaces sets.String) error {
+	if namespaces.Len() == 0 {
+		return nil
+	}
+	if restricted, ok := c.(*namespacedCache); ok && restricted.namespaces.Equal(namespaces) {
+		return nil
+	}
+	return fmt.Errorf("cache of manager is not restricted to namespaces %v of NamespaceScopeAdapter, build it by NewNamespacedCache", namespaces.List())
+}
+
+// namespacedController restricts all watches registered on controller to namespaces. Events of cluster-scoped
+// objects are passed through, since they may still be mapped to XSets in the namespaces.
+type namespacedController struct {
+	controller.Controller
+	namespaces sets.String
+}
+
+func newNamespacedController(c controller.Controller, namespaces sets.String) controller.Controller {
+	if namespaces.Len() == 0 {
+		return c
+	}
+	return &namespacedController{Controller: c, namespaces: namespaces}
+}
+
+func (c *namespacedController) Watch(src source.Source, eventhandler handler.EventHandler, predicates ...predicate.Predicate) error {
+	inScope := predicate.NewPredicateFuncs(func(object client.Object) bool {
+		return object.GetNamespace() == "" || c.namespaces.Has(object.GetNamespace())
+	})
+	return c.Controller.Watch(src, eventhandler, append([]predicate.Predicate{inScope}, predicates...)...)
+}
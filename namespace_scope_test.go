@@ -0,0 +1,74 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+type namespaceScopeController struct {
+	api.XSetController
+	namespaces []string
+}
+
+func (c *namespaceScopeController) GetWatchNamespaces() []string { return c.namespaces }
+
+func TestCheckNamespacedCache(t *testing.T) {
+	config := &rest.Config{Host: "http://127.0.0.1:0"}
+	opts := cache.Options{Scheme: clientgoscheme.Scheme, Mapper: meta.NewDefaultRESTMapper(nil)}
+	newCache := func(t *testing.T, newCacheFunc cache.NewCacheFunc) cache.Cache {
+		t.Helper()
+		c, err := newCacheFunc(config, opts)
+		if err != nil {
+			t.Fatalf("fail to build cache: %v", err)
+		}
+		return c
+	}
+	clusterCache := newCache(t, cache.New)
+	tenantCache := newCache(t, NewNamespacedCache(&namespaceScopeController{namespaces: []string{"tenant-a", "tenant-b"}}))
+
+	tests := []struct {
+		name       string
+		cache      cache.Cache
+		namespaces sets.String
+		wantErr    bool
+	}{
+		{name: "cluster-wide controller", cache: clusterCache},
+		{name: "cluster-wide cache", cache: clusterCache, namespaces: sets.NewString("tenant-a"), wantErr: true},
+		{name: "restricted cache", cache: tenantCache, namespaces: sets.NewString("tenant-b", "tenant-a")},
+		{name: "cache restricted to other namespaces", cache: tenantCache, namespaces: sets.NewString("tenant-a"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkNamespacedCache(tt.cache, tt.namespaces); (err != nil) != tt.wantErr {
+				t.Errorf("checkNamespacedCache() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, restricted := newCache(t, NewNamespacedCache(&namespaceScopeController{})).(*namespacedCache); restricted {
+		t.Errorf("NewNamespacedCache() restricts cache of cluster-wide controller")
+	}
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/synccontrols"
+)
+
+// targetNodeIndex records nodes Pod targets of XSets are bound to, so that XSets are reconciled once readiness of
+// their nodes changes
+type targetNodeIndex struct {
+	mu sync.RWMutex
+	// nodes maps XSet to nodes of its targets
+	nodes map[types.NamespacedName]sets.String
+	// xsets maps node to XSets with targets on it
+	xsets map[string]map[types.NamespacedName]struct{}
+}
+
+func newTargetNodeIndex() *targetNodeIndex {
+	return &targetNodeIndex{
+		nodes: map[types.NamespacedName]sets.String{},
+		xsets: map[string]map[types.NamespacedName]struct{}{},
+	}
+}
+
+// Set records nodes of Pod targets of XSet, and forgets the ones recorded before
+func (i *targetNodeIndex) Set(xset types.NamespacedName, targets []client.Object) {
+	nodes := sets.NewString()
+	for _, target := range targets {
+		if pod, ok := target.(*corev1.Pod); ok && pod.Spec.NodeName != "" {
+			nodes.Insert(pod.Spec.NodeName)
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.delete(xset)
+	if nodes.Len() == 0 {
+		return
+	}
+	i.nodes[xset] = nodes
+	for node := range nodes {
+		if _, ok := i.xsets[node]; !ok {
+			i.xsets[node] = map[types.NamespacedName]struct{}{}
+		}
+		i.xsets[node][xset] = struct{}{}
+	}
+}
+
+// Delete forgets nodes of targets of XSet
+func (i *targetNodeIndex) Delete(xset types.NamespacedName) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.delete(xset)
+}
+
+func (i *targetNodeIndex) delete(xset types.NamespacedName) {
+	nodes, ok := i.nodes[xset]
+	if !ok {
+		return
+	}
+	delete(i.nodes, xset)
+	for node := range nodes {
+		delete(i.xsets[node], xset)
+		if len(i.xsets[node]) == 0 {
+			delete(i.xsets, node)
+		}
+	}
+}
+
+// Requests returns requests of XSets with targets on the node
+func (i *targetNodeIndex) Requests(node client.Object) []reconcile.Request {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	xsets := i.xsets[node.GetName()]
+	requests := make([]reconcile.Request, 0, len(xsets))
+	for xset := range xsets {
+		requests = append(requests, reconcile.Request{NamespacedName: xset})
+	}
+	return requests
+}
+
+// watchTargetNodes watches nodes if NodeFailureReplaceAdapter is implemented, and enqueues XSets with targets on nodes
+// whose readiness changes. The informer of nodes is cluster-wide, and is shared by reading nodes of targets.
+func watchTargetNodes(c controller.Controller, xsetController api.XSetController, index *targetNodeIndex) error {
+	if _, ok := xsetController.(api.NodeFailureReplaceAdapter); !ok {
+		return nil
+	}
+	return c.Watch(&source.Kind{Type: &corev1.Node{}}, handler.EnqueueRequestsFromMapFunc(index.Requests), predicate.Funcs{
+		CreateFunc: func(event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(updateEvent event.UpdateEvent) bool {
+			oldNode, oldOk := updateEvent.ObjectOld.(*corev1.Node)
+			newNode, newOk := updateEvent.ObjectNew.(*corev1.Node)
+			return oldOk && newOk && synccontrols.IsNodeReady(oldNode) != synccontrols.IsNodeReady(newNode)
+		},
+		DeleteFunc: func(event.DeleteEvent) bool {
+			return true
+		},
+		GenericFunc: func(event.GenericEvent) bool {
+			return false
+		},
+	})
+}
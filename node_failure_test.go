@@ -0,0 +1,54 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestTargetNodeIndex(t *testing.T) {
+	index := newTargetNodeIndex()
+	podOn := func(name, node string) client.Object {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}, Spec: corev1.PodSpec{NodeName: node}}
+	}
+	node1 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	foo := types.NamespacedName{Namespace: "default", Name: "foo"}
+	bar := types.NamespacedName{Namespace: "default", Name: "bar"}
+
+	index.Set(foo, []client.Object{podOn("foo-0", "node-1"), podOn("foo-1", "node-2"), podOn("foo-2", "")})
+	index.Set(bar, []client.Object{podOn("bar-0", "node-1")})
+	if requests := index.Requests(node1); len(requests) != 2 {
+		t.Errorf("got requests %v, want foo and bar", requests)
+	}
+
+	// XSets whose targets moved off the node are not enqueued
+	index.Set(bar, []client.Object{podOn("bar-0", "node-3")})
+	if requests := index.Requests(node1); len(requests) != 1 || requests[0].NamespacedName != foo {
+		t.Errorf("got requests %v, want foo", requests)
+	}
+
+	index.Delete(foo)
+	index.Set(bar, nil)
+	if len(index.nodes) != 0 || len(index.xsets) != 0 {
+		t.Errorf("got index %v and %v, want empty", index.nodes, index.xsets)
+	}
+}
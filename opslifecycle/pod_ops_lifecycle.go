@@ -0,0 +1,103 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opslifecycle
+
+import (
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+var (
+	_ api.LifecycleAdapterGetter = &PodOpsLifecycle{}
+	_ api.AvailabilityAdapter    = &PodOpsLifecycle{}
+	_ api.LifecycleAdapter       = &podOpsLifecycleAdapter{}
+)
+
+// PodOpsLifecycle wires scaling in, updating and replacing of Pod targets through PodOpsLifecycle of KusionStack
+// operating. After lifecycle begins, PodOpsLifecycle controller takes traffic off the Pod and then grants the operate
+// label, which XSet waits for before deleting or updating the Pod. Origin Pods of replacing are deleted through the
+// scale in lifecycle once new Pods are service available.
+//
+// XSetController embeds it to implement LifecycleAdapterGetter and AvailabilityAdapter, and must keep the default
+// labels of XSetLabelAnnotationManager which are shared with PodOpsLifecycle.
+type PodOpsLifecycle struct {
+	labelAnnoManager api.XSetLabelAnnotationManager
+	scaleIn          *podOpsLifecycleAdapter
+	update           *podOpsLifecycleAdapter
+}
+
+// NewPodOpsLifecycle returns PodOpsLifecycle with id identifying the lifecycle of XSet on Pods, e.g., the lower case
+// kind of XSet. Default XSetLabelAnnotationManager is used if labelAnnoManager is nil.
+func NewPodOpsLifecycle(id string, labelAnnoManager api.XSetLabelAnnotationManager) *PodOpsLifecycle {
+	if labelAnnoManager == nil {
+		labelAnnoManager = api.NewXSetLabelAnnotationManager(nil)
+	}
+	return &PodOpsLifecycle{
+		labelAnnoManager: labelAnnoManager,
+		scaleIn:          &podOpsLifecycleAdapter{id: id, operationType: api.OpsLifecycleTypeScaleIn, labelAnnoManager: labelAnnoManager},
+		update:           &podOpsLifecycleAdapter{id: id, operationType: api.OpsLifecycleTypeUpdate, labelAnnoManager: labelAnnoManager},
+	}
+}
+
+func (p *PodOpsLifecycle) GetScaleInOpsLifecycleAdapter() api.LifecycleAdapter {
+	return p.scaleIn
+}
+
+func (p *PodOpsLifecycle) GetUpdateOpsLifecycleAdapter() api.LifecycleAdapter {
+	return p.update
+}
+
+// CheckTargetAvailable returns true once Pod is marked service available by PodOpsLifecycle controller, i.e., Pod is
+// ready and its traffic is on.
+func (p *PodOpsLifecycle) CheckTargetAvailable(object client.Object) (bool, *time.Duration) {
+	_, available := p.labelAnnoManager.Get(object, api.ServiceAvailableLabel)
+	return available, nil
+}
+
+type podOpsLifecycleAdapter struct {
+	id               string
+	operationType    api.OperationType
+	labelAnnoManager api.XSetLabelAnnotationManager
+}
+
+func (a *podOpsLifecycleAdapter) GetID() string {
+	return a.id
+}
+
+func (a *podOpsLifecycleAdapter) GetType() api.OperationType {
+	return a.operationType
+}
+
+func (a *podOpsLifecycleAdapter) AllowMultiType() bool {
+	return true
+}
+
+// WhenBegin marks Pods to scale in as preparing delete, so that PodOpsLifecycle controller takes their traffic off
+// without expecting them back
+func (a *podOpsLifecycleAdapter) WhenBegin(target client.Object) (bool, error) {
+	if a.operationType == api.OpsLifecycleTypeScaleIn {
+		return WhenBeginDelete(a.labelAnnoManager, target)
+	}
+	return false, nil
+}
+
+func (a *podOpsLifecycleAdapter) WhenFinish(_ client.Object) (bool, error) {
+	return false, nil
+}
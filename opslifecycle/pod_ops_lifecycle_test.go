@@ -0,0 +1,98 @@
+/*
+ Copyright 2024-2025 The KusionStack Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package opslifecycle
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+func TestPodOpsLifecycle(t *testing.T) {
+	ctx := context.TODO()
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: name}}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(newPod("foo-0"), newPod("foo-1")).Build()
+	mgr := api.NewXSetLabelAnnotationManager(nil)
+	lifecycle := NewPodOpsLifecycle("xset", nil)
+	scaleIn, update := lifecycle.GetScaleInOpsLifecycleAdapter(), lifecycle.GetUpdateOpsLifecycleAdapter()
+	if scaleIn.GetID() != "xset" || scaleIn.GetType() != api.OpsLifecycleTypeScaleIn ||
+		update.GetID() != "xset" || update.GetType() != api.OpsLifecycleTypeUpdate {
+		t.Fatalf("got adapters %s/%s and %s/%s, want scale in and update of xset",
+			scaleIn.GetID(), scaleIn.GetType(), update.GetID(), update.GetType())
+	}
+
+	// Pods to scale in are marked preparing delete, while the ones to update are expected back
+	scaledIn, updated := &corev1.Pod{}, &corev1.Pod{}
+	for pod, adapter := range map[*corev1.Pod]api.LifecycleAdapter{scaledIn: scaleIn, updated: update} {
+		name := "foo-0"
+		if adapter == update {
+			name = "foo-1"
+		}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: name}, pod); err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := Begin(ctx, mgr, c, adapter, pod); err != nil || !ok {
+			t.Fatalf("Begin() = %v, %v for %s", ok, err, adapter.GetType())
+		}
+		if !IsDuringOps(mgr, adapter, pod) {
+			t.Errorf("Pod %s is not during %s ops", pod.Name, adapter.GetType())
+		}
+	}
+	if _, ok := mgr.Get(scaledIn, api.PreparingDeleteLabel); !ok {
+		t.Error("Pod to scale in is not marked preparing delete")
+	}
+	if _, ok := mgr.Get(updated, api.PreparingDeleteLabel); ok {
+		t.Error("Pod to update is marked preparing delete")
+	}
+
+	// operation is allowed once PodOpsLifecycle controller grants the operate label
+	if _, allow := AllowOps(mgr, scaleIn, 0, scaledIn); allow {
+		t.Error("operation is allowed before traffic is off")
+	}
+	setOperate(mgr, scaleIn, scaledIn)
+	if _, allow := AllowOps(mgr, scaleIn, 0, scaledIn); !allow {
+		t.Error("operation is not allowed after operate label granted")
+	}
+
+	if ok, err := Finish(ctx, mgr, c, update, updated); err != nil || !ok {
+		t.Fatalf("Finish() = %v, %v", ok, err)
+	}
+	if IsDuringOps(mgr, update, updated) {
+		t.Error("Pod is still during update ops after finished")
+	}
+}
+
+func TestPodOpsLifecycleCheckTargetAvailable(t *testing.T) {
+	mgr := api.NewXSetLabelAnnotationManager(nil)
+	lifecycle := NewPodOpsLifecycle("xset", mgr)
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}}}
+	if available, _ := lifecycle.CheckTargetAvailable(pod); available {
+		t.Error("Pod is available before marked service available")
+	}
+	mgr.Set(pod, api.ServiceAvailableLabel, "true")
+	if available, recheckAfter := lifecycle.CheckTargetAvailable(pod); !available || recheckAfter != nil {
+		t.Errorf("CheckTargetAvailable() = %v, %v, want available once marked service available", available, recheckAfter)
+	}
+}
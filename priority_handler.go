@@ -0,0 +1,99 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+const (
+	// LowPriorityEnqueueQPS is the rate at which low priority events of XSets are enqueued with PriorityQueueAdapter
+	LowPriorityEnqueueQPS = 10
+	// LowPriorityEnqueueBurst is the burst of low priority events of XSets enqueued without delay
+	LowPriorityEnqueueBurst = 100
+)
+
+var _ handler.EventHandler = &enqueueXSetWithPriority{}
+
+// enqueueXSetWithPriority enqueues XSet of the event into the workqueue of controller. XSets being created, deleted,
+// with spec changed or resync requested are enqueued at once, while low priority events, e.g., periodic resyncs and
+// status updates of steady-state XSets, are paced by lowPriorityLimiter if set, so that a flood of them never delays
+// urgent XSets behind thousands of no-op reconciles.
+type enqueueXSetWithPriority struct {
+	lowPriorityLimiter workqueue.RateLimiter
+}
+
+// newEnqueueXSetWithPriority returns handler enqueuing XSets, which paces low priority events if PriorityQueueAdapter
+// is implemented and opted in
+func newEnqueueXSetWithPriority(xsetController api.XSetController) *enqueueXSetWithPriority {
+	e := &enqueueXSetWithPriority{}
+	if adapter, ok := xsetController.(api.PriorityQueueAdapter); ok && adapter.UsePriorityQueue() {
+		e.lowPriorityLimiter = &workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(LowPriorityEnqueueQPS), LowPriorityEnqueueBurst)}
+	}
+	return e
+}
+
+func (e *enqueueXSetWithPriority) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	if evt.Object == nil {
+		return
+	}
+	q.Add(requestFor(evt.Object.GetNamespace(), evt.Object.GetName()))
+}
+
+func (e *enqueueXSetWithPriority) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	if evt.ObjectNew == nil {
+		return
+	}
+	req := requestFor(evt.ObjectNew.GetNamespace(), evt.ObjectNew.GetName())
+	if e.lowPriorityLimiter == nil || isUrgentXSetUpdate(evt) {
+		q.Add(req)
+		return
+	}
+	q.AddAfter(req, e.lowPriorityLimiter.When(req))
+}
+
+func (e *enqueueXSetWithPriority) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	if evt.Object == nil {
+		return
+	}
+	q.Add(requestFor(evt.Object.GetNamespace(), evt.Object.GetName()))
+}
+
+func (e *enqueueXSetWithPriority) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	if evt.Object == nil {
+		return
+	}
+	q.Add(requestFor(evt.Object.GetNamespace(), evt.Object.GetName()))
+}
+
+// isUrgentXSetUpdate returns true if XSet is being deleted, with spec changed or resync requested
+func isUrgentXSetUpdate(evt event.UpdateEvent) bool {
+	return evt.ObjectNew.GetDeletionTimestamp() != nil ||
+		evt.ObjectOld == nil || evt.ObjectOld.GetGeneration() != evt.ObjectNew.GetGeneration() ||
+		evt.ObjectOld.GetAnnotations()[api.XSetResyncAnnotationKey] != evt.ObjectNew.GetAnnotations()[api.XSetResyncAnnotationKey]
+}
+
+func requestFor(namespace, name string) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+}
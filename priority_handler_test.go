@@ -0,0 +1,85 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// delayRecordingQueue records delays of items added, zero for items added at once
+type delayRecordingQueue struct {
+	workqueue.RateLimitingInterface
+	delays []time.Duration
+}
+
+func (q *delayRecordingQueue) Add(interface{}) { q.delays = append(q.delays, 0) }
+
+func (q *delayRecordingQueue) AddAfter(_ interface{}, duration time.Duration) {
+	q.delays = append(q.delays, duration)
+}
+
+type priorityQueueController struct {
+	api.XSetController
+}
+
+func (c *priorityQueueController) UsePriorityQueue() bool { return true }
+
+func TestEnqueueXSetWithPriority(t *testing.T) {
+	xset := func(generation int64) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", Generation: generation}}
+	}
+	steady := event.UpdateEvent{ObjectOld: xset(1), ObjectNew: xset(1)}
+	specChanged := event.UpdateEvent{ObjectOld: xset(1), ObjectNew: xset(2)}
+
+	// low priority events are enqueued at once unless opted in
+	q := &delayRecordingQueue{}
+	e := newEnqueueXSetWithPriority(&migrationController{})
+	for range LowPriorityEnqueueBurst + 1 {
+		e.Update(steady, q)
+	}
+	for _, delay := range q.delays {
+		if delay != 0 {
+			t.Fatalf("got low priority event delayed by %v without PriorityQueueAdapter", delay)
+		}
+	}
+
+	// low priority events beyond burst are paced, while urgent ones are enqueued at once
+	q = &delayRecordingQueue{}
+	e = newEnqueueXSetWithPriority(&priorityQueueController{})
+	for range LowPriorityEnqueueBurst + 1 {
+		e.Update(steady, q)
+	}
+	e.Update(specChanged, q)
+	e.Create(event.CreateEvent{Object: xset(1)}, q)
+	if delay := q.delays[LowPriorityEnqueueBurst-1]; delay != 0 {
+		t.Errorf("got low priority event within burst delayed by %v", delay)
+	}
+	if delay := q.delays[LowPriorityEnqueueBurst]; delay <= 0 {
+		t.Errorf("expect low priority event beyond burst delayed")
+	}
+	if urgent := q.delays[LowPriorityEnqueueBurst+1:]; urgent[0] != 0 || urgent[1] != 0 {
+		t.Errorf("got urgent events delayed by %v, want enqueued at once", urgent)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"kusionstack.io/kube-xset/resourcecontexts"
+)
+
+var _ manager.LeaderElectionRunnable = &resourceContextJanitor{}
+
+// resourceContextJanitor releases IDs of XSets deleted without finalizer every FullSyncInterval. Reconcile only
+// releases IDs in the ResourceContext named after a deleted XSet when its deletion is observed, which misses XSets
+// deleted while controller is down, and the ones sharing ResourceContexts by spec.scaleStrategy.context.
+type resourceContextJanitor struct {
+	resourceContextControl resourcecontexts.ResourceContextControl
+	namespaces             sets.String
+	interval               time.Duration
+	logger                 logr.Logger
+}
+
+func (j *resourceContextJanitor) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, j.releaseOrphanedIDs, j.interval)
+	return nil
+}
+
+func (j *resourceContextJanitor) NeedLeaderElection() bool {
+	return true
+}
+
+func (j *resourceContextJanitor) releaseOrphanedIDs(ctx context.Context) {
+	namespaces := j.namespaces.List()
+	if len(namespaces) == 0 {
+		// all namespaces
+		namespaces = []string{""}
+	}
+	for _, namespace := range namespaces {
+		if err := j.resourceContextControl.ReleaseOrphanedIDs(ctx, namespace); err != nil {
+			j.logger.Error(err, "failed to release orphaned IDs", "namespace", namespace)
+		}
+	}
+}
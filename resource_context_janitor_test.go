@@ -0,0 +1,58 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"kusionstack.io/kube-xset/testing/fake"
+)
+
+func TestResourceContextJanitor(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespaces sets.String
+		want       []string
+	}{
+		{name: "all namespaces", want: []string{""}},
+		{name: "watched namespaces", namespaces: sets.NewString("foo", "bar"), want: []string{"bar", "foo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			control := fake.NewResourceContextControl(&statusController{})
+			j := &resourceContextJanitor{
+				resourceContextControl: control,
+				namespaces:             tt.namespaces,
+				logger:                 logr.Discard(),
+			}
+			j.releaseOrphanedIDs(context.TODO())
+
+			var namespaces []string
+			for _, call := range control.Calls() {
+				namespaces = append(namespaces, call.Args[0].(string))
+			}
+			if !reflect.DeepEqual(namespaces, tt.want) {
+				t.Errorf("got orphaned IDs released in %v, want %v", namespaces, tt.want)
+			}
+		})
+	}
+}
@@ -34,6 +34,11 @@ var defaultResourceContextKeys = map[api.ResourceContextKeyEnum]string{
 	api.EnumScaleInContextDataKey:               "ScaleIn",
 	api.EnumReplaceNewTargetIDContextDataKey:    "ReplaceNewTargetID",
 	api.EnumReplaceOriginTargetIDContextDataKey: "ReplaceOriginTargetID",
+	api.EnumNodeNameContextDataKey:              "NodeName",
+	api.EnumZoneContextDataKey:                  "Zone",
+	api.EnumPostCreateContextDataKey:            "PostCreate",
+	api.EnumPostDeleteContextDataKey:            "PostDelete",
+	api.EnumSubsetContextDataKey:                "Subset",
 }
 
 type ResourceContextAdapterGetter struct{}
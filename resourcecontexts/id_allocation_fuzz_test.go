@@ -0,0 +1,97 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resourcecontexts
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	appsv1alpha1 "kusionstack.io/kube-api/apps/v1alpha1"
+	"kusionstack.io/kube-utils/controller/expectations"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/testing/idsim"
+)
+
+// simXSetController keeps specs of xsets by name, so that replicas scaled by simulator are read by allocation
+type simXSetController struct {
+	api.XSetController
+	specs map[string]*api.XSetSpec
+}
+
+func (m *simXSetController) GetXSetSpec(xset api.XSetObject) *api.XSetSpec {
+	return m.specs[xset.GetName()]
+}
+
+func (m *simXSetController) XSetMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}
+}
+
+func (m *simXSetController) NewXObject() client.Object {
+	return &appsv1.Deployment{}
+}
+
+func newIDSimulator() *idsim.Simulator {
+	scheme := runtime.NewScheme()
+	_ = appsv1alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	xsetController := &simXSetController{specs: map[string]*api.XSetSpec{}}
+	var xsets []api.XSetObject
+	// three xsets share a ResourceContext, and another one allocates IDs from its own
+	for _, owner := range []struct{ name, pool string }{{"foo", "shared"}, {"bar", "shared"}, {"baz", "shared"}, {"qux", ""}} {
+		name, pool := owner.name, owner.pool
+		xsetController.specs[name] = &api.XSetSpec{
+			Replicas:      pointer.Int32(0),
+			ScaleStrategy: api.ScaleStrategy{Context: pool},
+		}
+		xsets = append(xsets, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}})
+	}
+	r := &RealResourceContextControl{
+		Client:                 c,
+		EventRecorder:          &record.FakeRecorder{},
+		xsetController:         xsetController,
+		resourceContextAdapter: &DefaultResourceContextAdapter{},
+		resourceContextKeys:    defaultResourceContextKeys,
+		resourceContextGVK:     appsv1alpha1.SchemeGroupVersion.WithKind("ResourceContext"),
+		cacheExpectations:      expectations.NewxCacheExpectations(c, scheme, clock.RealClock{}),
+		xsetLabelManager:       api.NewXSetLabelAnnotationManager(nil),
+	}
+	return idsim.NewSimulator(r, xsetController, 8, xsets...)
+}
+
+func FuzzIDAllocation(f *testing.F) {
+	for seed := int64(0); seed < 8; seed++ {
+		f.Add(seed, uint8(64))
+	}
+	f.Fuzz(func(t *testing.T, seed int64, steps uint8) {
+		s := newIDSimulator()
+		ops := s.RandomOps(rand.New(rand.NewSource(seed)), int(steps))
+		if err := s.Run(context.TODO(), ops); err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+	})
+}
@@ -18,14 +18,21 @@ package resourcecontexts
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apiservererrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
@@ -38,20 +45,42 @@ import (
 	"kusionstack.io/kube-xset/xcontrol"
 )
 
+// ErrPoolExhausted indicates that the context pool reaches its capacity, and fewer IDs than replicas are allocated
+var ErrPoolExhausted = errors.New("context pool exhausted")
+
 type ResourceContextControl interface {
 	AllocateID(ctx context.Context, xsetObject api.XSetObject, currentRevision, updatedRevision string, replicas int, objs []client.Object) (map[int]*api.ContextDetail, error)
-	CleanUnusedIDs(ctx context.Context, xsetObject api.XSetObject, objs []client.Object) error
+	// CleanUnusedIDs reclaims IDs owned by xset but not used by any target, and returns the reclaimed IDs.
+	// With dryRun, the IDs to reclaim are only reported by event and ResourceContext is left untouched.
+	CleanUnusedIDs(ctx context.Context, xsetObject api.XSetObject, objs []client.Object, dryRun bool) ([]int, error)
 	UpdateToTargetContext(ctx context.Context, xsetObject api.XSetObject, ownedIDs map[int]*api.ContextDetail) error
+	// ReleaseDeletedOwnerIDs releases IDs owned by a deleted xset, which is not able to reclaim them before deletion
+	// in finalizer-less mode. Only the ResourceContext named after the xset is cleaned, since its spec is gone.
+	ReleaseDeletedOwnerIDs(ctx context.Context, namespace, name string) error
+	// ReleaseOrphanedIDs releases IDs of xsets no longer existing, in ResourceContexts of namespace, or of all namespaces
+	// if empty. It reclaims IDs missed by ReleaseDeletedOwnerIDs, e.g., of xsets deleted while controller is down, or
+	// in ResourceContexts shared by spec.scaleStrategy.context. Only ResourceContexts labelled by
+	// ResourceContextOwnerKindLabelKey with the kind of xset are cleaned.
+	ReleaseOrphanedIDs(ctx context.Context, namespace string) error
+	// GetCoOwnedIDs returns IDs owned by other xsets sharing ResourceContext of xset as an ID pool
+	GetCoOwnedIDs(ctx context.Context, xsetObject api.XSetObject) (sets.Int, error)
 	ExtractAvailableContexts(diff int, ownedIDs map[int]*api.ContextDetail, targetInstanceIDSet sets.Int) []*api.ContextDetail
 	DecideContextRevisionAfterCreate(contextDetail *api.ContextDetail, updatedRevision *appsv1.ControllerRevision, createErr error) bool
 	Get(detail *api.ContextDetail, enum api.ResourceContextKeyEnum) (string, bool)
 	Contains(detail *api.ContextDetail, enum api.ResourceContextKeyEnum, value string) bool
 	Put(detail *api.ContextDetail, enum api.ResourceContextKeyEnum, value string)
 	Remove(detail *api.ContextDetail, enum api.ResourceContextKeyEnum)
+	ProjectToTarget(detail *api.ContextDetail, target client.Object)
+	// FlushPendingWrites writes IDs released but deferred by ResourceContextWriteDebounceAdapter, and returns the
+	// duration after which they are allowed to be written if debounce window has not expired.
+	FlushPendingWrites(ctx context.Context, xsetObject api.XSetObject) (*time.Duration, error)
+	// ForgetOwner drops writes deferred for a deleted xset, which are never flushed
+	ForgetOwner(namespace, name string)
 }
 
 type RealResourceContextControl struct {
 	client.Client
+	apiReader client.Reader
 	record.EventRecorder
 	xsetController         api.XSetController
 	resourceContextAdapter api.ResourceContextAdapter
@@ -59,6 +88,7 @@ type RealResourceContextControl struct {
 	resourceContextGVK     schema.GroupVersionKind
 	cacheExpectations      expectations.CacheExpectationsInterface
 	xsetLabelManager       api.XSetLabelAnnotationManager
+	debouncer              *writeDebouncer
 }
 
 func NewRealResourceContextControl(
@@ -69,33 +99,61 @@ func NewRealResourceContextControl(
 	cacheExpectations expectations.CacheExpectationsInterface,
 	xsetLabelManager api.XSetLabelAnnotationManager,
 ) ResourceContextControl {
-	resourceContextKeys := resourceContextAdapter.GetContextKeys()
-	if resourceContextKeys == nil {
-		resourceContextKeys = defaultResourceContextKeys
-	}
-
 	return &RealResourceContextControl{
 		Client:                 mixin.Client,
+		apiReader:              mixin.APIReader,
 		EventRecorder:          mixin.Recorder,
 		xsetController:         xsetController,
 		resourceContextAdapter: resourceContextAdapter,
-		resourceContextKeys:    resourceContextKeys,
+		resourceContextKeys:    GetResourceContextKeys(resourceContextAdapter),
 		resourceContextGVK:     resourceContextGVK,
 		cacheExpectations:      cacheExpectations,
 		xsetLabelManager:       xsetLabelManager,
+		debouncer:              newWriteDebouncer(),
 	}
 }
 
+// GetResourceContextKeys returns keys provided by adapter, whose optional keys fall back to default ones
+func GetResourceContextKeys(resourceContextAdapter api.ResourceContextAdapter) map[api.ResourceContextKeyEnum]string {
+	resourceContextKeys := resourceContextAdapter.GetContextKeys()
+	if resourceContextKeys == nil {
+		return defaultResourceContextKeys
+	}
+	resourceContextKeys = maps.Clone(resourceContextKeys)
+	for enum, key := range defaultResourceContextKeys {
+		if _, ok := resourceContextKeys[enum]; !ok && int(enum) >= api.EnumContextKeyNum {
+			resourceContextKeys[enum] = key
+		}
+	}
+	return resourceContextKeys
+}
+
 func (r *RealResourceContextControl) AllocateID(
 	ctx context.Context,
 	xsetObject api.XSetObject,
 	currentRevision, updatedRevision string,
 	replicas int, objs []client.Object,
 ) (map[int]*api.ContextDetail, error) {
-	contextName := getContextName(r.xsetController, xsetObject)
+	ownedIDs, err := r.allocateID(ctx, xsetObject, currentRevision, updatedRevision, replicas, objs)
+	if r.retryFromAPIServer(ctx, err) {
+		return r.allocateID(withReadFromAPIServer(ctx), xsetObject, currentRevision, updatedRevision, replicas, objs)
+	}
+	return ownedIDs, err
+}
+
+func (r *RealResourceContextControl) allocateID(
+	ctx context.Context,
+	xsetObject api.XSetObject,
+	currentRevision, updatedRevision string,
+	replicas int, objs []client.Object,
+) (map[int]*api.ContextDetail, error) {
+	contextName := r.getContextName(xsetObject)
+	unlock := contextLocks.lock(xsetObject.GetNamespace(), contextName)
+	defer unlock()
+
 	targetContext := r.resourceContextAdapter.NewResourceContext()
 	notFound := false
-	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: xsetObject.GetNamespace(), Name: contextName}, targetContext); err != nil {
+	if err := r.getResourceContext(ctx, types.NamespacedName{Namespace: xsetObject.GetNamespace(), Name: contextName}, targetContext); err != nil {
 		if !apiservererrors.IsNotFound(err) {
 			return nil, fmt.Errorf("fail to find ResourceContext %s/%s for owner %s: %w", xsetObject.GetNamespace(), contextName, xsetObject.GetName(), err)
 		}
@@ -122,6 +180,8 @@ func (r *RealResourceContextControl) AllocateID(
 		}
 	}
 
+	r.hidePendingReleases(xsetObject, ownedIDs, existingIDs)
+
 	// get unrecorded model ids
 	unRecordedIDs := r.getUnRecordTargetIDs(existingIDs, objs, currentRevision)
 
@@ -132,21 +192,42 @@ func (r *RealResourceContextControl) AllocateID(
 
 	ownedIDs = r.doAllocateID(ownedIDs, existingIDs, unRecordedIDs, replicas, xsetObject.GetName(), xsetSpec, currentRevision, updatedRevision)
 
+	var err error
 	if notFound {
-		return ownedIDs, r.doCreateTargetContext(ctx, xsetObject, ownedIDs)
+		err = r.doCreateTargetContext(ctx, xsetObject, ownedIDs)
+	} else {
+		err = r.doUpdateTargetContext(ctx, xsetObject, ownedIDs, targetContext)
+	}
+	if err != nil {
+		return ownedIDs, err
 	}
 
-	return ownedIDs, r.doUpdateTargetContext(ctx, xsetObject, ownedIDs, targetContext)
+	if len(ownedIDs) < replicas {
+		return ownedIDs, fmt.Errorf("%w: only %d of %d IDs are allocated from ResourceContext %s/%s with capacity %d",
+			ErrPoolExhausted, len(ownedIDs), replicas, xsetObject.GetNamespace(), contextName, ptr.Deref(xsetSpec.ScaleStrategy.ContextCapacity, 0))
+	}
+	return ownedIDs, nil
+}
+
+func (r *RealResourceContextControl) CleanUnusedIDs(ctx context.Context, xsetObject api.XSetObject, objs []client.Object, dryRun bool) ([]int, error) {
+	ids, err := r.cleanUnusedIDs(ctx, xsetObject, objs, dryRun)
+	if r.retryFromAPIServer(ctx, err) {
+		return r.cleanUnusedIDs(withReadFromAPIServer(ctx), xsetObject, objs, dryRun)
+	}
+	return ids, err
 }
 
-func (r *RealResourceContextControl) CleanUnusedIDs(ctx context.Context, xsetObject api.XSetObject, objs []client.Object) error {
-	contextName := getContextName(r.xsetController, xsetObject)
+func (r *RealResourceContextControl) cleanUnusedIDs(ctx context.Context, xsetObject api.XSetObject, objs []client.Object, dryRun bool) ([]int, error) {
+	contextName := r.getContextName(xsetObject)
+	unlock := contextLocks.lock(xsetObject.GetNamespace(), contextName)
+	defer unlock()
+
 	targetContext := r.resourceContextAdapter.NewResourceContext()
-	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: xsetObject.GetNamespace(), Name: contextName}, targetContext); err != nil {
+	if err := r.getResourceContext(ctx, types.NamespacedName{Namespace: xsetObject.GetNamespace(), Name: contextName}, targetContext); err != nil {
 		if !apiservererrors.IsNotFound(err) {
-			return fmt.Errorf("fail to find ResourceContext %s/%s for owner %s: %w", xsetObject.GetNamespace(), contextName, xsetObject.GetName(), err)
+			return nil, fmt.Errorf("fail to find ResourceContext %s/%s for owner %s: %w", xsetObject.GetNamespace(), contextName, xsetObject.GetName(), err)
 		}
-		return nil
+		return nil, nil
 	}
 
 	resourceContextSpec := r.resourceContextAdapter.GetResourceContextSpec(targetContext)
@@ -162,10 +243,11 @@ func (r *RealResourceContextControl) CleanUnusedIDs(ctx context.Context, xsetObj
 			ownedIDs[detail.ID] = detail
 		}
 	}
+	r.hidePendingReleases(xsetObject, ownedIDs)
 	needCleanCount = len(ownedIDs) - maxInt(int(ptr.Deref(xsetSpec.Replicas, 0)), len(objs))
 
 	if needCleanCount <= 0 {
-		return nil
+		return nil, nil
 	}
 
 	for i := range objs {
@@ -174,30 +256,40 @@ func (r *RealResourceContextControl) CleanUnusedIDs(ctx context.Context, xsetObj
 		}
 	}
 
+	_, postDeleteEnabled := r.xsetController.(api.PostDeleteHookAdapter)
 	for i := range ownedIDs {
 		id := ownedIDs[i].ID
 		if _, exist := currentIDs[id]; exist {
 			continue
 		}
+		// hold IDs until PostDelete hook of their deleted targets succeeded
+		if value, _ := r.Get(ownedIDs[i], api.EnumPostDeleteContextDataKey); postDeleteEnabled && IsPostDeletePending(value) {
+			continue
+		}
 		allowDeleteIDs = append(allowDeleteIDs, id)
 	}
 
 	if len(allowDeleteIDs) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	if len(allowDeleteIDs) < needCleanCount {
 		needCleanCount = len(allowDeleteIDs)
 	}
 
-	deletedIDs := map[int]*api.ContextDetail{}
-	for i := range needCleanCount {
-		id := allowDeleteIDs[i]
+	// reclaim larger IDs first, so that the result is stable across reconciles
+	sort.Sort(sort.Reverse(sort.IntSlice(allowDeleteIDs)))
+	deletedIDs := allowDeleteIDs[:needCleanCount]
+	if dryRun {
+		r.EventRecorder.Eventf(xsetObject, corev1.EventTypeNormal, "ResourceContextCleanDryRun", "would clean unused IDs %v from ResourceContext %s/%s", deletedIDs, xsetObject.GetNamespace(), contextName)
+		return deletedIDs, nil
+	}
+
+	for _, id := range deletedIDs {
 		delete(ownedIDs, id)
-		deletedIDs[id] = ownedIDs[id]
 	}
-	r.EventRecorder.Eventf(xsetObject, corev1.EventTypeWarning, "ResourceContextClean", "clean %v unused IDs from ResourceContext %s/%s", deletedIDs, xsetObject.GetNamespace(), contextName)
-	return r.doUpdateTargetContext(ctx, xsetObject, ownedIDs, targetContext)
+	r.EventRecorder.Eventf(xsetObject, corev1.EventTypeWarning, "ResourceContextClean", "clean unused IDs %v from ResourceContext %s/%s", deletedIDs, xsetObject.GetNamespace(), contextName)
+	return deletedIDs, r.doUpdateTargetContext(ctx, xsetObject, ownedIDs, targetContext)
 }
 
 func (r *RealResourceContextControl) UpdateToTargetContext(
@@ -205,9 +297,24 @@ func (r *RealResourceContextControl) UpdateToTargetContext(
 	xSetObject api.XSetObject,
 	ownedIDs map[int]*api.ContextDetail,
 ) error {
-	contextName := getContextName(r.xsetController, xSetObject)
+	err := r.updateToTargetContext(ctx, xSetObject, ownedIDs)
+	if r.retryFromAPIServer(ctx, err) {
+		return r.updateToTargetContext(withReadFromAPIServer(ctx), xSetObject, ownedIDs)
+	}
+	return err
+}
+
+func (r *RealResourceContextControl) updateToTargetContext(
+	ctx context.Context,
+	xSetObject api.XSetObject,
+	ownedIDs map[int]*api.ContextDetail,
+) error {
+	contextName := r.getContextName(xSetObject)
+	unlock := contextLocks.lock(xSetObject.GetNamespace(), contextName)
+	defer unlock()
+
 	targetContext := r.resourceContextAdapter.NewResourceContext()
-	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: xSetObject.GetNamespace(), Name: contextName}, targetContext); err != nil {
+	if err := r.getResourceContext(ctx, types.NamespacedName{Namespace: xSetObject.GetNamespace(), Name: contextName}, targetContext); err != nil {
 		if !apiservererrors.IsNotFound(err) {
 			return fmt.Errorf("fail to find ResourceContext %s/%s: %w", xSetObject.GetNamespace(), contextName, err)
 		}
@@ -221,9 +328,182 @@ func (r *RealResourceContextControl) UpdateToTargetContext(
 		}
 	}
 
+	// skip writing unchanged contexts, and coalesce writes which only release IDs within debounce window
+	if released, releaseOnly := r.releasedIDs(xSetObject, targetContext, ownedIDs); releaseOnly {
+		key := clientutil.ObjectKeyString(xSetObject)
+		if released.Len() == 0 {
+			r.debouncer.cancelPending(key)
+			return nil
+		}
+		if window := r.getWriteDebounceWindow(xSetObject); window > 0 && r.debouncer.tryDefer(key, window, released) {
+			return nil
+		}
+	}
+
 	return r.doUpdateTargetContext(ctx, xSetObject, ownedIDs, targetContext)
 }
 
+func (r *RealResourceContextControl) ReleaseDeletedOwnerIDs(ctx context.Context, namespace, name string) error {
+	err := r.releaseDeletedOwnerIDs(ctx, namespace, name)
+	if r.retryFromAPIServer(ctx, err) {
+		return r.releaseDeletedOwnerIDs(withReadFromAPIServer(ctx), namespace, name)
+	}
+	return err
+}
+
+func (r *RealResourceContextControl) releaseDeletedOwnerIDs(ctx context.Context, namespace, name string) error {
+	xsetObject := r.xsetController.NewXSetObject()
+	xsetObject.SetNamespace(namespace)
+	xsetObject.SetName(name)
+	contextName := r.getContextName(xsetObject)
+	unlock := contextLocks.lock(namespace, contextName)
+	defer unlock()
+	r.debouncer.forget(clientutil.ObjectKeyString(xsetObject))
+
+	targetContext := r.resourceContextAdapter.NewResourceContext()
+	if err := r.getResourceContext(ctx, types.NamespacedName{Namespace: namespace, Name: contextName}, targetContext); err != nil {
+		if apiservererrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("fail to find ResourceContext %s/%s: %w", namespace, contextName, err)
+	}
+
+	resourceContextSpec := r.resourceContextAdapter.GetResourceContextSpec(targetContext)
+	var remaining []api.ContextDetail
+	for i := range resourceContextSpec.Contexts {
+		if r.Contains(&resourceContextSpec.Contexts[i], api.EnumOwnerContextKey, name) {
+			continue
+		}
+		remaining = append(remaining, resourceContextSpec.Contexts[i])
+	}
+	if err := r.writeRemainingContexts(ctx, targetContext, resourceContextSpec, remaining); err != nil {
+		return fmt.Errorf("fail to release IDs of deleted owner %s from ResourceContext %s/%s: %w", name, namespace, contextName, err)
+	}
+	return nil
+}
+
+func (r *RealResourceContextControl) ReleaseOrphanedIDs(ctx context.Context, namespace string) error {
+	list := &metav1.PartialObjectMetadataList{}
+	list.SetGroupVersionKind(r.resourceContextGVK.GroupVersion().WithKind(r.resourceContextGVK.Kind + "List"))
+	if err := r.listResourceContexts(ctx, list, client.InNamespace(namespace),
+		client.MatchingLabels{api.ResourceContextOwnerKindLabelKey: r.xsetController.XSetMeta().Kind}); err != nil {
+		return fmt.Errorf("fail to list ResourceContexts: %w", err)
+	}
+
+	var errs []error
+	for i := range list.Items {
+		key := types.NamespacedName{Namespace: list.Items[i].Namespace, Name: list.Items[i].Name}
+		err := r.releaseOrphanedIDs(ctx, key)
+		if r.retryFromAPIServer(ctx, err) {
+			err = r.releaseOrphanedIDs(withReadFromAPIServer(ctx), key)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (r *RealResourceContextControl) releaseOrphanedIDs(ctx context.Context, key types.NamespacedName) error {
+	unlock := contextLocks.lock(key.Namespace, key.Name)
+	defer unlock()
+
+	targetContext := r.resourceContextAdapter.NewResourceContext()
+	if err := r.getResourceContext(ctx, key, targetContext); err != nil {
+		if apiservererrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("fail to find ResourceContext %s: %w", key, err)
+	}
+
+	resourceContextSpec := r.resourceContextAdapter.GetResourceContextSpec(targetContext)
+	ownerExists := map[string]bool{}
+	var remaining []api.ContextDetail
+	for i := range resourceContextSpec.Contexts {
+		owner, ok := r.Get(&resourceContextSpec.Contexts[i], api.EnumOwnerContextKey)
+		if !ok || owner == "" {
+			remaining = append(remaining, resourceContextSpec.Contexts[i])
+			continue
+		}
+		exists, checked := ownerExists[owner]
+		if !checked {
+			var err error
+			if exists, err = r.xsetExists(ctx, types.NamespacedName{Namespace: key.Namespace, Name: owner}); err != nil {
+				return err
+			}
+			ownerExists[owner] = exists
+		}
+		if exists {
+			remaining = append(remaining, resourceContextSpec.Contexts[i])
+		}
+	}
+	if err := r.writeRemainingContexts(ctx, targetContext, resourceContextSpec, remaining); err != nil {
+		return fmt.Errorf("fail to release orphaned IDs from ResourceContext %s: %w", key, err)
+	}
+	return nil
+}
+
+// writeRemainingContexts updates ResourceContext with remaining context details, or deletes it if nothing remains
+func (r *RealResourceContextControl) writeRemainingContexts(
+	ctx context.Context,
+	targetContext api.ResourceContextObject,
+	resourceContextSpec *api.ResourceContextSpec,
+	remaining []api.ContextDetail,
+) error {
+	if len(remaining) == len(resourceContextSpec.Contexts) {
+		return nil
+	}
+	if len(remaining) == 0 {
+		if err := r.Client.Delete(ctx, targetContext); err != nil && !apiservererrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+	resourceContextSpec.Contexts = remaining
+	r.resourceContextAdapter.SetResourceContextSpec(resourceContextSpec, targetContext)
+	return r.Client.Update(ctx, targetContext)
+}
+
+// xsetExists reads xset from cache, and confirms it is gone with api server, since cache may not observe xset just
+// created yet
+func (r *RealResourceContextControl) xsetExists(ctx context.Context, key types.NamespacedName) (bool, error) {
+	xsetObject := r.xsetController.NewXSetObject()
+	err := r.Client.Get(ctx, key, xsetObject)
+	if apiservererrors.IsNotFound(err) && r.apiReader != nil {
+		err = r.apiReader.Get(ctx, key, xsetObject)
+	}
+	if apiservererrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("fail to find owner %s: %w", key, err)
+	}
+	return true, nil
+}
+
+func (r *RealResourceContextControl) GetCoOwnedIDs(ctx context.Context, xsetObject api.XSetObject) (sets.Int, error) {
+	coOwnedIDs := sets.Int{}
+	if r.xsetController.GetXSetSpec(xsetObject).ScaleStrategy.Context == "" {
+		return coOwnedIDs, nil
+	}
+
+	contextName := r.getContextName(xsetObject)
+	targetContext := r.resourceContextAdapter.NewResourceContext()
+	if err := r.getResourceContext(ctx, types.NamespacedName{Namespace: xsetObject.GetNamespace(), Name: contextName}, targetContext); err != nil {
+		if apiservererrors.IsNotFound(err) {
+			return coOwnedIDs, nil
+		}
+		return nil, fmt.Errorf("fail to find ResourceContext %s/%s: %w", xsetObject.GetNamespace(), contextName, err)
+	}
+	resourceContextSpec := r.resourceContextAdapter.GetResourceContextSpec(targetContext)
+	for i := range resourceContextSpec.Contexts {
+		if !r.Contains(&resourceContextSpec.Contexts[i], api.EnumOwnerContextKey, xsetObject.GetName()) {
+			coOwnedIDs.Insert(resourceContextSpec.Contexts[i].ID)
+		}
+	}
+	return coOwnedIDs, nil
+}
+
 func (r *RealResourceContextControl) ExtractAvailableContexts(diff int, ownedIDs map[int]*api.ContextDetail, targetInstanceIDSet sets.Int) []*api.ContextDetail {
 	var availableContexts []*api.ContextDetail
 	if diff <= 0 {
@@ -266,6 +546,56 @@ func (r *RealResourceContextControl) DecideContextRevisionAfterCreate(contextDet
 	return needUpdateContext
 }
 
+// decideContextsRevisionByRatios assigns newIDs in ascending order to revisions short of replicas desired by ratios,
+// pinned revisions first, and the remaining to updatedRevision.
+func (r *RealResourceContextControl) decideContextsRevisionByRatios(
+	ownedIDs, newIDs map[int]*api.ContextDetail,
+	spec *api.XSetSpec,
+	updatedRevision string,
+) {
+	ratios := spec.UpdateStrategy.RollingUpdate.ByRevisionRatios
+	desired := ratios.GetRevisionReplicas(ptr.Deref(spec.Replicas, 0), updatedRevision)
+	for i := range ownedIDs {
+		if _, exist := r.Get(ownedIDs[i], api.EnumReplaceOriginTargetIDContextDataKey); exist {
+			continue
+		}
+		if revision, exist := r.Get(ownedIDs[i], api.EnumRevisionContextDataKey); exist {
+			desired[revision]--
+		}
+	}
+
+	revisions := make([]string, 0, len(ratios.Revisions)+1)
+	for _, ratio := range ratios.Revisions {
+		revisions = append(revisions, ratio.Revision)
+	}
+	revisions = append(revisions, updatedRevision)
+	var ids []int
+	for id := range newIDs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		revision := updatedRevision
+		for _, candidate := range revisions {
+			if desired[candidate] > 0 {
+				revision = candidate
+				break
+			}
+		}
+		desired[revision]--
+		r.Put(newIDs[id], api.EnumRevisionContextDataKey, revision)
+	}
+}
+
+// PostDeleteDoneSuffix is appended to the target uid recorded by EnumPostDeleteContextDataKey once PostDelete hook
+// of the target succeeded
+const PostDeleteDoneSuffix = "/done"
+
+// IsPostDeletePending checks whether PostDelete hook of the target recorded in context has not succeeded yet
+func IsPostDeletePending(value string) bool {
+	return value != "" && !strings.HasSuffix(value, PostDeleteDoneSuffix)
+}
+
 // UnrecoverableCreateError checks a creation error is uncoverable or not
 // A recoverable error can be recovered by retrying create, such as 409/429
 // An unrecoverable error can only be recovered by updating the revision
@@ -281,6 +611,7 @@ func UnrecoverableCreateError(createErr error) bool {
 //     2.1 if partition is nil, use updatedRevision for all contexts
 //     2.2 if partition is not nil, assign the larger ((replicas-partition)-updatedReplicas) IDs
 //     to updatedRevision, while the remaining smaller sequence numbers use currentRevision.
+//  3. if owner update strategy is byRevisionRatios, assign IDs to revisions short of replicas desired by ratios
 func (r *RealResourceContextControl) DecideContextsRevisionBeforeCreate(
 	ownedIDs, newIDs map[int]*api.ContextDetail,
 	spec *api.XSetSpec,
@@ -301,6 +632,11 @@ func (r *RealResourceContextControl) DecideContextsRevisionBeforeCreate(
 		return
 	}
 
+	if rollingUpdateStrategy.ByRevisionRatios != nil {
+		r.decideContextsRevisionByRatios(ownedIDs, newIDs, spec, updatedRevision)
+		return
+	}
+
 	if rollingUpdateStrategy.ByPartition == nil || rollingUpdateStrategy.ByPartition.Partition == nil {
 		for i := range newIDs {
 			r.Put(newIDs[i], api.EnumRevisionContextDataKey, updatedRevision)
@@ -355,12 +691,33 @@ func (r *RealResourceContextControl) Remove(detail *api.ContextDetail, enum api.
 	detail.Remove(r.resourceContextKeys[enum])
 }
 
+// ProjectToTarget sets the ContextDetail data selected by ResourceContextProjectionAdapter as annotations on target
+func (r *RealResourceContextControl) ProjectToTarget(detail *api.ContextDetail, target client.Object) {
+	projectionAdapter, ok := r.resourceContextAdapter.(api.ResourceContextProjectionAdapter)
+	if !ok || detail == nil {
+		return
+	}
+
+	annotations := target.GetAnnotations()
+	for dataKey, annotationKey := range projectionAdapter.GetProjectedContextKeys() {
+		value, exist := detail.Get(dataKey)
+		if !exist {
+			continue
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[annotationKey] = value
+	}
+	target.SetAnnotations(annotations)
+}
+
 func (r *RealResourceContextControl) doCreateTargetContext(
 	ctx context.Context,
 	xSetObject api.XSetObject,
 	ownerIDs map[int]*api.ContextDetail,
 ) error {
-	contextName := getContextName(r.xsetController, xSetObject)
+	contextName := r.getContextName(xSetObject)
 	targetContext := r.resourceContextAdapter.NewResourceContext()
 	targetContext.SetNamespace(xSetObject.GetNamespace())
 	targetContext.SetName(contextName)
@@ -371,9 +728,11 @@ func (r *RealResourceContextControl) doCreateTargetContext(
 	}
 	sort.Sort(ContextDetailsByOrder(spec.Contexts))
 	r.resourceContextAdapter.SetResourceContextSpec(spec, targetContext)
+	r.labelOwnerKind(targetContext)
 	if err := r.Client.Create(ctx, targetContext); err != nil {
 		return err
 	}
+	r.debouncer.recordWrite(clientutil.ObjectKeyString(xSetObject))
 	return r.cacheExpectations.ExpectCreation(clientutil.ObjectKeyString(xSetObject), r.resourceContextGVK, targetContext.GetNamespace(), targetContext.GetName())
 }
 
@@ -410,6 +769,7 @@ func (r *RealResourceContextControl) doUpdateTargetContext(
 		if err != nil {
 			return err
 		}
+		r.debouncer.recordWrite(clientutil.ObjectKeyString(xsetObject))
 		return r.cacheExpectations.ExpectDeletion(clientutil.ObjectKeyString(xsetObject), r.resourceContextGVK, targetContext.GetNamespace(), targetContext.GetName())
 	}
 
@@ -423,10 +783,12 @@ func (r *RealResourceContextControl) doUpdateTargetContext(
 	// keep context detail in order by ID
 	sort.Sort(ContextDetailsByOrder(resourceContextSpec.Contexts))
 	r.resourceContextAdapter.SetResourceContextSpec(resourceContextSpec, targetContext)
+	r.labelOwnerKind(targetContext)
 	err := r.Client.Update(ctx, targetContext)
 	if err != nil {
 		return err
 	}
+	r.debouncer.recordWrite(clientutil.ObjectKeyString(xsetObject))
 	return r.cacheExpectations.ExpectUpdation(clientutil.ObjectKeyString(xsetObject), r.resourceContextGVK, targetContext.GetNamespace(), targetContext.GetName(), targetContext.GetResourceVersion())
 }
 
@@ -460,8 +822,12 @@ func (r *RealResourceContextControl) doAllocateID(
 	// first add unRecorded ids into ownedIDs
 	r.addUnrecordedIDs(ownedIDs, unRecordIDs, ownerName)
 
-	// find new IDs for owner to fulfill replicas
-	newIDs := r.allocateNewIDs(ownedIDs, existingIDs, replicas, ownerName)
+	// find new IDs for owner to fulfill replicas, without growing the pool beyond its capacity
+	want := replicas - len(ownedIDs)
+	if capacity := spec.ScaleStrategy.ContextCapacity; capacity != nil {
+		want = min(want, int(*capacity)-len(existingIDs)-len(unRecordIDs))
+	}
+	newIDs := r.allocateNewIDs(ownedIDs, existingIDs, want, ownerName)
 
 	// decide revision for newIDs
 	r.DecideContextsRevisionBeforeCreate(ownedIDs, newIDs, spec, currentRevision, updatedRevision)
@@ -487,17 +853,20 @@ func (r *RealResourceContextControl) addUnrecordedIDs(ownedIDs map[int]*api.Cont
 	}
 }
 
-// allocateNewIDs fulfill ids for ownedIDs in order to meet replicas
-func (r *RealResourceContextControl) allocateNewIDs(ownedIDs, existingIDs map[int]*api.ContextDetail, replicas int, ownerName string) map[int]*api.ContextDetail {
+// allocateNewIDs finds count new ids for ownedIDs
+func (r *RealResourceContextControl) allocateNewIDs(ownedIDs, existingIDs map[int]*api.ContextDetail, count int, ownerName string) map[int]*api.ContextDetail {
 	// use new ids from 0 inorder
 	var newIDs []int
 	for id := 0; ; id++ {
-		if len(newIDs) >= replicas-len(ownedIDs) {
+		if len(newIDs) >= count {
 			break
 		}
 		if _, exist := existingIDs[id]; exist {
 			continue
 		}
+		if _, exist := ownedIDs[id]; exist {
+			continue
+		}
 		newIDs = append(newIDs, id)
 	}
 
@@ -517,13 +886,120 @@ func (r *RealResourceContextControl) allocateNewIDs(ownedIDs, existingIDs map[in
 	return newOwnerIDs
 }
 
-func getContextName(xsetControl api.XSetController, instance api.XSetObject) string {
-	spec := xsetControl.GetXSetSpec(instance)
+type readFromAPIServerKey struct{}
+
+// withReadFromAPIServer returns context in which ResourceContext is read from api server rather than cache
+func withReadFromAPIServer(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readFromAPIServerKey{}, true)
+}
+
+// retryFromAPIServer returns true if writing ResourceContext read from cache fails with conflict, since cache may not
+// observe the last write made under contextLocks yet. Writes carry the resourceVersion read, so IDs are never
+// allocated twice by a stale read, and the write is retried once with ResourceContext read from api server.
+func (r *RealResourceContextControl) retryFromAPIServer(ctx context.Context, err error) bool {
+	if err == nil || r.apiReader == nil {
+		return false
+	}
+	if fromAPIServer, _ := ctx.Value(readFromAPIServerKey{}).(bool); fromAPIServer {
+		return false
+	}
+	return apiservererrors.IsConflict(err) || apiservererrors.IsAlreadyExists(err)
+}
+
+// getResourceContext reads ResourceContext from cache, or from api server when retrying a conflicting write
+func (r *RealResourceContextControl) getResourceContext(ctx context.Context, key types.NamespacedName, targetContext api.ResourceContextObject) error {
+	if fromAPIServer, _ := ctx.Value(readFromAPIServerKey{}).(bool); fromAPIServer && r.apiReader != nil {
+		return r.apiReader.Get(ctx, key, targetContext)
+	}
+	return r.Client.Get(ctx, key, targetContext)
+}
+
+func (r *RealResourceContextControl) listResourceContexts(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if r.apiReader != nil {
+		return r.apiReader.List(ctx, list, opts...)
+	}
+	return r.Client.List(ctx, list, opts...)
+}
+
+// labelOwnerKind labels ResourceContext with the kind of xset, so that its orphaned IDs are released by
+// ReleaseOrphanedIDs. The first kind is kept if ResourceContext is shared by xsets of different kinds.
+func (r *RealResourceContextControl) labelOwnerKind(targetContext api.ResourceContextObject) {
+	if _, ok := targetContext.GetLabels()[api.ResourceContextOwnerKindLabelKey]; ok {
+		return
+	}
+	labels := targetContext.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[api.ResourceContextOwnerKindLabelKey] = r.xsetController.XSetMeta().Kind
+	targetContext.SetLabels(labels)
+}
+
+func (r *RealResourceContextControl) getContextName(instance api.XSetObject) string {
+	return GetResourceContextName(r.xsetController, r.resourceContextAdapter, instance)
+}
+
+// GetResourceContextName returns spec.ScaleStrategy.Context or xset name, which can be customized by
+// ResourceContextNameAdapter
+func GetResourceContextName(xsetController api.XSetController, resourceContextAdapter api.ResourceContextAdapter, instance api.XSetObject) string {
+	name := instance.GetName()
+	spec := xsetController.GetXSetSpec(instance)
 	if spec.ScaleStrategy.Context != "" {
-		return spec.ScaleStrategy.Context
+		name = spec.ScaleStrategy.Context
+	}
+
+	if nameAdapter, ok := resourceContextAdapter.(api.ResourceContextNameAdapter); ok {
+		if customized := nameAdapter.GetResourceContextName(instance, name); customized != "" {
+			return customized
+		}
+	}
+	return name
+}
+
+// contextLocks serializes the read-modify-write of a ResourceContext, which may be shared by several xsets as an ID
+// pool, so that concurrent reconcilers in this process rarely conflict on it. It does not guard against other
+// processes, e.g., a former leader, which is left to the resourceVersion conflict of the write and retries of callers.
+var contextLocks = &keyedLocks{locks: map[types.NamespacedName]*keyedLock{}}
+
+type keyedLocks struct {
+	mu    sync.Mutex
+	locks map[types.NamespacedName]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	// refs is the number of callers holding or waiting for the lock
+	refs int
+}
+
+// lock locks the key, whose entry is removed once no one holds or waits for it, so that entries of deleted contexts
+// are not kept forever
+func (k *keyedLocks) lock(namespace, name string) (unlock func()) {
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(k.locks, key)
+		}
 	}
+}
 
-	return instance.GetName()
+func (k *keyedLocks) len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.locks)
 }
 
 type ContextDetailsByOrder []api.ContextDetail
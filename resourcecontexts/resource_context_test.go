@@ -17,14 +17,26 @@ limitations under the License.
 package resourcecontexts
 
 import (
+	"context"
+	"fmt"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/utils/pointer"
+	appsv1alpha1 "kusionstack.io/kube-api/apps/v1alpha1"
 	"kusionstack.io/kube-utils/controller/expectations"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"kusionstack.io/kube-xset/api"
 )
@@ -225,6 +237,46 @@ func TestRealResourceContextControl_fulfillOwnedIDs(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "want 5, existing [0] and [1] of other owner, capacity 3, alloc [2]",
+			args: args{
+				ownedIDs: map[int]*api.ContextDetail{
+					0: {
+						ID:   0,
+						Data: map[string]string{"Owner": "foo", "Revision": "defaultRv"},
+					},
+				},
+				existingIDs: map[int]*api.ContextDetail{
+					0: {
+						ID:   0,
+						Data: map[string]string{"Owner": "foo", "Revision": "defaultRv"},
+					},
+					1: {
+						ID:   1,
+						Data: map[string]string{"Owner": "bar", "Revision": "defaultRv"},
+					},
+				},
+				unRecordIDs:     map[int]string{},
+				ownerName:       "foo",
+				spec:            &api.XSetSpec{ScaleStrategy: api.ScaleStrategy{Context: "pool", ContextCapacity: pointer.Int32(3)}},
+				replicas:        5,
+				currentRevision: "defaultRv",
+				updatedRevision: "defaultRv",
+			},
+			fields: fields{
+				resourceContextKeys: defaultResourceContextKeys,
+			},
+			want: map[int]*api.ContextDetail{
+				0: {
+					ID:   0,
+					Data: map[string]string{"Owner": "foo", "Revision": "defaultRv"},
+				},
+				2: {
+					ID:   2,
+					Data: map[string]string{"Owner": "foo", "Revision": "defaultRv", "TargetJustCreate": "true"},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -499,6 +551,60 @@ func TestRealResourceContextControl_DecideContextsRevisionBeforeCreate(t *testin
 				},
 			},
 		},
+		{
+			name: "ownedIDs[0: newRevision], replicas: 4, stableRevision: 50%, want newIDs[1: stableRevision, 2: stableRevision, 3: newRevision]",
+			fields: fields{
+				resourceContextKeys: defaultResourceContextKeys,
+			},
+			args: args{
+				ownedIDs: map[int]*api.ContextDetail{
+					0: {
+						ID:   0,
+						Data: map[string]string{"Owner": "foo", "Revision": "newRevision"},
+					},
+				},
+				newIDs: map[int]*api.ContextDetail{
+					1: {
+						ID:   1,
+						Data: map[string]string{"Owner": "foo"},
+					},
+					2: {
+						ID:   2,
+						Data: map[string]string{"Owner": "foo"},
+					},
+					3: {
+						ID:   3,
+						Data: map[string]string{"Owner": "foo"},
+					},
+				},
+				spec: &api.XSetSpec{
+					Replicas: pointer.Int32(4),
+					UpdateStrategy: api.UpdateStrategy{
+						RollingUpdate: &api.RollingUpdateStrategy{
+							ByRevisionRatios: &api.ByRevisionRatios{
+								Revisions: []api.RevisionRatio{{Revision: "stableRevision", Percent: 50}},
+							},
+						},
+					},
+				},
+				currentRevision: "stableRevision",
+				updatedRevision: "newRevision",
+			},
+			want: map[int]*api.ContextDetail{
+				1: {
+					ID:   1,
+					Data: map[string]string{"Owner": "foo", "Revision": "stableRevision"},
+				},
+				2: {
+					ID:   2,
+					Data: map[string]string{"Owner": "foo", "Revision": "stableRevision"},
+				},
+				3: {
+					ID:   3,
+					Data: map[string]string{"Owner": "foo", "Revision": "newRevision"},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -519,3 +625,397 @@ func TestRealResourceContextControl_DecideContextsRevisionBeforeCreate(t *testin
 		})
 	}
 }
+
+type mockXSetController struct {
+	api.XSetController
+	replicas int32
+	context  string
+}
+
+func (m *mockXSetController) GetXSetSpec(_ api.XSetObject) *api.XSetSpec {
+	return &api.XSetSpec{
+		Replicas:      pointer.Int32(m.replicas),
+		ScaleStrategy: api.ScaleStrategy{Context: m.context},
+	}
+}
+
+func (m *mockXSetController) XSetMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}
+}
+
+func TestRealResourceContextControl_AllocateIDConcurrently(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv1alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	const owners, replicas, pool = 5, 4, "shared-pool"
+	xsetController := &mockXSetController{replicas: replicas, context: pool}
+	adapter := &DefaultResourceContextAdapter{}
+	r := &RealResourceContextControl{
+		Client:                 c,
+		EventRecorder:          record.NewFakeRecorder(100),
+		xsetController:         xsetController,
+		resourceContextAdapter: adapter,
+		resourceContextKeys:    defaultResourceContextKeys,
+		resourceContextGVK:     appsv1alpha1.SchemeGroupVersion.WithKind("ResourceContext"),
+		cacheExpectations:      expectations.NewxCacheExpectations(c, scheme, clock.RealClock{}),
+		xsetLabelManager:       api.NewXSetLabelAnnotationManager(nil),
+	}
+
+	var wg sync.WaitGroup
+	var attempts int32
+	results := make([]map[int]*api.ContextDetail, owners)
+	errs := make([]error, owners)
+	for i := 0; i < owners; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			owner := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("owner-%d", i)}}
+			errs[i] = retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+				atomic.AddInt32(&attempts, 1)
+				results[i], err = r.AllocateID(context.TODO(), owner, "rv", "rv", replicas, nil)
+				return err
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	// allocation is serialized on the same context, so no one should thrash on conflicts
+	if attempts != owners {
+		t.Errorf("got %d allocation attempts, want %d", attempts, owners)
+	}
+
+	allocated := map[int]int{}
+	for i := 0; i < owners; i++ {
+		if errs[i] != nil {
+			t.Fatalf("owner-%d failed to allocate IDs: %v", i, errs[i])
+		}
+		if len(results[i]) != replicas {
+			t.Fatalf("owner-%d got %d IDs, want %d", i, len(results[i]), replicas)
+		}
+		for id := range results[i] {
+			if other, exist := allocated[id]; exist {
+				t.Fatalf("ID %d is allocated to both owner-%d and owner-%d", id, other, i)
+			}
+			allocated[id] = i
+		}
+	}
+
+	rc := &appsv1alpha1.ResourceContext{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: pool}, rc); err != nil {
+		t.Fatalf("fail to get ResourceContext: %v", err)
+	}
+	if len(rc.Spec.Contexts) != owners*replicas {
+		t.Fatalf("got %d contexts in pool, want %d", len(rc.Spec.Contexts), owners*replicas)
+	}
+	for _, detail := range rc.Spec.Contexts {
+		if want := fmt.Sprintf("owner-%d", allocated[detail.ID]); detail.Data["Owner"] != want {
+			t.Errorf("ID %d is recorded for owner %s, want %s", detail.ID, detail.Data["Owner"], want)
+		}
+	}
+}
+
+func TestRealResourceContextControl_CleanUnusedIDs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv1alpha1.AddToScheme(scheme)
+	rc := &appsv1alpha1.ResourceContext{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"},
+		Spec: appsv1alpha1.ResourceContextSpec{
+			Contexts: []appsv1alpha1.ContextDetail{
+				{ID: 0, Data: map[string]string{"Owner": "foo"}},
+				{ID: 1, Data: map[string]string{"Owner": "foo"}},
+				{ID: 2, Data: map[string]string{"Owner": "foo"}},
+				{ID: 3, Data: map[string]string{"Owner": "foo"}},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(rc).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &RealResourceContextControl{
+		Client:                 c,
+		EventRecorder:          recorder,
+		xsetController:         &mockXSetController{replicas: 1},
+		resourceContextAdapter: &DefaultResourceContextAdapter{},
+		resourceContextKeys:    defaultResourceContextKeys,
+		resourceContextGVK:     appsv1alpha1.SchemeGroupVersion.WithKind("ResourceContext"),
+		cacheExpectations:      expectations.NewxCacheExpectations(c, scheme, clock.RealClock{}),
+		xsetLabelManager:       api.NewXSetLabelAnnotationManager(nil),
+	}
+	owner := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	target := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "default",
+		Name:      "foo-0",
+		Labels:    map[string]string{appsv1alpha1.PodInstanceIDLabelKey: "0"},
+	}}
+
+	ids, err := r.CleanUnusedIDs(context.TODO(), owner, []client.Object{target}, true)
+	if err != nil {
+		t.Fatalf("fail to clean unused IDs in dry-run: %v", err)
+	}
+	if want := []int{3, 2, 1}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got IDs %v in dry-run, want %v", ids, want)
+	}
+	if event := <-recorder.Events; event != "Normal ResourceContextCleanDryRun would clean unused IDs [3 2 1] from ResourceContext default/foo" {
+		t.Errorf("unexpected event %q", event)
+	}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "foo"}, rc); err != nil {
+		t.Fatalf("fail to get ResourceContext: %v", err)
+	}
+	if len(rc.Spec.Contexts) != 4 {
+		t.Errorf("dry-run should not change ResourceContext, got %d contexts", len(rc.Spec.Contexts))
+	}
+
+	ids, err = r.CleanUnusedIDs(context.TODO(), owner, []client.Object{target}, false)
+	if err != nil {
+		t.Fatalf("fail to clean unused IDs: %v", err)
+	}
+	if want := []int{3, 2, 1}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got IDs %v, want %v", ids, want)
+	}
+	if event := <-recorder.Events; event != "Warning ResourceContextClean clean unused IDs [3 2 1] from ResourceContext default/foo" {
+		t.Errorf("unexpected event %q", event)
+	}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "foo"}, rc); err != nil {
+		t.Fatalf("fail to get ResourceContext: %v", err)
+	}
+	if len(rc.Spec.Contexts) != 1 || rc.Spec.Contexts[0].ID != 0 {
+		t.Errorf("got contexts %v, want only ID 0", rc.Spec.Contexts)
+	}
+}
+
+// laggingClient reads from a cache which never observes writes made through it
+type laggingClient struct {
+	client.Client
+	cache client.Reader
+}
+
+func (c *laggingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	return c.cache.Get(ctx, key, obj)
+}
+
+// countingReader counts reads from api server
+type countingReader struct {
+	client.Reader
+	gets int
+}
+
+func (r *countingReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	r.gets++
+	return r.Reader.Get(ctx, key, obj)
+}
+
+func TestRealResourceContextControl_AllocateIDWithLaggingCache(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv1alpha1.AddToScheme(scheme)
+	apiServer := fake.NewClientBuilder().WithScheme(scheme).Build()
+	c := &laggingClient{Client: apiServer, cache: fake.NewClientBuilder().WithScheme(scheme).Build()}
+	apiReader := &countingReader{Reader: apiServer}
+
+	const replicas, pool = 2, "shared-pool"
+	r := &RealResourceContextControl{
+		Client:                 c,
+		apiReader:              apiReader,
+		EventRecorder:          record.NewFakeRecorder(100),
+		xsetController:         &mockXSetController{replicas: replicas, context: pool},
+		resourceContextAdapter: &DefaultResourceContextAdapter{},
+		resourceContextKeys:    defaultResourceContextKeys,
+		resourceContextGVK:     appsv1alpha1.SchemeGroupVersion.WithKind("ResourceContext"),
+		cacheExpectations:      expectations.NewxCacheExpectations(apiServer, scheme, clock.RealClock{}),
+		xsetLabelManager:       api.NewXSetLabelAnnotationManager(nil),
+	}
+
+	// ResourceContext is read from cache, and from api server only after writing it conflicts
+	allocated := map[int]string{}
+	for i, name := range []string{"foo", "bar"} {
+		owner := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+		ownedIDs, err := r.AllocateID(context.TODO(), owner, "rv", "rv", replicas, nil)
+		if err != nil {
+			t.Fatalf("%s fails to allocate IDs with lagging cache: %v", name, err)
+		}
+		for id := range ownedIDs {
+			if other, exist := allocated[id]; exist {
+				t.Fatalf("ID %d is allocated to both %s and %s", id, other, name)
+			}
+			allocated[id] = name
+		}
+		if apiReader.gets != i {
+			t.Errorf("got %d reads from api server after %s allocates IDs, want %d", apiReader.gets, name, i)
+		}
+	}
+
+	// stale ResourceContext in cache fails update with conflict, and IDs are allocated with the one of api server
+	stale := &appsv1alpha1.ResourceContext{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: pool}}
+	if err := c.cache.(client.Client).Create(context.TODO(), stale); err != nil {
+		t.Fatalf("fail to create stale ResourceContext in cache: %v", err)
+	}
+	owner := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "baz"}}
+	ownedIDs, err := r.AllocateID(context.TODO(), owner, "rv", "rv", replicas, nil)
+	if err != nil {
+		t.Fatalf("baz fails to allocate IDs with stale cache: %v", err)
+	}
+	for id := range ownedIDs {
+		if other, exist := allocated[id]; exist {
+			t.Fatalf("ID %d is allocated to both %s and baz", id, other)
+		}
+	}
+
+	rc := &appsv1alpha1.ResourceContext{}
+	if err := apiServer.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: pool}, rc); err != nil {
+		t.Fatalf("fail to get ResourceContext: %v", err)
+	}
+	if len(rc.Spec.Contexts) != 3*replicas {
+		t.Errorf("got %d contexts in pool, want %d", len(rc.Spec.Contexts), 3*replicas)
+	}
+	if n := contextLocks.len(); n != 0 {
+		t.Errorf("got %d context locks after allocation, want 0", n)
+	}
+}
+
+func TestKeyedLocks(t *testing.T) {
+	locks := &keyedLocks{locks: map[types.NamespacedName]*keyedLock{}}
+	unlock := locks.lock("default", "foo")
+
+	acquired := make(chan struct{})
+	go func() {
+		defer close(acquired)
+		locks.lock("default", "foo")()
+	}()
+	// the waiter keeps the entry until it unlocks, so that it never locks another mutex of the same key
+	locks.lock("default", "bar")()
+	if n := locks.len(); n != 1 {
+		t.Errorf("got %d locks while foo is held, want 1", n)
+	}
+	unlock()
+	<-acquired
+	if n := locks.len(); n != 0 {
+		t.Errorf("got %d locks after unlocked, want 0", n)
+	}
+}
+
+func TestRealResourceContextControl_GetCoOwnedIDs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv1alpha1.AddToScheme(scheme)
+	rc := &appsv1alpha1.ResourceContext{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "shared-pool"},
+		Spec: appsv1alpha1.ResourceContextSpec{
+			Contexts: []appsv1alpha1.ContextDetail{
+				{ID: 0, Data: map[string]string{"Owner": "foo"}},
+				{ID: 1, Data: map[string]string{"Owner": "bar"}},
+				{ID: 2, Data: map[string]string{"Owner": "baz"}},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(rc).Build()
+	r := &RealResourceContextControl{
+		Client:                 c,
+		xsetController:         &mockXSetController{context: "shared-pool"},
+		resourceContextAdapter: &DefaultResourceContextAdapter{},
+		resourceContextKeys:    defaultResourceContextKeys,
+	}
+	owner := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+
+	ids, err := r.GetCoOwnedIDs(context.TODO(), owner)
+	if err != nil {
+		t.Fatalf("fail to get co-owned IDs: %v", err)
+	}
+	if want := []int{1, 2}; !reflect.DeepEqual(ids.List(), want) {
+		t.Errorf("got co-owned IDs %v, want %v", ids.List(), want)
+	}
+
+	r.xsetController = &mockXSetController{}
+	if ids, err := r.GetCoOwnedIDs(context.TODO(), owner); err != nil || ids.Len() != 0 {
+		t.Errorf("got co-owned IDs %v, %v without context pool, want none", ids.List(), err)
+	}
+}
+
+type ownerKindXSetController struct {
+	mockXSetController
+}
+
+func (m *ownerKindXSetController) NewXSetObject() api.XSetObject {
+	return &appsv1.Deployment{}
+}
+
+func TestRealResourceContextControl_ReleaseOrphanedIDs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv1.AddToScheme(scheme)
+	_ = appsv1alpha1.AddToScheme(scheme)
+	newContext := func(name, kind string, owners ...string) *appsv1alpha1.ResourceContext {
+		rc := &appsv1alpha1.ResourceContext{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+		if kind != "" {
+			rc.Labels = map[string]string{api.ResourceContextOwnerKindLabelKey: kind}
+		}
+		for i, owner := range owners {
+			rc.Spec.Contexts = append(rc.Spec.Contexts, appsv1alpha1.ContextDetail{ID: i, Data: map[string]string{"Owner": owner}})
+		}
+		return rc
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}},
+		newContext("shared-pool", "Deployment", "foo", "bar", "foo"),
+		newContext("bar", "Deployment", "bar"),
+		newContext("legacy", "", "bar"),
+		newContext("other-kind", "CollaSet", "bar"),
+	).Build()
+	r := &RealResourceContextControl{
+		Client:                 c,
+		xsetController:         &ownerKindXSetController{},
+		resourceContextAdapter: &DefaultResourceContextAdapter{},
+		resourceContextKeys:    defaultResourceContextKeys,
+		resourceContextGVK:     appsv1alpha1.SchemeGroupVersion.WithKind("ResourceContext"),
+	}
+
+	if err := r.ReleaseOrphanedIDs(context.TODO(), "default"); err != nil {
+		t.Fatalf("fail to release orphaned IDs: %v", err)
+	}
+
+	rc := &appsv1alpha1.ResourceContext{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "shared-pool"}, rc); err != nil {
+		t.Fatalf("fail to get shared ResourceContext: %v", err)
+	}
+	var ids []int
+	for _, detail := range rc.Spec.Contexts {
+		ids = append(ids, detail.ID)
+	}
+	if want := []int{0, 2}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got IDs %v in shared ResourceContext, want %v of existing owner", ids, want)
+	}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "bar"}, rc); err == nil {
+		t.Errorf("ResourceContext of deleted owner is not deleted")
+	}
+	for _, name := range []string{"legacy", "other-kind"} {
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: name}, rc); err != nil {
+			t.Fatalf("fail to get ResourceContext %s: %v", name, err)
+		}
+		if len(rc.Spec.Contexts) != 1 {
+			t.Errorf("got %d IDs in ResourceContext %s not labelled with kind of xset, want untouched", len(rc.Spec.Contexts), name)
+		}
+	}
+}
+
+func TestRealResourceContextControl_LabelOwnerKind(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv1alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := &RealResourceContextControl{
+		Client:                 c,
+		xsetController:         &ownerKindXSetController{mockXSetController{replicas: 1}},
+		resourceContextAdapter: &DefaultResourceContextAdapter{},
+		resourceContextKeys:    defaultResourceContextKeys,
+		resourceContextGVK:     appsv1alpha1.SchemeGroupVersion.WithKind("ResourceContext"),
+		cacheExpectations:      expectations.NewxCacheExpectations(c, scheme, clock.RealClock{}),
+	}
+	owner := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	ownedIDs := map[int]*api.ContextDetail{0: {ID: 0, Data: map[string]string{"Owner": "foo"}}}
+	if err := r.doCreateTargetContext(context.TODO(), owner, ownedIDs); err != nil {
+		t.Fatalf("fail to create ResourceContext: %v", err)
+	}
+
+	rc := &appsv1alpha1.ResourceContext{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "foo"}, rc); err != nil {
+		t.Fatalf("fail to get ResourceContext: %v", err)
+	}
+	if kind := rc.Labels[api.ResourceContextOwnerKindLabelKey]; kind != "Deployment" {
+		t.Errorf("got owner kind %q, want Deployment", kind)
+	}
+}
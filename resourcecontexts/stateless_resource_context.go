@@ -0,0 +1,238 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resourcecontexts
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+var _ ResourceContextControl = &StatelessResourceContextControl{}
+
+// StatelessResourceContextControl never reads or writes ResourceContext. IDs owned by xset are derived from instance
+// ID labels of its targets, and data of contexts, e.g., revisions, subsets and replace pairs, from their labels, so
+// that they survive controller restarts. Contexts are kept in memory between syncs to hold IDs allocated to targets not
+// created yet. Context pool set by spec.scaleStrategy.context is not supported, since IDs are not shared across xsets.
+type StatelessResourceContextControl struct {
+	*RealResourceContextControl
+
+	mu       sync.Mutex
+	contexts map[types.NamespacedName]map[int]*api.ContextDetail
+}
+
+// NewStatelessResourceContextControl returns ResourceContextControl for controllers implementing StatelessAdapter
+func NewStatelessResourceContextControl(
+	xsetController api.XSetController,
+	resourceContextAdapter api.ResourceContextAdapter,
+	xsetLabelManager api.XSetLabelAnnotationManager,
+) ResourceContextControl {
+	return &StatelessResourceContextControl{
+		RealResourceContextControl: &RealResourceContextControl{
+			xsetController:         xsetController,
+			resourceContextAdapter: resourceContextAdapter,
+			resourceContextKeys:    GetResourceContextKeys(resourceContextAdapter),
+			xsetLabelManager:       xsetLabelManager,
+			debouncer:              newWriteDebouncer(),
+		},
+		contexts: map[types.NamespacedName]map[int]*api.ContextDetail{},
+	}
+}
+
+// IsStateless checks whether xsetController skips ResourceContext by StatelessAdapter
+func IsStateless(xsetController api.XSetController) bool {
+	adapter, ok := xsetController.(api.StatelessAdapter)
+	return ok && adapter.SkipResourceContext()
+}
+
+func (r *StatelessResourceContextControl) AllocateID(
+	_ context.Context,
+	xsetObject api.XSetObject,
+	currentRevision, updatedRevision string,
+	replicas int, objs []client.Object,
+) (map[int]*api.ContextDetail, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ownedIDs := r.getOwnedIDs(xsetObject)
+	r.recordTargetIDs(ownedIDs, objs, xsetObject.GetName(), currentRevision)
+
+	newIDs := r.allocateNewIDs(ownedIDs, ownedIDs, replicas-len(ownedIDs), xsetObject.GetName())
+	r.DecideContextsRevisionBeforeCreate(ownedIDs, newIDs, r.xsetController.GetXSetSpec(xsetObject), currentRevision, updatedRevision)
+	for id, detail := range newIDs {
+		ownedIDs[id] = detail
+	}
+	return copyContextDetails(ownedIDs), nil
+}
+
+func (r *StatelessResourceContextControl) CleanUnusedIDs(_ context.Context, xsetObject api.XSetObject, objs []client.Object, dryRun bool) ([]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ownedIDs := r.getOwnedIDs(xsetObject)
+	needCleanCount := len(ownedIDs) - maxInt(int(ptr.Deref(r.xsetController.GetXSetSpec(xsetObject).Replicas, 0)), len(objs))
+	if needCleanCount <= 0 {
+		return nil, nil
+	}
+
+	currentIDs := sets.Int{}
+	for i := range objs {
+		if id, err := xcontrol.GetInstanceID(r.xsetLabelManager, objs[i]); err == nil {
+			currentIDs.Insert(id)
+		}
+	}
+	_, postDeleteEnabled := r.xsetController.(api.PostDeleteHookAdapter)
+	var allowDeleteIDs []int
+	for id, detail := range ownedIDs {
+		if currentIDs.Has(id) {
+			continue
+		}
+		// hold IDs until PostDelete hook of their deleted targets succeeded
+		if value, _ := r.Get(detail, api.EnumPostDeleteContextDataKey); postDeleteEnabled && IsPostDeletePending(value) {
+			continue
+		}
+		allowDeleteIDs = append(allowDeleteIDs, id)
+	}
+
+	// reclaim larger IDs first, so that the result is stable across reconciles
+	sort.Sort(sort.Reverse(sort.IntSlice(allowDeleteIDs)))
+	deletedIDs := allowDeleteIDs[:min(needCleanCount, len(allowDeleteIDs))]
+	if len(deletedIDs) == 0 {
+		return nil, nil
+	}
+	if !dryRun {
+		for _, id := range deletedIDs {
+			delete(ownedIDs, id)
+		}
+	}
+	return deletedIDs, nil
+}
+
+// UpdateToTargetContext replaces contexts kept in memory for xset with ownedIDs, and nil ownedIDs releases all of them
+func (r *StatelessResourceContextControl) UpdateToTargetContext(_ context.Context, xsetObject api.XSetObject, ownedIDs map[int]*api.ContextDetail) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := types.NamespacedName{Namespace: xsetObject.GetNamespace(), Name: xsetObject.GetName()}
+	if len(ownedIDs) == 0 {
+		delete(r.contexts, key)
+		return nil
+	}
+	r.contexts[key] = copyContextDetails(ownedIDs)
+	return nil
+}
+
+func (r *StatelessResourceContextControl) ReleaseDeletedOwnerIDs(_ context.Context, namespace, name string) error {
+	r.ForgetOwner(namespace, name)
+	return nil
+}
+
+// ReleaseOrphanedIDs does nothing, since contexts of deleted xsets are dropped by ForgetOwner, and the ones deleted
+// while controller is down are never kept in memory
+func (r *StatelessResourceContextControl) ReleaseOrphanedIDs(context.Context, string) error {
+	return nil
+}
+
+// GetCoOwnedIDs returns nothing, since IDs are never shared across xsets
+func (r *StatelessResourceContextControl) GetCoOwnedIDs(context.Context, api.XSetObject) (sets.Int, error) {
+	return sets.Int{}, nil
+}
+
+// FlushPendingWrites does nothing, since there is no write to ResourceContext
+func (r *StatelessResourceContextControl) FlushPendingWrites(context.Context, api.XSetObject) (*time.Duration, error) {
+	return nil, nil
+}
+
+// ForgetOwner drops contexts kept in memory for a deleted xset
+func (r *StatelessResourceContextControl) ForgetOwner(namespace, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.contexts, types.NamespacedName{Namespace: namespace, Name: name})
+}
+
+func (r *StatelessResourceContextControl) getOwnedIDs(xsetObject api.XSetObject) map[int]*api.ContextDetail {
+	key := types.NamespacedName{Namespace: xsetObject.GetNamespace(), Name: xsetObject.GetName()}
+	ownedIDs, ok := r.contexts[key]
+	if !ok {
+		ownedIDs = map[int]*api.ContextDetail{}
+		r.contexts[key] = ownedIDs
+	}
+	return ownedIDs
+}
+
+// recordTargetIDs adds IDs used by targets to ownedIDs, and restores replace pairs from replace pair labels of targets,
+// which are otherwise recorded in ResourceContext only.
+func (r *StatelessResourceContextControl) recordTargetIDs(ownedIDs map[int]*api.ContextDetail, objs []client.Object, ownerName, defaultRevision string) {
+	idsByName := map[string]int{}
+	for _, obj := range objs {
+		id, err := xcontrol.GetInstanceID(r.xsetLabelManager, obj)
+		if err != nil || id < 0 {
+			continue
+		}
+		idsByName[obj.GetName()] = id
+		if _, exist := ownedIDs[id]; exist || obj.GetDeletionTimestamp() != nil {
+			continue
+		}
+		detail := &api.ContextDetail{ID: id}
+		r.Put(detail, api.EnumOwnerContextKey, ownerName)
+		r.Put(detail, api.EnumRevisionContextDataKey, xcontrol.GetTargetRevision(obj, defaultRevision))
+		if subset, exist := obj.GetLabels()[api.TargetSubsetLabelKey]; exist {
+			r.Put(detail, api.EnumSubsetContextDataKey, subset)
+		}
+		ownedIDs[id] = detail
+	}
+
+	for _, obj := range objs {
+		id, ok := idsByName[obj.GetName()]
+		if !ok || ownedIDs[id] == nil {
+			continue
+		}
+		if newID, exist := r.xsetLabelManager.Get(obj, api.XReplacePairNewId); exist {
+			if _, err := strconv.Atoi(newID); err == nil {
+				if _, recorded := r.Get(ownedIDs[id], api.EnumReplaceNewTargetIDContextDataKey); !recorded {
+					r.Put(ownedIDs[id], api.EnumReplaceNewTargetIDContextDataKey, newID)
+				}
+			}
+		}
+		if originName, exist := r.xsetLabelManager.Get(obj, api.XReplacePairOriginName); exist {
+			if originID, found := idsByName[originName]; found {
+				if _, recorded := r.Get(ownedIDs[id], api.EnumReplaceOriginTargetIDContextDataKey); !recorded {
+					r.Put(ownedIDs[id], api.EnumReplaceOriginTargetIDContextDataKey, strconv.Itoa(originID))
+				}
+			}
+		}
+	}
+}
+
+func copyContextDetails(contexts map[int]*api.ContextDetail) map[int]*api.ContextDetail {
+	copied := make(map[int]*api.ContextDetail, len(contexts))
+	for id, detail := range contexts {
+		copied[id] = &api.ContextDetail{ID: detail.ID, Data: maps.Clone(detail.Data)}
+	}
+	return copied
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resourcecontexts
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appsv1alpha1 "kusionstack.io/kube-api/apps/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+func newStatelessTarget(name, id, revision string, labels map[string]string) client.Object {
+	target := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "default",
+		Name:      name,
+		Labels: map[string]string{
+			appsv1alpha1.PodInstanceIDLabelKey:    id,
+			appsv1.ControllerRevisionHashLabelKey: revision,
+		},
+	}}
+	for k, v := range labels {
+		target.Labels[k] = v
+	}
+	return target
+}
+
+func TestStatelessResourceContextControl_AllocateID(t *testing.T) {
+	xsetController := &mockXSetController{replicas: 3}
+	owner := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	targets := []client.Object{
+		newStatelessTarget("foo-a", "0", "rev-1", map[string]string{appsv1alpha1.PodReplacePairNewId: "2"}),
+		newStatelessTarget("foo-b", "2", "rev-2", map[string]string{appsv1alpha1.PodReplacePairOriginName: "foo-a"}),
+	}
+
+	r := NewStatelessResourceContextControl(xsetController, &DefaultResourceContextAdapter{}, api.NewXSetLabelAnnotationManager(nil))
+	ownedIDs, err := r.AllocateID(context.TODO(), owner, "rev-1", "rev-2", 3, targets)
+	if err != nil {
+		t.Fatalf("fail to allocate IDs: %v", err)
+	}
+	want := map[int]map[string]string{
+		0: {"Owner": "foo", "Revision": "rev-1", "ReplaceNewTargetID": "2"},
+		1: {"Owner": "foo", "Revision": "rev-2", "TargetJustCreate": "true"},
+		2: {"Owner": "foo", "Revision": "rev-2", "ReplaceOriginTargetID": "0"},
+	}
+	got := map[int]map[string]string{}
+	for id, detail := range ownedIDs {
+		got[id] = detail.Data
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got owned IDs %v, want %v", got, want)
+	}
+
+	// ID allocated to target not created yet is held in memory
+	ownedIDs, err = r.AllocateID(context.TODO(), owner, "rev-1", "rev-2", 4, nil)
+	if err != nil {
+		t.Fatalf("fail to allocate IDs: %v", err)
+	}
+	if len(ownedIDs) != 4 || ownedIDs[1] == nil || ownedIDs[3] == nil {
+		t.Fatalf("got owned IDs %v, want 0-3", ownedIDs)
+	}
+
+	// scale in to 2 targets releases unused IDs
+	xsetController.replicas = 2
+	ids, err := r.CleanUnusedIDs(context.TODO(), owner, targets, false)
+	if err != nil {
+		t.Fatalf("fail to clean unused IDs: %v", err)
+	}
+	if want := []int{3, 1}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got cleaned IDs %v, want %v", ids, want)
+	}
+
+	delete(ownedIDs, 0)
+	if err := r.UpdateToTargetContext(context.TODO(), owner, ownedIDs); err != nil {
+		t.Fatalf("fail to update contexts: %v", err)
+	}
+	ownedIDs, _ = r.AllocateID(context.TODO(), owner, "rev-1", "rev-2", 0, nil)
+	if _, exist := ownedIDs[0]; exist {
+		t.Errorf("ID 0 released by update should not be owned")
+	}
+
+	// contexts are derived from targets again after being forgotten
+	r.ForgetOwner("default", "foo")
+	ownedIDs, _ = r.AllocateID(context.TODO(), owner, "rev-1", "rev-2", 0, targets)
+	if len(ownedIDs) != 2 || ownedIDs[0] == nil || ownedIDs[2] == nil {
+		t.Errorf("got owned IDs %v after forgotten, want 0 and 2", ownedIDs)
+	}
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resourcecontexts
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	apiservererrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clientutil "kusionstack.io/kube-utils/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// writeDebouncer coalesces ResourceContext writes which only release IDs, e.g., when scaling in and out in quick
+// succession. Released IDs are kept pending in memory per owner, and are hidden from the owner until written.
+// Writes allocating IDs or changing context data are never deferred, so that IDs are not shared by co-owners.
+// A nil writeDebouncer never defers writes.
+type writeDebouncer struct {
+	mu        sync.Mutex
+	lastWrite map[string]time.Time
+	pending   map[string]sets.Int
+}
+
+func newWriteDebouncer() *writeDebouncer {
+	return &writeDebouncer{
+		lastWrite: map[string]time.Time{},
+		pending:   map[string]sets.Int{},
+	}
+}
+
+// recordWrite records ResourceContext is written by owner, including pending released IDs
+func (d *writeDebouncer) recordWrite(key string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastWrite[key] = time.Now()
+	delete(d.pending, key)
+}
+
+// tryDefer keeps released IDs pending if owner has written ResourceContext within window
+func (d *writeDebouncer) tryDefer(key string, window time.Duration, released sets.Int) bool {
+	if d == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if time.Since(d.lastWrite[key]) >= window {
+		return false
+	}
+	d.pending[key] = released
+	return true
+}
+
+// pendingReleases returns IDs released by owner but not written yet
+func (d *writeDebouncer) pendingReleases(key string) sets.Int {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return sets.NewInt(d.pending[key].UnsortedList()...)
+}
+
+// due returns pending released IDs of owner, and the remaining duration before they are allowed to be written
+func (d *writeDebouncer) due(key string, window time.Duration) (sets.Int, time.Duration) {
+	if d == nil {
+		return nil, 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	released, exist := d.pending[key]
+	if !exist {
+		return nil, 0
+	}
+	return sets.NewInt(released.UnsortedList()...), window - time.Since(d.lastWrite[key])
+}
+
+// cancelPending drops pending released IDs of owner, which are owned again
+func (d *writeDebouncer) cancelPending(key string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.pending, key)
+}
+
+func (d *writeDebouncer) forget(key string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.lastWrite, key)
+	delete(d.pending, key)
+}
+
+func (r *RealResourceContextControl) ForgetOwner(namespace, name string) {
+	xsetObject := r.xsetController.NewXSetObject()
+	xsetObject.SetNamespace(namespace)
+	xsetObject.SetName(name)
+	r.debouncer.forget(clientutil.ObjectKeyString(xsetObject))
+}
+
+func (r *RealResourceContextControl) getWriteDebounceWindow(xsetObject api.XSetObject) time.Duration {
+	if adapter, ok := r.xsetController.(api.ResourceContextWriteDebounceAdapter); ok {
+		return adapter.GetResourceContextWriteDebounceWindow(xsetObject)
+	}
+	return 0
+}
+
+// hidePendingReleases removes IDs released by owner but not written yet from contexts read from ResourceContext
+func (r *RealResourceContextControl) hidePendingReleases(xsetObject api.XSetObject, contexts ...map[int]*api.ContextDetail) {
+	for id := range r.debouncer.pendingReleases(clientutil.ObjectKeyString(xsetObject)) {
+		for _, details := range contexts {
+			delete(details, id)
+		}
+	}
+}
+
+// releasedIDs returns IDs owned in ResourceContext but not in ownedIDs, and whether ownedIDs only releases IDs
+// without allocating IDs or changing data of IDs
+func (r *RealResourceContextControl) releasedIDs(xsetObject api.XSetObject, targetContext api.ResourceContextObject, ownedIDs map[int]*api.ContextDetail) (sets.Int, bool) {
+	persisted := map[int]*api.ContextDetail{}
+	resourceContextSpec := r.resourceContextAdapter.GetResourceContextSpec(targetContext)
+	for i := range resourceContextSpec.Contexts {
+		detail := &resourceContextSpec.Contexts[i]
+		if r.Contains(detail, api.EnumOwnerContextKey, xsetObject.GetName()) {
+			persisted[detail.ID] = detail
+		}
+	}
+
+	for id, detail := range ownedIDs {
+		if existing, exist := persisted[id]; !exist || !maps.Equal(existing.Data, detail.Data) {
+			return nil, false
+		}
+	}
+	released := sets.NewInt()
+	for id := range persisted {
+		if _, exist := ownedIDs[id]; !exist {
+			released.Insert(id)
+		}
+	}
+	return released, true
+}
+
+// FlushPendingWrites writes IDs released by xset but deferred, once debounce window since last write expires.
+// It returns the duration after which pending writes are allowed to be flushed.
+func (r *RealResourceContextControl) FlushPendingWrites(ctx context.Context, xsetObject api.XSetObject) (*time.Duration, error) {
+	key := clientutil.ObjectKeyString(xsetObject)
+	released, remaining := r.debouncer.due(key, r.getWriteDebounceWindow(xsetObject))
+	if released == nil {
+		return nil, nil
+	}
+	if remaining > 0 {
+		return &remaining, nil
+	}
+
+	contextName := r.getContextName(xsetObject)
+	unlock := contextLocks.lock(xsetObject.GetNamespace(), contextName)
+	defer unlock()
+
+	targetContext := r.resourceContextAdapter.NewResourceContext()
+	if err := r.getResourceContext(ctx, types.NamespacedName{Namespace: xsetObject.GetNamespace(), Name: contextName}, targetContext); err != nil {
+		if apiservererrors.IsNotFound(err) {
+			r.debouncer.forget(key)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fail to find ResourceContext %s/%s: %w", xsetObject.GetNamespace(), contextName, err)
+	}
+
+	ownedIDs := map[int]*api.ContextDetail{}
+	resourceContextSpec := r.resourceContextAdapter.GetResourceContextSpec(targetContext)
+	for i := range resourceContextSpec.Contexts {
+		detail := &resourceContextSpec.Contexts[i]
+		if r.Contains(detail, api.EnumOwnerContextKey, xsetObject.GetName()) && !released.Has(detail.ID) {
+			ownedIDs[detail.ID] = detail
+		}
+	}
+	if err := r.doUpdateTargetContext(ctx, xsetObject, ownedIDs, targetContext); err != nil {
+		return nil, fmt.Errorf("fail to flush released IDs %v to ResourceContext %s/%s: %w", released.List(), xsetObject.GetNamespace(), contextName, err)
+	}
+	return nil, nil
+}
@@ -0,0 +1,228 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resourcecontexts
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+	appsv1alpha1 "kusionstack.io/kube-api/apps/v1alpha1"
+	"kusionstack.io/kube-utils/controller/expectations"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+const debounceWindow = time.Minute
+
+type debounceXSetController struct {
+	mockXSetController
+}
+
+func (m *debounceXSetController) NewXSetObject() api.XSetObject {
+	return &appsv1.Deployment{}
+}
+
+func (m *debounceXSetController) GetResourceContextWriteDebounceWindow(api.XSetObject) time.Duration {
+	return debounceWindow
+}
+
+func newDebouncedResourceContextControl(objs ...client.Object) (*RealResourceContextControl, client.Client) {
+	scheme := runtime.NewScheme()
+	_ = appsv1alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+	return &RealResourceContextControl{
+		Client:                 c,
+		EventRecorder:          record.NewFakeRecorder(10),
+		xsetController:         &debounceXSetController{mockXSetController{replicas: 3}},
+		resourceContextAdapter: &DefaultResourceContextAdapter{},
+		resourceContextKeys:    defaultResourceContextKeys,
+		resourceContextGVK:     appsv1alpha1.SchemeGroupVersion.WithKind("ResourceContext"),
+		cacheExpectations:      expectations.NewxCacheExpectations(c, scheme, clock.RealClock{}),
+		xsetLabelManager:       api.NewXSetLabelAnnotationManager(nil),
+		debouncer:              newWriteDebouncer(),
+	}, c
+}
+
+func newOwnedContext(ids ...int) *appsv1alpha1.ResourceContext {
+	rc := &appsv1alpha1.ResourceContext{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	for _, id := range ids {
+		rc.Spec.Contexts = append(rc.Spec.Contexts, appsv1alpha1.ContextDetail{ID: id, Data: map[string]string{"Owner": "foo", "Revision": "rv"}})
+	}
+	return rc
+}
+
+func TestReleasedIDs(t *testing.T) {
+	detail := func(id int, revision string) *api.ContextDetail {
+		return &api.ContextDetail{ID: id, Data: map[string]string{"Owner": "foo", "Revision": revision}}
+	}
+	tests := []struct {
+		name         string
+		ownedIDs     map[int]*api.ContextDetail
+		wantReleased []int
+		wantRelease  bool
+	}{
+		{name: "unchanged", ownedIDs: map[int]*api.ContextDetail{0: detail(0, "rv"), 1: detail(1, "rv"), 2: detail(2, "rv")}, wantReleased: []int{}, wantRelease: true},
+		{name: "release only", ownedIDs: map[int]*api.ContextDetail{0: detail(0, "rv")}, wantReleased: []int{1, 2}, wantRelease: true},
+		{name: "release all", ownedIDs: nil, wantReleased: []int{0, 1, 2}, wantRelease: true},
+		{name: "allocate", ownedIDs: map[int]*api.ContextDetail{0: detail(0, "rv"), 3: detail(3, "rv")}},
+		{name: "change data", ownedIDs: map[int]*api.ContextDetail{0: detail(0, "rv2")}},
+	}
+	r, _ := newDebouncedResourceContextControl()
+	owner := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			released, releaseOnly := r.releasedIDs(owner, newOwnedContext(0, 1, 2), tt.ownedIDs)
+			if releaseOnly != tt.wantRelease {
+				t.Fatalf("releasedIDs() release only = %v, want %v", releaseOnly, tt.wantRelease)
+			}
+			if releaseOnly && !reflect.DeepEqual(released.List(), tt.wantReleased) {
+				t.Errorf("releasedIDs() = %v, want %v", released.List(), tt.wantReleased)
+			}
+		})
+	}
+}
+
+func TestWriteDebouncerTryDefer(t *testing.T) {
+	var nilDebouncer *writeDebouncer
+	if nilDebouncer.tryDefer("default/foo", debounceWindow, sets.NewInt(1)) {
+		t.Errorf("tryDefer() of nil debouncer = true")
+	}
+
+	d := newWriteDebouncer()
+	if d.tryDefer("default/foo", debounceWindow, sets.NewInt(1)) {
+		t.Errorf("tryDefer() without recent write = true")
+	}
+	d.recordWrite("default/foo")
+	if !d.tryDefer("default/foo", debounceWindow, sets.NewInt(1)) {
+		t.Errorf("tryDefer() within window = false")
+	}
+	if got := d.pendingReleases("default/foo").List(); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("pendingReleases() = %v, want [1]", got)
+	}
+	d.lastWrite["default/foo"] = time.Now().Add(-debounceWindow)
+	if d.tryDefer("default/foo", debounceWindow, sets.NewInt(1, 2)) {
+		t.Errorf("tryDefer() after window = true")
+	}
+
+	d.recordWrite("default/foo")
+	if got := d.pendingReleases("default/foo"); got.Len() != 0 {
+		t.Errorf("pendingReleases() = %v after write, want none", got.List())
+	}
+	d.tryDefer("default/foo", debounceWindow, sets.NewInt(1))
+	d.forget("default/foo")
+	if len(d.lastWrite) != 0 || len(d.pending) != 0 {
+		t.Errorf("forget() leaves %v and %v", d.lastWrite, d.pending)
+	}
+}
+
+func TestHidePendingReleases(t *testing.T) {
+	r, _ := newDebouncedResourceContextControl()
+	owner := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	r.debouncer.recordWrite("default/foo")
+	r.debouncer.tryDefer("default/foo", debounceWindow, sets.NewInt(1))
+
+	owned := map[int]*api.ContextDetail{0: {ID: 0}, 1: {ID: 1}}
+	existing := map[int]*api.ContextDetail{0: {ID: 0}, 1: {ID: 1}, 2: {ID: 2}}
+	r.hidePendingReleases(owner, owned, existing)
+	if _, exist := owned[1]; exist || len(owned) != 1 {
+		t.Errorf("hidePendingReleases() leaves owned IDs %v", owned)
+	}
+	if _, exist := existing[1]; exist || len(existing) != 2 {
+		t.Errorf("hidePendingReleases() leaves existing IDs %v", existing)
+	}
+}
+
+func TestUpdateToTargetContextDebounced(t *testing.T) {
+	r, c := newDebouncedResourceContextControl(newOwnedContext(0, 1, 2))
+	owner := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	ownedIDs := func(ids ...int) map[int]*api.ContextDetail {
+		details := map[int]*api.ContextDetail{}
+		for _, id := range ids {
+			details[id] = &api.ContextDetail{ID: id, Data: map[string]string{"Owner": "foo", "Revision": "rv"}}
+		}
+		return details
+	}
+	persisted := func() []int {
+		rc := &appsv1alpha1.ResourceContext{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "foo"}, rc); err != nil {
+			t.Fatalf("fail to get ResourceContext: %v", err)
+		}
+		var ids []int
+		for _, detail := range rc.Spec.Contexts {
+			ids = append(ids, detail.ID)
+		}
+		return ids
+	}
+
+	// releasing without recent write is written at once
+	if err := r.UpdateToTargetContext(context.TODO(), owner, ownedIDs(0, 1)); err != nil {
+		t.Fatalf("UpdateToTargetContext() = %v", err)
+	}
+	if got := persisted(); !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Fatalf("got persisted IDs %v, want [0 1]", got)
+	}
+
+	// releasing within window is deferred
+	if err := r.UpdateToTargetContext(context.TODO(), owner, ownedIDs(0)); err != nil {
+		t.Fatalf("UpdateToTargetContext() = %v", err)
+	}
+	if got := persisted(); !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Errorf("got persisted IDs %v, want release of 1 deferred", got)
+	}
+	if remaining, err := r.FlushPendingWrites(context.TODO(), owner); err != nil || remaining == nil || *remaining <= 0 {
+		t.Errorf("FlushPendingWrites() within window = %v, %v, want remaining window", remaining, err)
+	}
+
+	// flushed after window
+	r.debouncer.lastWrite["default/foo"] = time.Now().Add(-debounceWindow)
+	if remaining, err := r.FlushPendingWrites(context.TODO(), owner); err != nil || remaining != nil {
+		t.Fatalf("FlushPendingWrites() after window = %v, %v", remaining, err)
+	}
+	if got := persisted(); !reflect.DeepEqual(got, []int{0}) {
+		t.Errorf("got persisted IDs %v after flush, want [0]", got)
+	}
+
+	// allocating is never deferred
+	if err := r.UpdateToTargetContext(context.TODO(), owner, ownedIDs(0, 3)); err != nil {
+		t.Fatalf("UpdateToTargetContext() = %v", err)
+	}
+	if got := persisted(); !reflect.DeepEqual(got, []int{0, 3}) {
+		t.Errorf("got persisted IDs %v, want [0 3]", got)
+	}
+
+	if err := r.UpdateToTargetContext(context.TODO(), owner, ownedIDs(0)); err != nil {
+		t.Fatalf("UpdateToTargetContext() = %v", err)
+	}
+	r.ForgetOwner("default", "foo")
+	if remaining, err := r.FlushPendingWrites(context.TODO(), owner); err != nil || remaining != nil {
+		t.Errorf("FlushPendingWrites() of forgotten owner = %v, %v", remaining, err)
+	}
+	if got := persisted(); !reflect.DeepEqual(got, []int{0, 3}) {
+		t.Errorf("got persisted IDs %v after owner forgotten, want [0 3]", got)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"github.com/go-logr/logr"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/synccontrols"
+)
+
+// resyncRequested returns true if XSet is annotated with a resync value not handled yet. The value is marked handled
+// by markResyncHandled only after reconcile succeeds, so that a failed resync is retried with the same value.
+// Handled values are kept in memory, so that a pending value is handled once more after controller restarts.
+func (r *xSetCommonReconciler) resyncRequested(key string, instance api.XSetObject) bool {
+	value, ok := instance.GetAnnotations()[api.XSetResyncAnnotationKey]
+	if !ok || value == "" {
+		r.resyncHandled.Delete(key)
+		return false
+	}
+	handled, loaded := r.resyncHandled.Load(key)
+	return !loaded || handled.(string) != value
+}
+
+// markResyncHandled marks the resync value of XSet handled after a successful reconcile
+func (r *xSetCommonReconciler) markResyncHandled(key string, instance api.XSetObject) {
+	if value := instance.GetAnnotations()[api.XSetResyncAnnotationKey]; value != "" {
+		r.resyncHandled.Store(key, value)
+	}
+}
+
+// dumpSyncDecisions logs what the reconcile decided for each target, for operators to check an XSet which appears stuck.
+func dumpSyncDecisions(logger logr.Logger, syncContext *synccontrols.SyncContext, status *api.XSetStatus) {
+	logger.Info("resync decisions",
+		"currentRevision", status.CurrentRevision,
+		"updatedRevision", status.UpdatedRevision,
+		"ownedIDs", len(syncContext.OwnedIds),
+		"replicas", status.Replicas,
+		"updatedReplicas", status.UpdatedReplicas,
+		"availableReplicas", status.AvailableReplicas,
+		"conditions", status.Conditions)
+	for _, wrapper := range syncContext.TargetWrappers {
+		if wrapper.PlaceHolder {
+			logger.Info("resync decision", "id", wrapper.ID, "placeholder", true)
+			continue
+		}
+		logger.Info("resync decision",
+			"id", wrapper.ID,
+			"target", wrapper.GetName(),
+			"deleting", wrapper.GetDeletionTimestamp() != nil,
+			"toDelete", wrapper.ToDelete,
+			"toExclude", wrapper.ToExclude,
+			"duringScaleInOps", wrapper.IsDuringScaleInOps,
+			"duringUpdateOps", wrapper.IsDuringUpdateOps,
+			"decorationChanged", wrapper.DecorationChanged,
+			"context", wrapper.ContextDetail)
+	}
+}
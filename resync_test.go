@@ -0,0 +1,65 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+func TestResyncRequested(t *testing.T) {
+	r := &xSetCommonReconciler{}
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	setResync := func(value string) {
+		xset.Annotations = map[string]string{api.XSetResyncAnnotationKey: value}
+	}
+
+	if r.resyncRequested("default/foo", xset) {
+		t.Fatal("resync is requested without annotation")
+	}
+	setResync("1")
+	if !r.resyncRequested("default/foo", xset) {
+		t.Fatal("resync is not requested by annotation")
+	}
+	// reconcile failed, and the same value is handled again
+	if !r.resyncRequested("default/foo", xset) {
+		t.Fatal("resync is not retried before marked handled")
+	}
+	r.markResyncHandled("default/foo", xset)
+	if r.resyncRequested("default/foo", xset) {
+		t.Fatal("resync is requested again after handled")
+	}
+	setResync("2")
+	if !r.resyncRequested("default/foo", xset) {
+		t.Fatal("resync is not requested by a new value")
+	}
+	r.markResyncHandled("default/foo", xset)
+
+	// handled value is forgotten once annotation is removed, so that the same value requests resync again
+	xset.Annotations = nil
+	if r.resyncRequested("default/foo", xset) {
+		t.Fatal("resync is requested after annotation removed")
+	}
+	setResync("2")
+	if !r.resyncRequested("default/foo", xset) {
+		t.Fatal("resync is not requested by the value added back")
+	}
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package revisionowner
+
+import (
+	"encoding/json"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+var templateMetadataFields = []string{"labels", "annotations"}
+
+// GetExcludedTemplateMetadata returns labels and annotations of the current template of XSet which are excluded from
+// revisions by RevisionHashExclusionAdapter, so that they are applied to targets created from any revision
+func GetExcludedTemplateMetadata(xsetController api.XSetController, xset api.XSetObject) (map[string]string, map[string]string, error) {
+	keys := getRevisionHashExcludedKeys(xsetController, xset)
+	if len(keys) == 0 {
+		return nil, nil, nil
+	}
+	patch, err := xsetController.GetXSetPatch(xset)
+	if err != nil {
+		return nil, nil, err
+	}
+	data := map[string]interface{}{}
+	if err := json.Unmarshal(patch, &data); err != nil {
+		return nil, nil, err
+	}
+	excluded := make([]map[string]string, len(templateMetadataFields))
+	for i, field := range templateMetadataFields {
+		values, _, err := unstructured.NestedStringMap(data, "spec", "template", "metadata", field)
+		if err != nil {
+			return nil, nil, err
+		}
+		for key, value := range values {
+			if !isKeyExcluded(key, keys) {
+				continue
+			}
+			if excluded[i] == nil {
+				excluded[i] = map[string]string{}
+			}
+			excluded[i][key] = value
+		}
+	}
+	return excluded[0], excluded[1], nil
+}
+
+// RemoveExcludedTemplateMetadata removes labels and annotations excluded from revisions by RevisionHashExclusionAdapter
+// from object rendered from template, since existing targets are not updated when they change in template
+func RemoveExcludedTemplateMetadata(xsetController api.XSetController, xset api.XSetObject, object metav1.Object) {
+	keys := getRevisionHashExcludedKeys(xsetController, xset)
+	if len(keys) == 0 {
+		return
+	}
+	for _, values := range []map[string]string{object.GetLabels(), object.GetAnnotations()} {
+		for key := range values {
+			if isKeyExcluded(key, keys) {
+				delete(values, key)
+			}
+		}
+	}
+}
+
+func getRevisionHashExcludedKeys(xsetController api.XSetController, xset api.XSetObject) []string {
+	if adapter, ok := xsetController.(api.RevisionHashExclusionAdapter); ok {
+		return adapter.GetRevisionHashExcludedKeys(xset)
+	}
+	return nil
+}
+
+// excludeTemplateMetadata removes excluded keys from labels and annotations of template in patch, which is returned
+// as is if nothing is excluded, so that revisions of XSets without excluded keys do not change
+func excludeTemplateMetadata(patch []byte, keys []string) ([]byte, error) {
+	if len(keys) == 0 {
+		return patch, nil
+	}
+	data := map[string]interface{}{}
+	if err := json.Unmarshal(patch, &data); err != nil {
+		return nil, err
+	}
+	excluded := false
+	for _, field := range templateMetadataFields {
+		values, found, err := unstructured.NestedFieldNoCopy(data, "spec", "template", "metadata", field)
+		if err != nil || !found {
+			continue
+		}
+		valueMap, ok := values.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key := range valueMap {
+			if isKeyExcluded(key, keys) {
+				delete(valueMap, key)
+				excluded = true
+			}
+		}
+	}
+	if !excluded {
+		return patch, nil
+	}
+	return json.Marshal(data)
+}
+
+func isKeyExcluded(key string, keys []string) bool {
+	for _, excluded := range keys {
+		if prefix, ok := strings.CutSuffix(excluded, "*"); ok && strings.HasPrefix(key, prefix) {
+			return true
+		}
+		if excluded == key {
+			return true
+		}
+	}
+	return false
+}
@@ -18,12 +18,14 @@ package revisionowner
 
 import (
 	"context"
+	"fmt"
 
 	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"kusionstack.io/kube-utils/controller/history"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"kusionstack.io/kube-xset/api"
 	"kusionstack.io/kube-xset/xcontrol"
@@ -35,12 +37,16 @@ type revisionOwner struct {
 	api.XSetController
 
 	xcontrol.TargetControl
+
+	// reader reads templates referenced by XSets
+	reader client.Reader
 }
 
-func NewRevisionOwner(xsetController api.XSetController, xcontrol xcontrol.TargetControl) *revisionOwner {
+func NewRevisionOwner(xsetController api.XSetController, xcontrol xcontrol.TargetControl, reader client.Reader) *revisionOwner {
 	return &revisionOwner{
 		XSetController: xsetController,
 		TargetControl:  xcontrol,
+		reader:         reader,
 	}
 }
 
@@ -65,6 +71,12 @@ func (r *revisionOwner) GetInUsedRevisions(parent metav1.Object) (sets.String, e
 
 	res.Insert(status.UpdatedRevision)
 	res.Insert(status.CurrentRevision)
+	// revisions pinned by ratios are kept even if no target runs them yet
+	if rollingUpdate := spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil && rollingUpdate.ByRevisionRatios != nil {
+		for _, ratio := range rollingUpdate.ByRevisionRatios.Revisions {
+			res.Insert(ratio.Revision)
+		}
+	}
 
 	targets, _, err := r.TargetControl.GetFilteredTargets(context.TODO(), spec.Selector, xSetObject)
 	if err != nil {
@@ -100,6 +112,47 @@ func (r *revisionOwner) GetCurrentRevision(obj metav1.Object) string {
 	return r.XSetController.GetXSetStatus(xset).CurrentRevision
 }
 
+// getXSetPatch returns patch of XSet recorded in revision, in which template is replaced by the one referenced by XSet,
+// and keys excluded by RevisionHashExclusionAdapter are removed from template, and which is customized by
+// RevisionDataAdapter if implemented
 func (r *revisionOwner) getXSetPatch(obj metav1.Object) ([]byte, error) {
-	return r.XSetController.GetXSetPatch(obj)
+	patch, err := r.XSetController.GetXSetPatch(obj)
+	if err != nil {
+		return nil, err
+	}
+	xset, ok := obj.(api.XSetObject)
+	if !ok {
+		return patch, nil
+	}
+	if patch, err = r.applyTemplateRef(xset, patch); err != nil {
+		return nil, err
+	}
+	if patch, err = excludeTemplateMetadata(patch, getRevisionHashExcludedKeys(r.XSetController, xset)); err != nil {
+		return nil, fmt.Errorf("fail to exclude template metadata of %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+	}
+	adapter, ok := r.XSetController.(api.RevisionDataAdapter)
+	if !ok {
+		return patch, nil
+	}
+	data, err := adapter.GetRevisionData(xset, patch)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get revision data of %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+	}
+	return data, nil
+}
+
+// applyTemplateRef replaces template in patch with the one referenced by XSet, if enabled by TemplateRefAdapter
+func (r *revisionOwner) applyTemplateRef(xset api.XSetObject, patch []byte) ([]byte, error) {
+	if _, ok := r.XSetController.(api.TemplateRefAdapter); !ok {
+		return patch, nil
+	}
+	ref := r.XSetController.GetXSetSpec(xset).TemplateRef
+	if ref == nil {
+		return patch, nil
+	}
+	template, err := ResolveTemplateRef(context.TODO(), r.reader, r.XSetController, xset, ref)
+	if err != nil {
+		return nil, fmt.Errorf("fail to resolve template referenced by %s/%s: %w", xset.GetNamespace(), xset.GetName(), err)
+	}
+	return replaceTemplate(patch, template)
 }
@@ -0,0 +1,223 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package revisionowner
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+type patchController struct {
+	api.XSetController
+}
+
+func (c *patchController) GetXSetPatch(metav1.Object) ([]byte, error) {
+	return []byte(`{"spec":{"template":{"metadata":{"annotations":{"deployed-at":"now"}}}}}`), nil
+}
+
+type revisionDataController struct {
+	patchController
+}
+
+// GetRevisionData drops annotations of template and records config version of XSet instead
+func (c *revisionDataController) GetRevisionData(object api.XSetObject, patch []byte) ([]byte, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(patch, &data); err != nil {
+		return nil, err
+	}
+	template := data["spec"].(map[string]interface{})["template"].(map[string]interface{})
+	delete(template["metadata"].(map[string]interface{}), "annotations")
+	data["configVersion"] = object.GetAnnotations()["config-version"]
+	return json.Marshal(data)
+}
+
+func TestRevisionOwner_GetPatch(t *testing.T) {
+	xset := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:        "foo",
+		Annotations: map[string]string{"config-version": "v2"},
+	}}
+
+	patch, err := NewRevisionOwner(&patchController{}, nil, nil).GetPatch(xset)
+	if err != nil {
+		t.Fatalf("fail to get patch: %v", err)
+	}
+	if want := `{"spec":{"template":{"metadata":{"annotations":{"deployed-at":"now"}}}}}`; string(patch) != want {
+		t.Errorf("got patch %s, want %s", patch, want)
+	}
+
+	patch, err = NewRevisionOwner(&revisionDataController{}, nil, nil).GetPatch(xset)
+	if err != nil {
+		t.Fatalf("fail to get patch: %v", err)
+	}
+	if want := `{"configVersion":"v2","spec":{"template":{"metadata":{}}}}`; string(patch) != want {
+		t.Errorf("got patch %s, want %s", patch, want)
+	}
+}
+
+type hashExclusionController struct {
+	revisionDataController
+}
+
+func (c *hashExclusionController) GetXSetPatch(metav1.Object) ([]byte, error) {
+	return []byte(`{"spec":{"template":{"metadata":{"labels":{"app":"foo","argocd.argoproj.io/instance":"foo"},"annotations":{"deployed-at":"now"}}}}}`), nil
+}
+
+func (c *hashExclusionController) GetRevisionData(_ api.XSetObject, patch []byte) ([]byte, error) {
+	return patch, nil
+}
+
+func (c *hashExclusionController) GetRevisionHashExcludedKeys(api.XSetObject) []string {
+	return []string{"deployed-at", "argocd.argoproj.io/*"}
+}
+
+func TestRevisionOwner_HashExclusion(t *testing.T) {
+	xset := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+	controller := &hashExclusionController{}
+	patch, err := NewRevisionOwner(controller, nil, nil).GetPatch(xset)
+	if err != nil {
+		t.Fatalf("fail to get patch: %v", err)
+	}
+	if want := `{"spec":{"template":{"metadata":{"annotations":{},"labels":{"app":"foo"}}}}}`; string(patch) != want {
+		t.Errorf("got patch %s, want %s", patch, want)
+	}
+
+	labels, annotations, err := GetExcludedTemplateMetadata(controller, xset)
+	if err != nil {
+		t.Fatalf("fail to get excluded template metadata: %v", err)
+	}
+	if len(labels) != 1 || labels["argocd.argoproj.io/instance"] != "foo" || len(annotations) != 1 || annotations["deployed-at"] != "now" {
+		t.Errorf("got excluded labels %v and annotations %v", labels, annotations)
+	}
+
+	// patch is kept as is if nothing is excluded
+	if patch, _ := excludeTemplateMetadata([]byte(`{"spec": {}}`), []string{"deployed-at"}); string(patch) != `{"spec": {}}` {
+		t.Errorf("got patch %s, want it unchanged", patch)
+	}
+}
+
+type templateRefController struct {
+	revisionDataController
+}
+
+func (c *templateRefController) GetRevisionData(_ api.XSetObject, patch []byte) ([]byte, error) {
+	return patch, nil
+}
+
+func (c *templateRefController) GetXSetSpec(object api.XSetObject) *api.XSetSpec {
+	return &api.XSetSpec{TemplateRef: &api.TemplateReference{
+		APIVersion: object.GetAnnotations()["ref-api-version"],
+		Kind:       object.GetAnnotations()["ref-kind"],
+		Name:       "template",
+	}}
+}
+
+func (c *templateRefController) GetTemplateRefKinds() []metav1.TypeMeta {
+	return []metav1.TypeMeta{
+		{APIVersion: "v1", Kind: "ConfigMap"},
+		{APIVersion: "apps/v1", Kind: "Deployment"},
+	}
+}
+
+func TestRevisionOwner_TemplateRef(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "template"},
+		Data:       map[string]string{"template": "metadata:\n  labels:\n    app: foo\n"},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "template"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "bar"}},
+		}},
+	}
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(configMap, deployment).Build()
+	owner := NewRevisionOwner(&templateRefController{}, nil, c)
+
+	xset := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	patch, err := owner.GetPatch(xset)
+	if err != nil {
+		t.Fatalf("fail to get patch: %v", err)
+	}
+	if want := `{"spec":{"template":{"metadata":{"labels":{"app":"foo"}}}}}`; string(patch) != want {
+		t.Errorf("got patch %s, want %s", patch, want)
+	}
+
+	xset.Annotations = map[string]string{"ref-api-version": "apps/v1", "ref-kind": "Deployment"}
+	patch, err = owner.GetPatch(xset)
+	if err != nil {
+		t.Fatalf("fail to get patch: %v", err)
+	}
+	if want := `{"spec":{"template":{"metadata":{"creationTimestamp":null,"labels":{"app":"bar"}},"spec":{"containers":null}}}}`; string(patch) != want {
+		t.Errorf("got patch %s, want %s", patch, want)
+	}
+
+	// kinds not returned by TemplateRefAdapter are not allowed
+	xset.Annotations = map[string]string{"ref-kind": "Secret"}
+	if _, err := owner.GetPatch(xset); err == nil {
+		t.Errorf("expected error referencing Secret as template")
+	}
+}
+
+// ratioController pins revisions by ratios in spec, whose status is at revision foo-3
+type ratioController struct {
+	api.XSetController
+}
+
+func (c *ratioController) GetXSetSpec(api.XSetObject) *api.XSetSpec {
+	return &api.XSetSpec{UpdateStrategy: api.UpdateStrategy{RollingUpdate: &api.RollingUpdateStrategy{
+		ByRevisionRatios: &api.ByRevisionRatios{Revisions: []api.RevisionRatio{{Revision: "foo-1", Percent: 20}}},
+	}}}
+}
+
+func (c *ratioController) GetXSetStatus(api.XSetObject) *api.XSetStatus {
+	return &api.XSetStatus{CurrentRevision: "foo-3", UpdatedRevision: "foo-3"}
+}
+
+// targetsControl lists targets given
+type targetsControl struct {
+	xcontrol.TargetControl
+	targets []client.Object
+}
+
+func (c *targetsControl) GetFilteredTargets(context.Context, *metav1.LabelSelector, api.XSetObject) ([]client.Object, []client.Object, error) {
+	return c.targets, c.targets, nil
+}
+
+func TestRevisionOwner_GetInUsedRevisions(t *testing.T) {
+	target := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{appsv1.ControllerRevisionHashLabelKey: "foo-2"}}}
+	owner := NewRevisionOwner(&ratioController{}, &targetsControl{targets: []client.Object{target}}, nil)
+
+	// revision pinned by ratios is in use before any target runs it
+	revisions, err := owner.GetInUsedRevisions(&appsv1.Deployment{})
+	if err != nil {
+		t.Fatalf("GetInUsedRevisions() = %v", err)
+	}
+	if want := []string{"foo-1", "foo-2", "foo-3"}; !reflect.DeepEqual(revisions.List(), want) {
+		t.Errorf("GetInUsedRevisions() = %v, want %v", revisions.List(), want)
+	}
+}
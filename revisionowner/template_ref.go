@@ -0,0 +1,124 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package revisionowner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+const (
+	// DefaultConfigMapTemplateKey is the default key of ConfigMap data holding template
+	DefaultConfigMapTemplateKey = "template"
+	// DefaultTemplateFieldPath is the default path of field holding template in objects other than ConfigMap
+	DefaultTemplateFieldPath = "spec.template"
+)
+
+// GetTemplateRefGVK returns GroupVersionKind of object referenced as template, with defaults applied
+func GetTemplateRefGVK(ref *api.TemplateReference) schema.GroupVersionKind {
+	typeMeta := metav1.TypeMeta{APIVersion: ref.APIVersion, Kind: ref.Kind}
+	if typeMeta.APIVersion == "" {
+		typeMeta.APIVersion = "v1"
+	}
+	if typeMeta.Kind == "" {
+		typeMeta.Kind = "ConfigMap"
+	}
+	return typeMeta.GroupVersionKind()
+}
+
+// ResolveTemplateRef reads template referenced by XSet in JSON. Referenced kind must be allowed by TemplateRefAdapter,
+// so that it is read from cache.
+func ResolveTemplateRef(ctx context.Context, reader client.Reader, xsetController api.XSetController, xset api.XSetObject, ref *api.TemplateReference) ([]byte, error) {
+	gvk := GetTemplateRefGVK(ref)
+	if !isTemplateRefKindAllowed(xsetController, gvk) {
+		return nil, fmt.Errorf("kind %s is not allowed to be referenced as template", gvk.String())
+	}
+	key := client.ObjectKey{Namespace: xset.GetNamespace(), Name: ref.Name}
+	if gvk.Group == "" && gvk.Kind == "ConfigMap" {
+		configMap := &corev1.ConfigMap{}
+		if err := reader.Get(ctx, key, configMap); err != nil {
+			return nil, err
+		}
+		dataKey := ref.Key
+		if dataKey == "" {
+			dataKey = DefaultConfigMapTemplateKey
+		}
+		data, exist := configMap.Data[dataKey]
+		if !exist {
+			return nil, fmt.Errorf("key %s not found in ConfigMap %s", dataKey, ref.Name)
+		}
+		return yaml.YAMLToJSON([]byte(data))
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := reader.Get(ctx, key, obj); err != nil {
+		return nil, err
+	}
+	fieldPath := ref.Key
+	if fieldPath == "" {
+		fieldPath = DefaultTemplateFieldPath
+	}
+	template, found, err := unstructured.NestedFieldNoCopy(obj.Object, strings.Split(fieldPath, ".")...)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("field %s not found in %s %s", fieldPath, gvk.Kind, ref.Name)
+	}
+	return json.Marshal(template)
+}
+
+func isTemplateRefKindAllowed(xsetController api.XSetController, gvk schema.GroupVersionKind) bool {
+	adapter, ok := xsetController.(api.TemplateRefAdapter)
+	if !ok {
+		return false
+	}
+	for _, kind := range adapter.GetTemplateRefKinds() {
+		if kind.GroupVersionKind() == gvk {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceTemplate replaces spec.template in patch with template
+func replaceTemplate(patch, template []byte) ([]byte, error) {
+	data := map[string]interface{}{}
+	if err := json.Unmarshal(patch, &data); err != nil {
+		return nil, err
+	}
+	var templateData interface{}
+	if err := json.Unmarshal(template, &templateData); err != nil {
+		return nil, err
+	}
+	if err := unstructured.SetNestedField(data, templateData, "spec", "template"); err != nil {
+		return nil, err
+	}
+	return json.Marshal(data)
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subresources
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"kusionstack.io/kube-utils/controller/expectations"
+	"kusionstack.io/kube-utils/controller/mixin"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+var (
+	ConfigMapGvk = corev1.SchemeGroupVersion.WithKind("ConfigMap")
+	SecretGvk    = corev1.SchemeGroupVersion.WithKind("Secret")
+)
+
+var (
+	_ SubresourceControl = &RealConfigControl{}
+	_ api.WatchProvider  = &RealConfigControl{}
+)
+
+// RealConfigControl manages per-instance ConfigMaps and Secrets rendered from templates of SubResourceConfigAdapter.
+// Secrets are listed and watched as metadata only, so that Secrets in cluster are not cached in full.
+type RealConfigControl struct {
+	subresourceBase
+	configAdapter api.SubResourceConfigAdapter
+}
+
+func NewRealConfigControl(mixin *mixin.ReconcilerMixin, expectations expectations.CacheExpectationsInterface, xsetLabelAnnoMgr api.XSetLabelAnnotationManager, xsetController api.XSetController) *RealConfigControl {
+	// requires implementation of SubResourceConfigAdapter
+	configAdapter, ok := GetSubresourceConfigAdapter(xsetController)
+	if !ok {
+		return nil
+	}
+	return &RealConfigControl{
+		subresourceBase: subresourceBase{
+			client:           mixin.Client,
+			scheme:           mixin.Scheme,
+			expectations:     expectations,
+			xsetLabelAnnoMgr: xsetLabelAnnoMgr,
+			xsetController:   xsetController,
+		},
+		configAdapter: configAdapter,
+	}
+}
+
+// ConfigName returns the name of per-instance ConfigMap or Secret rendered from template
+func ConfigName(xsetName, templateName string, id int) string {
+	return fmt.Sprintf("%s-%s-%d", xsetName, templateName, id)
+}
+
+// configTemplateData is the data used to render values of ConfigMap and Secret templates
+type configTemplateData struct {
+	ID   int
+	Data map[string]string
+}
+
+// CreateTargetSubresources creates ConfigMaps and Secrets rendered from templates for the instance ID, if not exist
+func (cc *RealConfigControl) CreateTargetSubresources(ctx context.Context, xset api.XSetObject, contextDetail *api.ContextDetail) error {
+	data := configTemplateData{ID: contextDetail.ID, Data: contextDetail.Data}
+	for _, tmp := range cc.configAdapter.GetXSetConfigMapTemplates(xset) {
+		cm := tmp.DeepCopy()
+		var err error
+		for k, v := range cm.Data {
+			if cm.Data[k], err = renderConfigValue(v, data); err != nil {
+				return fmt.Errorf("fail to render ConfigMap template %s key %s: %w", tmp.Name, k, err)
+			}
+		}
+		cm.Name = ConfigName(xset.GetName(), tmp.Name, contextDetail.ID)
+		if err := cc.create(ctx, xset, cm, ConfigMapGvk, contextDetail.ID); err != nil {
+			return err
+		}
+	}
+	for _, tmp := range cc.configAdapter.GetXSetSecretTemplates(xset) {
+		secret := tmp.DeepCopy()
+		for k, v := range secret.StringData {
+			rendered, err := renderConfigValue(v, data)
+			if err != nil {
+				return fmt.Errorf("fail to render Secret template %s key %s: %w", tmp.Name, k, err)
+			}
+			secret.StringData[k] = rendered
+		}
+		secret.Name = ConfigName(xset.GetName(), tmp.Name, contextDetail.ID)
+		if err := cc.create(ctx, xset, secret, SecretGvk, contextDetail.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AdoptSubresources adopts orphaned ConfigMaps and Secrets matching selector of XSet
+func (cc *RealConfigControl) AdoptSubresources(ctx context.Context, xset api.XSetObject) error {
+	cms, secrets, err := cc.listConfigs(ctx, xset)
+	if err != nil {
+		return err
+	}
+	return errors.Join(cc.adopt(ctx, xset, cms), cc.adopt(ctx, xset, secrets))
+}
+
+// DeleteUnusedSubresources deletes ConfigMaps and Secrets whose instance ID is reclaimed or whose template is removed
+func (cc *RealConfigControl) DeleteUnusedSubresources(ctx context.Context, xset api.XSetObject, ownedIDs map[int]*api.ContextDetail) error {
+	cmNames, secretNames := sets.String{}, sets.String{}
+	for id := range ownedIDs {
+		for _, tmp := range cc.configAdapter.GetXSetConfigMapTemplates(xset) {
+			cmNames.Insert(ConfigName(xset.GetName(), tmp.Name, id))
+		}
+		for _, tmp := range cc.configAdapter.GetXSetSecretTemplates(xset) {
+			secretNames.Insert(ConfigName(xset.GetName(), tmp.Name, id))
+		}
+	}
+
+	cms, secrets, err := cc.listConfigs(ctx, xset)
+	if err != nil {
+		return err
+	}
+	return errors.Join(
+		cc.deleteUnused(ctx, xset, cms, cmNames, ConfigMapGvk),
+		cc.deleteUnused(ctx, xset, secrets, secretNames, SecretGvk),
+	)
+}
+
+func (cc *RealConfigControl) RegisterWatches(c controller.Controller) error {
+	if err := cc.watch(c, &corev1.ConfigMap{}); err != nil {
+		return err
+	}
+	// watch Secrets as metadata only, so that contents of all Secrets in cluster are not cached by informer
+	secret := &metav1.PartialObjectMetadata{}
+	secret.SetGroupVersionKind(SecretGvk)
+	return cc.watch(c, secret)
+}
+
+func (cc *RealConfigControl) listConfigs(ctx context.Context, xset api.XSetObject) (cms, secrets []client.Object, err error) {
+	if cms, err = cc.list(ctx, xset, &corev1.ConfigMapList{}); err != nil {
+		return nil, nil, fmt.Errorf("fail to list ConfigMaps: %w", err)
+	}
+	secretList := &metav1.PartialObjectMetadataList{}
+	secretList.SetGroupVersionKind(SecretGvk.GroupVersion().WithKind(SecretGvk.Kind + "List"))
+	if secrets, err = cc.list(ctx, xset, secretList); err != nil {
+		return nil, nil, fmt.Errorf("fail to list Secrets: %w", err)
+	}
+	return cms, secrets, nil
+}
+
+func renderConfigValue(value string, data configTemplateData) (string, error) {
+	tmpl, err := template.New("config").Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
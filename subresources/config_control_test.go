@@ -0,0 +1,113 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subresources
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+type configController struct {
+	*pvcController
+	configMaps []corev1.ConfigMap
+	secrets    []corev1.Secret
+}
+
+func (c *configController) GetXSetConfigMapTemplates(api.XSetObject) []corev1.ConfigMap {
+	return c.configMaps
+}
+
+func (c *configController) GetXSetSecretTemplates(api.XSetObject) []corev1.Secret { return c.secrets }
+
+func newTestConfigControl(controller *configController, objs ...client.Object) (*RealConfigControl, client.Client, *recordingExpectations) {
+	pc, c, exp := newTestPvcControl(controller, controller, objs...)
+	return &RealConfigControl{
+		subresourceBase: subresourceBase{
+			client:           c,
+			scheme:           pc.scheme,
+			expectations:     exp,
+			xsetLabelAnnoMgr: pc.xsetLabelAnnoMgr,
+			xsetController:   controller,
+		},
+		configAdapter: controller,
+	}, c, exp
+}
+
+func TestConfigControl(t *testing.T) {
+	controller := &configController{
+		pvcController: &pvcController{},
+		configMaps: []corev1.ConfigMap{{
+			ObjectMeta: metav1.ObjectMeta{Name: "conf"},
+			Data:       map[string]string{"zone": "{{ .Data.zone }}-{{ .ID }}"},
+		}},
+		secrets: []corev1.Secret{{
+			ObjectMeta: metav1.ObjectMeta{Name: "cred"},
+			StringData: map[string]string{"user": "user-{{ .ID }}"},
+		}},
+	}
+	cc, c, exp := newTestConfigControl(controller)
+	xset := newTestXSet()
+	for id := 0; id < 2; id++ {
+		if err := cc.CreateTargetSubresources(context.TODO(), xset, &api.ContextDetail{ID: id, Data: map[string]string{"zone": "a"}}); err != nil {
+			t.Fatalf("CreateTargetSubresources() = %v", err)
+		}
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: ConfigName("foo", "conf", 1)}, cm); err != nil {
+		t.Fatal(err)
+	}
+	if cm.Data["zone"] != "a-1" {
+		t.Errorf("rendered ConfigMap data = %q, want a-1", cm.Data["zone"])
+	}
+	if ref := metav1.GetControllerOf(cm); ref == nil || ref.UID != xset.UID {
+		t.Errorf("ConfigMap controller = %v, want XSet", ref)
+	}
+	secret := &corev1.Secret{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: ConfigName("foo", "cred", 0)}, secret); err != nil {
+		t.Fatal(err)
+	}
+	if secret.StringData["user"] != "user-0" {
+		t.Errorf("rendered Secret data = %q, want user-0", secret.StringData["user"])
+	}
+	// Secrets are only cached as metadata, which expectations can not observe
+	want := []string{"create ConfigMap default/foo-conf-0", "create ConfigMap default/foo-conf-1"}
+	if len(exp.records) != len(want) || exp.records[0] != want[0] || exp.records[1] != want[1] {
+		t.Errorf("expectations = %v, want %v", exp.records, want)
+	}
+
+	// instance 1 is reclaimed
+	if err := cc.DeleteUnusedSubresources(context.TODO(), xset, map[int]*api.ContextDetail{0: {ID: 0}}); err != nil {
+		t.Fatalf("DeleteUnusedSubresources() = %v", err)
+	}
+	cms, secrets, err := cc.listConfigs(context.TODO(), xset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cms) != 1 || cms[0].GetName() != "foo-conf-0" || len(secrets) != 1 || secrets[0].GetName() != "foo-cred-0" {
+		t.Errorf("left configs = %v, %v, want those of instance 0", cms, secrets)
+	}
+	if _, ok := secrets[0].(*metav1.PartialObjectMetadata); !ok {
+		t.Errorf("Secrets are listed as %T, want metadata only", secrets[0])
+	}
+}
@@ -22,3 +22,8 @@ func GetSubresourcePvcAdapter(control api.XSetController) (adapter api.SubResour
 	adapter, enabled = control.(api.SubResourcePvcAdapter)
 	return adapter, enabled
 }
+
+func GetSubresourceConfigAdapter(control api.XSetController) (adapter api.SubResourceConfigAdapter, enabled bool) {
+	adapter, enabled = control.(api.SubResourceConfigAdapter)
+	return adapter, enabled
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subresources
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// adoptOrphanedPvc adopts an orphaned pvc labeled with the instance ID and pvc template name, e.g., left by a previous
+// XSet or provisioned manually, instead of creating a duplicate one. It returns an error if the orphaned pvc conflicts
+// with pvc template in storage class or size.
+func (pc *RealPvcControl) adoptOrphanedPvc(ctx context.Context, id string, xset api.XSetObject, pvcTmp *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
+	selector := labels.NewSelector()
+	for _, req := range []struct {
+		key    api.XSetLabelAnnotationEnum
+		op     selection.Operator
+		values []string
+	}{
+		{api.XInstanceIdLabelKey, selection.Equals, []string{id}},
+		{api.SubResourcePvcTemplateLabelKey, selection.Equals, []string{pvcTmp.Name}},
+		{api.XOrphanedIndicationLabelKey, selection.DoesNotExist, nil},
+	} {
+		r, err := labels.NewRequirement(pc.xsetLabelAnnoMgr.Value(req.key), req.op, req.values)
+		if err != nil {
+			return nil, err
+		}
+		selector = selector.Add(*r)
+	}
+
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	if err := pc.client.List(ctx, pvcList, &client.ListOptions{
+		Namespace:     xset.GetNamespace(),
+		FieldSelector: fields.OneTermEqualSelector(FieldIndexOrphaned, "true"),
+		LabelSelector: selector,
+	}); err != nil {
+		return nil, fmt.Errorf("fail to list orphaned pvcs for id %s: %w", id, err)
+	}
+
+	var pvc *corev1.PersistentVolumeClaim
+	for i := range pvcList.Items {
+		if pvcList.Items[i].DeletionTimestamp == nil && len(pvcList.Items[i].OwnerReferences) == 0 {
+			pvc = &pvcList.Items[i]
+			break
+		}
+	}
+	if pvc == nil {
+		return nil, nil
+	}
+
+	sized := pc.withPvcSizeOverride(xset, id, pvcTmp)
+	if err := checkPvcConflict(pvc, sized); err != nil {
+		pc.recorder.Eventf(xset, corev1.EventTypeWarning, "PvcAdoptionConflict", "fail to adopt orphaned pvc %s for id %s: %s", pvc.Name, id, err.Error())
+		return nil, fmt.Errorf("fail to adopt orphaned pvc %s/%s: %w", pvc.Namespace, pvc.Name, err)
+	}
+
+	hash, err := PvcTmpHash(sized)
+	if err != nil {
+		return nil, err
+	}
+	patch := client.MergeFromWithOptions(pvc.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	xsetMeta := pc.xsetController.XSetMeta()
+	pvc.OwnerReferences = append(pvc.OwnerReferences, *metav1.NewControllerRef(xset, xsetMeta.GroupVersionKind()))
+	if pvc.Labels == nil {
+		pvc.Labels = map[string]string{}
+	}
+	for k, v := range pc.xsetController.GetXSetSpec(xset).Selector.MatchLabels {
+		pvc.Labels[k] = v
+	}
+	pc.xsetLabelAnnoMgr.Set(pvc, api.ControlledByXSetLabel, "true")
+	pc.xsetLabelAnnoMgr.Set(pvc, api.SubResourcePvcTemplateHashLabelKey, hash)
+	if err := pc.client.Patch(ctx, pvc, patch); err != nil {
+		return nil, fmt.Errorf("fail to adopt orphaned pvc %s/%s: %w", pvc.Namespace, pvc.Name, err)
+	}
+	pc.recorder.Eventf(xset, corev1.EventTypeNormal, "PvcAdopted", "adopt orphaned pvc %s for id %s", pvc.Name, id)
+	return pvc, nil
+}
+
+// checkPvcConflict checks whether pvc can be used in place of the one created from pvc template
+func checkPvcConflict(pvc, pvcTmp *corev1.PersistentVolumeClaim) error {
+	if tmpClass := ptr.Deref(pvcTmp.Spec.StorageClassName, ""); tmpClass != "" && tmpClass != ptr.Deref(pvc.Spec.StorageClassName, "") {
+		return fmt.Errorf("storage class %q mismatches %q in template", ptr.Deref(pvc.Spec.StorageClassName, ""), tmpClass)
+	}
+	tmpSize, size := pvcTmp.Spec.Resources.Requests[corev1.ResourceStorage], pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if size.Cmp(tmpSize) < 0 {
+		return fmt.Errorf("storage request %s is less than %s in template", size.String(), tmpSize.String())
+	}
+	return nil
+}
@@ -22,13 +22,16 @@ import (
 	"fmt"
 	"hash/fnv"
 	"strings"
+	"sync"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
 	appsv1alpha1 "kusionstack.io/kube-api/apps/v1alpha1"
 	kubeutilclient "kusionstack.io/kube-utils/client"
 	"kusionstack.io/kube-utils/controller/expectations"
@@ -42,10 +45,13 @@ import (
 
 const (
 	FieldIndexOwnerRefUID = "ownerRefUID"
+	FieldIndexOrphaned    = "orphaned"
 )
 
 var PVCGvk = corev1.SchemeGroupVersion.WithKind("PersistentVolumeClaim")
 
+// PvcControl manages pvcs declared by SubResourcePvcAdapter. Unlike SubresourceControl, pvcs are mounted by targets and
+// are upgraded along with pvc templates, so they are managed by a dedicated control.
 type PvcControl interface {
 	GetFilteredPvcs(context.Context, api.XSetObject) ([]*corev1.PersistentVolumeClaim, error)
 	CreateTargetPvcs(context.Context, api.XSetObject, client.Object, []*corev1.PersistentVolumeClaim) error
@@ -54,21 +60,40 @@ type PvcControl interface {
 	OrphanPvc(context.Context, api.XSetObject, *corev1.PersistentVolumeClaim) error
 	AdoptPvc(context.Context, api.XSetObject, *corev1.PersistentVolumeClaim) error
 	AdoptPvcsLeftByRetainPolicy(context.Context, api.XSetObject) ([]*corev1.PersistentVolumeClaim, error)
+	// IsTargetPvcTmpChanged is called concurrently for targets of large xsets, so it must be safe for concurrent use
 	IsTargetPvcTmpChanged(api.XSetObject, client.Object, []*corev1.PersistentVolumeClaim) (bool, error)
 	RetainPvcWhenXSetDeleted(xset api.XSetObject) bool
 	RetainPvcWhenXSetScaled(xset api.XSetObject) bool
+	ExpandPvcs(context.Context, api.XSetObject, []*corev1.PersistentVolumeClaim) ([]string, error)
+	DeleteExpiredPvcSnapshots(context.Context, api.XSetObject) error
+	IsTargetPvcsBound(context.Context, api.XSetObject, client.Object) (bool, error)
+	ReattachTargetPvcs(ctx context.Context, xset api.XSetObject, origin, x client.Object, existingPvcs []*corev1.PersistentVolumeClaim) error
+	GetDeletionBlockedPvcs(context.Context, api.XSetObject) ([]*corev1.PersistentVolumeClaim, error)
+	// ForgetOwner drops pvc templates cached for a deleted xset
+	ForgetOwner(namespace, name string)
 }
 
 type RealPvcControl struct {
 	client           client.Client
 	scheme           *runtime.Scheme
 	pvcAdapter       api.SubResourcePvcAdapter
-	expectations     *expectations.CacheExpectations
+	expectations     expectations.CacheExpectationsInterface
 	xsetLabelAnnoMgr api.XSetLabelAnnotationManager
 	xsetController   api.XSetController
+	recorder         record.EventRecorder
+	// tmpHashCache caches pvc templates and their hashes by namespaced name of xset, which are checked against every
+	// target on each reconcile
+	tmpHashCache sync.Map
 }
 
-func NewRealPvcControl(mixin *mixin.ReconcilerMixin, expectations *expectations.CacheExpectations, xsetLabelAnnoMgr api.XSetLabelAnnotationManager, xsetController api.XSetController) (PvcControl, error) {
+type pvcTmpHashCacheEntry struct {
+	uid        types.UID
+	generation int64
+	templates  []corev1.PersistentVolumeClaim
+	hashes     map[string]string
+}
+
+func NewRealPvcControl(mixin *mixin.ReconcilerMixin, expectations expectations.CacheExpectationsInterface, xsetLabelAnnoMgr api.XSetLabelAnnotationManager, xsetController api.XSetController) (PvcControl, error) {
 	// requires implementation of SubResourcePvcAdapter
 	pvcAdapter, ok := GetSubresourcePvcAdapter(xsetController)
 	if !ok {
@@ -85,6 +110,7 @@ func NewRealPvcControl(mixin *mixin.ReconcilerMixin, expectations *expectations.
 		expectations:     expectations,
 		xsetLabelAnnoMgr: xsetLabelAnnoMgr,
 		xsetController:   xsetController,
+		recorder:         mixin.Recorder,
 	}, nil
 }
 
@@ -149,11 +175,13 @@ func (pc *RealPvcControl) CreateTargetPvcs(ctx context.Context, xset api.XSetObj
 }
 
 func (pc *RealPvcControl) provisionUpdatedPvc(ctx context.Context, id string, xset api.XSetObject, existingPvcs []*corev1.PersistentVolumeClaim) (map[string]*corev1.PersistentVolumeClaim, error) {
-	updatedPvcs, _, err := pc.classifyTargetPvcs(id, xset, existingPvcs)
+	updatedPvcs, oldPvcs, err := pc.classifyTargetPvcs(id, xset, existingPvcs)
 	if err != nil {
 		return nil, err
 	}
 
+	ignoreTmpChange := pc.getPvcTemplateChangePolicy(xset) == api.IgnorePvcTemplateChangePolicyType
+	migration := pc.getStorageClassMigration(xset)
 	templates := pc.pvcAdapter.GetXSetPvcTemplate(xset)
 	for i := range templates {
 		pvcTmp := templates[i]
@@ -161,11 +189,29 @@ func (pc *RealPvcControl) provisionUpdatedPvc(ctx context.Context, id string, xs
 		if _, exist := updatedPvcs[pvcTmp.Name]; exist {
 			continue
 		}
+		// reuse old pvc if pvc template change is ignored, unless it is migrated to new StorageClass
+		oldPvc := oldPvcs[pvcTmp.Name]
+		if oldPvc != nil && ignoreTmpChange && (migration == nil || !isStorageClassChanged(oldPvc, &pvcTmp)) {
+			updatedPvcs[pvcTmp.Name] = oldPvc
+			continue
+		}
+		// adopt orphaned pvc if exists
+		adopted, err := pc.adoptOrphanedPvc(ctx, id, xset, &pvcTmp)
+		if err != nil {
+			return nil, err
+		}
+		if adopted != nil {
+			updatedPvcs[pvcTmp.Name] = adopted
+			continue
+		}
 		// create new pvc
 		claim, err := pc.buildPvcWithHash(id, xset, &pvcTmp)
 		if err != nil {
 			return nil, err
 		}
+		if err := pc.migrateDataSource(ctx, xset, migration, oldPvc, claim); err != nil {
+			return nil, err
+		}
 
 		if err := pc.client.Create(ctx, claim); err != nil {
 			return nil, fmt.Errorf("fail to create pvc for id %s: %w", id, err)
@@ -186,6 +232,7 @@ func (pc *RealPvcControl) provisionUpdatedPvc(ctx context.Context, id string, xs
 }
 
 func (pc *RealPvcControl) DeleteTargetPvcs(ctx context.Context, xset api.XSetObject, x client.Object, pvcs []*corev1.PersistentVolumeClaim) error {
+	snapshotPolicy := pc.getPvcSnapshotPolicy(xset)
 	for _, pvc := range pvcs {
 		if pvc.Labels == nil || x.GetLabels() == nil {
 			continue
@@ -198,6 +245,13 @@ func (pc *RealPvcControl) DeleteTargetPvcs(ctx context.Context, xset api.XSetObj
 			continue
 		}
 
+		// snapshot pvc before deleting it, in case of accidental scaling in
+		if snapshotPolicy != nil {
+			if err := pc.snapshotPvc(ctx, xset, pvc, snapshotPolicy); err != nil {
+				return err
+			}
+		}
+
 		if err := deletePvcWithExpectations(ctx, pc.client, xset, pc.expectations, pvc); err != nil {
 			return err
 		}
@@ -227,7 +281,7 @@ func (pc *RealPvcControl) DeleteTargetUnusedPvcs(ctx context.Context, xset api.X
 		return err
 	}
 	// delete old pvc if new pvc is provisioned and not RetainPVCWhenXSetScaled
-	if !pc.pvcAdapter.RetainPvcWhenXSetScaled(xset) {
+	if !pc.RetainPvcWhenXSetScaled(xset) {
 		return pc.deleteOldPvcs(ctx, xset, newPvcs, oldPvcs)
 	}
 	return nil
@@ -296,7 +350,11 @@ func (pc *RealPvcControl) AdoptPvcsLeftByRetainPolicy(ctx context.Context, xset
 	}
 
 	orphanedPvcList := &corev1.PersistentVolumeClaimList{}
-	if err := pc.client.List(ctx, orphanedPvcList, &client.ListOptions{Namespace: xset.GetNamespace(), LabelSelector: selector}); err != nil {
+	if err := pc.client.List(ctx, orphanedPvcList, &client.ListOptions{
+		Namespace:     xset.GetNamespace(),
+		FieldSelector: fields.OneTermEqualSelector(FieldIndexOrphaned, "true"),
+		LabelSelector: selector,
+	}); err != nil {
 		return nil, err
 	}
 
@@ -325,10 +383,14 @@ func (pc *RealPvcControl) AdoptPvcsLeftByRetainPolicy(ctx context.Context, xset
 }
 
 func (pc *RealPvcControl) IsTargetPvcTmpChanged(xset api.XSetObject, x client.Object, existingPvcs []*corev1.PersistentVolumeClaim) (bool, error) {
-	pvcTemplates := pc.pvcAdapter.GetXSetPvcTemplate(xset)
+	// only RecreateWithTarget policy updates targets for pvc template change, except for StorageClass migration
+	if pc.getPvcTemplateChangePolicy(xset) != api.RecreateWithTargetPvcTemplateChangePolicyType {
+		return pc.getStorageClassMigration(xset) != nil && pc.isTargetStorageClassChanged(xset, x, existingPvcs), nil
+	}
 	xSpecVolumes := pc.pvcAdapter.GetXSpecVolumes(x)
+	targetId, _ := pc.xsetLabelAnnoMgr.Get(x, api.XInstanceIdLabelKey)
 	// get pvc template hash values
-	newHashMapping, err := PvcTmpHashMapping(pvcTemplates)
+	newHashMapping, err := pc.getInstancePvcTmpHashMapping(xset, targetId)
 	if err != nil {
 		return false, err
 	}
@@ -340,7 +402,6 @@ func (pc *RealPvcControl) IsTargetPvcTmpChanged(xset api.XSetObject, x client.Ob
 			continue
 		}
 		pvcId, _ := pc.xsetLabelAnnoMgr.Get(pvc, api.XInstanceIdLabelKey)
-		targetId, _ := pc.xsetLabelAnnoMgr.Get(x, api.XInstanceIdLabelKey)
 		if pvcId != targetId {
 			continue
 		}
@@ -366,13 +427,35 @@ func (pc *RealPvcControl) IsTargetPvcTmpChanged(xset api.XSetObject, x client.Ob
 }
 
 func (pc *RealPvcControl) RetainPvcWhenXSetDeleted(xset api.XSetObject) bool {
+	if policy := pc.getPvcRetentionPolicy(xset); policy != nil {
+		return policy.WhenDeleted != api.DeletePersistentVolumeClaimRetentionPolicyType
+	}
 	return pc.pvcAdapter.RetainPvcWhenXSetDeleted(xset)
 }
 
 func (pc *RealPvcControl) RetainPvcWhenXSetScaled(xset api.XSetObject) bool {
+	if policy := pc.getPvcRetentionPolicy(xset); policy != nil {
+		return policy.WhenScaled != api.DeletePersistentVolumeClaimRetentionPolicyType
+	}
 	return pc.pvcAdapter.RetainPvcWhenXSetScaled(xset)
 }
 
+func (pc *RealPvcControl) getPvcTemplateChangePolicy(xset api.XSetObject) api.PvcTemplateChangePolicyType {
+	if adapter, ok := pc.pvcAdapter.(api.PvcTemplateChangePolicyAdapter); ok {
+		if policy := adapter.GetPvcTemplateChangePolicy(xset); policy != "" {
+			return policy
+		}
+	}
+	return api.RecreateWithTargetPvcTemplateChangePolicyType
+}
+
+func (pc *RealPvcControl) getPvcRetentionPolicy(xset api.XSetObject) *api.PersistentVolumeClaimRetentionPolicy {
+	if adapter, ok := pc.pvcAdapter.(api.PvcRetentionPolicyAdapter); ok {
+		return adapter.GetPvcRetentionPolicy(xset)
+	}
+	return nil
+}
+
 func (pc *RealPvcControl) deleteUnclaimedPvcs(ctx context.Context, xset api.XSetObject, oldPvcs map[string]*corev1.PersistentVolumeClaim, mountedPvcNames sets.String) error {
 	inUsedPvcNames := sets.String{}
 	templates := pc.pvcAdapter.GetXSetPvcTemplate(xset)
@@ -405,12 +488,15 @@ func (pc *RealPvcControl) deleteOldPvcs(ctx context.Context, xset api.XSetObject
 		if err := deletePvcWithExpectations(ctx, pc.client, xset, pc.expectations, pvc); err != nil {
 			return err
 		}
+		pc.recorder.Eventf(xset, corev1.EventTypeNormal, "OldPvcDeleted", "delete pvc %s provisioned from old template %s", pvc.Name, pvcTmpName)
 	}
 	return nil
 }
 
 func (pc *RealPvcControl) buildPvcWithHash(id string, xset api.XSetObject, pvcTmp *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
-	claim := pvcTmp.DeepCopy()
+	// pvc is created from, and labeled with hash of, template sized for the instance
+	sized := pc.withPvcSizeOverride(xset, id, pvcTmp)
+	claim := sized.DeepCopy()
 	claim.Name = ""
 	claim.GenerateName = fmt.Sprintf("%s-%s-", xset.GetName(), pvcTmp.Name)
 	claim.Namespace = xset.GetNamespace()
@@ -428,7 +514,7 @@ func (pc *RealPvcControl) buildPvcWithHash(id string, xset api.XSetObject, pvcTm
 	}
 	pc.xsetLabelAnnoMgr.Set(claim, api.ControlledByXSetLabel, "true")
 
-	hash, err := PvcTmpHash(pvcTmp)
+	hash, err := PvcTmpHash(sized)
 	if err != nil {
 		return nil, err
 	}
@@ -443,8 +529,7 @@ func (pc *RealPvcControl) classifyTargetPvcs(id string, xset api.XSetObject, exi
 	newPvcs := map[string]*corev1.PersistentVolumeClaim{}
 	oldPvcs := map[string]*corev1.PersistentVolumeClaim{}
 
-	newPvcTemplates := pc.pvcAdapter.GetXSetPvcTemplate(xset)
-	newTmpHash, err := PvcTmpHashMapping(newPvcTemplates)
+	newTmpHash, err := pc.getInstancePvcTmpHashMapping(xset, id)
 	if err != nil {
 		return newPvcs, oldPvcs, err
 	}
@@ -515,6 +600,47 @@ func PvcTmpHash(pvc *corev1.PersistentVolumeClaim) (string, error) {
 	return rand.SafeEncodeString(fmt.Sprint(hf.Sum32())), nil
 }
 
+// getPvcTmpHashMapping returns hash of pvc templates of xset, which is only calculated once for each generation of xset.
+// Returned mapping is shared and must not be modified.
+func (pc *RealPvcControl) getPvcTmpHashMapping(xset api.XSetObject) (map[string]string, error) {
+	entry, err := pc.getPvcTmpCacheEntry(xset)
+	if err != nil {
+		return nil, err
+	}
+	return entry.hashes, nil
+}
+
+// getPvcTemplates returns pvc templates of xset, which are only copied from xset once for each generation of xset.
+// Returned templates are shared and must not be modified.
+func (pc *RealPvcControl) getPvcTemplates(xset api.XSetObject) ([]corev1.PersistentVolumeClaim, error) {
+	entry, err := pc.getPvcTmpCacheEntry(xset)
+	if err != nil {
+		return nil, err
+	}
+	return entry.templates, nil
+}
+
+func (pc *RealPvcControl) getPvcTmpCacheEntry(xset api.XSetObject) (*pvcTmpHashCacheEntry, error) {
+	key := types.NamespacedName{Namespace: xset.GetNamespace(), Name: xset.GetName()}
+	if cached, ok := pc.tmpHashCache.Load(key); ok {
+		if entry := cached.(*pvcTmpHashCacheEntry); entry.uid == xset.GetUID() && entry.generation == xset.GetGeneration() && xset.GetGeneration() != 0 {
+			return entry, nil
+		}
+	}
+	templates := pc.pvcAdapter.GetXSetPvcTemplate(xset)
+	hashes, err := PvcTmpHashMapping(templates)
+	if err != nil {
+		return nil, err
+	}
+	entry := &pvcTmpHashCacheEntry{uid: xset.GetUID(), generation: xset.GetGeneration(), templates: templates, hashes: hashes}
+	pc.tmpHashCache.Store(key, entry)
+	return entry, nil
+}
+
+func (pc *RealPvcControl) ForgetOwner(namespace, name string) {
+	pc.tmpHashCache.Delete(types.NamespacedName{Namespace: namespace, Name: name})
+}
+
 func PvcTmpHashMapping(pvcTmps []corev1.PersistentVolumeClaim) (map[string]string, error) {
 	pvcHashMapping := map[string]string{}
 	for i := range pvcTmps {
@@ -528,12 +654,12 @@ func PvcTmpHashMapping(pvcTmps []corev1.PersistentVolumeClaim) (map[string]strin
 	return pvcHashMapping, nil
 }
 
-func deletePvcWithExpectations(ctx context.Context, client client.Client, xset api.XSetObject, expectations *expectations.CacheExpectations, pvc *corev1.PersistentVolumeClaim) error {
+func deletePvcWithExpectations(ctx context.Context, client client.Client, xset api.XSetObject, expectations expectations.CacheExpectationsInterface, pvc *corev1.PersistentVolumeClaim) error {
 	if err := client.Delete(ctx, pvc); err != nil {
 		return err
 	}
 
-	// expect deletion
+	// expect deletion, which is satisfied once pvc is terminating even if it is blocked by third-party finalizers
 	if err := expectations.ExpectDeletion(kubeutilclient.ObjectKeyString(xset), PVCGvk, pvc.GetNamespace(), pvc.GetName()); err != nil {
 		return err
 	}
@@ -550,5 +676,14 @@ func setUpCache(cache cache.Cache, controller api.XSetController) error {
 	}); err != nil {
 		return fmt.Errorf("failed to index by field for pvc->xset %s: %w", FieldIndexOwnerRefUID, err)
 	}
+
+	if err := cache.IndexField(context.TODO(), &corev1.PersistentVolumeClaim{}, FieldIndexOrphaned, func(object client.Object) []string {
+		if len(object.GetOwnerReferences()) > 0 {
+			return nil
+		}
+		return []string{"true"}
+	}); err != nil {
+		return fmt.Errorf("failed to index by field for pvc %s: %w", FieldIndexOrphaned, err)
+	}
 	return nil
 }
@@ -0,0 +1,695 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subresources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"kusionstack.io/kube-utils/controller/expectations"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// pvcController manages Pod targets with pvcs from templates
+type pvcController struct {
+	api.XSetController
+	templates         []corev1.PersistentVolumeClaim
+	retainWhenDeleted bool
+	retainWhenScaled  bool
+}
+
+func (c *pvcController) XSetMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "apps.kusionstack.io/v1alpha1", Kind: "XSet"}
+}
+
+func (c *pvcController) XMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+}
+
+func (c *pvcController) NewXSetObject() api.XSetObject { return &corev1.Pod{} }
+
+func (c *pvcController) GetXSetSpec(api.XSetObject) *api.XSetSpec {
+	return &api.XSetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}}}
+}
+
+func (c *pvcController) RetainPvcWhenXSetDeleted(api.XSetObject) bool { return c.retainWhenDeleted }
+
+func (c *pvcController) RetainPvcWhenXSetScaled(api.XSetObject) bool { return c.retainWhenScaled }
+
+func (c *pvcController) GetXSetPvcTemplate(api.XSetObject) []corev1.PersistentVolumeClaim {
+	return c.templates
+}
+
+func (c *pvcController) GetXSpecVolumes(object client.Object) []corev1.Volume {
+	return object.(*corev1.Pod).Spec.Volumes
+}
+
+func (c *pvcController) GetXVolumeMounts(object client.Object) []corev1.VolumeMount {
+	var mounts []corev1.VolumeMount
+	for _, container := range object.(*corev1.Pod).Spec.Containers {
+		mounts = append(mounts, container.VolumeMounts...)
+	}
+	return mounts
+}
+
+func (c *pvcController) SetXSpecVolumes(object client.Object, volumes []corev1.Volume) {
+	object.(*corev1.Pod).Spec.Volumes = volumes
+}
+
+// recordingExpectations records expected objects by operation, e.g., "delete PersistentVolumeClaim default/foo-0"
+type recordingExpectations struct {
+	expectations.CacheExpectationsInterface
+	mu      sync.Mutex
+	records []string
+}
+
+func (e *recordingExpectations) record(operation string, gvk schema.GroupVersionKind, namespace, name string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.records = append(e.records, fmt.Sprintf("%s %s %s/%s", operation, gvk.Kind, namespace, name))
+	return nil
+}
+
+func (e *recordingExpectations) ExpectCreation(_ string, gvk schema.GroupVersionKind, namespace, name string) error {
+	return e.record("create", gvk, namespace, name)
+}
+
+func (e *recordingExpectations) ExpectDeletion(_ string, gvk schema.GroupVersionKind, namespace, name string) error {
+	return e.record("delete", gvk, namespace, name)
+}
+
+func (e *recordingExpectations) ExpectUpdation(_ string, gvk schema.GroupVersionKind, namespace, name, _ string) error {
+	return e.record("update", gvk, namespace, name)
+}
+
+func newTestXSet() *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", UID: "foo-uid", Generation: 1}}
+}
+
+func newPvcTemplate(name, size string) corev1.PersistentVolumeClaim {
+	return corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceStorage: resource.MustParse(size),
+			}},
+		},
+	}
+}
+
+func newTestPvcControl(pvcAdapter api.SubResourcePvcAdapter, xsetController api.XSetController, objs ...client.Object) (*RealPvcControl, client.Client, *recordingExpectations) {
+	c := clientfake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(objs...).Build()
+	exp := &recordingExpectations{}
+	return &RealPvcControl{
+		client:           c,
+		scheme:           clientgoscheme.Scheme,
+		pvcAdapter:       pvcAdapter,
+		expectations:     exp,
+		xsetLabelAnnoMgr: api.NewXSetLabelAnnotationManager(nil),
+		xsetController:   xsetController,
+		recorder:         record.NewFakeRecorder(100),
+	}, c, exp
+}
+
+// newTargetWithPvcs returns a Pod target of instance id, with pvcs created from templates mounted
+func newTargetWithPvcs(t testing.TB, pc *RealPvcControl, xset api.XSetObject, id string) (*corev1.Pod, []*corev1.PersistentVolumeClaim) {
+	t.Helper()
+	target := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-" + id, Labels: map[string]string{}}}
+	pc.xsetLabelAnnoMgr.Set(target, api.XInstanceIdLabelKey, id)
+	if err := pc.CreateTargetPvcs(context.TODO(), xset, target, nil); err != nil {
+		t.Fatalf("CreateTargetPvcs() = %v", err)
+	}
+	pvcs, err := pc.GetFilteredPvcs(context.TODO(), xset)
+	if err != nil {
+		t.Fatalf("GetFilteredPvcs() = %v", err)
+	}
+	var owned []*corev1.PersistentVolumeClaim
+	for _, pvc := range pvcs {
+		if pvcID, _ := pc.xsetLabelAnnoMgr.Get(pvc, api.XInstanceIdLabelKey); pvcID == id {
+			owned = append(owned, pvc)
+		}
+	}
+	return target, owned
+}
+
+type retentionPvcController struct {
+	*pvcController
+	policy *api.PersistentVolumeClaimRetentionPolicy
+}
+
+func (c *retentionPvcController) GetPvcRetentionPolicy(api.XSetObject) *api.PersistentVolumeClaimRetentionPolicy {
+	return c.policy
+}
+
+func TestPvcRetentionPolicy(t *testing.T) {
+	tests := []struct {
+		name                   string
+		policy                 *api.PersistentVolumeClaimRetentionPolicy
+		wantRetainWhenDeleted  bool
+		wantRetainWhenScaledIn bool
+	}{
+		{name: "falls back to adapter without policy", wantRetainWhenDeleted: true},
+		{name: "empty policy retains", policy: &api.PersistentVolumeClaimRetentionPolicy{}, wantRetainWhenDeleted: true, wantRetainWhenScaledIn: true},
+		{
+			name:                  "delete when scaled",
+			policy:                &api.PersistentVolumeClaimRetentionPolicy{WhenScaled: api.DeletePersistentVolumeClaimRetentionPolicyType},
+			wantRetainWhenDeleted: true,
+		},
+		{
+			name:                   "delete when deleted",
+			policy:                 &api.PersistentVolumeClaimRetentionPolicy{WhenDeleted: api.DeletePersistentVolumeClaimRetentionPolicyType},
+			wantRetainWhenScaledIn: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := &retentionPvcController{pvcController: &pvcController{retainWhenDeleted: true}, policy: tt.policy}
+			pc, _, _ := newTestPvcControl(controller, controller)
+			if got := pc.RetainPvcWhenXSetDeleted(newTestXSet()); got != tt.wantRetainWhenDeleted {
+				t.Errorf("RetainPvcWhenXSetDeleted() = %v, want %v", got, tt.wantRetainWhenDeleted)
+			}
+			if got := pc.RetainPvcWhenXSetScaled(newTestXSet()); got != tt.wantRetainWhenScaledIn {
+				t.Errorf("RetainPvcWhenXSetScaled() = %v, want %v", got, tt.wantRetainWhenScaledIn)
+			}
+		})
+	}
+}
+
+type expansionPvcController struct {
+	*pvcController
+}
+
+func (c *expansionPvcController) ExpandPvcOnTemplateChange(api.XSetObject) bool { return true }
+
+func TestExpandPvcs(t *testing.T) {
+	template := newPvcTemplate("data", "1Gi")
+	template.Spec.StorageClassName = ptr.To("standard")
+	controller := &expansionPvcController{pvcController: &pvcController{templates: []corev1.PersistentVolumeClaim{template}}}
+	sc := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "standard"}, Provisioner: "fake"}
+	pc, c, _ := newTestPvcControl(controller, controller, sc)
+	xset := newTestXSet()
+	_, pvcs := newTargetWithPvcs(t, pc, xset, "0")
+	if len(pvcs) != 1 {
+		t.Fatalf("got %d pvcs, want 1", len(pvcs))
+	}
+
+	// nothing to expand before template changes
+	if expanding, err := pc.ExpandPvcs(context.TODO(), xset, pvcs); err != nil || len(expanding) != 0 {
+		t.Fatalf("ExpandPvcs() = %v, %v, want nothing expanding", expanding, err)
+	}
+
+	controller.templates[0].Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("2Gi")
+	xset.Generation++
+	if _, err := pc.ExpandPvcs(context.TODO(), xset, pvcs); !errors.Is(err, ErrPvcExpansionNotAllowed) {
+		t.Fatalf("ExpandPvcs() = %v, want ErrPvcExpansionNotAllowed", err)
+	}
+
+	sc.AllowVolumeExpansion = ptr.To(true)
+	if err := c.Update(context.TODO(), sc); err != nil {
+		t.Fatal(err)
+	}
+	expanding, err := pc.ExpandPvcs(context.TODO(), xset, pvcs)
+	if err != nil || len(expanding) != 1 || expanding[0] != pvcs[0].Name {
+		t.Fatalf("ExpandPvcs() = %v, %v, want %s expanding", expanding, err, pvcs[0].Name)
+	}
+	got := &corev1.PersistentVolumeClaim{}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(pvcs[0]), got); err != nil {
+		t.Fatal(err)
+	}
+	if size := got.Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != "2Gi" {
+		t.Errorf("storage request = %s, want 2Gi", size.String())
+	}
+	newHash, _ := PvcTmpHash(&controller.templates[0])
+	if hash, _ := pc.xsetLabelAnnoMgr.Get(got, api.SubResourcePvcTemplateHashLabelKey); hash != newHash {
+		t.Errorf("template hash = %s, want %s", hash, newHash)
+	}
+}
+
+func TestSnapshotPvc(t *testing.T) {
+	controller := &pvcController{}
+	pc, c, _ := newTestPvcControl(controller, controller)
+	recorder := pc.recorder.(*record.FakeRecorder)
+	xset := newTestXSet()
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "data-foo-0"}}
+	policy := &api.PvcSnapshotPolicy{VolumeSnapshotClassName: ptr.To("csi-snapshot")}
+
+	for i := 0; i < 2; i++ {
+		if err := pc.snapshotPvc(context.TODO(), xset, pvc, policy); err != nil {
+			t.Fatalf("snapshotPvc() = %v", err)
+		}
+	}
+	if got := len(recorder.Events); got != 1 {
+		t.Errorf("got %d events, want 1 only when snapshot is created", got)
+	}
+
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(VolumeSnapshotGvk)
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(pvc), snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if className, _, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName"); className != "csi-snapshot" {
+		t.Errorf("volumeSnapshotClassName = %q, want csi-snapshot", className)
+	}
+}
+
+func TestAdoptOrphanedPvc(t *testing.T) {
+	newOrphanedPvc := func(size string) *corev1.PersistentVolumeClaim {
+		pvc := newPvcTemplate("data", size)
+		pvc.Namespace, pvc.Name = "default", "data-manual"
+		pvc.Labels = map[string]string{}
+		mgr := api.NewXSetLabelAnnotationManager(nil)
+		mgr.Set(&pvc, api.XInstanceIdLabelKey, "0")
+		mgr.Set(&pvc, api.SubResourcePvcTemplateLabelKey, "data")
+		return &pvc
+	}
+
+	t.Run("adopt", func(t *testing.T) {
+		controller := &pvcController{templates: []corev1.PersistentVolumeClaim{newPvcTemplate("data", "1Gi")}}
+		pc, _, _ := newTestPvcControl(controller, controller, newOrphanedPvc("2Gi"))
+		xset := newTestXSet()
+		target, pvcs := newTargetWithPvcs(t, pc, xset, "0")
+		if len(pvcs) != 1 || pvcs[0].Name != "data-manual" {
+			t.Fatalf("pvcs of instance = %v, want data-manual adopted", pvcs)
+		}
+		if ref := metav1.GetControllerOf(pvcs[0]); ref == nil || ref.UID != xset.UID {
+			t.Errorf("controller of adopted pvc = %v, want XSet", ref)
+		}
+		hash, _ := PvcTmpHash(&controller.templates[0])
+		if got, _ := pc.xsetLabelAnnoMgr.Get(pvcs[0], api.SubResourcePvcTemplateHashLabelKey); got != hash {
+			t.Errorf("template hash of adopted pvc = %s, want %s", got, hash)
+		}
+		if volumes := target.Spec.Volumes; len(volumes) != 1 || volumes[0].PersistentVolumeClaim.ClaimName != "data-manual" {
+			t.Errorf("target volumes = %v, want data-manual mounted", volumes)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		controller := &pvcController{templates: []corev1.PersistentVolumeClaim{newPvcTemplate("data", "1Gi")}}
+		pc, _, _ := newTestPvcControl(controller, controller, newOrphanedPvc("500Mi"))
+		target := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-0", Labels: map[string]string{}}}
+		pc.xsetLabelAnnoMgr.Set(target, api.XInstanceIdLabelKey, "0")
+		if err := pc.CreateTargetPvcs(context.TODO(), newTestXSet(), target, nil); err == nil {
+			t.Fatal("CreateTargetPvcs() = nil, want error of conflicting orphaned pvc")
+		}
+		if got := len(pc.recorder.(*record.FakeRecorder).Events); got != 1 {
+			t.Errorf("got %d events, want PvcAdoptionConflict", got)
+		}
+	})
+}
+
+type tmpChangePvcController struct {
+	*pvcController
+	policy api.PvcTemplateChangePolicyType
+}
+
+func (c *tmpChangePvcController) GetPvcTemplateChangePolicy(api.XSetObject) api.PvcTemplateChangePolicyType {
+	return c.policy
+}
+
+func TestPvcTemplateChangePolicy(t *testing.T) {
+	tests := []struct {
+		policy      api.PvcTemplateChangePolicyType
+		wantChanged bool
+		wantReused  bool
+	}{
+		{policy: "", wantChanged: true},
+		{policy: api.RecreateWithTargetPvcTemplateChangePolicyType, wantChanged: true},
+		{policy: api.RecreateOnDeletePvcTemplateChangePolicyType},
+		{policy: api.IgnorePvcTemplateChangePolicyType, wantReused: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			controller := &tmpChangePvcController{
+				pvcController: &pvcController{templates: []corev1.PersistentVolumeClaim{newPvcTemplate("data", "1Gi")}},
+				policy:        tt.policy,
+			}
+			pc, _, _ := newTestPvcControl(controller, controller)
+			xset := newTestXSet()
+			target, pvcs := newTargetWithPvcs(t, pc, xset, "0")
+
+			controller.templates[0].Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}
+			xset.Generation++
+			changed, err := pc.IsTargetPvcTmpChanged(xset, target, pvcs)
+			if err != nil || changed != tt.wantChanged {
+				t.Errorf("IsTargetPvcTmpChanged() = %v, %v, want %v", changed, err, tt.wantChanged)
+			}
+
+			// recreate target of the same instance
+			newTarget := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-0-new", Labels: map[string]string{}}}
+			pc.xsetLabelAnnoMgr.Set(newTarget, api.XInstanceIdLabelKey, "0")
+			if err := pc.CreateTargetPvcs(context.TODO(), xset, newTarget, pvcs); err != nil {
+				t.Fatalf("CreateTargetPvcs() = %v", err)
+			}
+			reused := newTarget.Spec.Volumes[0].PersistentVolumeClaim.ClaimName == pvcs[0].Name
+			if reused != tt.wantReused {
+				t.Errorf("old pvc reused = %v, want %v", reused, tt.wantReused)
+			}
+		})
+	}
+}
+
+type preProvisionPvcController struct {
+	*pvcController
+	timeout time.Duration
+}
+
+func (c *preProvisionPvcController) GetPvcBoundTimeout(api.XSetObject) time.Duration {
+	return c.timeout
+}
+
+func TestIsTargetPvcsBound(t *testing.T) {
+	newPvc := func(name, storageClass string, phase corev1.PersistentVolumeClaimPhase, age time.Duration) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, CreationTimestamp: metav1.NewTime(time.Now().Add(-age))},
+			Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: ptr.To(storageClass)},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: phase},
+		}
+	}
+	waitForFirstConsumer := storagev1.VolumeBindingWaitForFirstConsumer
+	storageClasses := []client.Object{
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "immediate"}, Provisioner: "fake"},
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "delayed"}, Provisioner: "fake", VolumeBindingMode: &waitForFirstConsumer},
+	}
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		pvc     *corev1.PersistentVolumeClaim
+		want    bool
+	}{
+		{name: "bound", timeout: time.Minute, pvc: newPvc("data", "immediate", corev1.ClaimBound, 0), want: true},
+		{name: "pending", timeout: time.Minute, pvc: newPvc("data", "immediate", corev1.ClaimPending, 0)},
+		{name: "not in cache yet", timeout: time.Minute},
+		{name: "wait for first consumer", timeout: time.Minute, pvc: newPvc("data", "delayed", corev1.ClaimPending, 0), want: true},
+		{name: "timed out", timeout: time.Minute, pvc: newPvc("data", "immediate", corev1.ClaimPending, 2*time.Minute), want: true},
+		{name: "not waiting", pvc: newPvc("data", "immediate", corev1.ClaimPending, 0), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := &preProvisionPvcController{pvcController: &pvcController{}, timeout: tt.timeout}
+			objs := storageClasses
+			if tt.pvc != nil {
+				objs = append([]client.Object{tt.pvc}, storageClasses...)
+			}
+			pc, _, _ := newTestPvcControl(controller, controller, objs...)
+			target := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-0"},
+				Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+					Name:         "data",
+					VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}},
+				}}},
+			}
+			if got, err := pc.IsTargetPvcsBound(context.TODO(), newTestXSet(), target); err != nil || got != tt.want {
+				t.Errorf("IsTargetPvcsBound() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+type migrationPvcController struct {
+	*tmpChangePvcController
+	migration *api.StorageClassMigration
+}
+
+func (c *migrationPvcController) GetStorageClassMigration(api.XSetObject) *api.StorageClassMigration {
+	return c.migration
+}
+
+func TestStorageClassMigration(t *testing.T) {
+	template := newPvcTemplate("data", "1Gi")
+	template.Spec.StorageClassName = ptr.To("old")
+	controller := &migrationPvcController{
+		tmpChangePvcController: &tmpChangePvcController{
+			pvcController: &pvcController{templates: []corev1.PersistentVolumeClaim{template}},
+			policy:        api.IgnorePvcTemplateChangePolicyType,
+		},
+		migration: &api.StorageClassMigration{Type: api.SnapshotStorageClassMigrationType},
+	}
+	pc, c, _ := newTestPvcControl(controller, controller)
+	xset := newTestXSet()
+	target, pvcs := newTargetWithPvcs(t, pc, xset, "0")
+
+	// other changes are ignored
+	controller.templates[0].Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}
+	xset.Generation++
+	if changed, err := pc.IsTargetPvcTmpChanged(xset, target, pvcs); err != nil || changed {
+		t.Fatalf("IsTargetPvcTmpChanged() = %v, %v, want false", changed, err)
+	}
+
+	controller.templates[0].Spec.StorageClassName = ptr.To("new")
+	xset.Generation++
+	if changed, err := pc.IsTargetPvcTmpChanged(xset, target, pvcs); err != nil || !changed {
+		t.Fatalf("IsTargetPvcTmpChanged() = %v, %v, want true for StorageClass migration", changed, err)
+	}
+
+	newTarget := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-0-new", Labels: map[string]string{}}}
+	pc.xsetLabelAnnoMgr.Set(newTarget, api.XInstanceIdLabelKey, "0")
+	if err := pc.CreateTargetPvcs(context.TODO(), xset, newTarget, pvcs); err != nil {
+		t.Fatalf("CreateTargetPvcs() = %v", err)
+	}
+	claimName := newTarget.Spec.Volumes[0].PersistentVolumeClaim.ClaimName
+	if claimName == pvcs[0].Name {
+		t.Fatal("old pvc is reused, want new pvc on new StorageClass")
+	}
+	claim := &corev1.PersistentVolumeClaim{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: claimName}, claim); err != nil {
+		t.Fatal(err)
+	}
+	if ptr.Deref(claim.Spec.StorageClassName, "") != "new" || claim.Spec.DataSource == nil || claim.Spec.DataSource.Name != pvcs[0].Name {
+		t.Errorf("new pvc on StorageClass %s with data source %v, want restored from snapshot %s on new",
+			ptr.Deref(claim.Spec.StorageClassName, ""), claim.Spec.DataSource, pvcs[0].Name)
+	}
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(VolumeSnapshotGvk)
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(pvcs[0]), snapshot); err != nil {
+		t.Errorf("VolumeSnapshot of old pvc: %v", err)
+	}
+}
+
+type sizeOverridePvcController struct {
+	*expansionPvcController
+	sizes map[int]string
+}
+
+func (c *sizeOverridePvcController) GetPvcSizeOverride(_ api.XSetObject, id int, _ string) *resource.Quantity {
+	size, ok := c.sizes[id]
+	if !ok {
+		return nil
+	}
+	quantity := resource.MustParse(size)
+	return &quantity
+}
+
+func TestExpandOverriddenPvc(t *testing.T) {
+	template := newPvcTemplate("data", "1Gi")
+	template.Spec.StorageClassName = ptr.To("standard")
+	controller := &sizeOverridePvcController{
+		expansionPvcController: &expansionPvcController{pvcController: &pvcController{templates: []corev1.PersistentVolumeClaim{template}}},
+		sizes:                  map[int]string{0: "5Gi"},
+	}
+	sc := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "standard"}, Provisioner: "fake", AllowVolumeExpansion: ptr.To(true)}
+	pc, c, _ := newTestPvcControl(controller, controller, sc)
+	xset := newTestXSet()
+	target, pvcs := newTargetWithPvcs(t, pc, xset, "0")
+	if size := pvcs[0].Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != "5Gi" {
+		t.Fatalf("storage request = %s, want 5Gi overridden", size.String())
+	}
+	if size := controller.templates[0].Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != "1Gi" {
+		t.Fatalf("storage request of template = %s, want 1Gi unchanged", size.String())
+	}
+	if changed, err := pc.IsTargetPvcTmpChanged(xset, target, pvcs); err != nil || changed {
+		t.Fatalf("IsTargetPvcTmpChanged() = %v, %v, want false", changed, err)
+	}
+
+	controller.sizes[0] = "8Gi"
+	expanding, err := pc.ExpandPvcs(context.TODO(), xset, pvcs)
+	if err != nil || len(expanding) != 1 {
+		t.Fatalf("ExpandPvcs() = %v, %v, want overridden pvc expanding", expanding, err)
+	}
+	got := &corev1.PersistentVolumeClaim{}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(pvcs[0]), got); err != nil {
+		t.Fatal(err)
+	}
+	if size := got.Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != "8Gi" {
+		t.Errorf("storage request = %s, want 8Gi", size.String())
+	}
+	if changed, err := pc.IsTargetPvcTmpChanged(xset, target, []*corev1.PersistentVolumeClaim{got}); err != nil || changed {
+		t.Errorf("IsTargetPvcTmpChanged() = %v, %v after expansion, want false", changed, err)
+	}
+}
+
+type replacePvcController struct {
+	*pvcController
+	policy api.ReplacePvcPolicyType
+}
+
+func (c *replacePvcController) GetReplacePvcPolicy(api.XSetObject) api.ReplacePvcPolicyType {
+	return c.policy
+}
+
+func TestReattachTargetPvcs(t *testing.T) {
+	for _, policy := range []api.ReplacePvcPolicyType{api.ReprovisionReplacePvcPolicyType, api.ReattachReplacePvcPolicyType} {
+		t.Run(string(policy), func(t *testing.T) {
+			controller := &replacePvcController{
+				pvcController: &pvcController{templates: []corev1.PersistentVolumeClaim{newPvcTemplate("data", "1Gi")}},
+				policy:        policy,
+			}
+			pc, _, _ := newTestPvcControl(controller, controller)
+			xset := newTestXSet()
+			origin, pvcs := newTargetWithPvcs(t, pc, xset, "0")
+
+			newTarget := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-1", Labels: map[string]string{}}}
+			pc.xsetLabelAnnoMgr.Set(newTarget, api.XInstanceIdLabelKey, "1")
+			if err := pc.ReattachTargetPvcs(context.TODO(), xset, origin, newTarget, pvcs); err != nil {
+				t.Fatalf("ReattachTargetPvcs() = %v", err)
+			}
+			existingPvcs, err := pc.GetFilteredPvcs(context.TODO(), xset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := pc.CreateTargetPvcs(context.TODO(), xset, newTarget, existingPvcs); err != nil {
+				t.Fatalf("CreateTargetPvcs() = %v", err)
+			}
+			reattached := newTarget.Spec.Volumes[0].PersistentVolumeClaim.ClaimName == pvcs[0].Name
+			if want := policy == api.ReattachReplacePvcPolicyType; reattached != want {
+				t.Errorf("pvc of origin target mounted by new target = %v, want %v", reattached, want)
+			}
+		})
+	}
+}
+
+func TestDeletePvcBlockedByFinalizers(t *testing.T) {
+	controller := &pvcController{templates: []corev1.PersistentVolumeClaim{newPvcTemplate("data", "1Gi")}}
+	pc, c, exp := newTestPvcControl(controller, controller)
+	xset := newTestXSet()
+	target, pvcs := newTargetWithPvcs(t, pc, xset, "0")
+	pvc := pvcs[0]
+	pvc.Finalizers = []string{PvcProtectionFinalizer, "example.com/backup"}
+	if err := c.Update(context.TODO(), pvc); err != nil {
+		t.Fatal(err)
+	}
+	exp.records = nil
+
+	if err := pc.DeleteTargetPvcs(context.TODO(), xset, target, []*corev1.PersistentVolumeClaim{pvc}); err != nil {
+		t.Fatalf("DeleteTargetPvcs() = %v", err)
+	}
+	if want := "delete PersistentVolumeClaim default/" + pvc.Name; len(exp.records) != 1 || exp.records[0] != want {
+		t.Errorf("expectations = %v, want %s", exp.records, want)
+	}
+	blocked, err := pc.GetDeletionBlockedPvcs(context.TODO(), xset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocked) != 1 || blocked[0].Name != pvc.Name {
+		t.Fatalf("GetDeletionBlockedPvcs() = %v, want %s", blocked, pvc.Name)
+	}
+	if finalizers := DeletionProtectionFinalizers(blocked[0]); len(finalizers) != 1 || finalizers[0] != "example.com/backup" {
+		t.Errorf("DeletionProtectionFinalizers() = %v, want example.com/backup", finalizers)
+	}
+}
+
+func TestPvcDeletionRecheckBackoff(t *testing.T) {
+	tests := []struct {
+		deleting time.Duration
+		want     time.Duration
+	}{
+		{deleting: time.Second, want: minPvcDeletionRecheckBackoff},
+		{deleting: time.Minute, want: time.Minute},
+		{deleting: time.Hour, want: maxPvcDeletionRecheckBackoff},
+	}
+	for _, tt := range tests {
+		pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-tt.deleting)}}}
+		if got := PvcDeletionRecheckBackoff(pvc); got.Round(time.Second) != tt.want {
+			t.Errorf("PvcDeletionRecheckBackoff() of pvc deleting for %s = %s, want %s", tt.deleting, got, tt.want)
+		}
+	}
+}
+
+func TestPvcTmpCacheForgetOwner(t *testing.T) {
+	controller := &pvcController{templates: []corev1.PersistentVolumeClaim{newPvcTemplate("data", "1Gi")}}
+	pc, _, _ := newTestPvcControl(controller, controller)
+	xset := newTestXSet()
+	target, pvcs := newTargetWithPvcs(t, pc, xset, "0")
+
+	// cached templates are used until generation of xset changes
+	controller.templates = []corev1.PersistentVolumeClaim{newPvcTemplate("data", "2Gi")}
+	if changed, err := pc.IsTargetPvcTmpChanged(xset, target, pvcs); err != nil || changed {
+		t.Errorf("IsTargetPvcTmpChanged() with cached templates = %v, %v, want false", changed, err)
+	}
+
+	pc.ForgetOwner(xset.Namespace, xset.Name)
+	if _, ok := pc.tmpHashCache.Load(types.NamespacedName{Namespace: xset.Namespace, Name: xset.Name}); ok {
+		t.Fatal("templates of xset are still cached after ForgetOwner")
+	}
+	if changed, err := pc.IsTargetPvcTmpChanged(xset, target, pvcs); err != nil || !changed {
+		t.Errorf("IsTargetPvcTmpChanged() after ForgetOwner = %v, %v, want true", changed, err)
+	}
+
+	// xset recreated with the same name does not reuse templates of the deleted one
+	controller.templates = []corev1.PersistentVolumeClaim{newPvcTemplate("data", "1Gi")}
+	recreated := newTestXSet()
+	recreated.UID = "bar-uid"
+	if changed, err := pc.IsTargetPvcTmpChanged(recreated, target, pvcs); err != nil || changed {
+		t.Errorf("IsTargetPvcTmpChanged() of recreated xset = %v, %v, want false", changed, err)
+	}
+}
+
+func BenchmarkIsTargetPvcTmpChanged(b *testing.B) {
+	controller := &sizeOverridePvcController{
+		expansionPvcController: &expansionPvcController{pvcController: &pvcController{templates: []corev1.PersistentVolumeClaim{
+			newPvcTemplate("data", "1Gi"), newPvcTemplate("log", "1Gi"),
+		}}},
+		sizes: map[int]string{0: "2Gi"},
+	}
+	pc, _, _ := newTestPvcControl(controller, controller)
+	xset := newTestXSet()
+	var targets []*corev1.Pod
+	var pvcs [][]*corev1.PersistentVolumeClaim
+	for i := 0; i < 100; i++ {
+		target, owned := newTargetWithPvcs(b, pc, xset, strconv.Itoa(i))
+		targets = append(targets, target)
+		pvcs = append(pvcs, owned)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range targets {
+			if _, err := pc.IsTargetPvcTmpChanged(xset, targets[j], pvcs[j]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subresources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// ErrPvcExpansionNotAllowed is returned by ExpandPvcs if StorageClass of pvcs to expand does not allow volume expansion
+var ErrPvcExpansionNotAllowed = errors.New("StorageClass does not allow volume expansion")
+
+// ExpandPvcs expands pvcs in place if only the storage request of their pvc template grows, and returns names of
+// pvcs which are still expanding. Expanded pvcs are labeled with the new template hash, so that they are regarded
+// as updated and targets are not recreated for them.
+func (pc *RealPvcControl) ExpandPvcs(ctx context.Context, xset api.XSetObject, existingPvcs []*corev1.PersistentVolumeClaim) ([]string, error) {
+	adapter, ok := pc.pvcAdapter.(api.PvcExpansionAdapter)
+	if !ok || !adapter.ExpandPvcOnTemplateChange(xset) {
+		return nil, nil
+	}
+
+	templates := map[string]*corev1.PersistentVolumeClaim{}
+	pvcTemplates := pc.pvcAdapter.GetXSetPvcTemplate(xset)
+	for i := range pvcTemplates {
+		templates[pvcTemplates[i].Name] = &pvcTemplates[i]
+	}
+
+	var expanding []string
+	var errs []error
+	for _, pvc := range existingPvcs {
+		if pvc.DeletionTimestamp != nil {
+			continue
+		}
+		if isPvcExpanding(pvc) {
+			expanding = append(expanding, pvc.Name)
+			continue
+		}
+		tmpName, exist := pc.xsetLabelAnnoMgr.Get(pvc, api.SubResourcePvcTemplateLabelKey)
+		if !exist || templates[tmpName] == nil {
+			continue
+		}
+		expanded, err := pc.expandPvc(ctx, xset, pvc, templates[tmpName])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if expanded {
+			expanding = append(expanding, pvc.Name)
+		}
+	}
+	return expanding, errors.Join(errs...)
+}
+
+// expandPvc patches storage request of pvc to the one in template sized for the instance, if it is the only change
+// of the sized template pvc is created from
+func (pc *RealPvcControl) expandPvc(ctx context.Context, xset api.XSetObject, pvc, template *corev1.PersistentVolumeClaim) (bool, error) {
+	hash, _ := pc.xsetLabelAnnoMgr.Get(pvc, api.SubResourcePvcTemplateHashLabelKey)
+	id, _ := pc.xsetLabelAnnoMgr.Get(pvc, api.XInstanceIdLabelKey)
+	sized := pc.withPvcSizeOverride(xset, id, template)
+	newHash, err := PvcTmpHash(sized)
+	if err != nil {
+		return false, err
+	}
+
+	newSize, currentSize := sized.Spec.Resources.Requests[corev1.ResourceStorage], pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if newSize.Cmp(currentSize) <= 0 {
+		return false, nil
+	}
+
+	// sized template with current storage request should be the one pvc is created from
+	if hash != newHash {
+		oldTemplate := sized.DeepCopy()
+		if oldTemplate.Spec.Resources.Requests == nil {
+			oldTemplate.Spec.Resources.Requests = corev1.ResourceList{}
+		}
+		oldTemplate.Spec.Resources.Requests[corev1.ResourceStorage] = currentSize
+		if oldHash, err := PvcTmpHash(oldTemplate); err != nil || oldHash != hash {
+			return false, err
+		}
+	}
+
+	if allowed, err := pc.allowVolumeExpansion(ctx, pvc); err != nil {
+		return false, err
+	} else if !allowed {
+		return false, fmt.Errorf("fail to expand pvc %s/%s of StorageClass %q: %w", pvc.Namespace, pvc.Name, ptr.Deref(pvc.Spec.StorageClassName, ""), ErrPvcExpansionNotAllowed)
+	}
+
+	patch := client.MergeFromWithOptions(pvc.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	pvc.Spec.Resources.Requests = pvc.Spec.Resources.Requests.DeepCopy()
+	pvc.Spec.Resources.Requests[corev1.ResourceStorage] = newSize
+	pc.xsetLabelAnnoMgr.Set(pvc, api.SubResourcePvcTemplateHashLabelKey, newHash)
+	if err := pc.client.Patch(ctx, pvc, patch); err != nil {
+		pc.recorder.Eventf(pvc, corev1.EventTypeWarning, "PvcExpansionFailed", "fail to expand pvc from %s to %s: %s", currentSize.String(), newSize.String(), err.Error())
+		return false, fmt.Errorf("fail to expand pvc %s/%s: %w", pvc.Namespace, pvc.Name, err)
+	}
+	pc.recorder.Eventf(pvc, corev1.EventTypeNormal, "PvcExpanding", "expand pvc from %s to %s for %s", currentSize.String(), newSize.String(), xset.GetName())
+	return true, nil
+}
+
+func (pc *RealPvcControl) allowVolumeExpansion(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	if ptr.Deref(pvc.Spec.StorageClassName, "") == "" {
+		return false, nil
+	}
+	sc := &storagev1.StorageClass{}
+	if err := pc.client.Get(ctx, client.ObjectKey{Name: *pvc.Spec.StorageClassName}, sc); err != nil {
+		return false, fmt.Errorf("fail to get StorageClass %s: %w", *pvc.Spec.StorageClassName, err)
+	}
+	return ptr.Deref(sc.AllowVolumeExpansion, false), nil
+}
+
+// isPvcExpanding checks whether the capacity of pvc is less than its storage request
+func isPvcExpanding(pvc *corev1.PersistentVolumeClaim) bool {
+	capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]
+	if !ok {
+		return false
+	}
+	return capacity.Cmp(pvc.Spec.Resources.Requests[corev1.ResourceStorage]) < 0
+}
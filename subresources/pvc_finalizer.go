@@ -0,0 +1,76 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subresources
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// PvcProtectionFinalizer is the finalizer added by kubernetes to pvcs in use, which is removed once pvc is not mounted
+const PvcProtectionFinalizer = "kubernetes.io/pvc-protection"
+
+const (
+	minPvcDeletionRecheckBackoff = 10 * time.Second
+	maxPvcDeletionRecheckBackoff = 5 * time.Minute
+)
+
+// DeletionProtectionFinalizers returns third-party finalizers on pvc, which may block its deletion indefinitely
+func DeletionProtectionFinalizers(pvc *corev1.PersistentVolumeClaim) []string {
+	var finalizers []string
+	for _, finalizer := range pvc.Finalizers {
+		if finalizer != PvcProtectionFinalizer {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+	return finalizers
+}
+
+// PvcDeletionRecheckBackoff returns the duration to recheck a deletion blocked pvc, which grows with the time it is
+// being deleted
+func PvcDeletionRecheckBackoff(pvc *corev1.PersistentVolumeClaim) time.Duration {
+	if pvc.DeletionTimestamp == nil {
+		return minPvcDeletionRecheckBackoff
+	}
+	return min(max(time.Since(pvc.DeletionTimestamp.Time), minPvcDeletionRecheckBackoff), maxPvcDeletionRecheckBackoff)
+}
+
+// GetDeletionBlockedPvcs returns pvcs owned by XSet which are being deleted but blocked by third-party finalizers
+func (pc *RealPvcControl) GetDeletionBlockedPvcs(ctx context.Context, xset api.XSetObject) ([]*corev1.PersistentVolumeClaim, error) {
+	ownedPvcList := &corev1.PersistentVolumeClaimList{}
+	if err := pc.client.List(ctx, ownedPvcList, &client.ListOptions{
+		Namespace:     xset.GetNamespace(),
+		FieldSelector: fields.OneTermEqualSelector(FieldIndexOwnerRefUID, string(xset.GetUID())),
+	}); err != nil {
+		return nil, err
+	}
+
+	var blockedPvcs []*corev1.PersistentVolumeClaim
+	for i := range ownedPvcList.Items {
+		pvc := &ownedPvcList.Items[i]
+		if pvc.DeletionTimestamp != nil && len(DeletionProtectionFinalizers(pvc)) > 0 {
+			blockedPvcs = append(blockedPvcs, pvc)
+		}
+	}
+	return blockedPvcs, nil
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subresources
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+func (pc *RealPvcControl) getStorageClassMigration(xset api.XSetObject) *api.StorageClassMigration {
+	if adapter, ok := pc.pvcAdapter.(api.StorageClassMigrationAdapter); ok {
+		return adapter.GetStorageClassMigration(xset)
+	}
+	return nil
+}
+
+// isStorageClassChanged checks whether storageClassName of pvc template differs from the one of pvc
+func isStorageClassChanged(pvc, pvcTmp *corev1.PersistentVolumeClaim) bool {
+	return ptr.Deref(pvc.Spec.StorageClassName, "") != ptr.Deref(pvcTmp.Spec.StorageClassName, "")
+}
+
+// isTargetStorageClassChanged checks whether any pvc mounted by target is on a StorageClass other than its template's
+func (pc *RealPvcControl) isTargetStorageClassChanged(xset api.XSetObject, x client.Object, existingPvcs []*corev1.PersistentVolumeClaim) bool {
+	templates := map[string]*corev1.PersistentVolumeClaim{}
+	pvcTemplates, err := pc.getPvcTemplates(xset)
+	if err != nil {
+		return false
+	}
+	for i := range pvcTemplates {
+		templates[pvcTemplates[i].Name] = &pvcTemplates[i]
+	}
+	pvcs := map[string]*corev1.PersistentVolumeClaim{}
+	for _, pvc := range existingPvcs {
+		pvcs[pvc.Name] = pvc
+	}
+
+	for _, volume := range pc.pvcAdapter.GetXSpecVolumes(x) {
+		if volume.PersistentVolumeClaim == nil || templates[volume.Name] == nil {
+			continue
+		}
+		if pvc, exist := pvcs[volume.PersistentVolumeClaim.ClaimName]; exist && isStorageClassChanged(pvc, templates[volume.Name]) {
+			return true
+		}
+	}
+	return false
+}
+
+// migrateDataSource sets data source of new claim to the VolumeSnapshot of old pvc, if old pvc is on another
+// StorageClass and data is migrated by snapshot
+func (pc *RealPvcControl) migrateDataSource(ctx context.Context, xset api.XSetObject, migration *api.StorageClassMigration, oldPvc, claim *corev1.PersistentVolumeClaim) error {
+	if migration == nil || oldPvc == nil || !isStorageClassChanged(oldPvc, claim) {
+		return nil
+	}
+	if migration.Type != api.SnapshotStorageClassMigrationType {
+		pc.recorder.Eventf(xset, corev1.EventTypeNormal, "StorageClassMigrating", "re-provision pvc on StorageClass %s in place of pvc %s",
+			ptr.Deref(claim.Spec.StorageClassName, ""), oldPvc.Name)
+		return nil
+	}
+
+	if _, err := pc.createVolumeSnapshot(ctx, oldPvc, migration.VolumeSnapshotClassName); err != nil {
+		pc.recorder.Eventf(xset, corev1.EventTypeWarning, "StorageClassMigrationFailed", "fail to snapshot pvc %s: %s", oldPvc.Name, err.Error())
+		return err
+	}
+	claim.Spec.DataSource = &corev1.TypedLocalObjectReference{
+		APIGroup: ptr.To(VolumeSnapshotGvk.Group),
+		Kind:     VolumeSnapshotGvk.Kind,
+		Name:     oldPvc.Name,
+	}
+	pc.recorder.Eventf(xset, corev1.EventTypeNormal, "StorageClassMigrating", "restore pvc on StorageClass %s from VolumeSnapshot %s",
+		ptr.Deref(claim.Spec.StorageClassName, ""), oldPvc.Name)
+	return nil
+}
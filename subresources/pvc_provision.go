@@ -0,0 +1,86 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subresources
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// IsTargetPvcsBound checks whether pvcs mounted by target are Bound before target is created. It always returns true
+// if PvcPreProvisionAdapter is not implemented, or pvcs have been waited for longer than timeout.
+func (pc *RealPvcControl) IsTargetPvcsBound(ctx context.Context, xset api.XSetObject, x client.Object) (bool, error) {
+	adapter, ok := pc.pvcAdapter.(api.PvcPreProvisionAdapter)
+	if !ok {
+		return true, nil
+	}
+	timeout := adapter.GetPvcBoundTimeout(xset)
+	if timeout <= 0 {
+		return true, nil
+	}
+
+	for _, volume := range pc.pvcAdapter.GetXSpecVolumes(x) {
+		if volume.PersistentVolumeClaim == nil || volume.PersistentVolumeClaim.ClaimName == "" {
+			continue
+		}
+		pvc := &corev1.PersistentVolumeClaim{}
+		if err := pc.client.Get(ctx, client.ObjectKey{Namespace: x.GetNamespace(), Name: volume.PersistentVolumeClaim.ClaimName}, pvc); err != nil {
+			if apierrors.IsNotFound(err) {
+				// pvc just created is not in cache yet
+				return false, nil
+			}
+			return false, fmt.Errorf("fail to get pvc %s: %w", volume.PersistentVolumeClaim.ClaimName, err)
+		}
+		if pvc.Status.Phase == corev1.ClaimBound {
+			continue
+		}
+		if waitForFirstConsumer, err := pc.isWaitForFirstConsumer(ctx, pvc); err != nil {
+			return false, err
+		} else if waitForFirstConsumer {
+			continue
+		}
+		if time.Since(pvc.CreationTimestamp.Time) > timeout {
+			pc.recorder.Eventf(xset, corev1.EventTypeWarning, "PvcBoundTimeout", "pvc %s is not Bound in %s, create target anyway", pvc.Name, timeout.String())
+			continue
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+func (pc *RealPvcControl) isWaitForFirstConsumer(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	if ptr.Deref(pvc.Spec.StorageClassName, "") == "" {
+		return false, nil
+	}
+	sc := &storagev1.StorageClass{}
+	if err := pc.client.Get(ctx, client.ObjectKey{Name: *pvc.Spec.StorageClassName}, sc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("fail to get StorageClass %s: %w", *pvc.Spec.StorageClassName, err)
+	}
+	return ptr.Deref(sc.VolumeBindingMode, storagev1.VolumeBindingImmediate) == storagev1.VolumeBindingWaitForFirstConsumer, nil
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subresources
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// ReattachTargetPvcs relabels pvcs mounted by origin target with the instance ID of new target x if Reattach policy is
+// used, so that they are mounted by x in CreateTargetPvcs, and are not deleted along with origin target.
+func (pc *RealPvcControl) ReattachTargetPvcs(ctx context.Context, xset api.XSetObject, origin, x client.Object, existingPvcs []*corev1.PersistentVolumeClaim) error {
+	adapter, ok := pc.pvcAdapter.(api.ReplacePvcPolicyAdapter)
+	if !ok || adapter.GetReplacePvcPolicy(xset) != api.ReattachReplacePvcPolicyType {
+		return nil
+	}
+	newId, exist := pc.xsetLabelAnnoMgr.Get(x, api.XInstanceIdLabelKey)
+	if !exist {
+		return nil
+	}
+
+	mounted := map[string]bool{}
+	for _, volume := range pc.pvcAdapter.GetXSpecVolumes(origin) {
+		if volume.PersistentVolumeClaim != nil {
+			mounted[volume.PersistentVolumeClaim.ClaimName] = true
+		}
+	}
+	for _, pvc := range existingPvcs {
+		if !mounted[pvc.Name] {
+			continue
+		}
+		if id, _ := pc.xsetLabelAnnoMgr.Get(pvc, api.XInstanceIdLabelKey); id == newId {
+			continue
+		}
+		patch := client.MergeFromWithOptions(pvc.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		pc.xsetLabelAnnoMgr.Set(pvc, api.XInstanceIdLabelKey, newId)
+		if err := pc.client.Patch(ctx, pvc, patch); err != nil {
+			return fmt.Errorf("fail to reattach pvc %s/%s: %w", pvc.Namespace, pvc.Name, err)
+		}
+		pc.recorder.Eventf(xset, corev1.EventTypeNormal, "PvcReattached", "reattach pvc %s of target %s to instance %s", pvc.Name, origin.GetName(), newId)
+	}
+	return nil
+}
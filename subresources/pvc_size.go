@@ -0,0 +1,86 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subresources
+
+import (
+	"maps"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// withPvcSizeOverride returns a shallow copy of pvc template with storage request overridden for instance id, which
+// shares other fields with template and must not be modified. Pvc template is returned as is if no size is overridden.
+func (pc *RealPvcControl) withPvcSizeOverride(xset api.XSetObject, id string, pvcTmp *corev1.PersistentVolumeClaim) *corev1.PersistentVolumeClaim {
+	adapter, ok := pc.pvcAdapter.(api.PvcSizeOverrideAdapter)
+	if !ok {
+		return pvcTmp
+	}
+	instanceID, err := strconv.Atoi(id)
+	if err != nil {
+		return pvcTmp
+	}
+	size := adapter.GetPvcSizeOverride(xset, instanceID, pvcTmp.Name)
+	if size == nil {
+		return pvcTmp
+	}
+	sized := *pvcTmp
+	sized.Spec.Resources.Requests = maps.Clone(pvcTmp.Spec.Resources.Requests)
+	if sized.Spec.Resources.Requests == nil {
+		sized.Spec.Resources.Requests = corev1.ResourceList{}
+	}
+	sized.Spec.Resources.Requests[corev1.ResourceStorage] = *size
+	return &sized
+}
+
+// getInstancePvcTmpHashMapping returns hash of pvc templates sized for instance id, which pvcs of the instance are
+// labeled with. Returned mapping is shared and must not be modified.
+func (pc *RealPvcControl) getInstancePvcTmpHashMapping(xset api.XSetObject, id string) (map[string]string, error) {
+	hashes, err := pc.getPvcTmpHashMapping(xset)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := pc.pvcAdapter.(api.PvcSizeOverrideAdapter); !ok {
+		return hashes, nil
+	}
+
+	var sizedHashes map[string]string
+	templates, err := pc.getPvcTemplates(xset)
+	if err != nil {
+		return nil, err
+	}
+	for i := range templates {
+		sized := pc.withPvcSizeOverride(xset, id, &templates[i])
+		if sized == &templates[i] {
+			continue
+		}
+		hash, err := PvcTmpHash(sized)
+		if err != nil {
+			return nil, err
+		}
+		if sizedHashes == nil {
+			sizedHashes = maps.Clone(hashes)
+		}
+		sizedHashes[templates[i].Name] = hash
+	}
+	if sizedHashes == nil {
+		return hashes, nil
+	}
+	return sizedHashes, nil
+}
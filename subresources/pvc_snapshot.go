@@ -0,0 +1,126 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subresources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+var VolumeSnapshotGvk = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+
+func (pc *RealPvcControl) getPvcSnapshotPolicy(xset api.XSetObject) *api.PvcSnapshotPolicy {
+	if adapter, ok := pc.pvcAdapter.(api.PvcSnapshotAdapter); ok {
+		return adapter.GetPvcSnapshotPolicy(xset)
+	}
+	return nil
+}
+
+// snapshotPvc creates a VolumeSnapshot of pvc before it is deleted
+func (pc *RealPvcControl) snapshotPvc(ctx context.Context, xset api.XSetObject, pvc *corev1.PersistentVolumeClaim, policy *api.PvcSnapshotPolicy) error {
+	created, err := pc.createVolumeSnapshot(ctx, pvc, policy.VolumeSnapshotClassName)
+	if err != nil {
+		pc.recorder.Eventf(xset, corev1.EventTypeWarning, "PvcSnapshotFailed", "fail to create VolumeSnapshot for pvc %s: %s", pvc.Name, err.Error())
+		return err
+	}
+	// snapshot may be created in previous reconcile which fails to delete pvc
+	if !created {
+		return nil
+	}
+	pc.recorder.Eventf(xset, corev1.EventTypeNormal, "PvcSnapshotted", "create VolumeSnapshot %s before deleting pvc", pvc.Name)
+	return nil
+}
+
+// createVolumeSnapshot creates a VolumeSnapshot named after pvc, and returns false if it already exists. Snapshot is not
+// owned by XSet, so that it is retained even if XSet is deleted.
+func (pc *RealPvcControl) createVolumeSnapshot(ctx context.Context, pvc *corev1.PersistentVolumeClaim, volumeSnapshotClassName *string) (bool, error) {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(VolumeSnapshotGvk)
+	snapshot.SetNamespace(pvc.Namespace)
+	snapshot.SetName(pvc.Name)
+	snapshot.SetLabels(pvc.GetLabels())
+
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": pvc.Name,
+		},
+	}
+	if volumeSnapshotClassName != nil {
+		spec["volumeSnapshotClassName"] = *volumeSnapshotClassName
+	}
+	if err := unstructured.SetNestedMap(snapshot.Object, spec, "spec"); err != nil {
+		return false, err
+	}
+
+	if err := pc.client.Create(ctx, snapshot); apierrors.IsAlreadyExists(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("fail to create VolumeSnapshot for pvc %s/%s: %w", pvc.Namespace, pvc.Name, err)
+	}
+	return true, nil
+}
+
+// DeleteExpiredPvcSnapshots deletes VolumeSnapshots created for pvcs of XSet which outlive the TTL of snapshot policy.
+func (pc *RealPvcControl) DeleteExpiredPvcSnapshots(ctx context.Context, xset api.XSetObject) error {
+	policy := pc.getPvcSnapshotPolicy(xset)
+	if policy == nil || policy.TTL == nil {
+		return nil
+	}
+
+	xsetSpec := pc.xsetController.GetXSetSpec(xset)
+	ownerSelector := xsetSpec.Selector.DeepCopy()
+	if ownerSelector.MatchLabels == nil {
+		ownerSelector.MatchLabels = map[string]string{}
+	}
+	ownerSelector.MatchLabels[pc.xsetLabelAnnoMgr.Value(api.ControlledByXSetLabel)] = "true"
+	selector, err := metav1.LabelSelectorAsSelector(ownerSelector)
+	if err != nil {
+		return err
+	}
+
+	snapshots := &unstructured.UnstructuredList{}
+	snapshots.SetGroupVersionKind(VolumeSnapshotGvk.GroupVersion().WithKind(VolumeSnapshotGvk.Kind + "List"))
+	if err := pc.client.List(ctx, snapshots, &client.ListOptions{
+		Namespace:     xset.GetNamespace(),
+		LabelSelector: selector,
+	}); err != nil {
+		return fmt.Errorf("fail to list VolumeSnapshots: %w", err)
+	}
+
+	var errs []error
+	for i := range snapshots.Items {
+		snapshot := &snapshots.Items[i]
+		if snapshot.GetDeletionTimestamp() != nil || time.Since(snapshot.GetCreationTimestamp().Time) < policy.TTL.Duration {
+			continue
+		}
+		if err := pc.client.Delete(ctx, snapshot); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("fail to delete expired VolumeSnapshot %s/%s: %w", snapshot.GetNamespace(), snapshot.GetName(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
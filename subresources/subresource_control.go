@@ -0,0 +1,274 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subresources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kubeutilclient "kusionstack.io/kube-utils/client"
+	"kusionstack.io/kube-utils/controller/expectations"
+	"kusionstack.io/kube-utils/controller/mixin"
+	refmanagerutil "kusionstack.io/kube-utils/controller/refmanager"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// SubresourceControl manages a kind of per-instance objects owned by XSet, which are created along with targets,
+// adopted when left orphaned, and reclaimed once their instance IDs are reclaimed by scaling in. Subresources are
+// garbage collected along with XSet by owner references.
+type SubresourceControl interface {
+	// CreateTargetSubresources creates subresources for the instance before its target is created
+	CreateTargetSubresources(ctx context.Context, xset api.XSetObject, contextDetail *api.ContextDetail) error
+	// AdoptSubresources adopts orphaned subresources matching selector of XSet, e.g., left by a deleted XSet
+	AdoptSubresources(ctx context.Context, xset api.XSetObject) error
+	// DeleteUnusedSubresources deletes subresources whose instance IDs are not owned by XSet
+	DeleteUnusedSubresources(ctx context.Context, xset api.XSetObject, ownedIDs map[int]*api.ContextDetail) error
+}
+
+// NewSubresourceControls returns controls for per-instance subresources enabled by adapters of xsetController
+func NewSubresourceControls(mixin *mixin.ReconcilerMixin, expectations expectations.CacheExpectationsInterface, xsetLabelAnnoMgr api.XSetLabelAnnotationManager, xsetController api.XSetController) []SubresourceControl {
+	var controls []SubresourceControl
+	if control := NewRealConfigControl(mixin, expectations, xsetLabelAnnoMgr, xsetController); control != nil {
+		controls = append(controls, control)
+	}
+	if adapter, ok := xsetController.(api.SubResourceAdapter); ok {
+		for _, meta := range adapter.SubResourceMetas() {
+			controls = append(controls, &RealObjectControl{
+				subresourceBase: subresourceBase{
+					client:           mixin.Client,
+					scheme:           mixin.Scheme,
+					expectations:     expectations,
+					xsetLabelAnnoMgr: xsetLabelAnnoMgr,
+					xsetController:   xsetController,
+				},
+				adapter: adapter,
+				meta:    meta,
+			})
+		}
+	}
+	return controls
+}
+
+// subresourceBase contains helpers shared by SubresourceControl implementations
+type subresourceBase struct {
+	client           client.Client
+	scheme           *runtime.Scheme
+	expectations     expectations.CacheExpectationsInterface
+	xsetLabelAnnoMgr api.XSetLabelAnnotationManager
+	xsetController   api.XSetController
+}
+
+// create sets owner reference and labels of XSet on subresource and creates it with expectation
+func (b *subresourceBase) create(ctx context.Context, xset api.XSetObject, obj client.Object, gvk schema.GroupVersionKind, id int) error {
+	obj.SetNamespace(xset.GetNamespace())
+	obj.SetResourceVersion("")
+	xsetMeta := b.xsetController.XSetMeta()
+	obj.SetOwnerReferences(append(obj.GetOwnerReferences(), *metav1.NewControllerRef(xset, xsetMeta.GroupVersionKind())))
+	if obj.GetLabels() == nil {
+		obj.SetLabels(map[string]string{})
+	}
+	labels := obj.GetLabels()
+	for k, v := range b.xsetController.GetXSetSpec(xset).Selector.MatchLabels {
+		labels[k] = v
+	}
+	obj.SetLabels(labels)
+	b.xsetLabelAnnoMgr.Set(obj, api.ControlledByXSetLabel, "true")
+	b.xsetLabelAnnoMgr.Set(obj, api.XInstanceIdLabelKey, strconv.Itoa(id))
+
+	if err := b.client.Create(ctx, obj); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return nil
+		}
+		return fmt.Errorf("fail to create %s %s: %w", gvk.Kind, obj.GetName(), err)
+	}
+	return b.expect(xset, gvk, obj, true)
+}
+
+// list lists subresources labeled controlled by XSet with instance ID
+func (b *subresourceBase) list(ctx context.Context, xset api.XSetObject, list client.ObjectList) ([]client.Object, error) {
+	listGvk := list.GetObjectKind().GroupVersionKind()
+	if err := b.client.List(ctx, list, client.InNamespace(xset.GetNamespace()), client.MatchingLabels{
+		b.xsetLabelAnnoMgr.Value(api.ControlledByXSetLabel): "true",
+	}, client.HasLabels{b.xsetLabelAnnoMgr.Value(api.XInstanceIdLabelKey)}); err != nil {
+		return nil, err
+	}
+	// items of metadata list are not typed with kind, which is required to delete or patch them
+	if metaList, ok := list.(*metav1.PartialObjectMetadataList); ok {
+		for i := range metaList.Items {
+			metaList.Items[i].SetGroupVersionKind(listGvk.GroupVersion().WithKind(strings.TrimSuffix(listGvk.Kind, "List")))
+		}
+	}
+	items, err := apimeta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+	objs := make([]client.Object, 0, len(items))
+	for i := range items {
+		if obj, ok := items[i].(client.Object); ok {
+			objs = append(objs, obj)
+		}
+	}
+	return objs, nil
+}
+
+// adopt claims orphaned subresources matching selector of XSet
+func (b *subresourceBase) adopt(ctx context.Context, xset api.XSetObject, objs []client.Object) error {
+	xsetSpec := b.xsetController.GetXSetSpec(xset)
+	if xsetSpec.Selector.MatchLabels == nil {
+		return nil
+	}
+	matcher, err := refmanagerutil.LabelSelectorAsMatch(xsetSpec.Selector)
+	if err != nil {
+		return fmt.Errorf("fail to create labelSelector matcher: %w", err)
+	}
+	refManager := refmanagerutil.NewObjectControllerRefManager(refmanagerutil.NewOwnerRefWriter(b.client), xset, xset.GetObjectKind().GroupVersionKind(), matcher)
+
+	var errs []error
+	for _, obj := range objs {
+		if len(obj.GetOwnerReferences()) > 0 || obj.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if _, err := refManager.Claim(ctx, obj); err != nil {
+			errs = append(errs, fmt.Errorf("fail to adopt %s: %w", obj.GetName(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deleteUnused deletes subresources owned by XSet whose names are not expected
+func (b *subresourceBase) deleteUnused(ctx context.Context, xset api.XSetObject, objs []client.Object, names sets.String, gvk schema.GroupVersionKind) error {
+	var errs []error
+	for _, obj := range objs {
+		ownerRef := metav1.GetControllerOf(obj)
+		if ownerRef == nil || ownerRef.UID != xset.GetUID() || names.Has(obj.GetName()) || obj.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if err := b.client.Delete(ctx, obj); err != nil {
+			if !apierrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("fail to delete %s %s: %w", gvk.Kind, obj.GetName(), err))
+			}
+			continue
+		}
+		errs = append(errs, b.expect(xset, gvk, obj, false))
+	}
+	return errors.Join(errs...)
+}
+
+// watch enqueues owner XSet for changes of subresources of type obj
+func (b *subresourceBase) watch(c controller.Controller, obj client.Object) error {
+	return c.Watch(&source.Kind{Type: obj}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    b.xsetController.NewXSetObject(),
+	})
+}
+
+// expect adds creation or deletion expectation, only for kinds registered in scheme. Secrets are skipped, because they
+// are only cached as metadata, while expectations are checked by getting the typed object which caches Secrets in full.
+func (b *subresourceBase) expect(xset api.XSetObject, gvk schema.GroupVersionKind, obj client.Object, creation bool) error {
+	if !b.scheme.Recognizes(gvk) || gvk == SecretGvk {
+		return nil
+	}
+	if creation {
+		return b.expectations.ExpectCreation(kubeutilclient.ObjectKeyString(xset), gvk, obj.GetNamespace(), obj.GetName())
+	}
+	return b.expectations.ExpectDeletion(kubeutilclient.ObjectKeyString(xset), gvk, obj.GetNamespace(), obj.GetName())
+}
+
+var (
+	_ SubresourceControl = &RealObjectControl{}
+	_ api.WatchProvider  = &RealObjectControl{}
+)
+
+// RealObjectControl manages subresources of one kind registered by SubResourceAdapter
+type RealObjectControl struct {
+	subresourceBase
+	adapter api.SubResourceAdapter
+	meta    metav1.TypeMeta
+}
+
+func (oc *RealObjectControl) objectName(xset api.XSetObject, id int) string {
+	return fmt.Sprintf("%s-%s-%d", xset.GetName(), strings.ToLower(oc.meta.Kind), id)
+}
+
+func (oc *RealObjectControl) CreateTargetSubresources(ctx context.Context, xset api.XSetObject, contextDetail *api.ContextDetail) error {
+	obj, err := oc.adapter.NewSubResourceFrom(xset, oc.meta, contextDetail)
+	if err != nil {
+		return fmt.Errorf("fail to new %s for instance %d: %w", oc.meta.Kind, contextDetail.ID, err)
+	}
+	if obj == nil {
+		return nil
+	}
+	if obj.GetName() == "" {
+		obj.SetName(oc.objectName(xset, contextDetail.ID))
+	}
+	return oc.create(ctx, xset, obj, oc.meta.GroupVersionKind(), contextDetail.ID)
+}
+
+func (oc *RealObjectControl) AdoptSubresources(ctx context.Context, xset api.XSetObject) error {
+	objs, err := oc.listObjects(ctx, xset)
+	if err != nil {
+		return err
+	}
+	return oc.adopt(ctx, xset, objs)
+}
+
+func (oc *RealObjectControl) DeleteUnusedSubresources(ctx context.Context, xset api.XSetObject, ownedIDs map[int]*api.ContextDetail) error {
+	objs, err := oc.listObjects(ctx, xset)
+	if err != nil {
+		return err
+	}
+	names := sets.String{}
+	for _, obj := range objs {
+		id, err := strconv.Atoi(obj.GetLabels()[oc.xsetLabelAnnoMgr.Value(api.XInstanceIdLabelKey)])
+		if _, owned := ownedIDs[id]; err == nil && owned {
+			names.Insert(obj.GetName())
+		}
+	}
+	return oc.deleteUnused(ctx, xset, objs, names, oc.meta.GroupVersionKind())
+}
+
+func (oc *RealObjectControl) RegisterWatches(c controller.Controller) error {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(oc.meta.GroupVersionKind())
+	return oc.watch(c, obj)
+}
+
+func (oc *RealObjectControl) listObjects(ctx context.Context, xset api.XSetObject) ([]client.Object, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetAPIVersion(oc.meta.APIVersion)
+	list.SetKind(oc.meta.Kind + "List")
+	objs, err := oc.list(ctx, xset, list)
+	if err != nil {
+		return nil, fmt.Errorf("fail to list %s: %w", oc.meta.Kind, err)
+	}
+	return objs, nil
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subresources
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+var testSubResourceMeta = metav1.TypeMeta{APIVersion: "example.kusionstack.io/v1", Kind: "Certificate"}
+
+type objectController struct {
+	*pvcController
+}
+
+func (c *objectController) SubResourceMetas() []metav1.TypeMeta {
+	return []metav1.TypeMeta{testSubResourceMeta}
+}
+
+func (c *objectController) NewSubResourceFrom(_ api.XSetObject, meta metav1.TypeMeta, contextDetail *api.ContextDetail) (client.Object, error) {
+	// no certificate for instance 3
+	if contextDetail.ID == 3 {
+		return nil, nil
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(meta.APIVersion)
+	obj.SetKind(meta.Kind)
+	return obj, unstructured.SetNestedField(obj.Object, "id-"+strconv.Itoa(contextDetail.ID), "spec", "commonName")
+}
+
+func TestObjectControl(t *testing.T) {
+	controller := &objectController{pvcController: &pvcController{}}
+	pc, c, exp := newTestPvcControl(controller, controller)
+	oc := &RealObjectControl{
+		subresourceBase: subresourceBase{
+			client:           c,
+			scheme:           pc.scheme,
+			expectations:     exp,
+			xsetLabelAnnoMgr: pc.xsetLabelAnnoMgr,
+			xsetController:   controller,
+		},
+		adapter: controller,
+		meta:    testSubResourceMeta,
+	}
+	xset := newTestXSet()
+	for id := 0; id < 4; id++ {
+		if err := oc.CreateTargetSubresources(context.TODO(), xset, &api.ContextDetail{ID: id}); err != nil {
+			t.Fatalf("CreateTargetSubresources() = %v", err)
+		}
+	}
+	// creating again is no-op
+	if err := oc.CreateTargetSubresources(context.TODO(), xset, &api.ContextDetail{ID: 0}); err != nil {
+		t.Fatalf("CreateTargetSubresources() = %v", err)
+	}
+
+	objs, err := oc.listObjects(context.TODO(), xset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 3 {
+		t.Fatalf("got %d subresources, want 3", len(objs))
+	}
+	obj := objs[0].(*unstructured.Unstructured)
+	if obj.GetName() != "foo-certificate-0" || obj.GetLabels()["app"] != "foo" {
+		t.Errorf("subresource = %s with labels %v, want foo-certificate-0 labeled by selector", obj.GetName(), obj.GetLabels())
+	}
+	if commonName, _, _ := unstructured.NestedString(obj.Object, "spec", "commonName"); commonName != "id-0" {
+		t.Errorf("commonName = %q, want id-0", commonName)
+	}
+	// kinds not registered in scheme are not expected
+	if len(exp.records) != 0 {
+		t.Errorf("expectations = %v, want none", exp.records)
+	}
+
+	if err := oc.DeleteUnusedSubresources(context.TODO(), xset, map[int]*api.ContextDetail{1: {ID: 1}}); err != nil {
+		t.Fatalf("DeleteUnusedSubresources() = %v", err)
+	}
+	if objs, err = oc.listObjects(context.TODO(), xset); err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 1 || objs[0].GetName() != "foo-certificate-1" {
+		t.Errorf("left subresources = %v, want foo-certificate-1", objs)
+	}
+}
+
+// watchController records types of objects watched
+type watchController struct {
+	controller.Controller
+	watched []client.Object
+}
+
+func (c *watchController) Watch(src source.Source, _ handler.EventHandler, _ ...predicate.Predicate) error {
+	if kind, ok := src.(*source.Kind); ok {
+		c.watched = append(c.watched, kind.Type)
+	}
+	return nil
+}
+
+func TestRegisterWatches(t *testing.T) {
+	controller := &configController{pvcController: &pvcController{}}
+	cc, _, _ := newTestConfigControl(controller)
+	c := &watchController{}
+	if err := cc.RegisterWatches(c); err != nil {
+		t.Fatalf("RegisterWatches() = %v", err)
+	}
+	if len(c.watched) != 2 {
+		t.Fatalf("got %d watches, want ConfigMap and Secret", len(c.watched))
+	}
+	if _, ok := c.watched[0].(*corev1.ConfigMap); !ok {
+		t.Errorf("watched %T, want ConfigMap", c.watched[0])
+	}
+	if secret, ok := c.watched[1].(*metav1.PartialObjectMetadata); !ok || secret.GroupVersionKind() != SecretGvk {
+		t.Errorf("watched %T, want Secret metadata", c.watched[1])
+	}
+
+	oc := &RealObjectControl{subresourceBase: cc.subresourceBase, meta: testSubResourceMeta}
+	c = &watchController{}
+	if err := oc.RegisterWatches(c); err != nil {
+		t.Fatalf("RegisterWatches() = %v", err)
+	}
+	if len(c.watched) != 1 || c.watched[0].GetObjectKind().GroupVersionKind() != testSubResourceMeta.GroupVersionKind() {
+		t.Errorf("watched %v, want %s", c.watched, testSubResourceMeta.Kind)
+	}
+}
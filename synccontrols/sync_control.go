@@ -20,12 +20,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -39,8 +43,10 @@ import (
 	"kusionstack.io/kube-utils/controller/mixin"
 	controllerutils "kusionstack.io/kube-utils/controller/utils"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
 	"kusionstack.io/kube-xset/opslifecycle"
 	"kusionstack.io/kube-xset/resourcecontexts"
 	"kusionstack.io/kube-xset/subresources"
@@ -59,16 +65,30 @@ type SyncControl interface {
 	CalculateStatus(ctx context.Context, instance api.XSetObject, syncContext *SyncContext) *api.XSetStatus
 
 	BatchDeleteTargetsByLabel(ctx context.Context, targetControl xcontrol.TargetControl, needDeleteTargets []client.Object) error
+
+	ReleaseProtectionFinalizers(ctx context.Context, instance api.XSetObject, targets []client.Object) error
+
+	CanSkipSync(ctx context.Context, instance api.XSetObject, syncContext *SyncContext) (bool, error)
+
+	RemediateDrift(ctx context.Context, instance api.XSetObject, syncContext *SyncContext) error
+
+	// ForgetOwner drops states kept in memory for a deleted xset
+	ForgetOwner(namespace, name string)
 }
 
 func NewRealSyncControl(reconcileMixIn *mixin.ReconcilerMixin,
 	xsetController api.XSetController,
 	xControl xcontrol.TargetControl,
 	pvcControl subresources.PvcControl,
+	subresourceControls []subresources.SubresourceControl,
 	xsetLabelAnnoManager api.XSetLabelAnnotationManager,
 	resourceContexts resourcecontexts.ResourceContextControl,
 	cacheExpectations expectations.CacheExpectationsInterface,
-) SyncControl {
+) (SyncControl, error) {
+	kubeClient, err := newKubeClient(xsetController, reconcileMixIn.Config)
+	if err != nil {
+		return nil, err
+	}
 	xMeta := xsetController.XMeta()
 	targetGVK := xMeta.GroupVersionKind()
 	xsetMeta := xsetController.XSetMeta()
@@ -87,6 +107,7 @@ func NewRealSyncControl(reconcileMixIn *mixin.ReconcilerMixin,
 		UpdateLifecycleAdapter:  updateLifecycleAdapter,
 		CacheExpectations:       cacheExpectations,
 		TargetGVK:               targetGVK,
+		KubeClient:              kubeClient,
 	}
 	return &RealSyncControl{
 		ReconcilerMixin:        *reconcileMixIn,
@@ -95,15 +116,20 @@ func NewRealSyncControl(reconcileMixIn *mixin.ReconcilerMixin,
 		resourceContextControl: resourceContexts,
 		xControl:               xControl,
 		pvcControl:             pvcControl,
+		subresourceControls:    subresourceControls,
 
 		updateConfig:      updateConfig,
 		cacheExpectations: cacheExpectations,
 		xsetGVK:           xsetGVK,
 		targetGVK:         targetGVK,
+		writeLimiters:     newWriteLimiters(),
+		postCreateHooks:   newHookRunner(PostCreateHookTimeout, MaxConcurrentPostCreateHooks),
+		postDeleteHooks:   newHookRunner(PostDeleteHookTimeout, MaxConcurrentPostDeleteHooks),
+		deferredTargets:   newDeferredTargets(),
 
 		scaleInLifecycleAdapter: scaleInOpsLifecycleAdapter,
 		updateLifecycleAdapter:  updateLifecycleAdapter,
-	}
+	}, nil
 }
 
 var _ SyncControl = &RealSyncControl{}
@@ -112,6 +138,7 @@ type RealSyncControl struct {
 	mixin.ReconcilerMixin
 	xControl               xcontrol.TargetControl
 	pvcControl             subresources.PvcControl
+	subresourceControls    []subresources.SubresourceControl
 	xsetController         api.XSetController
 	xsetLabelAnnoMgr       api.XSetLabelAnnotationManager
 	resourceContextControl resourcecontexts.ResourceContextControl
@@ -123,6 +150,37 @@ type RealSyncControl struct {
 	cacheExpectations expectations.CacheExpectationsInterface
 	xsetGVK           schema.GroupVersionKind
 	targetGVK         schema.GroupVersionKind
+	writeLimiters     *writeLimiters
+	postCreateHooks   *hookRunner
+	postDeleteHooks   *hookRunner
+	deferredTargets   *deferredTargets
+	// driftChecks keeps driftCheck of each XSet
+	driftChecks sync.Map
+}
+
+func (r *RealSyncControl) ForgetOwner(namespace, name string) {
+	key := namespacedKeyString(namespace, name)
+	r.deferredTargets.forget(key)
+	r.writeLimiters.forget(key)
+	r.driftChecks.Delete(key)
+}
+
+// updatePvcExpansionCondition updates PvcExpansion condition by result of expanding pvcs. PvcExpansionNotAllowed
+// event is only emitted when condition transits to ExpansionNotAllowed, rather than on every reconcile.
+func (r *RealSyncControl) updatePvcExpansionCondition(instance api.XSetObject, status *api.XSetStatus, expanding []string, err error) {
+	switch {
+	case errors.Is(err, subresources.ErrPvcExpansionNotAllowed):
+		if cond := conditions.Get(status, api.XSetPvcExpansion); cond == nil || cond.Reason != conditions.ReasonExpansionNotAllowed {
+			r.Recorder.Eventf(instance, corev1.EventTypeWarning, "PvcExpansionNotAllowed", "%s", err.Error())
+		}
+		conditions.SetFromError(status, api.XSetPvcExpansion, err, conditions.ReasonExpansionNotAllowed, err.Error())
+	case err != nil:
+		conditions.SetFromError(status, api.XSetPvcExpansion, err, conditions.ReasonExpandFailed, err.Error())
+	case len(expanding) > 0:
+		conditions.SetFromError(status, api.XSetPvcExpansion, nil, conditions.ReasonExpanding, fmt.Sprintf("pvcs %v are expanding", expanding))
+	default:
+		conditions.Remove(status, api.XSetPvcExpansion)
+	}
 }
 
 // SyncTargets is used to parse targetWrappers and reclaim Target instance ID
@@ -134,12 +192,15 @@ func (r *RealSyncControl) SyncTargets(ctx context.Context, instance api.XSetObje
 		return false, fmt.Errorf("fail to get XSetSpec")
 	}
 
-	filteredTargets, allTargets, err := r.xControl.GetFilteredTargets(ctx, xspec.Selector, instance)
+	if syncContext.TargetSnapshot == nil {
+		syncContext.TargetSnapshot = xcontrol.NewTargetSnapshot(r.xControl, xspec.Selector, instance)
+	}
+	filteredTargets, allTargets, err := syncContext.TargetSnapshot.GetFilteredTargets(ctx)
 	if err != nil {
 		return false, fmt.Errorf("fail to get filtered Targets: %w", err)
 	}
 
-	if IsTargetNamingSuffixPolicyPersistentSequence(xspec) {
+	if IsTargetNamingDeterministic(r.xsetController, instance) {
 		// for naming with persistent sequences suffix, targets with same name should not exist at same time
 		syncContext.FilteredTarget = allTargets
 	} else {
@@ -147,6 +208,13 @@ func (r *RealSyncControl) SyncTargets(ctx context.Context, instance api.XSetObje
 		syncContext.FilteredTarget = filteredTargets
 	}
 
+	// report pvcs whose deletion is blocked, during both scaling in and XSet deletion
+	if _, enabled := subresources.GetSubresourcePvcAdapter(r.xsetController); enabled {
+		if err = r.syncPvcDeletionBlocked(ctx, instance, syncContext); err != nil {
+			return false, err
+		}
+	}
+
 	if instance.GetDeletionTimestamp() != nil {
 		return false, nil
 	}
@@ -164,6 +232,22 @@ func (r *RealSyncControl) SyncTargets(ctx context.Context, instance api.XSetObje
 		}
 		syncContext.ExistingPvcs = append(syncContext.ExistingPvcs, existingPvcs...)
 		syncContext.ExistingPvcs = append(syncContext.ExistingPvcs, adoptedPvcs...)
+
+		// expand pvcs in place if only storage request of pvc template grows, which does not block syncing
+		expanding, err := r.pvcControl.ExpandPvcs(ctx, instance, syncContext.ExistingPvcs)
+		r.updatePvcExpansionCondition(instance, syncContext.NewStatus, expanding, err)
+
+		// clean up pvc snapshots which outlive TTL
+		if err := r.pvcControl.DeleteExpiredPvcSnapshots(ctx, instance); err != nil {
+			r.Recorder.Eventf(instance, corev1.EventTypeWarning, "PvcSnapshotCleanupFailed", "%s", err.Error())
+		}
+	}
+
+	// adopt orphaned per-instance subresources
+	for _, control := range r.subresourceControls {
+		if err = control.AdoptSubresources(ctx, instance); err != nil {
+			return false, fmt.Errorf("fail to adopt subresources: %w", err)
+		}
 	}
 
 	// sync include exclude targets
@@ -172,25 +256,60 @@ func (r *RealSyncControl) SyncTargets(ctx context.Context, instance api.XSetObje
 		return false, fmt.Errorf("fail to deal with include exclude targets: %w", err)
 	}
 
-	if err := r.resourceContextControl.CleanUnusedIDs(ctx, instance, syncContext.FilteredTarget); err != nil {
+	// resolve instance IDs from fallback sources for targets without instance ID label
+	if err := r.resolveInstanceIDs(ctx, instance, syncContext.FilteredTarget); err != nil {
+		return false, fmt.Errorf("fail to resolve instance IDs: %w", err)
+	}
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		dryRun := instance.GetAnnotations()[api.XSetIDCleanDryRunAnnotationKey] == "true"
+		_, err := r.resourceContextControl.CleanUnusedIDs(ctx, instance, syncContext.FilteredTarget, dryRun)
+		return err
+	}); err != nil {
 		return false, fmt.Errorf("fail to clean unused ids: %w", err)
 	}
 
 	// get owned IDs
 	var ownedIDs map[int]*api.ContextDetail
-	if err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		ownedIDs, err = r.resourceContextControl.AllocateID(ctx, instance, syncContext.CurrentRevision.GetName(), syncContext.UpdatedRevision.GetName(), int(ptr.Deref(xspec.Replicas, 0)), syncContext.FilteredTarget)
 		syncContext.OwnedIds = ownedIDs
 		return err
-	}); err != nil {
+	})
+	if err = r.checkPoolExhausted(instance, syncContext, err); err != nil {
 		return false, fmt.Errorf("fail to allocate %d IDs using context when sync Targets: %w", ptr.Deref(xspec.Replicas, 0), err)
 	}
 
+	// assign IDs to targets adopted without instance ID
+	if err = r.assignIDsToAdoptedTargets(ctx, instance, syncContext); err != nil {
+		return false, fmt.Errorf("fail to assign IDs to adopted Targets: %w", err)
+	}
+	ownedIDs = syncContext.OwnedIds
+
 	// stateless case
 	var targetWrappers []*TargetWrapper
 	syncContext.CurrentIDs = sets.Int{}
 	idToReclaim := sets.Int{}
 	toDeleteTargetNames := sets.NewString(xspec.ScaleStrategy.TargetToDelete...)
+	var targetsToRelease []client.Object
+	needUpdateContext := false
+
+	// report and force delete targets stuck in terminating
+	stuckPolicy := getStuckTerminatingPolicy(r.xsetController, instance)
+	if err := r.handleStuckTerminatingTargets(ctx, instance, syncContext, stuckPolicy); err != nil {
+		r.Recorder.Eventf(instance, corev1.EventTypeWarning, "StuckTerminatingTarget", "handle targets stuck in terminating with error: %s", err.Error())
+		return false, err
+	}
+
+	// resolve infos of targets wrapped below, which are independent of each other
+	namingDeterministic := IsTargetNamingDeterministic(r.xsetController, instance)
+	wrapperInfos, err := r.resolveTargetWrapperInfos(ctx, instance, syncContext.FilteredTarget, func(target client.Object) bool {
+		_, replaceIndicate := r.xsetLabelAnnoMgr.Get(target, api.XReplaceIndicationLabelKey)
+		return target.GetDeletionTimestamp() == nil || namingDeterministic || replaceIndicate
+	})
+	if err != nil {
+		return false, err
+	}
 
 	for i := range syncContext.FilteredTarget {
 		target := syncContext.FilteredTarget[i]
@@ -198,6 +317,7 @@ func (r *RealSyncControl) SyncTargets(ctx context.Context, instance api.XSetObje
 		id, _ := xcontrol.GetInstanceID(r.xsetLabelAnnoMgr, target)
 		toDelete := toDeleteTargetNames.Has(xName)
 		toExclude := toExcludeTargetNames.Has(xName)
+		excluded := false
 
 		// priority: toDelete > toReplace > toExclude
 		if toDelete {
@@ -210,11 +330,36 @@ func (r *RealSyncControl) SyncTargets(ctx context.Context, instance api.XSetObje
 			} else {
 				// exclude target and delete its targetContext
 				idToReclaim.Insert(id)
+				excluded = true
 			}
 		}
 
-		// for naming with persistent sequences suffix, targets with same name should not exist at same time
-		if target.GetDeletionTimestamp() != nil && !IsTargetNamingSuffixPolicyPersistentSequence(xspec) {
+		// track target for PostDelete hook, except excluded one which is not deleted
+		if excluded {
+			if r.untrackTargetForPostDelete(ownedIDs[id]) {
+				needUpdateContext = true
+			}
+		} else if r.trackTargetForPostDelete(target, ownedIDs[id]) {
+			needUpdateContext = true
+		}
+
+		// record the node target landed on, to recreate target on it
+		if r.recordTargetNode(instance, target, ownedIDs[id]) {
+			needUpdateContext = true
+		}
+
+		// acknowledge deletion of protected target, whose finalizer is released after its ID reclaimed
+		if target.GetDeletionTimestamp() != nil && r.isProtectedByFinalizer(instance, target) {
+			if !r.isDeletedByXSet(target, ownedIDs[id]) {
+				r.Recorder.Eventf(target, corev1.EventTypeWarning, "TargetDeletedOutOfBand", "target with instance ID %d is deleted out of band, and will be recreated by %s %s", id, r.xsetGVK.Kind, instance.GetName())
+			}
+			targetsToRelease = append(targetsToRelease, target)
+		}
+
+		// for naming with persistent sequences suffix, targets with same name should not exist at same time, and
+		// targets stuck in terminating release their IDs if policy says so
+		stuck := isStuckTerminating(stuckPolicy, target)
+		if target.GetDeletionTimestamp() != nil && (!namingDeterministic || stuck && releasesStuckTerminatingID(stuckPolicy, target)) {
 			// 1. Reclaim ID from Target which is scaling in and terminating.
 			if contextDetail, exist := ownedIDs[id]; exist && r.resourceContextControl.Contains(contextDetail, api.EnumScaleInContextDataKey, "true") {
 				idToReclaim.Insert(id)
@@ -223,6 +368,10 @@ func (r *RealSyncControl) SyncTargets(ctx context.Context, instance api.XSetObje
 			_, replaceIndicate := r.xsetLabelAnnoMgr.Get(target, api.XReplaceIndicationLabelKey)
 			// 2. filter out Targets which are terminating and not replace indicate
 			if !replaceIndicate {
+				// stuck target retaining its ID keeps the ID from being reused
+				if stuck && !releasesStuckTerminatingID(stuckPolicy, target) && id >= 0 && !idToReclaim.Has(id) {
+					syncContext.CurrentIDs.Insert(id)
+				}
 				continue
 			}
 		}
@@ -235,26 +384,7 @@ func (r *RealSyncControl) SyncTargets(ctx context.Context, instance api.XSetObje
 			}
 		}
 
-		// sync decoration revisions
-		var decorationInfo DecorationInfo
-		if decorationAdapter, enabled := r.xsetController.(api.DecorationAdapter); enabled {
-			if decorationInfo.DecorationCurrentRevisions, err = decorationAdapter.GetTargetCurrentDecorationRevisions(ctx, r.Client, target); err != nil {
-				return false, err
-			}
-			if decorationInfo.DecorationUpdatedRevisions, err = decorationAdapter.GetTargetUpdatedDecorationRevisions(ctx, r.Client, target); err != nil {
-				return false, err
-			}
-			if decorationInfo.DecorationChanged, err = decorationAdapter.IsTargetDecorationChanged(decorationInfo.DecorationCurrentRevisions, decorationInfo.DecorationUpdatedRevisions); err != nil {
-				return false, err
-			}
-		}
-
-		// sync target ops priority
-		var opsPriority *api.OpsPriority
-		if opsPriority, err = r.xsetController.GetXOpsPriority(ctx, r.Client, target); err != nil {
-			return false, err
-		}
-
+		wrapperInfo := wrapperInfos[i]
 		targetWrappers = append(targetWrappers, &TargetWrapper{
 			Object:        target,
 			ID:            id,
@@ -264,11 +394,13 @@ func (r *RealSyncControl) SyncTargets(ctx context.Context, instance api.XSetObje
 			ToDelete:  toDelete,
 			ToExclude: toExclude,
 
-			IsDuringScaleInOps: opslifecycle.IsDuringOps(r.updateConfig.XsetLabelAnnoMgr, r.scaleInLifecycleAdapter, target),
-			IsDuringUpdateOps:  opslifecycle.IsDuringOps(r.updateConfig.XsetLabelAnnoMgr, r.updateLifecycleAdapter, target),
+			IsDuringScaleInOps: wrapperInfo.isDuringScaleInOps,
+			IsDuringUpdateOps:  wrapperInfo.isDuringUpdateOps,
+			OnPreemptingNode:   wrapperInfo.onPreemptingNode,
+			StuckTerminating:   stuck,
 
-			DecorationInfo: decorationInfo,
-			OpsPriority:    opsPriority,
+			DecorationInfo: wrapperInfo.DecorationInfo,
+			OpsPriority:    wrapperInfo.opsPriority,
 		})
 
 		if id >= 0 {
@@ -276,6 +408,16 @@ func (r *RealSyncControl) SyncTargets(ctx context.Context, instance api.XSetObje
 		}
 	}
 
+	// run PostCreate hooks for new targets in background, whose results are recorded in contexts
+	if r.runPostCreateHooks(instance, syncContext, targetWrappers) {
+		needUpdateContext = true
+	}
+
+	// run PostDelete hooks for deleted targets in background, whose IDs are held until hooks succeeded
+	if r.runPostDeleteHooks(instance, syncContext, ownedIDs, allTargets) {
+		needUpdateContext = true
+	}
+
 	// do include exclude targets, and skip doSync() if succeeded
 	var inExSucceed bool
 	if len(toExcludeTargetNames) > 0 || len(toIncludeTargetNames) > 0 {
@@ -293,12 +435,38 @@ func (r *RealSyncControl) SyncTargets(ctx context.Context, instance api.XSetObje
 	}
 
 	// reclaim Target ID which is (1) during ScalingIn, (2) ExcludeTargets
-	err = r.reclaimOwnedIDs(ctx, false, instance, idToReclaim, ownedIDs, syncContext.CurrentIDs)
+	err = r.reclaimOwnedIDs(ctx, needUpdateContext, instance, idToReclaim, ownedIDs, syncContext.CurrentIDs)
 	if err != nil {
 		r.Recorder.Eventf(instance, corev1.EventTypeWarning, "ReclaimOwnedIDs", "reclaim target contexts with error: %s", err.Error())
+		// do not hold protected targets in terminating forever if their IDs keep failing to be reclaimed
+		if releaseErr := r.ReleaseProtectionFinalizers(ctx, instance, filterProtectionReleaseTimedOut(targetsToRelease)); releaseErr != nil {
+			return false, errors.Join(err, releaseErr)
+		}
 		return false, err
 	}
 
+	// delete per-instance subresources whose instance ID is reclaimed
+	for _, control := range r.subresourceControls {
+		if err = control.DeleteUnusedSubresources(ctx, instance, ownedIDs); err != nil {
+			return false, fmt.Errorf("fail to delete unused subresources: %w", err)
+		}
+	}
+
+	// keep governing headless Service consistent with XSet
+	if err = r.syncHeadlessService(ctx, instance); err != nil {
+		return false, err
+	}
+
+	// keep companion objects consistent with targets by instance ID
+	if err = r.syncCompanions(ctx, instance, syncContext.FilteredTarget); err != nil {
+		return false, fmt.Errorf("fail to sync companions: %w", err)
+	}
+
+	// release protection finalizers after IDs of terminating targets reclaimed
+	if err = r.ReleaseProtectionFinalizers(ctx, instance, targetsToRelease); err != nil {
+		return false, fmt.Errorf("fail to release protection finalizers: %w", err)
+	}
+
 	// reclaim scaleStrategy for delete, exclude, include
 	err = r.reclaimScaleStrategy(ctx, toDeleteTargetNames, toExcludeTargetNames, toIncludeTargetNames, instance)
 	if err != nil {
@@ -429,11 +597,43 @@ func (r *RealSyncControl) Replace(ctx context.Context, xsetObject api.XSetObject
 	var idToReclaim sets.Int
 
 	defer func() {
-		syncContext.activeTargets = FilterOutActiveTargetWrappers(syncContext.TargetWrappers)
+		syncContext.activeTargets, syncContext.quarantinedTargets = splitQuarantinedTargetWrappers(r.xsetLabelAnnoMgr,
+			FilterOutStuckTerminatingTargetWrappers(FilterOutActiveTargetWrappers(syncContext.TargetWrappers)))
 		syncContext.replacingMap = classifyTargetReplacingMapping(r.xsetLabelAnnoMgr, syncContext.activeTargets)
 	}()
 
+	checkMaintenanceWindows(r.xsetController.GetXSetSpec(xsetObject), time.Now(), syncContext)
+
+	// replace targets requested to evict by descheduler
+	if err = r.replaceEvictionRequestedTargets(ctx, xsetObject, syncContext.TargetWrappers); err != nil {
+		r.Recorder.Eventf(xsetObject, corev1.EventTypeWarning, "ReplaceTarget", "replace targets requested to evict with error: %s", err.Error())
+		return err
+	}
+
+	// replace targets on nodes NotReady or unreachable
+	if err = r.replaceTargetsOnFailedNodes(ctx, xsetObject, syncContext); err != nil {
+		r.Recorder.Eventf(xsetObject, corev1.EventTypeWarning, "ReplaceTarget", "replace targets on failed nodes with error: %s", err.Error())
+		return err
+	}
+
+	// remediate targets which repeatedly fail
+	if err = r.remediateCrashLoopTargets(ctx, xsetObject, syncContext); err != nil {
+		r.Recorder.Eventf(xsetObject, corev1.EventTypeWarning, "RemediateTarget", "remediate crash looping targets with error: %s", err.Error())
+		return err
+	}
+
+	// take quarantined targets out of service
+	if err = r.quarantineTargets(ctx, xsetObject, syncContext.TargetWrappers); err != nil {
+		r.Recorder.Eventf(xsetObject, corev1.EventTypeWarning, "QuarantineTarget", "quarantine targets with error: %s", err.Error())
+		return err
+	}
+
 	needReplaceOriginTargets, needCleanLabelTargets, targetsNeedCleanLabels, needDeleteTargets := r.dealReplaceTargets(ctx, syncContext.TargetWrappers)
+	if syncContext.OutOfMaintenanceWindow && len(needDeleteTargets) > 0 {
+		// origin targets are kept along with their replacements until maintenance window
+		syncContext.heldByMaintenanceWindow += len(needDeleteTargets)
+		needDeleteTargets = nil
+	}
 
 	// delete origin targets for replace
 	err = r.BatchDeleteTargetsByLabel(ctx, r.xControl, needDeleteTargets)
@@ -492,13 +692,21 @@ func (r *RealSyncControl) Scale(ctx context.Context, xsetObject api.XSetObject,
 	logger := logr.FromContext(ctx)
 	var recordedRequeueAfter *time.Duration
 
-	diff := int(ptr.Deref(spec.Replicas, 0)) - len(syncContext.replacingMap)
+	activeTargets, replacingMap := syncContext.activeTargets, syncContext.replacingMap
+	if excludeTerminatingTargets(r.xsetController, xsetObject) {
+		activeTargets = FilterOutTerminatingTargetWrappers(activeTargets)
+		replacingMap = classifyTargetReplacingMapping(r.xsetLabelAnnoMgr, activeTargets)
+	}
+
+	diff := int(ptr.Deref(spec.Replicas, 0)) - len(replacingMap)
 	scaling := false
 
 	if diff >= 0 {
-		// trigger delete targets indicated in ScaleStrategy.TargetToDelete by label
-		for _, targetWrapper := range syncContext.activeTargets {
-			if targetWrapper.ToDelete {
+		// trigger delete targets indicated in ScaleStrategy.TargetToDelete by label, including quarantined ones
+		for _, targetWrapper := range append(slices.Clip(activeTargets), syncContext.quarantinedTargets...) {
+			if targetWrapper.ToDelete && syncContext.OutOfMaintenanceWindow {
+				syncContext.heldByMaintenanceWindow++
+			} else if targetWrapper.ToDelete {
 				err := r.BatchDeleteTargetsByLabel(ctx, r.xControl, []client.Object{targetWrapper.Object})
 				if err != nil {
 					return false, recordedRequeueAfter, err
@@ -510,9 +718,14 @@ func (r *RealSyncControl) Scale(ctx context.Context, xsetObject api.XSetObject,
 		if diff > 0 {
 			// collect instance ID in used from owned Targets
 			targetInstanceIDSet := sets.Int{}
-			for _, target := range syncContext.activeTargets {
+			for _, target := range activeTargets {
 				targetInstanceIDSet[target.ID] = struct{}{}
 			}
+			// catch unrecoverable errors before allocating IDs
+			if err := r.dryRunScaleOut(ctx, xsetObject, syncContext, targetInstanceIDSet); err != nil {
+				r.Recorder.Eventf(xsetObject, corev1.EventTypeWarning, "DryRunCreateFailed", "dry-run creation of target failed: %s", err.Error())
+				return false, recordedRequeueAfter, fmt.Errorf("fail to dry-run create target: %w", err)
+			}
 
 			// find IDs and their contexts which have not been used by owned Targets
 			var availableContexts []*api.ContextDetail
@@ -523,10 +736,31 @@ func (r *RealSyncControl) Scale(ctx context.Context, xsetObject api.XSetObject,
 			}
 
 			needUpdateContext := atomic.Bool{}
+			if r.assignZones(xsetObject, activeTargets, availableContexts) {
+				needUpdateContext.Store(true)
+			}
+			if r.assignSubsets(xsetObject, activeTargets, availableContexts) {
+				needUpdateContext.Store(true)
+			}
+			availableContexts = r.prioritizeCreation(xsetObject, availableContexts)
+			for _, availableContext := range availableContexts {
+				syncContext.Decisions.CreateIDs = append(syncContext.Decisions.CreateIDs, availableContext.ID)
+			}
+			waitingPvcCount := atomic.Int32{}
+			waitingPostDeleteCount := atomic.Int32{}
+			throttledCount := atomic.Int32{}
+			writeLimiter := r.getWriteLimiter(xsetObject)
+			// results of creation are collected per ID and recorded in status after all batches finished
+			createErrs := make([]error, len(availableContexts))
+			createRevisions := make([]string, len(availableContexts))
+			created := make([]bool, len(availableContexts))
+			backoffs := make([]time.Duration, len(availableContexts))
 			succCount, err := controllerutils.SlowStartBatch(len(availableContexts), controllerutils.SlowStartInitialBatchSize, false, func(i int, _ error) (err error) {
 				availableIDContext := availableContexts[i]
+				waitingPvc, waitingPostDelete, throttled := false, false, false
 				defer func() {
-					if r.resourceContextControl.DecideContextRevisionAfterCreate(availableIDContext, syncContext.UpdatedRevision, err) {
+					createErrs[i] = err
+					if !waitingPvc && !waitingPostDelete && !throttled && backoffs[i] <= 0 && r.resourceContextControl.DecideContextRevisionAfterCreate(availableIDContext, syncContext.UpdatedRevision, err) {
 						needUpdateContext.Store(true)
 					}
 				}()
@@ -540,18 +774,42 @@ func (r *RealSyncControl) Scale(ctx context.Context, xsetObject api.XSetObject,
 						}
 					}
 				}
+				// do not reuse ID until PostDelete hook of its previous target succeeded
+				if r.isPostDeletePending(availableIDContext) {
+					waitingPostDelete = true
+					waitingPostDeleteCount.Add(1)
+					return nil
+				}
+				// back off IDs which failed to create target of the same revision recently
+				createRevisions[i] = revision.GetName()
+				if remaining, backingOff := checkCreationBackoff(syncContext.NewStatus, availableIDContext.ID, revision.GetName()); backingOff {
+					backoffs[i] = remaining
+					return nil
+				}
+				// leave IDs beyond write rate limit of XSet to following reconciles
+				if !writeLimiter.tryAccept() {
+					throttled = true
+					throttledCount.Add(1)
+					return nil
+				}
 				// scale out new Targets with updatedRevision
 				// TODO use cache
 				target, err := NewTargetFrom(r.xsetController, r.xsetLabelAnnoMgr, xsetObject, revision, availableIDContext.ID,
 					func(object client.Object) error {
 						if _, exist := r.resourceContextControl.Get(availableIDContext, api.EnumJustCreateContextDataKey); exist {
 							r.xsetLabelAnnoMgr.Set(object, api.XCreatingLabel, strconv.FormatInt(time.Now().UnixNano(), 10))
+							StampLastAction(object, api.TargetActionCreatedForScale)
 						} else {
 							r.xsetLabelAnnoMgr.Set(object, api.XCompletingLabel, strconv.FormatInt(time.Now().UnixNano(), 10))
+							if _, recreate := r.resourceContextControl.Get(availableIDContext, api.EnumRecreateUpdateContextDataKey); recreate {
+								StampLastAction(object, api.TargetActionRecreatedForUpdate)
+							} else {
+								StampLastAction(object, api.TargetActionRecreated)
+							}
 						}
 
 						// decoration for target template
-						if decorationAdapter, ok := r.xsetController.(api.DecorationAdapter); ok {
+						if decorationAdapter, ok := GetDecorationAdapter(r.xsetController); ok {
 							revisionsInfo, ok := r.resourceContextControl.Get(availableIDContext, api.EnumTargetDecorationRevisionKey)
 							if !ok {
 								// get updated decoration revisions from target and write to resource context
@@ -570,26 +828,56 @@ func (r *RealSyncControl) Scale(ctx context.Context, xsetObject api.XSetObject,
 						}
 						return nil
 					},
-					r.xsetController.GetXSetTemplatePatcher(xsetObject),
+					r.subsetLabeler(availableIDContext),
+					GetTemplatePatcher(r.xsetController, xsetObject),
 				)
 				if err != nil {
 					return apierrors.NewInvalid(schema.GroupKind{Group: r.targetGVK.Group, Kind: r.targetGVK.Kind}, target.GetGenerateName(), []*field.Error{{Detail: err.Error()}})
 				}
+				r.stickToRecordedNode(xsetObject, target, availableIDContext)
+				r.injectZone(xsetObject, target, availableIDContext)
+				r.resourceContextControl.ProjectToTarget(availableIDContext, target)
+				if err = preCreateTarget(ctx, r.xsetController, xsetObject, target, availableIDContext); err != nil {
+					return err
+				}
 				// create pvcs for targets (pod)
 				if _, enabled := subresources.GetSubresourcePvcAdapter(r.xsetController); enabled {
 					err = r.pvcControl.CreateTargetPvcs(ctx, xsetObject, target, syncContext.ExistingPvcs)
 					if err != nil {
 						return fmt.Errorf("fail to create PVCs for target %s: %w", target.GetName(), err)
 					}
+					// wait for pvcs to be Bound before creating target
+					bound, err := r.pvcControl.IsTargetPvcsBound(ctx, xsetObject, target)
+					if err != nil {
+						return fmt.Errorf("fail to check PVCs bound for target %s: %w", target.GetName(), err)
+					}
+					if !bound {
+						waitingPvc = true
+						waitingPvcCount.Add(1)
+						return nil
+					}
+				}
+				// create per-instance subresources for targets
+				for _, control := range r.subresourceControls {
+					if err = control.CreateTargetSubresources(ctx, xsetObject, availableIDContext); err != nil {
+						return fmt.Errorf("fail to create subresources for target %s: %w", target.GetName(), err)
+					}
 				}
-				newTarget := target.DeepCopyObject().(client.Object)
+				// target is rendered for this creation only, and is not copied before created
 				logger.Info("try to create Target with revision of "+r.xsetGVK.Kind, "revision", revision.GetName())
-				if target, err = r.xControl.CreateTarget(ctx, newTarget); err != nil {
+				if target, err = r.xControl.CreateTarget(ctx, target); err != nil {
 					return err
 				}
+				created[i] = true
 				// add an expectation for this target creation, before next reconciling
 				return r.cacheExpectations.ExpectCreation(clientutil.ObjectKeyString(xsetObject), r.targetGVK, target.GetNamespace(), target.GetName())
 			})
+			if backingOffCount, backoff := recordCreationResults(syncContext, availableContexts, createRevisions, createErrs, created, backoffs); backingOffCount > 0 {
+				logger.Info("back off creating Targets for IDs which failed recently", "count", backingOffCount)
+				// IDs backing off are skipped without error, and are counted as succeeded by SlowStartBatch
+				succCount -= backingOffCount
+				recordedRequeueAfter = xcontrol.GetShorterDuration(recordedRequeueAfter, backoff)
+			}
 			if needUpdateContext.Load() {
 				logger.Info("try to update ResourceContext for XSet after scaling out", "Context", syncContext.OwnedIds)
 				if updateContextErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
@@ -599,19 +887,40 @@ func (r *RealSyncControl) Scale(ctx context.Context, xsetObject api.XSetObject,
 				}
 			}
 			if err != nil {
-				AddOrUpdateCondition(syncContext.NewStatus, api.XSetScale, err, "ScaleOutFailed", err.Error())
+				conditions.SetFromError(syncContext.NewStatus, api.XSetScale, err, conditions.ReasonScaleOutFailed, err.Error())
 				return succCount > 0, recordedRequeueAfter, err
 			}
+			if waiting := int(waitingPostDeleteCount.Load()); waiting > 0 {
+				logger.Info("wait for PostDelete hooks to succeed before reusing IDs", "count", waiting)
+				succCount -= waiting
+			}
+			if waiting := int(waitingPvcCount.Load()); waiting > 0 {
+				logger.Info("wait for PVCs to be Bound before creating Targets", "count", waiting)
+				succCount -= waiting
+				recordedRequeueAfter = xcontrol.GetShorterDuration(recordedRequeueAfter, ptr.To(PvcBoundCheckInterval))
+			}
+			if throttled := int(throttledCount.Load()); throttled > 0 {
+				logger.Info("throttle creating Targets by write rate limit of XSet", "count", throttled)
+				succCount -= throttled
+				syncContext.Decisions.RecordRequeue("WriteThrottled", ptr.To(writeLimiter.retryAfter()))
+				recordedRequeueAfter = xcontrol.GetShorterDuration(recordedRequeueAfter, ptr.To(writeLimiter.retryAfter()))
+			}
 			r.Recorder.Eventf(xsetObject, corev1.EventTypeNormal, "Scaled", "scale out %d Target(s)", succCount)
-			AddOrUpdateCondition(syncContext.NewStatus, api.XSetScale, nil, "Scaled", "")
+			conditions.SetFromError(syncContext.NewStatus, api.XSetScale, nil, conditions.ReasonScaled, "")
 			return succCount > 0, recordedRequeueAfter, err
 		}
 	}
 
-	if diff <= 0 {
+	if diff < 0 && syncContext.OutOfMaintenanceWindow {
+		// targets are not chosen to scale in until maintenance window
+		syncContext.heldByMaintenanceWindow -= diff
+	} else if diff <= 0 {
 		// chose the targets to scale in
-		targetsToScaleIn, requeueAfter := r.getTargetsToDelete(xsetObject, syncContext.activeTargets, syncContext.replacingMap, diff*-1)
+		targetsToScaleIn, requeueAfter := r.getTargetsToDelete(xsetObject, syncContext.UpdatedRevision.GetName(), activeTargets, replacingMap, diff*-1)
 		recordedRequeueAfter = xcontrol.GetShorterDuration(recordedRequeueAfter, requeueAfter)
+		for _, targetWrapper := range targetsToScaleIn {
+			syncContext.Decisions.DeleteIDs = append(syncContext.Decisions.DeleteIDs, targetWrapper.ID)
+		}
 		// filter out Targets need to trigger TargetOpsLifecycle
 		wrapperCh := make(chan *TargetWrapper, len(targetsToScaleIn))
 		for i := range targetsToScaleIn {
@@ -628,7 +937,9 @@ func (r *RealSyncControl) Scale(ctx context.Context, xsetObject api.XSetObject,
 
 			// trigger TargetOpsLifecycle with scaleIn OperationType
 			logger.V(1).Info("try to begin TargetOpsLifecycle for scaling in Target in XSet", "wrapper", ObjectKeyString(object))
-			if updated, err := opslifecycle.Begin(ctx, r.updateConfig.XsetLabelAnnoMgr, r.Client, r.scaleInLifecycleAdapter, object); err != nil {
+			if updated, err := opslifecycle.Begin(ctx, r.updateConfig.XsetLabelAnnoMgr, r.Client, r.scaleInLifecycleAdapter, object, func(obj client.Object) (bool, error) {
+				return StampLastAction(obj, api.TargetActionChosenForScaleIn), nil
+			}); err != nil {
 				return fmt.Errorf("fail to begin TargetOpsLifecycle for Scaling in Target %s/%s: %w", object.GetNamespace(), object.GetName(), err)
 			} else if updated {
 				wrapper.IsDuringScaleInOps = true
@@ -644,13 +955,14 @@ func (r *RealSyncControl) Scale(ctx context.Context, xsetObject api.XSetObject,
 		scaling = succCount > 0
 
 		if err != nil {
-			AddOrUpdateCondition(syncContext.NewStatus, api.XSetScale, err, "ScaleInFailed", err.Error())
+			conditions.SetFromError(syncContext.NewStatus, api.XSetScale, err, conditions.ReasonScaleInFailed, err.Error())
 			return scaling, recordedRequeueAfter, err
 		} else {
-			AddOrUpdateCondition(syncContext.NewStatus, api.XSetScale, nil, "Scaled", "")
+			conditions.SetFromError(syncContext.NewStatus, api.XSetScale, nil, conditions.ReasonScaled, "")
 		}
 
 		needUpdateContext := false
+		var approvalErr error
 		for i, targetWrapper := range targetsToScaleIn {
 			requeueAfter, allowed := opslifecycle.AllowOps(r.updateConfig.XsetLabelAnnoMgr, r.scaleInLifecycleAdapter, ptr.Deref(spec.UpdateStrategy.OperationDelaySeconds, 0), targetWrapper.Object)
 			if !allowed && targetWrapper.Object.GetDeletionTimestamp() == nil {
@@ -664,6 +976,19 @@ func (r *RealSyncControl) Scale(ctx context.Context, xsetObject api.XSetObject,
 				continue
 			}
 
+			// wait for deletion approval if required
+			if targetWrapper.GetDeletionTimestamp() == nil {
+				approved, approvalRequeueAfter, err := checkDeletionApproval(ctx, r.xsetController, r.xsetLabelAnnoMgr, r.xControl, r.Recorder, xsetObject, targetWrapper.Object)
+				if err != nil {
+					approvalErr = errors.Join(approvalErr, err)
+					continue
+				}
+				recordedRequeueAfter = xcontrol.GetShorterDuration(recordedRequeueAfter, approvalRequeueAfter)
+				if !approved {
+					continue
+				}
+			}
+
 			// if Target is allowed to operate or Target has already been deleted, promte to delete Target
 			if contextDetail, exist := syncContext.OwnedIds[targetWrapper.ID]; exist && !r.resourceContextControl.Contains(contextDetail, api.EnumScaleInContextDataKey, "true") {
 				needUpdateContext = true
@@ -683,18 +1008,31 @@ func (r *RealSyncControl) Scale(ctx context.Context, xsetObject api.XSetObject,
 			if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 				return r.resourceContextControl.UpdateToTargetContext(ctx, xsetObject, syncContext.OwnedIds)
 			}); err != nil {
-				AddOrUpdateCondition(syncContext.NewStatus, api.XSetScale, err, "ScaleInFailed", fmt.Sprintf("failed to update Context for scaling in: %s", err))
+				conditions.SetFromError(syncContext.NewStatus, api.XSetScale, err, conditions.ReasonScaleInFailed, fmt.Sprintf("failed to update Context for scaling in: %s", err))
 				return scaling, recordedRequeueAfter, err
 			} else {
-				AddOrUpdateCondition(syncContext.NewStatus, api.XSetScale, nil, "Scaled", "")
+				conditions.SetFromError(syncContext.NewStatus, api.XSetScale, nil, conditions.ReasonScaled, "")
 			}
 		}
 
 		// do delete Target resource
-		succCount, err = controllerutils.SlowStartBatch(len(wrapperCh), controllerutils.SlowStartInitialBatchSize, false, func(i int, _ error) error {
+		writeLimiter := r.getWriteLimiter(xsetObject)
+		throttledCount := atomic.Int32{}
+		evictionBlockedCount := atomic.Int32{}
+		succCount, err = BoundedParallelize(len(wrapperCh), getMaxConcurrentDeletions(r.xsetController), func(int) error {
 			target := <-wrapperCh
+			// leave Targets beyond write rate limit of XSet to following reconciles
+			if !writeLimiter.tryAccept() {
+				throttledCount.Add(1)
+				return nil
+			}
 			logger.Info("try to scale in Target", "target", ObjectKeyString(target))
-			if err := r.xControl.DeleteTarget(ctx, target.Object); err != nil {
+			if err := evictOrDeleteTarget(ctx, r.updateConfig, xsetObject, target.Object, r.scaleInGracePeriodSeconds(xsetObject, target.Object)); err != nil {
+				// eviction blocked by PodDisruptionBudget is retried in following reconciles
+				if errors.Is(err, errEvictionBlocked) {
+					evictionBlockedCount.Add(1)
+					return nil
+				}
 				return fmt.Errorf("fail to delete Target %s/%s when scaling in: %w", target.GetNamespace(), target.GetName(), err)
 			}
 
@@ -713,16 +1051,29 @@ func (r *RealSyncControl) Scale(ctx context.Context, xsetObject api.XSetObject,
 			}
 			return nil
 		})
+		if throttled := int(throttledCount.Load()); throttled > 0 {
+			logger.Info("throttle deleting Targets by write rate limit of XSet", "count", throttled)
+			succCount -= throttled
+			syncContext.Decisions.RecordRequeue("WriteThrottled", ptr.To(writeLimiter.retryAfter()))
+			recordedRequeueAfter = xcontrol.GetShorterDuration(recordedRequeueAfter, ptr.To(writeLimiter.retryAfter()))
+		}
+		if blocked := int(evictionBlockedCount.Load()); blocked > 0 {
+			logger.Info("eviction of Targets is blocked by PodDisruptionBudget", "count", blocked)
+			succCount -= blocked
+			syncContext.Decisions.RecordRequeue("EvictionBlocked", ptr.To(EvictionBlockedRequeueInterval))
+			recordedRequeueAfter = xcontrol.GetShorterDuration(recordedRequeueAfter, ptr.To(EvictionBlockedRequeueInterval))
+		}
 		scaling = scaling || succCount > 0
+		err = errors.Join(err, approvalErr)
 
 		if succCount > 0 {
 			r.Recorder.Eventf(xsetObject, corev1.EventTypeNormal, "Scaled", "scale in %d Target(s)", succCount)
 		}
 		if err != nil {
-			AddOrUpdateCondition(syncContext.NewStatus, api.XSetScale, err, "ScaleInFailed", fmt.Sprintf("fail to delete Target for scaling in: %s", err))
+			conditions.SetFromError(syncContext.NewStatus, api.XSetScale, err, conditions.ReasonScaleInFailed, fmt.Sprintf("fail to delete Target for scaling in: %s", err))
 			return scaling, recordedRequeueAfter, err
 		} else {
-			AddOrUpdateCondition(syncContext.NewStatus, api.XSetScale, nil, "Scaled", "")
+			conditions.SetFromError(syncContext.NewStatus, api.XSetScale, nil, conditions.ReasonScaled, "")
 		}
 	}
 
@@ -736,6 +1087,11 @@ func (r *RealSyncControl) Scale(ctx context.Context, xsetObject api.XSetObject,
 		}
 	}
 
+	// clear pending deletion of targets whose scaling in or update is canceled
+	if err := clearCanceledDeletionApprovals(ctx, r.xsetLabelAnnoMgr, r.xControl, syncContext.activeTargets); err != nil {
+		return scaling, recordedRequeueAfter, err
+	}
+
 	if needUpdateTargetContext {
 		logger.V(1).Info("try to update ResourceContext for XSet after scaling")
 		if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
@@ -746,7 +1102,7 @@ func (r *RealSyncControl) Scale(ctx context.Context, xsetObject api.XSetObject,
 	}
 
 	if !scaling {
-		AddOrUpdateCondition(syncContext.NewStatus, api.XSetScale, nil, "Scaled", "")
+		conditions.SetFromError(syncContext.NewStatus, api.XSetScale, nil, conditions.ReasonScaled, "")
 	}
 
 	return scaling, recordedRequeueAfter, nil
@@ -763,9 +1119,23 @@ func (r *RealSyncControl) Update(ctx context.Context, xsetObject api.XSetObject,
 		return false, recordedRequeueAfter, err
 	}
 
-	// 2. decide Target update candidates
-	candidates := r.decideTargetToUpdate(r.xsetController, xsetObject, targetUpdateInfos)
+	// 2. decide Target update candidates, all targets are rolled back regardless of partition
+	var candidates []*TargetUpdateInfo
+	if syncContext.RollingBack {
+		candidates = r.getTargetsUpdateTargets(targetUpdateInfos)
+	} else {
+		candidates = r.decideTargetToUpdate(r.xsetController, xsetObject, syncContext, targetUpdateInfos)
+	}
+	candidates, analysisRequeueAfter, err := r.gateUpdateByAnalysis(ctx, xsetObject, syncContext, targetUpdateInfos, candidates)
+	if err != nil {
+		return false, analysisRequeueAfter, err
+	}
+	if candidates, err = r.gateUpdateByWarningEvents(ctx, xsetObject, syncContext, targetUpdateInfos, candidates); err != nil {
+		return false, analysisRequeueAfter, err
+	}
+	spec := r.xsetController.GetXSetSpec(xsetObject)
 	targetToUpdate := filterOutPlaceHolderUpdateInfos(candidates)
+	preferPreemptingTargets(targetToUpdate)
 	targetCh := make(chan *TargetUpdateInfo, len(targetToUpdate))
 	updater := r.newTargetUpdater(xsetObject)
 	updating := false
@@ -776,10 +1146,15 @@ func (r *RealSyncControl) Update(ctx context.Context, xsetObject api.XSetObject,
 		if targetInfo.IsUpdatedRevision && !targetInfo.PvcTmpHashChanged && !targetInfo.DecorationChanged {
 			continue
 		}
+		syncContext.Decisions.UpdateIDs = append(syncContext.Decisions.UpdateIDs, targetInfo.ID)
 
 		// 3.1 fulfillTargetUpdateInfo to all not updatedRevision target
 		if targetInfo.CurrentRevision.GetName() != UnknownRevision {
-			if err = updater.FulfillTargetUpdatedInfo(ctx, syncContext.UpdatedRevision, targetInfo); err != nil {
+			revision := syncContext.UpdatedRevision
+			if targetInfo.PinnedRevision {
+				revision = targetInfo.UpdateRevision
+			}
+			if err = updater.FulfillTargetUpdatedInfo(ctx, revision, targetInfo); err != nil {
 				logger.Error(err, fmt.Sprintf("fail to analyze target %s/%s in-place update support", targetInfo.GetNamespace(), targetInfo.GetName()))
 				continue
 			}
@@ -789,6 +1164,11 @@ func (r *RealSyncControl) Update(ctx context.Context, xsetObject api.XSetObject,
 			continue
 		}
 
+		if syncContext.OutOfMaintenanceWindow && isRecreateUpdate(spec, targetInfo) {
+			syncContext.heldByMaintenanceWindow++
+			continue
+		}
+
 		if targetInfo.IsDuringScaleInOps || targetInfo.IsDuringUpdateOps {
 			continue
 		}
@@ -799,33 +1179,38 @@ func (r *RealSyncControl) Update(ctx context.Context, xsetObject api.XSetObject,
 	// 4. begin target update lifecycle
 	updating, err = updater.BeginUpdateTarget(ctx, syncContext, targetCh)
 	if err != nil {
-		return updating, recordedRequeueAfter, err
+		return updating, analysisRequeueAfter, err
 	}
 
 	// 5. (1) filter out  targets not allow to ops now, such as OperationDelaySeconds strategy; (2) update PlaceHolder Targets resourceContext revision
 	recordedRequeueAfter, err = updater.FilterAllowOpsTargets(ctx, candidates, syncContext.OwnedIds, syncContext, targetCh)
+	recordedRequeueAfter = xcontrol.GetShorterDuration(recordedRequeueAfter, analysisRequeueAfter)
 	if err != nil {
-		AddOrUpdateCondition(syncContext.NewStatus,
-			api.XSetUpdate, err, "UpdateFailed",
+		conditions.SetFromError(syncContext.NewStatus,
+			api.XSetUpdate, err, conditions.ReasonUpdateFailed,
 			fmt.Sprintf("fail to update Context for updating: %s", err))
 		return updating, recordedRequeueAfter, err
 	} else {
-		AddOrUpdateCondition(syncContext.NewStatus,
-			api.XSetUpdate, nil, "Updated", "")
+		conditions.SetFromError(syncContext.NewStatus,
+			api.XSetUpdate, nil, conditions.ReasonUpdated, "")
 	}
 
 	// 6. update Target
+	evictionBlockedCount := atomic.Int32{}
 	succCount, err := controllerutils.SlowStartBatch(len(targetCh), controllerutils.SlowStartInitialBatchSize, false, func(_ int, _ error) error {
 		targetInfo := <-targetCh
 		logger.Info("before target update operation",
 			"target", ObjectKeyString(targetInfo.Object),
 			"revision.from", targetInfo.CurrentRevision.GetName(),
-			"revision.to", syncContext.UpdatedRevision.GetName(),
+			"revision.to", targetInfo.UpdateRevision.GetName(),
 			"inPlaceUpdate", targetInfo.InPlaceUpdateSupport,
 			"onlyMetadataChanged", targetInfo.OnlyMetadataChanged,
 		)
 
-		spec := r.xsetController.GetXSetSpec(xsetObject)
+		if syncContext.OutOfMaintenanceWindow && isRecreateUpdate(spec, targetInfo) {
+			// targets during updating before maintenance window ends are not recreated either
+			return nil
+		}
 		if targetInfo.IsInReplace && spec.UpdateStrategy.UpdatePolicy != api.XSetReplaceTargetUpdateStrategyType {
 			// a replacing target should be replaced by an updated revision target when encountering upgrade
 			if err := updateReplaceOriginTarget(ctx, r.Client, r.Recorder, r.xsetLabelAnnoMgr, targetInfo, targetInfo.ReplacePairNewTargetInfo); err != nil {
@@ -833,18 +1218,29 @@ func (r *RealSyncControl) Update(ctx context.Context, xsetObject api.XSetObject,
 			}
 		} else {
 			if err := updater.UpgradeTarget(ctx, targetInfo); err != nil {
+				// eviction blocked by PodDisruptionBudget is retried in following reconciles
+				if errors.Is(err, errEvictionBlocked) {
+					evictionBlockedCount.Add(1)
+					return nil
+				}
 				return err
 			}
 		}
 		return nil
 	})
+	if blocked := int(evictionBlockedCount.Load()); blocked > 0 {
+		logger.Info("eviction of Targets is blocked by PodDisruptionBudget", "count", blocked)
+		succCount -= blocked
+		syncContext.Decisions.RecordRequeue("EvictionBlocked", ptr.To(EvictionBlockedRequeueInterval))
+		recordedRequeueAfter = xcontrol.GetShorterDuration(recordedRequeueAfter, ptr.To(EvictionBlockedRequeueInterval))
+	}
 
 	updating = updating || succCount > 0
 	if err != nil {
-		AddOrUpdateCondition(syncContext.NewStatus, api.XSetUpdate, err, "UpdateFailed", err.Error())
+		conditions.SetFromError(syncContext.NewStatus, api.XSetUpdate, err, conditions.ReasonUpdateFailed, err.Error())
 		return updating, recordedRequeueAfter, err
 	} else {
-		AddOrUpdateCondition(syncContext.NewStatus, api.XSetUpdate, nil, "Updated", "")
+		conditions.SetFromError(syncContext.NewStatus, api.XSetUpdate, nil, conditions.ReasonUpdated, "")
 	}
 
 	targetToUpdateSet := sets.String{}
@@ -902,21 +1298,29 @@ func (r *RealSyncControl) Update(ctx context.Context, xsetObject api.XSetObject,
 	return updating || succCount > 0, recordedRequeueAfter, err
 }
 
-func (r *RealSyncControl) CalculateStatus(_ context.Context, instance api.XSetObject, syncContext *SyncContext) *api.XSetStatus {
+func (r *RealSyncControl) CalculateStatus(ctx context.Context, instance api.XSetObject, syncContext *SyncContext) *api.XSetStatus {
 	newStatus := syncContext.NewStatus
 	newStatus.ObservedGeneration = instance.GetGeneration()
+	spec := r.xsetController.GetXSetSpec(instance)
+	stuckPolicy := getStuckTerminatingPolicy(r.xsetController, instance)
 
-	var readyReplicas, scheduledReplicas, replicas, terminatingReplicas, updatedReplicas, operatingReplicas, updatedReadyReplicas, availableReplicas, updatedAvailableReplicas int32
+	var readyReplicas, scheduledReplicas, replicas, terminatingReplicas, updatedReplicas, operatingReplicas, updatedReadyReplicas, availableReplicas, updatedAvailableReplicas, quarantinedReplicas int32
 
 	for _, target := range syncContext.FilteredTarget {
-		// for naming with persistent sequences suffix, terminating targets can be shown in status
+		// for naming with persistent sequences suffix, terminating targets can be shown in status unless they are stuck
 		if target.GetDeletionTimestamp() != nil {
 			terminatingReplicas++
-			if !IsTargetNamingSuffixPolicyPersistentSequence(r.xsetController.GetXSetSpec(instance)) {
+			if !IsTargetNamingDeterministic(r.xsetController, instance) || isStuckTerminating(stuckPolicy, target) {
 				continue
 			}
 		}
 
+		// quarantined targets are kept for debugging out of replicas
+		if isTargetQuarantined(r.xsetLabelAnnoMgr, target) {
+			quarantinedReplicas++
+			continue
+		}
+
 		replicas++
 
 		isUpdated := false
@@ -936,7 +1340,9 @@ func (r *RealSyncControl) CalculateStatus(_ context.Context, instance api.XSetOb
 			}
 		}
 
-		if r.xsetController.CheckAvailable(target) {
+		available, recheckAfter := checkTargetAvailableForStatus(r.xsetController, spec, target)
+		syncContext.RecheckAvailableAfter = xcontrol.GetShorterDuration(syncContext.RecheckAvailableAfter, recheckAfter)
+		if available {
 			availableReplicas++
 			if isUpdated {
 				updatedAvailableReplicas++
@@ -952,21 +1358,169 @@ func (r *RealSyncControl) CalculateStatus(_ context.Context, instance api.XSetOb
 	newStatus.Replicas = replicas
 	newStatus.UpdatedReplicas = updatedReplicas
 	newStatus.OperatingReplicas = operatingReplicas
-	newStatus.TerminatingReplicas = terminatingReplicas
+	newStatus.TerminatingReplicas = countTerminatingTargets(ctx, syncContext, terminatingReplicas)
 	newStatus.UpdatedReadyReplicas = updatedReadyReplicas
 	newStatus.ScheduledReplicas = scheduledReplicas
 	newStatus.AvailableReplicas = availableReplicas
 	newStatus.UpdatedAvailableReplicas = updatedAvailableReplicas
+	newStatus.QuarantinedReplicas = quarantinedReplicas
+	newStatus.PostCreatePendingReplicas = syncContext.postCreatePending
+
+	// pvcs are not listed if sync is skipped, and their status is kept
+	if _, enabled := subresources.GetSubresourcePvcAdapter(r.xsetController); enabled && !syncContext.SyncSkipped {
+		calculatePvcStatus(newStatus, syncContext.ExistingPvcs)
+	}
 
-	spec := r.xsetController.GetXSetSpec(instance)
 	if (spec.Replicas == nil && newStatus.UpdatedReadyReplicas >= 0) ||
 		newStatus.UpdatedReadyReplicas >= *spec.Replicas {
 		newStatus.CurrentRevision = syncContext.UpdatedRevision.Name
 	}
 
+	r.calculateRevisionReplicas(spec, syncContext, newStatus)
+	pruneCreationFailures(newStatus, syncContext)
+	pruneRemediations(newStatus, syncContext)
+	calculateWorkloadConditions(spec, instance.GetGeneration(), syncContext, newStatus)
+	calculateMaintenanceWindowCondition(instance.GetGeneration(), syncContext, newStatus)
+	r.calculateStuckTerminatingCondition(instance, syncContext, newStatus)
+	r.calculateSelectorStatus(spec, instance.GetGeneration(), syncContext, newStatus)
+	r.calculateInstanceStatuses(instance, syncContext, newStatus)
+
 	return newStatus
 }
 
+// countTerminatingTargets counts all owned targets with deletion timestamp, including inactive ones which are
+// filtered out of syncContext, so that replicas being drained during scaling in are still visible in status
+func countTerminatingTargets(ctx context.Context, syncContext *SyncContext, filteredCount int32) int32 {
+	if syncContext.TargetSnapshot == nil {
+		return filteredCount
+	}
+	_, allTargets, err := syncContext.TargetSnapshot.GetFilteredTargets(ctx)
+	if err != nil {
+		return filteredCount
+	}
+	var count int32
+	for _, target := range allTargets {
+		if target.GetDeletionTimestamp() != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// DefaultMaxInstanceStatuses is the default max size of status.instanceStatuses
+const DefaultMaxInstanceStatuses = 500
+
+// calculateInstanceStatuses reports the observed state of targets ordered by instance ID if InstanceStatusesAdapter
+// is enabled, and clears them otherwise
+func (r *RealSyncControl) calculateInstanceStatuses(instance api.XSetObject, syncContext *SyncContext, newStatus *api.XSetStatus) {
+	adapter, ok := r.xsetController.(api.InstanceStatusesAdapter)
+	if !ok || !adapter.EnableInstanceStatuses(instance) {
+		newStatus.InstanceStatuses = nil
+		newStatus.InstanceStatusesTruncated = false
+		return
+	}
+	maxSize := adapter.GetMaxInstanceStatuses(instance)
+	if maxSize <= 0 {
+		maxSize = DefaultMaxInstanceStatuses
+	}
+
+	instanceStatuses := make([]api.InstanceStatus, 0, len(syncContext.TargetWrappers))
+	for _, targetWrapper := range syncContext.TargetWrappers {
+		if targetWrapper.PlaceHolder || targetWrapper.Object == nil {
+			continue
+		}
+		target := targetWrapper.Object
+		instanceStatus := api.InstanceStatus{
+			ID:         targetWrapper.ID,
+			TargetName: target.GetName(),
+			Revision:   target.GetLabels()[appsv1.ControllerRevisionHashLabelKey],
+			Phase:      api.InstancePhaseRunning,
+		}
+		instanceStatus.Ready, _ = r.xsetController.CheckReadyTime(target)
+		if pod, ok := target.(*corev1.Pod); ok {
+			instanceStatus.NodeName = pod.Spec.NodeName
+		}
+
+		switch {
+		case target.GetDeletionTimestamp() != nil:
+			instanceStatus.Phase = api.InstancePhaseTerminating
+		case isTargetQuarantined(r.xsetLabelAnnoMgr, target):
+			instanceStatus.Phase = api.InstancePhaseQuarantined
+		case targetWrapper.IsDuringScaleInOps || targetWrapper.IsDuringUpdateOps:
+			instanceStatus.Phase = api.InstancePhaseOperating
+		case !r.xsetController.CheckScheduled(target):
+			instanceStatus.Phase = api.InstancePhasePending
+		}
+		instanceStatuses = append(instanceStatuses, instanceStatus)
+	}
+
+	sort.Slice(instanceStatuses, func(i, j int) bool {
+		if instanceStatuses[i].ID != instanceStatuses[j].ID {
+			return instanceStatuses[i].ID < instanceStatuses[j].ID
+		}
+		return instanceStatuses[i].TargetName < instanceStatuses[j].TargetName
+	})
+	newStatus.InstanceStatusesTruncated = len(instanceStatuses) > maxSize
+	if newStatus.InstanceStatusesTruncated {
+		instanceStatuses = instanceStatuses[:maxSize]
+	}
+	newStatus.InstanceStatuses = instanceStatuses
+}
+
+// calculatePvcStatus counts pvcs by phase and sets VolumesReady condition
+func calculatePvcStatus(newStatus *api.XSetStatus, pvcs []*corev1.PersistentVolumeClaim) {
+	var boundPvcCount, pendingPvcCount, lostPvcCount int32
+	var pendingPvcs, lostPvcs []string
+	for _, pvc := range pvcs {
+		switch pvc.Status.Phase {
+		case corev1.ClaimBound:
+			boundPvcCount++
+		case corev1.ClaimLost:
+			lostPvcCount++
+			lostPvcs = append(lostPvcs, pvc.Name)
+		default:
+			pendingPvcCount++
+			pendingPvcs = append(pendingPvcs, pvc.Name)
+		}
+	}
+	newStatus.BoundPvcCount = boundPvcCount
+	newStatus.PendingPvcCount = pendingPvcCount
+	newStatus.LostPvcCount = lostPvcCount
+
+	switch {
+	case lostPvcCount > 0:
+		err := fmt.Errorf("pvcs %v are lost", lostPvcs)
+		conditions.SetFromError(newStatus, api.XSetVolumesReady, err, conditions.ReasonPvcLost, err.Error())
+	case pendingPvcCount > 0:
+		err := fmt.Errorf("pvcs %v are pending", pendingPvcs)
+		conditions.SetFromError(newStatus, api.XSetVolumesReady, err, conditions.ReasonPvcPending, err.Error())
+	default:
+		conditions.SetFromError(newStatus, api.XSetVolumesReady, nil, conditions.ReasonVolumesReady, "")
+	}
+}
+
+// syncPvcDeletionBlocked reports pvcs whose deletion is blocked by third-party finalizers in condition, and rechecks
+// them with backoff
+func (r *RealSyncControl) syncPvcDeletionBlocked(ctx context.Context, instance api.XSetObject, syncContext *SyncContext) error {
+	blockedPvcs, err := r.pvcControl.GetDeletionBlockedPvcs(ctx, instance)
+	if err != nil {
+		return fmt.Errorf("fail to get deletion blocked PVCs: %w", err)
+	}
+	if len(blockedPvcs) == 0 {
+		conditions.Remove(syncContext.NewStatus, api.XSetPvcDeletionBlocked)
+		return nil
+	}
+
+	blocked := make([]string, 0, len(blockedPvcs))
+	for _, pvc := range blockedPvcs {
+		blocked = append(blocked, fmt.Sprintf("%s%v", pvc.Name, subresources.DeletionProtectionFinalizers(pvc)))
+		syncContext.RecheckPvcDeletionAfter = xcontrol.GetShorterDuration(syncContext.RecheckPvcDeletionAfter, ptr.To(subresources.PvcDeletionRecheckBackoff(pvc)))
+	}
+	conditions.SetFromError(syncContext.NewStatus, api.XSetPvcDeletionBlocked, nil, conditions.ReasonFinalizersPresent,
+		fmt.Sprintf("deletion of pvcs is blocked by finalizers: %s", strings.Join(blocked, ", ")))
+	return nil
+}
+
 // getAvailableTargetIDs try to extract and re-allocate want available IDs.
 func (r *RealSyncControl) getAvailableTargetIDs(
 	ctx context.Context,
@@ -977,7 +1531,7 @@ func (r *RealSyncControl) getAvailableTargetIDs(
 	ownedIDs := syncContext.OwnedIds
 	currentIDs := syncContext.CurrentIDs
 
-	availableContexts := r.resourceContextControl.ExtractAvailableContexts(want, ownedIDs, currentIDs)
+	availableContexts := r.extractAvailableContexts(instance, want, ownedIDs, currentIDs)
 	if len(availableContexts) >= want {
 		return availableContexts, ownedIDs, nil
 	}
@@ -986,14 +1540,33 @@ func (r *RealSyncControl) getAvailableTargetIDs(
 
 	var newOwnedIDs map[int]*api.ContextDetail
 	var err error
-	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		newOwnedIDs, err = r.resourceContextControl.AllocateID(ctx, instance, syncContext.CurrentRevision.GetName(), syncContext.UpdatedRevision.GetName(), len(ownedIDs)+diff, nil)
 		return err
-	}); err != nil {
+	})
+	if err = r.checkPoolExhausted(instance, syncContext, err); err != nil {
 		return nil, ownedIDs, fmt.Errorf("fail to allocate IDs using context when include Targets: %w", err)
 	}
 
-	return r.resourceContextControl.ExtractAvailableContexts(want, newOwnedIDs, currentIDs), newOwnedIDs, nil
+	return r.extractAvailableContexts(instance, want, newOwnedIDs, currentIDs), newOwnedIDs, nil
+}
+
+// checkPoolExhausted records PoolExhausted condition and event if the context pool reaches its capacity,
+// in which case targets are still synced with the partially allocated IDs, so the error is swallowed.
+func (r *RealSyncControl) checkPoolExhausted(instance api.XSetObject, syncContext *SyncContext, allocateErr error) error {
+	if !errors.Is(allocateErr, resourcecontexts.ErrPoolExhausted) {
+		if allocateErr == nil {
+			conditions.Remove(syncContext.NewStatus, api.XSetPoolExhausted)
+		}
+		return allocateErr
+	}
+
+	// record event only when pool becomes exhausted, rather than on every sync while it stays exhausted
+	if !conditions.IsTrue(syncContext.NewStatus, api.XSetPoolExhausted) {
+		r.Recorder.Event(instance, corev1.EventTypeWarning, "PoolExhausted", allocateErr.Error())
+	}
+	conditions.SetFromError(syncContext.NewStatus, api.XSetPoolExhausted, nil, conditions.ReasonPoolExhausted, allocateErr.Error())
+	return nil
 }
 
 // reclaimOwnedIDs delete and reclaim unused IDs
@@ -1018,6 +1591,10 @@ func (r *RealSyncControl) reclaimOwnedIDs(
 	}
 
 	for _, id := range idToReclaim.List() {
+		// hold ID until PostDelete hook of its deleted target succeeded
+		if r.isPostDeletePending(ownedIDs[id]) {
+			continue
+		}
 		needUpdateContext = true
 		delete(ownedIDs, id)
 	}
@@ -1050,6 +1627,18 @@ func FilterOutActiveTargetWrappers(targets []*TargetWrapper) []*TargetWrapper {
 	return filteredTargetWrappers
 }
 
+// FilterOutTerminatingTargetWrappers filters out targets with deletion timestamp
+func FilterOutTerminatingTargetWrappers(targets []*TargetWrapper) []*TargetWrapper {
+	var filteredTargetWrappers []*TargetWrapper
+	for i, target := range targets {
+		if target.GetDeletionTimestamp() != nil {
+			continue
+		}
+		filteredTargetWrappers = append(filteredTargetWrappers, targets[i])
+	}
+	return filteredTargetWrappers
+}
+
 func targetDuringReplace(labelMgr api.XSetLabelAnnotationManager, target client.Object) bool {
 	_, replaceIndicate := labelMgr.Get(target, api.XReplaceIndicationLabelKey)
 	_, replaceOriginTarget := labelMgr.Get(target, api.XReplacePairOriginName)
@@ -1057,12 +1646,75 @@ func targetDuringReplace(labelMgr api.XSetLabelAnnotationManager, target client.
 	return replaceIndicate || replaceOriginTarget || replaceNewTarget
 }
 
+// scaleInGracePeriodSeconds returns grace period overriding the one of targets deleted by scaling in or replace
+func (r *RealSyncControl) scaleInGracePeriodSeconds(xsetObject api.XSetObject, target client.Object) *int64 {
+	spec := r.xsetController.GetXSetSpec(xsetObject)
+	// origin target of replace pair is labeled with new target ID
+	if _, replaceOrigin := r.xsetLabelAnnoMgr.Get(target, api.XReplacePairNewId); replaceOrigin {
+		return spec.UpdateStrategy.ReplaceTerminationGracePeriodSeconds
+	}
+	return spec.ScaleStrategy.TerminationGracePeriodSeconds
+}
+
+// ReleaseProtectionFinalizers removes protection finalizer from terminating targets, so that their deletion can go on
+func (r *RealSyncControl) ReleaseProtectionFinalizers(ctx context.Context, instance api.XSetObject, targets []client.Object) error {
+	finalizer := GetTargetProtectionFinalizer(r.xsetController, instance)
+	if finalizer == "" {
+		return nil
+	}
+	_, err := BoundedParallelize(len(targets), getMaxConcurrentDeletions(r.xsetController), func(i int) error {
+		target := targets[i]
+		if target.GetDeletionTimestamp() == nil || !controllerutil.ContainsFinalizer(target, finalizer) {
+			return nil
+		}
+		if err := r.xControl.PatchTargetWithOptimisticLock(ctx, target, func(target client.Object) {
+			controllerutil.RemoveFinalizer(target, finalizer)
+		}); err != nil {
+			return fmt.Errorf("failed to remove protection finalizer from target %s/%s: %w", target.GetNamespace(), target.GetName(), err)
+		}
+		return r.cacheExpectations.ExpectUpdation(clientutil.ObjectKeyString(instance), r.targetGVK, target.GetNamespace(), target.GetName(), target.GetResourceVersion())
+	})
+	return err
+}
+
+// ProtectionFinalizerReleaseTimeout is how long a terminating target keeps its protection finalizer while its ID fails
+// to be reclaimed
+const ProtectionFinalizerReleaseTimeout = 10 * time.Minute
+
+// filterProtectionReleaseTimedOut returns targets terminating for longer than ProtectionFinalizerReleaseTimeout, whose
+// protection finalizers are released even if their IDs are not reclaimed yet
+func filterProtectionReleaseTimedOut(targets []client.Object) []client.Object {
+	var timedOut []client.Object
+	for _, target := range targets {
+		if deletionTimestamp := target.GetDeletionTimestamp(); deletionTimestamp != nil && time.Since(deletionTimestamp.Time) >= ProtectionFinalizerReleaseTimeout {
+			timedOut = append(timedOut, target)
+		}
+	}
+	return timedOut
+}
+
+func (r *RealSyncControl) isProtectedByFinalizer(instance api.XSetObject, target client.Object) bool {
+	finalizer := GetTargetProtectionFinalizer(r.xsetController, instance)
+	return finalizer != "" && controllerutil.ContainsFinalizer(target, finalizer)
+}
+
+// isDeletedByXSet checks whether target deletion is triggered by XSet, e.g., scaling in or to-delete label
+func (r *RealSyncControl) isDeletedByXSet(target client.Object, contextDetail *api.ContextDetail) bool {
+	if _, exist := r.xsetLabelAnnoMgr.Get(target, api.XDeletionIndicationLabelKey); exist {
+		return true
+	}
+	return contextDetail != nil && r.resourceContextControl.Contains(contextDetail, api.EnumScaleInContextDataKey, "true")
+}
+
 // BatchDeleteTargetsByLabel try to trigger target deletion by to-delete label
 func (r *RealSyncControl) BatchDeleteTargetsByLabel(ctx context.Context, targetControl xcontrol.TargetControl, needDeleteTargets []client.Object) error {
-	_, err := controllerutils.SlowStartBatch(len(needDeleteTargets), controllerutils.SlowStartInitialBatchSize, false, func(i int, _ error) error {
+	_, err := BoundedParallelize(len(needDeleteTargets), getMaxConcurrentDeletions(r.xsetController), func(i int) error {
 		target := needDeleteTargets[i]
 		if _, exist := r.xsetLabelAnnoMgr.Get(target, api.XDeletionIndicationLabelKey); !exist {
-			patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf(`{"metadata":{"labels":{"%s":"%d"}}}`, r.xsetLabelAnnoMgr.Value(api.XDeletionIndicationLabelKey), time.Now().UnixNano()))) // nolint
+			patch, err := preconditionedLabelPatch(target, r.xsetLabelAnnoMgr.Value(api.XDeletionIndicationLabelKey), strconv.FormatInt(time.Now().UnixNano(), 10))
+			if err != nil {
+				return err
+			}
 			if err := targetControl.PatchTarget(ctx, target, patch); err != nil {
 				return fmt.Errorf("failed to delete target when syncTargets %s/%s/%w", target.GetNamespace(), target.GetName(), err)
 			}
@@ -0,0 +1,412 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+	"kusionstack.io/kube-xset/resourcecontexts"
+	"kusionstack.io/kube-xset/subresources"
+	"kusionstack.io/kube-xset/testing/fake"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+const testProtectionFinalizer = "xset.kusionstack.io/protection"
+
+type protectionController struct {
+	api.XSetController
+}
+
+func (c *protectionController) XMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+}
+
+func (c *protectionController) GetTargetProtectionFinalizer(api.XSetObject) string {
+	return testProtectionFinalizer
+}
+
+func TestReleaseProtectionFinalizersOnTimeout(t *testing.T) {
+	terminating := func(name string, since time.Duration) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              name,
+			DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-since)},
+			Finalizers:        []string{testProtectionFinalizer},
+		}}
+	}
+	xsetController := &protectionController{}
+	targetControl := fake.NewTargetControl(xsetController, terminating("foo-0", time.Minute), terminating("foo-1", ProtectionFinalizerReleaseTimeout+time.Minute))
+	r := &RealSyncControl{
+		xsetController:    xsetController,
+		xControl:          targetControl,
+		cacheExpectations: &noopExpectations{},
+	}
+
+	targets := targetControl.Targets()
+	timedOut := filterProtectionReleaseTimedOut(targets)
+	if len(timedOut) != 1 || timedOut[0].GetName() != "foo-1" {
+		t.Fatalf("filterProtectionReleaseTimedOut() = %v, want only foo-1", timedOut)
+	}
+	if err := r.ReleaseProtectionFinalizers(context.TODO(), &corev1.Pod{}, timedOut); err != nil {
+		t.Fatalf("ReleaseProtectionFinalizers() = %v", err)
+	}
+
+	for name, want := range map[string]int{"foo-0": 1, "foo-1": 0} {
+		target, _ := targetControl.GetTarget(types.NamespacedName{Namespace: "default", Name: name})
+		if got := len(target.GetFinalizers()); got != want {
+			t.Errorf("target %s has %d finalizers, want %d", name, got, want)
+		}
+	}
+}
+
+type gracePeriodController struct {
+	api.XSetController
+	spec *api.XSetSpec
+}
+
+func (c *gracePeriodController) GetXSetSpec(api.XSetObject) *api.XSetSpec { return c.spec }
+
+func TestScaleInGracePeriodSeconds(t *testing.T) {
+	labelAnnoMgr := api.NewXSetLabelAnnotationManager(nil)
+	r := &RealSyncControl{
+		xsetController: &gracePeriodController{spec: &api.XSetSpec{
+			ScaleStrategy:  api.ScaleStrategy{TerminationGracePeriodSeconds: ptr.To[int64](10)},
+			UpdateStrategy: api.UpdateStrategy{ReplaceTerminationGracePeriodSeconds: ptr.To[int64](20)},
+		}},
+		xsetLabelAnnoMgr: labelAnnoMgr,
+	}
+	scaledIn := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo-0", Labels: map[string]string{}}}
+	replaceOrigin := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo-1", Labels: map[string]string{}}}
+	labelAnnoMgr.Set(replaceOrigin, api.XReplacePairNewId, "2")
+
+	if got := r.scaleInGracePeriodSeconds(&corev1.Pod{}, scaledIn); ptr.Deref(got, 0) != 10 {
+		t.Errorf("scaleInGracePeriodSeconds() of scaled-in target = %v, want 10", got)
+	}
+	if got := r.scaleInGracePeriodSeconds(&corev1.Pod{}, replaceOrigin); ptr.Deref(got, 0) != 20 {
+		t.Errorf("scaleInGracePeriodSeconds() of replace origin target = %v, want 20", got)
+	}
+
+	deleteOptions := &client.DeleteOptions{}
+	deleteOptions.ApplyOptions(targetDeleteOptions(scaledIn, ptr.To[int64](10)))
+	if ptr.Deref(deleteOptions.GracePeriodSeconds, 0) != 10 {
+		t.Errorf("targetDeleteOptions() sets grace period %v, want 10", deleteOptions.GracePeriodSeconds)
+	}
+	deleteOptions = &client.DeleteOptions{}
+	deleteOptions.ApplyOptions(targetDeleteOptions(scaledIn, nil))
+	if deleteOptions.GracePeriodSeconds != nil {
+		t.Errorf("targetDeleteOptions() sets grace period %v, want the one of target", *deleteOptions.GracePeriodSeconds)
+	}
+}
+
+type terminatingTargetsController struct {
+	api.XSetController
+	exclude bool
+}
+
+func (c *terminatingTargetsController) ExcludeTerminatingTargets(api.XSetObject) bool {
+	return c.exclude
+}
+
+func TestExcludeTerminatingTargets(t *testing.T) {
+	labelAnnoMgr := api.NewXSetLabelAnnotationManager(nil)
+	wrapper := func(name string, id int, terminating bool) *TargetWrapper {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}}}
+		if terminating {
+			pod.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		}
+		return &TargetWrapper{Object: pod, ID: id}
+	}
+	targets := []*TargetWrapper{wrapper("foo-0", 0, false), wrapper("foo-1", 1, true), wrapper("foo-2", 2, false)}
+
+	if excludeTerminatingTargets(&terminatingTargetsController{}, &corev1.Pod{}) {
+		t.Error("excludeTerminatingTargets() = true, want false when adapter disables it")
+	}
+	if excludeTerminatingTargets(&protectionController{}, &corev1.Pod{}) {
+		t.Error("excludeTerminatingTargets() = true, want false without adapter")
+	}
+	if !excludeTerminatingTargets(&terminatingTargetsController{exclude: true}, &corev1.Pod{}) {
+		t.Error("excludeTerminatingTargets() = false, want true when adapter enables it")
+	}
+
+	// terminating targets neither count as replicas nor take part in replace pairs
+	active := FilterOutTerminatingTargetWrappers(targets)
+	if len(active) != 2 || active[0].GetName() != "foo-0" || active[1].GetName() != "foo-2" {
+		t.Fatalf("FilterOutTerminatingTargetWrappers() = %v, want foo-0 and foo-2", active)
+	}
+	if replacingMap := classifyTargetReplacingMapping(labelAnnoMgr, active); len(replacingMap) != 2 {
+		t.Errorf("got %d replicas counted, want 2", len(replacingMap))
+	}
+}
+
+func TestUpdatePvcExpansionCondition(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	r := &RealSyncControl{}
+	r.Recorder = recorder
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	status := &api.XSetStatus{}
+	notAllowed := fmt.Errorf("fail to expand pvc default/foo-0: %w", subresources.ErrPvcExpansionNotAllowed)
+
+	assertCondition := func(reason string) {
+		t.Helper()
+		cond := conditions.Get(status, api.XSetPvcExpansion)
+		if reason == "" {
+			if cond != nil {
+				t.Fatalf("PvcExpansion condition = %+v, want none", cond)
+			}
+			return
+		}
+		if cond == nil || cond.Reason != reason {
+			t.Fatalf("PvcExpansion condition = %+v, want reason %s", cond, reason)
+		}
+	}
+
+	r.updatePvcExpansionCondition(xset, status, nil, notAllowed)
+	assertCondition(conditions.ReasonExpansionNotAllowed)
+	r.updatePvcExpansionCondition(xset, status, nil, errors.Join(errors.New("conflict"), notAllowed))
+	assertCondition(conditions.ReasonExpansionNotAllowed)
+	if got := len(recorder.Events); got != 1 {
+		t.Fatalf("got %d events while expansion stays not allowed, want 1", got)
+	}
+
+	r.updatePvcExpansionCondition(xset, status, []string{"foo-0"}, nil)
+	assertCondition(conditions.ReasonExpanding)
+	r.updatePvcExpansionCondition(xset, status, nil, errors.New("conflict"))
+	assertCondition(conditions.ReasonExpandFailed)
+	r.updatePvcExpansionCondition(xset, status, nil, nil)
+	assertCondition("")
+
+	r.updatePvcExpansionCondition(xset, status, nil, notAllowed)
+	assertCondition(conditions.ReasonExpansionNotAllowed)
+	if got := len(recorder.Events); got != 2 {
+		t.Fatalf("got %d events after expansion becomes not allowed again, want 2", got)
+	}
+}
+
+func TestCalculatePvcStatus(t *testing.T) {
+	newPvc := func(name string, phase corev1.PersistentVolumeClaimPhase) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: corev1.PersistentVolumeClaimStatus{Phase: phase}}
+	}
+	tests := []struct {
+		name       string
+		pvcs       []*corev1.PersistentVolumeClaim
+		wantCounts [3]int32
+		wantReason string
+		wantStatus metav1.ConditionStatus
+	}{
+		{name: "no pvcs", wantReason: conditions.ReasonVolumesReady, wantStatus: metav1.ConditionTrue},
+		{
+			name:       "all bound",
+			pvcs:       []*corev1.PersistentVolumeClaim{newPvc("a", corev1.ClaimBound), newPvc("b", corev1.ClaimBound)},
+			wantCounts: [3]int32{2, 0, 0},
+			wantReason: conditions.ReasonVolumesReady,
+			wantStatus: metav1.ConditionTrue,
+		},
+		{
+			name:       "pending",
+			pvcs:       []*corev1.PersistentVolumeClaim{newPvc("a", corev1.ClaimBound), newPvc("b", ""), newPvc("c", corev1.ClaimPending)},
+			wantCounts: [3]int32{1, 2, 0},
+			wantReason: conditions.ReasonPvcPending,
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name:       "lost prior to pending",
+			pvcs:       []*corev1.PersistentVolumeClaim{newPvc("a", corev1.ClaimLost), newPvc("b", corev1.ClaimPending)},
+			wantCounts: [3]int32{0, 1, 1},
+			wantReason: conditions.ReasonPvcLost,
+			wantStatus: metav1.ConditionFalse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &api.XSetStatus{}
+			calculatePvcStatus(status, tt.pvcs)
+			if got := [3]int32{status.BoundPvcCount, status.PendingPvcCount, status.LostPvcCount}; got != tt.wantCounts {
+				t.Errorf("bound, pending, lost pvc counts = %v, want %v", got, tt.wantCounts)
+			}
+			cond := conditions.Get(status, api.XSetVolumesReady)
+			if cond == nil || cond.Reason != tt.wantReason || cond.Status != tt.wantStatus {
+				t.Errorf("VolumesReady condition = %+v, want %s %s", cond, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
+
+// instanceStatusesController reports instance statuses of Pod targets, which are ready once running
+type instanceStatusesController struct {
+	api.XSetController
+	enabled bool
+	maxSize int
+}
+
+func (c *instanceStatusesController) EnableInstanceStatuses(api.XSetObject) bool { return c.enabled }
+
+func (c *instanceStatusesController) GetMaxInstanceStatuses(api.XSetObject) int { return c.maxSize }
+
+func (c *instanceStatusesController) CheckReadyTime(object client.Object) (bool, *metav1.Time) {
+	return object.(*corev1.Pod).Status.Phase == corev1.PodRunning, nil
+}
+
+func (c *instanceStatusesController) CheckScheduled(object client.Object) bool {
+	return object.(*corev1.Pod).Spec.NodeName != ""
+}
+
+func TestCalculateInstanceStatuses(t *testing.T) {
+	newWrapper := func(id int, nodeName string, phase corev1.PodPhase) *TargetWrapper {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("foo-%d", id), Labels: map[string]string{appsv1.ControllerRevisionHashLabelKey: "foo-1"}},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+		return &TargetWrapper{Object: pod, ID: id}
+	}
+	terminating := newWrapper(0, "node-a", corev1.PodRunning)
+	terminating.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	operating := newWrapper(4, "node-a", corev1.PodRunning)
+	operating.IsDuringUpdateOps = true
+	syncContext := &SyncContext{TargetWrappers: []*TargetWrapper{
+		operating,
+		newWrapper(2, "", corev1.PodPending),
+		{ID: 3, PlaceHolder: true},
+		newWrapper(1, "node-b", corev1.PodRunning),
+		terminating,
+	}}
+	xsetController := &instanceStatusesController{enabled: true}
+	r := &RealSyncControl{xsetController: xsetController, xsetLabelAnnoMgr: api.NewXSetLabelAnnotationManager(nil)}
+	newStatus := &api.XSetStatus{}
+
+	r.calculateInstanceStatuses(&corev1.Pod{}, syncContext, newStatus)
+	want := []api.InstanceStatus{
+		{ID: 0, TargetName: "foo-0", Revision: "foo-1", Phase: api.InstancePhaseTerminating, Ready: true, NodeName: "node-a"},
+		{ID: 1, TargetName: "foo-1", Revision: "foo-1", Phase: api.InstancePhaseRunning, Ready: true, NodeName: "node-b"},
+		{ID: 2, TargetName: "foo-2", Revision: "foo-1", Phase: api.InstancePhasePending},
+		{ID: 4, TargetName: "foo-4", Revision: "foo-1", Phase: api.InstancePhaseOperating, Ready: true, NodeName: "node-a"},
+	}
+	if !reflect.DeepEqual(newStatus.InstanceStatuses, want) || newStatus.InstanceStatusesTruncated {
+		t.Fatalf("got instance statuses %+v truncated %v, want %+v", newStatus.InstanceStatuses, newStatus.InstanceStatusesTruncated, want)
+	}
+
+	// instance statuses are capped by the max size
+	xsetController.maxSize = 2
+	r.calculateInstanceStatuses(&corev1.Pod{}, syncContext, newStatus)
+	if !reflect.DeepEqual(newStatus.InstanceStatuses, want[:2]) || !newStatus.InstanceStatusesTruncated {
+		t.Fatalf("got instance statuses %+v truncated %v, want the first 2 truncated", newStatus.InstanceStatuses, newStatus.InstanceStatusesTruncated)
+	}
+
+	// instance statuses are cleared once disabled
+	xsetController.enabled = false
+	r.calculateInstanceStatuses(&corev1.Pod{}, syncContext, newStatus)
+	if newStatus.InstanceStatuses != nil || newStatus.InstanceStatusesTruncated {
+		t.Errorf("got instance statuses %+v truncated %v, want cleared", newStatus.InstanceStatuses, newStatus.InstanceStatusesTruncated)
+	}
+}
+
+// inactiveTargetsController uses Pod as target, and treats Pods labelled inactive as inactive
+type inactiveTargetsController struct {
+	api.XSetController
+}
+
+func (c *inactiveTargetsController) XMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+}
+
+func (c *inactiveTargetsController) CheckInactive(object client.Object) bool {
+	return object.GetLabels()["inactive"] == "true"
+}
+
+func TestCountTerminatingTargets(t *testing.T) {
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", UID: "foo-uid"}}
+	newTarget := func(name string, terminating, inactive bool) *corev1.Pod {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            name,
+			Labels:          map[string]string{"inactive": fmt.Sprint(inactive)},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(xset, corev1.SchemeGroupVersion.WithKind("Pod"))},
+		}}
+		if terminating {
+			pod.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		}
+		return pod
+	}
+	targetControl := fake.NewTargetControl(&inactiveTargetsController{},
+		newTarget("foo-0", false, false),
+		newTarget("foo-1", true, false),
+		newTarget("foo-2", true, true),
+	)
+
+	// inactive targets filtered out of sync are counted as well
+	syncContext := &SyncContext{TargetSnapshot: xcontrol.NewTargetSnapshot(targetControl, &metav1.LabelSelector{}, xset)}
+	if got := countTerminatingTargets(context.TODO(), syncContext, 1); got != 2 {
+		t.Errorf("countTerminatingTargets() = %d, want 2", got)
+	}
+
+	// filtered count is kept if targets are not listed
+	if got := countTerminatingTargets(context.TODO(), &SyncContext{}, 1); got != 1 {
+		t.Errorf("countTerminatingTargets() = %d without snapshot, want 1", got)
+	}
+	targetControl.InjectError("GetFilteredTargets", errors.New("list failed"))
+	syncContext = &SyncContext{TargetSnapshot: xcontrol.NewTargetSnapshot(targetControl, &metav1.LabelSelector{}, xset)}
+	if got := countTerminatingTargets(context.TODO(), syncContext, 1); got != 1 {
+		t.Errorf("countTerminatingTargets() = %d when list fails, want 1", got)
+	}
+}
+
+func TestCheckPoolExhausted(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	r := &RealSyncControl{}
+	r.Recorder = recorder
+	syncContext := &SyncContext{NewStatus: &api.XSetStatus{}}
+	exhausted := fmt.Errorf("%w: 2 of 3 IDs allocated", resourcecontexts.ErrPoolExhausted)
+
+	// event is recorded once when pool becomes exhausted, and not again while it stays exhausted
+	for i := 0; i < 2; i++ {
+		if err := r.checkPoolExhausted(&corev1.Pod{}, syncContext, exhausted); err != nil {
+			t.Fatalf("checkPoolExhausted() = %v", err)
+		}
+		if !conditions.IsTrue(syncContext.NewStatus, api.XSetPoolExhausted) {
+			t.Errorf("checkPoolExhausted() does not set PoolExhausted condition")
+		}
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("checkPoolExhausted() records %d events, want 1", len(recorder.Events))
+	}
+
+	// event is recorded again once pool becomes exhausted after recovery
+	if err := r.checkPoolExhausted(&corev1.Pod{}, syncContext, nil); err != nil {
+		t.Fatalf("checkPoolExhausted() = %v", err)
+	}
+	if err := r.checkPoolExhausted(&corev1.Pod{}, syncContext, exhausted); err != nil {
+		t.Fatalf("checkPoolExhausted() = %v", err)
+	}
+	if len(recorder.Events) != 2 {
+		t.Errorf("checkPoolExhausted() records %d events, want 2", len(recorder.Events))
+	}
+}
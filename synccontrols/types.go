@@ -25,6 +25,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/xcontrol"
 )
 
 type SyncContext struct {
@@ -33,10 +34,14 @@ type SyncContext struct {
 	UpdatedRevision     *appsv1.ControllerRevision
 	ExistingSubResource []client.Object
 
+	// TargetSnapshot shares the listed targets within one reconcile
+	TargetSnapshot *xcontrol.TargetSnapshot
 	FilteredTarget []client.Object
 	TargetWrappers []*TargetWrapper
 	activeTargets  []*TargetWrapper
 	replacingMap   map[string]*TargetWrapper
+	// quarantinedTargets are split out of activeTargets, see XQuarantinedLabelKey
+	quarantinedTargets []*TargetWrapper
 
 	CurrentIDs sets.Int
 	OwnedIds   map[int]*api.ContextDetail
@@ -44,6 +49,69 @@ type SyncContext struct {
 	SubResources
 
 	NewStatus *api.XSetStatus
+	// RecheckAvailableAfter is the shortest duration after which targets are expected to become available
+	RecheckAvailableAfter *time.Duration
+	// RecheckPvcDeletionAfter is the duration after which pvcs blocked by finalizers are rechecked
+	RecheckPvcDeletionAfter *time.Duration
+	// RecheckPostCreateAfter is the shortest duration after which running PostCreate hooks are collected or failed
+	// ones are retried
+	RecheckPostCreateAfter *time.Duration
+	// postCreatePending is the number of targets whose PostCreate hook has not succeeded yet
+	postCreatePending int32
+	// RecheckPostDeleteAfter is the shortest duration after which running PostDelete hooks are collected or failed
+	// ones are retried
+	RecheckPostDeleteAfter *time.Duration
+	// RecheckStuckTerminatingAfter is the shortest duration after which terminating targets are regarded as stuck
+	RecheckStuckTerminatingAfter *time.Duration
+	// RecheckNodeFailureAfter is the shortest duration after which targets on failed nodes are to replace
+	RecheckNodeFailureAfter *time.Duration
+	// stuckTerminatingTargets are names of targets stuck in terminating, see StuckTerminatingAdapter
+	stuckTerminatingTargets []string
+
+	// RollingBack indicates updated revision is aborted by RolloutAnalysisAdapter, and UpdatedRevision is set to
+	// CurrentRevision to roll back targets regardless of partition
+	RollingBack bool
+
+	// OutOfMaintenanceWindow indicates that disruptive actions, i.e., scaling in, recreate updates and deleting
+	// targets replaced, are held since now is out of maintenance windows of XSet
+	OutOfMaintenanceWindow bool
+	// RecheckMaintenanceWindowAfter is the duration after which the next maintenance window starts, if any
+	// disruptive action is held
+	RecheckMaintenanceWindowAfter *time.Duration
+	maintenanceWindowErr          error
+	nextMaintenanceWindow         time.Time
+	heldByMaintenanceWindow       int
+
+	// SyncSkipped indicates nothing is to be synced, and only status is calculated within one reconcile
+	SyncSkipped bool
+
+	// Decisions records what is decided within one reconcile
+	Decisions SyncDecisions
+}
+
+// SyncDecisions records what a reconcile decides to do, which is logged as one line for troubleshooting
+type SyncDecisions struct {
+	// CreateIDs are instance IDs chosen to scale out
+	CreateIDs []int
+	// DeleteIDs are instance IDs chosen to scale in
+	DeleteIDs []int
+	// UpdateIDs are instance IDs chosen to update to updated revision
+	UpdateIDs []int
+	// RequeueReason is the reason of the shortest requeue
+	RequeueReason string
+	// RequeueAfter is the shortest requeue duration recorded
+	RequeueAfter *time.Duration
+}
+
+// RecordRequeue records requeue with reason if it is the shortest one so far
+func (d *SyncDecisions) RecordRequeue(reason string, after *time.Duration) {
+	if after == nil {
+		return
+	}
+	if d.RequeueAfter == nil || *after < *d.RequeueAfter {
+		d.RequeueAfter = after
+		d.RequeueReason = reason
+	}
 }
 
 type SubResources struct {
@@ -62,6 +130,10 @@ type TargetWrapper struct {
 
 	IsDuringScaleInOps bool
 	IsDuringUpdateOps  bool
+	// OnPreemptingNode indicates target is on a node going away soon, see PreemptionAwareAdapter
+	OnPreemptingNode bool
+	// StuckTerminating indicates target stays terminating beyond threshold, see StuckTerminatingAdapter
+	StuckTerminating bool
 
 	DecorationInfo
 
@@ -84,6 +156,8 @@ type TargetUpdateInfo struct {
 
 	InPlaceUpdateSupport bool
 	OnlyMetadataChanged  bool
+	// ResizeOnly indicates only cpu and memory of containers are changed, which are resized in place
+	ResizeOnly bool
 
 	// indicate if this target has up-to-date revision from its owner, like XSet
 	IsUpdatedRevision bool
@@ -91,6 +165,8 @@ type TargetUpdateInfo struct {
 	CurrentRevision *appsv1.ControllerRevision
 	// carry the desired update revision
 	UpdateRevision *appsv1.ControllerRevision
+	// indicates UpdateRevision is a revision pinned by ratios instead of the updated revision of XSet
+	PinnedRevision bool
 
 	SubResourcesChanged
 
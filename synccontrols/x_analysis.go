@@ -0,0 +1,115 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+)
+
+// IsRevisionAborted returns true if updated revision is rolled back by RolloutAnalysisAdapter, and template has not
+// changed to another revision since then
+func IsRevisionAborted(status *api.XSetStatus, currentRevision, updatedRevision *appsv1.ControllerRevision) bool {
+	return status.AbortedRevision != "" && status.AbortedRevision == updatedRevision.Name &&
+		currentRevision.Name != updatedRevision.Name
+}
+
+// gateUpdateByAnalysis asks RolloutAnalysisAdapter for the verdict on targets of updated revision before more targets
+// are updated, and returns candidates allowed to update. Rollout is not gated until targets of updated revision exist,
+// since there is nothing to analyze for the first step.
+func (r *RealSyncControl) gateUpdateByAnalysis(
+	ctx context.Context,
+	xsetObject api.XSetObject,
+	syncContext *SyncContext,
+	targetInfos, candidates []*TargetUpdateInfo,
+) ([]*TargetUpdateInfo, *time.Duration, error) {
+	adapter, ok := r.xsetController.(api.RolloutAnalysisAdapter)
+	if !ok {
+		conditions.Remove(syncContext.NewStatus, api.XSetRolloutAnalysis)
+		return candidates, nil, nil
+	}
+	if syncContext.RollingBack {
+		return candidates, nil, nil
+	}
+
+	var updatedTargets []client.Object
+	for _, targetInfo := range targetInfos {
+		if !targetInfo.PlaceHolder && targetInfo.IsUpdatedRevision && targetInfo.GetDeletionTimestamp() == nil {
+			updatedTargets = append(updatedTargets, targetInfo.Object)
+		}
+	}
+	if len(updatedTargets) == 0 || !hasPendingUpdate(candidates) {
+		return candidates, nil, nil
+	}
+
+	generation := xsetObject.GetGeneration()
+	result, err := adapter.Analyze(ctx, xsetObject, syncContext.UpdatedRevision, updatedTargets)
+	if err != nil {
+		conditions.Set(syncContext.NewStatus, api.XSetRolloutAnalysis, metav1.ConditionFalse, conditions.ReasonAnalysisFailed, err.Error(), generation)
+		return holdPendingUpdate(candidates), nil, fmt.Errorf("fail to analyze revision %s: %w", syncContext.UpdatedRevision.Name, err)
+	}
+
+	switch result.Verdict {
+	case api.AnalysisVerdictProceed:
+		conditions.Set(syncContext.NewStatus, api.XSetRolloutAnalysis, metav1.ConditionTrue, conditions.ReasonAnalysisProceeding, result.Message, generation)
+		return candidates, nil, nil
+	case api.AnalysisVerdictRollback:
+		syncContext.NewStatus.AbortedRevision = syncContext.UpdatedRevision.Name
+		conditions.Set(syncContext.NewStatus, api.XSetRolloutAnalysis, metav1.ConditionFalse, conditions.ReasonAnalysisRolledBack, result.Message, generation)
+		r.Recorder.Eventf(xsetObject, corev1.EventTypeWarning, "RolloutRolledBack", "revision %s is rolled back to %s by analysis: %s",
+			syncContext.UpdatedRevision.Name, syncContext.CurrentRevision.Name, result.Message)
+		// roll back in the next reconcile with updated revision aborted
+		return holdPendingUpdate(candidates), ptr.To(time.Duration(0)), nil
+	default:
+		conditions.Set(syncContext.NewStatus, api.XSetRolloutAnalysis, metav1.ConditionFalse, conditions.ReasonAnalysisPaused, result.Message, generation)
+		if result.RequeueAfter > 0 {
+			return holdPendingUpdate(candidates), ptr.To(result.RequeueAfter), nil
+		}
+		return holdPendingUpdate(candidates), nil, nil
+	}
+}
+
+// hasPendingUpdate returns true if any candidate is to start updating to updated revision
+func hasPendingUpdate(candidates []*TargetUpdateInfo) bool {
+	for _, candidate := range candidates {
+		if !candidate.IsUpdatedRevision && !candidate.IsDuringUpdateOps {
+			return true
+		}
+	}
+	return false
+}
+
+// holdPendingUpdate filters out candidates which are to start updating, and keeps those updated or during updating
+func holdPendingUpdate(candidates []*TargetUpdateInfo) []*TargetUpdateInfo {
+	var held []*TargetUpdateInfo
+	for _, candidate := range candidates {
+		if candidate.IsUpdatedRevision || candidate.IsDuringUpdateOps {
+			held = append(held, candidate)
+		}
+	}
+	return held
+}
@@ -0,0 +1,207 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+)
+
+type analysisController struct {
+	api.XSetController
+	result   *api.AnalysisResult
+	err      error
+	analyzed []string
+}
+
+func (c *analysisController) Analyze(_ context.Context, _ api.XSetObject, _ *appsv1.ControllerRevision, updatedTargets []client.Object) (*api.AnalysisResult, error) {
+	c.analyzed = nil
+	for _, target := range updatedTargets {
+		c.analyzed = append(c.analyzed, target.GetName())
+	}
+	return c.result, c.err
+}
+
+func TestIsRevisionAborted(t *testing.T) {
+	revision := func(name string) *appsv1.ControllerRevision {
+		return &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	tests := []struct {
+		name    string
+		aborted string
+		current string
+		updated string
+		want    bool
+	}{
+		{name: "nothing aborted", current: "rev-1", updated: "rev-2"},
+		{name: "updated revision aborted", aborted: "rev-2", current: "rev-1", updated: "rev-2", want: true},
+		{name: "template changed to another revision", aborted: "rev-2", current: "rev-1", updated: "rev-3"},
+		{name: "template reverted to current revision", aborted: "rev-1", current: "rev-1", updated: "rev-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &api.XSetStatus{AbortedRevision: tt.aborted}
+			if got := IsRevisionAborted(status, revision(tt.current), revision(tt.updated)); got != tt.want {
+				t.Errorf("IsRevisionAborted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGateUpdateByAnalysis(t *testing.T) {
+	info := func(name string, updated, updating bool) *TargetUpdateInfo {
+		return &TargetUpdateInfo{
+			TargetWrapper:     &TargetWrapper{Object: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}, IsDuringUpdateOps: updating},
+			IsUpdatedRevision: updated,
+		}
+	}
+	names := func(infos []*TargetUpdateInfo) []string {
+		var names []string
+		for _, info := range infos {
+			names = append(names, info.GetName())
+		}
+		return names
+	}
+
+	tests := []struct {
+		name            string
+		result          *api.AnalysisResult
+		err             error
+		noAdapter       bool
+		rollingBack     bool
+		noUpdated       bool
+		wantCandidates  []string
+		wantRequeue     *time.Duration
+		wantErr         bool
+		wantReason      string
+		wantAborted     bool
+		wantNotAnalyzed bool
+	}{
+		{
+			name:            "without adapter",
+			noAdapter:       true,
+			wantCandidates:  []string{"foo-0", "foo-1", "foo-2"},
+			wantNotAnalyzed: true,
+		},
+		{
+			name:            "no target of updated revision",
+			noUpdated:       true,
+			result:          &api.AnalysisResult{Verdict: api.AnalysisVerdictPause},
+			wantCandidates:  []string{"foo-0", "foo-1", "foo-2"},
+			wantNotAnalyzed: true,
+		},
+		{
+			name:           "proceed",
+			result:         &api.AnalysisResult{Verdict: api.AnalysisVerdictProceed, Message: "healthy"},
+			wantCandidates: []string{"foo-0", "foo-1", "foo-2"},
+			wantReason:     conditions.ReasonAnalysisProceeding,
+		},
+		{
+			name:           "pause",
+			result:         &api.AnalysisResult{Verdict: api.AnalysisVerdictPause, RequeueAfter: time.Minute},
+			wantCandidates: []string{"foo-0", "foo-1"},
+			wantRequeue:    ptr.To(time.Minute),
+			wantReason:     conditions.ReasonAnalysisPaused,
+		},
+		{
+			name:           "rollback",
+			result:         &api.AnalysisResult{Verdict: api.AnalysisVerdictRollback, Message: "error rate too high"},
+			wantCandidates: []string{"foo-0", "foo-1"},
+			wantRequeue:    ptr.To(time.Duration(0)),
+			wantReason:     conditions.ReasonAnalysisRolledBack,
+			wantAborted:    true,
+		},
+		{
+			name:           "analysis failed",
+			err:            errors.New("metrics unavailable"),
+			wantCandidates: []string{"foo-0", "foo-1"},
+			wantErr:        true,
+			wantReason:     conditions.ReasonAnalysisFailed,
+		},
+		{
+			name:            "rolling back",
+			rollingBack:     true,
+			result:          &api.AnalysisResult{Verdict: api.AnalysisVerdictRollback},
+			wantCandidates:  []string{"foo-0", "foo-1", "foo-2"},
+			wantNotAnalyzed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := &analysisController{result: tt.result, err: tt.err}
+			r := &RealSyncControl{xsetController: controller}
+			if tt.noAdapter {
+				r.xsetController = &fastPathController{}
+			}
+			recorder := record.NewFakeRecorder(10)
+			r.Recorder = recorder
+
+			// foo-0 is updated, foo-1 is during updating, and foo-2 is to start updating
+			infos := []*TargetUpdateInfo{info("foo-0", !tt.noUpdated, false), info("foo-1", false, true), info("foo-2", false, false)}
+			syncContext := &SyncContext{
+				CurrentRevision: &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: "rev-1"}},
+				UpdatedRevision: &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: "rev-2"}},
+				RollingBack:     tt.rollingBack,
+				NewStatus:       &api.XSetStatus{},
+			}
+			xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", Generation: 2}}
+
+			candidates, requeueAfter, err := r.gateUpdateByAnalysis(context.TODO(), xset, syncContext, infos, infos)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("gateUpdateByAnalysis() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := names(candidates); !slices.Equal(got, tt.wantCandidates) {
+				t.Errorf("candidates = %v, want %v", got, tt.wantCandidates)
+			}
+			if (requeueAfter == nil) != (tt.wantRequeue == nil) || (requeueAfter != nil && *requeueAfter != *tt.wantRequeue) {
+				t.Errorf("requeueAfter = %v, want %v", requeueAfter, tt.wantRequeue)
+			}
+			if tt.wantNotAnalyzed != (controller.analyzed == nil) {
+				t.Errorf("analyzed targets = %v, want analyzed %v", controller.analyzed, !tt.wantNotAnalyzed)
+			}
+
+			cond := conditions.Get(syncContext.NewStatus, api.XSetRolloutAnalysis)
+			if tt.wantReason == "" {
+				if cond != nil {
+					t.Errorf("RolloutAnalysis condition = %v, want none", cond)
+				}
+			} else if cond == nil || cond.Reason != tt.wantReason || cond.ObservedGeneration != 2 {
+				t.Errorf("RolloutAnalysis condition = %v, want reason %s", cond, tt.wantReason)
+			}
+
+			if aborted := syncContext.NewStatus.AbortedRevision == "rev-2"; aborted != tt.wantAborted {
+				t.Errorf("AbortedRevision = %q, want aborted %v", syncContext.NewStatus.AbortedRevision, tt.wantAborted)
+			}
+			if tt.wantAborted && len(recorder.Events) != 1 {
+				t.Errorf("recorded %d events, want RolloutRolledBack", len(recorder.Events))
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"fmt"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/api/validation"
+	"kusionstack.io/kube-xset/conditions"
+)
+
+// GetReplicasManager returns the autoscaler managing replicas of xset, marked by XSetReplicasManagedByAnnotationKey
+func GetReplicasManager(xset client.Object) (string, bool) {
+	manager, ok := xset.GetAnnotations()[api.XSetReplicasManagedByAnnotationKey]
+	return manager, ok && manager != ""
+}
+
+// GetReplicasBounds returns bounds of replicas in annotations of xset, nil for no bound
+func GetReplicasBounds(xset client.Object) (minReplicas, maxReplicas *int32, err error) {
+	if minReplicas, err = parseReplicasBound(xset, api.XSetMinReplicasAnnotationKey); err != nil {
+		return nil, nil, err
+	}
+	if maxReplicas, err = parseReplicasBound(xset, api.XSetMaxReplicasAnnotationKey); err != nil {
+		return nil, nil, err
+	}
+	if minReplicas != nil && maxReplicas != nil && *minReplicas > *maxReplicas {
+		return nil, nil, fmt.Errorf("min replicas %d is greater than max replicas %d", *minReplicas, *maxReplicas)
+	}
+	return minReplicas, maxReplicas, nil
+}
+
+func parseReplicasBound(xset client.Object, key string) (*int32, error) {
+	value, ok := xset.GetAnnotations()[key]
+	if !ok {
+		return nil, nil
+	}
+	bound, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || bound < 0 {
+		return nil, fmt.Errorf("invalid annotation %s=%q, must be a non-negative integer", key, value)
+	}
+	return ptr.To(int32(bound)), nil
+}
+
+// ClampReplicas clamps spec.replicas into bounds in annotations of xset if its replicas are managed by an autoscaler,
+// and returns the replicas before clamped if changed. spec is only changed in memory and never written back, so that
+// XSet controller fights with neither the autoscaler nor GitOps tools when both of them set replicas.
+func ClampReplicas(xset api.XSetObject, spec *api.XSetSpec) (*int32, error) {
+	if _, managed := GetReplicasManager(xset); !managed {
+		return nil, nil
+	}
+	minReplicas, maxReplicas, err := GetReplicasBounds(xset)
+	if err != nil {
+		return nil, err
+	}
+	replicas := ptr.Deref(spec.Replicas, 0)
+	clamped := replicas
+	if minReplicas != nil {
+		clamped = max(clamped, *minReplicas)
+	}
+	if maxReplicas != nil {
+		clamped = min(clamped, *maxReplicas)
+	}
+	if clamped == replicas {
+		return nil, nil
+	}
+	spec.Replicas = ptr.To(clamped)
+	return ptr.To(replicas), nil
+}
+
+// calculateSelectorStatus sets status.selector for scale subresource, and SelectorConsistent condition. Autoscalers
+// aggregate metrics of targets by status.selector, so that an empty selector counts every target in namespace, and
+// a selector not matching targets created from updated revision makes them orphaned and invisible to autoscalers.
+// A changed selector keeps status.selector it replaces, so that sync stays blocked by CheckSelector until reverted.
+func (r *RealSyncControl) calculateSelectorStatus(spec *api.XSetSpec, generation int64, syncContext *SyncContext, newStatus *api.XSetStatus) {
+	lastSelector := newStatus.Selector
+	newStatus.Selector = ""
+	if validation.IsSelectorEmpty(spec.Selector) {
+		conditions.Set(newStatus, api.XSetSelectorConsistent, metav1.ConditionFalse, conditions.ReasonSelectorEmpty,
+			"selector is empty and selects all targets in namespace", generation)
+		return
+	}
+	selector, err := metav1.LabelSelectorAsSelector(spec.Selector)
+	if err != nil {
+		conditions.Set(newStatus, api.XSetSelectorConsistent, metav1.ConditionFalse, conditions.ReasonSelectorInvalid, err.Error(), generation)
+		return
+	}
+	if lastSelector != "" && lastSelector != selector.String() {
+		newStatus.Selector = lastSelector
+		conditions.Set(newStatus, api.XSetSelectorConsistent, metav1.ConditionFalse, conditions.ReasonSelectorChanged,
+			fmt.Sprintf("selector is changed from %s to %s, which is immutable", lastSelector, selector.String()), generation)
+		return
+	}
+	newStatus.Selector = selector.String()
+
+	if syncContext.UpdatedRevision != nil {
+		target, err := r.xsetController.GetXObjectFromRevision(syncContext.UpdatedRevision)
+		if err == nil && !selector.Matches(labels.Set(target.GetLabels())) {
+			conditions.Set(newStatus, api.XSetSelectorConsistent, metav1.ConditionFalse, conditions.ReasonTemplateNotSelected,
+				fmt.Sprintf("selector %s does not select targets of revision %s", newStatus.Selector, syncContext.UpdatedRevision.Name), generation)
+			return
+		}
+	}
+	conditions.Set(newStatus, api.XSetSelectorConsistent, metav1.ConditionTrue, conditions.ReasonSelectorConsistent, "", generation)
+}
+
+// CheckSelector validates selector of xset defensively before sync, in case validation webhook of consumers is absent
+// or bypassed, and sync is expected to be skipped on error: targets created from a template not selected are orphaned
+// and created again endlessly, and targets selected before a selector change are no longer managed. An empty selector
+// is only reported by SelectorConsistent condition for compatibility, and xset being deleted is never blocked.
+func CheckSelector(setController api.XSetController, xset api.XSetObject, syncContext *SyncContext) error {
+	spec := setController.GetXSetSpec(xset)
+	if xset.GetDeletionTimestamp() != nil || validation.IsSelectorEmpty(spec.Selector) {
+		return nil
+	}
+	if selector, err := metav1.LabelSelectorAsSelector(spec.Selector); err == nil {
+		if lastSelector := setController.GetXSetStatus(xset).Selector; lastSelector != "" && lastSelector != selector.String() {
+			return fmt.Errorf("selector is changed from %s to %s, which is immutable", lastSelector, selector.String())
+		}
+	}
+
+	var templateLabels map[string]string
+	if syncContext.UpdatedRevision != nil {
+		target, err := setController.GetXObjectFromRevision(syncContext.UpdatedRevision)
+		if err != nil {
+			return fmt.Errorf("fail to get template of revision %s: %w", syncContext.UpdatedRevision.Name, err)
+		}
+		templateLabels = target.GetLabels()
+	}
+	return validation.ValidateXSetSelector(spec, templateLabels, field.NewPath("spec")).ToAggregate()
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+)
+
+func TestClampReplicas(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		replicas    *int32
+		want        int32
+		wantOrigin  *int32
+		wantErr     bool
+	}{
+		{
+			name:        "not managed by autoscaler",
+			annotations: map[string]string{api.XSetMinReplicasAnnotationKey: "3"},
+			replicas:    pointer.Int32(1),
+			want:        1,
+		},
+		{
+			name: "within bounds",
+			annotations: map[string]string{
+				api.XSetReplicasManagedByAnnotationKey: "hpa",
+				api.XSetMinReplicasAnnotationKey:       "2",
+				api.XSetMaxReplicasAnnotationKey:       "10",
+			},
+			replicas: pointer.Int32(5),
+			want:     5,
+		},
+		{
+			name: "reverted below min",
+			annotations: map[string]string{
+				api.XSetReplicasManagedByAnnotationKey: "hpa",
+				api.XSetMinReplicasAnnotationKey:       "2",
+			},
+			replicas:   nil,
+			want:       2,
+			wantOrigin: pointer.Int32(0),
+		},
+		{
+			name: "above max",
+			annotations: map[string]string{
+				api.XSetReplicasManagedByAnnotationKey: "keda",
+				api.XSetMaxReplicasAnnotationKey:       "10",
+			},
+			replicas:   pointer.Int32(20),
+			want:       10,
+			wantOrigin: pointer.Int32(20),
+		},
+		{
+			name: "min greater than max",
+			annotations: map[string]string{
+				api.XSetReplicasManagedByAnnotationKey: "hpa",
+				api.XSetMinReplicasAnnotationKey:       "5",
+				api.XSetMaxReplicasAnnotationKey:       "3",
+			},
+			replicas: pointer.Int32(1),
+			want:     1,
+			wantErr:  true,
+		},
+		{
+			name: "invalid bound",
+			annotations: map[string]string{
+				api.XSetReplicasManagedByAnnotationKey: "hpa",
+				api.XSetMinReplicasAnnotationKey:       "-1",
+			},
+			replicas: pointer.Int32(1),
+			want:     1,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xset := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}
+			spec := &api.XSetSpec{Replicas: tt.replicas}
+			origin, err := ClampReplicas(xset, spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got err %v, want err %v", err, tt.wantErr)
+			}
+			if got := pointer.Int32Deref(spec.Replicas, 0); got != tt.want {
+				t.Errorf("got replicas %d, want %d", got, tt.want)
+			}
+			if pointer.Int32Deref(origin, -1) != pointer.Int32Deref(tt.wantOrigin, -1) {
+				t.Errorf("got origin %v, want %v", origin, tt.wantOrigin)
+			}
+		})
+	}
+}
+
+type selectorController struct {
+	api.XSetController
+	spec           *api.XSetSpec
+	status         *api.XSetStatus
+	templateLabels map[string]string
+}
+
+func (c *selectorController) GetXSetSpec(api.XSetObject) *api.XSetSpec { return c.spec }
+
+func (c *selectorController) GetXSetStatus(api.XSetObject) *api.XSetStatus { return c.status }
+
+func (c *selectorController) GetXObjectFromRevision(*appsv1.ControllerRevision) (client.Object, error) {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: c.templateLabels}}, nil
+}
+
+func TestCheckSelector(t *testing.T) {
+	xsetController := &selectorController{
+		spec:           &api.XSetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}}},
+		status:         &api.XSetStatus{Selector: "app=foo"},
+		templateLabels: map[string]string{"app": "foo", "tier": "web"},
+	}
+	xset := &appsv1.Deployment{}
+	syncContext := &SyncContext{UpdatedRevision: &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: "foo-1"}}}
+	r := &RealSyncControl{xsetController: xsetController}
+	if err := CheckSelector(xsetController, xset, syncContext); err != nil {
+		t.Fatalf("CheckSelector() = %v", err)
+	}
+
+	// template not selected
+	xsetController.templateLabels = map[string]string{"app": "bar"}
+	if err := CheckSelector(xsetController, xset, syncContext); err == nil {
+		t.Errorf("expect error when template is not selected")
+	}
+	xsetController.templateLabels = map[string]string{"app": "foo", "tier": "web"}
+
+	// selector changed after targets selected, and status keeps the last selector until reverted
+	xsetController.spec.Selector.MatchLabels["tier"] = "web"
+	if err := CheckSelector(xsetController, xset, syncContext); err == nil {
+		t.Errorf("expect error when selector is changed")
+	}
+	newStatus := xsetController.status.DeepCopy()
+	r.calculateSelectorStatus(xsetController.spec, 2, syncContext, newStatus)
+	if cond := conditions.Get(newStatus, api.XSetSelectorConsistent); newStatus.Selector != "app=foo" || cond == nil || cond.Reason != conditions.ReasonSelectorChanged {
+		t.Errorf("got status selector %q and condition %v, want app=foo and %s", newStatus.Selector, cond, conditions.ReasonSelectorChanged)
+	}
+
+	// xset being deleted is never blocked
+	xset.DeletionTimestamp = &metav1.Time{}
+	if err := CheckSelector(xsetController, xset, syncContext); err != nil {
+		t.Errorf("CheckSelector() = %v for xset being deleted", err)
+	}
+}
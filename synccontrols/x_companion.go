@@ -0,0 +1,146 @@
+/*
+Copyright 2024-2025 The KusionStack Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package synccontrols
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clientutil "kusionstack.io/kube-utils/client"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+// syncCompanions keeps companion objects consistent with targets by instance ID:
+// (1) create missing companions for targets not terminating, (2) delete companions whose targets are all gone.
+func (r *RealSyncControl) syncCompanions(ctx context.Context, xsetObject api.XSetObject, targets []client.Object) error {
+	adapter, ok := r.xsetController.(api.CompanionAdapter)
+	if !ok {
+		return nil
+	}
+
+	var errs []error
+	for _, companionMeta := range adapter.CompanionMetas() {
+		companions, err := r.listCompanions(ctx, xsetObject, companionMeta)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		companionIDs := sets.Int{}
+		for _, companion := range companions {
+			id, err := xcontrol.GetInstanceID(r.xsetLabelAnnoMgr, companion)
+			if err != nil {
+				continue
+			}
+			companionIDs.Insert(id)
+			if companion.GetDeletionTimestamp() != nil {
+				continue
+			}
+			// companion is kept as long as any target holds its ID, including terminating and inactive ones
+			inUse, err := r.instanceIDInUse(ctx, xsetObject, id)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if inUse {
+				continue
+			}
+			if err := r.Client.Delete(ctx, companion); err != nil {
+				if !apierrors.IsNotFound(err) {
+					errs = append(errs, fmt.Errorf("fail to delete companion %s %s/%s: %w", companionMeta.Kind, companion.GetNamespace(), companion.GetName(), err))
+				}
+				continue
+			}
+			if err := r.cacheExpectations.ExpectDeletion(clientutil.ObjectKeyString(xsetObject), companionMeta.GroupVersionKind(), companion.GetNamespace(), companion.GetName()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		for _, target := range targets {
+			id, err := xcontrol.GetInstanceID(r.xsetLabelAnnoMgr, target)
+			if err != nil || companionIDs.Has(id) || target.GetDeletionTimestamp() != nil {
+				continue
+			}
+			if err := r.createCompanion(ctx, adapter, xsetObject, companionMeta, target, id); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *RealSyncControl) createCompanion(ctx context.Context, adapter api.CompanionAdapter, xsetObject api.XSetObject, companionMeta metav1.TypeMeta, target client.Object, id int) error {
+	companion, err := adapter.NewCompanionFrom(xsetObject, companionMeta, target, id)
+	if err != nil {
+		return fmt.Errorf("fail to new companion %s for target %s/%s: %w", companionMeta.Kind, target.GetNamespace(), target.GetName(), err)
+	}
+	if companion == nil {
+		return nil
+	}
+
+	companion.SetNamespace(xsetObject.GetNamespace())
+	if companion.GetName() == "" && companion.GetGenerateName() == "" {
+		companion.SetGenerateName(target.GetName() + "-")
+	}
+	ownerRef := metav1.NewControllerRef(xsetObject, r.xsetGVK)
+	companion.SetOwnerReferences(append(companion.GetOwnerReferences(), *ownerRef))
+	if companion.GetLabels() == nil {
+		companion.SetLabels(map[string]string{})
+	}
+	r.xsetLabelAnnoMgr.Set(companion, api.XInstanceIdLabelKey, fmt.Sprintf("%d", id))
+	controlByXSet(r.xsetLabelAnnoMgr, companion)
+
+	if err := r.Client.Create(ctx, companion); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return nil
+		}
+		return fmt.Errorf("fail to create companion %s for target %s/%s: %w", companionMeta.Kind, target.GetNamespace(), target.GetName(), err)
+	}
+	// wait for created companion observed in cache, otherwise a generated-name one would be created again
+	return r.cacheExpectations.ExpectCreation(clientutil.ObjectKeyString(xsetObject), companionMeta.GroupVersionKind(), companion.GetNamespace(), companion.GetName())
+}
+
+// listCompanions lists companion objects of kind meta controlled by xset
+func (r *RealSyncControl) listCompanions(ctx context.Context, xsetObject api.XSetObject, companionMeta metav1.TypeMeta) ([]client.Object, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetAPIVersion(companionMeta.APIVersion)
+	list.SetKind(companionMeta.Kind + "List")
+	if err := r.Client.List(ctx, list, client.InNamespace(xsetObject.GetNamespace()), client.HasLabels{
+		r.xsetLabelAnnoMgr.Value(api.XInstanceIdLabelKey),
+		r.xsetLabelAnnoMgr.Value(api.ControlledByXSetLabel),
+	}); err != nil {
+		return nil, fmt.Errorf("fail to list companion %s: %w", companionMeta.Kind, err)
+	}
+
+	var companions []client.Object
+	for i := range list.Items {
+		ownerRef := metav1.GetControllerOf(&list.Items[i])
+		if ownerRef == nil || ownerRef.UID != xsetObject.GetUID() {
+			continue
+		}
+		companions = append(companions, &list.Items[i])
+	}
+	return companions, nil
+}
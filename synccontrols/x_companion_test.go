@@ -0,0 +1,119 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+var configMapMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}
+
+type companionController struct {
+	api.XSetController
+}
+
+func (c *companionController) CompanionMetas() []metav1.TypeMeta {
+	return []metav1.TypeMeta{configMapMeta}
+}
+
+func (c *companionController) NewCompanionFrom(_ api.XSetObject, _ metav1.TypeMeta, target client.Object, _ int) (client.Object, error) {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: target.GetName()}}, nil
+}
+
+func TestSyncCompanions(t *testing.T) {
+	labelAnnoMgr := api.NewXSetLabelAnnotationManager(nil)
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", UID: "foo-uid"}}
+	xsetGVK := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	target := func(name, id string, terminating bool) client.Object {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: map[string]string{}}}
+		labelAnnoMgr.Set(pod, api.XInstanceIdLabelKey, id)
+		if terminating {
+			pod.DeletionTimestamp = &metav1.Time{}
+		}
+		return pod
+	}
+	companion := func(name, id string) *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            name,
+			Labels:          map[string]string{},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(xset, xsetGVK)},
+		}}
+		labelAnnoMgr.Set(cm, api.XInstanceIdLabelKey, id)
+		controlByXSet(labelAnnoMgr, cm)
+		return cm
+	}
+
+	c := clientfake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(companion("foo-8", "8"), companion("foo-9", "9")).Build()
+	targets := []client.Object{target("foo-0", "0", false), target("foo-1", "1", true)}
+	// inactive target is filtered out of targets, but its companion is kept as it still holds the ID in cache
+	inactive := target("foo-8", "8", false)
+	exp := &recordingExpectations{}
+	r := &RealSyncControl{
+		xsetController:    &companionController{},
+		xsetLabelAnnoMgr:  labelAnnoMgr,
+		xControl:          &patchTargetControl{xsetLabelAnnoMgr: labelAnnoMgr, targets: append([]client.Object{inactive}, targets...)},
+		cacheExpectations: exp,
+		xsetGVK:           xsetGVK,
+	}
+	r.Client = c
+
+	if err := r.syncCompanions(context.TODO(), xset, targets); err != nil {
+		t.Fatalf("syncCompanions() = %v", err)
+	}
+
+	// companion is created for running target only, and the one without target is deleted
+	companions := &corev1.ConfigMapList{}
+	if err := c.List(context.TODO(), companions); err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(companions.Items, func(i, j int) bool { return companions.Items[i].Name < companions.Items[j].Name })
+	if len(companions.Items) != 2 || companions.Items[0].Name != "foo-0" || companions.Items[1].Name != "foo-8" {
+		t.Fatalf("syncCompanions() results in companions %v, want foo-0 and foo-8", companions.Items)
+	}
+	if id, _ := labelAnnoMgr.Get(&companions.Items[0], api.XInstanceIdLabelKey); id != "0" {
+		t.Errorf("companion is labeled with instance ID %q, want 0", id)
+	}
+	if ownerRef := metav1.GetControllerOf(&companions.Items[0]); ownerRef == nil || ownerRef.UID != xset.UID {
+		t.Errorf("companion is not controlled by xset")
+	}
+	sort.Strings(exp.records)
+	if want := []string{"create ConfigMap default/foo-0", "delete ConfigMap default/foo-9"}; !reflect.DeepEqual(exp.records, want) {
+		t.Errorf("syncCompanions() expects %v, want %v", exp.records, want)
+	}
+
+	// companions in place are left as they are
+	exp.records = nil
+	if err := r.syncCompanions(context.TODO(), xset, targets); err != nil {
+		t.Fatalf("syncCompanions() = %v", err)
+	}
+	if len(exp.records) != 0 {
+		t.Errorf("syncCompanions() expects %v, want nothing", exp.records)
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+)
+
+// calculateWorkloadConditions sets Available, Progressing and ReplicaFailure conditions following the conventions
+// of Kubernetes workloads. They are calculated from counters in newStatus, so it must be called after replicas counted.
+func calculateWorkloadConditions(spec *api.XSetSpec, generation int64, syncContext *SyncContext, newStatus *api.XSetStatus) {
+	desired := int32(0)
+	if spec.Replicas != nil {
+		desired = *spec.Replicas
+	}
+
+	minAvailable := getMinAvailable(spec.MinAvailable, desired)
+	if newStatus.AvailableReplicas >= minAvailable {
+		conditions.Set(newStatus, api.XSetAvailable, metav1.ConditionTrue, conditions.ReasonMinimumReplicasAvailable,
+			fmt.Sprintf("%d of %d replicas available, requires %d", newStatus.AvailableReplicas, desired, minAvailable), generation)
+	} else {
+		conditions.Set(newStatus, api.XSetAvailable, metav1.ConditionFalse, conditions.ReasonMinimumReplicasUnavailable,
+			fmt.Sprintf("%d of %d replicas available, requires %d", newStatus.AvailableReplicas, desired, minAvailable), generation)
+	}
+
+	failureReason, failureMessage := getReplicaFailure(newStatus)
+	if failureReason != "" {
+		conditions.Set(newStatus, api.XSetReplicaFailure, metav1.ConditionTrue, failureReason, failureMessage, generation)
+	} else {
+		conditions.Remove(newStatus, api.XSetReplicaFailure)
+	}
+	// status is only calculated if XSet is not suspended
+	conditions.Remove(newStatus, api.XSetSuspended)
+
+	decisions := syncContext.Decisions
+	updateCond := conditions.Get(newStatus, api.XSetUpdate)
+	switch {
+	case spec.Paused:
+		conditions.Set(newStatus, api.XSetProgressing, metav1.ConditionUnknown, conditions.ReasonPaused, "XSet is paused", generation)
+	case newStatus.AutoPausedRevision != "":
+		conditions.Set(newStatus, api.XSetProgressing, metav1.ConditionUnknown, conditions.ReasonPaused,
+			fmt.Sprintf("rollout of revision %s is paused on warning events", newStatus.AutoPausedRevision), generation)
+	case failureReason != "":
+		conditions.Set(newStatus, api.XSetProgressing, metav1.ConditionFalse, conditions.ReasonRolloutStalled, failureMessage, generation)
+	case updateCond != nil && updateCond.Status == metav1.ConditionFalse && updateCond.Reason == conditions.ReasonUpdateFailed:
+		conditions.Set(newStatus, api.XSetProgressing, metav1.ConditionFalse, conditions.ReasonRolloutStalled, updateCond.Message, generation)
+	case len(decisions.CreateIDs) > 0 || len(decisions.DeleteIDs) > 0 || newStatus.Replicas != desired:
+		conditions.Set(newStatus, api.XSetProgressing, metav1.ConditionTrue, conditions.ReasonReplicasScaling,
+			fmt.Sprintf("%d of %d replicas exist", newStatus.Replicas, desired), generation)
+	case len(decisions.UpdateIDs) == 0 && isRevisionRatiosSatisfied(newStatus):
+		conditions.Set(newStatus, api.XSetProgressing, metav1.ConditionTrue, conditions.ReasonRolloutComplete,
+			"revisions have successfully rolled out by ratios", generation)
+	case len(decisions.UpdateIDs) > 0 || newStatus.UpdatedAvailableReplicas < newStatus.Replicas:
+		conditions.Set(newStatus, api.XSetProgressing, metav1.ConditionTrue, conditions.ReasonReplicasUpdating,
+			fmt.Sprintf("%d of %d replicas updated and available for revision %s",
+				newStatus.UpdatedAvailableReplicas, newStatus.Replicas, newStatus.UpdatedRevision), generation)
+	default:
+		conditions.Set(newStatus, api.XSetProgressing, metav1.ConditionTrue, conditions.ReasonRolloutComplete,
+			fmt.Sprintf("revision %s has successfully rolled out", newStatus.UpdatedRevision), generation)
+	}
+}
+
+// getMinAvailable resolves minAvailable against desired replicas, which defaults to all desired replicas
+func getMinAvailable(minAvailable *intstr.IntOrString, desired int32) int32 {
+	if minAvailable == nil {
+		return desired
+	}
+	value, err := intstr.GetScaledValueFromIntOrPercent(minAvailable, int(desired), true)
+	if err != nil {
+		return desired
+	}
+	return int32(min(max(value, 0), int(desired)))
+}
+
+// getReplicaFailure summarizes failures of creating or deleting targets which are kept in FailedCreations, Scale and
+// PoolExhausted conditions until succeeded
+func getReplicaFailure(newStatus *api.XSetStatus) (reason, message string) {
+	if len(newStatus.FailedCreations) > 0 {
+		return conditions.ReasonFailedCreate, summarizeCreationFailures(newStatus.FailedCreations)
+	}
+	if cond := conditions.Get(newStatus, api.XSetScale); cond != nil && cond.Status == metav1.ConditionFalse {
+		switch cond.Reason {
+		case conditions.ReasonScaleOutFailed:
+			return conditions.ReasonFailedCreate, cond.Message
+		case conditions.ReasonScaleInFailed:
+			return conditions.ReasonFailedDelete, cond.Message
+		}
+	}
+	if cond := conditions.Get(newStatus, api.XSetPoolExhausted); cond != nil && cond.Status == metav1.ConditionTrue {
+		return conditions.ReasonFailedCreate, fmt.Sprintf("%s: %s", cond.Reason, cond.Message)
+	}
+	return "", ""
+}
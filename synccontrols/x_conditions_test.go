@@ -0,0 +1,44 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+)
+
+func TestCalculateWorkloadConditionsOnResume(t *testing.T) {
+	newStatus := &api.XSetStatus{Replicas: 3, AvailableReplicas: 3, UpdatedAvailableReplicas: 3, UpdatedRevision: "foo-1"}
+	conditions.Set(newStatus, api.XSetSuspended, metav1.ConditionTrue, conditions.ReasonSuspendedByAnnotation, "", 1)
+	conditions.Set(newStatus, api.XSetProgressing, metav1.ConditionUnknown, conditions.ReasonSuspended, "XSet is suspended", 1)
+
+	calculateWorkloadConditions(&api.XSetSpec{Replicas: ptr.To[int32](3)}, 2, &SyncContext{}, newStatus)
+	if cond := conditions.Get(newStatus, api.XSetSuspended); cond != nil {
+		t.Errorf("got condition %v, want Suspended removed once resumed", cond)
+	}
+	if _, matched := conditions.FindAndCompare(newStatus, api.XSetProgressing, metav1.ConditionTrue, conditions.ReasonRolloutComplete); !matched {
+		t.Errorf("got condition %v, want Progressing recalculated once resumed", conditions.Get(newStatus, api.XSetProgressing))
+	}
+	if !conditions.IsTrue(newStatus, api.XSetAvailable) || !conditions.IsObserved(newStatus, api.XSetAvailable, 2) {
+		t.Errorf("got condition %v, want Available observed for generation 2", conditions.Get(newStatus, api.XSetAvailable))
+	}
+}
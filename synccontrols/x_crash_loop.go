@@ -0,0 +1,258 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientutil "kusionstack.io/kube-utils/client"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+const (
+	// RemediationBackoffBase is the backoff after the first remediation of targets for an instance ID
+	RemediationBackoffBase = 30 * time.Second
+	// RemediationBackoffMax is the max backoff of remediating targets for an instance ID again
+	RemediationBackoffMax = 10 * time.Minute
+)
+
+func getCrashLoopRemediation(xsetController api.XSetController, xset api.XSetObject) (api.CrashLoopRemediationAdapter, *api.CrashLoopRemediationPolicy) {
+	adapter, ok := xsetController.(api.CrashLoopRemediationAdapter)
+	if !ok {
+		return nil, nil
+	}
+	policy := adapter.GetCrashLoopRemediationPolicy(xset)
+	if policy == nil {
+		return nil, nil
+	}
+	return adapter, policy
+}
+
+// GetPodFailures returns sum of restart counts of containers and init containers of Pod, and whether Pod is in Failed
+// phase, which can be used to implement GetTargetFailures of CrashLoopRemediationAdapter
+func GetPodFailures(target client.Object) (int32, bool) {
+	pod, ok := target.(*corev1.Pod)
+	if !ok {
+		return 0, false
+	}
+	var failures int32
+	for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for i := range statuses {
+			failures += statuses[i].RestartCount
+		}
+	}
+	return failures, pod.Status.Phase == corev1.PodFailed
+}
+
+// remediationBackoff returns the backoff before remediating again after count remediations
+func remediationBackoff(count int32) time.Duration {
+	backoff := RemediationBackoffBase
+	for i := int32(1); i < count && backoff < RemediationBackoffMax; i++ {
+		backoff *= 2
+	}
+	return min(backoff, RemediationBackoffMax)
+}
+
+// findRemediation returns the remediation recorded for instance ID
+func findRemediation(status *api.XSetStatus, id int) *api.TargetRemediation {
+	for i := range status.Remediations {
+		if status.Remediations[i].ID == id {
+			return &status.Remediations[i]
+		}
+	}
+	return nil
+}
+
+// checkRemediationBackoff returns true if targets of revision for instance ID are remediated recently. Backoff is not
+// applied once revision changes, e.g., a fix is rolled out.
+func checkRemediationBackoff(status *api.XSetStatus, id int, revision string) bool {
+	remediation := findRemediation(status, id)
+	if remediation == nil || remediation.Revision != revision {
+		return false
+	}
+	return time.Since(remediation.LastRemediationTime.Time) < remediationBackoff(remediation.Count)
+}
+
+// recordRemediation records a remediation of target of revision for instance ID
+func recordRemediation(status *api.XSetStatus, id int, revision string, remediation api.CrashLoopRemediation, message string) {
+	record := findRemediation(status, id)
+	if record == nil {
+		status.Remediations = append(status.Remediations, api.TargetRemediation{ID: id})
+		sort.Slice(status.Remediations, func(i, j int) bool {
+			return status.Remediations[i].ID < status.Remediations[j].ID
+		})
+		record = findRemediation(status, id)
+	} else if record.Revision != revision {
+		record.Count = 0
+	}
+	record.Revision = revision
+	record.Remediation = remediation
+	record.Message = message
+	record.Count++
+	record.LastRemediationTime = metav1.Now()
+}
+
+// pruneRemediations removes remediations of instance IDs which are released
+func pruneRemediations(status *api.XSetStatus, syncContext *SyncContext) {
+	if syncContext.OwnedIds == nil {
+		return
+	}
+	remediations := status.Remediations[:0]
+	for _, remediation := range status.Remediations {
+		if _, owned := syncContext.OwnedIds[remediation.ID]; !owned {
+			continue
+		}
+		remediations = append(remediations, remediation)
+	}
+	if len(remediations) == 0 {
+		remediations = nil
+	}
+	status.Remediations = remediations
+}
+
+// isCrashLoopRemediable checks whether target is left to remediate, which is not the case for targets being updated,
+// scaled in, replaced, deleted or already quarantined
+func (r *RealSyncControl) isCrashLoopRemediable(wrapper *TargetWrapper) bool {
+	if wrapper.Object == nil || wrapper.PlaceHolder || wrapper.GetDeletionTimestamp() != nil {
+		return false
+	}
+	if wrapper.IsDuringScaleInOps || wrapper.IsDuringUpdateOps || wrapper.ToDelete || wrapper.ToExclude {
+		return false
+	}
+	for _, key := range []api.XSetLabelAnnotationEnum{api.XReplaceIndicationLabelKey, api.XQuarantinedLabelKey} {
+		if _, exist := r.xsetLabelAnnoMgr.Get(wrapper.Object, key); exist {
+			return false
+		}
+	}
+	return true
+}
+
+// remediateCrashLoopTargets remediates targets which fail beyond threshold by policy of CrashLoopRemediationAdapter.
+// Each instance ID is remediated with backoff recorded in status, recreating targets is held out of maintenance
+// windows, and replacing or quarantining them is limited by MaxReplacing of policy.
+func (r *RealSyncControl) remediateCrashLoopTargets(ctx context.Context, xsetObject api.XSetObject, syncContext *SyncContext) error {
+	adapter, policy := getCrashLoopRemediation(r.xsetController, xsetObject)
+	if policy == nil {
+		return nil
+	}
+	threshold := max(policy.FailureThreshold, 1)
+	remediation := policy.Remediation
+	if remediation == "" {
+		remediation = api.CrashLoopRemediationRecreate
+	}
+
+	replaceBudget := max(policy.MaxReplacing, 1) - r.countStartingTargets(adapter, threshold, syncContext.TargetWrappers)
+	var deferred []client.Object
+	var errs []error
+	for _, wrapper := range syncContext.TargetWrappers {
+		if !r.isCrashLoopRemediable(wrapper) {
+			continue
+		}
+		failures, failed := adapter.GetTargetFailures(wrapper.Object)
+		if !failed && failures < threshold {
+			continue
+		}
+		revision := wrapper.GetLabels()[appsv1.ControllerRevisionHashLabelKey]
+		if checkRemediationBackoff(syncContext.NewStatus, wrapper.ID, revision) {
+			continue
+		}
+		message := fmt.Sprintf("target failed %d time(s)", failures)
+		if failed {
+			message = fmt.Sprintf("target is in failed phase after %d failure(s)", failures)
+		}
+
+		var err error
+		switch remediation {
+		case api.CrashLoopRemediationRecreate:
+			if syncContext.OutOfMaintenanceWindow {
+				syncContext.heldByMaintenanceWindow++
+				continue
+			}
+			err = r.recreateCrashLoopTarget(ctx, xsetObject, wrapper.Object)
+		case api.CrashLoopRemediationReplace, api.CrashLoopRemediationQuarantine:
+			if replaceBudget <= 0 {
+				deferred = append(deferred, wrapper.Object)
+				continue
+			}
+			replaceBudget--
+			if remediation == api.CrashLoopRemediationReplace {
+				err = r.labelCrashLoopTarget(ctx, xsetObject, wrapper.Object, api.XReplaceIndicationLabelKey, strconv.FormatInt(time.Now().UnixNano(), 10))
+			} else {
+				err = r.labelCrashLoopTarget(ctx, xsetObject, wrapper.Object, api.XQuarantinedLabelKey, strconv.FormatInt(time.Now().Unix(), 10))
+			}
+		default:
+			return fmt.Errorf("unknown crash loop remediation %q", remediation)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		recordRemediation(syncContext.NewStatus, wrapper.ID, revision, remediation, message)
+		r.Recorder.Eventf(xsetObject, corev1.EventTypeWarning, "CrashLoopRemediated", "target %s/%s is remediated by %s: %s",
+			wrapper.GetNamespace(), wrapper.GetName(), remediation, message)
+	}
+	for _, target := range r.deferredTargets.update("CrashLoop", xsetObject, deferred) {
+		r.Recorder.Eventf(target, corev1.EventTypeNormal, "CrashLoopRemediationDeferred",
+			"failing target is deferred to %s, since at most %d target(s) are starting at the same time", strings.ToLower(string(remediation)), max(policy.MaxReplacing, 1))
+	}
+	return errors.Join(errs...)
+}
+
+// countStartingTargets counts targets which are neither available nor failing beyond threshold, e.g., replacements of
+// targets remediated before, which are waited for before more targets are replaced or quarantined
+func (r *RealSyncControl) countStartingTargets(adapter api.CrashLoopRemediationAdapter, threshold int32, targets []*TargetWrapper) int {
+	starting := 0
+	for _, wrapper := range targets {
+		if wrapper.Object == nil || wrapper.PlaceHolder || wrapper.GetDeletionTimestamp() != nil ||
+			isTargetQuarantined(r.xsetLabelAnnoMgr, wrapper.Object) || IsTargetAvailable(r.xsetController, wrapper.Object) {
+			continue
+		}
+		if failures, failed := adapter.GetTargetFailures(wrapper.Object); !failed && failures < threshold {
+			starting++
+		}
+	}
+	return starting
+}
+
+// recreateCrashLoopTarget deletes target, and its ID is then used to create target from the same revision by scaling
+func (r *RealSyncControl) recreateCrashLoopTarget(ctx context.Context, xsetObject api.XSetObject, target client.Object) error {
+	if err := r.xControl.DeleteTarget(ctx, target, targetDeleteOptions(target, nil)...); err != nil {
+		return fmt.Errorf("fail to delete crash looping target %s: %w", target.GetName(), err)
+	}
+	return r.cacheExpectations.ExpectDeletion(clientutil.ObjectKeyString(xsetObject), r.targetGVK, target.GetNamespace(), target.GetName())
+}
+
+// labelCrashLoopTarget sets label on target to replace or quarantine it
+func (r *RealSyncControl) labelCrashLoopTarget(ctx context.Context, xsetObject api.XSetObject, target client.Object, key api.XSetLabelAnnotationEnum, value string) error {
+	if err := r.xControl.PatchTargetWithOptimisticLock(ctx, target, func(obj client.Object) {
+		r.xsetLabelAnnoMgr.Set(obj, key, value)
+	}); err != nil {
+		return fmt.Errorf("fail to remediate crash looping target %s: %w", target.GetName(), err)
+	}
+	return r.cacheExpectations.ExpectUpdation(clientutil.ObjectKeyString(xsetObject), r.targetGVK, target.GetNamespace(), target.GetName(), target.GetResourceVersion())
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/testing/fake"
+)
+
+func TestGetPodFailures(t *testing.T) {
+	pod := &corev1.Pod{Status: corev1.PodStatus{
+		Phase:                 corev1.PodRunning,
+		InitContainerStatuses: []corev1.ContainerStatus{{Name: "init", RestartCount: 1}},
+		ContainerStatuses:     []corev1.ContainerStatus{{Name: "app", RestartCount: 3}, {Name: "sidecar", RestartCount: 2}},
+	}}
+	if failures, failed := GetPodFailures(pod); failures != 6 || failed {
+		t.Errorf("GetPodFailures() = %d, %v, want 6, false", failures, failed)
+	}
+	pod.Status.Phase = corev1.PodFailed
+	if _, failed := GetPodFailures(pod); !failed {
+		t.Errorf("expect pod in Failed phase to be failed")
+	}
+	if failures, failed := GetPodFailures(&corev1.ConfigMap{}); failures != 0 || failed {
+		t.Errorf("expect no failure of non-pod target, got %d, %v", failures, failed)
+	}
+}
+
+func TestRemediationBackoff(t *testing.T) {
+	status := &api.XSetStatus{}
+	if checkRemediationBackoff(status, 1, "rev-1") {
+		t.Fatalf("expect no backoff before remediation")
+	}
+
+	recordRemediation(status, 2, "rev-1", api.CrashLoopRemediationRecreate, "target failed 3 time(s)")
+	recordRemediation(status, 1, "rev-1", api.CrashLoopRemediationRecreate, "target failed 3 time(s)")
+	recordRemediation(status, 1, "rev-1", api.CrashLoopRemediationRecreate, "target failed 5 time(s)")
+	if len(status.Remediations) != 2 || status.Remediations[0].ID != 1 || status.Remediations[0].Count != 2 {
+		t.Fatalf("got unexpected remediations %+v", status.Remediations)
+	}
+	if !checkRemediationBackoff(status, 1, "rev-1") {
+		t.Errorf("expect backoff after remediation")
+	}
+	// backoff is not applied to another revision, whose remediations are counted from scratch
+	if checkRemediationBackoff(status, 1, "rev-2") {
+		t.Errorf("expect no backoff in another revision")
+	}
+	recordRemediation(status, 1, "rev-2", api.CrashLoopRemediationQuarantine, "target is in failed phase after 0 failure(s)")
+	if record := findRemediation(status, 1); record.Count != 1 || record.Revision != "rev-2" || record.Remediation != api.CrashLoopRemediationQuarantine {
+		t.Errorf("got unexpected remediation %+v", record)
+	}
+
+	// backoff expires
+	status.Remediations[0].LastRemediationTime = metav1.NewTime(time.Now().Add(-RemediationBackoffBase))
+	if checkRemediationBackoff(status, 1, "rev-2") {
+		t.Errorf("expect backoff to expire")
+	}
+
+	// records of released IDs are pruned
+	pruneRemediations(status, &SyncContext{OwnedIds: map[int]*api.ContextDetail{2: {ID: 2}}})
+	if len(status.Remediations) != 1 || status.Remediations[0].ID != 2 {
+		t.Errorf("got unexpected remediations %+v", status.Remediations)
+	}
+}
+
+func TestRemediationBackoffDuration(t *testing.T) {
+	for count, want := range map[int32]time.Duration{1: RemediationBackoffBase, 2: 2 * RemediationBackoffBase, 10: RemediationBackoffMax} {
+		if got := remediationBackoff(count); got != want {
+			t.Errorf("remediationBackoff(%d) = %v, want %v", count, got, want)
+		}
+	}
+}
+
+// crashLoopController quarantines Pod targets restarting 3 times, which are available once ready
+type crashLoopController struct {
+	api.XSetController
+	policy *api.CrashLoopRemediationPolicy
+}
+
+func (c *crashLoopController) XMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+}
+
+func (c *crashLoopController) CheckAvailable(object client.Object) bool {
+	ready, _ := c.CheckReadyTime(object)
+	return ready
+}
+
+func (c *crashLoopController) CheckReadyTime(object client.Object) (bool, *metav1.Time) {
+	for _, cond := range object.(*corev1.Pod).Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue, &cond.LastTransitionTime
+		}
+	}
+	return false, nil
+}
+
+func (c *crashLoopController) GetCrashLoopRemediationPolicy(api.XSetObject) *api.CrashLoopRemediationPolicy {
+	return c.policy
+}
+
+func (c *crashLoopController) GetTargetFailures(target client.Object) (int32, bool) {
+	return GetPodFailures(target)
+}
+
+func TestRemediateCrashLoopTargetsWithinBudget(t *testing.T) {
+	labelAnnoMgr := api.NewXSetLabelAnnotationManager(nil)
+	newPod := func(id int, restarts int32, ready bool) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("foo-%d", id), UID: types.UID(fmt.Sprintf("foo-%d-uid", id))},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "app", RestartCount: restarts}},
+				Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+			},
+		}
+		if ready {
+			pod.Status.Conditions[0].Status = corev1.ConditionTrue
+		}
+		return pod
+	}
+	pods := []client.Object{newPod(0, 5, false), newPod(1, 5, false), newPod(2, 0, true), newPod(3, 0, false)}
+	xsetController := &crashLoopController{policy: &api.CrashLoopRemediationPolicy{FailureThreshold: 3, Remediation: api.CrashLoopRemediationQuarantine, MaxReplacing: 1}}
+	targetControl := fake.NewTargetControl(xsetController, pods...)
+	recorder := record.NewFakeRecorder(100)
+	r := &RealSyncControl{
+		xsetController:    xsetController,
+		xsetLabelAnnoMgr:  labelAnnoMgr,
+		xControl:          targetControl,
+		cacheExpectations: &noopExpectations{},
+		deferredTargets:   newDeferredTargets(),
+	}
+	r.Recorder = recorder
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	syncContext := &SyncContext{NewStatus: &api.XSetStatus{}}
+	remediate := func(ids ...int) {
+		syncContext.TargetWrappers = nil
+		for _, id := range ids {
+			syncContext.TargetWrappers = append(syncContext.TargetWrappers, &TargetWrapper{ID: id, Object: pods[id]})
+		}
+		if err := r.remediateCrashLoopTargets(context.TODO(), xset, syncContext); err != nil {
+			t.Fatalf("remediateCrashLoopTargets() = %v", err)
+		}
+	}
+	quarantined := func() []string {
+		var names []string
+		for _, target := range targetControl.Targets() {
+			if isTargetQuarantined(labelAnnoMgr, target) {
+				names = append(names, target.GetName())
+			}
+		}
+		return names
+	}
+	deferredEvents := func() int {
+		count := 0
+		for len(recorder.Events) > 0 {
+			if strings.Contains(<-recorder.Events, "CrashLoopRemediationDeferred") {
+				count++
+			}
+		}
+		return count
+	}
+
+	// foo-3 is starting, which leaves no budget, and deferral is reported once
+	remediate(0, 1, 2, 3)
+	if got := quarantined(); len(got) != 0 {
+		t.Fatalf("got quarantined targets %v, want none", got)
+	}
+	if count := deferredEvents(); count != 2 {
+		t.Errorf("got %d deferred events, want ones of foo-0 and foo-1", count)
+	}
+	remediate(0, 1, 2, 3)
+	if count := deferredEvents(); count != 0 {
+		t.Errorf("got %d deferred events, want none", count)
+	}
+
+	// failing targets are remediated one by one once starting target is available
+	pods[3].(*corev1.Pod).Status.Conditions[0].Status = corev1.ConditionTrue
+	remediate(0, 1, 2, 3)
+	if got := quarantined(); !reflect.DeepEqual(got, []string{"foo-0"}) {
+		t.Errorf("got quarantined targets %v, want foo-0", got)
+	}
+	if count := deferredEvents(); count != 0 {
+		t.Errorf("got %d deferred events, want none", count)
+	}
+}
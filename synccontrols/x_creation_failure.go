@@ -0,0 +1,170 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+const (
+	// CreationBackoffBase is the backoff after the first failure of creating target for an instance ID
+	CreationBackoffBase = 10 * time.Second
+	// CreationBackoffMax is the max backoff of retrying to create target for an instance ID
+	CreationBackoffMax = 5 * time.Minute
+)
+
+// classifyCreationError returns the class of error returned by creating target
+func classifyCreationError(err error) api.CreationFailureReason {
+	switch {
+	case apierrors.IsForbidden(err) && strings.Contains(err.Error(), "exceeded quota"):
+		return api.CreationFailureQuotaExceeded
+	case apierrors.IsForbidden(err):
+		return api.CreationFailureForbidden
+	case apierrors.IsInvalid(err):
+		return api.CreationFailureInvalidTemplate
+	case apierrors.IsAlreadyExists(err):
+		return api.CreationFailureAlreadyExists
+	default:
+		return api.CreationFailureUnknown
+	}
+}
+
+// creationBackoff returns the backoff before retrying after count consecutive failures
+func creationBackoff(count int32) time.Duration {
+	backoff := CreationBackoffBase
+	for i := int32(1); i < count && backoff < CreationBackoffMax; i++ {
+		backoff *= 2
+	}
+	return min(backoff, CreationBackoffMax)
+}
+
+// findCreationFailure returns the failure recorded for instance ID
+func findCreationFailure(status *api.XSetStatus, id int) *api.CreationFailure {
+	for i := range status.FailedCreations {
+		if status.FailedCreations[i].ID == id {
+			return &status.FailedCreations[i]
+		}
+	}
+	return nil
+}
+
+// checkCreationBackoff returns the remaining backoff if creating target of revision for instance ID failed recently.
+// Backoff is not applied once revision changes, e.g., template is fixed.
+func checkCreationBackoff(status *api.XSetStatus, id int, revision string) (time.Duration, bool) {
+	failure := findCreationFailure(status, id)
+	if failure == nil || failure.Revision != revision {
+		return 0, false
+	}
+	remaining := time.Until(failure.LastFailureTime.Add(creationBackoff(failure.Count)))
+	return remaining, remaining > 0
+}
+
+// recordCreationFailure records a failure of creating target of revision for instance ID
+func recordCreationFailure(status *api.XSetStatus, id int, revision string, err error) {
+	now := metav1.Now()
+	reason := classifyCreationError(err)
+	failure := findCreationFailure(status, id)
+	if failure == nil {
+		status.FailedCreations = append(status.FailedCreations, api.CreationFailure{ID: id, FirstFailureTime: now})
+		sort.Slice(status.FailedCreations, func(i, j int) bool {
+			return status.FailedCreations[i].ID < status.FailedCreations[j].ID
+		})
+		failure = findCreationFailure(status, id)
+	} else if failure.Revision != revision {
+		failure.Count = 0
+		failure.FirstFailureTime = now
+	}
+	failure.Revision = revision
+	failure.Reason = reason
+	failure.Message = err.Error()
+	failure.Count++
+	failure.LastFailureTime = now
+}
+
+// clearCreationFailure removes the failure recorded for instance ID
+func clearCreationFailure(status *api.XSetStatus, id int) {
+	for i := range status.FailedCreations {
+		if status.FailedCreations[i].ID == id {
+			status.FailedCreations = append(status.FailedCreations[:i], status.FailedCreations[i+1:]...)
+			break
+		}
+	}
+	if len(status.FailedCreations) == 0 {
+		status.FailedCreations = nil
+	}
+}
+
+// recordCreationResults records failures of IDs failed to create in status, and clears the ones of IDs created. It
+// returns the number of IDs skipped for backoff and the shortest remaining backoff among them.
+func recordCreationResults(
+	syncContext *SyncContext,
+	availableContexts []*api.ContextDetail,
+	revisions []string,
+	errs []error,
+	created []bool,
+	backoffs []time.Duration,
+) (int, *time.Duration) {
+	var backingOffCount int
+	var shortest *time.Duration
+	for i, availableContext := range availableContexts {
+		switch {
+		case errs[i] != nil && !created[i]:
+			recordCreationFailure(syncContext.NewStatus, availableContext.ID, revisions[i], errs[i])
+		case created[i]:
+			clearCreationFailure(syncContext.NewStatus, availableContext.ID)
+		case backoffs[i] > 0:
+			backingOffCount++
+			syncContext.Decisions.RecordRequeue("CreationBackoff", &backoffs[i])
+			shortest = xcontrol.GetShorterDuration(shortest, &backoffs[i])
+		}
+	}
+	return backingOffCount, shortest
+}
+
+// pruneCreationFailures removes failures of instance IDs which are released or whose targets exist
+func pruneCreationFailures(status *api.XSetStatus, syncContext *SyncContext) {
+	if syncContext.OwnedIds == nil {
+		return
+	}
+	failures := status.FailedCreations[:0]
+	for _, failure := range status.FailedCreations {
+		if _, owned := syncContext.OwnedIds[failure.ID]; !owned || syncContext.CurrentIDs.Has(failure.ID) {
+			continue
+		}
+		failures = append(failures, failure)
+	}
+	if len(failures) == 0 {
+		failures = nil
+	}
+	status.FailedCreations = failures
+}
+
+// summarizeCreationFailures returns a brief message of recorded failures for ReplicaFailure condition
+func summarizeCreationFailures(failures []api.CreationFailure) string {
+	first := failures[0]
+	return fmt.Sprintf("%d instance ID(s) failed to create, e.g., ID %d failed %d time(s) since %s with %s: %s",
+		len(failures), first.ID, first.Count, first.FirstFailureTime.UTC().Format(time.RFC3339), first.Reason, first.Message)
+}
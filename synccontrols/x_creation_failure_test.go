@@ -0,0 +1,147 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+func TestClassifyCreationError(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	tests := []struct {
+		err  error
+		want api.CreationFailureReason
+	}{
+		{err: apierrors.NewForbidden(pods, "foo-0", errors.New("exceeded quota: compute")), want: api.CreationFailureQuotaExceeded},
+		{err: apierrors.NewForbidden(pods, "foo-0", errors.New("denied by webhook")), want: api.CreationFailureForbidden},
+		{err: apierrors.NewInvalid(schema.GroupKind{Kind: "Pod"}, "foo-0", nil), want: api.CreationFailureInvalidTemplate},
+		{err: apierrors.NewAlreadyExists(pods, "foo-0"), want: api.CreationFailureAlreadyExists},
+		{err: errors.New("timeout"), want: api.CreationFailureUnknown},
+	}
+	for _, tt := range tests {
+		if got := classifyCreationError(tt.err); got != tt.want {
+			t.Errorf("classifyCreationError(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCreationBackoff(t *testing.T) {
+	for count, want := range map[int32]time.Duration{
+		1:  CreationBackoffBase,
+		2:  2 * CreationBackoffBase,
+		3:  4 * CreationBackoffBase,
+		10: CreationBackoffMax,
+	} {
+		if got := creationBackoff(count); got != want {
+			t.Errorf("creationBackoff(%d) = %v, want %v", count, got, want)
+		}
+	}
+}
+
+func TestRecordCreationFailure(t *testing.T) {
+	status := &api.XSetStatus{}
+	quotaErr := apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "foo-1", errors.New("exceeded quota"))
+	recordCreationFailure(status, 1, "rev-1", quotaErr)
+	recordCreationFailure(status, 0, "rev-1", errors.New("timeout"))
+	recordCreationFailure(status, 1, "rev-1", quotaErr)
+	if len(status.FailedCreations) != 2 || status.FailedCreations[0].ID != 0 || status.FailedCreations[1].ID != 1 {
+		t.Fatalf("got failures %+v, want ordered by ID", status.FailedCreations)
+	}
+	failure := findCreationFailure(status, 1)
+	if failure.Count != 2 || failure.Reason != api.CreationFailureQuotaExceeded {
+		t.Errorf("got failure %+v, want counted twice for quota", failure)
+	}
+
+	// recent failures of the same revision are backed off
+	if remaining, backingOff := checkCreationBackoff(status, 1, "rev-1"); !backingOff || remaining > 2*CreationBackoffBase {
+		t.Errorf("checkCreationBackoff() = %v, %v, want backing off", remaining, backingOff)
+	}
+	// backoff is not applied once revision changes, and failures are recounted
+	if _, backingOff := checkCreationBackoff(status, 1, "rev-2"); backingOff {
+		t.Error("checkCreationBackoff() backs off a new revision")
+	}
+	recordCreationFailure(status, 1, "rev-2", quotaErr)
+	if failure := findCreationFailure(status, 1); failure.Count != 1 || failure.Revision != "rev-2" {
+		t.Errorf("got failure %+v, want recounted for new revision", failure)
+	}
+	// backoff expires
+	findCreationFailure(status, 1).LastFailureTime = metav1.NewTime(time.Now().Add(-CreationBackoffBase))
+	if _, backingOff := checkCreationBackoff(status, 1, "rev-2"); backingOff {
+		t.Error("checkCreationBackoff() backs off after backoff expired")
+	}
+
+	clearCreationFailure(status, 0)
+	clearCreationFailure(status, 1)
+	if status.FailedCreations != nil {
+		t.Errorf("got failures %+v after cleared, want nil", status.FailedCreations)
+	}
+}
+
+func TestRecordCreationResults(t *testing.T) {
+	status := &api.XSetStatus{}
+	recordCreationFailure(status, 1, "rev-1", errors.New("timeout"))
+	syncContext := &SyncContext{NewStatus: status}
+	availableContexts := []*api.ContextDetail{{ID: 0}, {ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+	revisions := []string{"rev-1", "rev-1", "rev-1", "rev-1", "rev-1"}
+	errs := []error{errors.New("timeout"), nil, nil, nil, nil}
+	created := []bool{false, true, false, false, true}
+	backoffs := []time.Duration{0, 0, time.Minute, 20 * time.Second, 0}
+
+	backingOffCount, backoff := recordCreationResults(syncContext, availableContexts, revisions, errs, created, backoffs)
+	// IDs 2 and 3 are skipped without error, which must be excluded from targets scaled out
+	if backingOffCount != 2 || backoff == nil || *backoff != 20*time.Second {
+		t.Errorf("recordCreationResults() = %d, %v, want 2 backing off for 20s", backingOffCount, backoff)
+	}
+	if len(status.FailedCreations) != 1 || status.FailedCreations[0].ID != 0 {
+		t.Errorf("got failures %+v, want failure of ID 0 recorded and the one of created ID 1 cleared", status.FailedCreations)
+	}
+	if syncContext.Decisions.RequeueReason != "CreationBackoff" {
+		t.Errorf("got requeue reason %q, want CreationBackoff", syncContext.Decisions.RequeueReason)
+	}
+}
+
+func TestPruneCreationFailures(t *testing.T) {
+	status := &api.XSetStatus{}
+	for id := 0; id < 3; id++ {
+		recordCreationFailure(status, id, "rev-1", errors.New("timeout"))
+	}
+
+	// failures are kept if IDs are not allocated
+	pruneCreationFailures(status, &SyncContext{})
+	if len(status.FailedCreations) != 3 {
+		t.Fatalf("got %d failures, want all kept", len(status.FailedCreations))
+	}
+
+	// failures of released IDs and of IDs whose targets exist are removed
+	syncContext := &SyncContext{
+		OwnedIds:   map[int]*api.ContextDetail{1: {ID: 1}, 2: {ID: 2}},
+		CurrentIDs: sets.NewInt(2),
+	}
+	pruneCreationFailures(status, syncContext)
+	if len(status.FailedCreations) != 1 || status.FailedCreations[0].ID != 1 {
+		t.Errorf("got failures %+v, want only the one of ID 1", status.FailedCreations)
+	}
+}
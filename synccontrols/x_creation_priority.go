@@ -0,0 +1,72 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// extractAvailableContexts returns want contexts of IDs not used by targets, preferring IDs of higher creation
+// priority if CreationPriorityAdapter is implemented
+func (r *RealSyncControl) extractAvailableContexts(owner api.XSetObject, want int, ownedIDs map[int]*api.ContextDetail, currentIDs sets.Int) []*api.ContextDetail {
+	if _, ok := r.xsetController.(api.CreationPriorityAdapter); !ok {
+		return r.resourceContextControl.ExtractAvailableContexts(want, ownedIDs, currentIDs)
+	}
+	availableContexts := r.resourceContextControl.ExtractAvailableContexts(len(ownedIDs), ownedIDs, currentIDs)
+	r.sortByCreationPriority(owner, availableContexts)
+	if len(availableContexts) > want {
+		availableContexts = availableContexts[:max(want, 0)]
+	}
+	return availableContexts
+}
+
+// prioritizeCreation returns contexts of the highest creation priority in order, and contexts of lower priority are
+// left to following syncs
+func (r *RealSyncControl) prioritizeCreation(owner api.XSetObject, contexts []*api.ContextDetail) []*api.ContextDetail {
+	if _, ok := r.xsetController.(api.CreationPriorityAdapter); !ok || len(contexts) == 0 {
+		return contexts
+	}
+	priorities := r.sortByCreationPriority(owner, contexts)
+	for i := range contexts {
+		if priorities[contexts[i].ID] != priorities[contexts[0].ID] {
+			return contexts[:i]
+		}
+	}
+	return contexts
+}
+
+// sortByCreationPriority sorts contexts by creation priority in descending order and then by ID, and returns the
+// priority of each ID
+func (r *RealSyncControl) sortByCreationPriority(owner api.XSetObject, contexts []*api.ContextDetail) map[int]int32 {
+	adapter := r.xsetController.(api.CreationPriorityAdapter)
+	priorities := make(map[int]int32, len(contexts))
+	for _, contextDetail := range contexts {
+		subset, _ := r.resourceContextControl.Get(contextDetail, api.EnumSubsetContextDataKey)
+		priorities[contextDetail.ID] = adapter.GetCreationPriority(owner, contextDetail.ID, subset)
+	}
+	sort.SliceStable(contexts, func(i, j int) bool {
+		if lPriority, rPriority := priorities[contexts[i].ID], priorities[contexts[j].ID]; lPriority != rPriority {
+			return lPriority > rPriority
+		}
+		return contexts[i].ID < contexts[j].ID
+	})
+	return priorities
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/resourcecontexts"
+)
+
+type creationPriorityController struct {
+	api.XSetController
+}
+
+// GetCreationPriority prefers subset primary, and then ID 5
+func (c *creationPriorityController) GetCreationPriority(_ api.XSetObject, id int, subset string) int32 {
+	switch {
+	case subset == "primary":
+		return 10
+	case id == 5:
+		return 5
+	}
+	return 0
+}
+
+func contextIDs(contexts []*api.ContextDetail) []int {
+	ids := make([]int, 0, len(contexts))
+	for _, contextDetail := range contexts {
+		ids = append(ids, contextDetail.ID)
+	}
+	return ids
+}
+
+func TestCreationPriority(t *testing.T) {
+	xsetController := &creationPriorityController{}
+	rcControl := resourcecontexts.NewStatelessResourceContextControl(xsetController, &resourcecontexts.DefaultResourceContextAdapter{}, api.NewXSetLabelAnnotationManager(nil))
+	r := &RealSyncControl{
+		xsetController:         xsetController,
+		resourceContextControl: rcControl,
+	}
+	owner := &corev1.Pod{}
+
+	ownedIDs := map[int]*api.ContextDetail{}
+	for id := 0; id < 8; id++ {
+		ownedIDs[id] = &api.ContextDetail{ID: id}
+	}
+	rcControl.Put(ownedIDs[6], api.EnumSubsetContextDataKey, "primary")
+	rcControl.Put(ownedIDs[7], api.EnumSubsetContextDataKey, "primary")
+
+	// IDs 1 and 7 are used by targets
+	available := r.extractAvailableContexts(owner, 4, ownedIDs, sets.NewInt(1, 7))
+	if got, want := contextIDs(available), []int{6, 5, 0, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("extractAvailableContexts() = %v, want %v", got, want)
+	}
+
+	created := r.prioritizeCreation(owner, available)
+	if got, want := contextIDs(created), []int{6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("prioritizeCreation() = %v, want %v", got, want)
+	}
+	created = r.prioritizeCreation(owner, available[1:])
+	if got, want := contextIDs(created), []int{5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("prioritizeCreation() = %v, want %v", got, want)
+	}
+	created = r.prioritizeCreation(owner, available[2:])
+	if got, want := contextIDs(created), []int{0, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("prioritizeCreation() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// GetDecorationAdapters returns adapters of all decoration kinds registered by xsetController. MultiDecorationAdapter
+// takes precedence over DecorationAdapter.
+func GetDecorationAdapters(xsetController api.XSetController) []api.DecorationAdapter {
+	if multiAdapter, ok := xsetController.(api.MultiDecorationAdapter); ok {
+		return multiAdapter.GetDecorationAdapters()
+	}
+	if adapter, ok := xsetController.(api.DecorationAdapter); ok {
+		return []api.DecorationAdapter{adapter}
+	}
+	return nil
+}
+
+// GetDecorationAdapter returns a DecorationAdapter which handles all decoration kinds registered by xsetController
+// as a whole. A single decoration kind is returned as it is, so that revisions recorded on targets are unchanged.
+func GetDecorationAdapter(xsetController api.XSetController) (api.DecorationAdapter, bool) {
+	adapters := GetDecorationAdapters(xsetController)
+	switch len(adapters) {
+	case 0:
+		return nil, false
+	case 1:
+		return adapters[0], true
+	default:
+		return multiDecorationAdapter(adapters), true
+	}
+}
+
+// multiDecorationAdapter composes several decoration kinds. Revisions of all kinds are encoded as a json object keyed
+// by decoration kind, e.g., {"Sidecar.apps.example.io":"rev-1","Config.apps.example.io":"rev-2"}.
+type multiDecorationAdapter []api.DecorationAdapter
+
+var _ api.DecorationAdapter = multiDecorationAdapter{}
+
+func decorationKey(adapter api.DecorationAdapter) string {
+	gvk := adapter.GetDecorationGroupVersionKind()
+	if gvk.Group == "" {
+		return gvk.Kind
+	}
+	return gvk.Kind + "." + gvk.Group
+}
+
+// decodeRevisions decodes revisions of all kinds. A plain revision is taken as the one of the first decoration kind,
+// which is recorded before more decoration kinds are registered.
+func (m multiDecorationAdapter) decodeRevisions(revisions string) map[string]string {
+	decoded := map[string]string{}
+	if revisions == "" {
+		return decoded
+	}
+	if err := json.Unmarshal([]byte(revisions), &decoded); err != nil {
+		return map[string]string{decorationKey(m[0]): revisions}
+	}
+	return decoded
+}
+
+func (m multiDecorationAdapter) encodeRevisions(revisions map[string]string) (string, error) {
+	encoded, err := json.Marshal(revisions)
+	if err != nil {
+		return "", fmt.Errorf("fail to encode decoration revisions: %w", err)
+	}
+	return string(encoded), nil
+}
+
+func (m multiDecorationAdapter) WatchDecoration(c controller.Controller) error {
+	for _, adapter := range m {
+		if err := adapter.WatchDecoration(c); err != nil {
+			return fmt.Errorf("fail to watch decoration %s: %w", decorationKey(adapter), err)
+		}
+	}
+	return nil
+}
+
+// GetDecorationGroupVersionKind returns gvk of the first decoration kind, use GetDecorationAdapters for all kinds.
+func (m multiDecorationAdapter) GetDecorationGroupVersionKind() metav1.GroupVersionKind {
+	return m[0].GetDecorationGroupVersionKind()
+}
+
+func (m multiDecorationAdapter) GetTargetCurrentDecorationRevisions(ctx context.Context, c client.Client, target client.Object) (string, error) {
+	revisions := map[string]string{}
+	for _, adapter := range m {
+		revision, err := adapter.GetTargetCurrentDecorationRevisions(ctx, c, target)
+		if err != nil {
+			return "", err
+		}
+		revisions[decorationKey(adapter)] = revision
+	}
+	return m.encodeRevisions(revisions)
+}
+
+func (m multiDecorationAdapter) GetTargetUpdatedDecorationRevisions(ctx context.Context, c client.Client, target client.Object) (string, error) {
+	revisions := map[string]string{}
+	for _, adapter := range m {
+		revision, err := adapter.GetTargetUpdatedDecorationRevisions(ctx, c, target)
+		if err != nil {
+			return "", err
+		}
+		revisions[decorationKey(adapter)] = revision
+	}
+	return m.encodeRevisions(revisions)
+}
+
+func (m multiDecorationAdapter) GetDecorationPatcherByRevisions(ctx context.Context, c client.Client, target client.Object, revision string) (func(client.Object) error, error) {
+	revisions := m.decodeRevisions(revision)
+	patchers := make([]func(client.Object) error, 0, len(m))
+	for _, adapter := range m {
+		patcher, err := adapter.GetDecorationPatcherByRevisions(ctx, c, target, revisions[decorationKey(adapter)])
+		if err != nil {
+			return nil, err
+		}
+		patchers = append(patchers, patcher)
+	}
+	return func(object client.Object) error {
+		for _, patcher := range patchers {
+			if patcher == nil {
+				continue
+			}
+			if err := patcher(object); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+func (m multiDecorationAdapter) IsTargetDecorationChanged(currentRevision, updatedRevision string) (bool, error) {
+	currentRevisions, updatedRevisions := m.decodeRevisions(currentRevision), m.decodeRevisions(updatedRevision)
+	for _, adapter := range m {
+		key := decorationKey(adapter)
+		changed, err := adapter.IsTargetDecorationChanged(currentRevisions[key], updatedRevisions[key])
+		if err != nil || changed {
+			return changed, err
+		}
+	}
+	return false, nil
+}
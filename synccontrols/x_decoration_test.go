@@ -0,0 +1,153 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// labelDecoration records its revision in target label of its kind, and patches the revision as label value
+type labelDecoration struct {
+	kind            string
+	updatedRevision string
+}
+
+func (d *labelDecoration) WatchDecoration(controller.Controller) error { return nil }
+
+func (d *labelDecoration) GetDecorationGroupVersionKind() metav1.GroupVersionKind {
+	return metav1.GroupVersionKind{Group: "apps.example.io", Version: "v1", Kind: d.kind}
+}
+
+func (d *labelDecoration) GetTargetCurrentDecorationRevisions(_ context.Context, _ client.Client, target client.Object) (string, error) {
+	return target.GetLabels()[d.kind], nil
+}
+
+func (d *labelDecoration) GetTargetUpdatedDecorationRevisions(context.Context, client.Client, client.Object) (string, error) {
+	return d.updatedRevision, nil
+}
+
+func (d *labelDecoration) GetDecorationPatcherByRevisions(_ context.Context, _ client.Client, _ client.Object, revision string) (func(client.Object) error, error) {
+	if revision == "" {
+		return nil, nil
+	}
+	return func(target client.Object) error {
+		labels := target.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[d.kind] = revision
+		target.SetLabels(labels)
+		return nil
+	}, nil
+}
+
+func (d *labelDecoration) IsTargetDecorationChanged(currentRevision, updatedRevision string) (bool, error) {
+	return currentRevision != updatedRevision, nil
+}
+
+type multiDecorationController struct {
+	api.XSetController
+	adapters []api.DecorationAdapter
+}
+
+func (c *multiDecorationController) GetDecorationAdapters() []api.DecorationAdapter {
+	return c.adapters
+}
+
+func TestGetDecorationAdapter(t *testing.T) {
+	sidecar := &labelDecoration{kind: "Sidecar"}
+	if adapter, ok := GetDecorationAdapter(&multiDecorationController{adapters: []api.DecorationAdapter{sidecar}}); !ok || adapter != sidecar {
+		t.Errorf("GetDecorationAdapter() = %v, %v, want single decoration kind as it is", adapter, ok)
+	}
+	if _, ok := GetDecorationAdapter(&multiDecorationController{}); ok {
+		t.Error("GetDecorationAdapter() = true, want false without decoration kinds")
+	}
+	adapter, ok := GetDecorationAdapter(&multiDecorationController{adapters: []api.DecorationAdapter{sidecar, &labelDecoration{kind: "Config"}}})
+	if _, multi := adapter.(multiDecorationAdapter); !ok || !multi {
+		t.Errorf("GetDecorationAdapter() = %T, want decoration kinds composed", adapter)
+	}
+}
+
+func TestMultiDecorationAdapter(t *testing.T) {
+	ctx := context.TODO()
+	sidecar := &labelDecoration{kind: "Sidecar", updatedRevision: "sidecar-1"}
+	config := &labelDecoration{kind: "Config", updatedRevision: "config-2"}
+	adapter := multiDecorationAdapter{sidecar, config}
+	target := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"Sidecar": "sidecar-1", "Config": "config-1"}}}
+
+	// revisions of all kinds are encoded by decoration key
+	current, err := adapter.GetTargetCurrentDecorationRevisions(ctx, nil, target)
+	if want := `{"Config.apps.example.io":"config-1","Sidecar.apps.example.io":"sidecar-1"}`; err != nil || current != want {
+		t.Fatalf("GetTargetCurrentDecorationRevisions() = %s, %v, want %s", current, err, want)
+	}
+	updated, err := adapter.GetTargetUpdatedDecorationRevisions(ctx, nil, target)
+	if err != nil {
+		t.Fatalf("GetTargetUpdatedDecorationRevisions() = %v", err)
+	}
+	if changed, err := adapter.IsTargetDecorationChanged(current, updated); err != nil || !changed {
+		t.Errorf("IsTargetDecorationChanged() = %v, %v, want changed by config", changed, err)
+	}
+
+	// patchers of all kinds are applied in order
+	patcher, err := adapter.GetDecorationPatcherByRevisions(ctx, nil, target, updated)
+	if err != nil {
+		t.Fatalf("GetDecorationPatcherByRevisions() = %v", err)
+	}
+	patched := &corev1.Pod{}
+	if err := patcher(patched); err != nil || patched.Labels["Sidecar"] != "sidecar-1" || patched.Labels["Config"] != "config-2" {
+		t.Errorf("got labels %v, %v, want revisions of all kinds patched", patched.Labels, err)
+	}
+}
+
+func TestMultiDecorationAdapterLegacyRevision(t *testing.T) {
+	ctx := context.TODO()
+	adapter := multiDecorationAdapter{&labelDecoration{kind: "Sidecar"}, &labelDecoration{kind: "Config"}}
+
+	// plain revision recorded before more kinds are registered is taken as the one of the first kind
+	if got := adapter.decodeRevisions("sidecar-1"); len(got) != 1 || got["Sidecar.apps.example.io"] != "sidecar-1" {
+		t.Errorf("decodeRevisions() = %v, want plain revision of the first kind", got)
+	}
+	if got := adapter.decodeRevisions(""); len(got) != 0 {
+		t.Errorf("decodeRevisions() = %v, want empty revisions", got)
+	}
+
+	updated := `{"Sidecar.apps.example.io":"sidecar-1","Config.apps.example.io":""}`
+	if changed, err := adapter.IsTargetDecorationChanged("sidecar-1", updated); err != nil || changed {
+		t.Errorf("IsTargetDecorationChanged() = %v, %v, want plain revision unchanged", changed, err)
+	}
+	updated = `{"Sidecar.apps.example.io":"sidecar-1","Config.apps.example.io":"config-1"}`
+	if changed, err := adapter.IsTargetDecorationChanged("sidecar-1", updated); err != nil || !changed {
+		t.Errorf("IsTargetDecorationChanged() = %v, %v, want changed by new kind", changed, err)
+	}
+
+	patcher, err := adapter.GetDecorationPatcherByRevisions(ctx, nil, &corev1.Pod{}, "sidecar-1")
+	if err != nil {
+		t.Fatalf("GetDecorationPatcherByRevisions() = %v", err)
+	}
+	patched := &corev1.Pod{}
+	if err := patcher(patched); err != nil || len(patched.Labels) != 1 || patched.Labels["Sidecar"] != "sidecar-1" {
+		t.Errorf("got labels %v, %v, want only the first kind patched", patched.Labels, err)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+// checkDeletionApproval returns whether target is allowed to be deleted by XSet. If DeletionApprovalAdapter requires
+// approval, target is labeled as pending deletion first, and is allowed once approved or timeout expires. The duration
+// until timeout is returned to requeue XSet.
+func checkDeletionApproval(
+	ctx context.Context,
+	xsetController api.XSetController,
+	xsetLabelAnnoMgr api.XSetLabelAnnotationManager,
+	targetControl xcontrol.TargetControl,
+	recorder record.EventRecorder,
+	owner api.XSetObject,
+	target client.Object,
+) (bool, *time.Duration, error) {
+	adapter, ok := xsetController.(api.DeletionApprovalAdapter)
+	if !ok {
+		return true, nil, nil
+	}
+	required, timeout := adapter.GetDeletionApproval(owner)
+	if !required || target.GetAnnotations()[api.TargetDeletionApprovedAnnotationKey] == "true" {
+		return true, nil, nil
+	}
+
+	pendingSince, pending := xsetLabelAnnoMgr.Get(target, api.XPendingDeletionLabelKey)
+	if !pending {
+		now := time.Now().Unix()
+		patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf(`{"metadata":{"labels":{%q:"%d"}}}`, xsetLabelAnnoMgr.Value(api.XPendingDeletionLabelKey), now)))
+		if err := targetControl.PatchTarget(ctx, target, patch); err != nil {
+			return false, nil, fmt.Errorf("fail to mark target %s/%s pending deletion: %w", target.GetNamespace(), target.GetName(), err)
+		}
+		recorder.Eventf(target, corev1.EventTypeNormal, "DeletionPendingApproval", "target is waiting for approval to be deleted")
+		pendingSince = strconv.FormatInt(now, 10)
+	}
+	if timeout <= 0 {
+		return false, nil, nil
+	}
+
+	since, err := strconv.ParseInt(pendingSince, 10, 64)
+	if err != nil {
+		// unparsable value is regarded as just requested
+		since = time.Now().Unix()
+	}
+	remaining := time.Until(time.Unix(since, 0).Add(timeout))
+	if remaining > 0 {
+		return false, &remaining, nil
+	}
+	recorder.Eventf(target, corev1.EventTypeNormal, "DeletionApprovalTimeout", "target is deleted without approval after waiting for %s", timeout)
+	return true, nil, nil
+}
+
+// clearCanceledDeletionApprovals removes XPendingDeletionLabelKey from targets which are no longer to be deleted, e.g.,
+// scaling in or recreate update of them is canceled, so that approvers do not act on stale requests.
+func clearCanceledDeletionApprovals(
+	ctx context.Context,
+	xsetLabelAnnoMgr api.XSetLabelAnnotationManager,
+	targetControl xcontrol.TargetControl,
+	targetWrappers []*TargetWrapper,
+) error {
+	var errs []error
+	for _, wrapper := range targetWrappers {
+		if wrapper.GetDeletionTimestamp() != nil || wrapper.IsDuringScaleInOps || wrapper.IsDuringUpdateOps || wrapper.ToDelete {
+			continue
+		}
+		if _, pending := xsetLabelAnnoMgr.Get(wrapper.Object, api.XPendingDeletionLabelKey); !pending {
+			continue
+		}
+		if _, replacing := xsetLabelAnnoMgr.Get(wrapper.Object, api.XReplaceIndicationLabelKey); replacing {
+			continue
+		}
+		if err := targetControl.PatchTargetWithOptimisticLock(ctx, wrapper.Object, func(target client.Object) {
+			xsetLabelAnnoMgr.Delete(target, api.XPendingDeletionLabelKey)
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("fail to clear pending deletion of target %s/%s: %w", wrapper.GetNamespace(), wrapper.GetName(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
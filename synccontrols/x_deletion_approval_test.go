@@ -0,0 +1,81 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/testing/fake"
+)
+
+type deletionApprovalController struct {
+	api.XSetController
+}
+
+func (c *deletionApprovalController) XMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+}
+
+func (c *deletionApprovalController) GetDeletionApproval(api.XSetObject) (bool, time.Duration) {
+	return true, 0
+}
+
+func TestClearCanceledDeletionApprovals(t *testing.T) {
+	xsetLabelAnnoMgr := api.NewXSetLabelAnnotationManager(nil)
+	xsetController := &deletionApprovalController{}
+	newTarget := func(name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+	}
+	targetControl := fake.NewTargetControl(xsetController, newTarget("canceled"), newTarget("scaling-in"), newTarget("replacing"))
+	getTarget := func(name string) *TargetWrapper {
+		target, _ := targetControl.GetTarget(types.NamespacedName{Namespace: "default", Name: name})
+		return &TargetWrapper{Object: target}
+	}
+
+	// targets are marked pending deletion while waiting for approval
+	for _, name := range []string{"canceled", "scaling-in", "replacing"} {
+		approved, _, err := checkDeletionApproval(context.TODO(), xsetController, xsetLabelAnnoMgr, targetControl, record.NewFakeRecorder(10), &corev1.Pod{}, getTarget(name).Object)
+		if err != nil || approved {
+			t.Fatalf("checkDeletionApproval(%s) = %v, %v, want waiting for approval", name, approved, err)
+		}
+		target := getTarget(name).Object
+		xsetLabelAnnoMgr.Set(target, api.XPendingDeletionLabelKey, "1700000000")
+		if name == "replacing" {
+			xsetLabelAnnoMgr.Set(target, api.XReplaceIndicationLabelKey, "true")
+		}
+		_ = targetControl.UpdateTarget(context.TODO(), target)
+	}
+
+	scalingIn := getTarget("scaling-in")
+	scalingIn.IsDuringScaleInOps = true
+	if err := clearCanceledDeletionApprovals(context.TODO(), xsetLabelAnnoMgr, targetControl, []*TargetWrapper{getTarget("canceled"), scalingIn, getTarget("replacing")}); err != nil {
+		t.Fatalf("clearCanceledDeletionApprovals() = %v", err)
+	}
+	for name, wantPending := range map[string]bool{"canceled": false, "scaling-in": true, "replacing": true} {
+		if _, pending := xsetLabelAnnoMgr.Get(getTarget(name).Object, api.XPendingDeletionLabelKey); pending != wantPending {
+			t.Errorf("target %s is pending deletion %v, want %v", name, pending, wantPending)
+		}
+	}
+}
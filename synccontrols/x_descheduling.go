@@ -0,0 +1,128 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// getDeschedulingReplace returns annotation keys requesting eviction and the max number of targets being replaced,
+// or nil keys if DeschedulingReplaceAdapter is not implemented or disabled
+func getDeschedulingReplace(setController api.XSetController, owner api.XSetObject) ([]string, int) {
+	adapter, ok := setController.(api.DeschedulingReplaceAdapter)
+	if !ok {
+		return nil, 0
+	}
+	replace := adapter.GetDeschedulingReplace(owner)
+	if replace == nil {
+		return nil, 0
+	}
+	keys := replace.AnnotationKeys
+	if len(keys) == 0 {
+		keys = api.DefaultEvictionRequestAnnotationKeys
+	}
+	return keys, max(replace.MaxReplacing, 1)
+}
+
+// isEvictionRequested returns true if target has any of annotation keys requesting eviction
+func isEvictionRequested(target client.Object, annotationKeys []string) bool {
+	annotations := target.GetAnnotations()
+	for _, key := range annotationKeys {
+		if _, exist := annotations[key]; exist {
+			return true
+		}
+	}
+	return false
+}
+
+// deferredTargets records targets whose replacement is deferred by the budget of each xset, so that deferral is
+// reported once per target rather than on every reconcile
+type deferredTargets struct {
+	mu sync.Mutex
+	// targets maps key of xset to UIDs of targets deferred by reason
+	targets map[string]map[string]sets.String
+}
+
+func newDeferredTargets() *deferredTargets {
+	return &deferredTargets{targets: map[string]map[string]sets.String{}}
+}
+
+// update records targets deferred for reason by xset, and returns those newly deferred since last update. Targets no
+// longer deferred are dropped, so that they are reported again if deferred later.
+func (d *deferredTargets) update(reason string, xset client.Object, targets []client.Object) []client.Object {
+	if d == nil {
+		return targets
+	}
+	key := ObjectKeyString(xset)
+	deferred := sets.NewString()
+	var newlyDeferred []client.Object
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, target := range targets {
+		uid := string(target.GetUID())
+		deferred.Insert(uid)
+		if !d.targets[key][reason].Has(uid) {
+			newlyDeferred = append(newlyDeferred, target)
+		}
+	}
+	switch {
+	case deferred.Len() > 0 && d.targets[key] == nil:
+		d.targets[key] = map[string]sets.String{reason: deferred}
+	case deferred.Len() > 0:
+		d.targets[key][reason] = deferred
+	default:
+		delete(d.targets[key], reason)
+		if len(d.targets[key]) == 0 {
+			delete(d.targets, key)
+		}
+	}
+	return newlyDeferred
+}
+
+// forget drops targets deferred by xset of key for all reasons
+func (d *deferredTargets) forget(key string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.targets, key)
+}
+
+// replaceEvictionRequestedTargets labels targets requested to evict with replace indication label, within the budget
+// of targets being replaced at the same time, so that they are replaced by Replace
+func (r *RealSyncControl) replaceEvictionRequestedTargets(ctx context.Context, xsetObject api.XSetObject, targets []*TargetWrapper) error {
+	annotationKeys, maxReplacing := getDeschedulingReplace(r.xsetController, xsetObject)
+	if len(annotationKeys) == 0 {
+		return nil
+	}
+
+	candidates, replacing := r.replaceCandidates(targets)
+	var requested []*TargetWrapper
+	for _, target := range candidates {
+		if isEvictionRequested(target, annotationKeys) {
+			requested = append(requested, target)
+		}
+	}
+	return r.labelTargetsToReplace(ctx, xsetObject, "EvictionRequest", "requested to evict", requested, replacing, maxReplacing, nil)
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/opslifecycle"
+	"kusionstack.io/kube-xset/testing/fake"
+)
+
+const testEvictionRequestKey = "descheduler.alpha.kubernetes.io/request-evict-only"
+
+type deschedulingController struct {
+	api.XSetController
+	replace *api.DeschedulingReplace
+}
+
+func (c *deschedulingController) XMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+}
+
+func (c *deschedulingController) XSetMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "apps.kusionstack.io/v1alpha1", Kind: "CollaSet"}
+}
+
+func (c *deschedulingController) GetDeschedulingReplace(api.XSetObject) *api.DeschedulingReplace {
+	return c.replace
+}
+
+func TestReplaceEvictionRequestedTargets(t *testing.T) {
+	labelAnnoMgr := api.NewXSetLabelAnnotationManager(nil)
+	newPod := func(name string, requested bool) *corev1.Pod {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default", Name: name, UID: types.UID(name + "-uid"), Labels: map[string]string{}, Annotations: map[string]string{},
+		}}
+		if requested {
+			pod.Annotations[testEvictionRequestKey] = ""
+		}
+		return pod
+	}
+	newTarget := newPod("foo-4", true)
+	labelAnnoMgr.Set(newTarget, api.XReplacePairOriginName, "foo-9")
+	pods := []client.Object{newPod("foo-0", false), newPod("foo-1", true), newPod("foo-2", true), newPod("foo-3", true), newTarget}
+	xsetController := &deschedulingController{replace: &api.DeschedulingReplace{MaxReplacing: 1}}
+	targetControl := fake.NewTargetControl(xsetController, pods...)
+	_, scaleInLifecycleAdapter := opslifecycle.GetLifecycleAdapters(xsetController, labelAnnoMgr, xsetController.XSetMeta())
+	recorder := record.NewFakeRecorder(100)
+	r := &RealSyncControl{
+		xsetController:          xsetController,
+		xsetLabelAnnoMgr:        labelAnnoMgr,
+		xControl:                targetControl,
+		cacheExpectations:       &noopExpectations{},
+		scaleInLifecycleAdapter: scaleInLifecycleAdapter,
+		deferredTargets:         newDeferredTargets(),
+	}
+	r.Recorder = recorder
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+
+	replaced := func() []string {
+		var names []string
+		for _, target := range targetControl.Targets() {
+			if _, exist := labelAnnoMgr.Get(target, api.XReplaceIndicationLabelKey); exist {
+				names = append(names, target.GetName())
+			}
+		}
+		return names
+	}
+	sync := func() {
+		var targets []*TargetWrapper
+		for _, target := range targetControl.Targets() {
+			targets = append(targets, &TargetWrapper{Object: target})
+		}
+		if err := r.replaceEvictionRequestedTargets(context.TODO(), xset, targets); err != nil {
+			t.Fatalf("replaceEvictionRequestedTargets() = %v", err)
+		}
+	}
+	deferredEvents := func() []string {
+		var events []string
+		for len(recorder.Events) > 0 {
+			if event := <-recorder.Events; strings.Contains(event, "EvictionRequestDeferred") {
+				events = append(events, event)
+			}
+		}
+		return events
+	}
+
+	// only one target requested is replaced within budget, and new target of replace is never replaced
+	sync()
+	if got := replaced(); len(got) != 1 || got[0] != "foo-1" {
+		t.Fatalf("got replaced targets %v, want foo-1", got)
+	}
+	if events := deferredEvents(); len(events) != 2 {
+		t.Errorf("got deferred events %v, want one for each of foo-2 and foo-3", events)
+	}
+
+	// deferral is reported once per target
+	sync()
+	if events := deferredEvents(); len(events) != 0 {
+		t.Errorf("got deferred events %v, want none for targets already deferred", events)
+	}
+
+	// deferred targets are replaced once budget is released
+	xsetController.replace.MaxReplacing = 3
+	sync()
+	if got := replaced(); len(got) != 3 {
+		t.Errorf("got replaced targets %v, want foo-1, foo-2 and foo-3", got)
+	}
+	if len(r.deferredTargets.targets) != 0 {
+		t.Errorf("deferred targets %v are not dropped once replaced", r.deferredTargets.targets)
+	}
+}
+
+func TestDeferredTargets(t *testing.T) {
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	target := func(uid string) client.Object {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: types.UID(uid)}}
+	}
+	deferred := newDeferredTargets()
+	if got := deferred.update("NodeFailure", xset, []client.Object{target("a"), target("b")}); len(got) != 2 {
+		t.Errorf("update() = %v, want both targets newly deferred", got)
+	}
+	if got := deferred.update("EvictionRequest", xset, []client.Object{target("a")}); len(got) != 1 {
+		t.Errorf("update() = %v, want target newly deferred for another reason", got)
+	}
+	if got := deferred.update("NodeFailure", xset, []client.Object{target("b"), target("c")}); len(got) != 1 || got[0].GetUID() != "c" {
+		t.Errorf("update() = %v, want c newly deferred", got)
+	}
+	if got := deferred.update("NodeFailure", xset, []client.Object{target("a")}); len(got) != 1 {
+		t.Errorf("update() = %v, want a deferred again once it was dropped", got)
+	}
+
+	deferred.forget(ObjectKeyString(xset))
+	if len(deferred.targets) != 0 {
+		t.Errorf("deferred targets %v are not dropped by forget", deferred.targets)
+	}
+}
@@ -0,0 +1,481 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	clientutil "kusionstack.io/kube-utils/client"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+	"kusionstack.io/kube-xset/revisionowner"
+)
+
+const (
+	// maxDriftsReported is the max number of fields or targets listed in events and condition
+	maxDriftsReported = 5
+)
+
+// DriftCheckInterval is the min interval between drift checks of an XSet, since rendering templates of all targets is
+// expensive. Targets are checked on every reconcile once XSet changes or drifted targets are being remediated.
+const DriftCheckInterval = 5 * time.Minute
+
+// driftCheck is the last check of an XSet finding no drift to remediate
+type driftCheck struct {
+	generation int64
+	time       time.Time
+}
+
+// pathToken is a key of map or an index of list in field path
+type pathToken struct {
+	value string
+	index bool
+}
+
+// driftField is a field of target drifted from template
+type driftField struct {
+	// path is the path of field, in which indexes of list are those in target
+	path []pathToken
+	// pointer is the JSON pointer of field in target to patch back
+	pointer string
+	// value is the field rendered in template
+	value interface{}
+	// missing indicates the field is missing in target
+	missing bool
+}
+
+func getDriftDetectionPolicy(xsetController api.XSetController, xset api.XSetObject) *api.DriftDetectionPolicy {
+	if adapter, ok := xsetController.(api.DriftDetectionAdapter); ok {
+		return adapter.GetDriftDetectionPolicy(xset)
+	}
+	return nil
+}
+
+// RemediateDrift compares targets with the template rendered from their revision, and remediates drifted targets by
+// policy of DriftDetectionAdapter. Drifted targets are reported in DriftDetected condition, and recreating them is
+// held out of maintenance windows like other disruptive actions, and limited by MaxUnavailable of policy.
+func (r *RealSyncControl) RemediateDrift(ctx context.Context, xsetObject api.XSetObject, syncContext *SyncContext) error {
+	key := ObjectKeyString(xsetObject)
+	policy := getDriftDetectionPolicy(r.xsetController, xsetObject)
+	if policy == nil {
+		r.driftChecks.Delete(key)
+		conditions.Remove(syncContext.NewStatus, api.XSetDriftDetected)
+		return nil
+	}
+	if last, ok := r.driftChecks.Load(key); ok {
+		if check := last.(driftCheck); check.generation == xsetObject.GetGeneration() && time.Since(check.time) < DriftCheckInterval {
+			return nil
+		}
+	}
+	ignorePaths := make([][]pathToken, 0, len(policy.IgnorePaths))
+	for _, path := range policy.IgnorePaths {
+		tokens, err := parseFieldPath(path)
+		if err != nil {
+			return fmt.Errorf("invalid ignore path %q of drift detection: %w", path, err)
+		}
+		ignorePaths = append(ignorePaths, tokens)
+	}
+	revisions := make(map[string]*appsv1.ControllerRevision, len(syncContext.Revisions))
+	for _, revision := range syncContext.Revisions {
+		revisions[revision.GetName()] = revision
+	}
+	// events are only emitted on targets once DriftDetected condition transits, rather than on every check
+	wasDrifted := conditions.IsTrue(syncContext.NewStatus, api.XSetDriftDetected)
+	recreateBudget := 0
+	if policy.Remediation == api.DriftRemediationRecreate {
+		recreateBudget = max(policy.MaxUnavailable, 1) - r.countUnavailableTargets(xsetObject, syncContext)
+	}
+
+	var drifted []string
+	var errs []error
+	for _, wrapper := range syncContext.TargetWrappers {
+		if !r.isDriftCheckable(wrapper) {
+			continue
+		}
+		revision, exist := revisions[wrapper.GetLabels()[appsv1.ControllerRevisionHashLabelKey]]
+		if !exist {
+			continue
+		}
+		desired, err := r.renderDesiredTarget(ctx, xsetObject, wrapper, revision)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("fail to render template of target %s: %w", wrapper.GetName(), err))
+			continue
+		}
+		revisionowner.RemoveExcludedTemplateMetadata(r.xsetController, xsetObject, desired)
+		drifts, err := findTargetDrifts(desired, wrapper.Object, ignorePaths)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("fail to compare target %s with template: %w", wrapper.GetName(), err))
+			continue
+		}
+		if len(drifts) == 0 {
+			continue
+		}
+		drifted = append(drifted, wrapper.GetName())
+		if !wasDrifted {
+			r.Recorder.Eventf(wrapper.Object, corev1.EventTypeWarning, "TargetDrifted", "fields are drifted from template: %s", formatDrifts(drifts))
+		}
+
+		switch policy.Remediation {
+		case api.DriftRemediationPatchBack:
+			err = r.patchBackDrifts(ctx, xsetObject, wrapper.Object, drifts)
+		case api.DriftRemediationRecreate:
+			if syncContext.OutOfMaintenanceWindow {
+				syncContext.heldByMaintenanceWindow++
+				continue
+			}
+			if recreateBudget <= 0 || !IsTargetAvailable(r.xsetController, wrapper.Object) {
+				continue
+			}
+			recreateBudget--
+			err = r.recreateDriftedTarget(ctx, xsetObject, wrapper.Object)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(drifted) > 0 {
+		sort.Strings(drifted)
+		conditions.Set(syncContext.NewStatus, api.XSetDriftDetected, metav1.ConditionTrue, conditions.ReasonTargetsDrifted,
+			fmt.Sprintf("%d target(s) drifted from template: %s", len(drifted), truncateList(drifted)), xsetObject.GetGeneration())
+	} else {
+		conditions.Set(syncContext.NewStatus, api.XSetDriftDetected, metav1.ConditionFalse, conditions.ReasonNoDrift, "no target drifted from template", xsetObject.GetGeneration())
+	}
+	remediating := len(drifted) > 0 && (policy.Remediation == api.DriftRemediationPatchBack || policy.Remediation == api.DriftRemediationRecreate)
+	if len(errs) > 0 || remediating {
+		r.driftChecks.Delete(key)
+	} else {
+		r.driftChecks.Store(key, driftCheck{generation: xsetObject.GetGeneration(), time: time.Now()})
+	}
+	return errors.Join(errs...)
+}
+
+// countUnavailableTargets counts desired replicas which are not available, including targets missing or terminating
+func (r *RealSyncControl) countUnavailableTargets(xsetObject api.XSetObject, syncContext *SyncContext) int {
+	available := 0
+	for _, wrapper := range syncContext.TargetWrappers {
+		if wrapper.Object != nil && !wrapper.PlaceHolder && wrapper.GetDeletionTimestamp() == nil && IsTargetAvailable(r.xsetController, wrapper.Object) {
+			available++
+		}
+	}
+	return max(int(ptr.Deref(r.xsetController.GetXSetSpec(xsetObject).Replicas, 0))-available, 0)
+}
+
+// isDriftCheckable checks whether target is expected to match its template, which is not the case for targets being
+// updated, scaled in, replaced, excluded or quarantined
+func (r *RealSyncControl) isDriftCheckable(wrapper *TargetWrapper) bool {
+	if wrapper.Object == nil || wrapper.PlaceHolder || wrapper.GetDeletionTimestamp() != nil {
+		return false
+	}
+	if wrapper.IsDuringScaleInOps || wrapper.IsDuringUpdateOps || wrapper.ToDelete || wrapper.ToExclude {
+		return false
+	}
+	for _, key := range []api.XSetLabelAnnotationEnum{api.XReplaceIndicationLabelKey, api.XReplacePairNewId, api.XReplacePairOriginName, api.XQuarantinedLabelKey} {
+		if _, exist := r.xsetLabelAnnoMgr.Get(wrapper.Object, key); exist {
+			return false
+		}
+	}
+	return true
+}
+
+// renderDesiredTarget renders target from revision with template patches and decorations applied, as it is created
+func (r *RealSyncControl) renderDesiredTarget(ctx context.Context, xsetObject api.XSetObject, wrapper *TargetWrapper, revision *appsv1.ControllerRevision) (client.Object, error) {
+	return NewTargetFrom(r.xsetController, r.xsetLabelAnnoMgr, xsetObject, revision, wrapper.ID,
+		r.subsetLabeler(wrapper.ContextDetail),
+		GetTemplatePatcher(r.xsetController, xsetObject),
+		func(object client.Object) error {
+			decorationAdapter, ok := GetDecorationAdapter(r.xsetController)
+			if !ok {
+				return nil
+			}
+			fn, err := decorationAdapter.GetDecorationPatcherByRevisions(ctx, r.Client, wrapper.Object, wrapper.DecorationCurrentRevisions)
+			if err != nil {
+				return err
+			}
+			return fn(object)
+		},
+	)
+}
+
+// patchBackDrifts patches drifted fields of target back to template by json patch, which fails if target is modified
+// since it is listed
+func (r *RealSyncControl) patchBackDrifts(ctx context.Context, xsetObject api.XSetObject, target client.Object, drifts []driftField) error {
+	ops := []map[string]interface{}{{"op": "test", "path": "/metadata/resourceVersion", "value": target.GetResourceVersion()}}
+	for _, drift := range drifts {
+		op := "replace"
+		if drift.missing {
+			op = "add"
+		}
+		ops = append(ops, map[string]interface{}{"op": op, "path": drift.pointer, "value": drift.value})
+	}
+	data, err := json.Marshal(ops)
+	if err != nil {
+		return err
+	}
+	if err := r.xControl.PatchTarget(ctx, target, client.RawPatch(types.JSONPatchType, data)); err != nil {
+		return fmt.Errorf("fail to patch back drifted target %s: %w", target.GetName(), err)
+	}
+	r.Recorder.Eventf(xsetObject, corev1.EventTypeNormal, "DriftPatchedBack", "drifted target %s/%s is patched back to template", target.GetNamespace(), target.GetName())
+	return r.cacheExpectations.ExpectUpdation(clientutil.ObjectKeyString(xsetObject), r.targetGVK, target.GetNamespace(), target.GetName(), target.GetResourceVersion())
+}
+
+// recreateDriftedTarget deletes target, and its ID is then used to create target from the same revision by scaling
+func (r *RealSyncControl) recreateDriftedTarget(ctx context.Context, xsetObject api.XSetObject, target client.Object) error {
+	if err := r.xControl.DeleteTarget(ctx, target, targetDeleteOptions(target, nil)...); err != nil {
+		return fmt.Errorf("fail to delete drifted target %s: %w", target.GetName(), err)
+	}
+	r.Recorder.Eventf(xsetObject, corev1.EventTypeNormal, "DriftRecreated", "drifted target %s/%s is deleted to recreate", target.GetNamespace(), target.GetName())
+	return r.cacheExpectations.ExpectDeletion(clientutil.ObjectKeyString(xsetObject), r.targetGVK, target.GetNamespace(), target.GetName())
+}
+
+// findTargetDrifts compares labels, annotations and fields except metadata and status of target with those rendered
+// in template. Fields only in target are not drifts, and elements of lists whose elements are all named, like
+// containers, are matched by name, so that elements added by others are not drifts either.
+func findTargetDrifts(desired, actual client.Object, ignorePaths [][]pathToken) ([]driftField, error) {
+	desiredMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return nil, err
+	}
+	actualMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(actual)
+	if err != nil {
+		return nil, err
+	}
+	delete(desiredMap, "apiVersion")
+	delete(desiredMap, "kind")
+	delete(desiredMap, "status")
+	desiredMap["metadata"] = map[string]interface{}{"labels": desired.GetLabels(), "annotations": desired.GetAnnotations()}
+	if len(desired.GetAnnotations()) == 0 {
+		delete(desiredMap["metadata"].(map[string]interface{}), "annotations")
+	}
+	// round trip of json normalizes labels and annotations into the same types as the others
+	data, err := json.Marshal(desiredMap)
+	if err != nil {
+		return nil, err
+	}
+	desiredMap = map[string]interface{}{}
+	if err := json.Unmarshal(data, &desiredMap); err != nil {
+		return nil, err
+	}
+	if data, err = json.Marshal(actualMap); err != nil {
+		return nil, err
+	}
+	actualMap = map[string]interface{}{}
+	if err := json.Unmarshal(data, &actualMap); err != nil {
+		return nil, err
+	}
+
+	var drifts []driftField
+	for _, drift := range findDrifts(desiredMap, actualMap, nil, "", nil) {
+		if !slices.ContainsFunc(ignorePaths, func(ignorePath []pathToken) bool { return matchFieldPath(ignorePath, drift.path) }) {
+			drifts = append(drifts, drift)
+		}
+	}
+	return drifts, nil
+}
+
+func findDrifts(desired, actual interface{}, path []pathToken, pointer string, drifts []driftField) []driftField {
+	switch desiredValue := desired.(type) {
+	case nil:
+		// fields not rendered
+		return drifts
+	case map[string]interface{}:
+		actualValue, ok := actual.(map[string]interface{})
+		if !ok {
+			return append(drifts, driftField{path: path, pointer: pointer, value: desired})
+		}
+		keys := make([]string, 0, len(desiredValue))
+		for key := range desiredValue {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := append(slices.Clip(path), pathToken{value: key})
+			childPointer := pointer + "/" + escapeJSONPointer(key)
+			if _, exist := actualValue[key]; !exist {
+				if desiredValue[key] != nil {
+					drifts = append(drifts, driftField{path: childPath, pointer: childPointer, value: desiredValue[key], missing: true})
+				}
+				continue
+			}
+			drifts = findDrifts(desiredValue[key], actualValue[key], childPath, childPointer, drifts)
+		}
+		return drifts
+	case []interface{}:
+		actualValue, ok := actual.([]interface{})
+		if !ok {
+			return append(drifts, driftField{path: path, pointer: pointer, value: desired})
+		}
+		if isNamedList(desiredValue) {
+			indexes := map[string]int{}
+			for i, element := range actualValue {
+				if name, ok := elementName(element); ok {
+					indexes[name] = i
+				}
+			}
+			for _, element := range desiredValue {
+				name, _ := elementName(element)
+				i, exist := indexes[name]
+				if !exist {
+					drifts = append(drifts, driftField{path: append(slices.Clip(path), pathToken{value: "name=" + name, index: true}),
+						pointer: pointer + "/-", value: element, missing: true})
+					continue
+				}
+				drifts = findDrifts(element, actualValue[i], append(slices.Clip(path), pathToken{value: strconv.Itoa(i), index: true}),
+					pointer+"/"+strconv.Itoa(i), drifts)
+			}
+			return drifts
+		}
+		if len(desiredValue) != len(actualValue) {
+			return append(drifts, driftField{path: path, pointer: pointer, value: desired})
+		}
+		for i := range desiredValue {
+			drifts = findDrifts(desiredValue[i], actualValue[i], append(slices.Clip(path), pathToken{value: strconv.Itoa(i), index: true}),
+				pointer+"/"+strconv.Itoa(i), drifts)
+		}
+		return drifts
+	default:
+		if !reflect.DeepEqual(desired, actual) {
+			return append(drifts, driftField{path: path, pointer: pointer, value: desired})
+		}
+		return drifts
+	}
+}
+
+func isNamedList(list []interface{}) bool {
+	if len(list) == 0 {
+		return false
+	}
+	for _, element := range list {
+		if _, ok := elementName(element); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func elementName(element interface{}) (string, bool) {
+	object, ok := element.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	name, ok := object["name"].(string)
+	return name, ok
+}
+
+func escapeJSONPointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// parseFieldPath parses JSONPath of a field, e.g., ".spec.containers[*].env" or "{.metadata.labels['app']}"
+func parseFieldPath(path string) ([]pathToken, error) {
+	path = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(path), "{"), "}")
+	var tokens []pathToken
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			end := i + 1
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			if end == i+1 {
+				return nil, fmt.Errorf("empty field name at %d", i)
+			}
+			tokens = append(tokens, pathToken{value: path[i+1 : end]})
+			i = end
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed bracket at %d", i)
+			}
+			inner := path[i+1 : i+end]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				tokens = append(tokens, pathToken{value: inner[1 : len(inner)-1]})
+			} else if _, err := strconv.Atoi(inner); err == nil || inner == "*" {
+				tokens = append(tokens, pathToken{value: inner, index: true})
+			} else {
+				return nil, fmt.Errorf("invalid subscript %q", inner)
+			}
+			i += end + 1
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", path[i], i)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty path")
+	}
+	return tokens, nil
+}
+
+// matchFieldPath checks whether path is or is under pattern, in which "*" matches any key or index
+func matchFieldPath(pattern, path []pathToken) bool {
+	if len(pattern) > len(path) {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i].value != "*" && pattern[i].value != path[i].value {
+			return false
+		}
+	}
+	return true
+}
+
+func formatFieldPath(path []pathToken) string {
+	var b strings.Builder
+	for _, token := range path {
+		switch {
+		case token.index:
+			b.WriteString("[" + token.value + "]")
+		case strings.ContainsAny(token.value, "./[]'"):
+			b.WriteString("['" + token.value + "']")
+		default:
+			b.WriteString("." + token.value)
+		}
+	}
+	return b.String()
+}
+
+func formatDrifts(drifts []driftField) string {
+	paths := make([]string, 0, len(drifts))
+	for _, drift := range drifts {
+		paths = append(paths, formatFieldPath(drift.path))
+	}
+	return truncateList(paths)
+}
+
+func truncateList(items []string) string {
+	if len(items) > maxDriftsReported {
+		return fmt.Sprintf("%s and %d more", strings.Join(items[:maxDriftsReported], ", "), len(items)-maxDriftsReported)
+	}
+	return strings.Join(items, ", ")
+}
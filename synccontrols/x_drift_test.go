@@ -0,0 +1,302 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+	"kusionstack.io/kube-xset/testing/fake"
+)
+
+func TestParseFieldPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []pathToken
+		wantErr bool
+	}{
+		{path: ".spec.containers[*].env", want: []pathToken{{value: "spec"}, {value: "containers"}, {value: "*", index: true}, {value: "env"}}},
+		{path: "{.metadata.annotations['sidecar.istio.io/status']}", want: []pathToken{{value: "metadata"}, {value: "annotations"}, {value: "sidecar.istio.io/status"}}},
+		{path: ".spec.volumes[0]", want: []pathToken{{value: "spec"}, {value: "volumes"}, {value: "0", index: true}}},
+		{path: "spec.containers", wantErr: true},
+		{path: ".spec[name]", wantErr: true},
+		{path: ".spec..containers", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := parseFieldPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFieldPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFieldPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindTargetDrifts(t *testing.T) {
+	desired := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "foo"}, Annotations: map[string]string{"team": "bar"}},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "app:v1", Args: []string{"--port", "80"}}},
+		},
+	}
+	actual := desired.DeepCopy()
+	actual.ResourceVersion = "10"
+	actual.Labels["injected"] = "true"
+	actual.Annotations["sidecar.istio.io/status"] = "{}"
+	actual.Spec.NodeName = "node-1"
+	actual.Spec.Containers[0].TerminationMessagePath = "/dev/termination-log"
+	actual.Spec.Containers = append([]corev1.Container{{Name: "istio-proxy", Image: "proxy:v1"}}, actual.Spec.Containers...)
+	actual.Status.Phase = corev1.PodRunning
+
+	// fields defaulted or added by others are not drifts
+	drifts, err := findTargetDrifts(desired, actual, nil)
+	if err != nil || len(drifts) != 0 {
+		t.Fatalf("expect no drift, got %v, %v", formatDrifts(drifts), err)
+	}
+
+	actual.Labels["app"] = "baz"
+	delete(actual.Annotations, "team")
+	actual.Spec.Containers[1].Image = "app:debug"
+	actual.Spec.Containers[1].Args = []string{"--port", "8080"}
+	drifts, err = findTargetDrifts(desired, actual, nil)
+	if err != nil {
+		t.Fatalf("findTargetDrifts() = %v", err)
+	}
+	if got, want := formatDrifts(drifts), ".metadata.annotations.team, .metadata.labels.app, .spec.containers[1].args[1], .spec.containers[1].image"; got != want {
+		t.Errorf("got drifts %s, want %s", got, want)
+	}
+	if !drifts[0].missing || drifts[0].pointer != "/metadata/annotations/team" || drifts[3].pointer != "/spec/containers/1/image" || drifts[3].value != "app:v1" {
+		t.Errorf("got unexpected drifts to patch back: %+v", drifts)
+	}
+
+	// ignored fields and fields under them are not drifts
+	ignorePaths := make([][]pathToken, 0, 2)
+	for _, path := range []string{".spec.containers[*].args", ".metadata.labels"} {
+		tokens, _ := parseFieldPath(path)
+		ignorePaths = append(ignorePaths, tokens)
+	}
+	drifts, _ = findTargetDrifts(desired, actual, ignorePaths)
+	if got, want := formatDrifts(drifts), ".metadata.annotations.team, .spec.containers[1].image"; got != want {
+		t.Errorf("got drifts %s, want %s", got, want)
+	}
+
+	// containers removed are added back
+	actual.Spec.Containers = actual.Spec.Containers[:1]
+	drifts, _ = findTargetDrifts(desired, actual, ignorePaths)
+	if got := formatDrifts(drifts); got != ".metadata.annotations.team, .spec.containers[name=app]" || drifts[1].pointer != "/spec/containers/-" {
+		t.Errorf("got drifts %s", got)
+	}
+}
+
+// driftController uses Pod as both XSet and target, whose targets are rendered from a fixed template
+type driftController struct {
+	api.XSetController
+	policy *api.DriftDetectionPolicy
+}
+
+func (c *driftController) XMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+}
+
+func (c *driftController) XSetMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+}
+
+func (c *driftController) GetXSetSpec(api.XSetObject) *api.XSetSpec {
+	return &api.XSetSpec{Replicas: ptr.To[int32](3)}
+}
+
+func (c *driftController) GetXObjectFromRevision(*appsv1.ControllerRevision) (client.Object, error) {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "foo"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}},
+	}, nil
+}
+
+func (c *driftController) GetXSetTemplatePatcher(metav1.Object) func(client.Object) error {
+	return func(client.Object) error { return nil }
+}
+
+func (c *driftController) CheckAvailable(object client.Object) bool {
+	return object.(*corev1.Pod).Status.Phase == corev1.PodRunning
+}
+
+func (c *driftController) GetDriftDetectionPolicy(api.XSetObject) *api.DriftDetectionPolicy {
+	return c.policy
+}
+
+func TestRemediateDrift(t *testing.T) {
+	labelAnnoMgr := api.NewXSetLabelAnnotationManager(nil)
+	xsetController := &driftController{policy: &api.DriftDetectionPolicy{Remediation: api.DriftRemediationRecreate, MaxUnavailable: 2}}
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", UID: "foo-uid", Generation: 1}}
+	revision := &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: "foo-1"}}
+
+	// foo-1 and foo-2 are drifted, and foo-2 is not available
+	var targets []client.Object
+	for id, image := range []string{"app:v1", "app:v2", "app:v2"} {
+		target, err := NewTargetFrom(xsetController, labelAnnoMgr, xset, revision, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pod := target.(*corev1.Pod)
+		pod.Name = fmt.Sprintf("foo-%d", id)
+		pod.Spec.Containers[0].Image = image
+		if id != 2 {
+			pod.Status.Phase = corev1.PodRunning
+		}
+		targets = append(targets, pod)
+	}
+	targetControl := fake.NewTargetControl(xsetController, targets...)
+	recorder := record.NewFakeRecorder(100)
+	r := &RealSyncControl{
+		xsetController:    xsetController,
+		xsetLabelAnnoMgr:  labelAnnoMgr,
+		xControl:          targetControl,
+		cacheExpectations: &noopExpectations{},
+	}
+	r.Recorder = recorder
+	newSyncContext := func() *SyncContext {
+		syncContext := &SyncContext{Revisions: []*appsv1.ControllerRevision{revision}, NewStatus: &api.XSetStatus{}}
+		for _, target := range targets {
+			syncContext.TargetWrappers = append(syncContext.TargetWrappers, &TargetWrapper{Object: target})
+		}
+		return syncContext
+	}
+	driftedEvents := func() int {
+		count := 0
+		for len(recorder.Events) > 0 {
+			if strings.Contains(<-recorder.Events, "TargetDrifted") {
+				count++
+			}
+		}
+		return count
+	}
+
+	// only foo-1 is recreated, since foo-2 is not available and leaves budget of one target only
+	syncContext := newSyncContext()
+	if err := r.RemediateDrift(context.TODO(), xset, syncContext); err != nil {
+		t.Fatalf("RemediateDrift() = %v", err)
+	}
+	calls := targetControl.CallsOf("DeleteTarget")
+	if len(calls) != 1 || calls[0].Args[0].(client.Object).GetName() != "foo-1" {
+		t.Fatalf("got %d deletions, want foo-1 recreated only", len(calls))
+	}
+	if !conditions.IsTrue(syncContext.NewStatus, api.XSetDriftDetected) {
+		t.Errorf("got condition %v, want DriftDetected true", conditions.Get(syncContext.NewStatus, api.XSetDriftDetected))
+	}
+	if count := driftedEvents(); count != 2 {
+		t.Errorf("got %d TargetDrifted events, want 2", count)
+	}
+
+	// drifted targets being remediated are checked again, without events while condition is unchanged
+	status := syncContext.NewStatus
+	syncContext = newSyncContext()
+	syncContext.NewStatus = status
+	xsetController.policy.MaxUnavailable = 1
+	if err := r.RemediateDrift(context.TODO(), xset, syncContext); err != nil {
+		t.Fatalf("RemediateDrift() = %v", err)
+	}
+	if calls := targetControl.CallsOf("DeleteTarget"); len(calls) != 1 {
+		t.Errorf("got %d deletions, want no target recreated out of budget", len(calls))
+	}
+	if count := driftedEvents(); count != 0 {
+		t.Errorf("got %d TargetDrifted events, want none while condition is unchanged", count)
+	}
+
+	// targets only reported are not checked again within interval until XSet changes
+	xsetController.policy.Remediation = api.DriftRemediationReport
+	targets = targets[:1]
+	syncContext = newSyncContext()
+	if err := r.RemediateDrift(context.TODO(), xset, syncContext); err != nil || conditions.IsTrue(syncContext.NewStatus, api.XSetDriftDetected) {
+		t.Fatalf("RemediateDrift() = %v, want no drift", err)
+	}
+	targets[0].(*corev1.Pod).Spec.Containers[0].Image = "app:v2"
+	syncContext = newSyncContext()
+	if err := r.RemediateDrift(context.TODO(), xset, syncContext); err != nil || conditions.Get(syncContext.NewStatus, api.XSetDriftDetected) != nil {
+		t.Fatalf("RemediateDrift() = %v, want drift check skipped within interval", err)
+	}
+	xset.Generation++
+	if err := r.RemediateDrift(context.TODO(), xset, syncContext); err != nil || !conditions.IsTrue(syncContext.NewStatus, api.XSetDriftDetected) {
+		t.Fatalf("RemediateDrift() = %v, want drift detected once XSet changes", err)
+	}
+}
+
+// hashExclusionDriftController excludes argocd labels from revisions, whose template is labeled with a newer value
+type hashExclusionDriftController struct {
+	driftController
+}
+
+func (c *hashExclusionDriftController) GetRevisionHashExcludedKeys(api.XSetObject) []string {
+	return []string{"argocd.argoproj.io/*"}
+}
+
+func (c *hashExclusionDriftController) GetXSetPatch(metav1.Object) ([]byte, error) {
+	return []byte(`{"spec":{"template":{"metadata":{"labels":{"app":"foo","argocd.argoproj.io/instance":"v2"}}}}}`), nil
+}
+
+func TestRemediateDriftWithRevisionHashExclusion(t *testing.T) {
+	labelAnnoMgr := api.NewXSetLabelAnnotationManager(nil)
+	xsetController := &hashExclusionDriftController{driftController{policy: &api.DriftDetectionPolicy{}}}
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", UID: "foo-uid", Generation: 1}}
+	revision := &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: "foo-1"}}
+	target, err := NewTargetFrom(xsetController, labelAnnoMgr, xset, revision, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// target is created with the excluded label in template of that time
+	target.SetName("foo-0")
+	target.GetLabels()["argocd.argoproj.io/instance"] = "v1"
+	r := &RealSyncControl{xsetController: xsetController, xsetLabelAnnoMgr: labelAnnoMgr}
+	r.Recorder = record.NewFakeRecorder(10)
+
+	syncContext := &SyncContext{
+		Revisions:      []*appsv1.ControllerRevision{revision},
+		TargetWrappers: []*TargetWrapper{{Object: target}},
+		NewStatus:      &api.XSetStatus{},
+	}
+	if err := r.RemediateDrift(context.TODO(), xset, syncContext); err != nil {
+		t.Fatalf("RemediateDrift() = %v", err)
+	}
+	if !conditions.IsFalse(syncContext.NewStatus, api.XSetDriftDetected) {
+		t.Errorf("got condition %v, want excluded label not regarded as drift", conditions.Get(syncContext.NewStatus, api.XSetDriftDetected))
+	}
+
+	// labels not excluded are still compared
+	target.GetLabels()["app"] = "bar"
+	xset.Generation++
+	if err := r.RemediateDrift(context.TODO(), xset, syncContext); err != nil {
+		t.Fatalf("RemediateDrift() = %v", err)
+	}
+	if cond := conditions.Get(syncContext.NewStatus, api.XSetDriftDetected); cond.Status != metav1.ConditionTrue || !strings.Contains(cond.Message, "foo-0") {
+		t.Errorf("got condition %v, want foo-0 drifted", cond)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	clientutil "kusionstack.io/kube-utils/client"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+const (
+	// DefaultEvictionFallbackTimeout is the default duration eviction is blocked before falling back
+	DefaultEvictionFallbackTimeout = 10 * time.Minute
+	// EvictionBlockedRequeueInterval is the duration after which eviction blocked by PodDisruptionBudget is retried
+	EvictionBlockedRequeueInterval = 10 * time.Second
+)
+
+// errEvictionBlocked is returned if eviction of target is blocked by PodDisruptionBudget, which is retried by requeue
+// instead of being reported as failure
+var errEvictionBlocked = errors.New("eviction is blocked")
+
+// getPodEviction returns how Pod targets are evicted, or nil if PodEvictionAdapter is not implemented
+func getPodEviction(setController api.XSetController, owner api.XSetObject) *api.PodEviction {
+	adapter, ok := setController.(api.PodEvictionAdapter)
+	if !ok {
+		return nil
+	}
+	return adapter.GetPodEviction(owner)
+}
+
+// evictOrDeleteTarget evicts Pod target if PodEvictionAdapter is enabled, otherwise deletes target directly. If
+// eviction is blocked, the time it is first blocked is recorded on target, and errEvictionBlocked is returned to retry
+// later, until fallback timeout expires and target is deleted directly with Delete fallback policy.
+func evictOrDeleteTarget(ctx context.Context, config *UpdateConfig, owner api.XSetObject, target client.Object, gracePeriodSeconds *int64) error {
+	eviction := getPodEviction(config.XsetController, owner)
+	pod, isPod := target.(*corev1.Pod)
+	if eviction == nil || !isPod {
+		return config.TargetControl.DeleteTarget(ctx, target, targetDeleteOptions(target, gracePeriodSeconds)...)
+	}
+	if config.KubeClient == nil {
+		return fmt.Errorf("fail to evict target %s/%s: kube client is not available", pod.Namespace, pod.Name)
+	}
+
+	blockedSince, blocked := evictionBlockedSince(pod)
+	if blocked && eviction.FallbackPolicy == api.EvictionFallbackDelete && time.Since(blockedSince) >= evictionFallbackTimeout(eviction) {
+		config.Recorder.Eventf(pod, corev1.EventTypeWarning, "EvictionFallback",
+			"eviction has been blocked since %s, target is deleted directly", blockedSince.Format(time.RFC3339))
+		return config.TargetControl.DeleteTarget(ctx, target, targetDeleteOptions(target, gracePeriodSeconds)...)
+	}
+
+	err := config.KubeClient.PolicyV1().Evictions(pod.Namespace).Evict(ctx, &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name},
+		DeleteOptions: &metav1.DeleteOptions{
+			GracePeriodSeconds: gracePeriodSeconds,
+			Preconditions:      ptr.To(targetPreconditions(pod)),
+		},
+	})
+	if err == nil || apierrors.IsNotFound(err) {
+		return nil
+	}
+	if !apierrors.IsTooManyRequests(err) {
+		return fmt.Errorf("fail to evict target %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+
+	if !blocked {
+		if patchErr := config.TargetControl.PatchTargetWithOptimisticLock(ctx, pod, func(target client.Object) {
+			annotations := target.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[api.TargetEvictionBlockedAnnotationKey] = time.Now().UTC().Format(time.RFC3339)
+			target.SetAnnotations(annotations)
+		}); patchErr != nil {
+			return patchErr
+		}
+		if expectErr := config.CacheExpectations.ExpectUpdation(clientutil.ObjectKeyString(owner), config.TargetGVK, pod.Namespace, pod.Name, pod.ResourceVersion); expectErr != nil {
+			return expectErr
+		}
+		config.Recorder.Eventf(pod, corev1.EventTypeWarning, "EvictionBlocked", "eviction is blocked: %v", err)
+	}
+	return fmt.Errorf("%w for target %s/%s: %v", errEvictionBlocked, pod.Namespace, pod.Name, err)
+}
+
+// evictionBlockedSince returns the time eviction of pod is first blocked, and false if it is not blocked
+func evictionBlockedSince(pod *corev1.Pod) (time.Time, bool) {
+	value, ok := pod.Annotations[api.TargetEvictionBlockedAnnotationKey]
+	if !ok {
+		return time.Time{}, false
+	}
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return since, true
+}
+
+func evictionFallbackTimeout(eviction *api.PodEviction) time.Duration {
+	if eviction.FallbackTimeout > 0 {
+		return eviction.FallbackTimeout
+	}
+	return DefaultEvictionFallbackTimeout
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/testing/fake"
+)
+
+type evictionController struct {
+	api.XSetController
+	eviction *api.PodEviction
+}
+
+func (c *evictionController) XMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+}
+
+func (c *evictionController) GetPodEviction(api.XSetObject) *api.PodEviction {
+	return c.eviction
+}
+
+func TestEvictOrDeleteTarget(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-0", UID: "foo-0-uid", ResourceVersion: "1"}}
+	kubeClient := kubefake.NewSimpleClientset(pod)
+	kubeClient.PrependReactor("create", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewTooManyRequests("disruption budget exceeded", 10)
+	})
+	xsetController := &evictionController{eviction: &api.PodEviction{FallbackPolicy: api.EvictionFallbackDelete, FallbackTimeout: time.Minute}}
+	targetControl := fake.NewTargetControl(xsetController, pod.DeepCopy())
+	config := &UpdateConfig{
+		XsetController:    xsetController,
+		TargetControl:     targetControl,
+		Recorder:          record.NewFakeRecorder(10),
+		CacheExpectations: &noopExpectations{},
+		KubeClient:        kubeClient,
+	}
+	key := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}
+
+	// blocked eviction is recorded on target and retried
+	target, _ := targetControl.GetTarget(key)
+	if err := evictOrDeleteTarget(context.TODO(), config, &corev1.Pod{}, target, nil); !errors.Is(err, errEvictionBlocked) {
+		t.Fatalf("evictOrDeleteTarget() = %v, want eviction blocked", err)
+	}
+	target, _ = targetControl.GetTarget(key)
+	if _, blocked := evictionBlockedSince(target.(*corev1.Pod)); !blocked {
+		t.Fatalf("expect eviction blocked time recorded on target")
+	}
+	if calls := targetControl.CallsOf("DeleteTarget"); len(calls) != 0 {
+		t.Fatalf("expect target not deleted before fallback timeout, got %d deletions", len(calls))
+	}
+
+	// target is deleted directly once fallback timeout expires
+	target.SetAnnotations(map[string]string{api.TargetEvictionBlockedAnnotationKey: time.Now().Add(-2 * time.Minute).UTC().Format(time.RFC3339)})
+	if err := evictOrDeleteTarget(context.TODO(), config, &corev1.Pod{}, target, nil); err != nil {
+		t.Fatalf("evictOrDeleteTarget() = %v", err)
+	}
+	if calls := targetControl.CallsOf("DeleteTarget"); len(calls) != 1 {
+		t.Fatalf("expect target deleted after fallback timeout, got %d deletions", len(calls))
+	}
+	// target is deleted only if it is not recreated since listed, whatever it is modified
+	deleteOpts := &client.DeleteOptions{}
+	deleteOpts.ApplyOptions(targetControl.CallsOf("DeleteTarget")[0].Args[1].([]client.DeleteOption))
+	if preconditions := deleteOpts.Preconditions; preconditions == nil || ptr.Deref(preconditions.UID, "") != pod.UID ||
+		preconditions.ResourceVersion != nil {
+		t.Errorf("expect deletion preconditioned on UID of target only, got %v", preconditions)
+	}
+}
+
+func TestEvictTargetWithoutFallback(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "default",
+		Name:        "foo-0",
+		UID:         "foo-0-uid",
+		Annotations: map[string]string{api.TargetEvictionBlockedAnnotationKey: time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)},
+	}}
+	kubeClient := kubefake.NewSimpleClientset(pod)
+	kubeClient.PrependReactor("create", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewTooManyRequests("disruption budget exceeded", 10)
+	})
+	xsetController := &evictionController{eviction: &api.PodEviction{FallbackPolicy: api.EvictionFallbackRetry, FallbackTimeout: time.Minute}}
+	targetControl := fake.NewTargetControl(xsetController, pod.DeepCopy())
+	config := &UpdateConfig{
+		XsetController:    xsetController,
+		TargetControl:     targetControl,
+		Recorder:          record.NewFakeRecorder(10),
+		CacheExpectations: &noopExpectations{},
+	}
+	target, _ := targetControl.GetTarget(types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name})
+
+	// target is never deleted directly without kube client to evict it
+	if err := evictOrDeleteTarget(context.TODO(), config, &corev1.Pod{}, target, nil); err == nil || errors.Is(err, errEvictionBlocked) {
+		t.Errorf("evictOrDeleteTarget() without kube client = %v, want error", err)
+	}
+
+	// eviction is retried after fallback timeout with Retry fallback policy
+	config.KubeClient = kubeClient
+	if err := evictOrDeleteTarget(context.TODO(), config, &corev1.Pod{}, target, nil); !errors.Is(err, errEvictionBlocked) {
+		t.Errorf("evictOrDeleteTarget() = %v, want eviction blocked", err)
+	}
+	if calls := targetControl.CallsOf("DeleteTarget"); len(calls) != 0 {
+		t.Errorf("expect target not deleted with Retry fallback policy, got %d deletions", len(calls))
+	}
+}
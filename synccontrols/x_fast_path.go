@@ -0,0 +1,156 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+	"kusionstack.io/kube-xset/opslifecycle"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+// labels indicating targets are to be synced by xset
+var syncIndicationLabels = []api.XSetLabelAnnotationEnum{
+	api.XSetUpdateIndicationLabelKey,
+	api.XDeletionIndicationLabelKey,
+	api.XReplaceIndicationLabelKey,
+	api.XReplacePairNewId,
+	api.XReplacePairOriginName,
+	api.XExcludeIndicationLabelKey,
+	api.XOrphanedIndicationLabelKey,
+}
+
+// CanSkipSync returns true if nothing is to be synced for instance, so that reconcile only recalculates status without
+// Replace, Scale, Update and listing PVCs. It is decided conservatively: status of last reconcile shows the revision of
+// current generation has rolled out, and targets in cache are exactly the desired replicas of updated revision and are
+// neither operated nor indicated to be synced. Targets listed are set to syncContext for status calculation.
+func (r *RealSyncControl) CanSkipSync(ctx context.Context, instance api.XSetObject, syncContext *SyncContext) (bool, error) {
+	if instance.GetDeletionTimestamp() != nil || !r.isSyncSkippable(instance) {
+		return false, nil
+	}
+	spec := r.xsetController.GetXSetSpec(instance)
+	if spec == nil {
+		return false, fmt.Errorf("fail to get XSetSpec")
+	}
+	if len(spec.ScaleStrategy.TargetToDelete) > 0 || len(spec.ScaleStrategy.TargetToExclude) > 0 || len(spec.ScaleStrategy.TargetToInclude) > 0 {
+		return false, nil
+	}
+	if !isRolloutComplete(r.xsetController.GetXSetStatus(instance), instance.GetGeneration(), syncContext.UpdatedRevision.GetName()) {
+		return false, nil
+	}
+
+	if syncContext.TargetSnapshot == nil {
+		return false, nil
+	}
+	filteredTargets, allTargets, err := syncContext.TargetSnapshot.GetFilteredTargets(ctx)
+	if err != nil {
+		return false, fmt.Errorf("fail to get filtered Targets: %w", err)
+	}
+	if len(allTargets) != len(filteredTargets) || len(filteredTargets) != int(ptr.Deref(spec.Replicas, 0)) {
+		return false, nil
+	}
+
+	evictionRequestKeys, _ := getDeschedulingReplace(r.xsetController, instance)
+	ids := make(map[int]struct{}, len(filteredTargets))
+	targetWrappers := make([]*TargetWrapper, 0, len(filteredTargets))
+	for _, target := range filteredTargets {
+		if target.GetDeletionTimestamp() != nil || !IsTargetUpdatedRevision(target, syncContext.UpdatedRevision.GetName()) || r.isTargetToSync(target) ||
+			isEvictionRequested(target, evictionRequestKeys) {
+			return false, nil
+		}
+		id, err := xcontrol.GetInstanceID(r.xsetLabelAnnoMgr, target)
+		if err != nil || id < 0 {
+			return false, nil
+		}
+		if _, duplicated := ids[id]; duplicated {
+			return false, nil
+		}
+		ids[id] = struct{}{}
+		targetWrappers = append(targetWrappers, &TargetWrapper{Object: target, ID: id})
+	}
+
+	syncContext.FilteredTarget = filteredTargets
+	syncContext.TargetWrappers = targetWrappers
+	syncContext.SyncSkipped = true
+	return true, nil
+}
+
+// isSyncSkippable returns true if skipping sync is opted in by SyncSkipAdapter, and xset is not synced with inputs
+// beyond its generation, targets' sync indication labels or status
+func (r *RealSyncControl) isSyncSkippable(instance api.XSetObject) bool {
+	adapter, ok := r.xsetController.(api.SyncSkipAdapter)
+	if !ok || !adapter.EnableSyncSkip(instance) {
+		return false
+	}
+	if len(r.subresourceControls) > 0 || len(GetDecorationAdapters(r.xsetController)) > 0 {
+		return false
+	}
+	if getDriftDetectionPolicy(r.xsetController, instance) != nil {
+		return false
+	}
+	switch r.xsetController.(type) {
+	case api.TemplatePatchAdapter, api.PostCreateHookAdapter, api.PostDeleteHookAdapter,
+		api.StickyNodeAdapter, api.ZonePlacementAdapter, api.InstanceStatusesAdapter, api.CrashLoopRemediationAdapter, api.NodeFailureReplaceAdapter:
+		return false
+	}
+	return true
+}
+
+// isTargetToSync returns true if target is during ops or indicated to be synced by xset
+func (r *RealSyncControl) isTargetToSync(target client.Object) bool {
+	if opslifecycle.IsDuringOps(r.xsetLabelAnnoMgr, r.scaleInLifecycleAdapter, target) ||
+		opslifecycle.IsDuringOps(r.xsetLabelAnnoMgr, r.updateLifecycleAdapter, target) {
+		return true
+	}
+	for _, label := range syncIndicationLabels {
+		if _, exist := r.xsetLabelAnnoMgr.Get(target, label); exist {
+			return true
+		}
+	}
+	return false
+}
+
+// isRolloutComplete returns true if status shows updated revision of generation has rolled out without failures,
+// and no condition is waiting for sync, e.g., blocked or expanding pvcs
+func isRolloutComplete(status *api.XSetStatus, generation int64, updatedRevision string) bool {
+	if status == nil || status.ObservedGeneration != generation ||
+		status.UpdatedRevision != updatedRevision || status.CurrentRevision != updatedRevision ||
+		len(status.FailedCreations) > 0 || status.PendingPvcCount > 0 || status.LostPvcCount > 0 {
+		return false
+	}
+	progressing := conditions.Get(status, api.XSetProgressing)
+	if progressing == nil || progressing.Reason != conditions.ReasonRolloutComplete || progressing.ObservedGeneration != generation {
+		return false
+	}
+	for _, condType := range []api.XSetConditionType{api.XSetPvcExpansion, api.XSetPvcDeletionBlocked, api.XSetPoolExhausted, api.XSetReplicaFailure} {
+		if conditions.IsTrue(status, condType) {
+			return false
+		}
+	}
+	for _, condType := range []api.XSetConditionType{api.XSetScale, api.XSetUpdate} {
+		if conditions.IsFalse(status, condType) {
+			return false
+		}
+	}
+	return true
+}
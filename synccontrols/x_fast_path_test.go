@@ -0,0 +1,205 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+	"kusionstack.io/kube-xset/opslifecycle"
+	"kusionstack.io/kube-xset/subresources"
+	"kusionstack.io/kube-xset/testing/fake"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+const fastPathRevision = "rev-2"
+
+type fastPathController struct {
+	api.XSetController
+	spec   *api.XSetSpec
+	status *api.XSetStatus
+	skip   bool
+}
+
+func (c *fastPathController) XSetMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "apps.kusionstack.io/v1alpha1", Kind: "XSet"}
+}
+
+func (c *fastPathController) GetXSetSpec(api.XSetObject) *api.XSetSpec { return c.spec }
+
+func (c *fastPathController) GetXSetStatus(api.XSetObject) *api.XSetStatus { return c.status }
+
+func (c *fastPathController) EnableSyncSkip(api.XSetObject) bool { return c.skip }
+
+type stickyFastPathController struct {
+	*fastPathController
+}
+
+func (c *stickyFastPathController) StickToRecordedNode(api.XSetObject) bool { return true }
+
+type listTargetControl struct {
+	xcontrol.TargetControl
+	targets []client.Object
+}
+
+func (c *listTargetControl) GetFilteredTargets(context.Context, *metav1.LabelSelector, api.XSetObject) ([]client.Object, []client.Object, error) {
+	return c.targets, c.targets, nil
+}
+
+func rolledOutStatus(generation int64) *api.XSetStatus {
+	return &api.XSetStatus{
+		ObservedGeneration: generation,
+		CurrentRevision:    fastPathRevision,
+		UpdatedRevision:    fastPathRevision,
+		Conditions: []metav1.Condition{
+			{Type: string(api.XSetProgressing), Status: metav1.ConditionFalse, Reason: conditions.ReasonRolloutComplete, ObservedGeneration: generation},
+			{Type: string(api.XSetScale), Status: metav1.ConditionTrue},
+		},
+	}
+}
+
+func TestIsRolloutComplete(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(status *api.XSetStatus)
+		want   bool
+	}{
+		{name: "rolled out", mutate: func(*api.XSetStatus) {}, want: true},
+		{name: "generation not observed", mutate: func(s *api.XSetStatus) { s.ObservedGeneration = 1 }},
+		{name: "updated revision changed", mutate: func(s *api.XSetStatus) { s.UpdatedRevision = "rev-3" }},
+		{name: "current revision not updated", mutate: func(s *api.XSetStatus) { s.CurrentRevision = "rev-1" }},
+		{name: "failed creations", mutate: func(s *api.XSetStatus) { s.FailedCreations = []api.CreationFailure{{}} }},
+		{name: "pending pvcs", mutate: func(s *api.XSetStatus) { s.PendingPvcCount = 1 }},
+		{name: "progressing", mutate: func(s *api.XSetStatus) { s.Conditions[0].Reason = "Updating" }},
+		{name: "progressing of old generation", mutate: func(s *api.XSetStatus) { s.Conditions[0].ObservedGeneration = 1 }},
+		{name: "pvc expanding", mutate: func(s *api.XSetStatus) {
+			s.Conditions = append(s.Conditions, metav1.Condition{Type: string(api.XSetPvcExpansion), Status: metav1.ConditionTrue})
+		}},
+		{name: "scale failed", mutate: func(s *api.XSetStatus) { s.Conditions[1].Status = metav1.ConditionFalse }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := rolledOutStatus(2)
+			tt.mutate(status)
+			if got := isRolloutComplete(status, 2, fastPathRevision); got != tt.want {
+				t.Errorf("isRolloutComplete() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if isRolloutComplete(nil, 2, fastPathRevision) {
+		t.Errorf("isRolloutComplete() of nil status = true")
+	}
+}
+
+func newFastPathTarget(id int) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "default",
+		Name:      "foo-" + strconv.Itoa(id),
+		UID:       types.UID("uid-" + strconv.Itoa(id)),
+		Labels: map[string]string{
+			appsv1.ControllerRevisionHashLabelKey:                                 fastPathRevision,
+			api.NewXSetLabelAnnotationManager(nil).Value(api.XInstanceIdLabelKey): strconv.Itoa(id),
+		},
+	}}
+}
+
+func TestCanSkipSync(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(c *fastPathController, targets []client.Object)
+		sticky bool
+		// subresources makes xset manage per-instance subresources
+		subresources bool
+		want         bool
+	}{
+		{name: "steady", mutate: func(*fastPathController, []client.Object) {}, want: true},
+		{name: "not opted in", mutate: func(c *fastPathController, _ []client.Object) { c.skip = false }},
+		{name: "stateful adapter", mutate: func(*fastPathController, []client.Object) {}, sticky: true},
+		{name: "subresources", mutate: func(*fastPathController, []client.Object) {}, subresources: true},
+		{name: "rollout not complete", mutate: func(c *fastPathController, _ []client.Object) { c.status.CurrentRevision = "rev-1" }},
+		{name: "scaling", mutate: func(c *fastPathController, _ []client.Object) { c.spec.Replicas = ptr.To[int32](3) }},
+		{name: "targets to delete", mutate: func(c *fastPathController, _ []client.Object) {
+			c.spec.ScaleStrategy.TargetToDelete = []string{"foo-0"}
+		}},
+		{name: "target of old revision", mutate: func(_ *fastPathController, targets []client.Object) {
+			targets[0].GetLabels()[appsv1.ControllerRevisionHashLabelKey] = "rev-1"
+		}},
+		{name: "target indicated to replace", mutate: func(_ *fastPathController, targets []client.Object) {
+			targets[0].GetLabels()[api.NewXSetLabelAnnotationManager(nil).Value(api.XReplaceIndicationLabelKey)] = "true"
+		}},
+		{name: "duplicated id", mutate: func(_ *fastPathController, targets []client.Object) {
+			targets[1].GetLabels()[api.NewXSetLabelAnnotationManager(nil).Value(api.XInstanceIdLabelKey)] = "0"
+		}},
+		{name: "terminating target", mutate: func(_ *fastPathController, targets []client.Object) {
+			targets[1].SetDeletionTimestamp(&metav1.Time{})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := &fastPathController{
+				spec:   &api.XSetSpec{Replicas: ptr.To[int32](2)},
+				status: rolledOutStatus(2),
+				skip:   true,
+			}
+			targets := []client.Object{newFastPathTarget(0), newFastPathTarget(1)}
+			tt.mutate(controller, targets)
+
+			var xsetController api.XSetController = controller
+			if tt.sticky {
+				xsetController = &stickyFastPathController{fastPathController: controller}
+			}
+			labelAnnoMgr := api.GetXSetLabelAnnotationManager(xsetController)
+			updateLifecycleAdapter, scaleInLifecycleAdapter := opslifecycle.GetLifecycleAdapters(xsetController, labelAnnoMgr, controller.XSetMeta())
+			r := &RealSyncControl{
+				xsetController:          xsetController,
+				xsetLabelAnnoMgr:        labelAnnoMgr,
+				scaleInLifecycleAdapter: scaleInLifecycleAdapter,
+				updateLifecycleAdapter:  updateLifecycleAdapter,
+			}
+			if tt.subresources {
+				r.subresourceControls = []subresources.SubresourceControl{fake.NewSubresourceControl()}
+			}
+			xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", Generation: 2}}
+			syncContext := &SyncContext{
+				UpdatedRevision: &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: fastPathRevision}},
+				TargetSnapshot:  xcontrol.NewTargetSnapshot(&listTargetControl{targets: targets}, nil, xset),
+			}
+
+			got, err := r.CanSkipSync(context.TODO(), xset, syncContext)
+			if err != nil {
+				t.Fatalf("CanSkipSync() = %v", err)
+			}
+			if got != tt.want || syncContext.SyncSkipped != tt.want {
+				t.Fatalf("CanSkipSync() = %v, SyncSkipped = %v, want %v", got, syncContext.SyncSkipped, tt.want)
+			}
+			if got && (len(syncContext.TargetWrappers) != 2 || len(syncContext.FilteredTarget) != 2) {
+				t.Errorf("CanSkipSync() sets %d target wrappers and %d filtered targets, want 2",
+					len(syncContext.TargetWrappers), len(syncContext.FilteredTarget))
+			}
+		})
+	}
+}
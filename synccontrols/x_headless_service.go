@@ -0,0 +1,154 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// getHeadlessService returns a copy of the governing headless Service of owner with name defaulted, or nil if disabled
+func getHeadlessService(setController api.XSetController, owner api.XSetObject) *api.HeadlessService {
+	adapter, ok := setController.(api.HeadlessServiceAdapter)
+	if !ok {
+		return nil
+	}
+	headless := adapter.GetHeadlessService(owner)
+	if headless == nil {
+		return nil
+	}
+	service := *headless
+	service.Ports = make([]corev1.ServicePort, len(headless.Ports))
+	for i := range headless.Ports {
+		headless.Ports[i].DeepCopyInto(&service.Ports[i])
+	}
+	if service.Name == "" {
+		service.Name = owner.GetName()
+	}
+	return &service
+}
+
+// GetInstanceHostname returns the hostname of Pod target with instance ID, in form of <xset>-<id>, which is
+// truncated to fit in a DNS label
+func GetInstanceHostname(owner api.XSetObject, id int) string {
+	suffix := fmt.Sprintf("-%d", id)
+	prefix := strings.ReplaceAll(owner.GetName(), ".", "-")
+	if len(prefix)+len(suffix) > validation.DNS1123LabelMaxLength {
+		prefix = strings.TrimRight(prefix[:validation.DNS1123LabelMaxLength-len(suffix)], "-")
+	}
+	return prefix + suffix
+}
+
+// injectHostname sets hostname and subdomain of Pod target from its instance ID, if governed by headless Service
+func injectHostname(setController api.XSetController, owner api.XSetObject, pod *corev1.Pod, id int) {
+	service := getHeadlessService(setController, owner)
+	if service == nil {
+		return
+	}
+	pod.Spec.Hostname = GetInstanceHostname(owner, id)
+	pod.Spec.Subdomain = service.Name
+}
+
+// syncHeadlessService creates the governing headless Service of xset if missing, and keeps its ports and selector
+// consistent with XSet. Service of the same name not controlled by xset is left untouched.
+func (r *RealSyncControl) syncHeadlessService(ctx context.Context, xsetObject api.XSetObject) error {
+	headless := getHeadlessService(r.xsetController, xsetObject)
+	if headless == nil || xsetObject.GetDeletionTimestamp() != nil {
+		return nil
+	}
+	if msgs := validation.IsDNS1035Label(headless.Name); len(msgs) > 0 {
+		return fmt.Errorf("invalid headless Service name %s: %s", headless.Name, strings.Join(msgs, ", "))
+	}
+	ports := defaultServicePorts(headless.Ports)
+	spec := r.xsetController.GetXSetSpec(xsetObject)
+	if spec.Selector == nil || len(spec.Selector.MatchLabels) == 0 {
+		return fmt.Errorf("headless Service %s requires matchLabels in selector", headless.Name)
+	}
+
+	service := &corev1.Service{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: xsetObject.GetNamespace(), Name: headless.Name}, service)
+	if apierrors.IsNotFound(err) {
+		service = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       xsetObject.GetNamespace(),
+				Name:            headless.Name,
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(xsetObject, r.xsetGVK)},
+			},
+			Spec: corev1.ServiceSpec{
+				ClusterIP:                corev1.ClusterIPNone,
+				Selector:                 spec.Selector.MatchLabels,
+				Ports:                    ports,
+				PublishNotReadyAddresses: headless.PublishNotReadyAddresses,
+			},
+		}
+		if err := r.Client.Create(ctx, service); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("fail to create headless Service %s: %w", headless.Name, err)
+		}
+		r.Recorder.Eventf(xsetObject, corev1.EventTypeNormal, "HeadlessServiceCreated", "created headless Service %s", headless.Name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("fail to get headless Service %s: %w", headless.Name, err)
+	}
+
+	if ownerRef := metav1.GetControllerOf(service); ownerRef == nil || ownerRef.UID != xsetObject.GetUID() {
+		r.Recorder.Eventf(xsetObject, corev1.EventTypeWarning, "HeadlessServiceConflict", "Service %s exists and is not controlled by %s", headless.Name, xsetObject.GetName())
+		return nil
+	}
+	if service.Spec.ClusterIP != corev1.ClusterIPNone {
+		return fmt.Errorf("service %s is not headless, clusterIP %s", headless.Name, service.Spec.ClusterIP)
+	}
+	if equality.Semantic.DeepEqual(service.Spec.Selector, spec.Selector.MatchLabels) &&
+		equality.Semantic.DeepEqual(service.Spec.Ports, ports) &&
+		service.Spec.PublishNotReadyAddresses == headless.PublishNotReadyAddresses {
+		return nil
+	}
+	service.Spec.Selector = spec.Selector.MatchLabels
+	service.Spec.Ports = ports
+	service.Spec.PublishNotReadyAddresses = headless.PublishNotReadyAddresses
+	if err := r.Client.Update(ctx, service); err != nil {
+		return fmt.Errorf("fail to update headless Service %s: %w", headless.Name, err)
+	}
+	return nil
+}
+
+// defaultServicePorts defaults protocol and targetPort of ports as api server does, so that they are comparable with
+// ports of existing Service
+func defaultServicePorts(ports []corev1.ServicePort) []corev1.ServicePort {
+	defaulted := make([]corev1.ServicePort, len(ports))
+	for i, port := range ports {
+		if port.Protocol == "" {
+			port.Protocol = corev1.ProtocolTCP
+		}
+		if port.TargetPort.Type == intstr.Int && port.TargetPort.IntVal == 0 {
+			port.TargetPort = intstr.FromInt(int(port.Port))
+		}
+		defaulted[i] = port
+	}
+	return defaulted
+}
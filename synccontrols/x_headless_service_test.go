@@ -0,0 +1,144 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+type headlessServiceController struct {
+	api.XSetController
+	service *api.HeadlessService
+}
+
+func (c *headlessServiceController) GetXSetSpec(api.XSetObject) *api.XSetSpec {
+	return &api.XSetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}}}
+}
+
+func (c *headlessServiceController) GetHeadlessService(api.XSetObject) *api.HeadlessService {
+	return c.service
+}
+
+func TestGetInstanceHostname(t *testing.T) {
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo.bar"}}
+	if got := GetInstanceHostname(xset, 3); got != "foo-bar-3" {
+		t.Errorf("GetInstanceHostname() = %s, want foo-bar-3", got)
+	}
+	xset.Name = strings.Repeat("a", 61) + "-b"
+	if got := GetInstanceHostname(xset, 12); got != strings.Repeat("a", 60)+"-12" {
+		t.Errorf("GetInstanceHostname() of long name = %s, want truncated to a DNS label", got)
+	}
+}
+
+func TestGetHeadlessService(t *testing.T) {
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+	if service := getHeadlessService(&headlessServiceController{}, xset); service != nil {
+		t.Errorf("getHeadlessService() = %v, want nil if disabled", service)
+	}
+
+	controller := &headlessServiceController{service: &api.HeadlessService{Ports: []corev1.ServicePort{{Name: "http", Port: 80}}}}
+	service := getHeadlessService(controller, xset)
+	if service == nil || service.Name != "foo" {
+		t.Fatalf("getHeadlessService() = %v, want name defaulted to foo", service)
+	}
+	service.Ports[0].Port = 8080
+	if controller.service.Name != "" || controller.service.Ports[0].Port != 80 {
+		t.Errorf("headless Service of adapter is modified to %v", controller.service)
+	}
+}
+
+func TestSyncHeadlessService(t *testing.T) {
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", UID: "foo-uid"}}
+	xsetGVK := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	controller := &headlessServiceController{service: &api.HeadlessService{Ports: []corev1.ServicePort{{Name: "http", Port: 80}}}}
+	c := clientfake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+	r := &RealSyncControl{xsetController: controller, xsetGVK: xsetGVK}
+	r.Client = c
+	r.Recorder = record.NewFakeRecorder(10)
+
+	getService := func() *corev1.Service {
+		service := &corev1.Service{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "foo"}, service); err != nil {
+			t.Fatal(err)
+		}
+		return service
+	}
+
+	// service is created with ports defaulted
+	if err := r.syncHeadlessService(context.TODO(), xset); err != nil {
+		t.Fatalf("syncHeadlessService() = %v", err)
+	}
+	service := getService()
+	if service.Spec.ClusterIP != corev1.ClusterIPNone || service.Spec.Selector["app"] != "foo" {
+		t.Errorf("service spec = %v, want headless Service selecting app=foo", service.Spec)
+	}
+	if port := service.Spec.Ports[0]; port.Protocol != corev1.ProtocolTCP || port.TargetPort != intstr.FromInt(80) {
+		t.Errorf("service port = %v, want protocol and targetPort defaulted", port)
+	}
+	if ownerRef := metav1.GetControllerOf(service); ownerRef == nil || ownerRef.UID != xset.UID {
+		t.Errorf("service is not controlled by xset")
+	}
+
+	// service in place is left as it is
+	resourceVersion := service.ResourceVersion
+	if err := r.syncHeadlessService(context.TODO(), xset); err != nil {
+		t.Fatalf("syncHeadlessService() = %v", err)
+	}
+	if service = getService(); service.ResourceVersion != resourceVersion {
+		t.Errorf("service in place is updated")
+	}
+
+	// ports changed are synced to service
+	controller.service.Ports = append(controller.service.Ports, corev1.ServicePort{Name: "peer", Port: 7000})
+	controller.service.PublishNotReadyAddresses = true
+	if err := r.syncHeadlessService(context.TODO(), xset); err != nil {
+		t.Fatalf("syncHeadlessService() = %v", err)
+	}
+	if service = getService(); len(service.Spec.Ports) != 2 || !service.Spec.PublishNotReadyAddresses {
+		t.Errorf("service spec = %v, want ports and publishNotReadyAddresses synced", service.Spec)
+	}
+
+	// service not controlled by xset is left untouched
+	other := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", UID: "bar-uid"}}
+	resourceVersion = service.ResourceVersion
+	controller.service.Ports = controller.service.Ports[:1]
+	if err := r.syncHeadlessService(context.TODO(), other); err != nil {
+		t.Fatalf("syncHeadlessService() = %v", err)
+	}
+	if service = getService(); service.ResourceVersion != resourceVersion {
+		t.Errorf("service not controlled by xset is updated")
+	}
+
+	// invalid name is rejected
+	controller.service.Name = "Foo_Bar"
+	if err := r.syncHeadlessService(context.TODO(), xset); err == nil {
+		t.Errorf("syncHeadlessService() = nil, want error of invalid name")
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"kusionstack.io/kube-xset/resourcecontexts"
+)
+
+const (
+	// HookBackoffBase is the backoff before a failed hook is called again, which is doubled on each failure
+	HookBackoffBase = 5 * time.Second
+	// HookBackoffMax is the max backoff before a failed hook is called again
+	HookBackoffMax = 10 * time.Minute
+	// HookPollInterval is the interval after which xset is reconciled again to collect results of running hooks
+	HookPollInterval = 2 * time.Second
+	// hookResultTTL is the duration after which results never collected, e.g., of targets gone, are dropped
+	hookResultTTL = 30 * time.Minute
+)
+
+// hookBackoff returns the backoff before a hook is called again after failing count times
+func hookBackoff(count int32) time.Duration {
+	backoff := HookBackoffBase
+	for i := int32(1); i < count && backoff < HookBackoffMax; i++ {
+		backoff *= 2
+	}
+	return min(backoff, HookBackoffMax)
+}
+
+// hookState is the state of PostCreate or PostDelete hook of a target recorded in ContextDetail, in form of <uid>,
+// <uid>/<failures>/<unix seconds of last failure> while hook keeps failing, or <uid>/done once hook succeeded.
+// PostCreate hook records <uid> once succeeded, while PostDelete hook records <uid> once target is tracked.
+type hookState struct {
+	uid         string
+	done        bool
+	failures    int32
+	lastFailure time.Time
+}
+
+func parseHookState(value string) hookState {
+	if uid, done := strings.CutSuffix(value, resourcecontexts.PostDeleteDoneSuffix); done {
+		return hookState{uid: uid, done: true}
+	}
+	parts := strings.Split(value, "/")
+	state := hookState{uid: parts[0]}
+	if len(parts) != 3 {
+		return state
+	}
+	if failures, err := strconv.ParseInt(parts[1], 10, 32); err == nil {
+		state.failures = int32(failures)
+	}
+	if lastFailure, err := strconv.ParseInt(parts[2], 10, 64); err == nil {
+		state.lastFailure = time.Unix(lastFailure, 0)
+	}
+	return state
+}
+
+func (s hookState) String() string {
+	switch {
+	case s.done:
+		return s.uid + resourcecontexts.PostDeleteDoneSuffix
+	case s.failures == 0:
+		return s.uid
+	default:
+		return fmt.Sprintf("%s/%d/%d", s.uid, s.failures, s.lastFailure.Unix())
+	}
+}
+
+// hookRunner calls hooks in background goroutines out of the reconcile path, and keeps their results until they
+// are collected by the following reconciles
+type hookRunner struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	workers chan struct{}
+	calls   map[string]*hookCall
+}
+
+type hookCall struct {
+	finished   bool
+	err        error
+	finishedAt time.Time
+}
+
+func newHookRunner(timeout time.Duration, maxConcurrent int) *hookRunner {
+	return &hookRunner{
+		timeout: timeout,
+		workers: make(chan struct{}, maxConcurrent),
+		calls:   map[string]*hookCall{},
+	}
+}
+
+// start calls fn with timeout in background, unless a call of key is running or not collected yet
+func (h *hookRunner) start(key string, fn func(ctx context.Context) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, exist := h.calls[key]; exist {
+		return
+	}
+	for k, call := range h.calls {
+		if call.finished && time.Since(call.finishedAt) > hookResultTTL {
+			delete(h.calls, k)
+		}
+	}
+	call := &hookCall{}
+	h.calls[key] = call
+
+	go func() {
+		h.workers <- struct{}{}
+		defer func() { <-h.workers }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+		err := fn(ctx)
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		call.finished, call.err, call.finishedAt = true, err, time.Now()
+	}()
+}
+
+// collect returns the result of call of key and forgets it if the call is finished. started is false if no call of
+// key is running or finished.
+func (h *hookRunner) collect(key string) (started, finished bool, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	call, exist := h.calls[key]
+	if !exist {
+		return false, false, nil
+	}
+	if !call.finished {
+		return true, false, nil
+	}
+	delete(h.calls, key)
+	return true, true, call.err
+}
+
+// step collects the result of hook call of state into it if the call is finished, or calls hook in background
+// unless the call is running or backing off. collected is true if state is changed, recheckAfter is the duration after
+// which state should be stepped again, and err is the error returned by the collected call.
+func (h *hookRunner) step(state *hookState, hook func(ctx context.Context) error) (collected bool, recheckAfter time.Duration, err error) {
+	started, finished, err := h.collect(state.uid)
+	switch {
+	case finished && err != nil:
+		state.failures++
+		state.lastFailure = time.Now()
+		return true, hookBackoff(state.failures), err
+	case finished:
+		state.failures = 0
+		return true, 0, nil
+	case started:
+		return false, HookPollInterval, nil
+	}
+	if state.failures > 0 {
+		if remaining := time.Until(state.lastFailure.Add(hookBackoff(state.failures))); remaining > 0 {
+			return false, remaining, nil
+		}
+	}
+	h.start(state.uid, hook)
+	return false, HookPollInterval, nil
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestHookState(t *testing.T) {
+	lastFailure := time.Unix(1700000000, 0)
+	for value, want := range map[string]hookState{
+		"foo-uid":              {uid: "foo-uid"},
+		"foo-uid/done":         {uid: "foo-uid", done: true},
+		"foo-uid/3/1700000000": {uid: "foo-uid", failures: 3, lastFailure: lastFailure},
+		"":                     {},
+	} {
+		got := parseHookState(value)
+		if got != want {
+			t.Errorf("parseHookState(%q) = %+v, want %+v", value, got, want)
+		}
+		if got.String() != value {
+			t.Errorf("hookState %+v formats %q, want %q", got, got.String(), value)
+		}
+	}
+}
+
+func TestHookBackoff(t *testing.T) {
+	for count, want := range map[int32]time.Duration{
+		1:  HookBackoffBase,
+		2:  2 * HookBackoffBase,
+		3:  4 * HookBackoffBase,
+		20: HookBackoffMax,
+	} {
+		if got := hookBackoff(count); got != want {
+			t.Errorf("hookBackoff(%d) = %v, want %v", count, got, want)
+		}
+	}
+}
+
+func TestHookRunner(t *testing.T) {
+	runner := newHookRunner(time.Second, 1)
+	release := make(chan struct{})
+	var calls atomic.Int32
+	hook := func(ctx context.Context) error {
+		calls.Add(1)
+		<-release
+		return errors.New("unavailable")
+	}
+
+	if started, _, _ := runner.collect("foo"); started {
+		t.Fatal("collect() reports started before hook is called")
+	}
+	runner.start("foo", hook)
+	runner.start("foo", hook)
+	if started, finished, _ := runner.collect("foo"); !started || finished {
+		t.Fatalf("collect() = %v, %v, want running", started, finished)
+	}
+
+	close(release)
+	var err error
+	if pollErr := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		_, finished, hookErr := runner.collect("foo")
+		err = hookErr
+		return finished, nil
+	}); pollErr != nil {
+		t.Fatalf("hook is not finished: %v", pollErr)
+	}
+	if err == nil || calls.Load() != 1 {
+		t.Errorf("got err %v after %d call(s), want hook called once and its error collected", err, calls.Load())
+	}
+	if started, _, _ := runner.collect("foo"); started {
+		t.Error("collect() reports started after result is collected")
+	}
+}
+
+func TestHookRunnerStep(t *testing.T) {
+	runner := newHookRunner(time.Second, 1)
+	hookErr := make(chan error)
+	hook := func(context.Context) error { return <-hookErr }
+	state := hookState{uid: "foo-uid"}
+	stepUntilCollected := func() (time.Duration, error) {
+		var recheckAfter time.Duration
+		var err error
+		if pollErr := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			var collected bool
+			collected, recheckAfter, err = runner.step(&state, hook)
+			return collected, nil
+		}); pollErr != nil {
+			t.Fatalf("hook result is not collected: %v", pollErr)
+		}
+		return recheckAfter, err
+	}
+
+	if collected, recheckAfter, _ := runner.step(&state, hook); collected || recheckAfter != HookPollInterval {
+		t.Fatalf("step() = %v, %v, want hook started and polled", collected, recheckAfter)
+	}
+	hookErr <- errors.New("unavailable")
+	if recheckAfter, err := stepUntilCollected(); err == nil || recheckAfter != HookBackoffBase || state.failures != 1 {
+		t.Fatalf("got err %v, recheck %v, state %+v, want failure recorded", err, recheckAfter, state)
+	}
+
+	// hook is not called during backoff
+	if collected, recheckAfter, _ := runner.step(&state, hook); collected || recheckAfter <= HookPollInterval || recheckAfter > HookBackoffBase {
+		t.Fatalf("step() = %v, %v, want backing off", collected, recheckAfter)
+	}
+	state.lastFailure = time.Now().Add(-HookBackoffBase)
+	runner.step(&state, hook)
+	hookErr <- nil
+	if _, err := stepUntilCollected(); err != nil || state.failures != 0 {
+		t.Errorf("got err %v, state %+v, want hook succeeded", err, state)
+	}
+}
@@ -0,0 +1,274 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientutil "kusionstack.io/kube-utils/client"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+const (
+	// podResizePending and podResizeInProgress are Pod conditions reported by kubelet during resize
+	podResizePending    corev1.PodConditionType = "PodResizePending"
+	podResizeInProgress corev1.PodConditionType = "PodResizeInProgress"
+
+	// ResizeRejectedTTL is the duration for which targets of XSet are not resized once resize is rejected, after which
+	// resize is tried again, e.g., when InPlacePodVerticalScaling has been turned on since then
+	ResizeRejectedTTL = 30 * time.Minute
+)
+
+// resizableResources are resources of containers which can be resized in place
+var resizableResources = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+
+// resizeRejections records when resize is rejected by api server for each xset
+type resizeRejections struct {
+	mu sync.Mutex
+	// rejected maps key of xset to the time resize was rejected
+	rejected map[string]time.Time
+}
+
+func (r *resizeRejections) reject(key string, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rejected == nil {
+		r.rejected = map[string]time.Time{}
+	}
+	r.rejected[key] = now
+}
+
+// isRejected returns true if resize is rejected for xset within ResizeRejectedTTL, and drops rejections expired
+func (r *resizeRejections) isRejected(key string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, rejectedAt := range r.rejected {
+		if now.Sub(rejectedAt) >= ResizeRejectedTTL {
+			delete(r.rejected, k)
+		}
+	}
+	_, rejected := r.rejected[key]
+	return rejected
+}
+
+// isResizeUnsupported returns true if resize is rejected since InPlacePodVerticalScaling is off. Forbidden errors of
+// exceeded quota are transient, and not taken as unsupported.
+func isResizeUnsupported(err error) bool {
+	if apierrors.IsInvalid(err) {
+		return true
+	}
+	return apierrors.IsForbidden(err) && !strings.Contains(err.Error(), "exceeded quota")
+}
+
+// isInPlaceResizeEnabled returns true if targets of owner are resized in place, unless resize has been rejected
+func isInPlaceResizeEnabled(config *UpdateConfig, owner api.XSetObject) bool {
+	adapter, ok := config.XsetController.(api.InPlaceResizeAdapter)
+	return ok && config.KubeClient != nil && !config.resizeRejections.isRejected(clientutil.ObjectKeyString(owner), time.Now()) &&
+		adapter.EnableInPlaceResize(owner)
+}
+
+// inPlaceResizeTargetUpdater resizes containers of Pod targets in place if only cpu and memory are changed, and
+// updates other targets by the updater of update policy
+type inPlaceResizeTargetUpdater struct {
+	TargetUpdater
+
+	// generic recreates targets whose resize is rejected
+	generic GenericTargetUpdater
+	// resized caches targets resized from current revisions to updated revision, nil if not resize only
+	resized map[string]client.Object
+}
+
+func newInPlaceResizeTargetUpdater(updater TargetUpdater) TargetUpdater {
+	return &inPlaceResizeTargetUpdater{TargetUpdater: updater, resized: map[string]client.Object{}}
+}
+
+func (u *inPlaceResizeTargetUpdater) Setup(config *UpdateConfig, xset api.XSetObject) {
+	u.TargetUpdater.Setup(config, xset)
+	u.generic.Setup(config, xset)
+}
+
+func (u *inPlaceResizeTargetUpdater) FulfillTargetUpdatedInfo(ctx context.Context, revision *appsv1.ControllerRevision, targetInfo *TargetUpdateInfo) error {
+	if isInPlaceResizeEnabled(u.generic.UpdateConfig, u.generic.OwnerObject) && targetInfo.CurrentRevision != nil {
+		resized, cached := u.resized[targetInfo.CurrentRevision.GetName()]
+		if !cached {
+			resized = u.resizeOnly(targetInfo.CurrentRevision, revision)
+			u.resized[targetInfo.CurrentRevision.GetName()] = resized
+		}
+		if resized != nil {
+			if _, isPod := targetInfo.Object.(*corev1.Pod); isPod {
+				targetInfo.ResizeOnly = true
+				targetInfo.InPlaceUpdateSupport = true
+				targetInfo.UpdatedTarget = resized
+				return nil
+			}
+		}
+	}
+	return u.TargetUpdater.FulfillTargetUpdatedInfo(ctx, revision, targetInfo)
+}
+
+func (u *inPlaceResizeTargetUpdater) UpgradeTarget(ctx context.Context, targetInfo *TargetUpdateInfo) error {
+	if !targetInfo.ResizeOnly {
+		return u.TargetUpdater.UpgradeTarget(ctx, targetInfo)
+	}
+	err := u.resize(ctx, targetInfo)
+	if isResizeUnsupported(err) {
+		// InPlacePodVerticalScaling is off, recreate target and stop resizing others of xset for a while
+		u.generic.resizeRejections.reject(clientutil.ObjectKeyString(u.generic.OwnerObject), time.Now())
+		u.generic.Recorder.Eventf(targetInfo.Object, corev1.EventTypeWarning, "ResizeRejected", "resize is rejected and target is recreated: %v", err)
+		return u.generic.RecreateTarget(ctx, targetInfo)
+	}
+	return err
+}
+
+func (u *inPlaceResizeTargetUpdater) GetTargetUpdateFinishStatus(ctx context.Context, targetInfo *TargetUpdateInfo) (bool, string, error) {
+	if pod, ok := targetInfo.Object.(*corev1.Pod); ok {
+		for _, condition := range pod.Status.Conditions {
+			if (condition.Type == podResizePending || condition.Type == podResizeInProgress) && condition.Status == corev1.ConditionTrue {
+				return false, fmt.Sprintf("%s: %s", condition.Type, condition.Message), nil
+			}
+		}
+	}
+	return u.TargetUpdater.GetTargetUpdateFinishStatus(ctx, targetInfo)
+}
+
+// resizeOnly returns the target of updated revision if only resizable resources of containers are changed from
+// current revision, and QoS class is kept, otherwise nil
+func (u *inPlaceResizeTargetUpdater) resizeOnly(current, updated *appsv1.ControllerRevision) client.Object {
+	if current == nil || updated == nil || current.GetName() == UnknownRevision || current.GetName() == updated.GetName() {
+		return nil
+	}
+	currentObj, err := u.generic.XsetController.GetXObjectFromRevision(current)
+	if err != nil {
+		return nil
+	}
+	updatedObj, err := u.generic.XsetController.GetXObjectFromRevision(updated)
+	if err != nil {
+		return nil
+	}
+	currentPod, ok := currentObj.(*corev1.Pod)
+	if !ok {
+		return nil
+	}
+	updatedPod, ok := updatedObj.(*corev1.Pod)
+	if !ok || len(currentPod.Spec.Containers) != len(updatedPod.Spec.Containers) {
+		return nil
+	}
+	if getPodQOS(currentPod) != getPodQOS(updatedPod) {
+		return nil
+	}
+	currentStripped, updatedStripped := stripResizableResources(currentPod), stripResizableResources(updatedPod)
+	if !equality.Semantic.DeepEqual(currentStripped.Spec, updatedStripped.Spec) ||
+		!equality.Semantic.DeepEqual(currentStripped.Labels, updatedStripped.Labels) ||
+		!equality.Semantic.DeepEqual(currentStripped.Annotations, updatedStripped.Annotations) {
+		return nil
+	}
+	if equality.Semantic.DeepEqual(currentPod.Spec.Containers, updatedPod.Spec.Containers) {
+		return nil
+	}
+	return updatedPod
+}
+
+// resize patches resources of containers by resize subresource, or Pod spec if the subresource is not served,
+// then moves target to updated revision
+func (u *inPlaceResizeTargetUpdater) resize(ctx context.Context, targetInfo *TargetUpdateInfo) error {
+	updatedPod := targetInfo.UpdatedTarget.(*corev1.Pod)
+	containers := make([]map[string]interface{}, 0, len(updatedPod.Spec.Containers))
+	for i := range updatedPod.Spec.Containers {
+		containers = append(containers, map[string]interface{}{
+			"name":      updatedPod.Spec.Containers[i].Name,
+			"resources": updatedPod.Spec.Containers[i].Resources,
+		})
+	}
+	patch, err := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"containers": containers}})
+	if err != nil {
+		return err
+	}
+
+	pods := u.generic.KubeClient.CoreV1().Pods(targetInfo.GetNamespace())
+	patchOptions := metav1.PatchOptions{FieldManager: xcontrol.GetFieldManager(u.generic.XsetController)}
+	_, err = pods.Patch(ctx, targetInfo.GetName(), types.StrategicMergePatchType, patch, patchOptions, "resize")
+	if apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+		_, err = pods.Patch(ctx, targetInfo.GetName(), types.StrategicMergePatchType, patch, patchOptions)
+	}
+	if err != nil {
+		return fmt.Errorf("fail to resize target %s/%s: %w", targetInfo.GetNamespace(), targetInfo.GetName(), err)
+	}
+
+	if err := u.generic.TargetControl.PatchTargetWithOptimisticLock(ctx, targetInfo.Object, func(target client.Object) {
+		target.GetLabels()[appsv1.ControllerRevisionHashLabelKey] = targetInfo.UpdateRevision.GetName()
+	}); err != nil {
+		return fmt.Errorf("fail to update revision of resized target %s/%s: %w", targetInfo.GetNamespace(), targetInfo.GetName(), err)
+	}
+	if err := u.generic.CacheExpectations.ExpectUpdation(clientutil.ObjectKeyString(u.generic.OwnerObject), u.generic.TargetGVK, targetInfo.GetNamespace(), targetInfo.GetName(), targetInfo.GetResourceVersion()); err != nil {
+		return err
+	}
+	u.generic.Recorder.Eventf(targetInfo.Object, corev1.EventTypeNormal, "UpdateTarget",
+		"succeed to update Target %s/%s from revision %s to revision %s by in-place resize",
+		targetInfo.GetNamespace(), targetInfo.GetName(), targetInfo.CurrentRevision.GetName(), targetInfo.UpdateRevision.GetName())
+	return nil
+}
+
+// stripResizableResources returns a copy of pod without resizable resources of containers
+func stripResizableResources(pod *corev1.Pod) *corev1.Pod {
+	stripped := pod.DeepCopy()
+	for i := range stripped.Spec.Containers {
+		for _, name := range resizableResources {
+			delete(stripped.Spec.Containers[i].Resources.Requests, name)
+			delete(stripped.Spec.Containers[i].Resources.Limits, name)
+		}
+	}
+	return stripped
+}
+
+// getPodQOS returns QoS class of pod by its cpu and memory, following the rules of kubelet
+func getPodQOS(pod *corev1.Pod) corev1.PodQOSClass {
+	requested, limited, guaranteed := false, false, true
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, container := range containers {
+		for _, name := range resizableResources {
+			request, hasRequest := container.Resources.Requests[name]
+			limit, hasLimit := container.Resources.Limits[name]
+			requested = requested || (hasRequest && !request.IsZero())
+			limited = limited || (hasLimit && !limit.IsZero())
+			if !hasLimit || limit.IsZero() || (hasRequest && !request.Equal(limit)) {
+				guaranteed = false
+			}
+		}
+	}
+	switch {
+	case !requested && !limited:
+		return corev1.PodQOSBestEffort
+	case guaranteed:
+		return corev1.PodQOSGuaranteed
+	default:
+		return corev1.PodQOSBurstable
+	}
+}
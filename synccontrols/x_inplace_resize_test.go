@@ -0,0 +1,185 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/testing/fake"
+)
+
+type resizeController struct {
+	api.XSetController
+}
+
+func (c *resizeController) XMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+}
+
+func (c *resizeController) GetXSetSpec(api.XSetObject) *api.XSetSpec { return &api.XSetSpec{} }
+
+func (c *resizeController) EnableInPlaceResize(api.XSetObject) bool { return true }
+
+func TestGetPodQOS(t *testing.T) {
+	resources := func(requests, limits string) corev1.ResourceRequirements {
+		r := corev1.ResourceRequirements{}
+		if requests != "" {
+			r.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(requests), corev1.ResourceMemory: resource.MustParse(requests + "Gi")}
+		}
+		if limits != "" {
+			r.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(limits), corev1.ResourceMemory: resource.MustParse(limits + "Gi")}
+		}
+		return r
+	}
+	tests := []struct {
+		name      string
+		resources []corev1.ResourceRequirements
+		want      corev1.PodQOSClass
+	}{
+		{name: "no resources", resources: []corev1.ResourceRequirements{{}}, want: corev1.PodQOSBestEffort},
+		{name: "requests equal to limits", resources: []corev1.ResourceRequirements{resources("1", "1")}, want: corev1.PodQOSGuaranteed},
+		{name: "only limits", resources: []corev1.ResourceRequirements{resources("", "2")}, want: corev1.PodQOSGuaranteed},
+		{name: "requests less than limits", resources: []corev1.ResourceRequirements{resources("1", "2")}, want: corev1.PodQOSBurstable},
+		{name: "one container without limits", resources: []corev1.ResourceRequirements{resources("1", "1"), resources("1", "")}, want: corev1.PodQOSBurstable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{}
+			for _, r := range tt.resources {
+				pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Resources: r})
+			}
+			if got := getPodQOS(pod); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsResizeUnsupported(t *testing.T) {
+	pods := corev1.Resource("pods")
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "resized"},
+		{name: "invalid", err: apierrors.NewInvalid(schema.GroupKind{Kind: "Pod"}, "foo-0", nil), want: true},
+		{name: "forbidden", err: apierrors.NewForbidden(pods, "foo-0", errors.New("pod updates may not change fields other than image")), want: true},
+		{name: "exceeded quota", err: apierrors.NewForbidden(pods, "foo-0", errors.New("exceeded quota: compute, requested: cpu=2, used: cpu=1, limited: cpu=2"))},
+		{name: "conflict", err: apierrors.NewConflict(pods, "foo-0", errors.New("object has been modified"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isResizeUnsupported(tt.err); got != tt.want {
+				t.Errorf("isResizeUnsupported() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResizeRejections(t *testing.T) {
+	rejections := &resizeRejections{}
+	now := time.Now()
+	rejections.reject("default/foo", now)
+	if !rejections.isRejected("default/foo", now.Add(time.Minute)) {
+		t.Errorf("resize of default/foo is expected to be rejected")
+	}
+	if rejections.isRejected("default/bar", now.Add(time.Minute)) {
+		t.Errorf("resize of default/bar is not expected to be rejected by default/foo")
+	}
+	if rejections.isRejected("default/foo", now.Add(ResizeRejectedTTL)) {
+		t.Errorf("resize of default/foo is expected to be tried again after ResizeRejectedTTL")
+	}
+	if len(rejections.rejected) != 0 {
+		t.Errorf("expired rejections %v are not dropped", rejections.rejected)
+	}
+}
+
+func TestInPlaceResizeRejected(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantErr      bool
+		wantRejected bool
+	}{
+		{name: "resized"},
+		{name: "feature gate off", err: apierrors.NewInvalid(schema.GroupKind{Kind: "Pod"}, "foo-0", nil), wantRejected: true},
+		{name: "exceeded quota", err: apierrors.NewForbidden(corev1.Resource("pods"), "foo-0", errors.New("exceeded quota: compute")), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default", Name: "foo-0", UID: "foo-0-uid",
+				Labels: map[string]string{appsv1.ControllerRevisionHashLabelKey: "rev-1"},
+			}}
+			kubeClient := kubefake.NewSimpleClientset(pod)
+			kubeClient.PrependReactor("patch", "pods", func(clienttesting.Action) (bool, runtime.Object, error) {
+				return tt.err != nil, nil, tt.err
+			})
+			xsetController := &resizeController{}
+			targetControl := fake.NewTargetControl(xsetController, pod.DeepCopy())
+			config := &UpdateConfig{
+				XsetController:    xsetController,
+				TargetControl:     targetControl,
+				Recorder:          record.NewFakeRecorder(10),
+				CacheExpectations: &noopExpectations{},
+				KubeClient:        kubeClient,
+			}
+			xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+			other := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "bar"}}
+			updater := newInPlaceResizeTargetUpdater(&recreateTargetUpdater{})
+			updater.Setup(config, xset)
+
+			target, _ := targetControl.GetTarget(types.NamespacedName{Namespace: "default", Name: "foo-0"})
+			err := updater.UpgradeTarget(context.TODO(), &TargetUpdateInfo{
+				TargetWrapper:   &TargetWrapper{Object: target},
+				ResizeOnly:      true,
+				UpdatedTarget:   &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "main"}}}},
+				CurrentRevision: &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: "rev-1"}},
+				UpdateRevision:  &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: "rev-2"}},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpgradeTarget() = %v, want error %v", err, tt.wantErr)
+			}
+			if recreated := len(targetControl.CallsOf("DeleteTarget")) > 0; recreated != tt.wantRejected {
+				t.Errorf("target recreated = %v, want %v", recreated, tt.wantRejected)
+			}
+			if enabled := isInPlaceResizeEnabled(config, xset); enabled == tt.wantRejected {
+				t.Errorf("in-place resize of xset enabled = %v, want %v", enabled, !tt.wantRejected)
+			}
+			if !isInPlaceResizeEnabled(config, other) {
+				t.Errorf("in-place resize of other xset is disabled by rejection of xset")
+			}
+		})
+	}
+}
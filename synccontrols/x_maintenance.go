@@ -0,0 +1,125 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/api/validation"
+	"kusionstack.io/kube-xset/conditions"
+)
+
+// checkMaintenanceWindows decides whether disruptive actions are held by maintenance windows of XSet, which is done
+// once before Replace, Scale and Update. Invalid windows hold disruptive actions until they are fixed.
+func checkMaintenanceWindows(spec *api.XSetSpec, now time.Time, syncContext *SyncContext) {
+	syncContext.OutOfMaintenanceWindow = false
+	syncContext.RecheckMaintenanceWindowAfter = nil
+	syncContext.maintenanceWindowErr = nil
+	syncContext.nextMaintenanceWindow = time.Time{}
+	if len(spec.MaintenanceWindows) == 0 {
+		return
+	}
+	if errs := validation.ValidateMaintenanceWindows(spec.MaintenanceWindows, field.NewPath("spec", "maintenanceWindows")); len(errs) > 0 {
+		syncContext.OutOfMaintenanceWindow = true
+		syncContext.maintenanceWindowErr = errs.ToAggregate()
+		return
+	}
+	inWindow, next := inMaintenanceWindows(spec.MaintenanceWindows, now)
+	if inWindow {
+		return
+	}
+	syncContext.OutOfMaintenanceWindow = true
+	if !next.IsZero() {
+		syncContext.nextMaintenanceWindow = next
+		syncContext.RecheckMaintenanceWindowAfter = ptr.To(next.Sub(now))
+	}
+}
+
+// inMaintenanceWindows returns whether now is in any of windows, otherwise when the next window starts. Windows must
+// be validated.
+func inMaintenanceWindows(windows []api.MaintenanceWindow, now time.Time) (bool, time.Time) {
+	var next time.Time
+	for _, window := range windows {
+		location, _ := time.LoadLocation(window.TimeZone)
+		start, _ := time.Parse(validation.MaintenanceWindowStartLayout, window.Start)
+		local := now.In(location)
+		// windows started on past days may last till now
+		lookBack := int(window.Duration.Duration/(24*time.Hour)) + 1
+		for offset := -lookBack; offset <= 7; offset++ {
+			day := local.AddDate(0, 0, offset)
+			begin := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, location)
+			if !windowStartsOn(window, begin.Weekday()) {
+				continue
+			}
+			if !now.Before(begin) && now.Before(begin.Add(window.Duration.Duration)) {
+				return true, time.Time{}
+			}
+			if begin.After(now) && (next.IsZero() || begin.Before(next)) {
+				next = begin
+			}
+		}
+	}
+	return false, next
+}
+
+func windowStartsOn(window api.MaintenanceWindow, weekday time.Weekday) bool {
+	if len(window.Days) == 0 {
+		return true
+	}
+	for _, day := range window.Days {
+		if parsed, _ := validation.ParseWeekday(day); parsed == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// isRecreateUpdate checks whether target is deleted and recreated to update, the same as FilterAllowOpsTargets decides.
+// Targets updated by replace are not, since targets replaced are only deleted by Replace.
+func isRecreateUpdate(spec *api.XSetSpec, targetInfo *TargetUpdateInfo) bool {
+	if spec.UpdateStrategy.UpdatePolicy == api.XSetReplaceTargetUpdateStrategyType || targetInfo.IsInReplace {
+		return false
+	}
+	return spec.UpdateStrategy.UpdatePolicy == api.XSetRecreateTargetUpdateStrategyType ||
+		(!targetInfo.OnlyMetadataChanged && !targetInfo.InPlaceUpdateSupport)
+}
+
+// calculateMaintenanceWindowCondition sets WaitingForMaintenanceWindow condition if any disruptive action is held in
+// this reconcile, and XSet is only requeued for the next window in that case.
+func calculateMaintenanceWindowCondition(generation int64, syncContext *SyncContext, newStatus *api.XSetStatus) {
+	if syncContext.heldByMaintenanceWindow == 0 {
+		syncContext.RecheckMaintenanceWindowAfter = nil
+		conditions.Remove(newStatus, api.XSetWaitingForMaintenanceWindow)
+		return
+	}
+	if syncContext.maintenanceWindowErr != nil {
+		conditions.Set(newStatus, api.XSetWaitingForMaintenanceWindow, metav1.ConditionTrue, conditions.ReasonInvalidMaintenanceWindow,
+			fmt.Sprintf("disruptive actions are held by invalid maintenance windows: %v", syncContext.maintenanceWindowErr), generation)
+		return
+	}
+	message := fmt.Sprintf("%d disruptive action(s) are held out of maintenance windows", syncContext.heldByMaintenanceWindow)
+	if !syncContext.nextMaintenanceWindow.IsZero() {
+		message = fmt.Sprintf("%s until %s", message, syncContext.nextMaintenanceWindow.UTC().Format(time.RFC3339))
+	}
+	conditions.Set(newStatus, api.XSetWaitingForMaintenanceWindow, metav1.ConditionTrue, conditions.ReasonOutOfMaintenanceWindow, message, generation)
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+)
+
+func TestInMaintenanceWindows(t *testing.T) {
+	// 2025-01-04 is Saturday
+	saturdayNight := api.MaintenanceWindow{Days: []string{"Saturday"}, Start: "22:00", Duration: metav1.Duration{Duration: 4 * time.Hour}}
+	shanghaiDaily := api.MaintenanceWindow{Start: "02:00", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "Asia/Shanghai"}
+	tests := []struct {
+		name     string
+		windows  []api.MaintenanceWindow
+		now      time.Time
+		inWindow bool
+		next     time.Time
+	}{
+		{
+			name:     "in window",
+			windows:  []api.MaintenanceWindow{saturdayNight},
+			now:      time.Date(2025, 1, 4, 23, 0, 0, 0, time.UTC),
+			inWindow: true,
+		},
+		{
+			name:     "in window spanning days",
+			windows:  []api.MaintenanceWindow{saturdayNight},
+			now:      time.Date(2025, 1, 5, 1, 59, 0, 0, time.UTC),
+			inWindow: true,
+		},
+		{
+			name:    "window ended",
+			windows: []api.MaintenanceWindow{saturdayNight},
+			now:     time.Date(2025, 1, 5, 2, 0, 0, 0, time.UTC),
+			next:    time.Date(2025, 1, 11, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "nearest window",
+			windows: []api.MaintenanceWindow{saturdayNight, shanghaiDaily},
+			now:     time.Date(2025, 1, 4, 12, 0, 0, 0, time.UTC),
+			next:    time.Date(2025, 1, 4, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "in window of time zone",
+			windows:  []api.MaintenanceWindow{saturdayNight, shanghaiDaily},
+			now:      time.Date(2025, 1, 4, 18, 30, 0, 0, time.UTC),
+			inWindow: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inWindow, next := inMaintenanceWindows(tt.windows, tt.now)
+			if inWindow != tt.inWindow || !next.Equal(tt.next) {
+				t.Errorf("inMaintenanceWindows() = %v, %v, want %v, %v", inWindow, next, tt.inWindow, tt.next)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindowCondition(t *testing.T) {
+	now := time.Date(2025, 1, 4, 12, 0, 0, 0, time.UTC)
+	spec := &api.XSetSpec{MaintenanceWindows: []api.MaintenanceWindow{{Start: "22:00", Duration: metav1.Duration{Duration: time.Hour}}}}
+	syncContext := &SyncContext{}
+	checkMaintenanceWindows(spec, now, syncContext)
+	if !syncContext.OutOfMaintenanceWindow || *syncContext.RecheckMaintenanceWindowAfter != 10*time.Hour {
+		t.Fatalf("expect out of maintenance window until 10h later, got %v", syncContext.RecheckMaintenanceWindowAfter)
+	}
+
+	// requeue and condition only if any disruptive action is held
+	status := &api.XSetStatus{}
+	calculateMaintenanceWindowCondition(1, syncContext, status)
+	if syncContext.RecheckMaintenanceWindowAfter != nil || conditions.Get(status, api.XSetWaitingForMaintenanceWindow) != nil {
+		t.Errorf("expect nothing waiting for maintenance window")
+	}
+	checkMaintenanceWindows(spec, now, syncContext)
+	syncContext.heldByMaintenanceWindow = 2
+	calculateMaintenanceWindowCondition(1, syncContext, status)
+	if _, matched := conditions.FindAndCompare(status, api.XSetWaitingForMaintenanceWindow, metav1.ConditionTrue, conditions.ReasonOutOfMaintenanceWindow); !matched || syncContext.RecheckMaintenanceWindowAfter == nil {
+		t.Errorf("expect waiting for maintenance window, got %v", status.Conditions)
+	}
+
+	// invalid windows hold disruptive actions
+	spec.MaintenanceWindows[0].Start = "10pm"
+	checkMaintenanceWindows(spec, now, syncContext)
+	calculateMaintenanceWindowCondition(1, syncContext, status)
+	if _, matched := conditions.FindAndCompare(status, api.XSetWaitingForMaintenanceWindow, metav1.ConditionTrue, conditions.ReasonInvalidMaintenanceWindow); !matched || !syncContext.OutOfMaintenanceWindow {
+		t.Errorf("expect held by invalid maintenance window, got %v", status.Conditions)
+	}
+
+	spec.MaintenanceWindows = nil
+	checkMaintenanceWindows(spec, now, syncContext)
+	if syncContext.OutOfMaintenanceWindow {
+		t.Errorf("expect disruptive actions allowed without maintenance windows")
+	}
+}
@@ -0,0 +1,176 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+// getNodeFailureReplace returns how targets on failed nodes are replaced, or nil if NodeFailureReplaceAdapter is not
+// implemented or disabled
+func getNodeFailureReplace(setController api.XSetController, owner api.XSetObject) *api.NodeFailureReplace {
+	adapter, ok := setController.(api.NodeFailureReplaceAdapter)
+	if !ok {
+		return nil
+	}
+	return adapter.GetNodeFailureReplace(owner)
+}
+
+// IsNodeReady returns true if Ready condition of node is True
+func IsNodeReady(node *corev1.Node) bool {
+	_, failed := nodeFailedSince(node)
+	return !failed
+}
+
+// nodeFailedSince returns when node becomes NotReady or unreachable, i.e., Ready condition turns False or Unknown
+func nodeFailedSince(node *corev1.Node) (time.Time, bool) {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type != corev1.NodeReady {
+			continue
+		}
+		if condition.Status == corev1.ConditionTrue {
+			return time.Time{}, false
+		}
+		return condition.LastTransitionTime.Time, true
+	}
+	return time.Time{}, false
+}
+
+// pdbBudget tracks disruptions allowed by PodDisruptionBudgets within one reconcile
+type pdbBudget struct {
+	reader client.Reader
+	// pdbs are listed by namespace on demand
+	pdbs map[string][]*policyv1.PodDisruptionBudget
+	// consumed is the number of disruptions consumed of each PodDisruptionBudget
+	consumed map[types.UID]int32
+}
+
+// allow consumes a disruption of every PodDisruptionBudget selecting pod, and returns false without consuming any if
+// one of them allows no more disruption
+func (b *pdbBudget) allow(ctx context.Context, pod *corev1.Pod) (bool, error) {
+	pdbs, listed := b.pdbs[pod.Namespace]
+	if !listed {
+		pdbList := &policyv1.PodDisruptionBudgetList{}
+		if err := b.reader.List(ctx, pdbList, client.InNamespace(pod.Namespace)); err != nil {
+			return false, err
+		}
+		for i := range pdbList.Items {
+			pdbs = append(pdbs, &pdbList.Items[i])
+		}
+		b.pdbs[pod.Namespace] = pdbs
+	}
+
+	var selecting []*policyv1.PodDisruptionBudget
+	for _, pdb := range pdbs {
+		if pdb.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		if pdb.Status.DisruptionsAllowed-b.consumed[pdb.UID] <= 0 {
+			return false, nil
+		}
+		selecting = append(selecting, pdb)
+	}
+	for _, pdb := range selecting {
+		b.consumed[pdb.UID]++
+	}
+	return true, nil
+}
+
+// replaceTargetsOnFailedNodes labels Pod targets on nodes NotReady or unreachable beyond toleration period with
+// replace indication label, within the budget of targets being replaced at the same time and PodDisruptionBudgets,
+// so that they are replaced by Replace. Targets beyond the budget are deferred with reason NodeFailure.
+func (r *RealSyncControl) replaceTargetsOnFailedNodes(ctx context.Context, xsetObject api.XSetObject, syncContext *SyncContext) error {
+	replace := getNodeFailureReplace(r.xsetController, xsetObject)
+	if replace == nil {
+		return nil
+	}
+	tolerationPeriod := replace.TolerationPeriod
+	if tolerationPeriod <= 0 {
+		tolerationPeriod = api.DefaultNodeFailureTolerationPeriod
+	}
+
+	candidates, replacing := r.replaceCandidates(syncContext.TargetWrappers)
+	nodes := map[string]*corev1.Node{}
+	var failed []*TargetWrapper
+	for _, target := range candidates {
+		pod, ok := target.Object.(*corev1.Pod)
+		if !ok || pod.Spec.NodeName == "" {
+			continue
+		}
+		// nodes are read from the cache shared with the watch of target nodes
+		node, cached := nodes[pod.Spec.NodeName]
+		if !cached {
+			node = &corev1.Node{}
+			if err := r.Client.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return err
+				}
+				node = nil
+			}
+			nodes[pod.Spec.NodeName] = node
+		}
+		if node == nil {
+			continue
+		}
+		since, nodeFailed := nodeFailedSince(node)
+		if !nodeFailed {
+			continue
+		}
+		if remaining := time.Until(since.Add(tolerationPeriod)); remaining > 0 {
+			syncContext.RecheckNodeFailureAfter = xcontrol.GetShorterDuration(syncContext.RecheckNodeFailureAfter, &remaining)
+			continue
+		}
+		failed = append(failed, target)
+	}
+
+	// PodDisruptionBudgets are read rarely, only for ready targets on failed nodes, so they are read from API server
+	// rather than cached by informers of all namespaces
+	budget := &pdbBudget{reader: r.APIReader, pdbs: map[string][]*policyv1.PodDisruptionBudget{}, consumed: map[types.UID]int32{}}
+	return r.labelTargetsToReplace(ctx, xsetObject, "NodeFailure", "on failed node", failed, replacing, max(replace.MaxReplacing, 1),
+		func(target *TargetWrapper) (bool, error) {
+			pod := target.Object.(*corev1.Pod)
+			// targets not ready are no longer counted healthy by PodDisruptionBudgets, and replacing them disrupts nothing
+			if ready, _ := r.xsetController.CheckReadyTime(pod); !ready {
+				return true, nil
+			}
+			allowed, err := budget.allow(ctx, pod)
+			if err != nil {
+				return false, fmt.Errorf("fail to check PodDisruptionBudgets of target %s/%s: %w", pod.Namespace, pod.Name, err)
+			}
+			if !allowed {
+				r.Recorder.Eventf(pod, corev1.EventTypeNormal, "NodeFailureReplaceBlocked", "target on failed node %s is not replaced, since PodDisruptionBudgets allow no disruption", pod.Spec.NodeName)
+			}
+			return allowed, nil
+		})
+}
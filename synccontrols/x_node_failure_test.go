@@ -0,0 +1,196 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/opslifecycle"
+	"kusionstack.io/kube-xset/testing/fake"
+)
+
+type nodeFailureController struct {
+	api.XSetController
+	replace *api.NodeFailureReplace
+}
+
+func (c *nodeFailureController) XMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+}
+
+func (c *nodeFailureController) XSetMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "apps.kusionstack.io/v1alpha1", Kind: "CollaSet"}
+}
+
+func (c *nodeFailureController) CheckReadyTime(object client.Object) (bool, *metav1.Time) {
+	for _, condition := range object.(*corev1.Pod).Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+			return true, &condition.LastTransitionTime
+		}
+	}
+	return false, nil
+}
+
+func (c *nodeFailureController) GetNodeFailureReplace(api.XSetObject) *api.NodeFailureReplace {
+	return c.replace
+}
+
+func newNode(name string, ready corev1.ConditionStatus, since time.Duration) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{
+			Type: corev1.NodeReady, Status: ready, LastTransitionTime: metav1.NewTime(time.Now().Add(-since)),
+		}}},
+	}
+}
+
+func TestReplaceTargetsOnFailedNodes(t *testing.T) {
+	c := clientfake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+		newNode("node-ok", corev1.ConditionTrue, time.Hour),
+		newNode("node-failed", corev1.ConditionUnknown, time.Hour),
+		newNode("node-recent", corev1.ConditionFalse, time.Minute),
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "guarded", UID: "guarded"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"guarded": "true"}}},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 0},
+		},
+	).Build()
+	newPod := func(name, node string, ready, guarded bool) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: map[string]string{}},
+			Spec:       corev1.PodSpec{NodeName: node},
+		}
+		if ready {
+			pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+		}
+		if guarded {
+			pod.Labels["guarded"] = "true"
+		}
+		return pod
+	}
+	pods := []client.Object{
+		newPod("foo-0", "node-ok", true, true),
+		newPod("foo-1", "node-failed", false, true),
+		newPod("foo-2", "node-failed", true, true),
+		newPod("foo-3", "node-recent", false, false),
+		newPod("foo-4", "node-failed", false, false),
+	}
+	xsetController := &nodeFailureController{replace: &api.NodeFailureReplace{TolerationPeriod: 5 * time.Minute, MaxReplacing: 1}}
+	targetControl := fake.NewTargetControl(xsetController, pods...)
+	labelAnnoMgr := api.NewXSetLabelAnnotationManager(nil)
+	_, scaleInLifecycleAdapter := opslifecycle.GetLifecycleAdapters(xsetController, labelAnnoMgr, xsetController.XSetMeta())
+	r := &RealSyncControl{
+		xsetController:          xsetController,
+		xsetLabelAnnoMgr:        labelAnnoMgr,
+		xControl:                targetControl,
+		cacheExpectations:       &noopExpectations{},
+		scaleInLifecycleAdapter: scaleInLifecycleAdapter,
+		deferredTargets:         newDeferredTargets(),
+	}
+	r.Client = c
+	r.APIReader = c
+	recorder := record.NewFakeRecorder(100)
+	r.Recorder = recorder
+
+	replaced := func() []string {
+		var names []string
+		for _, target := range targetControl.Targets() {
+			if _, exist := labelAnnoMgr.Get(target, api.XReplaceIndicationLabelKey); exist {
+				names = append(names, target.GetName())
+			}
+		}
+		return names
+	}
+	sync := func() *SyncContext {
+		syncContext := &SyncContext{}
+		for _, target := range targetControl.Targets() {
+			syncContext.TargetWrappers = append(syncContext.TargetWrappers, &TargetWrapper{Object: target})
+		}
+		if err := r.replaceTargetsOnFailedNodes(context.TODO(), &corev1.Pod{}, syncContext); err != nil {
+			t.Fatalf("replaceTargetsOnFailedNodes() = %v", err)
+		}
+		return syncContext
+	}
+	deferredEvents := func() []string {
+		var events []string
+		for len(recorder.Events) > 0 {
+			if event := <-recorder.Events; strings.Contains(event, "NodeFailureDeferred") {
+				events = append(events, event)
+			}
+		}
+		return events
+	}
+
+	// only one target is replaced within budget, and node failed recently is rechecked after toleration period
+	syncContext := sync()
+	if got := replaced(); len(got) != 1 || got[0] != "foo-1" {
+		t.Fatalf("got replaced targets %v, want foo-1", got)
+	}
+	if events := deferredEvents(); len(events) != 2 {
+		t.Errorf("expect deferral of foo-2 and foo-4 reported, got %v", events)
+	}
+	sync()
+	if events := deferredEvents(); len(events) != 0 {
+		t.Errorf("expect deferral reported once, got %v", events)
+	}
+	if recheck := syncContext.RecheckNodeFailureAfter; recheck == nil || *recheck > 4*time.Minute || *recheck < 3*time.Minute {
+		t.Errorf("expect recheck after about 4m, got %v", recheck)
+	}
+
+	// ready target guarded by PodDisruptionBudget is not replaced, while others are replaced once budget is released
+	xsetController.replace.MaxReplacing = 3
+	sync()
+	if got := replaced(); len(got) != 2 || got[0] != "foo-1" || got[1] != "foo-4" {
+		t.Errorf("got replaced targets %v, want foo-1 and foo-4", got)
+	}
+	target, _ := targetControl.GetTarget(types.NamespacedName{Namespace: "default", Name: "foo-0"})
+	if _, exist := labelAnnoMgr.Get(target, api.XReplaceIndicationLabelKey); exist {
+		t.Errorf("expect target on ready node not replaced")
+	}
+}
+
+func TestPdbBudget(t *testing.T) {
+	c := clientfake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(&policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", UID: "foo"},
+		Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}}},
+		Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 1},
+	}).Build()
+	budget := &pdbBudget{reader: c, pdbs: map[string][]*policyv1.PodDisruptionBudget{}, consumed: map[types.UID]int32{}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Labels: map[string]string{"app": "foo"}}}
+	for i, want := range []bool{true, false} {
+		if allowed, err := budget.allow(context.TODO(), pod); err != nil || allowed != want {
+			t.Errorf("allow() #%d = %v, %v, want %v", i, allowed, err, want)
+		}
+	}
+	other := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Labels: map[string]string{"app": "bar"}}}
+	if allowed, _ := budget.allow(context.TODO(), other); !allowed {
+		t.Errorf("expect pod not selected by PodDisruptionBudget allowed")
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+const (
+	// PostCreateHookTimeout is the timeout of each PostCreate hook call
+	PostCreateHookTimeout = 30 * time.Second
+	// MaxConcurrentPostCreateHooks is the max number of PostCreate hooks called in parallel
+	MaxConcurrentPostCreateHooks = 16
+)
+
+// runPostCreateHooks starts PostCreate hook in background for targets which have not been registered, and records
+// results of finished ones in their contexts. Failures are retried with backoff and reported as events on target
+// instead of failing the sync. It returns true if any context is changed.
+func (r *RealSyncControl) runPostCreateHooks(instance api.XSetObject, syncContext *SyncContext, targetWrappers []*TargetWrapper) bool {
+	adapter, ok := r.xsetController.(api.PostCreateHookAdapter)
+	if !ok {
+		return false
+	}
+
+	changed := false
+	for _, wrapper := range targetWrappers {
+		if wrapper.ContextDetail == nil || wrapper.GetDeletionTimestamp() != nil {
+			continue
+		}
+		value, _ := r.resourceContextControl.Get(wrapper.ContextDetail, api.EnumPostCreateContextDataKey)
+		state := parseHookState(value)
+		if state.uid != string(wrapper.GetUID()) {
+			// target is new or recreated
+			state = hookState{uid: string(wrapper.GetUID())}
+		} else if state.failures == 0 {
+			continue
+		}
+		syncContext.postCreatePending++
+
+		// hook reads copies, since objects of sync may be changed before it finishes
+		xset := instance.DeepCopyObject().(api.XSetObject)
+		target := wrapper.Object.DeepCopyObject().(client.Object)
+		contextDetail := &api.ContextDetail{ID: wrapper.ContextDetail.ID, Data: maps.Clone(wrapper.ContextDetail.Data)}
+		collected, recheckAfter, err := r.postCreateHooks.step(&state, func(ctx context.Context) error {
+			return adapter.PostCreate(ctx, xset, target, contextDetail)
+		})
+		if recheckAfter > 0 {
+			syncContext.RecheckPostCreateAfter = xcontrol.GetShorterDuration(syncContext.RecheckPostCreateAfter, &recheckAfter)
+		}
+		if !collected {
+			continue
+		}
+		if err != nil {
+			r.Recorder.Eventf(wrapper.Object, corev1.EventTypeWarning, "PostCreateFailed",
+				"PostCreate hook failed %d time(s), retry after %s: %s", state.failures, recheckAfter, err)
+		} else {
+			syncContext.postCreatePending--
+			r.Recorder.Eventf(wrapper.Object, corev1.EventTypeNormal, "PostCreateSucceeded", "PostCreate hook succeeded")
+		}
+		r.resourceContextControl.Put(wrapper.ContextDetail, api.EnumPostCreateContextDataKey, state.String())
+		changed = true
+	}
+	return changed
+}
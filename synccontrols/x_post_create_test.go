@@ -0,0 +1,115 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"kusionstack.io/kube-utils/controller/mixin"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/testing/fake"
+)
+
+type postCreateController struct {
+	api.XSetController
+	calls   atomic.Int32
+	release chan error
+}
+
+func (c *postCreateController) PostCreate(ctx context.Context, _ api.XSetObject, _ client.Object, _ *api.ContextDetail) error {
+	c.calls.Add(1)
+	select {
+	case err := <-c.release:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestRunPostCreateHooks(t *testing.T) {
+	xsetController := &postCreateController{release: make(chan error)}
+	resourceContextControl := fake.NewResourceContextControl(xsetController)
+	r := &RealSyncControl{
+		ReconcilerMixin:        mixin.ReconcilerMixin{Recorder: record.NewFakeRecorder(10)},
+		xsetController:         xsetController,
+		resourceContextControl: resourceContextControl,
+		postCreateHooks:        newHookRunner(PostCreateHookTimeout, MaxConcurrentPostCreateHooks),
+	}
+	xset := &corev1.Pod{}
+	contextDetail := &api.ContextDetail{ID: 0}
+	wrappers := []*TargetWrapper{{Object: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo-0", UID: "foo-0-uid"}}, ContextDetail: contextDetail}}
+	sync := func() (*SyncContext, bool) {
+		syncContext := &SyncContext{}
+		return syncContext, r.runPostCreateHooks(xset, syncContext, wrappers)
+	}
+	waitCollected := func() *SyncContext {
+		var syncContext *SyncContext
+		if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			var changed bool
+			syncContext, changed = sync()
+			return changed, nil
+		}); err != nil {
+			t.Fatalf("hook result is not collected: %v", err)
+		}
+		return syncContext
+	}
+
+	// hook is started in background without blocking sync, and is not called again while running
+	syncContext, changed := sync()
+	if changed || syncContext.postCreatePending != 1 || syncContext.RecheckPostCreateAfter == nil {
+		t.Fatalf("got changed %v, pending %d, recheck %v, want hook running and polled", changed, syncContext.postCreatePending, syncContext.RecheckPostCreateAfter)
+	}
+	sync()
+
+	// failure is recorded in context and retried after backoff
+	xsetController.release <- errors.New("unavailable")
+	syncContext = waitCollected()
+	value, _ := resourceContextControl.Get(contextDetail, api.EnumPostCreateContextDataKey)
+	state := parseHookState(value)
+	if state.uid != "foo-0-uid" || state.failures != 1 || syncContext.postCreatePending != 1 {
+		t.Fatalf("got state %q with %d pending, want one failure recorded", value, syncContext.postCreatePending)
+	}
+	if syncContext, _ = sync(); syncContext.RecheckPostCreateAfter == nil || *syncContext.RecheckPostCreateAfter > HookBackoffBase {
+		t.Errorf("got recheck %v, want retried after backoff", syncContext.RecheckPostCreateAfter)
+	}
+	if calls := xsetController.calls.Load(); calls != 1 {
+		t.Fatalf("PostCreate is called %d time(s) during backoff, want once", calls)
+	}
+
+	// hook is retried once backoff expires, and success is recorded
+	state.lastFailure = time.Now().Add(-HookBackoffBase)
+	resourceContextControl.Put(contextDetail, api.EnumPostCreateContextDataKey, state.String())
+	sync()
+	xsetController.release <- nil
+	syncContext = waitCollected()
+	if value, _ = resourceContextControl.Get(contextDetail, api.EnumPostCreateContextDataKey); value != "foo-0-uid" || syncContext.postCreatePending != 0 {
+		t.Errorf("got state %q with %d pending, want hook succeeded", value, syncContext.postCreatePending)
+	}
+	if syncContext, changed = sync(); changed || syncContext.RecheckPostCreateAfter != nil || xsetController.calls.Load() != 2 {
+		t.Errorf("got changed %v, recheck %v, want registered target skipped", changed, syncContext.RecheckPostCreateAfter)
+	}
+}
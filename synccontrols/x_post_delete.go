@@ -0,0 +1,132 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/resourcecontexts"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+const (
+	// PostDeleteHookTimeout is the timeout of each PostDelete hook call
+	PostDeleteHookTimeout = 30 * time.Second
+	// MaxConcurrentPostDeleteHooks is the max number of PostDelete hooks called in parallel
+	MaxConcurrentPostDeleteHooks = 16
+)
+
+// trackTargetForPostDelete records uid of target in its context, so that PostDelete hook is called once it is gone.
+// A target is not tracked until hook of the previous target on the same ID has succeeded. It returns true if context
+// is changed.
+func (r *RealSyncControl) trackTargetForPostDelete(target client.Object, contextDetail *api.ContextDetail) bool {
+	if _, ok := r.xsetController.(api.PostDeleteHookAdapter); !ok || contextDetail == nil {
+		return false
+	}
+	value, _ := r.resourceContextControl.Get(contextDetail, api.EnumPostDeleteContextDataKey)
+	if resourcecontexts.IsPostDeletePending(value) {
+		return false
+	}
+	r.resourceContextControl.Put(contextDetail, api.EnumPostDeleteContextDataKey, string(target.GetUID()))
+	return true
+}
+
+// untrackTargetForPostDelete stops tracking target which leaves xset without being deleted, e.g., excluded.
+// It returns true if context is changed.
+func (r *RealSyncControl) untrackTargetForPostDelete(contextDetail *api.ContextDetail) bool {
+	if contextDetail == nil {
+		return false
+	}
+	if _, exist := r.resourceContextControl.Get(contextDetail, api.EnumPostDeleteContextDataKey); !exist {
+		return false
+	}
+	r.resourceContextControl.Remove(contextDetail, api.EnumPostDeleteContextDataKey)
+	return true
+}
+
+// isPostDeletePending checks whether the ID is held until PostDelete hook of its deleted target succeeded
+func (r *RealSyncControl) isPostDeletePending(contextDetail *api.ContextDetail) bool {
+	if _, ok := r.xsetController.(api.PostDeleteHookAdapter); !ok || contextDetail == nil {
+		return false
+	}
+	value, _ := r.resourceContextControl.Get(contextDetail, api.EnumPostDeleteContextDataKey)
+	return resourcecontexts.IsPostDeletePending(value)
+}
+
+// runPostDeleteHooks starts PostDelete hook in background for tracked targets which are fully deleted, and records
+// results of finished ones in their contexts. Failures are retried with backoff and reported as events on xset, while
+// their IDs are neither reclaimed nor reused. It returns true if any context is changed.
+func (r *RealSyncControl) runPostDeleteHooks(
+	instance api.XSetObject,
+	syncContext *SyncContext,
+	ownedIDs map[int]*api.ContextDetail,
+	allTargets []client.Object,
+) bool {
+	adapter, ok := r.xsetController.(api.PostDeleteHookAdapter)
+	if !ok {
+		return false
+	}
+
+	existingUIDs := sets.NewString()
+	for _, target := range allTargets {
+		existingUIDs.Insert(string(target.GetUID()))
+	}
+
+	changed := false
+	for _, contextDetail := range ownedIDs {
+		value, _ := r.resourceContextControl.Get(contextDetail, api.EnumPostDeleteContextDataKey)
+		if !resourcecontexts.IsPostDeletePending(value) {
+			continue
+		}
+		state := parseHookState(value)
+		if existingUIDs.Has(state.uid) {
+			// target still exists or is terminating
+			continue
+		}
+
+		// hook reads copies, since objects of sync may be changed before it finishes
+		xset := instance.DeepCopyObject().(api.XSetObject)
+		detail := &api.ContextDetail{ID: contextDetail.ID, Data: maps.Clone(contextDetail.Data)}
+		collected, recheckAfter, err := r.postDeleteHooks.step(&state, func(ctx context.Context) error {
+			return adapter.PostDelete(ctx, xset, types.UID(state.uid), detail)
+		})
+		if recheckAfter > 0 {
+			syncContext.RecheckPostDeleteAfter = xcontrol.GetShorterDuration(syncContext.RecheckPostDeleteAfter, &recheckAfter)
+		}
+		if !collected {
+			continue
+		}
+		if err != nil {
+			r.Recorder.Eventf(instance, corev1.EventTypeWarning, "PostDeleteFailed",
+				"PostDelete hook for instance ID %d failed %d time(s), retry after %s: %s", contextDetail.ID, state.failures, recheckAfter, err)
+		} else {
+			state = hookState{uid: state.uid, done: true}
+			r.Recorder.Eventf(instance, corev1.EventTypeNormal, "PostDeleteSucceeded", "PostDelete hook for instance ID %d succeeded", contextDetail.ID)
+		}
+		r.resourceContextControl.Put(contextDetail, api.EnumPostDeleteContextDataKey, state.String())
+		changed = true
+	}
+	return changed
+}
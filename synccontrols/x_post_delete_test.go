@@ -0,0 +1,92 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"kusionstack.io/kube-utils/controller/mixin"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/testing/fake"
+)
+
+type postDeleteController struct {
+	api.XSetController
+	mu    sync.Mutex
+	calls []types.UID
+}
+
+func (c *postDeleteController) PostDelete(_ context.Context, _ api.XSetObject, targetUID types.UID, _ *api.ContextDetail) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, targetUID)
+	return nil
+}
+
+func TestRunPostDeleteHooks(t *testing.T) {
+	xsetController := &postDeleteController{}
+	resourceContextControl := fake.NewResourceContextControl(xsetController)
+	r := &RealSyncControl{
+		ReconcilerMixin:        mixin.ReconcilerMixin{Recorder: record.NewFakeRecorder(10)},
+		xsetController:         xsetController,
+		resourceContextControl: resourceContextControl,
+		postDeleteHooks:        newHookRunner(PostDeleteHookTimeout, MaxConcurrentPostDeleteHooks),
+	}
+	xset := &corev1.Pod{}
+	alive := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo-0", UID: "alive-uid"}}
+	ownedIDs := map[int]*api.ContextDetail{0: {ID: 0}, 1: {ID: 1}}
+	if !r.trackTargetForPostDelete(alive, ownedIDs[0]) || !r.trackTargetForPostDelete(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: "gone-uid"}}, ownedIDs[1]) {
+		t.Fatal("trackTargetForPostDelete() = false, want targets tracked")
+	}
+	allTargets := []client.Object{alive}
+
+	// hook of the deleted target is started in background, and its ID is held until hook succeeded
+	syncContext := &SyncContext{}
+	if r.runPostDeleteHooks(xset, syncContext, ownedIDs, allTargets) || syncContext.RecheckPostDeleteAfter == nil {
+		t.Fatalf("got recheck %v, want hook running and polled", syncContext.RecheckPostDeleteAfter)
+	}
+	if !r.isPostDeletePending(ownedIDs[1]) {
+		t.Fatal("isPostDeletePending() = false, want ID held while hook is running")
+	}
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return r.runPostDeleteHooks(xset, &SyncContext{}, ownedIDs, allTargets), nil
+	}); err != nil {
+		t.Fatalf("hook result is not collected: %v", err)
+	}
+	if r.isPostDeletePending(ownedIDs[1]) {
+		value, _ := resourceContextControl.Get(ownedIDs[1], api.EnumPostDeleteContextDataKey)
+		t.Errorf("got state %q, want ID released once hook succeeded", value)
+	}
+
+	// existing target is not called, and the deleted one is called once
+	if !r.isPostDeletePending(ownedIDs[0]) {
+		t.Error("isPostDeletePending() = false, want existing target tracked")
+	}
+	if len(xsetController.calls) != 1 || xsetController.calls[0] != "gone-uid" {
+		t.Errorf("PostDelete is called for %v, want gone-uid only", xsetController.calls)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// getPreemptionTaintKeys returns keys of taints marking nodes going away, or nil if PreemptionAwareAdapter is not implemented
+func getPreemptionTaintKeys(setController api.XSetController, owner api.XSetObject) []string {
+	adapter, ok := setController.(api.PreemptionAwareAdapter)
+	if !ok {
+		return nil
+	}
+	if keys := adapter.GetPreemptionTaintKeys(owner); len(keys) > 0 {
+		return keys
+	}
+	return api.DefaultPreemptionTaintKeys
+}
+
+// isOnPreemptingNode returns true if target is a Pod on node with any of taint keys
+func (r *RealSyncControl) isOnPreemptingNode(ctx context.Context, target client.Object, taintKeys []string) (bool, error) {
+	pod, ok := target.(*corev1.Pod)
+	if !ok || pod.Spec.NodeName == "" || len(taintKeys) == 0 {
+		return false, nil
+	}
+	node := &corev1.Node{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return hasPreemptionTaint(node, taintKeys), nil
+}
+
+func hasPreemptionTaint(node *corev1.Node, taintKeys []string) bool {
+	for _, taint := range node.Spec.Taints {
+		for _, key := range taintKeys {
+			if taint.Key == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// preferPreemptingTargets moves targets on preempting nodes ahead of others, keeping the order otherwise, so that
+// they are updated first within the budget of update strategy
+func preferPreemptingTargets(targetInfos []*TargetUpdateInfo) {
+	sort.SliceStable(targetInfos, func(i, j int) bool {
+		return targetInfos[i].OnPreemptingNode && !targetInfos[j].OnPreemptingNode
+	})
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+func TestPreferPreemptingTargets(t *testing.T) {
+	info := func(id int, preempting bool) *TargetUpdateInfo {
+		return &TargetUpdateInfo{TargetWrapper: &TargetWrapper{
+			Object:           &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
+			ID:               id,
+			OnPreemptingNode: preempting,
+		}}
+	}
+	infos := []*TargetUpdateInfo{info(0, false), info(1, true), info(2, false), info(3, true)}
+	preferPreemptingTargets(infos)
+	for i, want := range []int{1, 3, 0, 2} {
+		if infos[i].ID != want {
+			t.Errorf("target %d is ID %d, want %d", i, infos[i].ID, want)
+		}
+	}
+}
+
+func TestHasPreemptionTaint(t *testing.T) {
+	node := &corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{
+		{Key: "dedicated", Value: "foo", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "ToBeDeletedByClusterAutoscaler", Effect: corev1.TaintEffectNoSchedule},
+	}}}
+	if !hasPreemptionTaint(node, api.DefaultPreemptionTaintKeys) {
+		t.Errorf("node marked by cluster-autoscaler is expected to be preempting")
+	}
+	if hasPreemptionTaint(node, []string{"karpenter.sh/disruption"}) {
+		t.Errorf("node without karpenter disruption taint is not expected to be preempting")
+	}
+}
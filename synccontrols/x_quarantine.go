@@ -0,0 +1,80 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	clientutil "kusionstack.io/kube-utils/client"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// isTargetQuarantined returns true if target is quarantined by operators or for repeated failures
+func isTargetQuarantined(xsetLabelAnnoMgr api.XSetLabelAnnotationManager, target client.Object) bool {
+	_, quarantined := xsetLabelAnnoMgr.Get(target, api.XQuarantinedLabelKey)
+	return quarantined
+}
+
+// splitQuarantinedTargetWrappers splits quarantined targets out of targets, which are kept out of replicas, so that
+// replacements are created in their place and they are chosen neither to update nor to scale in
+func splitQuarantinedTargetWrappers(xsetLabelAnnoMgr api.XSetLabelAnnotationManager, targets []*TargetWrapper) ([]*TargetWrapper, []*TargetWrapper) {
+	var active, quarantined []*TargetWrapper
+	for i, target := range targets {
+		if target.Object != nil && isTargetQuarantined(xsetLabelAnnoMgr, target.Object) {
+			quarantined = append(quarantined, targets[i])
+			continue
+		}
+		active = append(active, targets[i])
+	}
+	return active, quarantined
+}
+
+// quarantineTargets takes quarantined targets out of service by QuarantineAdapter
+func (r *RealSyncControl) quarantineTargets(ctx context.Context, xsetObject api.XSetObject, targets []*TargetWrapper) error {
+	adapter, ok := r.xsetController.(api.QuarantineAdapter)
+	if !ok {
+		return nil
+	}
+	var errs []error
+	for _, target := range targets {
+		if target.PlaceHolder || target.Object == nil || target.GetDeletionTimestamp() != nil ||
+			!isTargetQuarantined(r.xsetLabelAnnoMgr, target.Object) {
+			continue
+		}
+		// check on a copy, so that targets already out of service are not patched
+		if !adapter.QuarantineTarget(target.Object.DeepCopyObject().(client.Object)) {
+			continue
+		}
+		if err := r.xControl.PatchTargetWithOptimisticLock(ctx, target.Object, func(obj client.Object) {
+			adapter.QuarantineTarget(obj)
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("fail to take quarantined target %s out of service: %w", target.GetName(), err))
+			continue
+		}
+		if err := r.cacheExpectations.ExpectUpdation(clientutil.ObjectKeyString(xsetObject), r.targetGVK, target.GetNamespace(), target.GetName(), target.GetResourceVersion()); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		r.Recorder.Eventf(target.Object, corev1.EventTypeNormal, "TargetQuarantined", "quarantined target is taken out of service by %s %s", r.xsetGVK.Kind, xsetObject.GetName())
+	}
+	return errors.Join(errs...)
+}
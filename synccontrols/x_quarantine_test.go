@@ -0,0 +1,106 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/testing/fake"
+)
+
+type quarantineController struct {
+	api.XSetController
+}
+
+func (c *quarantineController) XMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+}
+
+func (c *quarantineController) QuarantineTarget(target client.Object) bool {
+	labels := target.GetLabels()
+	if _, exist := labels["app"]; !exist {
+		return false
+	}
+	delete(labels, "app")
+	target.SetLabels(labels)
+	return true
+}
+
+func TestSplitQuarantinedTargetWrappers(t *testing.T) {
+	labelAnnoMgr := api.NewXSetLabelAnnotationManager(nil)
+	quarantined := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo-1", Labels: map[string]string{api.TargetQuarantinedLabelKey: "1700000000"}}}
+	targets := []*TargetWrapper{
+		{Object: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo-0"}}, ID: 0},
+		{Object: quarantined, ID: 1},
+		{ID: 2, PlaceHolder: true},
+	}
+	active, got := splitQuarantinedTargetWrappers(labelAnnoMgr, targets)
+	if len(active) != 2 || len(got) != 1 || got[0].ID != 1 {
+		t.Errorf("got active %d, quarantined %v", len(active), got)
+	}
+}
+
+func TestQuarantineTargets(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-1", ResourceVersion: "1",
+		Labels: map[string]string{"app": "foo", api.TargetQuarantinedLabelKey: "1700000000"}}}
+	xsetController := &quarantineController{}
+	targetControl := fake.NewTargetControl(xsetController, pod.DeepCopy(),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-0", Labels: map[string]string{"app": "foo"}}})
+	r := &RealSyncControl{
+		xsetController:    xsetController,
+		xsetLabelAnnoMgr:  api.NewXSetLabelAnnotationManager(nil),
+		xControl:          targetControl,
+		cacheExpectations: &noopExpectations{},
+	}
+	r.Recorder = record.NewFakeRecorder(10)
+
+	wrappers := func() []*TargetWrapper {
+		var wrappers []*TargetWrapper
+		for _, target := range targetControl.Targets() {
+			wrappers = append(wrappers, &TargetWrapper{Object: target})
+		}
+		return wrappers
+	}
+	if err := r.quarantineTargets(context.TODO(), &corev1.Pod{}, wrappers()); err != nil {
+		t.Fatalf("quarantineTargets() = %v", err)
+	}
+	quarantined, _ := targetControl.GetTarget(types.NamespacedName{Namespace: "default", Name: "foo-1"})
+	if _, exist := quarantined.GetLabels()["app"]; exist {
+		t.Errorf("expect quarantined target taken out of service")
+	}
+	other, _ := targetControl.GetTarget(types.NamespacedName{Namespace: "default", Name: "foo-0"})
+	if _, exist := other.GetLabels()["app"]; !exist {
+		t.Errorf("expect target not quarantined kept in service")
+	}
+
+	// targets already out of service are not patched again
+	targetControl.Reset()
+	if err := r.quarantineTargets(context.TODO(), &corev1.Pod{}, wrappers()); err != nil {
+		t.Fatalf("quarantineTargets() = %v", err)
+	}
+	if calls := targetControl.CallsOf("PatchTargetWithOptimisticLock"); len(calls) != 0 {
+		t.Errorf("expect no patch of targets already out of service, got %d", len(calls))
+	}
+}
@@ -18,10 +18,8 @@ package synccontrols
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -60,13 +58,7 @@ func (r *RealSyncControl) cleanReplaceTargetLabels(
 		}()
 		target := needCleanLabelTargets[i]
 		needCleanLabels := targetsNeedCleanLabels[i]
-		var deletePatch []map[string]string
 		for _, labelKey := range needCleanLabels {
-			patchOperation := map[string]string{
-				"op":   "remove",
-				"path": fmt.Sprintf("/metadata/labels/%s", strings.ReplaceAll(labelKey, "/", "~1")),
-			}
-			deletePatch = append(deletePatch, patchOperation)
 			// replace finished, (1) remove ReplaceNewTargetID, ReplaceOriginTargetID key from IDs, (2) try to delete origin Target's ID
 			if labelKey == r.xsetLabelAnnoMgr.Value(api.XReplacePairOriginName) {
 				needUpdateContext = true
@@ -97,12 +89,13 @@ func (r *RealSyncControl) cleanReplaceTargetLabels(
 				}
 			}
 		}
-		// patch to bytes
-		patchBytes, err := json.Marshal(deletePatch)
-		if err != nil {
-			return err
-		}
-		if err = r.xControl.PatchTarget(ctx, target, client.RawPatch(types.JSONPatchType, patchBytes)); err != nil {
+		if err = r.xControl.PatchTargetWithOptimisticLock(ctx, target, func(target client.Object) {
+			labels := target.GetLabels()
+			for _, labelKey := range needCleanLabels {
+				delete(labels, labelKey)
+			}
+			target.SetLabels(labels)
+		}); err != nil {
 			return fmt.Errorf("failed to remove replace pair label %s/%s: %w", target.GetNamespace(), target.GetName(), err)
 		}
 		return nil
@@ -154,11 +147,17 @@ func (r *RealSyncControl) replaceOriginTargets(
 			r.resourceContextControl.Remove(ownedIDs[newTargetContext.ID], api.EnumJustCreateContextDataKey)
 		}
 
+		// replace pair target stays in the subset of origin target
+		if subset, exist := r.resourceContextControl.Get(ownedIDs[originTargetId], api.EnumSubsetContextDataKey); exist {
+			r.resourceContextControl.Put(newTargetContext, api.EnumSubsetContextDataKey, subset)
+		}
+
 		// create target using update revision if replaced by update, otherwise using current revision
 		newTarget, err := NewTargetFrom(r.xsetController, r.xsetLabelAnnoMgr, instance, replaceRevision, newTargetContext.ID,
-			r.xsetController.GetXSetTemplatePatcher(instance),
+			r.subsetLabeler(newTargetContext),
+			GetTemplatePatcher(r.xsetController, instance),
 			func(object client.Object) error {
-				if decorationAdapter, ok := r.xsetController.(api.DecorationAdapter); ok {
+				if decorationAdapter, ok := GetDecorationAdapter(r.xsetController); ok {
 					// get current decoration patcher from origin target, and patch new target
 					if fn, err := decorationAdapter.GetDecorationPatcherByRevisions(ctx, r.Client, originTarget, originWrapper.DecorationUpdatedRevisions); err != nil {
 						return err
@@ -175,15 +174,38 @@ func (r *RealSyncControl) replaceOriginTargets(
 
 		r.xsetLabelAnnoMgr.Set(newTarget, api.XReplacePairOriginName, originTarget.GetName())
 		r.xsetLabelAnnoMgr.Set(newTarget, api.XCreatingLabel, strconv.FormatInt(time.Now().UnixNano(), 10))
+		StampLastAction(newTarget, api.TargetActionCreatedForReplace)
 		r.resourceContextControl.Put(newTargetContext, api.EnumRevisionContextDataKey, replaceRevision.GetName())
+		r.stickToRecordedNode(instance, newTarget, ownedIDs[originTargetId])
+		// replace pair target stays in the zone of origin target
+		if zone, exist := r.resourceContextControl.Get(ownedIDs[originTargetId], api.EnumZoneContextDataKey); exist {
+			r.resourceContextControl.Put(newTargetContext, api.EnumZoneContextDataKey, zone)
+		}
+		r.injectZone(instance, newTarget, newTargetContext)
+		r.resourceContextControl.ProjectToTarget(newTargetContext, newTarget)
+		if err = preCreateTarget(ctx, r.xsetController, instance, newTarget, newTargetContext); err != nil {
+			return err
+		}
+		if err = dryRunCreateTarget(ctx, r.xsetController, r.xControl, instance, newTarget); err != nil {
+			return fmt.Errorf("fail to dry-run create replace pair target %s/%s: %w", newTarget.GetNamespace(), newTarget.GetName(), err)
+		}
 
-		// create pvcs for new target
+		// create pvcs for new target, reattach pvcs of origin target if required
 		if _, enabled := subresources.GetSubresourcePvcAdapter(r.xsetController); enabled {
+			if err = r.pvcControl.ReattachTargetPvcs(ctx, instance, originTarget, newTarget, syncContext.ExistingPvcs); err != nil {
+				return fmt.Errorf("fail to reattach PVCs of origin target %s: %w", originTarget.GetName(), err)
+			}
 			err = r.pvcControl.CreateTargetPvcs(ctx, instance, newTarget, syncContext.ExistingPvcs)
 			if err != nil {
 				return fmt.Errorf("fail to create PVCs for target %s: %w", newTarget.GetName(), err)
 			}
 		}
+		// create per-instance subresources for new target
+		for _, control := range r.subresourceControls {
+			if err = control.CreateTargetSubresources(ctx, instance, newTargetContext); err != nil {
+				return fmt.Errorf("fail to create subresources for target %s: %w", newTarget.GetName(), err)
+			}
+		}
 
 		if newCreatedTarget, err := r.xControl.CreateTarget(ctx, newTarget); err == nil {
 			r.Recorder.Eventf(originTarget,
@@ -198,8 +220,10 @@ func (r *RealSyncControl) replaceOriginTargets(
 				return err
 			}
 
-			patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf(`{"metadata":{"labels":{%q:%q}}}`, r.xsetLabelAnnoMgr.Value(api.XReplacePairNewId), newInstanceId)))
-			if err = r.xControl.PatchTarget(ctx, originTarget, patch); err != nil {
+			if err = r.xControl.PatchTargetWithOptimisticLock(ctx, originTarget, func(target client.Object) {
+				r.xsetLabelAnnoMgr.Set(target, api.XReplacePairNewId, newInstanceId)
+				StampLastAction(target, api.TargetActionReplacing)
+			}); err != nil {
 				return fmt.Errorf("fail to update origin target %s/%s pair label %s when updating by replaceUpdate: %w", originTarget.GetNamespace(), originTarget.GetName(), newCreatedTarget.GetName(), err)
 			}
 			logger.Info("replaceOriginTargets", "replacing originTarget", originTarget.GetName(), "originTargetId", originTargetId, "newTargetContextID", newInstanceId)
@@ -277,12 +301,12 @@ func (r *RealSyncControl) dealReplaceTargets(ctx context.Context, targets []*Tar
 				needCleanLabels = append(needCleanLabels, r.xsetLabelAnnoMgr.Value(api.XReplacePairOriginName))
 			} else if _, exist := r.xsetLabelAnnoMgr.Get(originTarget.Object, api.XReplaceIndicationLabelKey); !exist {
 				// replace canceled, delete replace new target if new target is not service available
-				if !r.xsetController.CheckAvailable(target) {
+				if !IsTargetAvailable(r.xsetController, target) {
 					needDeleteTargets = append(needDeleteTargets, target)
 				}
 			} else if !replaceByUpdate {
 				// not replace update, delete origin target when new created target is service available
-				if r.xsetController.CheckAvailable(target) {
+				if IsTargetAvailable(r.xsetController, target) {
 					needDeleteTargets = append(needDeleteTargets, originTarget.Object)
 				}
 			}
@@ -452,3 +476,75 @@ func (r *RealSyncControl) mapReplaceOriginToNewTargetContext(ownedIDs map[int]*a
 	}
 	return mapOriginToNewTargetContext
 }
+
+// replaceCandidates returns active targets which may be labeled with replace indication label, and the number of
+// targets being replaced. New targets of replace, and targets terminating, to delete, quarantined or scaling in are
+// not candidates.
+func (r *RealSyncControl) replaceCandidates(targets []*TargetWrapper) ([]*TargetWrapper, int) {
+	var candidates []*TargetWrapper
+	replacing := 0
+	for _, target := range FilterOutActiveTargetWrappers(targets) {
+		if target.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if _, exist := r.xsetLabelAnnoMgr.Get(target, api.XReplaceIndicationLabelKey); exist {
+			replacing++
+			continue
+		}
+		if _, isNewTarget := r.xsetLabelAnnoMgr.Get(target, api.XReplacePairOriginName); isNewTarget || target.ToDelete ||
+			isTargetQuarantined(r.xsetLabelAnnoMgr, target) ||
+			opslifecycle.IsDuringOps(r.xsetLabelAnnoMgr, r.scaleInLifecycleAdapter, target) {
+			continue
+		}
+		candidates = append(candidates, target)
+	}
+	return candidates, replacing
+}
+
+// labelTargetsToReplace labels targets with replace indication label for reason, so that they are replaced by Replace,
+// while at most maxReplacing targets are being replaced at the same time. Targets beyond the budget are deferred, and
+// deferral is reported once by events of targets. Targets are skipped if allow returns false, e.g., by
+// PodDisruptionBudgets. description tells why targets are replaced in messages, e.g., "requested to evict".
+func (r *RealSyncControl) labelTargetsToReplace(
+	ctx context.Context,
+	xsetObject api.XSetObject,
+	reason, description string,
+	targets []*TargetWrapper,
+	replacing, maxReplacing int,
+	allow func(*TargetWrapper) (bool, error),
+) error {
+	for i, target := range targets {
+		if replacing >= maxReplacing {
+			var deferred []client.Object
+			for _, target := range targets[i:] {
+				deferred = append(deferred, target.Object)
+			}
+			for _, target := range r.deferredTargets.update(reason, xsetObject, deferred) {
+				r.Recorder.Eventf(target, corev1.EventTypeNormal, reason+"Deferred",
+					"target %s is deferred, since %d target(s) are being replaced", description, replacing)
+			}
+			return nil
+		}
+		if allow != nil {
+			allowed, err := allow(target)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				continue
+			}
+		}
+		if err := r.xControl.PatchTargetWithOptimisticLock(ctx, target.Object, func(obj client.Object) {
+			r.xsetLabelAnnoMgr.Set(obj, api.XReplaceIndicationLabelKey, strconv.FormatInt(time.Now().UnixNano(), 10))
+		}); err != nil {
+			return fmt.Errorf("fail to replace target %s/%s %s: %w", target.GetNamespace(), target.GetName(), description, err)
+		}
+		if err := r.cacheExpectations.ExpectUpdation(clientutil.ObjectKeyString(xsetObject), r.targetGVK, target.GetNamespace(), target.GetName(), target.GetResourceVersion()); err != nil {
+			return err
+		}
+		r.Recorder.Eventf(target.Object, corev1.EventTypeNormal, "ReplaceFor"+reason, "target %s is replaced by %s %s", description, r.xsetGVK.Kind, xsetObject.GetName())
+		replacing++
+	}
+	r.deferredTargets.update(reason, xsetObject, nil)
+	return nil
+}
@@ -0,0 +1,189 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// getRevisionRatios returns revisions pinned by ratios, or nil if targets are not kept in revisions by ratios
+func getRevisionRatios(spec *api.XSetSpec) *api.ByRevisionRatios {
+	if spec.UpdateStrategy.RollingUpdate == nil {
+		return nil
+	}
+	return spec.UpdateStrategy.RollingUpdate.ByRevisionRatios
+}
+
+// decideTargetToUpdateByRevisionRatios keeps targets in revisions by ratios. Targets stay in their revisions as many
+// as desired, the ones sorted last by orderByDefault first, and the others are updated to revisions short of
+// replicas, pinned revisions first. Update is held if any pinned revision is not found in history.
+func (r *RealSyncControl) decideTargetToUpdateByRevisionRatios(xset api.XSetObject, syncContext *SyncContext, targetInfos []*TargetUpdateInfo) []*TargetUpdateInfo {
+	spec := r.xsetController.GetXSetSpec(xset)
+	ratios := getRevisionRatios(spec)
+	updatedRevision := syncContext.UpdatedRevision
+	revisions := map[string]*appsv1.ControllerRevision{updatedRevision.GetName(): updatedRevision}
+	var order []string
+	for _, ratio := range ratios.Revisions {
+		if ratio.Revision == updatedRevision.GetName() {
+			continue
+		}
+		revision := findRevision(syncContext.Revisions, ratio.Revision)
+		if revision == nil {
+			r.Recorder.Eventf(xset, corev1.EventTypeWarning, "PinnedRevisionNotFound",
+				"revision %s pinned by ratios is not found, targets are not updated", ratio.Revision)
+			return nil
+		}
+		revisions[ratio.Revision] = revision
+		order = append(order, ratio.Revision)
+	}
+	order = append(order, updatedRevision.GetName())
+	desired := ratios.GetRevisionReplicas(ptr.Deref(spec.Replicas, 0), updatedRevision.GetName())
+
+	ordered := newOrderedTargetUpdateInfos(targetInfos, r.xsetController.CheckReadyTime)
+	sort.Sort(ordered)
+	var moved []*TargetUpdateInfo
+	for i := len(ordered.targets) - 1; i >= 0; i-- {
+		targetInfo := ordered.targets[i]
+		revision := r.getUpdateInfoRevision(targetInfo)
+		if desired[revision] <= 0 {
+			moved = append(moved, targetInfo)
+			continue
+		}
+		desired[revision]--
+		targetInfo.UpdateRevision = revisions[revision]
+		targetInfo.IsUpdatedRevision = true
+		targetInfo.PinnedRevision = revision != updatedRevision.GetName()
+	}
+	for _, targetInfo := range moved {
+		revision := updatedRevision.GetName()
+		for _, candidate := range order {
+			if desired[candidate] > 0 {
+				revision = candidate
+				break
+			}
+		}
+		desired[revision]--
+		targetInfo.UpdateRevision = revisions[revision]
+		targetInfo.IsUpdatedRevision = false
+		targetInfo.PinnedRevision = revision != updatedRevision.GetName()
+	}
+
+	// targets kept in pinned revisions are only updated if decoration changed, as by partition
+	var targetToUpdate []*TargetUpdateInfo
+	for _, targetInfo := range targetInfos {
+		if targetInfo.PinnedRevision && targetInfo.IsUpdatedRevision && !targetInfo.DecorationChanged {
+			continue
+		}
+		targetToUpdate = append(targetToUpdate, targetInfo)
+	}
+	return targetToUpdate
+}
+
+// getUpdateInfoRevision returns revision of target, or the one recorded in context for PlaceHolder
+func (r *RealSyncControl) getUpdateInfoRevision(targetInfo *TargetUpdateInfo) string {
+	if targetInfo.PlaceHolder {
+		if targetInfo.ContextDetail == nil {
+			return ""
+		}
+		revision, _ := r.resourceContextControl.Get(targetInfo.ContextDetail, api.EnumRevisionContextDataKey)
+		return revision
+	}
+	return targetInfo.CurrentRevision.GetName()
+}
+
+// getRevisionSurplus returns the revision getter and the number of targets in each revision beyond replicas desired by
+// ratios, or nil if revisions are not pinned by ratios. Targets in revisions not desired are all surplus.
+func (r *RealSyncControl) getRevisionSurplus(owner api.XSetObject, updatedRevision string, targets []*TargetWrapper) (func(*TargetWrapper) string, map[string]int) {
+	spec := r.xsetController.GetXSetSpec(owner)
+	ratios := getRevisionRatios(spec)
+	if ratios == nil {
+		return nil, nil
+	}
+	revisionOf := func(target *TargetWrapper) string {
+		return target.GetLabels()[appsv1.ControllerRevisionHashLabelKey]
+	}
+	surplus := map[string]int{}
+	for revision, replicas := range ratios.GetRevisionReplicas(ptr.Deref(spec.Replicas, 0), updatedRevision) {
+		surplus[revision] = -int(replicas)
+	}
+	for _, target := range targets {
+		surplus[revisionOf(target)]++
+	}
+	return revisionOf, surplus
+}
+
+// calculateRevisionReplicas reports replicas of revisions pinned by ratios and updated revision in status
+func (r *RealSyncControl) calculateRevisionReplicas(spec *api.XSetSpec, syncContext *SyncContext, newStatus *api.XSetStatus) {
+	ratios := getRevisionRatios(spec)
+	if ratios == nil || syncContext.UpdatedRevision == nil {
+		newStatus.RevisionReplicas = nil
+		return
+	}
+	desired := ratios.GetRevisionReplicas(ptr.Deref(spec.Replicas, 0), syncContext.UpdatedRevision.GetName())
+	revisionReplicas := map[string]*api.RevisionReplicas{}
+	for revision, replicas := range desired {
+		revisionReplicas[revision] = &api.RevisionReplicas{Revision: revision, DesiredReplicas: replicas}
+	}
+	for _, target := range syncContext.FilteredTarget {
+		if target.GetDeletionTimestamp() != nil || isTargetQuarantined(r.xsetLabelAnnoMgr, target) {
+			continue
+		}
+		revision := target.GetLabels()[appsv1.ControllerRevisionHashLabelKey]
+		if _, exist := revisionReplicas[revision]; !exist {
+			revisionReplicas[revision] = &api.RevisionReplicas{Revision: revision}
+		}
+		revisionReplicas[revision].Replicas++
+		if available, _ := checkTargetAvailableForStatus(r.xsetController, spec, target); available {
+			revisionReplicas[revision].AvailableReplicas++
+		}
+	}
+	newStatus.RevisionReplicas = make([]api.RevisionReplicas, 0, len(revisionReplicas))
+	for _, replicas := range revisionReplicas {
+		newStatus.RevisionReplicas = append(newStatus.RevisionReplicas, *replicas)
+	}
+	sort.Slice(newStatus.RevisionReplicas, func(i, j int) bool {
+		return newStatus.RevisionReplicas[i].Revision < newStatus.RevisionReplicas[j].Revision
+	})
+}
+
+// isRevisionRatiosSatisfied returns true if every revision has as many available replicas as desired by ratios
+func isRevisionRatiosSatisfied(status *api.XSetStatus) bool {
+	if len(status.RevisionReplicas) == 0 {
+		return false
+	}
+	for _, replicas := range status.RevisionReplicas {
+		if replicas.Replicas != replicas.DesiredReplicas || replicas.AvailableReplicas != replicas.DesiredReplicas {
+			return false
+		}
+	}
+	return true
+}
+
+func findRevision(revisions []*appsv1.ControllerRevision, name string) *appsv1.ControllerRevision {
+	for _, revision := range revisions {
+		if revision.GetName() == name {
+			return revision
+		}
+	}
+	return nil
+}
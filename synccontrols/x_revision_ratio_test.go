@@ -0,0 +1,117 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+type revisionRatioController struct {
+	api.XSetController
+	spec *api.XSetSpec
+}
+
+func (c *revisionRatioController) GetXSetSpec(api.XSetObject) *api.XSetSpec {
+	return c.spec
+}
+
+func (c *revisionRatioController) CheckReadyTime(client.Object) (bool, *metav1.Time) {
+	return true, nil
+}
+
+func newRevisionRatioUpdateInfos(revisions ...*appsv1.ControllerRevision) []*TargetUpdateInfo {
+	var infos []*TargetUpdateInfo
+	for i, revision := range revisions {
+		target := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:   "foo-" + string(rune('a'+i)),
+			Labels: map[string]string{appsv1.ControllerRevisionHashLabelKey: revision.Name},
+		}}
+		infos = append(infos, &TargetUpdateInfo{TargetWrapper: &TargetWrapper{Object: target, ID: i}, CurrentRevision: revision})
+	}
+	return infos
+}
+
+func TestDecideTargetToUpdateByRevisionRatios(t *testing.T) {
+	stable := &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: "foo-1"}}
+	updated := &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: "foo-2"}}
+	xsetController := &revisionRatioController{spec: &api.XSetSpec{
+		Replicas: ptr.To[int32](4),
+		UpdateStrategy: api.UpdateStrategy{RollingUpdate: &api.RollingUpdateStrategy{
+			ByRevisionRatios: &api.ByRevisionRatios{Revisions: []api.RevisionRatio{{Revision: stable.Name, Percent: 75}}},
+		}},
+	}}
+	r := &RealSyncControl{xsetController: xsetController}
+	r.Recorder = record.NewFakeRecorder(10)
+	syncContext := &SyncContext{Revisions: []*appsv1.ControllerRevision{stable, updated}, UpdatedRevision: updated}
+	targetRevisions := func(infos []*TargetUpdateInfo) map[string]int {
+		got := map[string]int{}
+		for _, info := range infos {
+			got[info.CurrentRevision.Name+"->"+info.UpdateRevision.Name]++
+		}
+		return got
+	}
+
+	// one of targets in stable revision is updated
+	infos := newRevisionRatioUpdateInfos(stable, stable, stable, stable)
+	candidates := r.decideTargetToUpdateByRevisionRatios(&corev1.Pod{}, syncContext, infos)
+	if want := map[string]int{"foo-1->foo-2": 1}; !reflect.DeepEqual(targetRevisions(candidates), want) {
+		t.Errorf("got candidates %v, want %v", targetRevisions(candidates), want)
+	}
+
+	// targets in updated revision beyond ratios are rolled back to stable revision
+	infos = newRevisionRatioUpdateInfos(updated, updated, updated, stable)
+	candidates = r.decideTargetToUpdateByRevisionRatios(&corev1.Pod{}, syncContext, infos)
+	if want := map[string]int{"foo-2->foo-2": 1, "foo-2->foo-1": 2}; !reflect.DeepEqual(targetRevisions(candidates), want) {
+		t.Errorf("got candidates %v, want %v", targetRevisions(candidates), want)
+	}
+	var pinned int
+	for _, candidate := range candidates {
+		if candidate.PinnedRevision {
+			pinned++
+			if candidate.IsUpdatedRevision {
+				t.Errorf("target %s pinned to revision %s should not be updated revision", candidate.GetName(), candidate.UpdateRevision.Name)
+			}
+		}
+	}
+	if pinned != 2 {
+		t.Errorf("got %d targets pinned, want 2", pinned)
+	}
+
+	// targets are not updated if pinned revision is not found
+	syncContext.Revisions = []*appsv1.ControllerRevision{updated}
+	if candidates := r.decideTargetToUpdateByRevisionRatios(&corev1.Pod{}, syncContext, newRevisionRatioUpdateInfos(stable)); candidates != nil {
+		t.Errorf("got candidates %v, want none", targetRevisions(candidates))
+	}
+}
+
+func TestGetRevisionReplicas(t *testing.T) {
+	ratios := &api.ByRevisionRatios{Revisions: []api.RevisionRatio{{Revision: "foo-1", Percent: 75}, {Revision: "foo-2", Percent: 50}}}
+	want := map[string]int32{"foo-1": 7, "foo-2": 3, "foo-3": 0}
+	if got := ratios.GetRevisionReplicas(10, "foo-3"); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetRevisionReplicas() = %v, want %v", got, want)
+	}
+}
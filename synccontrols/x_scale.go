@@ -19,6 +19,7 @@ package synccontrols
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sort"
 	"strconv"
 	"time"
@@ -44,7 +45,7 @@ import (
 // getTargetsToDelete
 // 1. finds number of diff targets from filteredTargets to do scaleIn
 // 2. finds targets allowed to scale in out of diff
-func (r *RealSyncControl) getTargetsToDelete(xsetObject api.XSetObject, filteredTargets []*TargetWrapper, replaceMapping map[string]*TargetWrapper, diff int) ([]*TargetWrapper, *time.Duration) {
+func (r *RealSyncControl) getTargetsToDelete(xsetObject api.XSetObject, updatedRevision string, filteredTargets []*TargetWrapper, replaceMapping map[string]*TargetWrapper, diff int) ([]*TargetWrapper, *time.Duration) {
 	var countedTargets []*TargetWrapper
 	var recordedRequeueAfter *time.Duration
 	for _, target := range filteredTargets {
@@ -54,7 +55,11 @@ func (r *RealSyncControl) getTargetsToDelete(xsetObject api.XSetObject, filtered
 	}
 
 	// 1. select targets to delete in first round according to diff
-	sort.Sort(newActiveTargetsForDeletion(countedTargets, r.xsetController.CheckReadyTime))
+	sortedTargets := newActiveTargetsForDeletion(countedTargets, r.xsetController.CheckReadyTime)
+	sortedTargets.zoneOf, sortedTargets.zoneCounts = r.getZoneCounts(xsetObject, filteredTargets)
+	sortedTargets.subsetOf, sortedTargets.subsetSurplus = r.getSubsetSurplus(xsetObject, filteredTargets)
+	sortedTargets.revisionOf, sortedTargets.revisionSurplus = r.getRevisionSurplus(xsetObject, updatedRevision, filteredTargets)
+	sort.Sort(sortedTargets)
 	if diff > len(countedTargets) {
 		diff = len(countedTargets)
 	}
@@ -81,7 +86,7 @@ func (r *RealSyncControl) getTargetsToDelete(xsetObject api.XSetObject, filtered
 				continue
 			}
 			// when scaleIn origin Target, newTarget should be deleted if not service available
-			if !r.xsetController.CheckAvailable(target.Object) {
+			if !IsTargetAvailable(r.xsetController, target.Object) {
 				needDeleteTargets = append(needDeleteTargets, replacePairTarget)
 			}
 		}
@@ -94,6 +99,18 @@ func (r *RealSyncControl) getTargetsToDelete(xsetObject api.XSetObject, filtered
 type ActiveTargetsForDeletion struct {
 	targets        []*TargetWrapper
 	checkReadyFunc func(object client.Object) (bool, *metav1.Time)
+
+	// zoneOf and zoneCounts are set if zone placement is enabled
+	zoneOf     func(target *TargetWrapper) string
+	zoneCounts map[string]int
+
+	// subsetOf and subsetSurplus are set if subsets are enabled
+	subsetOf      func(target *TargetWrapper) string
+	subsetSurplus map[string]int
+
+	// revisionOf and revisionSurplus are set if revisions are pinned by ratios
+	revisionOf      func(target *TargetWrapper) string
+	revisionSurplus map[string]int
 }
 
 func newActiveTargetsForDeletion(
@@ -111,7 +128,7 @@ func (s *ActiveTargetsForDeletion) Swap(i, j int) {
 	s.targets[i], s.targets[j] = s.targets[j], s.targets[i]
 }
 
-// Less sort deletion order by: targetToDelete > targetToExclude > duringScaleIn > others
+// Less sort deletion order by: targetToDelete > targetToExclude > duringScaleIn > onPreemptingNode > others
 func (s *ActiveTargetsForDeletion) Less(i, j int) bool {
 	l, r := s.targets[i], s.targets[j]
 
@@ -128,6 +145,11 @@ func (s *ActiveTargetsForDeletion) Less(i, j int) bool {
 		return l.IsDuringScaleInOps
 	}
 
+	// targets on nodes going away should be deleted before others
+	if l.OnPreemptingNode != r.OnPreemptingNode {
+		return l.OnPreemptingNode
+	}
+
 	lReady, _ := s.checkReadyFunc(l.Object)
 	rReady, _ := s.checkReadyFunc(r.Object)
 	if lReady != rReady {
@@ -143,6 +165,27 @@ func (s *ActiveTargetsForDeletion) Less(i, j int) bool {
 		}
 	}
 
+	// targets in revisions beyond their replicas by ratios the most should be deleted first
+	if s.revisionOf != nil {
+		if lSurplus, rSurplus := s.revisionSurplus[s.revisionOf(l)], s.revisionSurplus[s.revisionOf(r)]; lSurplus != rSurplus {
+			return lSurplus > rSurplus
+		}
+	}
+
+	// targets in subsets beyond their replicas the most should be deleted first
+	if s.subsetOf != nil {
+		if lSurplus, rSurplus := s.subsetSurplus[s.subsetOf(l)], s.subsetSurplus[s.subsetOf(r)]; lSurplus != rSurplus {
+			return lSurplus > rSurplus
+		}
+	}
+
+	// targets in zones with more targets should be deleted first to keep zones even
+	if s.zoneOf != nil {
+		if lCount, rCount := s.zoneCounts[s.zoneOf(l)], s.zoneCounts[s.zoneOf(r)]; lCount != rCount {
+			return lCount > rCount
+		}
+	}
+
 	// TODO consider service available timestamps
 	return CompareTarget(l.Object, r.Object, s.checkReadyFunc)
 }
@@ -239,6 +282,121 @@ func (r *RealSyncControl) includeTarget(ctx context.Context, xsetObject api.XSet
 	return r.cacheExpectations.ExpectUpdation(clientutil.ObjectKeyString(xsetObject), r.targetGVK, target.GetNamespace(), target.GetName(), target.GetResourceVersion())
 }
 
+// resolveInstanceIDs persists instance ID label for targets without it, if ID can be resolved by InstanceIDAdapter
+// and is neither used by other targets nor owned by other xsets sharing the context pool
+func (r *RealSyncControl) resolveInstanceIDs(ctx context.Context, xsetObject api.XSetObject, targets []client.Object) error {
+	adapter, ok := r.xsetController.(api.InstanceIDAdapter)
+	if !ok {
+		return nil
+	}
+
+	var targetsWithoutID []client.Object
+	for _, target := range targets {
+		if _, err := xcontrol.GetInstanceID(r.xsetLabelAnnoMgr, target); err != nil && target.GetDeletionTimestamp() == nil {
+			targetsWithoutID = append(targetsWithoutID, target)
+		}
+	}
+
+	if len(targetsWithoutID) == 0 {
+		return nil
+	}
+	coOwnedIDs, err := r.resourceContextControl.GetCoOwnedIDs(ctx, xsetObject)
+	if err != nil {
+		return fmt.Errorf("fail to get IDs owned by other xsets: %w", err)
+	}
+	usedIDs := sets.Int{}.Union(coOwnedIDs)
+
+	lookups := adapter.GetInstanceIDLookups(xsetObject)
+	for _, target := range targetsWithoutID {
+		id, err := xcontrol.GetInstanceIDWithFallback(r.xsetLabelAnnoMgr, target, lookups...)
+		// leave it to be assigned a new ID if the resolved one is in use
+		if err != nil || usedIDs.Has(id) {
+			continue
+		}
+		inUse, err := r.instanceIDInUse(ctx, xsetObject, id)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			continue
+		}
+		usedIDs.Insert(id)
+		if err := r.xControl.PatchTargetWithOptimisticLock(ctx, target, func(target client.Object) {
+			r.xsetLabelAnnoMgr.Set(target, api.XInstanceIdLabelKey, strconv.Itoa(id))
+		}); err != nil {
+			return err
+		}
+		if err := r.cacheExpectations.ExpectUpdation(clientutil.ObjectKeyString(xsetObject), r.targetGVK, target.GetNamespace(), target.GetName(), target.GetResourceVersion()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// instanceIDInUse checks by cache index if any target owned by xset holds instance ID, including inactive ones
+// which are filtered out of targets being synced
+func (r *RealSyncControl) instanceIDInUse(ctx context.Context, xsetObject api.XSetObject, id int) (bool, error) {
+	targets, err := r.xControl.GetTargetsByInstanceID(ctx, xsetObject, id)
+	if err != nil {
+		return false, fmt.Errorf("fail to get targets with instance ID %d: %w", id, err)
+	}
+	return len(targets) > 0, nil
+}
+
+// assignIDsToAdoptedTargets allocates instance IDs and context entries for targets without instance ID,
+// which are adopted from orphaned targets matching selector.
+func (r *RealSyncControl) assignIDsToAdoptedTargets(ctx context.Context, xsetObject api.XSetObject, syncContext *SyncContext) error {
+	var targetsWithoutID []client.Object
+	syncContext.CurrentIDs = sets.Int{}
+	for _, target := range syncContext.FilteredTarget {
+		if id, err := xcontrol.GetInstanceID(r.xsetLabelAnnoMgr, target); err == nil {
+			syncContext.CurrentIDs.Insert(id)
+		} else if target.GetDeletionTimestamp() == nil {
+			targetsWithoutID = append(targetsWithoutID, target)
+		}
+	}
+	if len(targetsWithoutID) == 0 {
+		return nil
+	}
+
+	availableContexts, ownedIDs, err := r.getAvailableTargetIDs(ctx, len(targetsWithoutID), xsetObject, syncContext)
+	if err != nil {
+		return err
+	}
+	syncContext.OwnedIds = ownedIDs
+
+	// available contexts may be fewer than targets if context pool is exhausted
+	for i, j := 0, 0; i < len(targetsWithoutID) && j < len(availableContexts); j++ {
+		target, contextDetail := targetsWithoutID[i], availableContexts[j]
+		// skip IDs still held by targets not in FilteredTarget, e.g., inactive ones
+		inUse, err := r.instanceIDInUse(ctx, xsetObject, contextDetail.ID)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			continue
+		}
+		i++
+		r.resourceContextControl.Remove(contextDetail, api.EnumJustCreateContextDataKey)
+		r.resourceContextControl.Put(contextDetail, api.EnumRevisionContextDataKey, xcontrol.GetTargetRevision(target, syncContext.CurrentRevision.GetName()))
+		if err := r.xControl.PatchTargetWithOptimisticLock(ctx, target, func(target client.Object) {
+			r.xsetLabelAnnoMgr.Set(target, api.XInstanceIdLabelKey, strconv.Itoa(contextDetail.ID))
+			controlByXSet(r.xsetLabelAnnoMgr, target)
+		}); err != nil {
+			return err
+		}
+		if err := r.cacheExpectations.ExpectUpdation(clientutil.ObjectKeyString(xsetObject), r.targetGVK, target.GetNamespace(), target.GetName(), target.GetResourceVersion()); err != nil {
+			return err
+		}
+		syncContext.CurrentIDs.Insert(contextDetail.ID)
+		r.Recorder.Eventf(target, corev1.EventTypeNormal, "AdoptTarget", "target is adopted by %s %s with instance ID %d", r.xsetGVK.Kind, xsetObject.GetName(), contextDetail.ID)
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		return r.resourceContextControl.UpdateToTargetContext(ctx, xsetObject, syncContext.OwnedIds)
+	})
+}
+
 // reclaimScaleStrategy updates targetToDelete, targetToExclude, targetToInclude in scaleStrategy
 func (r *RealSyncControl) reclaimScaleStrategy(ctx context.Context, deletedTargets, excludedTargets, includedTargets sets.String, xsetObject api.XSetObject) error {
 	// ReclaimScaleStrategy FeatureGate defaults to false
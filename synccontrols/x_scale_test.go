@@ -0,0 +1,160 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"kusionstack.io/kube-utils/controller/expectations"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/resourcecontexts"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+type instanceIDController struct {
+	api.XSetController
+}
+
+// GetInstanceIDLookups resolves instance ID from the ordinal suffix of target name
+func (c *instanceIDController) GetInstanceIDLookups(api.XSetObject) []api.InstanceIDLookup {
+	return []api.InstanceIDLookup{func(target client.Object) (int, bool) {
+		id, err := strconv.Atoi(target.GetName()[strings.LastIndex(target.GetName(), "-")+1:])
+		return id, err == nil
+	}}
+}
+
+type coOwnedResourceContextControl struct {
+	resourcecontexts.ResourceContextControl
+	coOwnedIDs sets.Int
+}
+
+func (c *coOwnedResourceContextControl) GetCoOwnedIDs(context.Context, api.XSetObject) (sets.Int, error) {
+	return c.coOwnedIDs, nil
+}
+
+type patchTargetControl struct {
+	xcontrol.TargetControl
+	xsetLabelAnnoMgr api.XSetLabelAnnotationManager
+	// targets are those in cache, looked up by instance ID
+	targets []client.Object
+}
+
+func (c *patchTargetControl) GetTargetsByInstanceID(_ context.Context, _ api.XSetObject, id int) ([]client.Object, error) {
+	var targets []client.Object
+	for _, target := range c.targets {
+		if targetID, err := xcontrol.GetInstanceID(c.xsetLabelAnnoMgr, target); err == nil && targetID == id {
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+func (c *patchTargetControl) PatchTargetWithOptimisticLock(_ context.Context, target client.Object, mutateFn func(target client.Object)) error {
+	mutateFn(target)
+	return nil
+}
+
+type noopExpectations struct {
+	expectations.CacheExpectationsInterface
+}
+
+func (e *noopExpectations) ExpectUpdation(string, schema.GroupVersionKind, string, string, string) error {
+	return nil
+}
+
+func (e *noopExpectations) ExpectCreation(string, schema.GroupVersionKind, string, string) error {
+	return nil
+}
+
+func (e *noopExpectations) ExpectDeletion(string, schema.GroupVersionKind, string, string) error {
+	return nil
+}
+
+// recordingExpectations records expected objects by operation, e.g., "create ConfigMap default/foo-0"
+type recordingExpectations struct {
+	noopExpectations
+	mu      sync.Mutex
+	records []string
+}
+
+func (e *recordingExpectations) record(operation string, gvk schema.GroupVersionKind, namespace, name string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.records = append(e.records, fmt.Sprintf("%s %s %s/%s", operation, gvk.Kind, namespace, name))
+	return nil
+}
+
+func (e *recordingExpectations) ExpectCreation(_ string, gvk schema.GroupVersionKind, namespace, name string) error {
+	return e.record("create", gvk, namespace, name)
+}
+
+func (e *recordingExpectations) ExpectDeletion(_ string, gvk schema.GroupVersionKind, namespace, name string) error {
+	return e.record("delete", gvk, namespace, name)
+}
+
+func (e *recordingExpectations) ExpectUpdation(_ string, gvk schema.GroupVersionKind, namespace, name, _ string) error {
+	return e.record("update", gvk, namespace, name)
+}
+
+func TestResolveInstanceIDs(t *testing.T) {
+	labelAnnoMgr := api.NewXSetLabelAnnotationManager(nil)
+	target := func(name, id string) client.Object {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: map[string]string{}}}
+		if id != "" {
+			pod.Labels[labelAnnoMgr.Value(api.XInstanceIdLabelKey)] = id
+		}
+		return pod
+	}
+	targets := []client.Object{
+		target("foo-0", "0"),
+		target("legacy-0", ""),
+		target("legacy-1", ""),
+		target("legacy-2", ""),
+		target("legacy-3", ""),
+	}
+	// inactive target is filtered out of targets, but still holds its ID in cache
+	inactive := target("foo-3", "3")
+	r := &RealSyncControl{
+		xsetController:         &instanceIDController{},
+		xsetLabelAnnoMgr:       labelAnnoMgr,
+		xControl:               &patchTargetControl{xsetLabelAnnoMgr: labelAnnoMgr, targets: append([]client.Object{inactive}, targets...)},
+		resourceContextControl: &coOwnedResourceContextControl{coOwnedIDs: sets.NewInt(2)},
+		cacheExpectations:      &noopExpectations{},
+	}
+
+	if err := r.resolveInstanceIDs(context.TODO(), &corev1.Pod{}, targets); err != nil {
+		t.Fatalf("resolveInstanceIDs() = %v", err)
+	}
+	// ID 0 is used by foo-0, ID 2 is owned by another xset sharing the pool and ID 3 is held by inactive foo-3,
+	// so only legacy-1 is resolved
+	for i, want := range []string{"0", "", "1", "", ""} {
+		if got := targets[i].GetLabels()[labelAnnoMgr.Value(api.XInstanceIdLabelKey)]; got != want {
+			t.Errorf("%s gets instance ID %q, want %q", targets[i].GetName(), got, want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+func getStuckTerminatingPolicy(xsetController api.XSetController, xset api.XSetObject) *api.StuckTerminatingPolicy {
+	if adapter, ok := xsetController.(api.StuckTerminatingAdapter); ok {
+		return adapter.GetStuckTerminatingPolicy(xset)
+	}
+	return nil
+}
+
+// stuckTerminatingRemaining returns the duration before terminating target is regarded as stuck by policy, which is
+// not positive once it is stuck
+func stuckTerminatingRemaining(policy *api.StuckTerminatingPolicy, target client.Object) (time.Duration, bool) {
+	if policy == nil || target.GetDeletionTimestamp() == nil {
+		return 0, false
+	}
+	threshold := policy.Threshold
+	if threshold <= 0 {
+		threshold = api.DefaultStuckTerminatingThreshold
+	}
+	return time.Until(target.GetDeletionTimestamp().Add(threshold)), true
+}
+
+// isStuckTerminating returns true if target stays terminating beyond threshold of policy
+func isStuckTerminating(policy *api.StuckTerminatingPolicy, target client.Object) bool {
+	remaining, terminating := stuckTerminatingRemaining(policy, target)
+	return terminating && remaining <= 0
+}
+
+// isForceDeleted returns true if target is deleted with grace period 0, e.g., force deleted as stuck target
+func isForceDeleted(target client.Object) bool {
+	return target.GetDeletionTimestamp() != nil && ptr.Deref(target.GetDeletionGracePeriodSeconds(), 1) == 0
+}
+
+// releasesStuckTerminatingID returns true if instance ID of stuck target is released to its replacement. ID is
+// released only once stuck target is force deleted, so that volumes bound to the ID, e.g., ReadWriteOnce PVCs, are not
+// shared by the replacement while processes of stuck target may still be running.
+func releasesStuckTerminatingID(policy *api.StuckTerminatingPolicy, target client.Object) bool {
+	return policy != nil && policy.IDPolicy == api.StuckTerminatingReleaseID && policy.ForceDelete && isForceDeleted(target)
+}
+
+// FilterOutStuckTerminatingTargetWrappers filters out targets stuck in terminating, which are not counted in scaling
+// and updating
+func FilterOutStuckTerminatingTargetWrappers(targets []*TargetWrapper) []*TargetWrapper {
+	var filteredTargetWrappers []*TargetWrapper
+	for i, target := range targets {
+		if target.StuckTerminating {
+			continue
+		}
+		filteredTargetWrappers = append(filteredTargetWrappers, targets[i])
+	}
+	return filteredTargetWrappers
+}
+
+// handleStuckTerminatingTargets records targets stuck in terminating for TargetsStuckTerminating condition, and force
+// deletes them if policy opts in. Targets about to be stuck are rechecked once they are.
+func (r *RealSyncControl) handleStuckTerminatingTargets(ctx context.Context, xsetObject api.XSetObject, syncContext *SyncContext, policy *api.StuckTerminatingPolicy) error {
+	syncContext.stuckTerminatingTargets = nil
+	if policy == nil {
+		return nil
+	}
+	var errs []error
+	for _, target := range syncContext.FilteredTarget {
+		remaining, terminating := stuckTerminatingRemaining(policy, target)
+		if !terminating {
+			continue
+		}
+		if remaining > 0 {
+			syncContext.RecheckStuckTerminatingAfter = xcontrol.GetShorterDuration(syncContext.RecheckStuckTerminatingAfter, &remaining)
+			continue
+		}
+		syncContext.stuckTerminatingTargets = append(syncContext.stuckTerminatingTargets, target.GetName())
+		// targets already force deleted are waiting for finalizers
+		if !policy.ForceDelete || isForceDeleted(target) {
+			continue
+		}
+		if err := r.xControl.DeleteTarget(ctx, target, targetDeleteOptions(target, ptr.To[int64](0))...); err != nil {
+			errs = append(errs, fmt.Errorf("fail to force delete stuck target %s: %w", target.GetName(), err))
+			continue
+		}
+		r.Recorder.Eventf(xsetObject, corev1.EventTypeWarning, "StuckTargetForceDeleted", "target %s/%s terminating since %s is force deleted",
+			target.GetNamespace(), target.GetName(), target.GetDeletionTimestamp().UTC().Format(time.RFC3339))
+	}
+	return errors.Join(errs...)
+}
+
+// calculateStuckTerminatingCondition reports targets stuck in terminating in TargetsStuckTerminating condition
+func (r *RealSyncControl) calculateStuckTerminatingCondition(instance api.XSetObject, syncContext *SyncContext, newStatus *api.XSetStatus) {
+	if getStuckTerminatingPolicy(r.xsetController, instance) == nil {
+		conditions.Remove(newStatus, api.XSetTargetsStuckTerminating)
+		return
+	}
+	if syncContext.SyncSkipped {
+		return
+	}
+	if stuck := syncContext.stuckTerminatingTargets; len(stuck) > 0 {
+		sort.Strings(stuck)
+		conditions.Set(newStatus, api.XSetTargetsStuckTerminating, metav1.ConditionTrue, conditions.ReasonTargetsStuckTerminating,
+			fmt.Sprintf("%d target(s) stuck in terminating: %s", len(stuck), truncateList(stuck)), instance.GetGeneration())
+	} else {
+		conditions.Set(newStatus, api.XSetTargetsStuckTerminating, metav1.ConditionFalse, conditions.ReasonNoTargetStuckTerminating, "no target stuck in terminating", instance.GetGeneration())
+	}
+}
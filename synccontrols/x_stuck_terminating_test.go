@@ -0,0 +1,140 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+	"kusionstack.io/kube-xset/testing/fake"
+)
+
+type stuckTerminatingController struct {
+	api.XSetController
+	policy *api.StuckTerminatingPolicy
+}
+
+func (c *stuckTerminatingController) XMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+}
+
+func (c *stuckTerminatingController) GetStuckTerminatingPolicy(api.XSetObject) *api.StuckTerminatingPolicy {
+	return c.policy
+}
+
+func terminatingPod(name string, since time.Duration, gracePeriodSeconds int64) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "default", Name: name, UID: types.UID("uid-" + name), ResourceVersion: "1",
+		DeletionTimestamp:          ptr.To(metav1.NewTime(time.Now().Add(-since))),
+		DeletionGracePeriodSeconds: ptr.To(gracePeriodSeconds),
+	}}
+}
+
+func TestHandleStuckTerminatingTargets(t *testing.T) {
+	targets := []client.Object{
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-0"}},
+		terminatingPod("foo-1", time.Minute, 30),
+		terminatingPod("foo-2", time.Hour, 30),
+		terminatingPod("foo-3", time.Hour, 0),
+	}
+	xsetController := &stuckTerminatingController{policy: &api.StuckTerminatingPolicy{Threshold: 10 * time.Minute}}
+	targetControl := fake.NewTargetControl(xsetController, targets...)
+	r := &RealSyncControl{xsetController: xsetController, xControl: targetControl}
+	r.Recorder = record.NewFakeRecorder(10)
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Generation: 1}}
+	syncContext := &SyncContext{FilteredTarget: targets, NewStatus: &api.XSetStatus{}}
+
+	// stuck targets are reported, and the one about to be stuck is rechecked
+	if err := r.handleStuckTerminatingTargets(context.TODO(), xset, syncContext, xsetController.policy); err != nil {
+		t.Fatalf("handleStuckTerminatingTargets() = %v", err)
+	}
+	r.calculateStuckTerminatingCondition(xset, syncContext, syncContext.NewStatus)
+	if !conditions.IsTrue(syncContext.NewStatus, api.XSetTargetsStuckTerminating) {
+		t.Errorf("expect TargetsStuckTerminating condition true")
+	}
+	if got := syncContext.stuckTerminatingTargets; len(got) != 2 || got[0] != "foo-2" || got[1] != "foo-3" {
+		t.Errorf("got stuck targets %v", got)
+	}
+	if recheck := syncContext.RecheckStuckTerminatingAfter; recheck == nil || *recheck > 9*time.Minute || *recheck < 8*time.Minute {
+		t.Errorf("expect recheck after about 9m, got %v", recheck)
+	}
+	if calls := targetControl.CallsOf("DeleteTarget"); len(calls) != 0 {
+		t.Errorf("expect no force deletion without opt-in, got %d", len(calls))
+	}
+
+	// stuck targets not force deleted yet are force deleted with grace period 0 once opted in
+	xsetController.policy.ForceDelete = true
+	if err := r.handleStuckTerminatingTargets(context.TODO(), xset, syncContext, xsetController.policy); err != nil {
+		t.Fatalf("handleStuckTerminatingTargets() = %v", err)
+	}
+	calls := targetControl.CallsOf("DeleteTarget")
+	if len(calls) != 1 || calls[0].Args[0].(client.Object).GetName() != "foo-2" {
+		t.Fatalf("expect foo-2 force deleted, got %v", calls)
+	}
+	deleteOpts := &client.DeleteOptions{}
+	deleteOpts.ApplyOptions(calls[0].Args[1].([]client.DeleteOption))
+	if ptr.Deref(deleteOpts.GracePeriodSeconds, -1) != 0 {
+		t.Errorf("expect grace period 0, got %v", deleteOpts.GracePeriodSeconds)
+	}
+
+	// condition is removed once policy is disabled
+	xsetController.policy = nil
+	r.calculateStuckTerminatingCondition(xset, syncContext, syncContext.NewStatus)
+	if conditions.Get(syncContext.NewStatus, api.XSetTargetsStuckTerminating) != nil {
+		t.Errorf("expect TargetsStuckTerminating condition removed")
+	}
+}
+
+func TestFilterOutStuckTerminatingTargetWrappers(t *testing.T) {
+	targets := []*TargetWrapper{{ID: 0}, {ID: 1, StuckTerminating: true}, {ID: 2}}
+	if got := FilterOutStuckTerminatingTargetWrappers(targets); len(got) != 2 || got[0].ID != 0 || got[1].ID != 2 {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestReleasesStuckTerminatingID(t *testing.T) {
+	gracefullyDeleted := terminatingPod("foo-0", time.Hour, 30)
+	forceDeleted := terminatingPod("foo-1", time.Hour, 0)
+	tests := []struct {
+		name   string
+		policy *api.StuckTerminatingPolicy
+		target client.Object
+		want   bool
+	}{
+		{name: "retain", policy: &api.StuckTerminatingPolicy{ForceDelete: true}, target: forceDeleted},
+		{name: "release without force deletion", policy: &api.StuckTerminatingPolicy{IDPolicy: api.StuckTerminatingReleaseID}, target: forceDeleted},
+		{name: "release before force deleted", policy: &api.StuckTerminatingPolicy{IDPolicy: api.StuckTerminatingReleaseID, ForceDelete: true}, target: gracefullyDeleted},
+		{name: "release once force deleted", policy: &api.StuckTerminatingPolicy{IDPolicy: api.StuckTerminatingReleaseID, ForceDelete: true}, target: forceDeleted, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := releasesStuckTerminatingID(tt.policy, tt.target); got != tt.want {
+				t.Errorf("releasesStuckTerminatingID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"slices"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+func getSubsets(setController api.XSetController, owner api.XSetObject) []api.Subset {
+	adapter, ok := setController.(api.SubsetAdapter)
+	if !ok {
+		return nil
+	}
+	return adapter.GetSubsets(owner)
+}
+
+// getSubsetReplicas returns the number of instances of each subset out of replicas. Subsets with replicas are served
+// in order, and those without replicas evenly share the rest, earlier ones getting the remainder.
+func getSubsetReplicas(subsets []api.Subset, replicas int) map[string]int {
+	subsetReplicas := map[string]int{}
+	left := replicas
+	var shared []string
+	for _, subset := range subsets {
+		if subset.Replicas == nil {
+			shared = append(shared, subset.Name)
+			continue
+		}
+		count, err := intstr.GetScaledValueFromIntOrPercent(subset.Replicas, replicas, false)
+		if err != nil {
+			count = 0
+		}
+		count = min(max(count, 0), left)
+		subsetReplicas[subset.Name] = count
+		left -= count
+	}
+	for i, name := range shared {
+		subsetReplicas[name] = left / len(shared)
+		if i < left%len(shared) {
+			subsetReplicas[name]++
+		}
+	}
+	return subsetReplicas
+}
+
+// getSubsetSurplus returns the subset getter and the number of targets in each subset beyond its replicas, which is
+// negative for subsets lacking targets, or nil if subsets are disabled. Targets in no subset are all surplus.
+func (r *RealSyncControl) getSubsetSurplus(owner api.XSetObject, targets []*TargetWrapper) (func(*TargetWrapper) string, map[string]int) {
+	subsets := getSubsets(r.xsetController, owner)
+	if len(subsets) == 0 {
+		return nil, nil
+	}
+	subsetOf := func(target *TargetWrapper) string {
+		if target.ContextDetail == nil {
+			return ""
+		}
+		subset, _ := r.resourceContextControl.Get(target.ContextDetail, api.EnumSubsetContextDataKey)
+		return subset
+	}
+	surplus := map[string]int{}
+	for name, replicas := range getSubsetReplicas(subsets, int(ptr.Deref(r.xsetController.GetXSetSpec(owner).Replicas, 0))) {
+		surplus[name] = -replicas
+	}
+	for _, target := range targets {
+		surplus[subsetOf(target)]++
+	}
+	return subsetOf, surplus
+}
+
+// assignSubsets records a subset for each context to create target with, choosing the subset lacking the most
+// targets. Contexts with a subset still existing keep their subset. It returns true if any context is changed.
+func (r *RealSyncControl) assignSubsets(owner api.XSetObject, activeTargets []*TargetWrapper, contexts []*api.ContextDetail) bool {
+	subsets := getSubsets(r.xsetController, owner)
+	if len(subsets) == 0 {
+		return false
+	}
+
+	_, surplus := r.getSubsetSurplus(owner, activeTargets)
+	changed := false
+	for _, contextDetail := range contexts {
+		if subset, exist := r.resourceContextControl.Get(contextDetail, api.EnumSubsetContextDataKey); exist &&
+			slices.ContainsFunc(subsets, func(s api.Subset) bool { return s.Name == subset }) {
+			surplus[subset]++
+			continue
+		}
+		subset := subsets[0].Name
+		for _, s := range subsets[1:] {
+			if surplus[s.Name] < surplus[subset] {
+				subset = s.Name
+			}
+		}
+		surplus[subset]++
+		r.resourceContextControl.Put(contextDetail, api.EnumSubsetContextDataKey, subset)
+		changed = true
+	}
+	return changed
+}
+
+// subsetLabeler returns the function labeling target with the subset recorded in context, which is expected to run
+// before patches of subsets are selected by GetTemplatePatcher
+func (r *RealSyncControl) subsetLabeler(contextDetail *api.ContextDetail) func(client.Object) error {
+	return func(target client.Object) error {
+		if contextDetail == nil {
+			return nil
+		}
+		subset, exist := r.resourceContextControl.Get(contextDetail, api.EnumSubsetContextDataKey)
+		if !exist || subset == "" {
+			return nil
+		}
+		labels := target.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[api.TargetSubsetLabelKey] = subset
+		target.SetLabels(labels)
+		return nil
+	}
+}
+
+// getSubsetPatches returns patches of the subset target is labeled with, named after the subset so that they are
+// recorded apart from patches of TemplatePatchAdapter
+func getSubsetPatches(subsets []api.Subset, target client.Object) []api.TemplatePatch {
+	name, exist := target.GetLabels()[api.TargetSubsetLabelKey]
+	if !exist {
+		return nil
+	}
+	for _, subset := range subsets {
+		if subset.Name != name {
+			continue
+		}
+		patches := make([]api.TemplatePatch, 0, len(subset.Patches))
+		for _, patch := range subset.Patches {
+			patch.Name = subset.Name + "/" + patch.Name
+			patches = append(patches, patch)
+		}
+		return patches
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/resourcecontexts"
+)
+
+type subsetController struct {
+	api.XSetController
+	replicas int32
+	subsets  []api.Subset
+}
+
+func (c *subsetController) GetXSetSpec(api.XSetObject) *api.XSetSpec {
+	return &api.XSetSpec{Replicas: ptr.To(c.replicas)}
+}
+
+func (c *subsetController) GetXSetTemplatePatcher(metav1.Object) func(client.Object) error {
+	return nil
+}
+
+func (c *subsetController) GetSubsets(api.XSetObject) []api.Subset {
+	return c.subsets
+}
+
+func TestGetSubsetReplicas(t *testing.T) {
+	tests := []struct {
+		name     string
+		subsets  []api.Subset
+		replicas int
+		want     map[string]int
+	}{
+		{
+			name:     "even share",
+			subsets:  []api.Subset{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+			replicas: 5,
+			want:     map[string]int{"a": 2, "b": 2, "c": 1},
+		},
+		{
+			name: "absolute and percentage",
+			subsets: []api.Subset{
+				{Name: "a", Replicas: ptr.To(intstr.FromInt(2))},
+				{Name: "b", Replicas: ptr.To(intstr.FromString("50%"))},
+				{Name: "c"},
+			},
+			replicas: 9,
+			want:     map[string]int{"a": 2, "b": 4, "c": 3},
+		},
+		{
+			name: "capped by replicas",
+			subsets: []api.Subset{
+				{Name: "a", Replicas: ptr.To(intstr.FromInt(4))},
+				{Name: "b", Replicas: ptr.To(intstr.FromInt(4))},
+				{Name: "c"},
+			},
+			replicas: 6,
+			want:     map[string]int{"a": 4, "b": 2, "c": 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getSubsetReplicas(tt.subsets, tt.replicas); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getSubsetReplicas() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssignSubsets(t *testing.T) {
+	xsetController := &subsetController{
+		replicas: 4,
+		subsets: []api.Subset{
+			{Name: "zone-a", Replicas: ptr.To(intstr.FromInt(1))},
+			{Name: "zone-b", Patches: []api.TemplatePatch{{
+				Name:  "node-pool",
+				Type:  api.MergePatchType,
+				Patch: []byte(`{"spec":{"nodeSelector":{"pool":"b"}}}`),
+			}}},
+		},
+	}
+	labelAnnoMgr := api.NewXSetLabelAnnotationManager(nil)
+	rcControl := resourcecontexts.NewStatelessResourceContextControl(xsetController, &resourcecontexts.DefaultResourceContextAdapter{}, labelAnnoMgr)
+	r := &RealSyncControl{
+		xsetController:         xsetController,
+		xsetLabelAnnoMgr:       labelAnnoMgr,
+		resourceContextControl: rcControl,
+	}
+	owner := &corev1.Pod{}
+
+	existing := &api.ContextDetail{ID: 0}
+	rcControl.Put(existing, api.EnumSubsetContextDataKey, "zone-a")
+	activeTargets := []*TargetWrapper{{ContextDetail: existing}}
+	contexts := []*api.ContextDetail{{ID: 1}, {ID: 2}, {ID: 3}}
+	if !r.assignSubsets(owner, activeTargets, contexts) {
+		t.Fatalf("assignSubsets() should change contexts")
+	}
+	for _, detail := range contexts {
+		if subset, _ := rcControl.Get(detail, api.EnumSubsetContextDataKey); subset != "zone-b" {
+			t.Errorf("ID %d is assigned to subset %q, want zone-b", detail.ID, subset)
+		}
+	}
+	if r.assignSubsets(owner, activeTargets, contexts) {
+		t.Errorf("assignSubsets() should keep subsets of contexts")
+	}
+
+	// targets in zone-a beyond its replicas are scaled in first
+	subsetOf, surplus := r.getSubsetSurplus(owner, append(activeTargets, &TargetWrapper{ContextDetail: existing}))
+	if subsetOf(activeTargets[0]) != "zone-a" || surplus["zone-a"] != 1 || surplus["zone-b"] != -3 {
+		t.Errorf("got surplus %v, want zone-a 1 and zone-b -3", surplus)
+	}
+
+	target := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}}}
+	if err := r.subsetLabeler(contexts[0])(target); err != nil {
+		t.Fatalf("subsetLabeler() = %v", err)
+	}
+	if err := GetTemplatePatcher(xsetController, owner)(target); err != nil {
+		t.Fatalf("template patcher = %v", err)
+	}
+	if target.Labels[api.TargetSubsetLabelKey] != "zone-b" || target.Spec.NodeSelector["pool"] != "b" {
+		t.Errorf("target is not patched by subset zone-b: %v, %v", target.Labels, target.Spec.NodeSelector)
+	}
+}
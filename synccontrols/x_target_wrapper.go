@@ -0,0 +1,88 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/opslifecycle"
+)
+
+const (
+	// ParallelTargetWrapperThreshold is the min number of targets to build target wrappers and update infos in parallel,
+	// in which GetXOpsPriority, DecorationAdapter and PvcControl.IsTargetPvcTmpChanged are called concurrently
+	ParallelTargetWrapperThreshold = 200
+	// MaxConcurrentTargetWrapperWorkers is the max number of workers building target wrappers and update infos
+	MaxConcurrentTargetWrapperWorkers = 16
+)
+
+// targetWrapperWorkers returns the number of workers to build wrappers of count targets
+func targetWrapperWorkers(count int) int {
+	if count < ParallelTargetWrapperThreshold {
+		return 1
+	}
+	return MaxConcurrentTargetWrapperWorkers
+}
+
+// targetWrapperInfo is the part of TargetWrapper resolved independently for each target
+type targetWrapperInfo struct {
+	DecorationInfo
+	opsPriority        *api.OpsPriority
+	isDuringScaleInOps bool
+	isDuringUpdateOps  bool
+	onPreemptingNode   bool
+}
+
+// resolveTargetWrapperInfos resolves decoration revisions, ops priority, ops lifecycle and node preemption of targets,
+// with a bounded worker pool for large xsets. Targets not selected are skipped and their infos are nil.
+func (r *RealSyncControl) resolveTargetWrapperInfos(ctx context.Context, xsetObject api.XSetObject, targets []client.Object, selected func(target client.Object) bool) ([]*targetWrapperInfo, error) {
+	decorationAdapter, decorationEnabled := GetDecorationAdapter(r.xsetController)
+	preemptionTaintKeys := getPreemptionTaintKeys(r.xsetController, xsetObject)
+	infos := make([]*targetWrapperInfo, len(targets))
+	_, err := BoundedParallelize(len(targets), targetWrapperWorkers(len(targets)), func(i int) (err error) {
+		target := targets[i]
+		if !selected(target) {
+			return nil
+		}
+		info := &targetWrapperInfo{}
+		if decorationEnabled {
+			if info.DecorationCurrentRevisions, err = decorationAdapter.GetTargetCurrentDecorationRevisions(ctx, r.Client, target); err != nil {
+				return err
+			}
+			if info.DecorationUpdatedRevisions, err = decorationAdapter.GetTargetUpdatedDecorationRevisions(ctx, r.Client, target); err != nil {
+				return err
+			}
+			if info.DecorationChanged, err = decorationAdapter.IsTargetDecorationChanged(info.DecorationCurrentRevisions, info.DecorationUpdatedRevisions); err != nil {
+				return err
+			}
+		}
+		if info.opsPriority, err = r.xsetController.GetXOpsPriority(ctx, r.Client, target); err != nil {
+			return err
+		}
+		info.isDuringScaleInOps = opslifecycle.IsDuringOps(r.updateConfig.XsetLabelAnnoMgr, r.scaleInLifecycleAdapter, target)
+		info.isDuringUpdateOps = opslifecycle.IsDuringOps(r.updateConfig.XsetLabelAnnoMgr, r.updateLifecycleAdapter, target)
+		if info.onPreemptingNode, err = r.isOnPreemptingNode(ctx, target, preemptionTaintKeys); err != nil {
+			return err
+		}
+		infos[i] = info
+		return nil
+	})
+	return infos, err
+}
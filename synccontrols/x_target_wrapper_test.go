@@ -0,0 +1,127 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/opslifecycle"
+)
+
+// wrapperController returns ops priority of targets from their names, and records max concurrent calls of it
+type wrapperController struct {
+	multiDecorationController
+	mu          sync.Mutex
+	inflight    int32
+	maxInflight int32
+}
+
+func (c *wrapperController) XSetMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "apps.kusionstack.io/v1alpha1", Kind: "XSet"}
+}
+
+func (c *wrapperController) GetXOpsPriority(_ context.Context, _ client.Client, target client.Object) (*api.OpsPriority, error) {
+	inflight := atomic.AddInt32(&c.inflight, 1)
+	defer atomic.AddInt32(&c.inflight, -1)
+	c.mu.Lock()
+	c.maxInflight = max(c.maxInflight, inflight)
+	c.mu.Unlock()
+	time.Sleep(time.Millisecond)
+
+	var id int32
+	if _, err := fmt.Sscanf(target.GetName(), "foo-%d", &id); err != nil {
+		return nil, err
+	}
+	return &api.OpsPriority{DeletionCost: id}, nil
+}
+
+func TestTargetWrapperWorkers(t *testing.T) {
+	if got := targetWrapperWorkers(ParallelTargetWrapperThreshold - 1); got != 1 {
+		t.Errorf("targetWrapperWorkers() below threshold = %d, want 1", got)
+	}
+	if got := targetWrapperWorkers(ParallelTargetWrapperThreshold); got != MaxConcurrentTargetWrapperWorkers {
+		t.Errorf("targetWrapperWorkers() at threshold = %d, want %d", got, MaxConcurrentTargetWrapperWorkers)
+	}
+}
+
+func TestResolveTargetWrapperInfos(t *testing.T) {
+	for _, count := range []int{ParallelTargetWrapperThreshold - 1, ParallelTargetWrapperThreshold} {
+		t.Run(strconv.Itoa(count), func(t *testing.T) {
+			xsetController := &wrapperController{multiDecorationController: multiDecorationController{adapters: []api.DecorationAdapter{
+				&labelDecoration{kind: "Sidecar", updatedRevision: "rev-2"},
+				&labelDecoration{kind: "Config", updatedRevision: "rev-1"},
+			}}}
+			labelAnnoMgr := api.NewXSetLabelAnnotationManager(nil)
+			updateLifecycleAdapter, scaleInLifecycleAdapter := opslifecycle.GetLifecycleAdapters(xsetController, labelAnnoMgr, xsetController.XSetMeta())
+			r := &RealSyncControl{
+				xsetController:          xsetController,
+				xsetLabelAnnoMgr:        labelAnnoMgr,
+				updateConfig:            &UpdateConfig{XsetLabelAnnoMgr: labelAnnoMgr},
+				scaleInLifecycleAdapter: scaleInLifecycleAdapter,
+				updateLifecycleAdapter:  updateLifecycleAdapter,
+			}
+
+			targets := make([]client.Object, count)
+			for i := range targets {
+				revision := "rev-1"
+				if i%2 == 0 {
+					revision = "rev-2"
+				}
+				targets[i] = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      fmt.Sprintf("foo-%d", i),
+					Labels:    map[string]string{"Sidecar": revision, "Config": "rev-1"},
+				}}
+			}
+			infos, err := r.resolveTargetWrapperInfos(context.TODO(), &corev1.Pod{}, targets, func(target client.Object) bool {
+				return target.GetName() != "foo-1"
+			})
+			if err != nil {
+				t.Fatalf("resolveTargetWrapperInfos() = %v", err)
+			}
+
+			for i, info := range infos {
+				if i == 1 {
+					if info != nil {
+						t.Errorf("info of target not selected = %v, want nil", info)
+					}
+					continue
+				}
+				if info.opsPriority == nil || info.opsPriority.DeletionCost != int32(i) {
+					t.Errorf("ops priority of target %d = %v, want deletion cost %d", i, info.opsPriority, i)
+				}
+				if wantChanged := i%2 == 1; info.DecorationChanged != wantChanged {
+					t.Errorf("decoration of target %d changed = %v, want %v", i, info.DecorationChanged, wantChanged)
+				}
+			}
+			if wantParallel := count >= ParallelTargetWrapperThreshold; (xsetController.maxInflight > 1) != wantParallel {
+				t.Errorf("max concurrent calls of GetXOpsPriority = %d, want parallel %v", xsetController.maxInflight, wantParallel)
+			}
+		})
+	}
+}
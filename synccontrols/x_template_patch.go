@@ -0,0 +1,195 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+// GetTemplatePatcher returns patcher which applies GetXSetTemplatePatcher, then patches of TemplatePatchAdapter and
+// patches of the subset target belongs to
+func GetTemplatePatcher(xsetController api.XSetController, xset api.XSetObject) func(client.Object) error {
+	patcher := xsetController.GetXSetTemplatePatcher(xset)
+	adapter, ok := xsetController.(api.TemplatePatchAdapter)
+	subsets := getSubsets(xsetController, xset)
+	if !ok && len(subsets) == 0 {
+		return patcher
+	}
+	var patches []api.TemplatePatch
+	if ok {
+		patches = adapter.GetTemplatePatches(xset)
+	}
+	xsetLabelAnnoMgr := api.GetXSetLabelAnnotationManager(xsetController)
+	return func(target client.Object) error {
+		if patcher != nil {
+			if err := patcher(target); err != nil {
+				return err
+			}
+		}
+		selected, err := selectTemplatePatches(append(slices.Clip(patches), getSubsetPatches(subsets, target)...), xsetLabelAnnoMgr, target)
+		if err != nil {
+			return err
+		}
+		return applyTemplatePatches(target, selected)
+	}
+}
+
+// selectTemplatePatches returns patches selecting instance ID of target. Patches with InstanceIDs are skipped for
+// target without instance ID.
+func selectTemplatePatches(patches []api.TemplatePatch, xsetLabelAnnoMgr api.XSetLabelAnnotationManager, target client.Object) ([]api.TemplatePatch, error) {
+	id, idErr := xcontrol.GetInstanceID(xsetLabelAnnoMgr, target)
+	selected := make([]api.TemplatePatch, 0, len(patches))
+	for _, patch := range patches {
+		if patch.InstanceIDs == "" {
+			selected = append(selected, patch)
+			continue
+		}
+		if idErr != nil {
+			continue
+		}
+		match, err := instanceIDsMatch(patch.InstanceIDs, id)
+		if err != nil {
+			return nil, fmt.Errorf("fail to select instances of template patch %s: %w", patch.Name, err)
+		}
+		if match {
+			selected = append(selected, patch)
+		}
+	}
+	return selected, nil
+}
+
+// instanceIDsMatch checks whether id is selected by comma separated IDs or ID ranges, e.g., "0,3-5"
+func instanceIDsMatch(instanceIDs string, id int) (bool, error) {
+	for _, item := range strings.Split(instanceIDs, ",") {
+		item = strings.TrimSpace(item)
+		lower, upper, isRange := strings.Cut(item, "-")
+		from, err := strconv.Atoi(strings.TrimSpace(lower))
+		if err != nil {
+			return false, fmt.Errorf("invalid instance ID %q", item)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(strings.TrimSpace(upper)); err != nil || to < from {
+				return false, fmt.Errorf("invalid instance ID range %q", item)
+			}
+		}
+		if from <= id && id <= to {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// applyTemplatePatches applies patches which are not recorded in TargetAppliedPatchesAnnotationKey of target, and
+// records all of them afterward
+func applyTemplatePatches(target client.Object, patches []api.TemplatePatch) error {
+	applied := sets.NewString()
+	if value := target.GetAnnotations()[api.TargetAppliedPatchesAnnotationKey]; value != "" {
+		applied.Insert(strings.Split(value, ",")...)
+	}
+
+	records := make([]string, 0, len(patches))
+	for _, patch := range patches {
+		record := fmt.Sprintf("%s:%s", patch.Name, templatePatchHash(patch))
+		records = append(records, record)
+		if applied.Has(record) {
+			continue
+		}
+		if err := applyTemplatePatch(target, patch); err != nil {
+			return fmt.Errorf("fail to apply template patch %s: %w", patch.Name, err)
+		}
+	}
+
+	annotations := target.GetAnnotations()
+	if len(records) == 0 {
+		if _, exist := annotations[api.TargetAppliedPatchesAnnotationKey]; exist {
+			delete(annotations, api.TargetAppliedPatchesAnnotationKey)
+			target.SetAnnotations(annotations)
+		}
+		return nil
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[api.TargetAppliedPatchesAnnotationKey] = strings.Join(records, ",")
+	target.SetAnnotations(annotations)
+	return nil
+}
+
+func applyTemplatePatch(target client.Object, patch api.TemplatePatch) error {
+	original, err := json.Marshal(target)
+	if err != nil {
+		return fmt.Errorf("fail to marshal target: %w", err)
+	}
+
+	var patched []byte
+	switch patch.Type {
+	case api.JSONPatchType:
+		jsonPatch, err := jsonpatch.DecodePatch(patch.Patch)
+		if err != nil {
+			return fmt.Errorf("fail to decode json patch: %w", err)
+		}
+		patched, err = jsonPatch.Apply(original)
+		if err != nil {
+			return err
+		}
+	case api.MergePatchType:
+		if patched, err = jsonpatch.MergePatch(original, patch.Patch); err != nil {
+			return err
+		}
+	case api.StrategicMergePatchType:
+		if _, unstructured := target.(runtime.Unstructured); unstructured {
+			return fmt.Errorf("strategic merge patch is not supported for unstructured target")
+		}
+		if patched, err = strategicpatch.StrategicMergePatch(original, patch.Patch, target); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported patch type %q", patch.Type)
+	}
+
+	// decode into a new object, so that fields removed by patch are not left over
+	result := reflect.New(reflect.TypeOf(target).Elem()).Interface()
+	if err = json.Unmarshal(patched, result); err != nil {
+		return fmt.Errorf("fail to unmarshal patched target: %w", err)
+	}
+	reflect.ValueOf(target).Elem().Set(reflect.ValueOf(result).Elem())
+	return nil
+}
+
+func templatePatchHash(patch api.TemplatePatch) string {
+	hf := fnv.New32()
+	_, _ = hf.Write([]byte(patch.Type))
+	_, _ = hf.Write(patch.Patch)
+	return rand.SafeEncodeString(fmt.Sprint(hf.Sum32()))
+}
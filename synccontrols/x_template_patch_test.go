@@ -0,0 +1,175 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+func TestApplyTemplatePatches(t *testing.T) {
+	newTarget := func() *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "foo", "tier": "web"}},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: "app:v1"},
+				{Name: "sidecar", Image: "sidecar:v1"},
+			}},
+		}
+	}
+	patches := []api.TemplatePatch{
+		{Name: "json", Type: api.JSONPatchType, Patch: []byte(`[{"op":"add","path":"/spec/tolerations/-","value":{"key":"dedicated","operator":"Exists"}}]`)},
+		{Name: "merge", Type: api.MergePatchType, Patch: []byte(`{"metadata":{"labels":{"tier":null}}}`)},
+		{Name: "strategic", Type: api.StrategicMergePatchType, Patch: []byte(`{"spec":{"containers":[{"name":"sidecar","image":"sidecar:v2"}]}}`)},
+	}
+
+	target := newTarget()
+	target.Spec.Tolerations = []corev1.Toleration{{Key: "zone", Operator: corev1.TolerationOpExists}}
+	if err := applyTemplatePatches(target, patches); err != nil {
+		t.Fatalf("applyTemplatePatches() = %v", err)
+	}
+	if len(target.Spec.Tolerations) != 2 || target.Spec.Tolerations[1].Key != "dedicated" {
+		t.Errorf("got tolerations %v, want json patch applied", target.Spec.Tolerations)
+	}
+	if _, exist := target.Labels["tier"]; exist || target.Labels["app"] != "foo" {
+		t.Errorf("got labels %v, want tier removed by merge patch", target.Labels)
+	}
+	if len(target.Spec.Containers) != 2 || target.Spec.Containers[0].Image != "app:v1" || target.Spec.Containers[1].Image != "sidecar:v2" {
+		t.Errorf("got containers %v, want sidecar merged by name", target.Spec.Containers)
+	}
+	records := strings.Split(target.Annotations[api.TargetAppliedPatchesAnnotationKey], ",")
+	if len(records) != len(patches) || !strings.HasPrefix(records[0], "json:") {
+		t.Fatalf("got applied patches %v, want all patches recorded in order", records)
+	}
+
+	// recorded patches are not applied again, e.g., json patch appending to a list
+	if err := applyTemplatePatches(target, patches); err != nil || len(target.Spec.Tolerations) != 2 {
+		t.Errorf("got tolerations %v, %v, want json patch applied once", target.Spec.Tolerations, err)
+	}
+
+	// changed patch is applied again, and record is removed once no patch is left
+	patches[0].Patch = []byte(`[{"op":"add","path":"/spec/tolerations/-","value":{"key":"gpu","operator":"Exists"}}]`)
+	if err := applyTemplatePatches(target, patches); err != nil || len(target.Spec.Tolerations) != 3 {
+		t.Errorf("got tolerations %v, %v, want changed json patch applied", target.Spec.Tolerations, err)
+	}
+	if err := applyTemplatePatches(target, nil); err != nil {
+		t.Fatalf("applyTemplatePatches() = %v", err)
+	}
+	if _, exist := target.Annotations[api.TargetAppliedPatchesAnnotationKey]; exist {
+		t.Errorf("got annotations %v, want applied patches record removed", target.Annotations)
+	}
+}
+
+func TestApplyTemplatePatchErrors(t *testing.T) {
+	tests := map[string]struct {
+		target client.Object
+		patch  api.TemplatePatch
+	}{
+		"unsupported type": {
+			target: &corev1.Pod{},
+			patch:  api.TemplatePatch{Name: "foo", Type: "yaml", Patch: []byte(`{}`)},
+		},
+		"invalid json patch": {
+			target: &corev1.Pod{},
+			patch:  api.TemplatePatch{Name: "foo", Type: api.JSONPatchType, Patch: []byte(`{}`)},
+		},
+		"strategic merge patch on unstructured": {
+			target: &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Pod"}},
+			patch:  api.TemplatePatch{Name: "foo", Type: api.StrategicMergePatchType, Patch: []byte(`{}`)},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := applyTemplatePatches(tt.target, []api.TemplatePatch{tt.patch}); err == nil {
+				t.Error("applyTemplatePatches() = nil, want error")
+			}
+			if _, exist := tt.target.GetAnnotations()[api.TargetAppliedPatchesAnnotationKey]; exist {
+				t.Error("failed patch is recorded as applied")
+			}
+		})
+	}
+}
+
+func TestInstanceIDsMatch(t *testing.T) {
+	tests := []struct {
+		instanceIDs string
+		id          int
+		want        bool
+		wantErr     bool
+	}{
+		{instanceIDs: "0", id: 0, want: true},
+		{instanceIDs: "0", id: 1},
+		{instanceIDs: "0, 3-5", id: 4, want: true},
+		{instanceIDs: "0,3-5", id: 5, want: true},
+		{instanceIDs: "0,3-5", id: 6},
+		{instanceIDs: "2 - 2", id: 2, want: true},
+		{instanceIDs: "5-3", id: 4, wantErr: true},
+		{instanceIDs: "a", id: 0, wantErr: true},
+		{instanceIDs: "1-b", id: 1, wantErr: true},
+		{instanceIDs: "0,", id: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := instanceIDsMatch(tt.instanceIDs, tt.id)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("instanceIDsMatch(%q, %d) = %v, %v, want %v, error %v", tt.instanceIDs, tt.id, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSelectTemplatePatches(t *testing.T) {
+	xsetLabelAnnoMgr := api.NewXSetLabelAnnotationManager(nil)
+	patches := []api.TemplatePatch{
+		{Name: "all"},
+		{Name: "first", InstanceIDs: "0"},
+		{Name: "range", InstanceIDs: "1-3"},
+	}
+	names := func(patches []api.TemplatePatch) string {
+		var names []string
+		for _, patch := range patches {
+			names = append(names, patch.Name)
+		}
+		return strings.Join(names, ",")
+	}
+	withID := func(id string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{xsetLabelAnnoMgr.Value(api.XInstanceIdLabelKey): id}}}
+	}
+
+	for target, want := range map[*corev1.Pod]string{
+		withID("0"): "all,first",
+		withID("2"): "all,range",
+		withID("7"): "all",
+		// patches with instance IDs are skipped for target without instance ID
+		{}: "all",
+	} {
+		selected, err := selectTemplatePatches(patches, xsetLabelAnnoMgr, target)
+		if err != nil || names(selected) != want {
+			t.Errorf("selectTemplatePatches() for labels %v = %s, %v, want %s", target.Labels, names(selected), err, want)
+		}
+	}
+
+	invalid := append(patches, api.TemplatePatch{Name: "invalid", InstanceIDs: "x"})
+	if _, err := selectTemplatePatches(invalid, xsetLabelAnnoMgr, withID("0")); err == nil {
+		t.Error("selectTemplatePatches() = nil, want error for invalid instance IDs")
+	}
+}
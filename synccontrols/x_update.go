@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -27,6 +28,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/utils/ptr"
@@ -36,6 +38,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
 	"kusionstack.io/kube-xset/opslifecycle"
 	"kusionstack.io/kube-xset/resourcecontexts"
 	"kusionstack.io/kube-xset/subresources"
@@ -45,12 +48,22 @@ import (
 const UnknownRevision = "__unknownRevision__"
 
 func (r *RealSyncControl) attachTargetUpdateInfo(_ context.Context, xsetObject api.XSetObject, syncContext *SyncContext) ([]*TargetUpdateInfo, error) {
-	activeTargets := FilterOutActiveTargetWrappers(syncContext.TargetWrappers)
+	// quarantined targets and targets stuck in terminating are not updated
+	activeTargets, _ := splitQuarantinedTargetWrappers(r.xsetLabelAnnoMgr,
+		FilterOutStuckTerminatingTargetWrappers(FilterOutActiveTargetWrappers(syncContext.TargetWrappers)))
+	if excludeTerminatingTargets(r.xsetController, xsetObject) {
+		activeTargets = FilterOutTerminatingTargetWrappers(activeTargets)
+	}
 	targetUpdateInfoList := make([]*TargetUpdateInfo, len(activeTargets))
+	spec := r.xsetController.GetXSetSpec(xsetObject)
+
+	_, pvcEnabled := subresources.GetSubresourcePvcAdapter(r.xsetController)
 
-	for i, target := range activeTargets {
+	// update infos of targets are independent of each other, build them in parallel for large xsets
+	if _, err := BoundedParallelize(len(activeTargets), targetWrapperWorkers(len(activeTargets)), func(i int) (err error) {
+		target := activeTargets[i]
 		updateInfo := &TargetUpdateInfo{
-			TargetWrapper: syncContext.TargetWrappers[i],
+			TargetWrapper: target,
 		}
 
 		updateInfo.UpdateRevision = syncContext.UpdatedRevision
@@ -85,18 +98,22 @@ func (r *RealSyncControl) attachTargetUpdateInfo(_ context.Context, xsetObject a
 				"target is going to be updated by recreate because: (1) controller-revision-hash label not found, or (2) not found in history revisions")
 		}
 
-		var err error
-		spec := r.xsetController.GetXSetSpec(xsetObject)
 		// decide whether the TargetOpsLifecycle is during ops or not
 		updateInfo.RequeueForOperationDelay, updateInfo.IsAllowUpdateOps = opslifecycle.AllowOps(r.updateConfig.XsetLabelAnnoMgr, r.updateLifecycleAdapter, ptr.Deref(spec.UpdateStrategy.OperationDelaySeconds, 0), target)
 		// check subresource pvc template changed
-		if _, enabled := subresources.GetSubresourcePvcAdapter(r.xsetController); enabled {
+		if pvcEnabled {
 			updateInfo.PvcTmpHashChanged, err = r.pvcControl.IsTargetPvcTmpChanged(xsetObject, target.Object, syncContext.ExistingPvcs)
 			if err != nil {
-				return nil, err
+				return err
+			}
+			if updateInfo.PvcTmpHashChanged && !updateInfo.IsDuringUpdateOps {
+				r.Recorder.Eventf(target.Object, corev1.EventTypeNormal, "PvcTemplateChanged", "pvc template changed, target is going to be recreated with new pvcs")
 			}
 		}
 		targetUpdateInfoList[i] = updateInfo
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 
 	// attach replace info
@@ -118,7 +135,7 @@ func (r *RealSyncControl) attachTargetUpdateInfo(_ context.Context, xsetObject a
 
 		if replacePairNewTarget != nil {
 			// origin target is allowed to ops if new pod is serviceAvailable
-			newTargetSa := r.xsetController.CheckAvailable(replacePairNewTarget.Object)
+			newTargetSa := IsTargetAvailable(r.xsetController, replacePairNewTarget.Object)
 			originTargetInfo.IsAllowUpdateOps = originTargetInfo.IsAllowUpdateOps || newTargetSa
 			// attach replace new target updateInfo
 			ReplacePairNewTargetInfo := targetUpdateInfoMap[replacePairNewTarget.GetName()]
@@ -161,7 +178,7 @@ func filterOutPlaceHolderUpdateInfos(targets []*TargetUpdateInfo) []*TargetUpdat
 	return filteredTargetUpdateInfos
 }
 
-func (r *RealSyncControl) decideTargetToUpdate(xsetController api.XSetController, xset api.XSetObject, targetInfos []*TargetUpdateInfo) []*TargetUpdateInfo {
+func (r *RealSyncControl) decideTargetToUpdate(xsetController api.XSetController, xset api.XSetObject, syncContext *SyncContext, targetInfos []*TargetUpdateInfo) []*TargetUpdateInfo {
 	spec := xsetController.GetXSetSpec(xset)
 	filteredPodInfos := r.getTargetsUpdateTargets(targetInfos)
 
@@ -170,6 +187,10 @@ func (r *RealSyncControl) decideTargetToUpdate(xsetController api.XSetController
 		return r.decideTargetToUpdateByLabel(activeTargetInfos)
 	}
 
+	if getRevisionRatios(spec) != nil {
+		return r.decideTargetToUpdateByRevisionRatios(xset, syncContext, filteredPodInfos)
+	}
+
 	return r.decideTargetToUpdateByPartition(xsetController, xset, filteredPodInfos)
 }
 
@@ -285,6 +306,10 @@ func (o *orderByDefault) Less(i, j int) bool {
 		return true
 	}
 
+	if l.OnPreemptingNode != r.OnPreemptingNode {
+		return l.OnPreemptingNode
+	}
+
 	lReady, _ := o.checkReadyFunc(l.Object)
 	rReady, _ := o.checkReadyFunc(r.Object)
 	if lReady != rReady {
@@ -320,6 +345,12 @@ type UpdateConfig struct {
 
 	CacheExpectations expectations.CacheExpectationsInterface
 	TargetGVK         schema.GroupVersionKind
+
+	// KubeClient resizes Pod targets in place if InPlaceResizeAdapter is implemented, and evicts Pod targets if
+	// PodEvictionAdapter is implemented
+	KubeClient kubernetes.Interface
+	// resizeRejections records xsets whose resize is rejected by api server, whose targets are not resized for a while
+	resizeRejections resizeRejections
 }
 
 type TargetUpdater interface {
@@ -372,6 +403,12 @@ func (r *RealSyncControl) newTargetUpdater(xset api.XSetObject) TargetUpdater {
 			targetUpdater = &recreateTargetUpdater{}
 		}
 	}
+	// resize Pod targets in place instead of the update policy if possible, except for Recreate and Replace policies
+	if spec.UpdateStrategy.UpdatePolicy != api.XSetRecreateTargetUpdateStrategyType &&
+		spec.UpdateStrategy.UpdatePolicy != api.XSetReplaceTargetUpdateStrategyType &&
+		isInPlaceResizeEnabled(r.updateConfig, xset) {
+		targetUpdater = newInPlaceResizeTargetUpdater(targetUpdater)
+	}
 	targetUpdater.Setup(r.updateConfig, xset)
 	return targetUpdater
 }
@@ -397,6 +434,8 @@ func (u *GenericTargetUpdater) BeginUpdateTarget(ctx context.Context, syncContex
 				return opslifecycle.WhenBeginDelete(u.XsetLabelAnnoMgr, obj)
 			}
 			return false, nil
+		}, func(obj client.Object) (bool, error) {
+			return StampLastAction(obj, api.TargetActionChosenForUpdate), nil
 		}); err != nil {
 			return fmt.Errorf("fail to begin TargetOpsLifecycle for updating Target %s/%s: %w", targetInfo.GetNamespace(), targetInfo.GetName(), err)
 		} else if updated {
@@ -410,10 +449,10 @@ func (u *GenericTargetUpdater) BeginUpdateTarget(ctx context.Context, syncContex
 
 	updating := succCount > 0
 	if err != nil {
-		AddOrUpdateCondition(syncContext.NewStatus, api.XSetUpdate, err, "UpdateFailed", err.Error())
+		conditions.SetFromError(syncContext.NewStatus, api.XSetUpdate, err, conditions.ReasonUpdateFailed, err.Error())
 		return updating, err
 	} else {
-		AddOrUpdateCondition(syncContext.NewStatus, api.XSetUpdate, nil, "Updated", "")
+		conditions.SetFromError(syncContext.NewStatus, api.XSetUpdate, nil, conditions.ReasonUpdated, "")
 	}
 	return updating, nil
 }
@@ -456,7 +495,19 @@ func (u *GenericTargetUpdater) FilterAllowOpsTargets(ctx context.Context, candid
 
 		// mark targetContext "TargetRecreateUpgrade" if upgrade by recreate
 		isRecreateUpdatePolicy := spec.UpdateStrategy.UpdatePolicy == api.XSetRecreateTargetUpdateStrategyType
-		if (!targetInfo.OnlyMetadataChanged && !targetInfo.InPlaceUpdateSupport) || isRecreateUpdatePolicy {
+		isRecreate := (!targetInfo.OnlyMetadataChanged && !targetInfo.InPlaceUpdateSupport) || isRecreateUpdatePolicy
+		if isRecreate && !targetInfo.PlaceHolder {
+			// wait for deletion approval if required before recreating
+			approved, approvalRequeueAfter, err := checkDeletionApproval(ctx, u.XsetController, u.XsetLabelAnnoMgr, u.TargetControl, u.Recorder, u.OwnerObject, targetInfo.Object)
+			if err != nil {
+				return recordedRequeueAfter, err
+			}
+			recordedRequeueAfter = xcontrol.GetShorterDuration(recordedRequeueAfter, approvalRequeueAfter)
+			if !approved {
+				continue
+			}
+		}
+		if isRecreate {
 			u.ResourceContextControl.Put(ownedIDs[targetInfo.ID], api.EnumRecreateUpdateContextDataKey, "true")
 		}
 
@@ -508,7 +559,8 @@ func (u *GenericTargetUpdater) FinishUpdateTarget(ctx context.Context, targetInf
 }
 
 func (u *GenericTargetUpdater) RecreateTarget(ctx context.Context, targetInfo *TargetUpdateInfo) error {
-	if err := u.TargetControl.DeleteTarget(ctx, targetInfo.Object); err != nil {
+	spec := u.XsetController.GetXSetSpec(u.OwnerObject)
+	if err := evictOrDeleteTarget(ctx, u.UpdateConfig, u.OwnerObject, targetInfo.Object, spec.UpdateStrategy.TerminationGracePeriodSeconds); err != nil {
 		return fmt.Errorf("fail to delete Target %s/%s when updating by recreate: %w", targetInfo.GetNamespace(), targetInfo.GetName(), err)
 	}
 
@@ -616,8 +668,10 @@ func (u *replaceUpdateTargetUpdater) FinishUpdateTarget(ctx context.Context, tar
 	if finishByCancelUpdate {
 		// cancel replace update by removing to-replace and replace-by-update label from origin target
 		if targetInfo.IsInReplace {
-			patch := client.RawPatch(types.MergePatchType, fmt.Appendf(nil, `{"metadata":{"labels":{"%s":null, "%s":null}}}`, u.XsetLabelAnnoMgr.Value(api.XReplaceIndicationLabelKey), u.XsetLabelAnnoMgr.Value(api.XReplaceByReplaceUpdateLabelKey)))
-			if err := u.TargetControl.PatchTarget(ctx, targetInfo.Object, patch); err != nil {
+			if err := u.TargetControl.PatchTargetWithOptimisticLock(ctx, targetInfo.Object, func(target client.Object) {
+				u.XsetLabelAnnoMgr.Delete(target, api.XReplaceIndicationLabelKey)
+				u.XsetLabelAnnoMgr.Delete(target, api.XReplaceByReplaceUpdateLabelKey)
+			}); err != nil {
 				return fmt.Errorf("failed to patch replace pair target %s/%s %w when cancel replace update", targetInfo.GetNamespace(), targetInfo.GetName(), err)
 			}
 		}
@@ -627,8 +681,9 @@ func (u *replaceUpdateTargetUpdater) FinishUpdateTarget(ctx context.Context, tar
 	ReplacePairNewTargetInfo := targetInfo.ReplacePairNewTargetInfo
 	if ReplacePairNewTargetInfo != nil {
 		if _, exist := u.XsetLabelAnnoMgr.Get(targetInfo.Object, api.XDeletionIndicationLabelKey); !exist {
-			patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf(`{"metadata":{"labels":{%q:"%d"}}}`, u.XsetLabelAnnoMgr.Value(api.XDeletionIndicationLabelKey), time.Now().UnixNano())))
-			if err := u.TargetControl.PatchTarget(ctx, targetInfo.Object, patch); err != nil {
+			if err := u.TargetControl.PatchTargetWithOptimisticLock(ctx, targetInfo.Object, func(target client.Object) {
+				u.XsetLabelAnnoMgr.Set(target, api.XDeletionIndicationLabelKey, strconv.FormatInt(time.Now().UnixNano(), 10))
+			}); err != nil {
 				return fmt.Errorf("failed to delete replace pair origin target %s/%s %w", targetInfo.GetNamespace(), targetInfo.ReplacePairNewTargetInfo.GetName(), err)
 			}
 		}
@@ -649,7 +704,7 @@ func (u *GenericTargetUpdater) isTargetUpdatedServiceAvailable(targetInfo *Targe
 		return false, "replace origin target", nil
 	}
 
-	if u.XsetController.CheckAvailable(targetInfo.Object) {
+	if IsTargetAvailable(u.XsetController, targetInfo.Object) {
 		return true, "", nil
 	}
 
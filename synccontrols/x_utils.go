@@ -18,18 +18,35 @@ package synccontrols
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
-	"k8s.io/apimachinery/pkg/api/meta"
+	corev1 "k8s.io/api/core/v1"
 	apimachineryvalidation "k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
 	clientutils "kusionstack.io/kube-utils/client"
 	controllerutils "kusionstack.io/kube-utils/controller/utils"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+	"kusionstack.io/kube-xset/resourcecontexts"
+	"kusionstack.io/kube-xset/revisionowner"
+	"kusionstack.io/kube-xset/xcontrol"
 )
 
 func NewTargetFrom(setController api.XSetController, xsetLabelAnnoMgr api.XSetLabelAnnotationManager, owner api.XSetObject, revision *appsv1.ControllerRevision, id int, updateFuncs ...func(client.Object) error) (client.Object, error) {
@@ -37,6 +54,27 @@ func NewTargetFrom(setController api.XSetController, xsetLabelAnnoMgr api.XSetLa
 	if err != nil {
 		return nil, err
 	}
+	// labels and annotations excluded from revision are rendered from the current template
+	excludedLabels, excludedAnnotations, err := revisionowner.GetExcludedTemplateMetadata(setController, owner)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get template metadata excluded from revision: %w", err)
+	}
+	if len(excludedLabels) > 0 {
+		labels := targetObj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		maps.Copy(labels, excludedLabels)
+		targetObj.SetLabels(labels)
+	}
+	if len(excludedAnnotations) > 0 {
+		annotations := targetObj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		maps.Copy(annotations, excludedAnnotations)
+		targetObj.SetAnnotations(annotations)
+	}
 
 	meta := setController.XSetMeta()
 	ownerRef := metav1.NewControllerRef(owner, meta.GroupVersionKind())
@@ -47,10 +85,18 @@ func NewTargetFrom(setController api.XSetController, xsetLabelAnnoMgr api.XSetLa
 	if IsTargetNamingSuffixPolicyPersistentSequence(setController.GetXSetSpec(owner)) {
 		targetObj.SetName(fmt.Sprintf("%s%d", targetObj.GetGenerateName(), id))
 	}
+	if namingAdapter, ok := setController.(api.TargetNamingAdapter); ok {
+		if name := namingAdapter.GetTargetName(owner, id); name != "" {
+			targetObj.SetName(name)
+		}
+	}
 
 	xsetLabelAnnoMgr.Set(targetObj, api.XInstanceIdLabelKey, fmt.Sprintf("%d", id))
 	targetObj.GetLabels()[appsv1.ControllerRevisionHashLabelKey] = revision.GetName()
 	controlByXSet(xsetLabelAnnoMgr, targetObj)
+	if finalizer := GetTargetProtectionFinalizer(setController, owner); finalizer != "" {
+		controllerutil.AddFinalizer(targetObj, finalizer)
+	}
 
 	for _, fn := range updateFuncs {
 		if err := fn(targetObj); err != nil {
@@ -58,45 +104,32 @@ func NewTargetFrom(setController api.XSetController, xsetLabelAnnoMgr api.XSetLa
 		}
 	}
 
-	return targetObj, nil
-}
-
-const ConditionUpdatePeriodBackOff = 30 * time.Second
-
-func AddOrUpdateCondition(status *api.XSetStatus, conditionType api.XSetConditionType, err error, reason, message string) {
-	condStatus := metav1.ConditionTrue
-	if err != nil {
-		condStatus = metav1.ConditionFalse
+	if pod, ok := targetObj.(*corev1.Pod); ok {
+		injectSpread(setController.GetXSetSpec(owner), pod)
+		injectReadinessGates(setController, owner, pod)
+		injectHostname(setController, owner, pod, id)
 	}
 
-	existCond := meta.FindStatusCondition(status.Conditions, string(conditionType))
-	if existCond != nil && existCond.Reason == reason && existCond.Status == condStatus {
-		now := metav1.Now()
-		if now.Sub(existCond.LastTransitionTime.Time) < ConditionUpdatePeriodBackOff || existCond.Message == message {
-			return
+	if defaulter, ok := setController.(api.TemplateDefaulter); ok {
+		if err := defaulter.DefaultTarget(owner, targetObj); err != nil {
+			return targetObj, fmt.Errorf("fail to default target: %w", err)
 		}
 	}
 
-	cond := &metav1.Condition{
-		Type:               string(conditionType),
-		Status:             condStatus,
-		LastTransitionTime: metav1.Now(),
-		Reason:             reason,
-		Message:            message,
-	}
-	if status.Conditions == nil {
-		status.Conditions = []metav1.Condition{}
-	}
-	meta.SetStatusCondition(&status.Conditions, *cond)
+	return targetObj, nil
+}
 
-	// we must update last transition time if reason changed but status not changed
-	for i := range status.Conditions {
-		c := status.Conditions[i]
-		if c.Type == string(conditionType) {
-			status.Conditions[i].LastTransitionTime = metav1.Now()
-			return
-		}
-	}
+// Deprecated: use conditions.MessageUpdatePeriodBackOff instead.
+const ConditionUpdatePeriodBackOff = conditions.MessageUpdatePeriodBackOff
+
+// PvcBoundCheckInterval is the interval to requeue when waiting for pvcs to be Bound before creating targets
+const PvcBoundCheckInterval = 5 * time.Second
+
+// AddOrUpdateCondition sets condition true if err is nil, otherwise false.
+//
+// Deprecated: use conditions.SetFromError instead.
+func AddOrUpdateCondition(status *api.XSetStatus, conditionType api.XSetConditionType, err error, reason, message string) {
+	conditions.SetFromError(status, conditionType, err, reason, message)
 }
 
 func GetTargetsPrefix(controllerName string) string {
@@ -117,10 +150,14 @@ func IsTargetUpdatedRevision(target client.Object, revision string) bool {
 }
 
 func ObjectKeyString(obj client.Object) string {
-	if obj.GetNamespace() == "" {
-		return obj.GetName()
+	return namespacedKeyString(obj.GetNamespace(), obj.GetName())
+}
+
+func namespacedKeyString(namespace, name string) string {
+	if namespace == "" {
+		return name
 	}
-	return obj.GetNamespace() + "/" + obj.GetName()
+	return namespace + "/" + name
 }
 
 func controlByXSet(xsetLabelAnnoMgr api.XSetLabelAnnotationManager, obj client.Object) {
@@ -142,11 +179,12 @@ func IsControlledByXSet(xsetLabelManager api.XSetLabelAnnotationManager, obj cli
 }
 
 func ApplyTemplatePatcher(ctx context.Context, xsetController api.XSetController, c client.Client, xset api.XSetObject, targets []*TargetWrapper) error {
+	patcher := GetTemplatePatcher(xsetController, xset)
 	_, patchErr := controllerutils.SlowStartBatch(len(targets), controllerutils.SlowStartInitialBatchSize, false, func(i int, _ error) error {
 		if targets[i].Object == nil || targets[i].PlaceHolder {
 			return nil
 		}
-		_, err := clientutils.UpdateOnConflict(ctx, c, c, targets[i].Object, xsetController.GetXSetTemplatePatcher(xset))
+		_, err := clientutils.UpdateOnConflict(ctx, c, c, targets[i].Object, patcher)
 		return err
 	})
 	return patchErr
@@ -174,9 +212,450 @@ func afterOrZero(t1, t2 *metav1.Time) bool {
 	return t1.After(t2.Time)
 }
 
+// IsTargetAvailable checks whether target is available by AvailabilityAdapter if implemented, otherwise by CheckAvailable
+func IsTargetAvailable(xsetController api.XSetController, target client.Object) bool {
+	available, _ := checkTargetAvailable(xsetController, target)
+	return available
+}
+
+func checkTargetAvailable(xsetController api.XSetController, target client.Object) (bool, *time.Duration) {
+	if !checkReadinessGates(xsetController, target) {
+		return false, nil
+	}
+	if adapter, ok := xsetController.(api.AvailabilityAdapter); ok {
+		return adapter.CheckTargetAvailable(target)
+	}
+	return xsetController.CheckAvailable(target), nil
+}
+
+// checkReadinessGates returns false if ReadinessGateAdapter is implemented and any readinessGate condition of Pod
+// target is not True
+func checkReadinessGates(xsetController api.XSetController, target client.Object) bool {
+	if _, ok := xsetController.(api.ReadinessGateAdapter); !ok {
+		return true
+	}
+	pod, ok := target.(*corev1.Pod)
+	if !ok {
+		return true
+	}
+	for _, gate := range pod.Spec.ReadinessGates {
+		passed := false
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == gate.ConditionType {
+				passed = cond.Status == corev1.ConditionTrue
+				break
+			}
+		}
+		if !passed {
+			return false
+		}
+	}
+	return true
+}
+
+// injectReadinessGates injects readinessGates returned by ReadinessGateAdapter into Pod target
+func injectReadinessGates(setController api.XSetController, owner api.XSetObject, pod *corev1.Pod) {
+	adapter, ok := setController.(api.ReadinessGateAdapter)
+	if !ok {
+		return
+	}
+	for _, conditionType := range adapter.GetReadinessGates(owner) {
+		exist := false
+		for _, gate := range pod.Spec.ReadinessGates {
+			if gate.ConditionType == conditionType {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			pod.Spec.ReadinessGates = append(pod.Spec.ReadinessGates, corev1.PodReadinessGate{ConditionType: conditionType})
+		}
+	}
+}
+
+// checkTargetAvailableForStatus additionally requires target to be ready for minReadySeconds when counting available
+// replicas, whether availability is checked by CheckAvailable or AvailabilityAdapter
+func checkTargetAvailableForStatus(xsetController api.XSetController, spec *api.XSetSpec, target client.Object) (bool, *time.Duration) {
+	available, recheckAfter := checkTargetAvailable(xsetController, target)
+	if !available || spec.MinReadySeconds <= 0 {
+		return available, recheckAfter
+	}
+	ready, readyTime := xsetController.CheckReadyTime(target)
+	if !ready {
+		return false, nil
+	}
+	if readyTime == nil {
+		return true, nil
+	}
+	minReady := time.Duration(spec.MinReadySeconds) * time.Second
+	if elapsed := time.Since(readyTime.Time); elapsed < minReady {
+		recheckAfter := minReady - elapsed
+		return false, &recheckAfter
+	}
+	return true, nil
+}
+
+// injectSpread injects pod anti-affinity or topologySpreadConstraints against targets selected by XSet selector
+func injectSpread(spec *api.XSetSpec, pod *corev1.Pod) {
+	if spec.SpreadStrategy == nil || spec.Selector == nil {
+		return
+	}
+	strategy := spec.SpreadStrategy
+	topologyKeys := strategy.TopologyKeys
+	if len(topologyKeys) == 0 {
+		topologyKeys = []string{corev1.LabelHostname}
+	}
+
+	switch strategy.Type {
+	case api.SpreadStrategyAntiAffinity:
+		if pod.Spec.Affinity == nil {
+			pod.Spec.Affinity = &corev1.Affinity{}
+		}
+		if pod.Spec.Affinity.PodAntiAffinity == nil {
+			pod.Spec.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+		}
+		antiAffinity := pod.Spec.Affinity.PodAntiAffinity
+		for _, key := range topologyKeys {
+			term := corev1.PodAffinityTerm{LabelSelector: spec.Selector.DeepCopy(), TopologyKey: key}
+			if strategy.Required {
+				antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, term)
+			} else {
+				antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+					corev1.WeightedPodAffinityTerm{Weight: 100, PodAffinityTerm: term})
+			}
+		}
+	case api.SpreadStrategyTopologySpread:
+		whenUnsatisfiable := corev1.ScheduleAnyway
+		if strategy.Required {
+			whenUnsatisfiable = corev1.DoNotSchedule
+		}
+		for _, key := range topologyKeys {
+			pod.Spec.TopologySpreadConstraints = append(pod.Spec.TopologySpreadConstraints, corev1.TopologySpreadConstraint{
+				MaxSkew:           ptr.Deref(strategy.MaxSkew, 1),
+				TopologyKey:       key,
+				WhenUnsatisfiable: whenUnsatisfiable,
+				LabelSelector:     spec.Selector.DeepCopy(),
+			})
+		}
+	}
+}
+
+func isStickyNodeEnabled(setController api.XSetController, owner api.XSetObject) bool {
+	adapter, ok := setController.(api.StickyNodeAdapter)
+	return ok && adapter.StickToRecordedNode(owner)
+}
+
+// recordTargetNode records the node of Pod target in its context, and returns true if context is changed
+func (r *RealSyncControl) recordTargetNode(owner api.XSetObject, target client.Object, contextDetail *api.ContextDetail) bool {
+	pod, ok := target.(*corev1.Pod)
+	if !ok || contextDetail == nil || pod.Spec.NodeName == "" || !isStickyNodeEnabled(r.xsetController, owner) {
+		return false
+	}
+	if r.resourceContextControl.Contains(contextDetail, api.EnumNodeNameContextDataKey, pod.Spec.NodeName) {
+		return false
+	}
+	r.resourceContextControl.Put(contextDetail, api.EnumNodeNameContextDataKey, pod.Spec.NodeName)
+	return true
+}
+
+// stickToRecordedNode injects required node affinity to the node recorded in context into Pod target
+func (r *RealSyncControl) stickToRecordedNode(owner api.XSetObject, target client.Object, contextDetail *api.ContextDetail) {
+	pod, ok := target.(*corev1.Pod)
+	if !ok || contextDetail == nil || !isStickyNodeEnabled(r.xsetController, owner) {
+		return
+	}
+	nodeName, exist := r.resourceContextControl.Get(contextDetail, api.EnumNodeNameContextDataKey)
+	if !exist || nodeName == "" {
+		return
+	}
+
+	requireNodeAffinity(pod, corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{{
+		Key:      metav1.ObjectNameField,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{nodeName},
+	}}})
+}
+
+// requireNodeAffinity merges requirements of term into required node affinity of pod. Node selector terms are ORed,
+// so requirements are added to every existing term.
+func requireNodeAffinity(pod *corev1.Pod, term corev1.NodeSelectorTerm) {
+	if pod.Spec.Affinity == nil {
+		pod.Spec.Affinity = &corev1.Affinity{}
+	}
+	if pod.Spec.Affinity.NodeAffinity == nil {
+		pod.Spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	nodeAffinity := pod.Spec.Affinity.NodeAffinity
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+	selector := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(selector.NodeSelectorTerms) == 0 {
+		selector.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+	}
+	for i := range selector.NodeSelectorTerms {
+		selector.NodeSelectorTerms[i].MatchExpressions = append(selector.NodeSelectorTerms[i].MatchExpressions, term.MatchExpressions...)
+		selector.NodeSelectorTerms[i].MatchFields = append(selector.NodeSelectorTerms[i].MatchFields, term.MatchFields...)
+	}
+}
+
+func getZonePlacement(setController api.XSetController, owner api.XSetObject) (string, []string) {
+	adapter, ok := setController.(api.ZonePlacementAdapter)
+	if !ok {
+		return "", nil
+	}
+	topologyKey, zones := adapter.GetZonePlacement(owner)
+	if topologyKey == "" {
+		topologyKey = corev1.LabelTopologyZone
+	}
+	return topologyKey, zones
+}
+
+// getZoneCounts returns the zone getter and the number of targets in each zone, or nil if zone placement is disabled
+func (r *RealSyncControl) getZoneCounts(owner api.XSetObject, targets []*TargetWrapper) (func(*TargetWrapper) string, map[string]int) {
+	if _, zones := getZonePlacement(r.xsetController, owner); len(zones) == 0 {
+		return nil, nil
+	}
+	zoneOf := func(target *TargetWrapper) string {
+		if target.ContextDetail == nil {
+			return ""
+		}
+		zone, _ := r.resourceContextControl.Get(target.ContextDetail, api.EnumZoneContextDataKey)
+		return zone
+	}
+	zoneCounts := map[string]int{}
+	for _, target := range targets {
+		zoneCounts[zoneOf(target)]++
+	}
+	return zoneOf, zoneCounts
+}
+
+// assignZones records a zone for each context to create target with, choosing the zone with the fewest targets.
+// Contexts with a zone still in placement keep their zone. It returns true if any context is changed.
+func (r *RealSyncControl) assignZones(owner api.XSetObject, activeTargets []*TargetWrapper, contexts []*api.ContextDetail) bool {
+	_, zones := getZonePlacement(r.xsetController, owner)
+	if len(zones) == 0 {
+		return false
+	}
+
+	_, zoneCounts := r.getZoneCounts(owner, activeTargets)
+	changed := false
+	for _, contextDetail := range contexts {
+		if zone, exist := r.resourceContextControl.Get(contextDetail, api.EnumZoneContextDataKey); exist && slices.Contains(zones, zone) {
+			zoneCounts[zone]++
+			continue
+		}
+		zone := zones[0]
+		for _, z := range zones[1:] {
+			if zoneCounts[z] < zoneCounts[zone] {
+				zone = z
+			}
+		}
+		zoneCounts[zone]++
+		r.resourceContextControl.Put(contextDetail, api.EnumZoneContextDataKey, zone)
+		changed = true
+	}
+	return changed
+}
+
+// injectZone injects required node affinity to the zone recorded in context into Pod target
+func (r *RealSyncControl) injectZone(owner api.XSetObject, target client.Object, contextDetail *api.ContextDetail) {
+	pod, ok := target.(*corev1.Pod)
+	if !ok || contextDetail == nil {
+		return
+	}
+	topologyKey, zones := getZonePlacement(r.xsetController, owner)
+	if len(zones) == 0 {
+		return
+	}
+	zone, exist := r.resourceContextControl.Get(contextDetail, api.EnumZoneContextDataKey)
+	if !exist || zone == "" {
+		return
+	}
+	requireNodeAffinity(pod, corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{
+		Key:      topologyKey,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{zone},
+	}}})
+}
+
+// newKubeClient returns the client to access Pod subresources, or nil if neither InPlaceResizeAdapter nor
+// PodEvictionAdapter is implemented
+func newKubeClient(setController api.XSetController, config *rest.Config) (kubernetes.Interface, error) {
+	_, resizeEnabled := setController.(api.InPlaceResizeAdapter)
+	_, evictionEnabled := setController.(api.PodEvictionAdapter)
+	if (!resizeEnabled && !evictionEnabled) || config == nil {
+		return nil, nil
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kube client for Pod subresources: %w", err)
+	}
+	return kubeClient, nil
+}
+
+func excludeTerminatingTargets(setController api.XSetController, owner api.XSetObject) bool {
+	adapter, ok := setController.(api.TerminatingTargetsAdapter)
+	return ok && adapter.ExcludeTerminatingTargets(owner)
+}
+
+// targetDeleteOptions returns options deleting target with UID it is listed with as precondition, so that target
+// recreated since then is never deleted. ResourceVersion is left out, since targets are modified by kubelet and others
+// all the time, and deletion with a stale one always fails with conflict.
+func targetDeleteOptions(target client.Object, gracePeriodSeconds *int64) []client.DeleteOption {
+	opts := []client.DeleteOption{client.Preconditions(targetPreconditions(target))}
+	if gracePeriodSeconds != nil {
+		opts = append(opts, client.GracePeriodSeconds(*gracePeriodSeconds))
+	}
+	return opts
+}
+
+// preconditionedLabelPatch returns merge patch labeling target, carrying UID target is listed with, so that the patch
+// fails with conflict if target is recreated since then. ResourceVersion is left out as in targetDeleteOptions.
+func preconditionedLabelPatch(target client.Object, key, value string) (client.Patch, error) {
+	metadata := map[string]interface{}{"labels": map[string]string{key: value}}
+	if uid := target.GetUID(); uid != "" {
+		metadata["uid"] = uid
+	}
+	patch, err := json.Marshal(map[string]interface{}{"metadata": metadata})
+	if err != nil {
+		return nil, err
+	}
+	return client.RawPatch(types.MergePatchType, patch), nil
+}
+
+// targetPreconditions returns UID of target as precondition of deletion, which is left empty if unknown
+func targetPreconditions(target client.Object) metav1.Preconditions {
+	var preconditions metav1.Preconditions
+	if uid := target.GetUID(); uid != "" {
+		preconditions.UID = &uid
+	}
+	return preconditions
+}
+
+// GetTargetProtectionFinalizer returns the finalizer requested by TargetProtectionFinalizerAdapter, or empty if not requested
+func GetTargetProtectionFinalizer(setController api.XSetController, owner api.XSetObject) string {
+	if adapter, ok := setController.(api.TargetProtectionFinalizerAdapter); ok {
+		return adapter.GetTargetProtectionFinalizer(owner)
+	}
+	return ""
+}
+
+// preCreateTarget calls PreCreateHookAdapter if implemented with the rendered target and its ContextDetail
+func preCreateTarget(ctx context.Context, xsetController api.XSetController, xsetObject api.XSetObject, target client.Object, contextDetail *api.ContextDetail) error {
+	adapter, ok := xsetController.(api.PreCreateHookAdapter)
+	if !ok {
+		return nil
+	}
+	if err := adapter.PreCreate(ctx, xsetObject, target, contextDetail); err != nil {
+		return fmt.Errorf("fail to run PreCreate hook for target of ID %d: %w", contextDetail.ID, err)
+	}
+	return nil
+}
+
+// dryRunCreateTarget dry-runs target creation if enabled by DryRunCreateAdapter, and only returns the
+// unrecoverable error, so that recoverable errors are left to the real creation to handle.
+func dryRunCreateTarget(ctx context.Context, xsetController api.XSetController, xControl xcontrol.TargetControl, xsetObject api.XSetObject, target client.Object) error {
+	adapter, ok := xsetController.(api.DryRunCreateAdapter)
+	if !ok || !adapter.DryRunCreateTargets(xsetObject) {
+		return nil
+	}
+	if err := xControl.DryRunCreateTarget(ctx, target); err != nil && resourcecontexts.UnrecoverableCreateError(err) {
+		return err
+	}
+	return nil
+}
+
+// dryRunScaleOut dry-runs creation of a target rendered from updated revision with an ID not used by targets, before
+// IDs are allocated for scaling out, so that unrecoverable errors neither consume IDs nor fail their contexts. Targets
+// failing to render are left to the real creation to handle.
+func (r *RealSyncControl) dryRunScaleOut(ctx context.Context, xsetObject api.XSetObject, syncContext *SyncContext, usedIDs sets.Int) error {
+	if adapter, ok := r.xsetController.(api.DryRunCreateAdapter); !ok || !adapter.DryRunCreateTargets(xsetObject) {
+		return nil
+	}
+	id := 0
+	for usedIDs.Has(id) {
+		id++
+	}
+	target, err := NewTargetFrom(r.xsetController, r.xsetLabelAnnoMgr, xsetObject, syncContext.UpdatedRevision, id,
+		GetTemplatePatcher(r.xsetController, xsetObject))
+	if err != nil {
+		return nil
+	}
+	return dryRunCreateTarget(ctx, r.xsetController, r.xControl, xsetObject, target)
+}
+
+// DefaultMaxConcurrentDeletions is the default number of targets deleted in parallel
+const DefaultMaxConcurrentDeletions = 16
+
+func getMaxConcurrentDeletions(xsetController api.XSetController) int {
+	if adapter, ok := xsetController.(api.DeletionConcurrencyAdapter); ok {
+		if workers := adapter.GetMaxConcurrentDeletions(); workers > 0 {
+			return workers
+		}
+	}
+	return DefaultMaxConcurrentDeletions
+}
+
+// BoundedParallelize calls fn for each index in [0, count) with at most workers goroutines,
+// and returns the number of successful calls and the aggregated errors.
+func BoundedParallelize(count, workers int, fn func(i int) error) (int, error) {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var succCount int32
+	errs := make([]error, count)
+	indexCh := make(chan int, count)
+	for i := 0; i < count; i++ {
+		indexCh <- i
+	}
+	close(indexCh)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, count); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexCh {
+				if errs[i] = fn(i); errs[i] == nil {
+					atomic.AddInt32(&succCount, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return int(succCount), errors.Join(errs...)
+}
+
 func IsTargetNamingSuffixPolicyPersistentSequence(xsetSpec *api.XSetSpec) bool {
 	if xsetSpec == nil || xsetSpec.NamingStrategy == nil {
 		return false
 	}
 	return xsetSpec.NamingStrategy.TargetNamingSuffixPolicy == api.TargetNamingSuffixPolicyPersistentSequence
 }
+
+// IsTargetNamingDeterministic returns true if targets of xset are named by instance ID, either by PersistentSequence
+// naming policy or by TargetNamingAdapter, in which case targets with same name should not exist at same time.
+// TargetNamingAdapter is probed with ID 0, since empty names fall back to NamingStrategy for xsets it does not name.
+func IsTargetNamingDeterministic(xsetController api.XSetController, xsetObject api.XSetObject) bool {
+	if namingAdapter, ok := xsetController.(api.TargetNamingAdapter); ok && namingAdapter.GetTargetName(xsetObject, 0) != "" {
+		return true
+	}
+	return IsTargetNamingSuffixPolicyPersistentSequence(xsetController.GetXSetSpec(xsetObject))
+}
+
+// StampLastAction records action as the last action taken on target, and returns false if action is already
+// the last one recorded, so that the timestamp stays at when action was first taken.
+func StampLastAction(target client.Object, action api.TargetAction) bool {
+	annotations := target.GetAnnotations()
+	if strings.HasPrefix(annotations[api.TargetLastActionAnnotationKey], string(action)+"@") {
+		return false
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[api.TargetLastActionAnnotationKey] = fmt.Sprintf("%s@%s", action, time.Now().UTC().Format(time.RFC3339))
+	target.SetAnnotations(annotations)
+	return true
+}
@@ -0,0 +1,494 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/testing/fake"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+type namingController struct {
+	api.XSetController
+	spec *api.XSetSpec
+}
+
+func (c *namingController) GetXSetSpec(api.XSetObject) *api.XSetSpec { return c.spec }
+
+// GetTargetName names targets of xsets labeled with stable identity only
+func (c *namingController) GetTargetName(object api.XSetObject, id int) string {
+	if object.GetLabels()["stable-identity"] != "true" {
+		return ""
+	}
+	return fmt.Sprintf("%s-%d", object.GetName(), id)
+}
+
+func TestIsTargetNamingDeterministic(t *testing.T) {
+	persistentSequence := &api.XSetSpec{NamingStrategy: &api.NamingStrategy{TargetNamingSuffixPolicy: api.TargetNamingSuffixPolicyPersistentSequence}}
+	tests := []struct {
+		name   string
+		labels map[string]string
+		spec   *api.XSetSpec
+		want   bool
+	}{
+		{name: "named by adapter", labels: map[string]string{"stable-identity": "true"}, spec: &api.XSetSpec{}, want: true},
+		{name: "not named by adapter", spec: &api.XSetSpec{}},
+		{name: "persistent sequence", spec: persistentSequence, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: tt.labels}}
+			if got := IsTargetNamingDeterministic(&namingController{spec: tt.spec}, xset); got != tt.want {
+				t.Errorf("IsTargetNamingDeterministic() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type dryRunController struct {
+	api.XSetController
+	enabled bool
+}
+
+func (c *dryRunController) XSetMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "apps.kusionstack.io/v1alpha1", Kind: "XSet"}
+}
+
+func (c *dryRunController) GetXSetSpec(api.XSetObject) *api.XSetSpec { return &api.XSetSpec{} }
+
+func (c *dryRunController) GetXObjectFromRevision(*appsv1.ControllerRevision) (client.Object, error) {
+	return &corev1.Pod{}, nil
+}
+
+func (c *dryRunController) GetXSetTemplatePatcher(metav1.Object) func(client.Object) error {
+	return func(client.Object) error { return nil }
+}
+
+func (c *dryRunController) DryRunCreateTargets(api.XSetObject) bool { return c.enabled }
+
+type dryRunTargetControl struct {
+	xcontrol.TargetControl
+	err     error
+	created []client.Object
+}
+
+func (c *dryRunTargetControl) DryRunCreateTarget(_ context.Context, target client.Object) error {
+	c.created = append(c.created, target)
+	return c.err
+}
+
+func TestDryRunScaleOut(t *testing.T) {
+	invalid := apierrors.NewInvalid(schema.GroupKind{Kind: "Pod"}, "foo-", []*field.Error{{Detail: "invalid"}})
+	alreadyExists := apierrors.NewAlreadyExists(schema.GroupResource{Resource: "pods"}, "foo-1")
+	tests := []struct {
+		name        string
+		enabled     bool
+		err         error
+		wantErr     bool
+		wantDryRuns int
+	}{
+		{name: "not enabled", err: invalid},
+		{name: "unrecoverable error", enabled: true, err: fmt.Errorf("failed to dry-run create target: %w", invalid), wantErr: true, wantDryRuns: 1},
+		{name: "recoverable error", enabled: true, err: alreadyExists, wantDryRuns: 1},
+		{name: "succeeded", enabled: true, wantDryRuns: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xControl := &dryRunTargetControl{err: tt.err}
+			r := &RealSyncControl{
+				xsetController:   &dryRunController{enabled: tt.enabled},
+				xsetLabelAnnoMgr: api.NewXSetLabelAnnotationManager(nil),
+				xControl:         xControl,
+			}
+			xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+			syncContext := &SyncContext{UpdatedRevision: &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: "rev-2"}}}
+			err := r.dryRunScaleOut(context.Background(), xset, syncContext, sets.NewInt(0, 1, 3))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dryRunScaleOut() = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(xControl.created) != tt.wantDryRuns {
+				t.Fatalf("dryRunScaleOut() dry-runs %d targets, want %d", len(xControl.created), tt.wantDryRuns)
+			}
+			if tt.wantDryRuns > 0 {
+				if id, _ := r.xsetLabelAnnoMgr.Get(xControl.created[0], api.XInstanceIdLabelKey); id != "2" {
+					t.Errorf("dryRunScaleOut() dry-runs target of ID %s, want the first unused ID 2", id)
+				}
+			}
+		})
+	}
+}
+
+func TestPreconditionedLabelPatch(t *testing.T) {
+	target := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo-0", UID: "foo-0-uid", ResourceVersion: "5"}}
+	patch, err := preconditionedLabelPatch(target, "to-delete", "1")
+	if err != nil {
+		t.Fatalf("preconditionedLabelPatch() = %v", err)
+	}
+	data, _ := patch.Data(target)
+	if want := `{"metadata":{"labels":{"to-delete":"1"},"uid":"foo-0-uid"}}`; string(data) != want {
+		t.Errorf("got patch %s, want %s", data, want)
+	}
+
+	// preconditions unknown are left out rather than clearing fields of target
+	patch, _ = preconditionedLabelPatch(&corev1.Pod{}, "to-delete", "1")
+	data, _ = patch.Data(target)
+	if want := `{"metadata":{"labels":{"to-delete":"1"}}}`; string(data) != want {
+		t.Errorf("got patch %s, want %s", data, want)
+	}
+}
+
+type defaulterController struct {
+	dryRunController
+	err error
+}
+
+// DefaultTarget labels target with the value set by update funcs, to verify it runs last
+func (c *defaulterController) DefaultTarget(_ api.XSetObject, target client.Object) error {
+	if c.err != nil {
+		return c.err
+	}
+	labels := target.GetLabels()
+	labels["tenant"] = "t-" + labels["patched"]
+	target.SetLabels(labels)
+	return nil
+}
+
+func TestNewTargetFromWithTemplateDefaulter(t *testing.T) {
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	revision := &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: "rev-1"}}
+	patch := func(target client.Object) error {
+		target.GetLabels()["patched"] = "1"
+		return nil
+	}
+
+	target, err := NewTargetFrom(&defaulterController{}, api.NewXSetLabelAnnotationManager(nil), xset, revision, 0, patch)
+	if err != nil {
+		t.Fatalf("NewTargetFrom() = %v", err)
+	}
+	if got := target.GetLabels()["tenant"]; got != "t-1" {
+		t.Errorf("NewTargetFrom() defaults label tenant to %q, want t-1", got)
+	}
+
+	if _, err := NewTargetFrom(&defaulterController{err: fmt.Errorf("no tenant")}, api.NewXSetLabelAnnotationManager(nil), xset, revision, 0); err == nil {
+		t.Errorf("NewTargetFrom() returns no error, want error of defaulter")
+	}
+}
+
+func TestInjectSpread(t *testing.T) {
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}}
+	tests := []struct {
+		name     string
+		strategy *api.SpreadStrategy
+		check    func(t *testing.T, pod *corev1.Pod)
+	}{
+		{
+			name: "no strategy",
+			check: func(t *testing.T, pod *corev1.Pod) {
+				if pod.Spec.Affinity != nil || len(pod.Spec.TopologySpreadConstraints) != 0 {
+					t.Errorf("expect nothing injected, got %v and %v", pod.Spec.Affinity, pod.Spec.TopologySpreadConstraints)
+				}
+			},
+		},
+		{
+			name:     "preferred anti-affinity on hostname by default",
+			strategy: &api.SpreadStrategy{Type: api.SpreadStrategyAntiAffinity},
+			check: func(t *testing.T, pod *corev1.Pod) {
+				terms := pod.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+				if len(terms) != 1 || terms[0].PodAffinityTerm.TopologyKey != corev1.LabelHostname || terms[0].PodAffinityTerm.LabelSelector.MatchLabels["app"] != "foo" {
+					t.Errorf("got preferred anti-affinity %v", terms)
+				}
+			},
+		},
+		{
+			name:     "required anti-affinity",
+			strategy: &api.SpreadStrategy{Type: api.SpreadStrategyAntiAffinity, Required: true, TopologyKeys: []string{corev1.LabelTopologyZone}},
+			check: func(t *testing.T, pod *corev1.Pod) {
+				terms := pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+				if len(terms) != 1 || terms[0].TopologyKey != corev1.LabelTopologyZone {
+					t.Errorf("got required anti-affinity %v", terms)
+				}
+			},
+		},
+		{
+			name:     "topology spread",
+			strategy: &api.SpreadStrategy{Type: api.SpreadStrategyTopologySpread, Required: true, TopologyKeys: []string{corev1.LabelTopologyZone, corev1.LabelHostname}},
+			check: func(t *testing.T, pod *corev1.Pod) {
+				constraints := pod.Spec.TopologySpreadConstraints
+				if len(constraints) != 2 || constraints[0].MaxSkew != 1 || constraints[0].WhenUnsatisfiable != corev1.DoNotSchedule ||
+					constraints[1].TopologyKey != corev1.LabelHostname {
+					t.Errorf("got topology spread constraints %v", constraints)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{}
+			injectSpread(&api.XSetSpec{Selector: selector, SpreadStrategy: tt.strategy}, pod)
+			tt.check(t, pod)
+		})
+	}
+}
+
+type placementController struct {
+	api.XSetController
+	sticky bool
+	zones  []string
+}
+
+func (c *placementController) StickToRecordedNode(api.XSetObject) bool { return c.sticky }
+
+func (c *placementController) GetZonePlacement(api.XSetObject) (string, []string) { return "", c.zones }
+
+func TestStickToRecordedNode(t *testing.T) {
+	xsetController := &placementController{sticky: true}
+	r := &RealSyncControl{
+		xsetController:         xsetController,
+		resourceContextControl: fake.NewResourceContextControl(xsetController),
+	}
+	xset := &corev1.Pod{}
+	contextDetail := &api.ContextDetail{ID: 0}
+
+	landed := &corev1.Pod{Spec: corev1.PodSpec{NodeName: "node-a"}}
+	if !r.recordTargetNode(xset, landed, contextDetail) {
+		t.Fatal("recordTargetNode() = false, want node recorded")
+	}
+	if r.recordTargetNode(xset, landed, contextDetail) {
+		t.Error("recordTargetNode() = true, want no change for node already recorded")
+	}
+
+	// recorded node is required in every existing node selector term
+	recreated := &corev1.Pod{Spec: corev1.PodSpec{Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{
+			{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}}}},
+			{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"b"}}}},
+		}},
+	}}}}
+	r.stickToRecordedNode(xset, recreated, contextDetail)
+	for _, term := range recreated.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		if len(term.MatchExpressions) != 1 || len(term.MatchFields) != 1 || term.MatchFields[0].Values[0] != "node-a" {
+			t.Errorf("got node selector term %v, want its expression kept and node-a required", term)
+		}
+	}
+
+	xsetController.sticky = false
+	notSticky := &corev1.Pod{}
+	r.stickToRecordedNode(xset, notSticky, contextDetail)
+	if notSticky.Spec.Affinity != nil {
+		t.Errorf("expect no affinity injected when not sticky, got %v", notSticky.Spec.Affinity)
+	}
+}
+
+func TestPlaceInZones(t *testing.T) {
+	xsetController := &placementController{zones: []string{"zone-a", "zone-b"}}
+	resourceContextControl := fake.NewResourceContextControl(xsetController)
+	r := &RealSyncControl{
+		xsetController:         xsetController,
+		resourceContextControl: resourceContextControl,
+	}
+	xset := &corev1.Pod{}
+	inZone := func(id int, zone string) *api.ContextDetail {
+		contextDetail := &api.ContextDetail{ID: id}
+		if zone != "" {
+			resourceContextControl.Put(contextDetail, api.EnumZoneContextDataKey, zone)
+		}
+		return contextDetail
+	}
+	activeTargets := []*TargetWrapper{
+		{ID: 0, ContextDetail: inZone(0, "zone-a")},
+		{ID: 1, ContextDetail: inZone(1, "zone-a")},
+	}
+	// context in a zone out of placement is moved, and new ones go to the zone with fewest targets
+	contexts := []*api.ContextDetail{inZone(2, "zone-c"), inZone(3, ""), inZone(4, "zone-a")}
+	if !r.assignZones(xset, activeTargets, contexts) {
+		t.Fatal("assignZones() = false, want contexts changed")
+	}
+	var zones []string
+	for _, contextDetail := range contexts {
+		zone, _ := resourceContextControl.Get(contextDetail, api.EnumZoneContextDataKey)
+		zones = append(zones, zone)
+	}
+	if want := []string{"zone-b", "zone-b", "zone-a"}; fmt.Sprint(zones) != fmt.Sprint(want) {
+		t.Errorf("assignZones() assigns %v, want %v", zones, want)
+	}
+
+	// zone is required by node affinity, merged with affinity of template
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		NodeSelector: map[string]string{"pool": "a"},
+		Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "arch", Operator: corev1.NodeSelectorOpIn, Values: []string{"arm64"}}}},
+			}},
+		}},
+	}}
+	r.injectZone(xset, pod, contexts[0])
+	if len(pod.Spec.NodeSelector) != 1 {
+		t.Errorf("expect nodeSelector of template untouched, got %v", pod.Spec.NodeSelector)
+	}
+	terms := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 || len(terms[0].MatchExpressions) != 2 ||
+		terms[0].MatchExpressions[1].Key != corev1.LabelTopologyZone || terms[0].MatchExpressions[1].Values[0] != "zone-b" {
+		t.Errorf("got node selector terms %v, want arm64 and zone-b required", terms)
+	}
+}
+
+// readyTimeController checks Pod targets available once ready, and ready since their start time
+type readyTimeController struct {
+	api.XSetController
+}
+
+func (c *readyTimeController) CheckAvailable(object client.Object) bool {
+	return object.(*corev1.Pod).Status.Phase == corev1.PodRunning
+}
+
+func (c *readyTimeController) CheckReadyTime(object client.Object) (bool, *metav1.Time) {
+	pod := object.(*corev1.Pod)
+	return pod.Status.Phase == corev1.PodRunning, pod.Status.StartTime
+}
+
+// customAvailabilityController only checks Pod targets available by annotation
+type customAvailabilityController struct {
+	readyTimeController
+}
+
+func (c *customAvailabilityController) CheckTargetAvailable(object client.Object) (bool, *time.Duration) {
+	return object.GetAnnotations()["available"] == "true", nil
+}
+
+func TestCheckTargetAvailableForStatus(t *testing.T) {
+	newPod := func(readyFor time.Duration, available bool) *corev1.Pod {
+		startTime := metav1.NewTime(time.Now().Add(-readyFor))
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"available": fmt.Sprint(available)}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, StartTime: &startTime},
+		}
+	}
+	tests := []struct {
+		name            string
+		xsetController  api.XSetController
+		minReadySeconds int32
+		target          *corev1.Pod
+		want            bool
+		wantRecheck     bool
+	}{
+		{name: "available without minReadySeconds", xsetController: &readyTimeController{}, target: newPod(0, false), want: true},
+		{name: "ready shorter than minReadySeconds", xsetController: &readyTimeController{}, minReadySeconds: 60, target: newPod(10*time.Second, false), wantRecheck: true},
+		{name: "ready longer than minReadySeconds", xsetController: &readyTimeController{}, minReadySeconds: 60, target: newPod(2*time.Minute, false), want: true},
+		{name: "adapter unavailable", xsetController: &customAvailabilityController{}, minReadySeconds: 60, target: newPod(2*time.Minute, false)},
+		{name: "adapter available shorter than minReadySeconds", xsetController: &customAvailabilityController{}, minReadySeconds: 60, target: newPod(10*time.Second, true), wantRecheck: true},
+		{name: "adapter available longer than minReadySeconds", xsetController: &customAvailabilityController{}, minReadySeconds: 60, target: newPod(2*time.Minute, true), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &api.XSetSpec{MinReadySeconds: tt.minReadySeconds}
+			got, recheckAfter := checkTargetAvailableForStatus(tt.xsetController, spec, tt.target)
+			if got != tt.want || (recheckAfter != nil) != tt.wantRecheck {
+				t.Errorf("checkTargetAvailableForStatus() = %v, %v, want %v with recheck %v", got, recheckAfter, tt.want, tt.wantRecheck)
+			}
+			if recheckAfter != nil && (*recheckAfter <= 0 || *recheckAfter > 50*time.Second) {
+				t.Errorf("got recheck after %v, want the rest of minReadySeconds", *recheckAfter)
+			}
+		})
+	}
+}
+
+// readinessGateController injects service-ready readinessGate, and checks Pod targets available once running
+type readinessGateController struct {
+	readyTimeController
+}
+
+func (c *readinessGateController) GetReadinessGates(api.XSetObject) []corev1.PodConditionType {
+	return []corev1.PodConditionType{api.PodServiceReadyReadinessGate}
+}
+
+func TestReadinessGates(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{ReadinessGates: []corev1.PodReadinessGate{{ConditionType: "custom"}}}}
+	injectReadinessGates(&readyTimeController{}, &corev1.Pod{}, pod)
+	if len(pod.Spec.ReadinessGates) != 1 {
+		t.Fatalf("got readinessGates %v, want untouched without adapter", pod.Spec.ReadinessGates)
+	}
+	xsetController := &readinessGateController{}
+	injectReadinessGates(xsetController, &corev1.Pod{}, pod)
+	injectReadinessGates(xsetController, &corev1.Pod{}, pod)
+	want := []corev1.PodReadinessGate{{ConditionType: "custom"}, {ConditionType: api.PodServiceReadyReadinessGate}}
+	if fmt.Sprint(pod.Spec.ReadinessGates) != fmt.Sprint(want) {
+		t.Fatalf("got readinessGates %v, want %v injected once", pod.Spec.ReadinessGates, want)
+	}
+
+	// running Pod is only available once all readinessGate conditions are True
+	pod.Status.Phase = corev1.PodRunning
+	if available, _ := checkTargetAvailable(&readyTimeController{}, pod); !available {
+		t.Error("Pod is not available without adapter")
+	}
+	if available, _ := checkTargetAvailable(xsetController, pod); available {
+		t.Error("Pod is available without readinessGate conditions")
+	}
+	pod.Status.Conditions = []corev1.PodCondition{
+		{Type: "custom", Status: corev1.ConditionTrue},
+		{Type: api.PodServiceReadyReadinessGate, Status: corev1.ConditionFalse},
+	}
+	if available, _ := checkTargetAvailable(xsetController, pod); available {
+		t.Error("Pod is available with a False readinessGate condition")
+	}
+	pod.Status.Conditions[1].Status = corev1.ConditionTrue
+	if available, _ := checkTargetAvailable(xsetController, pod); !available {
+		t.Error("Pod is not available with all readinessGate conditions True")
+	}
+}
+
+// preCreateController sets hostname of Pod targets by instance ID, and fails for IDs in failedIDs
+type preCreateController struct {
+	api.XSetController
+	failedIDs sets.Int
+}
+
+func (c *preCreateController) PreCreate(_ context.Context, _ api.XSetObject, target client.Object, contextDetail *api.ContextDetail) error {
+	if c.failedIDs.Has(contextDetail.ID) {
+		return fmt.Errorf("shard of ID %d is not assigned", contextDetail.ID)
+	}
+	target.(*corev1.Pod).Spec.Hostname = fmt.Sprintf("foo-%d", contextDetail.ID)
+	return nil
+}
+
+func TestPreCreateTarget(t *testing.T) {
+	pod := &corev1.Pod{}
+	if err := preCreateTarget(context.TODO(), &readyTimeController{}, &corev1.Pod{}, pod, &api.ContextDetail{ID: 1}); err != nil || pod.Spec.Hostname != "" {
+		t.Fatalf("preCreateTarget() = %v with hostname %q, want untouched without adapter", err, pod.Spec.Hostname)
+	}
+
+	xsetController := &preCreateController{failedIDs: sets.NewInt(2)}
+	if err := preCreateTarget(context.TODO(), xsetController, &corev1.Pod{}, pod, &api.ContextDetail{ID: 1}); err != nil || pod.Spec.Hostname != "foo-1" {
+		t.Errorf("preCreateTarget() = %v with hostname %q, want foo-1", err, pod.Spec.Hostname)
+	}
+	if err := preCreateTarget(context.TODO(), xsetController, &corev1.Pod{}, &corev1.Pod{}, &api.ContextDetail{ID: 2}); err == nil {
+		t.Error("preCreateTarget() = nil, want error of hook")
+	}
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+)
+
+func getWarningEventPolicy(xsetController api.XSetController, xsetObject api.XSetObject) *api.WarningEventPolicy {
+	adapter, ok := xsetController.(api.WarningEventPauseAdapter)
+	if !ok {
+		return nil
+	}
+	return adapter.GetWarningEventPolicy(xsetObject)
+}
+
+// gateUpdateByWarningEvents pauses rollout of updated revision once its targets emit Warning events beyond threshold
+// of WarningEventPauseAdapter, and returns candidates allowed to update. Pause is recorded in AutoPausedRevision of
+// status, and kept until resumed by XSetResumeRolloutAnnotationKey or template changes to another revision.
+func (r *RealSyncControl) gateUpdateByWarningEvents(
+	ctx context.Context,
+	xsetObject api.XSetObject,
+	syncContext *SyncContext,
+	targetInfos, candidates []*TargetUpdateInfo,
+) ([]*TargetUpdateInfo, error) {
+	newStatus := syncContext.NewStatus
+	updatedRevision := syncContext.UpdatedRevision.Name
+	policy := getWarningEventPolicy(r.xsetController, xsetObject)
+	if policy == nil || syncContext.RollingBack || newStatus.AutoPausedRevision != updatedRevision {
+		newStatus.AutoPausedRevision = ""
+	}
+	if policy == nil || syncContext.RollingBack {
+		conditions.Remove(newStatus, api.XSetRolloutAutoPaused)
+		return candidates, nil
+	}
+
+	generation := xsetObject.GetGeneration()
+	if xsetObject.GetAnnotations()[api.XSetResumeRolloutAnnotationKey] == updatedRevision {
+		if newStatus.AutoPausedRevision != "" {
+			r.Recorder.Eventf(xsetObject, corev1.EventTypeNormal, "RolloutResumed", "rollout of revision %s is resumed by annotation %s",
+				updatedRevision, api.XSetResumeRolloutAnnotationKey)
+		}
+		newStatus.AutoPausedRevision = ""
+		conditions.Set(newStatus, api.XSetRolloutAutoPaused, metav1.ConditionFalse, conditions.ReasonResumedByAnnotation,
+			fmt.Sprintf("rollout of revision %s is resumed by annotation %s", updatedRevision, api.XSetResumeRolloutAnnotationKey), generation)
+		return candidates, nil
+	}
+	if newStatus.AutoPausedRevision == updatedRevision {
+		return holdPendingUpdate(candidates), nil
+	}
+	conditions.Remove(newStatus, api.XSetRolloutAutoPaused)
+
+	updatedTargets := map[types.UID]struct{}{}
+	for _, targetInfo := range targetInfos {
+		if !targetInfo.PlaceHolder && IsTargetUpdatedRevision(targetInfo.Object, updatedRevision) {
+			updatedTargets[targetInfo.GetUID()] = struct{}{}
+		}
+	}
+	if len(updatedTargets) == 0 || !hasPendingUpdate(candidates) {
+		return candidates, nil
+	}
+
+	count, sample, err := r.countWarningEvents(ctx, xsetObject.GetNamespace(), policy, updatedTargets, time.Now())
+	if err != nil {
+		return holdPendingUpdate(candidates), fmt.Errorf("fail to list warning events of revision %s: %w", updatedRevision, err)
+	}
+	if count < policy.Threshold {
+		return candidates, nil
+	}
+	newStatus.AutoPausedRevision = updatedRevision
+	message := fmt.Sprintf("%d warning events from targets of revision %s within %s, e.g., %s: %s",
+		count, updatedRevision, policy.Window, sample.Reason, sample.Message)
+	conditions.Set(newStatus, api.XSetRolloutAutoPaused, metav1.ConditionTrue, conditions.ReasonWarningEventsExceeded, message, generation)
+	r.Recorder.Eventf(xsetObject, corev1.EventTypeWarning, "RolloutAutoPaused", "rollout is paused: %s", message)
+	return holdPendingUpdate(candidates), nil
+}
+
+// warningEventsPageSize is the max number of events listed in a request, so that namespaces with lots of events are
+// listed in pages rather than loaded from api server at once
+const warningEventsPageSize = 500
+
+// countWarningEvents counts occurrences of Warning events involving targets within window of policy, and returns the
+// latest one as sample
+func (r *RealSyncControl) countWarningEvents(ctx context.Context, namespace string, policy *api.WarningEventPolicy, targets map[types.UID]struct{}, now time.Time) (int32, *corev1.Event, error) {
+	var count int32
+	var sample *corev1.Event
+	var sampleLastSeen time.Time
+	events := &corev1.EventList{}
+	for {
+		if err := r.APIReader.List(ctx, events, client.InNamespace(namespace),
+			client.MatchingFieldsSelector{Selector: fields.OneTermEqualSelector("type", corev1.EventTypeWarning)},
+			client.Limit(warningEventsPageSize), client.Continue(events.Continue)); err != nil {
+			return 0, nil, err
+		}
+		for i := range events.Items {
+			event := &events.Items[i]
+			if event.Type != corev1.EventTypeWarning {
+				continue
+			}
+			if _, exist := targets[event.InvolvedObject.UID]; !exist {
+				continue
+			}
+			if len(policy.Reasons) > 0 && !slices.Contains(policy.Reasons, event.Reason) {
+				continue
+			}
+			lastSeen := getEventLastSeen(event)
+			if now.Sub(lastSeen) > policy.Window {
+				continue
+			}
+			count += countEventInWindow(event, lastSeen, now.Add(-policy.Window))
+			if sample == nil || lastSeen.After(sampleLastSeen) {
+				sample, sampleLastSeen = event.DeepCopy(), lastSeen
+			}
+		}
+		if events.Continue == "" {
+			return count, sample, nil
+		}
+	}
+}
+
+// countEventInWindow returns occurrences of event since windowStart. Occurrences of an aggregated event first seen
+// before windowStart are prorated by time, assuming they occur evenly between first and last seen, and all of them are
+// counted if first seen is unknown.
+func countEventInWindow(event *corev1.Event, lastSeen, windowStart time.Time) int32 {
+	count := max(event.Count, 1)
+	if event.Series != nil {
+		count = max(event.Series.Count, 1)
+	}
+	firstSeen := getEventFirstSeen(event)
+	if count == 1 || firstSeen.IsZero() || !firstSeen.Before(windowStart) || !lastSeen.After(firstSeen) {
+		return count
+	}
+	return max(int32(float64(count)*float64(lastSeen.Sub(windowStart))/float64(lastSeen.Sub(firstSeen))), 1)
+}
+
+// getEventFirstSeen returns when event is first seen, recorded differently by core/v1 and events.k8s.io/v1 recorders
+func getEventFirstSeen(event *corev1.Event) time.Time {
+	if !event.FirstTimestamp.IsZero() {
+		return event.FirstTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// getEventLastSeen returns when event is last seen, recorded differently by core/v1 and events.k8s.io/v1 recorders
+func getEventLastSeen(event *corev1.Event) time.Time {
+	if event.Series != nil && !event.Series.LastObservedTime.IsZero() {
+		return event.Series.LastObservedTime.Time
+	}
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+)
+
+type warningEventController struct {
+	api.XSetController
+}
+
+func (c *warningEventController) GetWarningEventPolicy(api.XSetObject) *api.WarningEventPolicy {
+	return &api.WarningEventPolicy{Threshold: 3, Window: 10 * time.Minute, Reasons: []string{"BackOff"}}
+}
+
+func newWarningEvent(name string, uid types.UID, eventType, reason string, count int32, lastSeen time.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Namespace: "default", Name: name},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "default", UID: uid},
+		Type:           eventType,
+		Reason:         reason,
+		Message:        "back-off restarting failed container",
+		Count:          count,
+		LastTimestamp:  metav1.NewTime(lastSeen),
+	}
+}
+
+func TestGateUpdateByWarningEvents(t *testing.T) {
+	now := time.Now()
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+		newWarningEvent("updated-backoff", "updated", corev1.EventTypeWarning, "BackOff", 2, now),
+		newWarningEvent("updated-backoff-expired", "updated", corev1.EventTypeWarning, "BackOff", 5, now.Add(-time.Hour)),
+		newWarningEvent("updated-unhealthy", "updated", corev1.EventTypeWarning, "Unhealthy", 5, now),
+		newWarningEvent("updated-normal", "updated", corev1.EventTypeNormal, "BackOff", 5, now),
+		newWarningEvent("current-backoff", "current", corev1.EventTypeWarning, "BackOff", 5, now),
+	).Build()
+	r := &RealSyncControl{xsetController: &warningEventController{}}
+	r.APIReader = c
+	r.Recorder = record.NewFakeRecorder(10)
+
+	updated := &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: "foo-2"}}
+	newInfo := func(uid types.UID, revision string) *TargetUpdateInfo {
+		target := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: string(uid), UID: uid,
+			Labels: map[string]string{appsv1.ControllerRevisionHashLabelKey: revision}}}
+		return &TargetUpdateInfo{TargetWrapper: &TargetWrapper{Object: target}, IsUpdatedRevision: revision == updated.Name}
+	}
+	infos := []*TargetUpdateInfo{newInfo("updated", "foo-2"), newInfo("current", "foo-1")}
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	syncContext := &SyncContext{UpdatedRevision: updated, NewStatus: &api.XSetStatus{}}
+
+	// 2 BackOff events within window do not reach threshold
+	candidates, err := r.gateUpdateByWarningEvents(context.TODO(), xset, syncContext, infos, infos)
+	if err != nil || len(candidates) != 2 || syncContext.NewStatus.AutoPausedRevision != "" {
+		t.Fatalf("got %d candidates, paused revision %q and err %v, want rollout proceeding",
+			len(candidates), syncContext.NewStatus.AutoPausedRevision, err)
+	}
+
+	if err := c.Create(context.TODO(), newWarningEvent("updated-backoff-2", "updated", corev1.EventTypeWarning, "BackOff", 1, now)); err != nil {
+		t.Fatalf("fail to create event: %v", err)
+	}
+	candidates, err = r.gateUpdateByWarningEvents(context.TODO(), xset, syncContext, infos, infos)
+	if err != nil || len(candidates) != 1 || syncContext.NewStatus.AutoPausedRevision != updated.Name {
+		t.Fatalf("got %d candidates, paused revision %q and err %v, want rollout paused",
+			len(candidates), syncContext.NewStatus.AutoPausedRevision, err)
+	}
+	if !conditions.IsTrue(syncContext.NewStatus, api.XSetRolloutAutoPaused) {
+		t.Errorf("expect RolloutAutoPaused condition true")
+	}
+
+	// rollout is resumed by annotation
+	xset.Annotations = map[string]string{api.XSetResumeRolloutAnnotationKey: updated.Name}
+	candidates, err = r.gateUpdateByWarningEvents(context.TODO(), xset, syncContext, infos, infos)
+	if err != nil || len(candidates) != 2 || syncContext.NewStatus.AutoPausedRevision != "" {
+		t.Fatalf("got %d candidates, paused revision %q and err %v, want rollout resumed",
+			len(candidates), syncContext.NewStatus.AutoPausedRevision, err)
+	}
+	if _, found := conditions.FindAndCompare(syncContext.NewStatus, api.XSetRolloutAutoPaused, metav1.ConditionFalse, conditions.ReasonResumedByAnnotation); !found {
+		t.Errorf("expect RolloutAutoPaused condition resumed by annotation")
+	}
+}
+
+func TestCountEventInWindow(t *testing.T) {
+	now := time.Now()
+	windowStart := now.Add(-10 * time.Minute)
+	tests := []struct {
+		name  string
+		event *corev1.Event
+		want  int32
+	}{
+		{name: "single", event: &corev1.Event{}, want: 1},
+		{name: "aggregated within window", event: &corev1.Event{Count: 10, FirstTimestamp: metav1.NewTime(now.Add(-5 * time.Minute))}, want: 10},
+		{name: "aggregated across window", event: &corev1.Event{Count: 40, FirstTimestamp: metav1.NewTime(now.Add(-40 * time.Minute))}, want: 10},
+		{name: "series across window", event: &corev1.Event{Series: &corev1.EventSeries{Count: 20}, EventTime: metav1.NewMicroTime(now.Add(-20 * time.Minute))}, want: 10},
+		{name: "first seen unknown", event: &corev1.Event{Count: 40}, want: 40},
+		{name: "prorated at least once", event: &corev1.Event{Count: 2, FirstTimestamp: metav1.NewTime(now.Add(-24 * time.Hour))}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countEventInWindow(tt.event, now, windowStart); got != tt.want {
+				t.Errorf("countEventInWindow() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/flowcontrol"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// writeLimiters keeps token buckets limiting targets created and deleted by each XSet with XSetWriteQPSAnnotationKey
+type writeLimiters struct {
+	mu       sync.Mutex
+	limiters map[string]*writeLimiter
+	// invalid keeps the last error of invalid annotations of each XSet, which is reported once
+	invalid map[string]string
+}
+
+type writeLimiter struct {
+	qps   float32
+	burst int
+	flowcontrol.RateLimiter
+}
+
+func newWriteLimiters() *writeLimiters {
+	return &writeLimiters{limiters: map[string]*writeLimiter{}, invalid: map[string]string{}}
+}
+
+// getWriteRateLimit returns qps and burst in annotations of xset, and zero qps if it is not limited. Burst defaults to
+// qps rounded up.
+func getWriteRateLimit(xset client.Object) (float32, int, error) {
+	value, ok := xset.GetAnnotations()[api.XSetWriteQPSAnnotationKey]
+	if !ok {
+		return 0, 0, nil
+	}
+	qps, err := strconv.ParseFloat(value, 32)
+	if err != nil || qps <= 0 {
+		return 0, 0, fmt.Errorf("invalid annotation %s=%q, must be a positive number", api.XSetWriteQPSAnnotationKey, value)
+	}
+	burst := int(math.Ceil(qps))
+	if value, ok := xset.GetAnnotations()[api.XSetWriteBurstAnnotationKey]; ok {
+		if burst, err = strconv.Atoi(value); err != nil || burst <= 0 {
+			return 0, 0, fmt.Errorf("invalid annotation %s=%q, must be a positive integer", api.XSetWriteBurstAnnotationKey, value)
+		}
+	}
+	return float32(qps), burst, nil
+}
+
+// get returns the limiter of xset, which is renewed once its annotations change, or nil if xset is not limited.
+// Error of invalid annotations is returned only once until it changes.
+func (l *writeLimiters) get(xset client.Object) (*writeLimiter, error) {
+	if l == nil {
+		return nil, nil
+	}
+	qps, burst, err := getWriteRateLimit(xset)
+	key := ObjectKeyString(xset)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil {
+		delete(l.limiters, key)
+		if l.invalid[key] == err.Error() {
+			return nil, nil
+		}
+		l.invalid[key] = err.Error()
+		return nil, err
+	}
+	delete(l.invalid, key)
+	if qps == 0 {
+		delete(l.limiters, key)
+		return nil, nil
+	}
+	limiter, ok := l.limiters[key]
+	if !ok || limiter.qps != qps || limiter.burst != burst {
+		limiter = &writeLimiter{qps: qps, burst: burst, RateLimiter: flowcontrol.NewTokenBucketRateLimiter(qps, burst)}
+		l.limiters[key] = limiter
+	}
+	return limiter, nil
+}
+
+// forget drops the limiter of a deleted xset
+func (l *writeLimiters) forget(key string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.limiters, key)
+	delete(l.invalid, key)
+}
+
+// tryAccept takes a token if available, and always succeeds for nil limiter
+func (l *writeLimiter) tryAccept() bool {
+	return l == nil || l.TryAccept()
+}
+
+// retryAfter returns the interval after which a token is available again
+func (l *writeLimiter) retryAfter() time.Duration {
+	return time.Duration(float64(time.Second) / float64(l.qps))
+}
+
+// getWriteLimiter returns the limiter of targets created and deleted by xset, which is disabled with a warning event
+// once annotations turn invalid
+func (r *RealSyncControl) getWriteLimiter(xset api.XSetObject) *writeLimiter {
+	limiter, err := r.writeLimiters.get(xset)
+	if err != nil {
+		r.Recorder.Eventf(xset, corev1.EventTypeWarning, "InvalidWriteRateLimit", "write rate limit is disabled: %s", err.Error())
+	}
+	return limiter
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package synccontrols
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+func TestGetWriteRateLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		qps         float32
+		burst       int
+		wantErr     bool
+	}{
+		{name: "unlimited"},
+		{name: "default burst", annotations: map[string]string{api.XSetWriteQPSAnnotationKey: "2.5"}, qps: 2.5, burst: 3},
+		{name: "burst", annotations: map[string]string{api.XSetWriteQPSAnnotationKey: "1", api.XSetWriteBurstAnnotationKey: "20"}, qps: 1, burst: 20},
+		{name: "invalid qps", annotations: map[string]string{api.XSetWriteQPSAnnotationKey: "0"}, wantErr: true},
+		{name: "invalid burst", annotations: map[string]string{api.XSetWriteQPSAnnotationKey: "1", api.XSetWriteBurstAnnotationKey: "x"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}
+			qps, burst, err := getWriteRateLimit(xset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getWriteRateLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if qps != tt.qps || burst != tt.burst {
+				t.Errorf("getWriteRateLimit() = %v, %v, want %v, %v", qps, burst, tt.qps, tt.burst)
+			}
+		})
+	}
+}
+
+func TestWriteLimiters(t *testing.T) {
+	limiters := newWriteLimiters()
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "default",
+		Name:        "foo",
+		Annotations: map[string]string{api.XSetWriteQPSAnnotationKey: "0.5", api.XSetWriteBurstAnnotationKey: "2"},
+	}}
+
+	limiter, err := limiters.get(xset)
+	if err != nil || limiter == nil {
+		t.Fatalf("get() = %v, %v", limiter, err)
+	}
+	if !limiter.tryAccept() || !limiter.tryAccept() || limiter.tryAccept() {
+		t.Errorf("limiter should accept burst of 2 only")
+	}
+	if limiter.retryAfter() != 2*time.Second {
+		t.Errorf("retryAfter() = %v, want 2s", limiter.retryAfter())
+	}
+	if again, _ := limiters.get(xset); again != limiter {
+		t.Errorf("limiter should be kept while annotations are unchanged")
+	}
+
+	xset.Annotations[api.XSetWriteBurstAnnotationKey] = "5"
+	if renewed, _ := limiters.get(xset); renewed == limiter || !renewed.tryAccept() {
+		t.Errorf("limiter should be renewed once annotations change")
+	}
+
+	delete(xset.Annotations, api.XSetWriteQPSAnnotationKey)
+	if limiter, _ = limiters.get(xset); limiter != nil || !limiter.tryAccept() {
+		t.Errorf("nil limiter should accept all")
+	}
+
+	xset.Annotations[api.XSetWriteQPSAnnotationKey] = "x"
+	if _, err = limiters.get(xset); err == nil {
+		t.Errorf("get() should return error of invalid annotations")
+	}
+	if _, err = limiters.get(xset); err != nil {
+		t.Errorf("get() = %v, want error reported only once", err)
+	}
+	xset.Annotations[api.XSetWriteQPSAnnotationKey] = "-1"
+	if _, err = limiters.get(xset); err == nil {
+		t.Errorf("get() should return error once invalid annotations change")
+	}
+
+	xset.Annotations[api.XSetWriteQPSAnnotationKey] = "1"
+	if limiter, _ = limiters.get(xset); limiter == nil {
+		t.Fatalf("get() should return limiter of valid annotations")
+	}
+	limiters.forget(ObjectKeyString(xset))
+	if len(limiters.limiters) != 0 || len(limiters.invalid) != 0 {
+		t.Errorf("limiter of xset is not dropped by forget")
+	}
+}
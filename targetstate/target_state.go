@@ -0,0 +1,167 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package targetstate provides helpers to read and write lifecycle state labels kept on targets by XSet controllers,
+// so that external tooling and webhooks interoperate with them without hardcoding label keys. Writers only mutate
+// targets in memory, and callers are responsible for persisting them.
+package targetstate
+
+import (
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/opslifecycle"
+)
+
+// State reads and writes lifecycle state labels of targets managed by one kind of XSet
+type State struct {
+	labelAnnoMgr   api.XSetLabelAnnotationManager
+	updateAdapter  api.LifecycleAdapter
+	scaleInAdapter api.LifecycleAdapter
+}
+
+// NewState returns State with default label keys and lifecycle adapters of XSet kind, which are used by
+// XSetController implementing neither LabelAnnotationManagerGetter nor LifecycleAdapterGetter.
+func NewState(xsetTypeMeta metav1.TypeMeta) *State {
+	labelAnnoMgr := api.NewXSetLabelAnnotationManager(nil)
+	updateAdapter, scaleInAdapter := opslifecycle.GetLifecycleAdapters(nil, labelAnnoMgr, xsetTypeMeta)
+	return &State{labelAnnoMgr: labelAnnoMgr, updateAdapter: updateAdapter, scaleInAdapter: scaleInAdapter}
+}
+
+// NewStateForController returns State with label keys and lifecycle adapters of xsetController
+func NewStateForController(xsetController api.XSetController) *State {
+	labelAnnoMgr := api.GetXSetLabelAnnotationManager(xsetController)
+	updateAdapter, scaleInAdapter := opslifecycle.GetLifecycleAdapters(xsetController, labelAnnoMgr, xsetController.XSetMeta())
+	return &State{labelAnnoMgr: labelAnnoMgr, updateAdapter: updateAdapter, scaleInAdapter: scaleInAdapter}
+}
+
+// GetInstanceID returns instance ID of target
+func (s *State) GetInstanceID(target client.Object) (int, bool) {
+	value, exist := s.labelAnnoMgr.Get(target, api.XInstanceIdLabelKey)
+	if !exist {
+		return -1, false
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return -1, false
+	}
+	return id, true
+}
+
+// IsJustCreated checks whether target is created for a new instance ID, rather than recreated for an existing one
+func (s *State) IsJustCreated(target client.Object) bool {
+	_, exist := s.labelAnnoMgr.Get(target, api.XCreatingLabel)
+	return exist
+}
+
+// MarkJustCreated marks target as created for a new instance ID
+func (s *State) MarkJustCreated(target client.Object) {
+	s.labelAnnoMgr.Set(target, api.XCreatingLabel, nowString())
+}
+
+// IsToReplace checks whether target is indicated to be replaced
+func (s *State) IsToReplace(target client.Object) bool {
+	_, exist := s.labelAnnoMgr.Get(target, api.XReplaceIndicationLabelKey)
+	return exist
+}
+
+// MarkToReplace indicates target to be replaced by a new target
+func (s *State) MarkToReplace(target client.Object) {
+	if !s.IsToReplace(target) {
+		s.labelAnnoMgr.Set(target, api.XReplaceIndicationLabelKey, nowString())
+	}
+}
+
+// GetReplaceNewTargetID returns instance ID of the new target replacing origin target
+func (s *State) GetReplaceNewTargetID(origin client.Object) (int, bool) {
+	value, exist := s.labelAnnoMgr.Get(origin, api.XReplacePairNewId)
+	if !exist {
+		return -1, false
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return -1, false
+	}
+	return id, true
+}
+
+// GetReplaceOriginTargetName returns name of the origin target replaced by the new target
+func (s *State) GetReplaceOriginTargetName(newTarget client.Object) (string, bool) {
+	return s.labelAnnoMgr.Get(newTarget, api.XReplacePairOriginName)
+}
+
+// IsToDelete checks whether target is indicated to be deleted
+func (s *State) IsToDelete(target client.Object) bool {
+	_, exist := s.labelAnnoMgr.Get(target, api.XDeletionIndicationLabelKey)
+	return exist
+}
+
+// MarkToDelete indicates target to be deleted, which is scaled in and not recreated
+func (s *State) MarkToDelete(target client.Object) {
+	if !s.IsToDelete(target) {
+		s.labelAnnoMgr.Set(target, api.XDeletionIndicationLabelKey, nowString())
+	}
+}
+
+// IsQuarantined checks whether target is quarantined, which is kept for debugging out of replicas
+func (s *State) IsQuarantined(target client.Object) bool {
+	_, exist := s.labelAnnoMgr.Get(target, api.XQuarantinedLabelKey)
+	return exist
+}
+
+// MarkQuarantined quarantines target, so that a replacement is created in its place while it is kept alive
+func (s *State) MarkQuarantined(target client.Object) {
+	if !s.IsQuarantined(target) {
+		s.labelAnnoMgr.Set(target, api.XQuarantinedLabelKey, strconv.FormatInt(time.Now().Unix(), 10))
+	}
+}
+
+// IsChosenForScaleIn checks whether target is chosen to scale in, and is during scale-in ops lifecycle
+func (s *State) IsChosenForScaleIn(target client.Object) bool {
+	return opslifecycle.IsDuringOps(s.labelAnnoMgr, s.scaleInAdapter, target)
+}
+
+// IsPreparingDelete checks whether target is preparing to be deleted
+func (s *State) IsPreparingDelete(target client.Object) bool {
+	_, exist := s.labelAnnoMgr.Get(target, api.PreparingDeleteLabel)
+	return exist
+}
+
+// IsDuringUpdate checks whether target is chosen to update, and is during update ops lifecycle
+func (s *State) IsDuringUpdate(target client.Object) bool {
+	return opslifecycle.IsDuringOps(s.labelAnnoMgr, s.updateAdapter, target)
+}
+
+// IsToUpdate checks whether target is indicated to be updated by label, regardless of update strategy
+func (s *State) IsToUpdate(target client.Object) bool {
+	_, exist := s.labelAnnoMgr.Get(target, api.XSetUpdateIndicationLabelKey)
+	return exist
+}
+
+// MarkToUpdate indicates target to be updated by label, regardless of update strategy
+func (s *State) MarkToUpdate(target client.Object) {
+	if !s.IsToUpdate(target) {
+		s.labelAnnoMgr.Set(target, api.XSetUpdateIndicationLabelKey, nowString())
+	}
+}
+
+func nowString() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 10)
+}
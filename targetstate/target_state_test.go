@@ -0,0 +1,143 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package targetstate
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/opslifecycle"
+)
+
+type customLabelController struct {
+	api.XSetController
+}
+
+func (c *customLabelController) XSetMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "apps.example.io/v1", Kind: "FooSet"}
+}
+
+func (c *customLabelController) GetLabelManagerAdapter() map[api.XSetLabelAnnotationEnum]string {
+	return map[api.XSetLabelAnnotationEnum]string{api.XInstanceIdLabelKey: "example.io/instance-id"}
+}
+
+func TestStateMarks(t *testing.T) {
+	state := NewState(metav1.TypeMeta{APIVersion: "apps.example.io/v1", Kind: "FooSet"})
+	target := &corev1.Pod{}
+
+	checks := []struct {
+		name  string
+		is    func() bool
+		mark  func()
+		label api.XSetLabelAnnotationEnum
+	}{
+		{name: "JustCreated", is: func() bool { return state.IsJustCreated(target) }, mark: func() { state.MarkJustCreated(target) }, label: api.XCreatingLabel},
+		{name: "ToReplace", is: func() bool { return state.IsToReplace(target) }, mark: func() { state.MarkToReplace(target) }, label: api.XReplaceIndicationLabelKey},
+		{name: "ToDelete", is: func() bool { return state.IsToDelete(target) }, mark: func() { state.MarkToDelete(target) }, label: api.XDeletionIndicationLabelKey},
+		{name: "Quarantined", is: func() bool { return state.IsQuarantined(target) }, mark: func() { state.MarkQuarantined(target) }, label: api.XQuarantinedLabelKey},
+		{name: "ToUpdate", is: func() bool { return state.IsToUpdate(target) }, mark: func() { state.MarkToUpdate(target) }, label: api.XSetUpdateIndicationLabelKey},
+	}
+	for _, check := range checks {
+		if check.is() {
+			t.Errorf("Is%s() = true before marked", check.name)
+		}
+		check.mark()
+		if !check.is() {
+			t.Errorf("Is%s() = false after marked", check.name)
+		}
+		if _, exist := state.labelAnnoMgr.Get(target, check.label); !exist {
+			t.Errorf("Mark%s() does not set label of enum %d", check.name, check.label)
+		}
+	}
+
+	// time of marking is kept once marked
+	replaceValue, _ := state.labelAnnoMgr.Get(target, api.XReplaceIndicationLabelKey)
+	state.MarkToReplace(target)
+	if value, _ := state.labelAnnoMgr.Get(target, api.XReplaceIndicationLabelKey); value != replaceValue {
+		t.Errorf("MarkToReplace() overwrites %s with %s", replaceValue, value)
+	}
+}
+
+func TestStateReplacePair(t *testing.T) {
+	state := NewState(metav1.TypeMeta{APIVersion: "apps.example.io/v1", Kind: "FooSet"})
+	origin := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+		state.labelAnnoMgr.Value(api.XInstanceIdLabelKey): "1",
+		state.labelAnnoMgr.Value(api.XReplacePairNewId):   "3",
+	}}}
+	newTarget := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+		state.labelAnnoMgr.Value(api.XInstanceIdLabelKey):        "3",
+		state.labelAnnoMgr.Value(api.XReplacePairOriginName):     "foo-1",
+		state.labelAnnoMgr.Value(api.PreparingDeleteLabel):       "true",
+		state.labelAnnoMgr.Value(api.XReplaceIndicationLabelKey): "true",
+	}}}
+
+	if id, ok := state.GetInstanceID(origin); !ok || id != 1 {
+		t.Errorf("GetInstanceID() = %d, %v, want 1", id, ok)
+	}
+	if id, ok := state.GetReplaceNewTargetID(origin); !ok || id != 3 {
+		t.Errorf("GetReplaceNewTargetID() = %d, %v, want 3", id, ok)
+	}
+	if name, ok := state.GetReplaceOriginTargetName(newTarget); !ok || name != "foo-1" {
+		t.Errorf("GetReplaceOriginTargetName() = %s, %v, want foo-1", name, ok)
+	}
+	if _, ok := state.GetReplaceNewTargetID(newTarget); ok {
+		t.Error("GetReplaceNewTargetID() = true for target without replace pair")
+	}
+	if !state.IsPreparingDelete(newTarget) || state.IsPreparingDelete(origin) {
+		t.Error("IsPreparingDelete() does not follow the label")
+	}
+
+	origin.Labels[state.labelAnnoMgr.Value(api.XInstanceIdLabelKey)] = "x"
+	if _, ok := state.GetInstanceID(origin); ok {
+		t.Error("GetInstanceID() = true for invalid instance ID")
+	}
+}
+
+func TestStateOpsLifecycle(t *testing.T) {
+	state := NewState(metav1.TypeMeta{APIVersion: "apps.example.io/v1", Kind: "FooSet"})
+	target := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-0"}}
+	c := fake.NewClientBuilder().WithObjects(target).Build()
+
+	if state.IsChosenForScaleIn(target) || state.IsDuringUpdate(target) {
+		t.Fatal("target is during ops before lifecycle begins")
+	}
+	if _, err := opslifecycle.Begin(context.TODO(), state.labelAnnoMgr, c, state.scaleInAdapter, target); err != nil {
+		t.Fatalf("Begin() = %v", err)
+	}
+	if !state.IsChosenForScaleIn(target) || state.IsDuringUpdate(target) {
+		t.Error("got target not chosen for scale in only after scale-in lifecycle begins")
+	}
+	if _, err := opslifecycle.Begin(context.TODO(), state.labelAnnoMgr, c, state.updateAdapter, target); err != nil {
+		t.Fatalf("Begin() = %v", err)
+	}
+	if !state.IsDuringUpdate(target) {
+		t.Error("IsDuringUpdate() = false after update lifecycle begins")
+	}
+}
+
+func TestNewStateForController(t *testing.T) {
+	state := NewStateForController(&customLabelController{})
+	target := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"example.io/instance-id": "2"}}}
+	if id, ok := state.GetInstanceID(target); !ok || id != 2 {
+		t.Errorf("GetInstanceID() = %d, %v, want custom label key used", id, ok)
+	}
+}
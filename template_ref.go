@@ -0,0 +1,119 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/revisionowner"
+)
+
+// templateRefIndex records objects referenced as template by XSets, so that XSets are reconciled once templates change
+type templateRefIndex struct {
+	mu sync.RWMutex
+	// refs maps XSet to key of object it references
+	refs map[types.NamespacedName]string
+	// xsets maps key of referenced object to XSets referencing it
+	xsets map[string]map[types.NamespacedName]struct{}
+}
+
+func newTemplateRefIndex() *templateRefIndex {
+	return &templateRefIndex{
+		refs:  map[types.NamespacedName]string{},
+		xsets: map[string]map[types.NamespacedName]struct{}{},
+	}
+}
+
+func templateRefKey(gvk schema.GroupVersionKind, namespace, name string) string {
+	return gvk.String() + "/" + namespace + "/" + name
+}
+
+// Set records object referenced by XSet, and forgets the one referenced before if any
+func (i *templateRefIndex) Set(xset types.NamespacedName, ref *api.TemplateReference) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.delete(xset)
+	if ref == nil {
+		return
+	}
+	key := templateRefKey(revisionowner.GetTemplateRefGVK(ref), xset.Namespace, ref.Name)
+	i.refs[xset] = key
+	if _, ok := i.xsets[key]; !ok {
+		i.xsets[key] = map[types.NamespacedName]struct{}{}
+	}
+	i.xsets[key][xset] = struct{}{}
+}
+
+// Delete forgets object referenced by XSet
+func (i *templateRefIndex) Delete(xset types.NamespacedName) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.delete(xset)
+}
+
+func (i *templateRefIndex) delete(xset types.NamespacedName) {
+	key, ok := i.refs[xset]
+	if !ok {
+		return
+	}
+	delete(i.refs, xset)
+	delete(i.xsets[key], xset)
+	if len(i.xsets[key]) == 0 {
+		delete(i.xsets, key)
+	}
+}
+
+// Requests returns requests of XSets referencing the object
+func (i *templateRefIndex) Requests(gvk schema.GroupVersionKind, obj client.Object) []reconcile.Request {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	xsets := i.xsets[templateRefKey(gvk, obj.GetNamespace(), obj.GetName())]
+	requests := make([]reconcile.Request, 0, len(xsets))
+	for xset := range xsets {
+		requests = append(requests, reconcile.Request{NamespacedName: xset})
+	}
+	return requests
+}
+
+// watchTemplateRefs watches kinds allowed by TemplateRefAdapter, and enqueues XSets referencing changed objects
+func watchTemplateRefs(c controller.Controller, xsetController api.XSetController, index *templateRefIndex) error {
+	adapter, ok := xsetController.(api.TemplateRefAdapter)
+	if !ok {
+		return nil
+	}
+	for _, kind := range adapter.GetTemplateRefKinds() {
+		gvk := kind.GroupVersionKind()
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		if err := c.Watch(&source.Kind{Type: obj}, handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+			return index.Requests(gvk, obj)
+		})); err != nil {
+			return err
+		}
+	}
+	return nil
+}
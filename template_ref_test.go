@@ -0,0 +1,57 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+func TestTemplateRefIndex(t *testing.T) {
+	index := newTemplateRefIndex()
+	configMapGVK := corev1.SchemeGroupVersion.WithKind("ConfigMap")
+	template := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "template"}}
+	foo := types.NamespacedName{Namespace: "default", Name: "foo"}
+	bar := types.NamespacedName{Namespace: "default", Name: "bar"}
+
+	index.Set(foo, &api.TemplateReference{Name: "template"})
+	index.Set(bar, &api.TemplateReference{Name: "template"})
+	if requests := index.Requests(configMapGVK, template); len(requests) != 2 {
+		t.Errorf("got requests %v, want foo and bar", requests)
+	}
+
+	// XSets referencing another object or nothing are not enqueued
+	index.Set(foo, &api.TemplateReference{Name: "other"})
+	index.Set(bar, nil)
+	if requests := index.Requests(configMapGVK, template); len(requests) != 0 {
+		t.Errorf("got requests %v, want none", requests)
+	}
+	other := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other"}}
+	if requests := index.Requests(configMapGVK, other); len(requests) != 1 || requests[0].NamespacedName != foo {
+		t.Errorf("got requests %v, want foo", requests)
+	}
+
+	index.Delete(foo)
+	if len(index.refs) != 0 || len(index.xsets) != 0 {
+		t.Errorf("got index %v and %v, want empty", index.refs, index.xsets)
+	}
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/testing/integration"
+)
+
+func TestTargetControl(t *testing.T) {
+	ctx := context.Background()
+	xsetController := &integration.SampleXSetController{}
+	set := integration.NewSampleSet("default", "foo", 2, "nginx")
+	set.UID = types.UID("foo-uid")
+	control := NewTargetControl(xsetController)
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       "default",
+		GenerateName:    "foo-",
+		Labels:          map[string]string{"app": "foo"},
+		OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(set, integration.SampleGroupVersion.WithKind("SampleSet"))},
+	}}
+	created, err := control.CreateTarget(ctx, pod)
+	if err != nil {
+		t.Fatalf("failed to create target: %v", err)
+	}
+	if created.GetName() == "" || created.GetUID() == "" {
+		t.Fatalf("expect name and uid set on created target, got %q and %q", created.GetName(), created.GetUID())
+	}
+
+	filtered, all, err := control.GetFilteredTargets(ctx, set.Spec.Selector, set)
+	if err != nil || len(filtered) != 1 || len(all) != 1 {
+		t.Fatalf("expect 1 target owned, got %d filtered, %d all, err %v", len(filtered), len(all), err)
+	}
+
+	control.InjectError("DeleteTarget", errors.New("injected"))
+	if err := control.DeleteTarget(ctx, created); err == nil {
+		t.Fatal("expect injected error of DeleteTarget")
+	}
+	control.InjectError("DeleteTarget", nil)
+	if err := control.DeleteTarget(ctx, created); err != nil {
+		t.Fatalf("failed to delete target: %v", err)
+	}
+	if len(control.Targets()) != 0 {
+		t.Fatalf("expect no target left, got %d", len(control.Targets()))
+	}
+	if calls := control.CallsOf("DeleteTarget"); len(calls) != 2 {
+		t.Fatalf("expect 2 DeleteTarget calls recorded, got %d", len(calls))
+	}
+}
+
+func TestResourceContextControl(t *testing.T) {
+	ctx := context.Background()
+	xsetController := &integration.SampleXSetController{}
+	set := integration.NewSampleSet("default", "foo", 3, "nginx")
+	control := NewResourceContextControl(xsetController)
+
+	ownedIDs, err := control.AllocateID(ctx, set, "rv1", "rv2", 3, nil)
+	if err != nil || len(ownedIDs) != 3 {
+		t.Fatalf("expect 3 IDs allocated, got %v, err %v", ownedIDs, err)
+	}
+	for id := 0; id < 3; id++ {
+		if !control.Contains(ownedIDs[id], api.EnumRevisionContextDataKey, "rv2") {
+			t.Errorf("expect ID %d allocated with updated revision, got %v", id, ownedIDs[id])
+		}
+	}
+
+	delete(ownedIDs, 2)
+	if err := control.UpdateToTargetContext(ctx, set, ownedIDs); err != nil {
+		t.Fatalf("failed to update context: %v", err)
+	}
+	if owned := control.OwnedIDs(set); len(owned) != 2 {
+		t.Fatalf("expect 2 IDs owned after release, got %v", owned)
+	}
+}
+
+func TestSubresourceControl(t *testing.T) {
+	ctx := context.Background()
+	set := integration.NewSampleSet("default", "foo", 3, "nginx")
+	control := NewSubresourceControl()
+
+	for id := 0; id < 3; id++ {
+		if err := control.CreateTargetSubresources(ctx, set, &api.ContextDetail{ID: id}); err != nil {
+			t.Fatalf("failed to create subresources: %v", err)
+		}
+	}
+	if err := control.DeleteUnusedSubresources(ctx, set, map[int]*api.ContextDetail{0: {ID: 0}, 2: {ID: 2}}); err != nil {
+		t.Fatalf("failed to delete unused subresources: %v", err)
+	}
+	if ids := control.IDs(set); len(ids) != 2 || ids[0] != 0 || ids[1] != 2 {
+		t.Fatalf("expect subresources of ids [0 2] left, got %v", ids)
+	}
+
+	control.InjectError("CreateTargetSubresources", errors.New("injected"))
+	if err := control.CreateTargetSubresources(ctx, set, &api.ContextDetail{ID: 1}); err == nil {
+		t.Fatal("expect injected error of CreateTargetSubresources")
+	}
+	if ids := control.IDs(set); len(ids) != 2 {
+		t.Fatalf("expect failed creation take no effect, got ids %v", ids)
+	}
+}
@@ -0,0 +1,253 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/subresources"
+)
+
+var _ subresources.PvcControl = &PvcControl{}
+
+// PvcControl is an in-memory PvcControl. Pvcs are created from templates of SubResourcePvcAdapter if implemented,
+// and are reused by targets with the same instance ID.
+type PvcControl struct {
+	Recorder
+
+	// RetainWhenXSetDeleted is returned by RetainPvcWhenXSetDeleted
+	RetainWhenXSetDeleted bool
+	// RetainWhenXSetScaled is returned by RetainPvcWhenXSetScaled
+	RetainWhenXSetScaled bool
+	// TemplateChanged is returned by IsTargetPvcTmpChanged
+	TemplateChanged bool
+	// Unbound makes IsTargetPvcsBound return false
+	Unbound bool
+
+	xsetController   api.XSetController
+	xsetLabelAnnoMgr api.XSetLabelAnnotationManager
+
+	mu   sync.RWMutex
+	pvcs map[types.NamespacedName]*corev1.PersistentVolumeClaim
+	seq  int
+}
+
+// NewPvcControl returns PvcControl of pvcs managed by xsetController, which initially stores pvcs
+func NewPvcControl(xsetController api.XSetController, pvcs ...*corev1.PersistentVolumeClaim) *PvcControl {
+	c := &PvcControl{
+		xsetController:   xsetController,
+		xsetLabelAnnoMgr: api.GetXSetLabelAnnotationManager(xsetController),
+		pvcs:             map[types.NamespacedName]*corev1.PersistentVolumeClaim{},
+	}
+	for _, pvc := range pvcs {
+		c.pvcs[client.ObjectKeyFromObject(pvc)] = pvc.DeepCopy()
+	}
+	return c
+}
+
+// Pvcs returns copies of all stored pvcs sorted by namespace and name
+func (c *PvcControl) Pvcs() []*corev1.PersistentVolumeClaim {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.list(func(*corev1.PersistentVolumeClaim) bool { return true })
+}
+
+func (c *PvcControl) GetFilteredPvcs(_ context.Context, xset api.XSetObject) ([]*corev1.PersistentVolumeClaim, error) {
+	if err := c.record("GetFilteredPvcs", xset); err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.list(func(pvc *corev1.PersistentVolumeClaim) bool { return isOwnedBy(pvc, xset) }), nil
+}
+
+func (c *PvcControl) CreateTargetPvcs(_ context.Context, xset api.XSetObject, target client.Object, existingPvcs []*corev1.PersistentVolumeClaim) error {
+	if err := c.record("CreateTargetPvcs", xset, copyObject(target), existingPvcs); err != nil {
+		return err
+	}
+	adapter, enabled := subresources.GetSubresourcePvcAdapter(c.xsetController)
+	id, exist := c.xsetLabelAnnoMgr.Get(target, api.XInstanceIdLabelKey)
+	if !enabled || !exist {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var volumes []corev1.Volume
+	for _, tmp := range adapter.GetXSetPvcTemplate(xset) {
+		pvc := c.findPvc(xset, id, tmp.Name)
+		if pvc == nil {
+			c.seq++
+			pvc = tmp.DeepCopy()
+			pvc.Namespace = xset.GetNamespace()
+			pvc.Name = fmt.Sprintf("%s-%s-%d", xset.GetName(), tmp.Name, c.seq)
+			pvc.ResourceVersion = "1"
+			c.xsetLabelAnnoMgr.Set(pvc, api.XInstanceIdLabelKey, id)
+			c.xsetLabelAnnoMgr.Set(pvc, api.SubResourcePvcTemplateLabelKey, tmp.Name)
+			xsetMeta := c.xsetController.XSetMeta()
+			pvc.OwnerReferences = append(pvc.OwnerReferences, *metav1.NewControllerRef(xset, xsetMeta.GroupVersionKind()))
+			c.pvcs[client.ObjectKeyFromObject(pvc)] = pvc
+		}
+		volumes = append(volumes, corev1.Volume{
+			Name: tmp.Name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.Name},
+			},
+		})
+	}
+	adapter.SetXSpecVolumes(target, append(volumes, adapter.GetXSpecVolumes(target)...))
+	return nil
+}
+
+// DeleteTargetPvcs deletes pvcs with the same instance ID as target
+func (c *PvcControl) DeleteTargetPvcs(_ context.Context, xset api.XSetObject, target client.Object, pvcs []*corev1.PersistentVolumeClaim) error {
+	if err := c.record("DeleteTargetPvcs", xset, copyObject(target), pvcs); err != nil {
+		return err
+	}
+	targetID, _ := c.xsetLabelAnnoMgr.Get(target, api.XInstanceIdLabelKey)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, pvc := range pvcs {
+		if pvcID, _ := c.xsetLabelAnnoMgr.Get(pvc, api.XInstanceIdLabelKey); pvcID == targetID {
+			delete(c.pvcs, client.ObjectKeyFromObject(pvc))
+		}
+	}
+	return nil
+}
+
+func (c *PvcControl) DeleteTargetUnusedPvcs(_ context.Context, xset api.XSetObject, target client.Object, pvcs []*corev1.PersistentVolumeClaim) error {
+	return c.record("DeleteTargetUnusedPvcs", xset, copyObject(target), pvcs)
+}
+
+func (c *PvcControl) OrphanPvc(_ context.Context, xset api.XSetObject, pvc *corev1.PersistentVolumeClaim) error {
+	if err := c.record("OrphanPvc", xset, pvc.DeepCopy()); err != nil {
+		return err
+	}
+	var ownerRefs []metav1.OwnerReference
+	for _, ref := range pvc.OwnerReferences {
+		if ref.UID != xset.GetUID() {
+			ownerRefs = append(ownerRefs, ref)
+		}
+	}
+	pvc.OwnerReferences = ownerRefs
+	return c.store(pvc)
+}
+
+func (c *PvcControl) AdoptPvc(_ context.Context, xset api.XSetObject, pvc *corev1.PersistentVolumeClaim) error {
+	if err := c.record("AdoptPvc", xset, pvc.DeepCopy()); err != nil {
+		return err
+	}
+	if metav1.GetControllerOf(pvc) == nil {
+		xsetMeta := c.xsetController.XSetMeta()
+		pvc.OwnerReferences = append(pvc.OwnerReferences, *metav1.NewControllerRef(xset, xsetMeta.GroupVersionKind()))
+	}
+	return c.store(pvc)
+}
+
+func (c *PvcControl) AdoptPvcsLeftByRetainPolicy(_ context.Context, xset api.XSetObject) ([]*corev1.PersistentVolumeClaim, error) {
+	return nil, c.record("AdoptPvcsLeftByRetainPolicy", xset)
+}
+
+func (c *PvcControl) IsTargetPvcTmpChanged(xset api.XSetObject, target client.Object, pvcs []*corev1.PersistentVolumeClaim) (bool, error) {
+	if err := c.record("IsTargetPvcTmpChanged", xset, copyObject(target), pvcs); err != nil {
+		return false, err
+	}
+	return c.TemplateChanged, nil
+}
+
+func (c *PvcControl) RetainPvcWhenXSetDeleted(_ api.XSetObject) bool {
+	return c.RetainWhenXSetDeleted
+}
+
+func (c *PvcControl) RetainPvcWhenXSetScaled(_ api.XSetObject) bool {
+	return c.RetainWhenXSetScaled
+}
+
+func (c *PvcControl) ExpandPvcs(_ context.Context, xset api.XSetObject, pvcs []*corev1.PersistentVolumeClaim) ([]string, error) {
+	return nil, c.record("ExpandPvcs", xset, pvcs)
+}
+
+func (c *PvcControl) DeleteExpiredPvcSnapshots(_ context.Context, xset api.XSetObject) error {
+	return c.record("DeleteExpiredPvcSnapshots", xset)
+}
+
+func (c *PvcControl) IsTargetPvcsBound(_ context.Context, xset api.XSetObject, target client.Object) (bool, error) {
+	if err := c.record("IsTargetPvcsBound", xset, copyObject(target)); err != nil {
+		return false, err
+	}
+	return !c.Unbound, nil
+}
+
+func (c *PvcControl) ReattachTargetPvcs(_ context.Context, xset api.XSetObject, origin, target client.Object, pvcs []*corev1.PersistentVolumeClaim) error {
+	return c.record("ReattachTargetPvcs", xset, copyObject(origin), copyObject(target), pvcs)
+}
+
+func (c *PvcControl) GetDeletionBlockedPvcs(_ context.Context, xset api.XSetObject) ([]*corev1.PersistentVolumeClaim, error) {
+	return nil, c.record("GetDeletionBlockedPvcs", xset)
+}
+
+// ForgetOwner records call only, since nothing is cached
+func (c *PvcControl) ForgetOwner(namespace, name string) {
+	_ = c.record("ForgetOwner", namespace, name)
+}
+
+// findPvc returns stored pvc owned by xset which is created from template for instance ID
+func (c *PvcControl) findPvc(xset api.XSetObject, id, template string) *corev1.PersistentVolumeClaim {
+	for _, pvc := range c.pvcs {
+		pvcID, _ := c.xsetLabelAnnoMgr.Get(pvc, api.XInstanceIdLabelKey)
+		pvcTemplate, _ := c.xsetLabelAnnoMgr.Get(pvc, api.SubResourcePvcTemplateLabelKey)
+		if isOwnedBy(pvc, xset) && pvcID == id && pvcTemplate == template {
+			return pvc
+		}
+	}
+	return nil
+}
+
+// store replaces the stored pvc with pvc, and bumps resourceVersion of both
+func (c *PvcControl) store(pvc *corev1.PersistentVolumeClaim) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := client.ObjectKeyFromObject(pvc)
+	stored, exist := c.pvcs[key]
+	if !exist {
+		return apierrors.NewNotFound(corev1.Resource("persistentvolumeclaims"), key.Name)
+	}
+	pvc.ResourceVersion = nextResourceVersion(stored.ResourceVersion)
+	c.pvcs[key] = pvc.DeepCopy()
+	return nil
+}
+
+// list returns copies of stored pvcs selected, sorted by namespace and name
+func (c *PvcControl) list(selected func(pvc *corev1.PersistentVolumeClaim) bool) []*corev1.PersistentVolumeClaim {
+	var pvcs []*corev1.PersistentVolumeClaim
+	for _, pvc := range c.pvcs {
+		if selected(pvc) {
+			pvcs = append(pvcs, pvc.DeepCopy())
+		}
+	}
+	sortObjects(pvcs)
+	return pvcs
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package fake provides in-memory implementations of TargetControl, PvcControl, ResourceContextControl and
+// SubresourceControl, which record calls, so that adapters and controllers built on XSetController can be unit tested
+// without a real client.
+package fake
+
+import (
+	"sync"
+)
+
+// Call is a call recorded by fake controls
+type Call struct {
+	// Method is the name of method called, e.g., CreateTarget
+	Method string
+	// Args are arguments of the call except context
+	Args []interface{}
+}
+
+// Recorder records calls of fake controls, and returns errors injected by method
+type Recorder struct {
+	mu     sync.Mutex
+	calls  []Call
+	errors map[string]error
+}
+
+// Calls returns all calls recorded in order
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsOf returns calls of method recorded in order
+func (r *Recorder) CallsOf(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []Call
+	for _, call := range r.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset clears calls recorded and errors injected
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+	r.errors = nil
+}
+
+// InjectError makes calls of method return err without taking effect, nil err clears it
+func (r *Recorder) InjectError(method string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.errors == nil {
+		r.errors = map[string]error{}
+	}
+	if err == nil {
+		delete(r.errors, method)
+		return
+	}
+	r.errors[method] = err
+}
+
+// record records call of method, and returns error injected for it
+func (r *Recorder) record(method string, args ...interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+	return r.errors[method]
+}
@@ -0,0 +1,300 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fake
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/resourcecontexts"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+var _ resourcecontexts.ResourceContextControl = &ResourceContextControl{}
+
+// ResourceContextControl is an in-memory ResourceContextControl. IDs are kept by context named after xset, or
+// spec.scaleStrategy.context if set, and are owned by xsets recorded in context data as the real one does.
+type ResourceContextControl struct {
+	Recorder
+
+	xsetController   api.XSetController
+	xsetLabelAnnoMgr api.XSetLabelAnnotationManager
+	keys             map[api.ResourceContextKeyEnum]string
+
+	mu       sync.Mutex
+	contexts map[types.NamespacedName]map[int]*api.ContextDetail
+}
+
+// NewResourceContextControl returns ResourceContextControl of xsets managed by xsetController
+func NewResourceContextControl(xsetController api.XSetController) *ResourceContextControl {
+	keys := resourcecontexts.GetResourceContextAdapter(xsetController).GetContextKeys()
+	defaultKeys := (&resourcecontexts.DefaultResourceContextAdapter{}).GetContextKeys()
+	if keys == nil {
+		keys = defaultKeys
+	} else {
+		keys = maps.Clone(keys)
+		for enum, key := range defaultKeys {
+			if _, ok := keys[enum]; !ok && int(enum) >= api.EnumContextKeyNum {
+				keys[enum] = key
+			}
+		}
+	}
+	return &ResourceContextControl{
+		xsetController:   xsetController,
+		xsetLabelAnnoMgr: api.GetXSetLabelAnnotationManager(xsetController),
+		keys:             keys,
+		contexts:         map[types.NamespacedName]map[int]*api.ContextDetail{},
+	}
+}
+
+// OwnedIDs returns copies of contexts owned by xset
+func (c *ResourceContextControl) OwnedIDs(xset api.XSetObject) map[int]*api.ContextDetail {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return copyContexts(c.ownedIDs(xset))
+}
+
+func (c *ResourceContextControl) AllocateID(_ context.Context, xset api.XSetObject, currentRevision, updatedRevision string, replicas int, objs []client.Object) (map[int]*api.ContextDetail, error) {
+	if err := c.record("AllocateID", xset, currentRevision, updatedRevision, replicas, objs); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	contexts := c.getContexts(xset)
+	owned := c.ownedIDs(xset)
+	// record IDs used by targets but not owned
+	for _, obj := range objs {
+		id, err := xcontrol.GetInstanceID(c.xsetLabelAnnoMgr, obj)
+		if err != nil || id < 0 {
+			continue
+		}
+		if _, exist := owned[id]; exist {
+			continue
+		}
+		revision := obj.GetLabels()[appsv1.ControllerRevisionHashLabelKey]
+		if revision == "" {
+			revision = currentRevision
+		}
+		detail := c.newContextDetail(id, xset.GetName(), revision)
+		contexts[id], owned[id] = detail, detail
+	}
+	// allocate lowest free IDs up to replicas
+	for id := 0; len(owned) < replicas; id++ {
+		if _, exist := contexts[id]; exist {
+			continue
+		}
+		detail := c.newContextDetail(id, xset.GetName(), updatedRevision)
+		c.Put(detail, api.EnumJustCreateContextDataKey, "true")
+		contexts[id], owned[id] = detail, detail
+	}
+	return copyContexts(owned), nil
+}
+
+func (c *ResourceContextControl) CleanUnusedIDs(_ context.Context, xset api.XSetObject, objs []client.Object, dryRun bool) ([]int, error) {
+	if err := c.record("CleanUnusedIDs", xset, objs, dryRun); err != nil {
+		return nil, err
+	}
+	used := sets.NewInt()
+	for _, obj := range objs {
+		if id, err := xcontrol.GetInstanceID(c.xsetLabelAnnoMgr, obj); err == nil {
+			used.Insert(id)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	contexts := c.getContexts(xset)
+	var unused []int
+	for id := range c.ownedIDs(xset) {
+		if used.Has(id) {
+			continue
+		}
+		unused = append(unused, id)
+		if !dryRun {
+			delete(contexts, id)
+		}
+	}
+	sort.Ints(unused)
+	return unused, nil
+}
+
+// UpdateToTargetContext replaces IDs owned by xset with ownedIDs, and nil ownedIDs releases all of them
+func (c *ResourceContextControl) UpdateToTargetContext(_ context.Context, xset api.XSetObject, ownedIDs map[int]*api.ContextDetail) error {
+	if err := c.record("UpdateToTargetContext", xset, copyContexts(ownedIDs)); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	contexts := c.getContexts(xset)
+	for id := range c.ownedIDs(xset) {
+		delete(contexts, id)
+	}
+	for id, detail := range copyContexts(ownedIDs) {
+		contexts[id] = detail
+	}
+	return nil
+}
+
+func (c *ResourceContextControl) ReleaseDeletedOwnerIDs(_ context.Context, namespace, name string) error {
+	if err := c.record("ReleaseDeletedOwnerIDs", namespace, name); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	contexts := c.contexts[types.NamespacedName{Namespace: namespace, Name: name}]
+	for id, detail := range contexts {
+		if c.Contains(detail, api.EnumOwnerContextKey, name) {
+			delete(contexts, id)
+		}
+	}
+	return nil
+}
+
+// ReleaseOrphanedIDs records call only, since whether owners exist is unknown in memory
+func (c *ResourceContextControl) ReleaseOrphanedIDs(_ context.Context, namespace string) error {
+	return c.record("ReleaseOrphanedIDs", namespace)
+}
+
+func (c *ResourceContextControl) GetCoOwnedIDs(_ context.Context, xset api.XSetObject) (sets.Int, error) {
+	if err := c.record("GetCoOwnedIDs", xset); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	coOwnedIDs := sets.Int{}
+	for id, detail := range c.getContexts(xset) {
+		if !c.Contains(detail, api.EnumOwnerContextKey, xset.GetName()) {
+			coOwnedIDs.Insert(id)
+		}
+	}
+	return coOwnedIDs, nil
+}
+
+func (c *ResourceContextControl) ExtractAvailableContexts(diff int, ownedIDs map[int]*api.ContextDetail, targetInstanceIDSet sets.Int) []*api.ContextDetail {
+	var availableContexts []*api.ContextDetail
+	ids := make([]int, 0, len(ownedIDs))
+	for id := range ownedIDs {
+		if !targetInstanceIDSet.Has(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		if len(availableContexts) == diff {
+			break
+		}
+		availableContexts = append(availableContexts, ownedIDs[id])
+	}
+	return availableContexts
+}
+
+func (c *ResourceContextControl) DecideContextRevisionAfterCreate(contextDetail *api.ContextDetail, updatedRevision *appsv1.ControllerRevision, createErr error) bool {
+	if !resourcecontexts.UnrecoverableCreateError(createErr) {
+		c.Remove(contextDetail, api.EnumJustCreateContextDataKey)
+		c.Remove(contextDetail, api.EnumRecreateUpdateContextDataKey)
+		return true
+	}
+	if c.Contains(contextDetail, api.EnumJustCreateContextDataKey, "true") ||
+		c.Contains(contextDetail, api.EnumRecreateUpdateContextDataKey, "true") {
+		c.Put(contextDetail, api.EnumRevisionContextDataKey, updatedRevision.GetName())
+		c.Remove(contextDetail, api.EnumTargetDecorationRevisionKey)
+		return true
+	}
+	return false
+}
+
+func (c *ResourceContextControl) Get(detail *api.ContextDetail, enum api.ResourceContextKeyEnum) (string, bool) {
+	return detail.Get(c.keys[enum])
+}
+
+func (c *ResourceContextControl) Contains(detail *api.ContextDetail, enum api.ResourceContextKeyEnum, value string) bool {
+	return detail.Contains(c.keys[enum], value)
+}
+
+func (c *ResourceContextControl) Put(detail *api.ContextDetail, enum api.ResourceContextKeyEnum, value string) {
+	detail.Put(c.keys[enum], value)
+}
+
+func (c *ResourceContextControl) Remove(detail *api.ContextDetail, enum api.ResourceContextKeyEnum) {
+	detail.Remove(c.keys[enum])
+}
+
+// ProjectToTarget records call only, since projection is up to ResourceContextProjectionAdapter
+func (c *ResourceContextControl) ProjectToTarget(detail *api.ContextDetail, target client.Object) {
+	_ = c.record("ProjectToTarget", detail, target)
+}
+
+// FlushPendingWrites records call only, since writes are never deferred
+func (c *ResourceContextControl) FlushPendingWrites(_ context.Context, xset api.XSetObject) (*time.Duration, error) {
+	return nil, c.record("FlushPendingWrites", xset)
+}
+
+// ForgetOwner records call only, since writes are never deferred
+func (c *ResourceContextControl) ForgetOwner(namespace, name string) {
+	_ = c.record("ForgetOwner", namespace, name)
+}
+
+func (c *ResourceContextControl) newContextDetail(id int, owner, revision string) *api.ContextDetail {
+	detail := &api.ContextDetail{ID: id}
+	c.Put(detail, api.EnumOwnerContextKey, owner)
+	c.Put(detail, api.EnumRevisionContextDataKey, revision)
+	return detail
+}
+
+// getContexts returns contexts used by xset, which are created if not found
+func (c *ResourceContextControl) getContexts(xset api.XSetObject) map[int]*api.ContextDetail {
+	key := types.NamespacedName{Namespace: xset.GetNamespace(), Name: xset.GetName()}
+	if spec := c.xsetController.GetXSetSpec(xset); spec != nil && spec.ScaleStrategy.Context != "" {
+		key.Name = spec.ScaleStrategy.Context
+	}
+	if _, exist := c.contexts[key]; !exist {
+		c.contexts[key] = map[int]*api.ContextDetail{}
+	}
+	return c.contexts[key]
+}
+
+func (c *ResourceContextControl) ownedIDs(xset api.XSetObject) map[int]*api.ContextDetail {
+	owned := map[int]*api.ContextDetail{}
+	for id, detail := range c.getContexts(xset) {
+		if c.Contains(detail, api.EnumOwnerContextKey, xset.GetName()) {
+			owned[id] = detail
+		}
+	}
+	return owned
+}
+
+func copyContexts(contexts map[int]*api.ContextDetail) map[int]*api.ContextDetail {
+	if contexts == nil {
+		return nil
+	}
+	copied := make(map[int]*api.ContextDetail, len(contexts))
+	for id, detail := range contexts {
+		copied[id] = &api.ContextDetail{ID: detail.ID, Data: maps.Clone(detail.Data)}
+	}
+	return copied
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fake
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/subresources"
+)
+
+var _ subresources.SubresourceControl = &SubresourceControl{}
+
+// SubresourceControl is an in-memory SubresourceControl, which keeps the instance IDs subresources are created for
+// by xset.
+type SubresourceControl struct {
+	Recorder
+
+	mu  sync.RWMutex
+	ids map[types.NamespacedName]map[int]bool
+}
+
+// NewSubresourceControl returns SubresourceControl without any subresource
+func NewSubresourceControl() *SubresourceControl {
+	return &SubresourceControl{ids: map[types.NamespacedName]map[int]bool{}}
+}
+
+// IDs returns instance IDs of xset with subresources in ascending order
+func (c *SubresourceControl) IDs(xset api.XSetObject) []int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var ids []int
+	for id := range c.ids[types.NamespacedName{Namespace: xset.GetNamespace(), Name: xset.GetName()}] {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func (c *SubresourceControl) CreateTargetSubresources(_ context.Context, xset api.XSetObject, contextDetail *api.ContextDetail) error {
+	if err := c.record("CreateTargetSubresources", xset, contextDetail); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := types.NamespacedName{Namespace: xset.GetNamespace(), Name: xset.GetName()}
+	if c.ids[key] == nil {
+		c.ids[key] = map[int]bool{}
+	}
+	c.ids[key][contextDetail.ID] = true
+	return nil
+}
+
+func (c *SubresourceControl) AdoptSubresources(_ context.Context, xset api.XSetObject) error {
+	return c.record("AdoptSubresources", xset)
+}
+
+// DeleteUnusedSubresources deletes subresources of instance IDs not in ownedIDs
+func (c *SubresourceControl) DeleteUnusedSubresources(_ context.Context, xset api.XSetObject, ownedIDs map[int]*api.ContextDetail) error {
+	if err := c.record("DeleteUnusedSubresources", xset, ownedIDs); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := types.NamespacedName{Namespace: xset.GetNamespace(), Name: xset.GetName()}
+	for id := range c.ids[key] {
+		if _, owned := ownedIDs[id]; !owned {
+			delete(c.ids[key], id)
+		}
+	}
+	return nil
+}
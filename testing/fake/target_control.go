@@ -0,0 +1,275 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+var _ xcontrol.TargetControl = &TargetControl{}
+
+// TargetControl is an in-memory TargetControl. Targets are owned by xset with controller ownerReference, as done by
+// the real one, and objects passed in and returned are copies of the stored ones.
+type TargetControl struct {
+	Recorder
+
+	xsetController   api.XSetController
+	xsetLabelAnnoMgr api.XSetLabelAnnotationManager
+	groupResource    schema.GroupResource
+
+	mu      sync.RWMutex
+	targets map[types.NamespacedName]client.Object
+	seq     int
+}
+
+// NewTargetControl returns TargetControl of targets managed by xsetController, which initially stores targets
+func NewTargetControl(xsetController api.XSetController, targets ...client.Object) *TargetControl {
+	xMeta := xsetController.XMeta()
+	gvk := xMeta.GroupVersionKind()
+	c := &TargetControl{
+		xsetController:   xsetController,
+		xsetLabelAnnoMgr: api.GetXSetLabelAnnotationManager(xsetController),
+		groupResource:    schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind},
+		targets:          map[types.NamespacedName]client.Object{},
+	}
+	for _, target := range targets {
+		c.targets[client.ObjectKeyFromObject(target)] = copyObject(target)
+	}
+	return c
+}
+
+// Targets returns copies of all stored targets sorted by namespace and name
+func (c *TargetControl) Targets() []client.Object {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.list(func(client.Object) bool { return true })
+}
+
+// GetTarget returns copy of target stored with key
+func (c *TargetControl) GetTarget(key types.NamespacedName) (client.Object, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	target, ok := c.targets[key]
+	if !ok {
+		return nil, false
+	}
+	return copyObject(target), true
+}
+
+func (c *TargetControl) GetFilteredTargets(_ context.Context, selector *metav1.LabelSelector, owner api.XSetObject) ([]client.Object, []client.Object, error) {
+	if err := c.record("GetFilteredTargets", selector, owner); err != nil {
+		return nil, nil, err
+	}
+	matcher, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fail to create labelSelector matcher: %w", err)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	allTargets := c.list(func(target client.Object) bool {
+		return isOwnedBy(target, owner) && matcher.Matches(labels.Set(target.GetLabels()))
+	})
+	var filteredTargets []client.Object
+	for _, target := range allTargets {
+		if !c.xsetController.CheckInactive(target) {
+			filteredTargets = append(filteredTargets, target)
+		}
+	}
+	return filteredTargets, allTargets, nil
+}
+
+func (c *TargetControl) CreateTarget(_ context.Context, target client.Object) (client.Object, error) {
+	if err := c.record("CreateTarget", copyObject(target)); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	created := copyObject(target)
+	if created.GetName() == "" {
+		created.SetName(fmt.Sprintf("%s%d", created.GetGenerateName(), c.seq))
+	}
+	key := client.ObjectKeyFromObject(created)
+	if _, exist := c.targets[key]; exist {
+		return nil, apierrors.NewAlreadyExists(c.groupResource, key.Name)
+	}
+	created.SetUID(types.UID(fmt.Sprintf("fake-uid-%d", c.seq)))
+	created.SetResourceVersion("1")
+	created.SetCreationTimestamp(metav1.Now())
+	c.targets[key] = created
+	return copyObject(created), nil
+}
+
+func (c *TargetControl) DryRunCreateTarget(_ context.Context, target client.Object) error {
+	return c.record("DryRunCreateTarget", copyObject(target))
+}
+
+func (c *TargetControl) DeleteTarget(_ context.Context, target client.Object, opts ...client.DeleteOption) error {
+	if err := c.record("DeleteTarget", copyObject(target), opts); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := client.ObjectKeyFromObject(target)
+	if _, exist := c.targets[key]; !exist {
+		return apierrors.NewNotFound(c.groupResource, key.Name)
+	}
+	delete(c.targets, key)
+	return nil
+}
+
+func (c *TargetControl) UpdateTarget(_ context.Context, target client.Object) error {
+	if err := c.record("UpdateTarget", copyObject(target)); err != nil {
+		return err
+	}
+	return c.store(target)
+}
+
+// PatchTarget stores target as it is, since patches are computed from targets mutated by callers
+func (c *TargetControl) PatchTarget(_ context.Context, target client.Object, patch client.Patch) error {
+	if err := c.record("PatchTarget", copyObject(target), patch); err != nil {
+		return err
+	}
+	return c.store(target)
+}
+
+func (c *TargetControl) PatchTargetWithOptimisticLock(_ context.Context, target client.Object, mutateFn func(target client.Object)) error {
+	if err := c.record("PatchTargetWithOptimisticLock", copyObject(target)); err != nil {
+		return err
+	}
+	mutateFn(target)
+	return c.store(target)
+}
+
+func (c *TargetControl) OrphanTarget(_ context.Context, xset api.XSetObject, target client.Object) error {
+	if err := c.record("OrphanTarget", xset, copyObject(target)); err != nil {
+		return err
+	}
+	var ownerRefs []metav1.OwnerReference
+	for _, ref := range target.GetOwnerReferences() {
+		if ref.UID != xset.GetUID() {
+			ownerRefs = append(ownerRefs, ref)
+		}
+	}
+	target.SetOwnerReferences(ownerRefs)
+	return c.store(target)
+}
+
+func (c *TargetControl) AdoptTarget(_ context.Context, xset api.XSetObject, target client.Object) error {
+	if err := c.record("AdoptTarget", xset, copyObject(target)); err != nil {
+		return err
+	}
+	if metav1.GetControllerOf(target) == nil {
+		xsetMeta := c.xsetController.XSetMeta()
+		ownerRef := metav1.NewControllerRef(xset, xsetMeta.GroupVersionKind())
+		target.SetOwnerReferences(append(target.GetOwnerReferences(), *ownerRef))
+	}
+	return c.store(target)
+}
+
+func (c *TargetControl) GetTargetsByInstanceID(_ context.Context, xset api.XSetObject, id int) ([]client.Object, error) {
+	if err := c.record("GetTargetsByInstanceID", xset, id); err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.list(func(target client.Object) bool {
+		value, _ := c.xsetLabelAnnoMgr.Get(target, api.XInstanceIdLabelKey)
+		return isOwnedBy(target, xset) && value == strconv.Itoa(id)
+	}), nil
+}
+
+func (c *TargetControl) VisitTargets(_ context.Context, owner api.XSetObject, visitor func(target client.Object) error) error {
+	if err := c.record("VisitTargets", owner); err != nil {
+		return err
+	}
+	c.mu.RLock()
+	targets := c.list(func(target client.Object) bool { return isOwnedBy(target, owner) })
+	c.mu.RUnlock()
+	for _, target := range targets {
+		if err := visitor(target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// store replaces the stored target with target, and bumps resourceVersion of both
+func (c *TargetControl) store(target client.Object) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := client.ObjectKeyFromObject(target)
+	stored, exist := c.targets[key]
+	if !exist {
+		return apierrors.NewNotFound(c.groupResource, key.Name)
+	}
+	target.SetResourceVersion(nextResourceVersion(stored.GetResourceVersion()))
+	c.targets[key] = copyObject(target)
+	return nil
+}
+
+// list returns copies of stored targets selected, sorted by namespace and name
+func (c *TargetControl) list(selected func(target client.Object) bool) []client.Object {
+	var targets []client.Object
+	for _, target := range c.targets {
+		if selected(target) {
+			targets = append(targets, copyObject(target))
+		}
+	}
+	sortObjects(targets)
+	return targets
+}
+
+func isOwnedBy(obj client.Object, owner api.XSetObject) bool {
+	ownerRef := metav1.GetControllerOf(obj)
+	return ownerRef != nil && ownerRef.UID == owner.GetUID()
+}
+
+func copyObject[T client.Object](obj T) T {
+	return obj.DeepCopyObject().(T)
+}
+
+func nextResourceVersion(resourceVersion string) string {
+	rv, _ := strconv.ParseInt(resourceVersion, 10, 64)
+	return strconv.FormatInt(rv+1, 10)
+}
+
+func sortObjects[T client.Object](objs []T) {
+	sort.Slice(objs, func(i, j int) bool {
+		if objs[i].GetNamespace() != objs[j].GetNamespace() {
+			return objs[i].GetNamespace() < objs[j].GetNamespace()
+		}
+		return objs[i].GetName() < objs[j].GetName()
+	})
+}
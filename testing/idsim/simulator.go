@@ -0,0 +1,306 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package idsim simulates reconciles of xsets allocating instance IDs from ResourceContext with random operation
+// sequences, and checks invariants of ID allocation after every step, so that ResourceContextControl and adapters
+// customizing it can be covered by property-based and fuzz tests.
+package idsim
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// Allocator is the part of ResourceContextControl driven by Simulator
+type Allocator interface {
+	AllocateID(ctx context.Context, xsetObject api.XSetObject, currentRevision, updatedRevision string, replicas int, objs []client.Object) (map[int]*api.ContextDetail, error)
+	CleanUnusedIDs(ctx context.Context, xsetObject api.XSetObject, objs []client.Object, dryRun bool) ([]int, error)
+	UpdateToTargetContext(ctx context.Context, xsetObject api.XSetObject, ownedIDs map[int]*api.ContextDetail) error
+	ExtractAvailableContexts(diff int, ownedIDs map[int]*api.ContextDetail, targetInstanceIDSet sets.Int) []*api.ContextDetail
+}
+
+// OpType is the type of operation applied by Simulator
+type OpType int
+
+const (
+	// OpSync reconciles xset once: cleans unused IDs, allocates IDs, and creates or deletes targets to replicas
+	OpSync OpType = iota
+	// OpScale sets replicas of xset
+	OpScale
+	// OpKillTarget deletes a target of xset out of band, e.g., evicted, whose ID is still owned by xset
+	OpKillTarget
+)
+
+// Op is an operation applied by Simulator on the xset indexed by XSet
+type Op struct {
+	Type OpType
+	XSet int
+	// Replicas is the replicas set by OpScale
+	Replicas int
+	// Target selects the target killed by OpKillTarget, modulo the number of live targets
+	Target int
+}
+
+func (o Op) String() string {
+	switch o.Type {
+	case OpScale:
+		return fmt.Sprintf("scale(%d, %d)", o.XSet, o.Replicas)
+	case OpKillTarget:
+		return fmt.Sprintf("kill(%d, %d)", o.XSet, o.Target)
+	default:
+		return fmt.Sprintf("sync(%d)", o.XSet)
+	}
+}
+
+// Simulator keeps live targets of xsets in memory, and drives Allocator by operations on them. Replicas of xsets are
+// set to spec returned by GetXSetSpec, which is expected to be read by Allocator.
+type Simulator struct {
+	allocator        Allocator
+	xsetController   api.XSetController
+	xsetLabelAnnoMgr api.XSetLabelAnnotationManager
+	xsets            []api.XSetObject
+	maxReplicas      int
+	revision         string
+
+	targets  [][]client.Object
+	ownedIDs []map[int]*api.ContextDetail
+	seq      int
+}
+
+// NewSimulator returns Simulator of xsets managed by xsetController, whose replicas are scaled up to maxReplicas
+func NewSimulator(allocator Allocator, xsetController api.XSetController, maxReplicas int, xsets ...api.XSetObject) *Simulator {
+	return &Simulator{
+		allocator:        allocator,
+		xsetController:   xsetController,
+		xsetLabelAnnoMgr: api.GetXSetLabelAnnotationManager(xsetController),
+		xsets:            xsets,
+		maxReplicas:      maxReplicas,
+		revision:         "sim-revision",
+		targets:          make([][]client.Object, len(xsets)),
+		ownedIDs:         make([]map[int]*api.ContextDetail, len(xsets)),
+	}
+}
+
+// RandomOps returns n random operations
+func (s *Simulator) RandomOps(rnd *rand.Rand, n int) []Op {
+	ops := make([]Op, 0, n)
+	for i := 0; i < n; i++ {
+		op := Op{XSet: rnd.Intn(len(s.xsets))}
+		switch p := rnd.Intn(10); {
+		case p < 3:
+			op.Type, op.Replicas = OpScale, rnd.Intn(s.maxReplicas+1)
+		case p < 5:
+			op.Type, op.Target = OpKillTarget, rnd.Int()
+		default:
+			op.Type = OpSync
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// Run applies ops and checks invariants after each of them, then syncs all xsets until converged
+func (s *Simulator) Run(ctx context.Context, ops []Op) error {
+	for i, op := range ops {
+		if err := s.Apply(ctx, op); err != nil {
+			return fmt.Errorf("step %d %s: %w", i, op, err)
+		}
+		if err := s.CheckInvariants(); err != nil {
+			return fmt.Errorf("step %d %s: %w", i, op, err)
+		}
+	}
+	return s.Converge(ctx)
+}
+
+// Apply applies op
+func (s *Simulator) Apply(ctx context.Context, op Op) error {
+	switch op.Type {
+	case OpScale:
+		s.xsetController.GetXSetSpec(s.xsets[op.XSet]).Replicas = ptr.To(int32(op.Replicas))
+	case OpKillTarget:
+		if targets := s.targets[op.XSet]; len(targets) > 0 {
+			idx := op.Target % len(targets)
+			s.targets[op.XSet] = append(targets[:idx:idx], targets[idx+1:]...)
+		}
+	default:
+		return s.Sync(ctx, op.XSet)
+	}
+	return nil
+}
+
+// Sync reconciles xset indexed by i as SyncTargets and Scale do
+func (s *Simulator) Sync(ctx context.Context, i int) error {
+	xset := s.xsets[i]
+	replicas := s.replicas(i)
+	if _, err := s.allocator.CleanUnusedIDs(ctx, xset, s.targets[i], false); err != nil {
+		return fmt.Errorf("fail to clean unused IDs: %w", err)
+	}
+	ownedIDs, err := s.allocator.AllocateID(ctx, xset, s.revision, s.revision, replicas, s.targets[i])
+	if err != nil {
+		return fmt.Errorf("fail to allocate IDs: %w", err)
+	}
+	s.ownedIDs[i] = ownedIDs
+
+	liveIDs := s.liveIDs(i)
+	for id := range liveIDs {
+		if _, owned := ownedIDs[id]; !owned {
+			return fmt.Errorf("ID %d of live target is not owned by %s", id, xset.GetName())
+		}
+	}
+
+	diff := replicas - len(s.targets[i])
+	if diff > 0 {
+		poolIDs := s.poolLiveIDs(i)
+		for _, detail := range s.allocator.ExtractAvailableContexts(diff, ownedIDs, liveIDs) {
+			if poolIDs.Has(detail.ID) {
+				return fmt.Errorf("ID %d is extracted for %s while held by a live target", detail.ID, xset.GetName())
+			}
+			s.targets[i] = append(s.targets[i], s.newTarget(xset, detail.ID))
+			poolIDs.Insert(detail.ID)
+		}
+		return nil
+	}
+	if diff < 0 {
+		// scale in targets with larger IDs, and release their IDs
+		sort.Slice(s.targets[i], func(l, r int) bool { return s.getID(s.targets[i][l]) < s.getID(s.targets[i][r]) })
+		deleted := s.targets[i][replicas:]
+		s.targets[i] = s.targets[i][:replicas]
+		released := make(map[int]*api.ContextDetail, len(ownedIDs))
+		for id, detail := range ownedIDs {
+			released[id] = detail
+		}
+		for _, target := range deleted {
+			delete(released, s.getID(target))
+		}
+		if err := s.allocator.UpdateToTargetContext(ctx, xset, released); err != nil {
+			return fmt.Errorf("fail to release IDs: %w", err)
+		}
+		s.ownedIDs[i] = released
+	}
+	return nil
+}
+
+// Converge syncs all xsets until targets and owned IDs of each xset are exactly its replicas
+func (s *Simulator) Converge(ctx context.Context) error {
+	// the first round releases and allocates IDs, and the second one creates targets on IDs released by others
+	for round := 0; round < 3; round++ {
+		for i := range s.xsets {
+			if err := s.Sync(ctx, i); err != nil {
+				return fmt.Errorf("converge round %d of %s: %w", round, s.xsets[i].GetName(), err)
+			}
+			if err := s.CheckInvariants(); err != nil {
+				return fmt.Errorf("converge round %d of %s: %w", round, s.xsets[i].GetName(), err)
+			}
+		}
+	}
+	for i, xset := range s.xsets {
+		replicas := s.replicas(i)
+		if len(s.targets[i]) != replicas || len(s.ownedIDs[i]) != replicas {
+			return fmt.Errorf("%s is not converged to %d replicas: %d targets, %d IDs owned", xset.GetName(), replicas, len(s.targets[i]), len(s.ownedIDs[i]))
+		}
+	}
+	return nil
+}
+
+// CheckInvariants checks that neither live targets nor owners of IDs last allocated share IDs within a pool
+func (s *Simulator) CheckInvariants() error {
+	liveOwners := map[string]map[int]string{}
+	idOwners := map[string]map[int]string{}
+	for i, xset := range s.xsets {
+		pool := s.pool(i)
+		if liveOwners[pool] == nil {
+			liveOwners[pool], idOwners[pool] = map[int]string{}, map[int]string{}
+		}
+		for _, target := range s.targets[i] {
+			id := s.getID(target)
+			if owner, exist := liveOwners[pool][id]; exist {
+				return fmt.Errorf("ID %d is held by live targets of both %s and %s", id, owner, xset.GetName())
+			}
+			liveOwners[pool][id] = xset.GetName()
+		}
+		for id, detail := range s.ownedIDs[i] {
+			if detail.ID != id {
+				return fmt.Errorf("ID %d owned by %s is recorded as %d", id, xset.GetName(), detail.ID)
+			}
+			if owner, exist := idOwners[pool][id]; exist {
+				return fmt.Errorf("ID %d is owned by both %s and %s", id, owner, xset.GetName())
+			}
+			idOwners[pool][id] = xset.GetName()
+		}
+	}
+	return nil
+}
+
+// Targets returns live targets of xset indexed by i
+func (s *Simulator) Targets(i int) []client.Object {
+	return append([]client.Object(nil), s.targets[i]...)
+}
+
+func (s *Simulator) newTarget(xset api.XSetObject, id int) client.Object {
+	s.seq++
+	target := s.xsetController.NewXObject()
+	target.SetNamespace(xset.GetNamespace())
+	target.SetName(fmt.Sprintf("%s-%d", xset.GetName(), s.seq))
+	s.xsetLabelAnnoMgr.Set(target, api.XInstanceIdLabelKey, strconv.Itoa(id))
+	return target
+}
+
+func (s *Simulator) getID(target client.Object) int {
+	value, _ := s.xsetLabelAnnoMgr.Get(target, api.XInstanceIdLabelKey)
+	id, _ := strconv.Atoi(value)
+	return id
+}
+
+func (s *Simulator) liveIDs(i int) sets.Int {
+	ids := sets.NewInt()
+	for _, target := range s.targets[i] {
+		ids.Insert(s.getID(target))
+	}
+	return ids
+}
+
+// poolLiveIDs returns IDs held by live targets of xsets sharing pool with xset indexed by i
+func (s *Simulator) poolLiveIDs(i int) sets.Int {
+	ids := sets.NewInt()
+	for j := range s.xsets {
+		if s.pool(j) == s.pool(i) {
+			ids = ids.Union(s.liveIDs(j))
+		}
+	}
+	return ids
+}
+
+// pool returns the ResourceContext xset allocates IDs from
+func (s *Simulator) pool(i int) string {
+	xset := s.xsets[i]
+	if contextName := s.xsetController.GetXSetSpec(xset).ScaleStrategy.Context; contextName != "" {
+		return xset.GetNamespace() + "/" + contextName
+	}
+	return xset.GetNamespace() + "/" + xset.GetName()
+}
+
+func (s *Simulator) replicas(i int) int {
+	return int(ptr.Deref(s.xsetController.GetXSetSpec(s.xsets[i]).Replicas, 0))
+}
@@ -0,0 +1,267 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package integration runs XSetController on envtest, so that adapters and reconciler paths can be covered by
+// integration tests, e.g.,
+//
+//	env, err := integration.Start(integration.Options{})
+//	defer env.Stop()
+//	set := integration.NewSampleSet("default", "foo", 3, "nginx")
+//	err = env.CreateXSet(ctx, set)
+//	err = env.WaitForReplicas(ctx, set, 3)
+//
+// Binaries of kube-apiserver and etcd are located by KUBEBUILDER_ASSETS as required by envtest.
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+	appsv1alpha1 "kusionstack.io/kube-api/apps/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	xset "kusionstack.io/kube-xset"
+	"kusionstack.io/kube-xset/api"
+)
+
+const (
+	// DefaultPollInterval is the default interval to poll objects when waiting
+	DefaultPollInterval = 200 * time.Millisecond
+	// DefaultTimeout is the default timeout of waiting
+	DefaultTimeout = 30 * time.Second
+)
+
+// Options are options to start Env
+type Options struct {
+	// XSetController is the controller to run, defaults to SampleXSetController
+	XSetController api.XSetController
+	// CRDs are installed besides SampleCRDs, e.g., CRDs of XSet and targets of XSetController
+	CRDs []apiextensionsv1.CustomResourceDefinition
+	// CRDDirectoryPaths are paths of directories containing CRD files to install
+	CRDDirectoryPaths []string
+	// SchemeBuilders add types of XSetController to scheme besides built-in types and SampleSet
+	SchemeBuilders []func(*runtime.Scheme) error
+	// PollInterval is the interval to poll objects when waiting, defaults to DefaultPollInterval
+	PollInterval time.Duration
+	// Timeout is the timeout of waiting, defaults to DefaultTimeout
+	Timeout time.Duration
+}
+
+// Env is an envtest environment running XSetController by manager
+type Env struct {
+	Environment    *envtest.Environment
+	Config         *rest.Config
+	Manager        ctrl.Manager
+	XSetController api.XSetController
+	// Client reads from API server directly, not from cache of manager
+	Client client.Client
+
+	pollInterval time.Duration
+	timeout      time.Duration
+	cancel       context.CancelFunc
+	done         chan error
+}
+
+// Start starts envtest and manager running XSetController
+func Start(opts Options) (*Env, error) {
+	if opts.XSetController == nil {
+		opts.XSetController = &SampleXSetController{}
+	}
+	env := &Env{
+		Environment: &envtest.Environment{
+			CRDs:                  append(SampleCRDs(), opts.CRDs...),
+			CRDDirectoryPaths:     opts.CRDDirectoryPaths,
+			ErrorIfCRDPathMissing: len(opts.CRDDirectoryPaths) > 0,
+		},
+		XSetController: opts.XSetController,
+		pollInterval:   opts.PollInterval,
+		timeout:        opts.Timeout,
+		done:           make(chan error, 1),
+	}
+	if env.pollInterval <= 0 {
+		env.pollInterval = DefaultPollInterval
+	}
+	if env.timeout <= 0 {
+		env.timeout = DefaultTimeout
+	}
+
+	var err error
+	if env.Config, err = env.Environment.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start envtest: %w", err)
+	}
+	if err := env.startManager(opts.SchemeBuilders); err != nil {
+		return nil, errors.Join(err, env.Environment.Stop())
+	}
+	return env, nil
+}
+
+func (e *Env) startManager(schemeBuilders []func(*runtime.Scheme) error) error {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range append([]func(*runtime.Scheme) error{clientgoscheme.AddToScheme, appsv1alpha1.AddToScheme, AddToScheme}, schemeBuilders...) {
+		if err := addToScheme(scheme); err != nil {
+			return fmt.Errorf("failed to build scheme: %w", err)
+		}
+	}
+
+	var err error
+	if e.Client, err = client.New(e.Config, client.Options{Scheme: scheme}); err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	if e.Manager, err = ctrl.NewManager(e.Config, ctrl.Options{Scheme: scheme, MetricsBindAddress: "0"}); err != nil {
+		return fmt.Errorf("failed to create manager: %w", err)
+	}
+	if err := xset.SetUpWithManager(e.Manager, e.XSetController); err != nil {
+		return fmt.Errorf("failed to set up %s: %w", e.XSetController.ControllerName(), err)
+	}
+
+	var ctx context.Context
+	ctx, e.cancel = context.WithCancel(context.Background())
+	go func() {
+		e.done <- e.Manager.Start(ctx)
+	}()
+	if !e.Manager.GetCache().WaitForCacheSync(ctx) {
+		e.cancel()
+		return fmt.Errorf("failed to wait for cache sync: %w", <-e.done)
+	}
+	return nil
+}
+
+// Stop stops manager and envtest
+func (e *Env) Stop() error {
+	var err error
+	if e.cancel != nil {
+		e.cancel()
+		err = <-e.done
+	}
+	return errors.Join(err, e.Environment.Stop())
+}
+
+// CreateXSet creates xset, and its namespace if not found
+func (e *Env) CreateXSet(ctx context.Context, xset api.XSetObject) error {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: xset.GetNamespace()}}
+	if err := e.Client.Create(ctx, namespace); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create namespace %s: %w", namespace.Name, err)
+	}
+	return e.Client.Create(ctx, xset)
+}
+
+// TriggerUpdate updates xset by mutate, e.g., changing template or replicas, and retries on conflict
+func (e *Env) TriggerUpdate(ctx context.Context, xset api.XSetObject, mutate func(xset api.XSetObject)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := e.Client.Get(ctx, client.ObjectKeyFromObject(xset), xset); err != nil {
+			return err
+		}
+		mutate(xset)
+		return e.Client.Update(ctx, xset)
+	})
+}
+
+// WaitForReplicas waits until xset owns replicas targets not terminating, and its status observes them
+func (e *Env) WaitForReplicas(ctx context.Context, xset api.XSetObject, replicas int32) error {
+	return e.waitForStatus(ctx, xset, replicas, func(status *api.XSetStatus) bool {
+		return status.Replicas == replicas
+	})
+}
+
+// WaitForUpdatedReplicas waits until xset owns replicas targets not terminating, which are all of updated revision
+func (e *Env) WaitForUpdatedReplicas(ctx context.Context, xset api.XSetObject, replicas int32) error {
+	return e.waitForStatus(ctx, xset, replicas, func(status *api.XSetStatus) bool {
+		return status.Replicas == replicas && status.UpdatedReplicas == replicas
+	})
+}
+
+func (e *Env) waitForStatus(ctx context.Context, xset api.XSetObject, replicas int32, satisfied func(status *api.XSetStatus) bool) error {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	var lastStatus *api.XSetStatus
+	var lastCount int
+	err := wait.PollImmediateUntil(e.pollInterval, func() (bool, error) {
+		if err := e.Client.Get(ctx, client.ObjectKeyFromObject(xset), xset); err != nil {
+			return false, err
+		}
+		targets, err := e.ListTargets(ctx, xset)
+		if err != nil {
+			return false, err
+		}
+		lastStatus, lastCount = e.XSetController.GetXSetStatus(xset), len(targets)
+		return lastStatus.ObservedGeneration == xset.GetGeneration() && lastCount == int(replicas) && satisfied(lastStatus), nil
+	}, ctx.Done())
+	if err != nil {
+		return fmt.Errorf("failed to wait for %d replicas of %s, got %d targets and status %+v: %w", replicas, xset.GetName(), lastCount, lastStatus, err)
+	}
+	return nil
+}
+
+// ListTargets lists targets controlled by xset which are not terminating
+func (e *Env) ListTargets(ctx context.Context, xset api.XSetObject) ([]client.Object, error) {
+	targetList := e.XSetController.NewXObjectList()
+	if err := e.Client.List(ctx, targetList, client.InNamespace(xset.GetNamespace())); err != nil {
+		return nil, err
+	}
+	items, err := meta.ExtractList(targetList)
+	if err != nil {
+		return nil, err
+	}
+	var targets []client.Object
+	for _, item := range items {
+		target, ok := item.(client.Object)
+		if !ok || target.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if owner := metav1.GetControllerOf(target); owner != nil && owner.UID == xset.GetUID() {
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+// MarkPodsReady sets PodReady condition of pods controlled by xset, since no kubelet runs in envtest
+func (e *Env) MarkPodsReady(ctx context.Context, xset api.XSetObject) error {
+	targets, err := e.ListTargets(ctx, xset)
+	if err != nil {
+		return err
+	}
+	for _, target := range targets {
+		pod, ok := target.(*corev1.Pod)
+		if !ok {
+			return fmt.Errorf("target %s is not pod", target.GetName())
+		}
+		pod.Status.Phase = corev1.PodRunning
+		pod.Status.Conditions = append(pod.Status.Conditions, corev1.PodCondition{
+			Type:               corev1.PodReady,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.Now(),
+		})
+		if err := e.Client.Status().Update(ctx, pod); err != nil {
+			return fmt.Errorf("failed to mark pod %s ready: %w", pod.Name, err)
+		}
+	}
+	return nil
+}
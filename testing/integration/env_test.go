@@ -0,0 +1,152 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package integration
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
+	appsv1alpha1 "kusionstack.io/kube-api/apps/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+func TestSampleSetScaleOut(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS is not set")
+	}
+	env, err := Start(Options{})
+	if err != nil {
+		t.Fatalf("failed to start env: %v", err)
+	}
+	defer func() {
+		if err := env.Stop(); err != nil {
+			t.Errorf("failed to stop env: %v", err)
+		}
+	}()
+
+	ctx := context.Background()
+	set := NewSampleSet("default", "foo", 3, "nginx:v1")
+	if err := env.CreateXSet(ctx, set); err != nil {
+		t.Fatalf("failed to create SampleSet: %v", err)
+	}
+	if err := env.WaitForReplicas(ctx, set, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.TriggerUpdate(ctx, set, func(xset api.XSetObject) {
+		xset.(*SampleSet).Spec.Replicas = ptr.To[int32](5)
+	}); err != nil {
+		t.Fatalf("failed to scale out SampleSet: %v", err)
+	}
+	if err := env.WaitForReplicas(ctx, set, 5); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSampleSetIDCleanDryRun(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS is not set")
+	}
+	env, err := Start(Options{})
+	if err != nil {
+		t.Fatalf("failed to start env: %v", err)
+	}
+	defer func() {
+		if err := env.Stop(); err != nil {
+			t.Errorf("failed to stop env: %v", err)
+		}
+	}()
+
+	// IDs 2 to 4 are owned by foo but used by no target
+	ctx := context.Background()
+	if err := env.Client.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dry-run"}}); err != nil {
+		t.Fatalf("failed to create namespace: %v", err)
+	}
+	resourceContext := &appsv1alpha1.ResourceContext{ObjectMeta: metav1.ObjectMeta{Namespace: "dry-run", Name: "foo"}}
+	for id := 0; id < 5; id++ {
+		resourceContext.Spec.Contexts = append(resourceContext.Spec.Contexts, appsv1alpha1.ContextDetail{ID: id, Data: map[string]string{"Owner": "foo"}})
+	}
+	if err := env.Client.Create(ctx, resourceContext); err != nil {
+		t.Fatalf("failed to create ResourceContext: %v", err)
+	}
+	set := NewSampleSet("dry-run", "foo", 2, "nginx:v1")
+	set.SetAnnotations(map[string]string{api.XSetIDCleanDryRunAnnotationKey: "true"})
+	if err := env.CreateXSet(ctx, set); err != nil {
+		t.Fatalf("failed to create SampleSet: %v", err)
+	}
+	if err := env.WaitForReplicas(ctx, set, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// unused IDs are reported but kept in dry-run
+	if err := env.poll(ctx, func() (bool, error) {
+		events := &corev1.EventList{}
+		if err := env.Client.List(ctx, events, client.InNamespace("dry-run")); err != nil {
+			return false, err
+		}
+		for _, event := range events.Items {
+			if event.InvolvedObject.Name == "foo" && event.Reason == "ResourceContextCleanDryRun" {
+				return true, nil
+			}
+		}
+		return false, nil
+	}); err != nil {
+		t.Fatalf("failed to wait for ResourceContextCleanDryRun event: %v", err)
+	}
+	if ids := env.contextIDs(ctx, t, "dry-run", "foo"); len(ids) != 5 {
+		t.Fatalf("got IDs %v in dry-run, want all 5 kept", ids)
+	}
+
+	// unused IDs are reclaimed once dry-run is turned off
+	if err := env.TriggerUpdate(ctx, set, func(xset api.XSetObject) {
+		xset.SetAnnotations(nil)
+	}); err != nil {
+		t.Fatalf("failed to turn off dry-run: %v", err)
+	}
+	if err := env.poll(ctx, func() (bool, error) {
+		return len(env.contextIDs(ctx, t, "dry-run", "foo")) == 2, nil
+	}); err != nil {
+		t.Fatalf("failed to wait for unused IDs reclaimed, got %v: %v", env.contextIDs(ctx, t, "dry-run", "foo"), err)
+	}
+}
+
+func (e *Env) poll(ctx context.Context, condition wait.ConditionFunc) error {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	return wait.PollImmediateUntil(e.pollInterval, condition, ctx.Done())
+}
+
+func (e *Env) contextIDs(ctx context.Context, t *testing.T, namespace, name string) []int {
+	t.Helper()
+	resourceContext := &appsv1alpha1.ResourceContext{}
+	if err := e.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, resourceContext); err != nil {
+		t.Fatalf("failed to get ResourceContext: %v", err)
+	}
+	var ids []int
+	for _, detail := range resourceContext.Spec.Contexts {
+		ids = append(ids, detail.ID)
+	}
+	return ids
+}
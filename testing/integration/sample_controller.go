@@ -0,0 +1,151 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+var _ api.XSetController = &SampleXSetController{}
+
+// SampleXSetController is a sample XSetController managing pods by SampleSet. Pods are ready along with PodReady
+// condition, which is only set by test since no kubelet runs in envtest.
+type SampleXSetController struct{}
+
+func (c *SampleXSetController) ControllerName() string {
+	return "sampleset-controller"
+}
+
+func (c *SampleXSetController) FinalizerName() string {
+	return "samples.xset.kusionstack.io/finalizer"
+}
+
+func (c *SampleXSetController) XSetMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: SampleGroupVersion.String(), Kind: "SampleSet"}
+}
+
+func (c *SampleXSetController) XMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "Pod"}
+}
+
+func (c *SampleXSetController) NewXSetObject() api.XSetObject {
+	return &SampleSet{}
+}
+
+func (c *SampleXSetController) NewXObject() client.Object {
+	return &corev1.Pod{}
+}
+
+func (c *SampleXSetController) NewXObjectList() client.ObjectList {
+	return &corev1.PodList{}
+}
+
+func (c *SampleXSetController) GetXSetSpec(object api.XSetObject) *api.XSetSpec {
+	return &object.(*SampleSet).Spec.XSetSpec
+}
+
+// GetXSetPatch returns the pod template of SampleSet, which is recorded in revisions
+func (c *SampleXSetController) GetXSetPatch(object metav1.Object) ([]byte, error) {
+	set := object.(*SampleSet)
+	template, err := json.Marshal(set.Spec.Template)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(template, &raw); err != nil {
+		return nil, err
+	}
+	raw["$patch"] = "replace"
+	return json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{"template": raw},
+	})
+}
+
+func (c *SampleXSetController) GetXSetStatus(object api.XSetObject) *api.XSetStatus {
+	return &object.(*SampleSet).Status
+}
+
+func (c *SampleXSetController) SetXSetStatus(object api.XSetObject, status *api.XSetStatus) {
+	object.(*SampleSet).Status = *status
+}
+
+func (c *SampleXSetController) UpdateScaleStrategy(ctx context.Context, cli client.Client, object api.XSetObject, scaleStrategy *api.ScaleStrategy) error {
+	set := object.(*SampleSet)
+	set.Spec.ScaleStrategy = *scaleStrategy
+	return cli.Update(ctx, set)
+}
+
+func (c *SampleXSetController) GetXSetTemplatePatcher(_ metav1.Object) func(client.Object) error {
+	return func(client.Object) error { return nil }
+}
+
+// GetXObjectFromRevision returns pod from the template recorded in revision
+func (c *SampleXSetController) GetXObjectFromRevision(revision *appsv1.ControllerRevision) (client.Object, error) {
+	var patch struct {
+		Spec struct {
+			Template corev1.PodTemplateSpec `json:"template"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(revision.Data.Raw, &patch); err != nil {
+		return nil, fmt.Errorf("fail to decode revision %s: %w", revision.Name, err)
+	}
+	template := patch.Spec.Template
+	pod := &corev1.Pod{
+		ObjectMeta: *template.ObjectMeta.DeepCopy(),
+		Spec:       *template.Spec.DeepCopy(),
+	}
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	return pod, nil
+}
+
+func (c *SampleXSetController) CheckScheduled(object client.Object) bool {
+	return object.(*corev1.Pod).Spec.NodeName != ""
+}
+
+func (c *SampleXSetController) CheckReadyTime(object client.Object) (bool, *metav1.Time) {
+	for _, cond := range object.(*corev1.Pod).Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue, &cond.LastTransitionTime
+		}
+	}
+	return false, nil
+}
+
+func (c *SampleXSetController) CheckAvailable(object client.Object) bool {
+	ready, _ := c.CheckReadyTime(object)
+	return ready
+}
+
+func (c *SampleXSetController) CheckInactive(object client.Object) bool {
+	pod := object.(*corev1.Pod)
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
+
+func (c *SampleXSetController) GetXOpsPriority(_ context.Context, _ client.Client, _ client.Object) (*api.OpsPriority, error) {
+	return &api.OpsPriority{}, nil
+}
@@ -0,0 +1,174 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package integration
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	appsv1alpha1 "kusionstack.io/kube-api/apps/v1alpha1"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// SampleGroupVersion is the group version of SampleSet
+var SampleGroupVersion = schema.GroupVersion{Group: "samples.xset.kusionstack.io", Version: "v1alpha1"}
+
+var (
+	sampleSchemeBuilder = runtime.NewSchemeBuilder(func(s *runtime.Scheme) error {
+		s.AddKnownTypes(SampleGroupVersion, &SampleSet{}, &SampleSetList{})
+		metav1.AddToGroupVersion(s, SampleGroupVersion)
+		return nil
+	})
+	// AddToScheme adds SampleSet to scheme
+	AddToScheme = sampleSchemeBuilder.AddToScheme
+)
+
+// SampleSetSpec is the spec of SampleSet, which manages pods from template
+type SampleSetSpec struct {
+	api.XSetSpec `json:",inline"`
+
+	// Template is the pod template of targets
+	Template corev1.PodTemplateSpec `json:"template,omitempty"`
+}
+
+// SampleSet is a sample XSet managing pods, which is used to run XSetController in integration tests
+type SampleSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SampleSetSpec  `json:"spec,omitempty"`
+	Status api.XSetStatus `json:"status,omitempty"`
+}
+
+// SampleSetList is a list of SampleSet
+type SampleSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []SampleSet `json:"items"`
+}
+
+// NewSampleSet returns a SampleSet of replicas pods running image
+func NewSampleSet(namespace, name string, replicas int32, image string) *SampleSet {
+	labels := map[string]string{"app": name}
+	return &SampleSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: SampleSetSpec{
+			XSetSpec: api.XSetSpec{
+				Replicas: ptr.To(replicas),
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "main", Image: image}},
+				},
+			},
+		},
+	}
+}
+
+func (in *SampleSet) DeepCopyInto(out *SampleSet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.XSetSpec.DeepCopyInto(&out.Spec.XSetSpec)
+	in.Spec.Template.DeepCopyInto(&out.Spec.Template)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+func (in *SampleSet) DeepCopy() *SampleSet {
+	if in == nil {
+		return nil
+	}
+	out := new(SampleSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *SampleSet) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+func (in *SampleSetList) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	out := new(SampleSetList)
+	*out = *in
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]SampleSet, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+	return out
+}
+
+// SampleCRDs returns CRDs of SampleSet and ResourceContext used by default ResourceContextAdapter, whose schemas
+// preserve unknown fields. SampleSet serves status and scale subresources, so that it can be scaled by autoscalers.
+func SampleCRDs() []apiextensionsv1.CustomResourceDefinition {
+	return []apiextensionsv1.CustomResourceDefinition{
+		newCRD(SampleGroupVersion, "SampleSet", "samplesets", true),
+		newCRD(appsv1alpha1.SchemeGroupVersion, "ResourceContext", "resourcecontexts", false),
+	}
+}
+
+func newCRD(gv schema.GroupVersion, kind, plural string, withStatus bool) apiextensionsv1.CustomResourceDefinition {
+	version := apiextensionsv1.CustomResourceDefinitionVersion{
+		Name:    gv.Version,
+		Served:  true,
+		Storage: true,
+		Schema: &apiextensionsv1.CustomResourceValidation{
+			OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+				Type:                   "object",
+				XPreserveUnknownFields: ptr.To(true),
+			},
+		},
+	}
+	if withStatus {
+		version.Subresources = &apiextensionsv1.CustomResourceSubresources{
+			Status: &apiextensionsv1.CustomResourceSubresourceStatus{},
+			Scale: &apiextensionsv1.CustomResourceSubresourceScale{
+				SpecReplicasPath:   ".spec.replicas",
+				StatusReplicasPath: ".status.replicas",
+				LabelSelectorPath:  ptr.To(".status.selector"),
+			},
+		}
+	}
+	return apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: plural + "." + gv.Group},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: gv.Group,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Kind:     kind,
+				ListKind: kind + "List",
+				Plural:   plural,
+				Singular: strings.ToLower(kind),
+			},
+			Scope:    apiextensionsv1.NamespaceScoped,
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{version},
+		},
+	}
+}
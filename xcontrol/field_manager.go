@@ -0,0 +1,77 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xcontrol
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// GetFieldManager returns field manager of writes set by FieldManagerAdapter, or empty if not set
+func GetFieldManager(xsetController api.XSetController) string {
+	adapter, ok := xsetController.(api.FieldManagerAdapter)
+	if !ok {
+		return ""
+	}
+	return adapter.GetFieldManager()
+}
+
+// NewFieldManagerClient returns client setting fieldManager on every create, update and patch, including those of
+// status, unless the write sets its own field manager. c is returned as it is if fieldManager is empty.
+func NewFieldManagerClient(c client.Client, fieldManager string) client.Client {
+	if fieldManager == "" {
+		return c
+	}
+	return &fieldManagerClient{Client: c, fieldOwner: client.FieldOwner(fieldManager)}
+}
+
+type fieldManagerClient struct {
+	client.Client
+	fieldOwner client.FieldOwner
+}
+
+func (c *fieldManagerClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	return c.Client.Create(ctx, obj, append([]client.CreateOption{c.fieldOwner}, opts...)...)
+}
+
+func (c *fieldManagerClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return c.Client.Update(ctx, obj, append([]client.UpdateOption{c.fieldOwner}, opts...)...)
+}
+
+func (c *fieldManagerClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return c.Client.Patch(ctx, obj, patch, append([]client.PatchOption{c.fieldOwner}, opts...)...)
+}
+
+func (c *fieldManagerClient) Status() client.StatusWriter {
+	return &fieldManagerStatusWriter{StatusWriter: c.Client.Status(), fieldOwner: c.fieldOwner}
+}
+
+type fieldManagerStatusWriter struct {
+	client.StatusWriter
+	fieldOwner client.FieldOwner
+}
+
+func (w *fieldManagerStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return w.StatusWriter.Update(ctx, obj, append([]client.UpdateOption{w.fieldOwner}, opts...)...)
+}
+
+func (w *fieldManagerStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return w.StatusWriter.Patch(ctx, obj, patch, append([]client.PatchOption{w.fieldOwner}, opts...)...)
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xcontrol
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fieldManagerRecorder records field managers of writes
+type fieldManagerRecorder struct {
+	client.Client
+	client.StatusWriter
+	fieldManagers []string
+}
+
+func (r *fieldManagerRecorder) Create(_ context.Context, _ client.Object, opts ...client.CreateOption) error {
+	r.fieldManagers = append(r.fieldManagers, (&client.CreateOptions{}).ApplyOptions(opts).FieldManager)
+	return nil
+}
+
+func (r *fieldManagerRecorder) Update(_ context.Context, _ client.Object, opts ...client.UpdateOption) error {
+	r.fieldManagers = append(r.fieldManagers, (&client.UpdateOptions{}).ApplyOptions(opts).FieldManager)
+	return nil
+}
+
+func (r *fieldManagerRecorder) Patch(_ context.Context, _ client.Object, _ client.Patch, opts ...client.PatchOption) error {
+	r.fieldManagers = append(r.fieldManagers, (&client.PatchOptions{}).ApplyOptions(opts).FieldManager)
+	return nil
+}
+
+func (r *fieldManagerRecorder) Status() client.StatusWriter { return r }
+
+func TestFieldManagerClient(t *testing.T) {
+	recorder := &fieldManagerRecorder{}
+	if c := NewFieldManagerClient(recorder, ""); c != client.Client(recorder) {
+		t.Fatalf("client should be returned as it is without field manager")
+	}
+
+	c := NewFieldManagerClient(recorder, "platform-xset")
+	ctx := context.TODO()
+	pod := &corev1.Pod{}
+	_ = c.Create(ctx, pod)
+	_ = c.Update(ctx, pod)
+	_ = c.Patch(ctx, pod, client.Merge)
+	_ = c.Status().Update(ctx, pod)
+	_ = c.Status().Patch(ctx, pod, client.Merge)
+	// field manager set by write itself is kept
+	_ = c.Patch(ctx, pod, client.Merge, client.FieldOwner("manual"))
+
+	want := []string{"platform-xset", "platform-xset", "platform-xset", "platform-xset", "platform-xset", "manual"}
+	if !reflect.DeepEqual(recorder.fieldManagers, want) {
+		t.Errorf("got field managers %v, want %v", recorder.fieldManagers, want)
+	}
+}
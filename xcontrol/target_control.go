@@ -21,11 +21,14 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
 	"kusionstack.io/kube-utils/controller/mixin"
 	refmanagerutil "kusionstack.io/kube-utils/controller/refmanager"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
@@ -36,24 +39,45 @@ import (
 
 const (
 	FieldIndexOwnerRefUID = "ownerRefUID"
+	FieldIndexInstanceID  = "instanceID"
+	FieldIndexOrphaned    = "orphaned"
 )
 
 type TargetControl interface {
 	GetFilteredTargets(ctx context.Context, selector *metav1.LabelSelector, owner api.XSetObject) ([]client.Object, []client.Object, error)
 	CreateTarget(ctx context.Context, target client.Object) (client.Object, error)
-	DeleteTarget(ctx context.Context, target client.Object) error
+	DryRunCreateTarget(ctx context.Context, target client.Object) error
+	DeleteTarget(ctx context.Context, target client.Object, opts ...client.DeleteOption) error
 	UpdateTarget(ctx context.Context, target client.Object) error
 	PatchTarget(ctx context.Context, target client.Object, patch client.Patch) error
+	PatchTargetWithOptimisticLock(ctx context.Context, target client.Object, mutateFn func(target client.Object)) error
 	OrphanTarget(ctx context.Context, xset api.XSetObject, target client.Object) error
 	AdoptTarget(ctx context.Context, xset api.XSetObject, target client.Object) error
+	GetTargetsByInstanceID(ctx context.Context, xset api.XSetObject, id int) ([]client.Object, error)
+	VisitTargets(ctx context.Context, owner api.XSetObject, visitor func(target client.Object) error) error
 }
 
 type targetControl struct {
-	client client.Client
-	schema *runtime.Scheme
+	client    client.Client
+	apiReader client.Reader
+	schema    *runtime.Scheme
 
-	xsetController api.XSetController
-	xGVK           schema.GroupVersionKind
+	xsetController   api.XSetController
+	xsetLabelAnnoMgr api.XSetLabelAnnotationManager
+	xGVK             schema.GroupVersionKind
+}
+
+type listFromAPIServerKey struct{}
+
+// WithListFromAPIServer returns context in which targets are listed from api server instead of cache, e.g., to force
+// a full re-list when cache is suspected to be stale.
+func WithListFromAPIServer(ctx context.Context) context.Context {
+	return context.WithValue(ctx, listFromAPIServerKey{}, true)
+}
+
+func listFromAPIServer(ctx context.Context) bool {
+	forced, _ := ctx.Value(listFromAPIServerKey{}).(bool)
+	return forced
 }
 
 func NewTargetControl(mixin *mixin.ReconcilerMixin, xsetController api.XSetController) (TargetControl, error) {
@@ -64,59 +88,201 @@ func NewTargetControl(mixin *mixin.ReconcilerMixin, xsetController api.XSetContr
 	xMeta := xsetController.XMeta()
 	gvk := xMeta.GroupVersionKind()
 	return &targetControl{
-		client:         mixin.Client,
-		schema:         mixin.Scheme,
-		xsetController: xsetController,
-		xGVK:           gvk,
+		client:           mixin.Client,
+		apiReader:        mixin.APIReader,
+		schema:           mixin.Scheme,
+		xsetController:   xsetController,
+		xsetLabelAnnoMgr: api.GetXSetLabelAnnotationManager(xsetController),
+		xGVK:             gvk,
 	}, nil
 }
 
 func (r *targetControl) GetFilteredTargets(ctx context.Context, selector *metav1.LabelSelector, owner api.XSetObject) ([]client.Object, []client.Object, error) {
-	targetList := r.xsetController.NewXObjectList()
-	if err := r.client.List(ctx, targetList, &client.ListOptions{
-		Namespace:     owner.GetNamespace(),
-		FieldSelector: fields.OneTermEqualSelector(FieldIndexOwnerRefUID, string(owner.GetUID())),
-	}); err != nil {
+	refManager, err := r.newRefManager(selector, owner)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// claim targets while visiting them, so that only claimed ones are kept rather than the whole list
+	var allTargets []client.Object
+	var errList []error
+	claim := func(target client.Object) error {
+		ok, err := refManager.Claim(ctx, target)
+		if err != nil {
+			errList = append(errList, err)
+			return nil
+		}
+		if ok {
+			allTargets = append(allTargets, target)
+		}
+		return nil
+	}
+	if err := r.VisitTargets(ctx, owner, claim); err != nil {
 		return nil, nil, err
 	}
 
+	if adapter, ok := r.xsetController.(api.TargetAdoptionAdapter); ok && adapter.AdoptOrphanedTargets(owner) {
+		orphans, err := r.getOrphanedTargets(ctx, selector, owner)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range orphans {
+			_ = claim(orphans[i])
+		}
+	}
+
+	if len(errList) > 0 {
+		return nil, nil, errors.Join(errList...)
+	}
+	return filterOutInactiveTargets(r.xsetController, allTargets), allTargets, nil
+}
+
+// VisitTargets calls visitor on each target owned by xset. Targets are listed from cache by owner index, unless
+// listing from api server is forced by WithListFromAPIServer, in which case targets in namespace of xset are listed
+// page by page with chunk size of TargetListChunkAdapter, and only owned ones are copied out of each page.
+func (r *targetControl) VisitTargets(ctx context.Context, owner api.XSetObject, visitor func(target client.Object) error) error {
+	if !listFromAPIServer(ctx) || r.apiReader == nil {
+		items, err := r.listTargets(ctx, &client.ListOptions{
+			Namespace:     owner.GetNamespace(),
+			FieldSelector: fields.OneTermEqualSelector(FieldIndexOwnerRefUID, string(owner.GetUID())),
+		})
+		if err != nil {
+			return err
+		}
+		for i := range items {
+			if err := visitor(items[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// owner index is only available in cache, so list all targets in namespace and filter owned ones by controller
+	// ref. Listing by selector of xset would miss owned targets no longer matching it, which are then never released.
+	// Zero chunk size means no limit.
+	var chunkSize int64
+	if adapter, ok := r.xsetController.(api.TargetListChunkAdapter); ok {
+		chunkSize = adapter.GetTargetListChunkSize(owner)
+	}
+	listOptions := &client.ListOptions{Namespace: owner.GetNamespace(), Limit: max(chunkSize, 0)}
+	for {
+		targetList := r.xsetController.NewXObjectList()
+		if err := r.apiReader.List(ctx, targetList, listOptions); err != nil {
+			return err
+		}
+		items, err := getListItems(targetList)
+		if err != nil {
+			return err
+		}
+		for i := range items {
+			ownerRef := metav1.GetControllerOf(items[i])
+			if ownerRef == nil || ownerRef.UID != owner.GetUID() {
+				continue
+			}
+			// copy the owned target, otherwise keeping it would retain the whole page it points into
+			if err := visitor(items[i].DeepCopyObject().(client.Object)); err != nil {
+				return err
+			}
+		}
+
+		listOptions.Continue = targetList.GetContinue()
+		if listOptions.Continue == "" {
+			return nil
+		}
+	}
+}
+
+func (r *targetControl) listTargets(ctx context.Context, opts ...client.ListOption) ([]client.Object, error) {
+	targetList := r.xsetController.NewXObjectList()
+	if err := r.client.List(ctx, targetList, opts...); err != nil {
+		return nil, err
+	}
+	return getListItems(targetList)
+}
+
+func getListItems(targetList client.ObjectList) ([]client.Object, error) {
 	targetListVal := reflect.Indirect(reflect.ValueOf(targetList))
 	itemsVal := targetListVal.FieldByName("Items")
-	if !itemsVal.IsValid() {
-		return nil, nil, fmt.Errorf("target list items is invalid")
+	if !itemsVal.IsValid() || itemsVal.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("target list items is invalid")
 	}
 
-	var items []client.Object
-	if itemsVal.Kind() == reflect.Slice {
-		items = make([]client.Object, itemsVal.Len())
-		for i := 0; i < itemsVal.Len(); i++ {
-			itemVal := itemsVal.Index(i).Addr().Interface()
-			items[i] = itemVal.(client.Object)
-		}
-	} else {
-		return nil, nil, fmt.Errorf("target list items is invalid")
+	items := make([]client.Object, itemsVal.Len())
+	for i := 0; i < itemsVal.Len(); i++ {
+		itemVal := itemsVal.Index(i).Addr().Interface()
+		items[i] = itemVal.(client.Object)
 	}
+	return items, nil
+}
 
-	allTargets, err := r.getTargets(ctx, items, selector, owner)
+// getOrphanedTargets returns targets matching selector without controller, which are candidates to adopt.
+// Targets excluded by xset are left orphaned.
+func (r *targetControl) getOrphanedTargets(ctx context.Context, selector *metav1.LabelSelector, owner api.XSetObject) ([]client.Object, error) {
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
 	if err != nil {
-		return nil, nil, err
+		return nil, fmt.Errorf("fail to convert labelSelector: %w", err)
+	}
+	items, err := r.listTargets(ctx, &client.ListOptions{
+		Namespace:     owner.GetNamespace(),
+		FieldSelector: fields.OneTermEqualSelector(FieldIndexOrphaned, "true"),
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	items = filterOutInactiveTargets(r.xsetController, items)
-	filteredTargets, err := r.getTargets(ctx, items, selector, owner)
-
-	return filteredTargets, allTargets, err
+	var orphans []client.Object
+	for i := range items {
+		if metav1.GetControllerOf(items[i]) != nil || items[i].GetDeletionTimestamp() != nil {
+			continue
+		}
+		if _, excluded := r.xsetLabelAnnoMgr.Get(items[i], api.XOrphanedIndicationLabelKey); excluded {
+			continue
+		}
+		orphans = append(orphans, items[i])
+	}
+	return orphans, nil
 }
 
 func (r *targetControl) CreateTarget(ctx context.Context, target client.Object) (client.Object, error) {
 	if err := r.client.Create(ctx, target); err != nil {
+		if existing, ok := r.getCreatedTarget(ctx, target, err); ok {
+			return existing, nil
+		}
 		return nil, fmt.Errorf("failed to create target: %w", err)
 	}
 	return target, nil
 }
 
-func (r *targetControl) DeleteTarget(ctx context.Context, target client.Object) error {
-	return r.client.Delete(ctx, target)
+// getCreatedTarget returns the existing target if a target with deterministic name has already been created
+// by the same controller, e.g., in a previous reconcile not observed by cache yet.
+func (r *targetControl) getCreatedTarget(ctx context.Context, target client.Object, createErr error) (client.Object, bool) {
+	if !apierrors.IsAlreadyExists(createErr) || target.GetName() == "" {
+		return nil, false
+	}
+
+	ownerRef := metav1.GetControllerOf(target)
+	existing := r.xsetController.NewXObject()
+	if err := r.client.Get(ctx, client.ObjectKeyFromObject(target), existing); err != nil {
+		return nil, false
+	}
+	existingOwnerRef := metav1.GetControllerOf(existing)
+	if ownerRef == nil || existingOwnerRef == nil || ownerRef.UID != existingOwnerRef.UID || existing.GetDeletionTimestamp() != nil {
+		return nil, false
+	}
+	return existing, true
+}
+
+// DryRunCreateTarget sends a dry-run creation request of target, the target passed in is not modified
+func (r *targetControl) DryRunCreateTarget(ctx context.Context, target client.Object) error {
+	if err := r.client.Create(ctx, target.DeepCopyObject().(client.Object), client.DryRunAll); err != nil {
+		return fmt.Errorf("failed to dry-run create target: %w", err)
+	}
+	return nil
+}
+
+func (r *targetControl) DeleteTarget(ctx context.Context, target client.Object, opts ...client.DeleteOption) error {
+	return r.client.Delete(ctx, target, opts...)
 }
 
 func (r *targetControl) UpdateTarget(ctx context.Context, target client.Object) error {
@@ -127,6 +293,29 @@ func (r *targetControl) PatchTarget(ctx context.Context, target client.Object, p
 	return r.client.Patch(ctx, target, patch)
 }
 
+// PatchTargetWithOptimisticLock patches the changes made by mutateFn to target with resourceVersion precondition, so
+// that fields changed by other controllers are not overwritten. On conflict, target is refreshed from api server and
+// mutateFn is applied again.
+func (r *targetControl) PatchTargetWithOptimisticLock(ctx context.Context, target client.Object, mutateFn func(target client.Object)) error {
+	refresh := false
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if refresh {
+			reader := r.apiReader
+			if reader == nil {
+				reader = r.client
+			}
+			if err := reader.Get(ctx, client.ObjectKeyFromObject(target), target); err != nil {
+				return err
+			}
+		}
+		refresh = true
+
+		original := target.DeepCopyObject().(client.Object)
+		mutateFn(target)
+		return r.client.Patch(ctx, target, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{}))
+	})
+}
+
 func (r *targetControl) OrphanTarget(ctx context.Context, xset api.XSetObject, target client.Object) error {
 	spec := r.xsetController.GetXSetSpec(xset)
 	if spec.Selector.MatchLabels == nil {
@@ -168,29 +357,33 @@ func (r *targetControl) AdoptTarget(ctx context.Context, xset api.XSetObject, ta
 	return nil
 }
 
-func (r *targetControl) getTargets(ctx context.Context, candidates []client.Object, selector *metav1.LabelSelector, xset api.XSetObject) ([]client.Object, error) {
-	// Use RefManager to adopt/orphan as needed.
-	writer := refmanagerutil.NewOwnerRefWriter(r.client)
-	matcher, err := refmanagerutil.LabelSelectorAsMatch(selector)
+// GetTargetsByInstanceID returns targets owned by xset with instance ID, using cache index
+func (r *targetControl) GetTargetsByInstanceID(ctx context.Context, xset api.XSetObject, id int) ([]client.Object, error) {
+	items, err := r.listTargets(ctx, &client.ListOptions{
+		Namespace:     xset.GetNamespace(),
+		FieldSelector: fields.OneTermEqualSelector(FieldIndexInstanceID, strconv.Itoa(id)),
+	})
 	if err != nil {
-		return nil, fmt.Errorf("fail to create labelSelector matcher: %w", err)
+		return nil, err
 	}
-	refManager := refmanagerutil.NewObjectControllerRefManager(writer, xset, xset.GetObjectKind().GroupVersionKind(), matcher)
 
-	var claimObjs []client.Object
-	var errList []error
-	for _, obj := range candidates {
-		ok, err := refManager.Claim(ctx, obj)
-		if err != nil {
-			errList = append(errList, err)
-			continue
-		}
-		if ok {
-			claimObjs = append(claimObjs, obj)
+	var targets []client.Object
+	for i := range items {
+		if ownerRef := metav1.GetControllerOf(items[i]); ownerRef != nil && ownerRef.UID == xset.GetUID() {
+			targets = append(targets, items[i])
 		}
 	}
+	return targets, nil
+}
 
-	return claimObjs, errors.Join(errList...)
+func (r *targetControl) newRefManager(selector *metav1.LabelSelector, xset api.XSetObject) (*refmanagerutil.ObjectControllerRefManager, error) {
+	// Use RefManager to adopt/orphan as needed.
+	writer := refmanagerutil.NewOwnerRefWriter(r.client)
+	matcher, err := refmanagerutil.LabelSelectorAsMatch(selector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create labelSelector matcher: %w", err)
+	}
+	return refmanagerutil.NewObjectControllerRefManager(writer, xset, xset.GetObjectKind().GroupVersionKind(), matcher), nil
 }
 
 func setUpCache(cache cache.Cache, controller api.XSetController) error {
@@ -203,6 +396,29 @@ func setUpCache(cache cache.Cache, controller api.XSetController) error {
 	}); err != nil {
 		return fmt.Errorf("failed to index by field for x->xset %s: %w", FieldIndexOwnerRefUID, err)
 	}
+
+	labelMgr := api.GetXSetLabelAnnotationManager(controller)
+	if err := cache.IndexField(context.TODO(), controller.NewXObject(), FieldIndexInstanceID, func(object client.Object) []string {
+		ownerRef := metav1.GetControllerOf(object)
+		if ownerRef == nil || ownerRef.Kind != controller.XSetMeta().Kind {
+			return nil
+		}
+		if id, exist := labelMgr.Get(object, api.XInstanceIdLabelKey); exist {
+			return []string{id}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to index by field for x %s: %w", FieldIndexInstanceID, err)
+	}
+
+	if err := cache.IndexField(context.TODO(), controller.NewXObject(), FieldIndexOrphaned, func(object client.Object) []string {
+		if metav1.GetControllerOf(object) != nil {
+			return nil
+		}
+		return []string{"true"}
+	}); err != nil {
+		return fmt.Errorf("failed to index by field for x %s: %w", FieldIndexOrphaned, err)
+	}
 	return nil
 }
 
@@ -0,0 +1,220 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xcontrol
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+type chunkController struct {
+	api.XSetController
+	spec *api.XSetSpec
+}
+
+func (c *chunkController) NewXObjectList() client.ObjectList { return &corev1.PodList{} }
+
+func (c *chunkController) GetXSetSpec(api.XSetObject) *api.XSetSpec { return c.spec }
+
+func (c *chunkController) GetTargetListChunkSize(api.XSetObject) int64 { return 2 }
+
+// pagingReader lists pods page by page, using the index of next pod as continue token
+type pagingReader struct {
+	client.Reader
+	pods  []corev1.Pod
+	lists []client.ListOptions
+}
+
+func (r *pagingReader) List(_ context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOptions := (&client.ListOptions{}).ApplyOptions(opts)
+	r.lists = append(r.lists, *listOptions)
+
+	var matched []corev1.Pod
+	for _, pod := range r.pods {
+		if pod.Namespace == listOptions.Namespace &&
+			(listOptions.LabelSelector == nil || listOptions.LabelSelector.Matches(labels.Set(pod.Labels))) {
+			matched = append(matched, pod)
+		}
+	}
+	start, _ := strconv.Atoi(listOptions.Continue)
+	end := len(matched)
+	if listOptions.Limit > 0 {
+		end = min(start+int(listOptions.Limit), end)
+	}
+	podList := list.(*corev1.PodList)
+	podList.Items = matched[start:end]
+	if end < len(matched) {
+		podList.Continue = strconv.Itoa(end)
+	}
+	return nil
+}
+
+func TestVisitTargetsInChunks(t *testing.T) {
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", UID: "xset-uid"}}
+	pod := func(name, app string, owner types.UID) corev1.Pod {
+		return corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            name,
+			Labels:          map[string]string{"app": app},
+			OwnerReferences: []metav1.OwnerReference{{Name: "owner", UID: owner, Controller: ptr.To(true)}},
+		}}
+	}
+	reader := &pagingReader{pods: []corev1.Pod{
+		pod("foo-0", "foo", "xset-uid"),
+		pod("bar-0", "bar", "other-uid"),
+		pod("foo-1", "foo", "xset-uid"),
+		pod("foo-2", "foo", "other-uid"),
+		pod("foo-3", "foo", "xset-uid"),
+		pod("foo-4", "foo", "xset-uid"),
+		pod("foo-5", "foo", "xset-uid"),
+		pod("foo-6", "released", "xset-uid"),
+	}}
+	control := &targetControl{
+		apiReader: reader,
+		xsetController: &chunkController{spec: &api.XSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}},
+		}},
+	}
+
+	var visited []string
+	if err := control.VisitTargets(WithListFromAPIServer(context.TODO()), xset, func(target client.Object) error {
+		visited = append(visited, target.GetName())
+		return nil
+	}); err != nil {
+		t.Fatalf("VisitTargets() = %v", err)
+	}
+
+	// owned targets not matching selector are visited as well, so that they can be released
+	if want := []string{"foo-0", "foo-1", "foo-3", "foo-4", "foo-5", "foo-6"}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("VisitTargets() visits %v, want %v", visited, want)
+	}
+	var continues []string
+	for _, listOptions := range reader.lists {
+		if listOptions.Limit != 2 || listOptions.LabelSelector != nil {
+			t.Errorf("VisitTargets() lists with limit %d and selector %v, want 2 and no selector", listOptions.Limit, listOptions.LabelSelector)
+		}
+		continues = append(continues, listOptions.Continue)
+	}
+	if want := []string{"", "2", "4", "6"}; !reflect.DeepEqual(continues, want) {
+		t.Errorf("VisitTargets() lists with continue tokens %q, want %q", continues, want)
+	}
+}
+
+// listRecordingClient records options of list calls served by cache
+type listRecordingClient struct {
+	client.Client
+	lists []client.ListOptions
+}
+
+func (c *listRecordingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.lists = append(c.lists, *(&client.ListOptions{}).ApplyOptions(opts))
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestVisitTargetsFromCacheWithoutResync(t *testing.T) {
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", UID: "xset-uid"}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-0"}}
+	c := &listRecordingClient{Client: clientfake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(pod).Build()}
+	reader := &pagingReader{pods: []corev1.Pod{*pod}}
+	control := &targetControl{client: c, apiReader: reader, xsetController: &chunkController{}}
+
+	var visited []string
+	if err := control.VisitTargets(context.TODO(), xset, func(target client.Object) error {
+		visited = append(visited, target.GetName())
+		return nil
+	}); err != nil {
+		t.Fatalf("VisitTargets() = %v", err)
+	}
+
+	// chunk size only applies to resyncs, regular reconciles are served by owner index of cache
+	if len(reader.lists) != 0 {
+		t.Errorf("VisitTargets() lists %d times from api server, want none", len(reader.lists))
+	}
+	if len(c.lists) != 1 || c.lists[0].FieldSelector.String() != FieldIndexOwnerRefUID+"=xset-uid" {
+		t.Errorf("VisitTargets() lists from cache with %v, want owner index selector", c.lists)
+	}
+	if want := []string{"foo-0"}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("VisitTargets() visits %v, want %v", visited, want)
+	}
+}
+
+// conflictOnceClient fails the first patch with conflict, as if target was changed since it was read
+type conflictOnceClient struct {
+	client.Client
+	patches int
+}
+
+func (c *conflictOnceClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.patches++
+	if c.patches == 1 {
+		return apierrors.NewConflict(schema.GroupResource{Resource: "pods"}, obj.GetName(), nil)
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestPatchTargetWithOptimisticLockRetriesOnConflict(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-0", Labels: map[string]string{"app": "foo"}}}
+	c := &conflictOnceClient{Client: clientfake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(pod).Build()}
+	control := &targetControl{client: c}
+
+	stale := &corev1.Pod{}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(pod), stale); err != nil {
+		t.Fatal(err)
+	}
+	// another writer changes target after it is read
+	changed := stale.DeepCopy()
+	changed.Labels["other"] = "true"
+	if err := c.Update(context.TODO(), changed); err != nil {
+		t.Fatal(err)
+	}
+
+	var mutations int
+	if err := control.PatchTargetWithOptimisticLock(context.TODO(), stale, func(target client.Object) {
+		mutations++
+		labels := target.GetLabels()
+		labels["patched"] = "true"
+		target.SetLabels(labels)
+	}); err != nil {
+		t.Fatalf("PatchTargetWithOptimisticLock() = %v", err)
+	}
+
+	if mutations != 2 || c.patches != 2 {
+		t.Errorf("PatchTargetWithOptimisticLock() mutates %d times and patches %d times, want 2 and 2", mutations, c.patches)
+	}
+	got := &corev1.Pod{}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(pod), got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Labels["patched"] != "true" || got.Labels["other"] != "true" {
+		t.Errorf("PatchTargetWithOptimisticLock() results in labels %v, want both patched and other", got.Labels)
+	}
+}
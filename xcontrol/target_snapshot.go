@@ -0,0 +1,61 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xcontrol
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// TargetSnapshot lists targets of an XSet at most once, and shares the result within one reconcile.
+// Targets are shared by pointer, so in-place updates made during reconcile are visible to later readers.
+// It is not safe for concurrent use.
+type TargetSnapshot struct {
+	control  TargetControl
+	selector *metav1.LabelSelector
+	owner    api.XSetObject
+
+	loaded          bool
+	filteredTargets []client.Object
+	allTargets      []client.Object
+}
+
+func NewTargetSnapshot(control TargetControl, selector *metav1.LabelSelector, owner api.XSetObject) *TargetSnapshot {
+	return &TargetSnapshot{
+		control:  control,
+		selector: selector,
+		owner:    owner,
+	}
+}
+
+// GetFilteredTargets returns the same result as TargetControl.GetFilteredTargets, which is listed on first call.
+func (s *TargetSnapshot) GetFilteredTargets(ctx context.Context) ([]client.Object, []client.Object, error) {
+	if s.loaded {
+		return s.filteredTargets, s.allTargets, nil
+	}
+
+	filteredTargets, allTargets, err := s.control.GetFilteredTargets(ctx, s.selector, s.owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.filteredTargets, s.allTargets, s.loaded = filteredTargets, allTargets, true
+	return filteredTargets, allTargets, nil
+}
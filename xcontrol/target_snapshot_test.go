@@ -0,0 +1,72 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xcontrol
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kusionstack.io/kube-xset/api"
+)
+
+// countingTargetControl counts GetFilteredTargets calls and fails the first one if err is set
+type countingTargetControl struct {
+	TargetControl
+	calls   int
+	err     error
+	targets []client.Object
+}
+
+func (c *countingTargetControl) GetFilteredTargets(context.Context, *metav1.LabelSelector, api.XSetObject) ([]client.Object, []client.Object, error) {
+	c.calls++
+	if c.err != nil {
+		err := c.err
+		c.err = nil
+		return nil, nil, err
+	}
+	return c.targets, c.targets, nil
+}
+
+func TestTargetSnapshot(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-0"}}
+	control := &countingTargetControl{err: errors.New("list failed"), targets: []client.Object{pod}}
+	snapshot := NewTargetSnapshot(control, nil, &corev1.Pod{})
+
+	if _, _, err := snapshot.GetFilteredTargets(context.TODO()); err == nil {
+		t.Fatal("GetFilteredTargets() returns no error, want list error")
+	}
+	// failed list is not cached
+	for i := 0; i < 2; i++ {
+		filtered, all, err := snapshot.GetFilteredTargets(context.TODO())
+		if err != nil || len(filtered) != 1 || len(all) != 1 {
+			t.Fatalf("GetFilteredTargets() = %d, %d, %v, want 1, 1, nil", len(filtered), len(all), err)
+		}
+		// in-place updates are shared with later readers
+		filtered[0].SetLabels(map[string]string{"round": "done"})
+	}
+	if control.calls != 2 {
+		t.Errorf("GetFilteredTargets() lists %d times, want 2", control.calls)
+	}
+	if pod.Labels["round"] != "done" {
+		t.Errorf("GetFilteredTargets() does not share targets by pointer")
+	}
+}
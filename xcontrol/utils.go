@@ -19,6 +19,7 @@ package xcontrol
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -47,6 +48,44 @@ func GetInstanceID(xsetLabelAnnoMgr api.XSetLabelAnnotationManager, target clien
 	return int(id), nil
 }
 
+// GetInstanceIDWithFallback gets instance ID from label, and falls back to lookups in order if label is missing.
+func GetInstanceIDWithFallback(xsetLabelAnnoMgr api.XSetLabelAnnotationManager, target client.Object, lookups ...api.InstanceIDLookup) (int, error) {
+	id, err := GetInstanceID(xsetLabelAnnoMgr, target)
+	if err == nil {
+		return id, nil
+	}
+	for _, lookup := range lookups {
+		if id, ok := lookup(target); ok && id >= 0 {
+			return id, nil
+		}
+	}
+	return id, err
+}
+
+// InstanceIDFromAnnotation looks up instance ID from annotation with key.
+func InstanceIDFromAnnotation(key string) api.InstanceIDLookup {
+	return func(target client.Object) (int, bool) {
+		val, exist := target.GetAnnotations()[key]
+		if !exist {
+			return -1, false
+		}
+		id, err := strconv.Atoi(val)
+		return id, err == nil
+	}
+}
+
+// InstanceIDFromNameSuffix looks up instance ID from name in the form of <prefix><id>, e.g., <xset>-<id>.
+func InstanceIDFromNameSuffix(prefix string) api.InstanceIDLookup {
+	return func(target client.Object) (int, bool) {
+		name := target.GetName()
+		if !strings.HasPrefix(name, prefix) {
+			return -1, false
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(name, prefix))
+		return id, err == nil
+	}
+}
+
 func GetTargetRevision(target client.Object, defaultRevision string) string {
 	if target.GetLabels() == nil {
 		return defaultRevision
@@ -20,21 +20,26 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
 	clientutil "kusionstack.io/kube-utils/client"
-	"kusionstack.io/kube-utils/controller/expectations"
 	"kusionstack.io/kube-utils/controller/history"
 	"kusionstack.io/kube-utils/controller/mixin"
+	controllerutils "kusionstack.io/kube-utils/controller/utils"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -43,6 +48,7 @@ import (
 
 	"kusionstack.io/kube-xset/api"
 	"kusionstack.io/kube-xset/api/validation"
+	"kusionstack.io/kube-xset/conditions"
 	"kusionstack.io/kube-xset/resourcecontexts"
 	"kusionstack.io/kube-xset/revisionowner"
 	"kusionstack.io/kube-xset/subresources"
@@ -56,15 +62,43 @@ type xSetCommonReconciler struct {
 	XSetController api.XSetController
 	meta           metav1.TypeMeta
 	finalizerName  string
+	finalizerless  bool
+	namespaces     sets.String
 	xsetGVK        schema.GroupVersionKind
 
 	// reconcile logic helpers
-	cacheExpectations      *expectations.CacheExpectations
+	cacheExpectations      *expectationTracker
+	expectationTimeout     time.Duration
+	expectationRequeue     time.Duration
+	requeueJitter          float64
+	unsatisfiedSince       sync.Map
+	resyncHandled          sync.Map
+	lastFullSync           sync.Map
 	targetControl          xcontrol.TargetControl
 	pvcControl             subresources.PvcControl
 	syncControl            synccontrols.SyncControl
 	revisionManager        history.HistoryManager
 	resourceContextControl resourcecontexts.ResourceContextControl
+	xsetLabelMgr           api.XSetLabelAnnotationManager
+	templateRefs           *templateRefIndex
+	targetNodes            *targetNodeIndex
+}
+
+const (
+	// DefaultExpectationTimeout is the default duration to wait for unsatisfied cache expectations
+	DefaultExpectationTimeout = 5 * time.Minute
+	// DefaultExpectationRequeueDelay is the default delay to requeue when cache expectations are unsatisfied
+	DefaultExpectationRequeueDelay = 30 * time.Second
+	// DefaultRequeueJitterFactor is the default max factor of requeue duration added as jitter
+	DefaultRequeueJitterFactor = 0.1
+	// FullSyncInterval is the max interval between full syncs of an XSet whose sync is skipped when nothing changed
+	FullSyncInterval = 10 * time.Minute
+)
+
+// ExpectationDebugPath returns the path on metrics server to inspect pending cache expectations of XSets managed by
+// controller, e.g., /debug/expectations/<controller>?key=<namespace>/<name>.
+func ExpectationDebugPath(controllerName string) string {
+	return "/debug/expectations/" + controllerName
 }
 
 func SetUpWithManager(mgr ctrl.Manager, xsetController api.XSetController) error {
@@ -76,7 +110,16 @@ func SetUpWithManager(mgr ctrl.Manager, xsetController api.XSetController) error
 		return err
 	}
 
+	if err := checkNamespacedCache(mgr.GetCache(), getWatchNamespaces(xsetController)); err != nil {
+		return err
+	}
+	if err := installCacheTransforms(mgr, xsetController); err != nil {
+		return err
+	}
+
 	reconcilerMixin := mixin.NewReconcilerMixin(xsetController.ControllerName(), mgr)
+	// every write of controls built from reconcilerMixin is attributed to field manager of FieldManagerAdapter
+	reconcilerMixin.Client = xcontrol.NewFieldManagerClient(reconcilerMixin.Client, xcontrol.GetFieldManager(xsetController))
 	xsetLabelManager := api.GetXSetLabelAnnotationManager(xsetController)
 	xsetMeta := xsetController.XSetMeta()
 	xsetGVK := xsetMeta.GroupVersionKind()
@@ -88,15 +131,36 @@ func SetUpWithManager(mgr ctrl.Manager, xsetController api.XSetController) error
 	if err != nil {
 		return err
 	}
-	cacheExpectations := expectations.NewxCacheExpectations(reconcilerMixin.Client, reconcilerMixin.Scheme, clock.RealClock{})
-	resourceContextControl := resourcecontexts.NewRealResourceContextControl(reconcilerMixin, xsetController, resourceContextAdapter, resourceContextGVK, cacheExpectations, xsetLabelManager)
+	expectationTimeout, expectationRequeue := DefaultExpectationTimeout, DefaultExpectationRequeueDelay
+	if adapter, ok := xsetController.(api.ExpectationAdapter); ok {
+		if timeout := adapter.GetExpectationTimeout(); timeout > 0 {
+			expectationTimeout = timeout
+		}
+		if delay := adapter.GetExpectationRequeueDelay(); delay > 0 {
+			expectationRequeue = delay
+		}
+	}
+	cacheExpectations := newExpectationTracker(newResourceVersionExpectations(reconcilerMixin.Client, reconcilerMixin.APIReader, reconcilerMixin.Scheme, expectationTimeout))
+	if err := mgr.AddMetricsExtraHandler(ExpectationDebugPath(xsetController.ControllerName()), cacheExpectations); err != nil {
+		return fmt.Errorf("failed to register expectation debug handler: %w", err)
+	}
+	var resourceContextControl resourcecontexts.ResourceContextControl
+	if resourcecontexts.IsStateless(xsetController) {
+		resourceContextControl = resourcecontexts.NewStatelessResourceContextControl(xsetController, resourceContextAdapter, xsetLabelManager)
+	} else {
+		resourceContextControl = resourcecontexts.NewRealResourceContextControl(reconcilerMixin, xsetController, resourceContextAdapter, resourceContextGVK, cacheExpectations, xsetLabelManager)
+	}
 	pvcControl, err := subresources.NewRealPvcControl(reconcilerMixin, cacheExpectations, xsetLabelManager, xsetController)
 	if err != nil {
 		return errors.New("failed to create pvc control")
 	}
-	syncControl := synccontrols.NewRealSyncControl(reconcilerMixin, xsetController, targetControl, pvcControl, xsetLabelManager, resourceContextControl, cacheExpectations)
+	subresourceControls := subresources.NewSubresourceControls(reconcilerMixin, cacheExpectations, xsetLabelManager, xsetController)
+	syncControl, err := synccontrols.NewRealSyncControl(reconcilerMixin, xsetController, targetControl, pvcControl, subresourceControls, xsetLabelManager, resourceContextControl, cacheExpectations)
+	if err != nil {
+		return err
+	}
 	revisionControl := history.NewRevisionControl(reconcilerMixin.Client, reconcilerMixin.Client)
-	revisionOwner := revisionowner.NewRevisionOwner(xsetController, targetControl)
+	revisionOwner := revisionowner.NewRevisionOwner(xsetController, targetControl, reconcilerMixin.Client)
 	revisionManager := history.NewHistoryManager(revisionControl, revisionOwner)
 
 	reconciler := &xSetCommonReconciler{
@@ -109,19 +173,42 @@ func SetUpWithManager(mgr ctrl.Manager, xsetController api.XSetController) error
 		syncControl:            syncControl,
 		revisionManager:        revisionManager,
 		resourceContextControl: resourceContextControl,
+		xsetLabelMgr:           xsetLabelManager,
 		cacheExpectations:      cacheExpectations,
+		expectationTimeout:     expectationTimeout,
+		expectationRequeue:     expectationRequeue,
+		requeueJitter:          DefaultRequeueJitterFactor,
 		xsetGVK:                xsetGVK,
+		templateRefs:           newTemplateRefIndex(),
+		targetNodes:            newTargetNodeIndex(),
+	}
+	reconciler.namespaces = getWatchNamespaces(xsetController)
+	if adapter, ok := xsetController.(api.FinalizerlessAdapter); ok {
+		reconciler.finalizerless = adapter.SkipXSetFinalizer()
+	}
+	if adapter, ok := xsetController.(api.RequeueJitterAdapter); ok {
+		reconciler.requeueJitter = adapter.GetRequeueJitterFactor()
 	}
 
-	c, err := controller.New(xsetController.ControllerName(), mgr, controller.Options{
-		MaxConcurrentReconciles: 5,
-		Reconciler:              reconciler,
-	})
+	if reconciler.finalizerless && !resourcecontexts.IsStateless(xsetController) {
+		if err := mgr.Add(&resourceContextJanitor{
+			resourceContextControl: resourceContextControl,
+			namespaces:             reconciler.namespaces,
+			interval:               FullSyncInterval,
+			logger:                 mgr.GetLogger().WithName(xsetController.ControllerName()),
+		}); err != nil {
+			return fmt.Errorf("failed to add ResourceContext janitor: %w", err)
+		}
+	}
+
+	controllerOptions := newControllerOptions(xsetController, reconciler)
+	c, err := controller.New(xsetController.ControllerName(), mgr, controllerOptions)
 	if err != nil {
 		return fmt.Errorf("failed to create controller: %w", err)
 	}
+	c = newNamespacedController(c, reconciler.namespaces)
 
-	if err := c.Watch(&source.Kind{Type: xsetController.NewXSetObject()}, &handler.EnqueueRequestForObject{}); err != nil {
+	if err := c.Watch(&source.Kind{Type: xsetController.NewXSetObject()}, newEnqueueXSetWithPriority(xsetController)); err != nil {
 		return fmt.Errorf("failed to watch %s: %w", xsetController.XSetMeta().Kind, err)
 	}
 
@@ -146,22 +233,66 @@ func SetUpWithManager(mgr ctrl.Manager, xsetController api.XSetController) error
 		return fmt.Errorf("failed to watch %s: %w", targetMeta.Kind, err)
 	}
 
+	// watch for templates referenced by XSets changed
+	if err := watchTemplateRefs(c, xsetController, reconciler.templateRefs); err != nil {
+		return fmt.Errorf("failed to watch templates referenced: %w", err)
+	}
+
+	// watch for readiness of nodes targets are bound to changed
+	if err := watchTargetNodes(c, xsetController, reconciler.targetNodes); err != nil {
+		return fmt.Errorf("failed to watch nodes: %w", err)
+	}
+
+	// watch for governing headless Services changed
+	if err := watchHeadlessServices(c, xsetController); err != nil {
+		return fmt.Errorf("failed to watch services: %w", err)
+	}
+
 	// watch for decoration changed
-	if adapter, ok := xsetController.(api.DecorationAdapter); ok {
+	for _, adapter := range synccontrols.GetDecorationAdapters(xsetController) {
 		err = adapter.WatchDecoration(c)
 		if err != nil {
 			return err
 		}
 	}
 
+	// watch for objects provided by adapters and subresource controls
+	watchProviders := []interface{}{xsetController}
+	for _, control := range subresourceControls {
+		watchProviders = append(watchProviders, control)
+	}
+	for _, candidate := range watchProviders {
+		if provider, ok := candidate.(api.WatchProvider); ok {
+			if err := provider.RegisterWatches(c); err != nil {
+				return fmt.Errorf("failed to register watches: %w", err)
+			}
+		}
+	}
+
 	return nil
 }
 
+// newControllerOptions returns options of XSet controller, with rate limiter of RateLimiterAdapter if implemented
+func newControllerOptions(xsetController api.XSetController, reconciler reconcile.Reconciler) controller.Options {
+	controllerOptions := controller.Options{
+		MaxConcurrentReconciles: 5,
+		Reconciler:              reconciler,
+	}
+	if adapter, ok := xsetController.(api.RateLimiterAdapter); ok {
+		controllerOptions.RateLimiter = adapter.GetRateLimiter()
+	}
+	return controllerOptions
+}
+
 func (r *xSetCommonReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
 	kind := r.meta.Kind
 	key := req.String()
 	ctx = logr.NewContext(ctx, r.Logger.WithValues(kind, key))
 	logger := logr.FromContext(ctx)
+	if r.namespaces.Len() > 0 && !r.namespaces.Has(req.Namespace) {
+		// requests mapped by adapters may be out of the namespaces to watch
+		return ctrl.Result{}, nil
+	}
 	instance := r.XSetController.NewXSetObject()
 	if err := r.Client.Get(ctx, req.NamespacedName, instance); err != nil {
 		if !apierrors.IsNotFound(err) {
@@ -170,18 +301,76 @@ func (r *xSetCommonReconciler) Reconcile(ctx context.Context, req reconcile.Requ
 		}
 
 		logger.Info("object deleted")
+		if r.finalizerless {
+			// nothing is reclaimed before deletion without finalizer, so release IDs here in best effort
+			if err := r.resourceContextControl.ReleaseDeletedOwnerIDs(ctx, req.Namespace, req.Name); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		r.resourceContextControl.ForgetOwner(req.Namespace, req.Name)
+		r.syncControl.ForgetOwner(req.Namespace, req.Name)
+		if r.pvcControl != nil {
+			r.pvcControl.ForgetOwner(req.Namespace, req.Name)
+		}
 		r.cacheExpectations.DeleteExpectations(req.String())
+		r.unsatisfiedSince.Delete(req.String())
+		r.resyncHandled.Delete(req.String())
+		r.lastFullSync.Delete(req.String())
+		r.templateRefs.Delete(req.NamespacedName)
+		r.targetNodes.Delete(req.NamespacedName)
+		suspendedXSets.DeleteLabelValues(r.XSetController.ControllerName(), req.Namespace, req.Name)
+		return ctrl.Result{}, nil
+	}
+
+	r.templateRefs.Set(req.NamespacedName, r.XSetController.GetXSetSpec(instance).TemplateRef)
+
+	if api.IsXSetMigrating(instance) && instance.GetDeletionTimestamp() == nil {
+		// revisions and ResourceContext of the legacy workload are being adopted, reconciled once migration completes.
+		// XSet deleted during migration is still reconciled to release its resources and finalizer.
+		logger.Info("migration in progress, skip reconcile", "stage", instance.GetAnnotations()[api.XSetMigrationAnnotationKey])
 		return ctrl.Result{}, nil
 	}
 
+	if api.IsXSetSuspended(instance) && instance.GetDeletionTimestamp() == nil {
+		logger.V(1).Info("suspended by annotation, skip reconcile")
+		return ctrl.Result{}, r.suspend(ctx, instance)
+	}
+	suspendedXSets.DeleteLabelValues(r.XSetController.ControllerName(), req.Namespace, req.Name)
+	if conditions.IsTrue(r.XSetController.GetXSetStatus(instance), api.XSetSuspended) {
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, "Resumed", "reconciliation is resumed")
+	}
+
 	if err := r.ensureFinalizer(ctx, instance); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// clamp replicas managed by autoscaler into bounds, on spec held by instance in this reconcile only
+	if origin, err := synccontrols.ClampReplicas(instance, r.XSetController.GetXSetSpec(instance)); err != nil {
+		r.Recorder.Eventf(instance, corev1.EventTypeWarning, "InvalidReplicasBounds", "replicas are not clamped: %v", err)
+	} else if origin != nil {
+		replicas := r.XSetController.GetXSetSpec(instance).Replicas
+		logger.Info("replicas clamped into bounds", "replicas", *origin, "clamped", *replicas)
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, "ReplicasClamped", "replicas %d are clamped to %d", *origin, *replicas)
+	}
+
+	// force resync requested by annotation, drop expectations and list targets from api server
+	resync := r.resyncRequested(req.String(), instance)
+	if resync {
+		logger.Info("force resync requested", "resync", instance.GetAnnotations()[api.XSetResyncAnnotationKey])
+		r.cacheExpectations.DeleteExpectations(req.String())
+		r.unsatisfiedSince.Delete(req.String())
+		ctx = xcontrol.WithListFromAPIServer(ctx)
+	}
+
 	// if cacheExpectation not fulfilled, shortcut this reconciling till informer cache is updated.
-	if satisfied := r.cacheExpectations.SatisfiedExpectations(req.String()); !satisfied {
-		logger.Info("not satisfied to reconcile")
-		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	if requeueAfter, unsatisfied := r.waitForExpectations(req.String()); unsatisfied {
+		pending := r.cacheExpectations.Pending(key)
+		if len(pending) > 0 {
+			logger.Info("not satisfied to reconcile", "pendingCount", len(pending), "oldestPending", pending[0])
+		} else {
+			logger.Info("not satisfied to reconcile")
+		}
+		return requeueResult(&requeueAfter, r.requeueJitter), nil
 	}
 
 	currentRevision, updatedRevision, revisions, collisionCount, _, err := r.revisionManager.ConstructRevisions(ctx, instance)
@@ -191,6 +380,13 @@ func (r *xSetCommonReconciler) Reconcile(ctx context.Context, req reconcile.Requ
 
 	xsetStatus := r.XSetController.GetXSetStatus(instance)
 	newStatus := xsetStatus.DeepCopy()
+	// revision rolled back by analysis is aborted until template changes, and targets are kept in current revision
+	rollingBack := synccontrols.IsRevisionAborted(xsetStatus, currentRevision, updatedRevision)
+	if rollingBack {
+		updatedRevision = currentRevision
+	} else {
+		newStatus.AbortedRevision = ""
+	}
 	newStatus.UpdatedRevision = updatedRevision.Name
 	newStatus.CurrentRevision = currentRevision.Name
 	newStatus.CollisionCount = &collisionCount
@@ -198,20 +394,65 @@ func (r *xSetCommonReconciler) Reconcile(ctx context.Context, req reconcile.Requ
 		Revisions:       revisions,
 		CurrentRevision: currentRevision,
 		UpdatedRevision: updatedRevision,
+		RollingBack:     rollingBack,
 		NewStatus:       newStatus,
+		TargetSnapshot:  xcontrol.NewTargetSnapshot(r.targetControl, r.XSetController.GetXSetSpec(instance).Selector, instance),
+	}
+
+	var requeueAfter *time.Duration
+	var syncErr error
+	if err := synccontrols.CheckSelector(r.XSetController, instance, syncContext); err != nil {
+		// selector is reported by SelectorConsistent condition, and sync is resumed once it is fixed
+		logger.Info("invalid selector, skip sync", "reason", err.Error())
+		r.Recorder.Eventf(instance, corev1.EventTypeWarning, "InvalidSelector", "sync is skipped: %v", err)
+	} else if skip, err := r.canSkipSync(ctx, key, resync, instance, syncContext); err != nil {
+		syncErr = err
+	} else if skip {
+		logger.V(1).Info("nothing to sync, only calculate status")
+	} else {
+		requeueAfter, syncErr = r.doSync(ctx, instance, syncContext)
+		if syncErr == nil {
+			r.lastFullSync.Store(key, time.Now())
+		}
 	}
-
-	requeueAfter, syncErr := r.doSync(ctx, instance, syncContext)
+	flushAfter, flushErr := r.resourceContextControl.FlushPendingWrites(ctx, instance)
+	syncErr = errors.Join(syncErr, flushErr)
 	if syncErr != nil {
 		logger.Error(syncErr, "failed to sync")
 	}
 
 	newStatus = r.syncControl.CalculateStatus(ctx, instance, syncContext)
+	if _, ok := r.XSetController.(api.NodeFailureReplaceAdapter); ok && syncContext.FilteredTarget != nil {
+		r.targetNodes.Set(req.NamespacedName, syncContext.FilteredTarget)
+	}
+	if resync {
+		dumpSyncDecisions(logger, syncContext, newStatus)
+	}
+	requeueAfter = xcontrol.GetShorterDuration(requeueAfter, syncContext.RecheckAvailableAfter)
+	requeueAfter = xcontrol.GetShorterDuration(requeueAfter, syncContext.RecheckPvcDeletionAfter)
+	requeueAfter = xcontrol.GetShorterDuration(requeueAfter, syncContext.RecheckPostCreateAfter)
+	requeueAfter = xcontrol.GetShorterDuration(requeueAfter, syncContext.RecheckPostDeleteAfter)
+	requeueAfter = xcontrol.GetShorterDuration(requeueAfter, syncContext.RecheckStuckTerminatingAfter)
+	requeueAfter = xcontrol.GetShorterDuration(requeueAfter, syncContext.RecheckNodeFailureAfter)
+	requeueAfter = xcontrol.GetShorterDuration(requeueAfter, flushAfter)
+	requeueAfter = xcontrol.GetShorterDuration(requeueAfter, syncContext.RecheckMaintenanceWindowAfter)
+	syncContext.Decisions.RecordRequeue("WaitingAvailable", syncContext.RecheckAvailableAfter)
+	syncContext.Decisions.RecordRequeue("WaitingPvcDeletion", syncContext.RecheckPvcDeletionAfter)
+	syncContext.Decisions.RecordRequeue("RetryingPostCreate", syncContext.RecheckPostCreateAfter)
+	syncContext.Decisions.RecordRequeue("RetryingPostDelete", syncContext.RecheckPostDeleteAfter)
+	syncContext.Decisions.RecordRequeue("WaitingStuckTerminating", syncContext.RecheckStuckTerminatingAfter)
+	syncContext.Decisions.RecordRequeue("WaitingNodeFailure", syncContext.RecheckNodeFailureAfter)
+	syncContext.Decisions.RecordRequeue("FlushingResourceContext", flushAfter)
+	syncContext.Decisions.RecordRequeue("WaitingMaintenanceWindow", syncContext.RecheckMaintenanceWindowAfter)
+	logSyncDecision(logger, r.XSetController.GetXSetSpec(instance), syncContext, newStatus, syncErr)
 	// update status anyway
 	if err := r.updateStatus(ctx, instance, newStatus); err != nil {
-		return requeueResult(requeueAfter), fmt.Errorf("fail to update status of %s %s: %w", kind, req, err)
+		return requeueResult(requeueAfter, r.requeueJitter), fmt.Errorf("fail to update status of %s %s: %w", kind, req, err)
 	}
-	return requeueResult(requeueAfter), syncErr
+	if resync && syncErr == nil {
+		r.markResyncHandled(req.String(), instance)
+	}
+	return requeueResult(requeueAfter, r.requeueJitter), syncErr
 }
 
 func (r *xSetCommonReconciler) doSync(ctx context.Context, instance api.XSetObject, syncContext *synccontrols.SyncContext) (*time.Duration, error) {
@@ -221,7 +462,7 @@ func (r *xSetCommonReconciler) doSync(ctx context.Context, instance api.XSetObje
 	}
 
 	if instance.GetDeletionTimestamp() != nil {
-		return nil, r.releaseResourcesForDeletion(ctx, instance, syncContext.NewStatus)
+		return nil, r.releaseResourcesForDeletion(ctx, instance, syncContext)
 	}
 
 	err = r.syncControl.Replace(ctx, instance, syncContext)
@@ -231,18 +472,53 @@ func (r *xSetCommonReconciler) doSync(ctx context.Context, instance api.XSetObje
 
 	_, scaleRequeueAfter, scaleErr := r.syncControl.Scale(ctx, instance, syncContext)
 	_, updateRequeueAfter, updateErr := r.syncControl.Update(ctx, instance, syncContext)
+	syncContext.Decisions.RecordRequeue("Scaling", scaleRequeueAfter)
+	syncContext.Decisions.RecordRequeue("Updating", updateRequeueAfter)
 	patcherErr := synccontrols.ApplyTemplatePatcher(ctx, r.XSetController, r.Client, instance, syncContext.TargetWrappers)
+	driftErr := r.syncControl.RemediateDrift(ctx, instance, syncContext)
 
-	err = errors.Join(scaleErr, updateErr, patcherErr)
+	err = errors.Join(scaleErr, updateErr, patcherErr, driftErr)
 	if updateRequeueAfter != nil && (scaleRequeueAfter == nil || *updateRequeueAfter < *scaleRequeueAfter) {
 		return updateRequeueAfter, err
 	}
 	return scaleRequeueAfter, err
 }
 
+// canSkipSync returns true if nothing changed since the last full sync, which is done at least every FullSyncInterval
+// to catch up with changes not detected, e.g., pvcs not listed.
+func (r *xSetCommonReconciler) canSkipSync(ctx context.Context, key string, resync bool, instance api.XSetObject, syncContext *synccontrols.SyncContext) (bool, error) {
+	if resync {
+		return false, nil
+	}
+	last, ok := r.lastFullSync.Load(key)
+	if !ok || time.Since(last.(time.Time)) >= FullSyncInterval {
+		return false, nil
+	}
+	return r.syncControl.CanSkipSync(ctx, instance, syncContext)
+}
+
+// suspend reflects suspension of XSet in Suspended and Progressing conditions and metrics. Other fields of status are
+// left as observed by the last reconcile, since targets are not synced.
+func (r *xSetCommonReconciler) suspend(ctx context.Context, instance api.XSetObject) error {
+	suspendedXSets.WithLabelValues(r.XSetController.ControllerName(), instance.GetNamespace(), instance.GetName()).Set(1)
+	status := r.XSetController.GetXSetStatus(instance)
+	if !conditions.IsTrue(status, api.XSetSuspended) {
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, "Suspended", "reconciliation is suspended by annotation %s", api.XSetSuspendAnnotationKey)
+	}
+	newStatus := status.DeepCopy()
+	generation := instance.GetGeneration()
+	conditions.Set(newStatus, api.XSetSuspended, metav1.ConditionTrue, conditions.ReasonSuspendedByAnnotation,
+		fmt.Sprintf("reconciliation is suspended by annotation %s", api.XSetSuspendAnnotationKey), generation)
+	conditions.Set(newStatus, api.XSetProgressing, metav1.ConditionUnknown, conditions.ReasonSuspended, "XSet is suspended", generation)
+	return r.updateStatus(ctx, instance, newStatus)
+}
+
 func (r *xSetCommonReconciler) ensureFinalizer(ctx context.Context, instance api.XSetObject) error {
 	logger := logr.FromContext(ctx)
 	if instance.GetDeletionTimestamp() == nil {
+		if r.finalizerless {
+			return nil
+		}
 		// ensure finalizer
 		if err := clientutil.AddFinalizerAndUpdate(ctx, r.Client, instance, r.finalizerName); err != nil {
 			r.Recorder.Eventf(instance, corev1.EventTypeWarning, "FailedAddFinalizer", fmt.Sprintf("failed to add finalizer %s, err: %v", r.finalizerName, err))
@@ -250,11 +526,7 @@ func (r *xSetCommonReconciler) ensureFinalizer(ctx context.Context, instance api
 		}
 		return nil
 	}
-	status := r.XSetController.GetXSetStatus(instance)
-	terminatingCond := meta.FindStatusCondition(status.Conditions, string(api.XSetTerminating))
-	if terminatingCond != nil &&
-		terminatingCond.Status == metav1.ConditionTrue &&
-		terminatingCond.Reason == "Deleted" {
+	if _, deleted := conditions.FindAndCompare(r.XSetController.GetXSetStatus(instance), api.XSetTerminating, metav1.ConditionTrue, conditions.ReasonDeleted); deleted {
 		// remove finalizer
 		if err := clientutil.RemoveFinalizerAndUpdate(ctx, r.Client, instance, r.finalizerName); err != nil {
 			r.Recorder.Eventf(instance, corev1.EventTypeWarning, "FailedRemoveFinalizer", fmt.Sprintf("failed to remove finalizer %s, err: %v", r.finalizerName, err))
@@ -265,39 +537,46 @@ func (r *xSetCommonReconciler) ensureFinalizer(ctx context.Context, instance api
 	return nil
 }
 
-func (r *xSetCommonReconciler) releaseResourcesForDeletion(ctx context.Context, instance api.XSetObject, newStatus *api.XSetStatus) error {
+func (r *xSetCommonReconciler) releaseResourcesForDeletion(ctx context.Context, instance api.XSetObject, syncContext *synccontrols.SyncContext) error {
 	if instance.GetDeletionTimestamp() == nil {
 		return nil
 	}
+	newStatus := syncContext.NewStatus
 
 	// reclaim target sub resources before remove finalizers
 	if err := r.ensureReclaimTargetSubResources(ctx, instance); err != nil {
-		synccontrols.AddOrUpdateCondition(newStatus, api.XSetTerminating, err, "ReclaimSubResourcesFailed", err.Error())
+		conditions.SetFromError(newStatus, api.XSetTerminating, err, conditions.ReasonReclaimSubResourcesFailed, err.Error())
 		return err
 	}
 
 	// reclaim decoration ownerReferences before remove finalizers
-	if err := r.ensureReclaimOwnerReferences(ctx, instance); err != nil {
-		synccontrols.AddOrUpdateCondition(newStatus, api.XSetTerminating, err, "ReclaimOwnerReferencesFailed", err.Error())
+	if err := r.ensureReclaimOwnerReferences(ctx, instance, syncContext.TargetSnapshot); err != nil {
+		conditions.SetFromError(newStatus, api.XSetTerminating, err, conditions.ReasonReclaimOwnerReferencesFailed, err.Error())
 		return err
 	}
 
-	// reclaim targets deletion before remove finalizers
-	if cleaned, err := r.ensureReclaimTargetsDeletion(ctx, instance); err != nil {
-		synccontrols.AddOrUpdateCondition(newStatus, api.XSetTerminating, err, "ReclaimTargetsDeletionFailed", err.Error())
+	if r.orphanTargetsOnDeletion(instance) {
+		// orphan targets instead of deleting them before remove finalizers
+		if err := r.ensureOrphanTargets(ctx, instance, syncContext.TargetSnapshot); err != nil {
+			conditions.SetFromError(newStatus, api.XSetTerminating, err, conditions.ReasonOrphanTargetsFailed, err.Error())
+			return err
+		}
+	} else if cleaned, err := r.ensureReclaimTargetsDeletion(ctx, instance, syncContext.TargetSnapshot); err != nil {
+		// reclaim targets deletion before remove finalizers
+		conditions.SetFromError(newStatus, api.XSetTerminating, err, conditions.ReasonReclaimTargetsDeletionFailed, err.Error())
 		return err
 	} else if !cleaned {
-		synccontrols.AddOrUpdateCondition(newStatus, api.XSetTerminating, errors.New("deleting targets"), "ReclaimingTargetsDeletion", fmt.Sprintf("waiting for all %s deleted", r.XSetController.XMeta().Kind))
+		conditions.SetFromError(newStatus, api.XSetTerminating, errors.New("deleting targets"), conditions.ReasonReclaimingTargetsDeletion, fmt.Sprintf("waiting for all %s deleted", r.XSetController.XMeta().Kind))
 		return nil
 	}
 
 	// reclaim owner IDs in ResourceContextControl
 	if err := r.resourceContextControl.UpdateToTargetContext(ctx, instance, nil); err != nil {
-		synccontrols.AddOrUpdateCondition(newStatus, api.XSetTerminating, err, "ReclaimResourceContext", err.Error())
+		conditions.SetFromError(newStatus, api.XSetTerminating, err, conditions.ReasonReclaimResourceContext, err.Error())
 		return err
 	}
 
-	synccontrols.AddOrUpdateCondition(newStatus, api.XSetTerminating, nil, "Deleted", "")
+	conditions.SetFromError(newStatus, api.XSetTerminating, nil, conditions.ReasonDeleted, "")
 	return nil
 }
 
@@ -311,10 +590,10 @@ func (r *xSetCommonReconciler) ensureReclaimTargetSubResources(ctx context.Conte
 	return nil
 }
 
-// ensureReclaimPvcs removes xset ownerReference from pvcs if RetainPvcWhenXSetDeleted.
+// ensureReclaimPvcs removes xset ownerReference from pvcs if RetainPvcWhenXSetDeleted or targets are orphaned.
 // This allows pvcs to be retained for other xsets with same pvc template.
 func (r *xSetCommonReconciler) ensureReclaimPvcs(ctx context.Context, xset api.XSetObject) error {
-	if !r.pvcControl.RetainPvcWhenXSetDeleted(xset) {
+	if !r.pvcControl.RetainPvcWhenXSetDeleted(xset) && !r.orphanTargetsOnDeletion(xset) {
 		return nil
 	}
 	var needReclaimPvcs []*corev1.PersistentVolumeClaim
@@ -337,9 +616,8 @@ func (r *xSetCommonReconciler) ensureReclaimPvcs(ctx context.Context, xset api.X
 	return nil
 }
 
-func (r *xSetCommonReconciler) ensureReclaimTargetsDeletion(ctx context.Context, instance api.XSetObject) (bool, error) {
-	xSetSpec := r.XSetController.GetXSetSpec(instance)
-	_, targets, err := r.targetControl.GetFilteredTargets(ctx, xSetSpec.Selector, instance)
+func (r *xSetCommonReconciler) ensureReclaimTargetsDeletion(ctx context.Context, instance api.XSetObject, snapshot *xcontrol.TargetSnapshot) (bool, error) {
+	_, targets, err := snapshot.GetFilteredTargets(ctx)
 	if err != nil {
 		return false, fmt.Errorf("fail to get filtered Targets: %w", err)
 	}
@@ -355,36 +633,104 @@ func (r *xSetCommonReconciler) ensureReclaimTargetsDeletion(ctx context.Context,
 			return false, r.syncControl.BatchDeleteTargetsByLabel(ctx, r.targetControl, targets)
 		}
 	}
-	return false, nil
+	// all targets are terminating, release protection finalizers to let them go
+	return false, r.syncControl.ReleaseProtectionFinalizers(ctx, instance, targets)
+}
+
+// orphanTargetsOnDeletion returns true if targets are orphaned rather than deleted when xset is deleted, either by
+// TargetDeletionPolicyAdapter or by orphan propagation policy of the deletion.
+func (r *xSetCommonReconciler) orphanTargetsOnDeletion(instance api.XSetObject) bool {
+	if controllerutil.ContainsFinalizer(instance, metav1.FinalizerOrphanDependents) {
+		return true
+	}
+	if adapter, ok := r.XSetController.(api.TargetDeletionPolicyAdapter); ok {
+		return adapter.GetTargetDeletionPolicy(instance) == api.OrphanTargetDeletionPolicyType
+	}
+	return false
 }
 
-// ensureReclaimOwnerReferences removes decoration ownerReference from filteredPods if xset is deleting.
-func (r *xSetCommonReconciler) ensureReclaimOwnerReferences(ctx context.Context, instance api.XSetObject) error {
-	decorationAdapter, ok := r.XSetController.(api.DecorationAdapter)
-	if !ok {
+// ensureOrphanTargets strips xset ownerReference, control labels and protection finalizer from targets, so that
+// targets keep running after xset is deleted. Instance ID label is kept for another xset to adopt them.
+func (r *xSetCommonReconciler) ensureOrphanTargets(ctx context.Context, instance api.XSetObject, snapshot *xcontrol.TargetSnapshot) error {
+	_, targets, err := snapshot.GetFilteredTargets(ctx)
+	if err != nil {
+		return fmt.Errorf("fail to get filtered Targets: %w", err)
+	}
+	if len(targets) == 0 {
 		return nil
 	}
-	xSetSpec := r.XSetController.GetXSetSpec(instance)
-	_, filteredTargets, err := r.targetControl.GetFilteredTargets(ctx, xSetSpec.Selector, instance)
+
+	finalizer := synccontrols.GetTargetProtectionFinalizer(r.XSetController, instance)
+	xMeta := r.XSetController.XMeta()
+	targetGVK := xMeta.GroupVersionKind()
+	_, err = controllerutils.SlowStartBatch(len(targets), controllerutils.SlowStartInitialBatchSize, false, func(i int, _ error) error {
+		target := targets[i]
+		if err := r.targetControl.PatchTargetWithOptimisticLock(ctx, target, func(target client.Object) {
+			var ownerRefs []metav1.OwnerReference
+			for _, ref := range target.GetOwnerReferences() {
+				if ref.UID != instance.GetUID() {
+					ownerRefs = append(ownerRefs, ref)
+				}
+			}
+			target.SetOwnerReferences(ownerRefs)
+			for _, label := range orphanedTargetLabels {
+				r.xsetLabelMgr.Delete(target, label)
+			}
+			if finalizer != "" {
+				controllerutil.RemoveFinalizer(target, finalizer)
+			}
+		}); err != nil {
+			return fmt.Errorf("failed to orphan target %s/%s: %w", target.GetNamespace(), target.GetName(), err)
+		}
+		return r.cacheExpectations.ExpectUpdation(clientutil.ObjectKeyString(instance), targetGVK, target.GetNamespace(), target.GetName(), target.GetResourceVersion())
+	})
+	if err != nil {
+		return err
+	}
+	r.Recorder.Eventf(instance, corev1.EventTypeNormal, "TargetsOrphaned", "orphaned %d %s before xset deleted", len(targets), xMeta.Kind)
+	return nil
+}
+
+// orphanedTargetLabels are control labels stripped from targets orphaned on xset deletion
+var orphanedTargetLabels = []api.XSetLabelAnnotationEnum{
+	api.ControlledByXSetLabel,
+	api.XSetUpdateIndicationLabelKey,
+	api.XDeletionIndicationLabelKey,
+	api.XReplaceIndicationLabelKey,
+	api.XReplacePairNewId,
+	api.XReplacePairOriginName,
+}
+
+// ensureReclaimOwnerReferences removes ownerReferences of all decoration kinds from filteredPods if xset is deleting.
+func (r *xSetCommonReconciler) ensureReclaimOwnerReferences(ctx context.Context, instance api.XSetObject, snapshot *xcontrol.TargetSnapshot) error {
+	decorationAdapters := synccontrols.GetDecorationAdapters(r.XSetController)
+	if len(decorationAdapters) == 0 {
+		return nil
+	}
+	_, filteredTargets, err := snapshot.GetFilteredTargets(ctx)
 	if err != nil {
 		return fmt.Errorf("fail to get filtered Targets: %w", err)
 	}
 	// reclaim decoration ownerReferences on filteredPods
-	gvk := decorationAdapter.GetDecorationGroupVersionKind()
+	decorationKinds := sets.NewString()
+	for _, adapter := range decorationAdapters {
+		decorationKinds.Insert(adapter.GetDecorationGroupVersionKind().Kind)
+	}
 	for i := range filteredTargets {
 		if len(filteredTargets[i].GetOwnerReferences()) == 0 {
 			continue
 		}
 		var newOwnerRefs []metav1.OwnerReference
 		for j := range filteredTargets[i].GetOwnerReferences() {
-			if filteredTargets[i].GetOwnerReferences()[j].Kind == gvk.Kind {
+			if decorationKinds.Has(filteredTargets[i].GetOwnerReferences()[j].Kind) {
 				continue
 			}
 			newOwnerRefs = append(newOwnerRefs, filteredTargets[i].GetOwnerReferences()[j])
 		}
 		if len(newOwnerRefs) != len(filteredTargets[i].GetOwnerReferences()) {
-			filteredTargets[i].SetOwnerReferences(newOwnerRefs)
-			if err := r.targetControl.UpdateTarget(ctx, filteredTargets[i]); err != nil {
+			if err := r.targetControl.PatchTargetWithOptimisticLock(ctx, filteredTargets[i], func(target client.Object) {
+				target.SetOwnerReferences(newOwnerRefs)
+			}); err != nil {
 				return err
 			}
 		}
@@ -392,19 +738,62 @@ func (r *xSetCommonReconciler) ensureReclaimOwnerReferences(ctx context.Context,
 	return nil
 }
 
+// updateStatus updates status of XSet if changed, and retries on conflict with the latest XSet. Expectation is only
+// recorded when status is actually written.
 func (r *xSetCommonReconciler) updateStatus(ctx context.Context, instance api.XSetObject, status *api.XSetStatus) error {
-	r.XSetController.SetXSetStatus(instance, status)
-	if err := r.Client.Status().Update(ctx, instance); err != nil {
+	if equality.Semantic.DeepEqual(r.XSetController.GetXSetStatus(instance), status) {
+		return nil
+	}
+
+	resourceVersion := instance.GetResourceVersion()
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		r.XSetController.SetXSetStatus(instance, status)
+		err := r.Client.Status().Update(ctx, instance)
+		if !apierrors.IsConflict(err) {
+			return err
+		}
+		// refresh XSet from api server and retry, since cache may not observe the conflicting write yet
+		if getErr := r.APIReader.Get(ctx, client.ObjectKeyFromObject(instance), instance); getErr != nil {
+			return getErr
+		}
+		return err
+	}); err != nil {
 		return fmt.Errorf("fail to update status of %s: %w", instance.GetName(), err)
 	}
+	if instance.GetResourceVersion() == resourceVersion {
+		return nil
+	}
 	return r.cacheExpectations.ExpectUpdation(clientutil.ObjectKeyString(instance), r.xsetGVK, instance.GetNamespace(), instance.GetName(), instance.GetResourceVersion())
 }
 
-func requeueResult(requeueTime *time.Duration) reconcile.Result {
+// waitForExpectations checks whether reconciling should wait for cache expectations, and drops them once they are
+// unsatisfied longer than expectation timeout.
+func (r *xSetCommonReconciler) waitForExpectations(key string) (time.Duration, bool) {
+	if r.cacheExpectations.SatisfiedExpectations(key) {
+		r.unsatisfiedSince.Delete(key)
+		return 0, false
+	}
+	since, _ := r.unsatisfiedSince.LoadOrStore(key, time.Now())
+	elapsed := time.Since(since.(time.Time))
+	if elapsed >= r.expectationTimeout {
+		r.Logger.Info("cache expectations expired, drop them", "key", key, "elapsed", elapsed.String())
+		r.cacheExpectations.DeleteExpectations(key)
+		r.unsatisfiedSince.Delete(key)
+		return 0, false
+	}
+	return min(r.expectationRequeue, r.expectationTimeout-elapsed), true
+}
+
+// requeueResult returns result to requeue after requeueTime with jitter, so that XSets requeued with the same fixed
+// duration spread out over time.
+func requeueResult(requeueTime *time.Duration, jitterFactor float64) reconcile.Result {
 	if requeueTime != nil {
 		if *requeueTime == 0 {
 			return reconcile.Result{Requeue: true}
 		}
+		if jitterFactor > 0 {
+			return reconcile.Result{RequeueAfter: wait.Jitter(*requeueTime, jitterFactor)}
+		}
 		return reconcile.Result{RequeueAfter: *requeueTime}
 	}
 	return reconcile.Result{}
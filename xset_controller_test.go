@@ -0,0 +1,425 @@
+/*
+ * Copyright 2024-2025 KusionStack Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xset
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"kusionstack.io/kube-utils/controller/expectations"
+	"kusionstack.io/kube-utils/controller/history"
+	"kusionstack.io/kube-utils/controller/mixin"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"kusionstack.io/kube-xset/api"
+	"kusionstack.io/kube-xset/conditions"
+	"kusionstack.io/kube-xset/testing/fake"
+	"kusionstack.io/kube-xset/xcontrol"
+)
+
+type rateLimiterController struct {
+	api.XSetController
+	rateLimiter workqueue.RateLimiter
+}
+
+func (c *rateLimiterController) GetRateLimiter() workqueue.RateLimiter { return c.rateLimiter }
+
+func TestNewControllerOptions(t *testing.T) {
+	reconciler := &xSetCommonReconciler{}
+	if options := newControllerOptions(&rateLimiterController{}, reconciler); options.RateLimiter != nil || options.Reconciler != reconciler {
+		t.Errorf("got rate limiter %v, want default one", options.RateLimiter)
+	}
+
+	rateLimiter := workqueue.NewItemExponentialFailureRateLimiter(time.Second, time.Minute)
+	if options := newControllerOptions(&rateLimiterController{rateLimiter: rateLimiter}, reconciler); options.RateLimiter != rateLimiter {
+		t.Errorf("got rate limiter %v, want the one of RateLimiterAdapter", options.RateLimiter)
+	}
+}
+
+// unsatisfiedExpectations are never satisfied until deleted
+type unsatisfiedExpectations struct {
+	expectations.CacheExpectationsInterface
+	deleted []string
+}
+
+func (e *unsatisfiedExpectations) SatisfiedExpectations(string) bool { return false }
+
+func (e *unsatisfiedExpectations) DeleteExpectations(key string) { e.deleted = append(e.deleted, key) }
+
+func TestWaitForExpectations(t *testing.T) {
+	exp := &unsatisfiedExpectations{}
+	r := &xSetCommonReconciler{
+		ReconcilerMixin:    mixin.ReconcilerMixin{Logger: logr.Discard()},
+		cacheExpectations:  newExpectationTracker(exp),
+		expectationTimeout: time.Minute,
+		expectationRequeue: 30 * time.Second,
+	}
+
+	requeueAfter, unsatisfied := r.waitForExpectations("default/foo")
+	if !unsatisfied || requeueAfter != 30*time.Second {
+		t.Fatalf("waitForExpectations() = %s, %v, want requeue after 30s", requeueAfter, unsatisfied)
+	}
+
+	// requeue no later than timeout
+	r.unsatisfiedSince.Store("default/foo", time.Now().Add(-50*time.Second))
+	if requeueAfter, unsatisfied = r.waitForExpectations("default/foo"); !unsatisfied || requeueAfter > 10*time.Second {
+		t.Fatalf("waitForExpectations() = %s, %v, want requeue within 10s", requeueAfter, unsatisfied)
+	}
+
+	// drop expectations once timed out
+	r.unsatisfiedSince.Store("default/foo", time.Now().Add(-time.Minute))
+	if _, unsatisfied = r.waitForExpectations("default/foo"); unsatisfied {
+		t.Fatal("waitForExpectations() = unsatisfied, want expectations dropped after timeout")
+	}
+	if len(exp.deleted) != 1 || exp.deleted[0] != "default/foo" {
+		t.Errorf("deleted expectations = %v, want default/foo", exp.deleted)
+	}
+	if _, ok := r.unsatisfiedSince.Load("default/foo"); ok {
+		t.Error("unsatisfied time is kept after expectations are dropped")
+	}
+}
+
+// statusController uses Pod as XSet, whose observedGeneration is kept in status message
+type statusController struct {
+	api.XSetController
+}
+
+func (c *statusController) GetXSetStatus(object api.XSetObject) *api.XSetStatus {
+	generation, _ := strconv.ParseInt(object.(*corev1.Pod).Status.Message, 10, 64)
+	return &api.XSetStatus{ObservedGeneration: generation}
+}
+
+func (c *statusController) SetXSetStatus(object api.XSetObject, status *api.XSetStatus) {
+	object.(*corev1.Pod).Status.Message = strconv.FormatInt(status.ObservedGeneration, 10)
+}
+
+// updationExpectations records expected resourceVersions
+type updationExpectations struct {
+	expectations.CacheExpectationsInterface
+	resourceVersions []string
+}
+
+func (e *updationExpectations) ExpectUpdation(_ string, _ schema.GroupVersionKind, _, _, resourceVersion string) error {
+	e.resourceVersions = append(e.resourceVersions, resourceVersion)
+	return nil
+}
+
+func TestUpdateStatus(t *testing.T) {
+	ctx := context.TODO()
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}, Status: corev1.PodStatus{Message: "1"}}
+	c := clientfake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(xset).Build()
+	exp := &updationExpectations{}
+	r := &xSetCommonReconciler{
+		ReconcilerMixin:   mixin.ReconcilerMixin{Client: c, APIReader: c},
+		XSetController:    &statusController{},
+		cacheExpectations: newExpectationTracker(exp),
+	}
+
+	instance := &corev1.Pod{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(xset), instance); err != nil {
+		t.Fatal(err)
+	}
+	// unchanged status is not written
+	if err := r.updateStatus(ctx, instance, &api.XSetStatus{ObservedGeneration: 1}); err != nil {
+		t.Fatalf("updateStatus() = %v", err)
+	}
+	if len(exp.resourceVersions) != 0 {
+		t.Fatalf("got expectations %v of unchanged status, want none", exp.resourceVersions)
+	}
+
+	// XSet is changed by others, which makes instance stale
+	latest := instance.DeepCopy()
+	latest.Labels = map[string]string{"changed": "true"}
+	if err := c.Update(ctx, latest); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.updateStatus(ctx, instance, &api.XSetStatus{ObservedGeneration: 2}); err != nil {
+		t.Fatalf("updateStatus() = %v, want retried on conflict", err)
+	}
+	got := &corev1.Pod{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(xset), got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.Message != "2" || got.Labels["changed"] != "true" {
+		t.Errorf("got status %q and labels %v, want status written on the latest XSet", got.Status.Message, got.Labels)
+	}
+	if want := []string{got.ResourceVersion}; fmt.Sprint(exp.resourceVersions) != fmt.Sprint(want) {
+		t.Errorf("got expectations %v, want %v", exp.resourceVersions, want)
+	}
+}
+
+// orphanController uses Pod as both XSet and target, whose targets are protected by finalizer
+type orphanController struct {
+	api.XSetController
+	policy api.TargetDeletionPolicyType
+}
+
+func (c *orphanController) XMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+}
+
+func (c *orphanController) CheckInactive(client.Object) bool { return false }
+
+func (c *orphanController) GetTargetProtectionFinalizer(api.XSetObject) string {
+	return "xset.kusionstack.io/protection"
+}
+
+func (c *orphanController) GetTargetDeletionPolicy(api.XSetObject) api.TargetDeletionPolicyType {
+	return c.policy
+}
+
+func TestOrphanTargetsOnDeletion(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     api.TargetDeletionPolicyType
+		finalizers []string
+		want       bool
+	}{
+		{name: "default policy", want: false},
+		{name: "delete policy", policy: api.DeleteTargetDeletionPolicyType, want: false},
+		{name: "orphan policy", policy: api.OrphanTargetDeletionPolicyType, want: true},
+		{name: "orphan propagation", finalizers: []string{metav1.FinalizerOrphanDependents}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &xSetCommonReconciler{XSetController: &orphanController{policy: tt.policy}}
+			xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Finalizers: tt.finalizers}}
+			if got := r.orphanTargetsOnDeletion(xset); got != tt.want {
+				t.Errorf("orphanTargetsOnDeletion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsureOrphanTargets(t *testing.T) {
+	xsetController := &orphanController{policy: api.OrphanTargetDeletionPolicyType}
+	labelMgr := api.GetXSetLabelAnnotationManager(xsetController)
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", UID: "foo-uid"}}
+	otherOwner := metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "bar", UID: "bar-uid"}
+	target := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       "default",
+		Name:            "foo-0",
+		ResourceVersion: "1",
+		OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(xset, corev1.SchemeGroupVersion.WithKind("Pod")), otherOwner},
+		Finalizers:      []string{"xset.kusionstack.io/protection", "other"},
+	}}
+	labelMgr.Set(target, api.ControlledByXSetLabel, "true")
+	labelMgr.Set(target, api.XSetUpdateIndicationLabelKey, "true")
+	labelMgr.Set(target, api.XInstanceIdLabelKey, "0")
+	targetControl := fake.NewTargetControl(xsetController, target)
+	exp := &updationExpectations{}
+	r := &xSetCommonReconciler{
+		ReconcilerMixin:   mixin.ReconcilerMixin{Recorder: record.NewFakeRecorder(10)},
+		XSetController:    xsetController,
+		targetControl:     targetControl,
+		xsetLabelMgr:      labelMgr,
+		cacheExpectations: newExpectationTracker(exp),
+	}
+
+	if err := r.ensureOrphanTargets(context.TODO(), xset, xcontrol.NewTargetSnapshot(targetControl, &metav1.LabelSelector{}, xset)); err != nil {
+		t.Fatalf("ensureOrphanTargets() = %v", err)
+	}
+	got, _ := targetControl.GetTarget(client.ObjectKeyFromObject(target))
+	if refs := got.GetOwnerReferences(); len(refs) != 1 || refs[0].UID != otherOwner.UID {
+		t.Errorf("got ownerReferences %v, want only the one of other owner", refs)
+	}
+	if finalizers := got.GetFinalizers(); len(finalizers) != 1 || finalizers[0] != "other" {
+		t.Errorf("got finalizers %v, want protection finalizer removed", finalizers)
+	}
+	for _, label := range []api.XSetLabelAnnotationEnum{api.ControlledByXSetLabel, api.XSetUpdateIndicationLabelKey} {
+		if _, ok := labelMgr.Get(got, label); ok {
+			t.Errorf("control label %s is kept on orphaned target", labelMgr.Value(label))
+		}
+	}
+	if id, ok := labelMgr.Get(got, api.XInstanceIdLabelKey); !ok || id != "0" {
+		t.Errorf("got instance ID %q, want kept for adoption", id)
+	}
+	if want := []string{got.GetResourceVersion()}; fmt.Sprint(exp.resourceVersions) != fmt.Sprint(want) {
+		t.Errorf("got expectations %v, want %v", exp.resourceVersions, want)
+	}
+
+	// targets are no longer selected once orphaned
+	if err := r.ensureOrphanTargets(context.TODO(), xset, xcontrol.NewTargetSnapshot(targetControl, &metav1.LabelSelector{}, xset)); err != nil {
+		t.Fatalf("ensureOrphanTargets() = %v", err)
+	}
+	if calls := targetControl.CallsOf("PatchTargetWithOptimisticLock"); len(calls) != 1 {
+		t.Errorf("got %d patches, want orphaned target untouched", len(calls))
+	}
+}
+
+// suspendController uses Pod as XSet, whose status is kept in controller
+type suspendController struct {
+	api.XSetController
+	status *api.XSetStatus
+}
+
+func (c *suspendController) ControllerName() string { return "suspend-controller" }
+
+func (c *suspendController) GetXSetStatus(api.XSetObject) *api.XSetStatus { return c.status }
+
+func (c *suspendController) SetXSetStatus(_ api.XSetObject, status *api.XSetStatus) {
+	c.status = status
+}
+
+func TestSuspend(t *testing.T) {
+	ctx := context.TODO()
+	xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "default",
+		Name:        "foo",
+		Generation:  2,
+		Annotations: map[string]string{api.XSetSuspendAnnotationKey: "true"},
+	}}
+	c := clientfake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(xset).Build()
+	recorder := record.NewFakeRecorder(10)
+	xsetController := &suspendController{status: &api.XSetStatus{ObservedGeneration: 1, Replicas: 3}}
+	r := &xSetCommonReconciler{
+		ReconcilerMixin:   mixin.ReconcilerMixin{Client: c, APIReader: c, Recorder: recorder},
+		XSetController:    xsetController,
+		cacheExpectations: newExpectationTracker(&updationExpectations{}),
+	}
+	if !api.IsXSetSuspended(xset) {
+		t.Fatalf("expect XSet suspended by annotation")
+	}
+
+	instance := &corev1.Pod{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(xset), instance); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.suspend(ctx, instance); err != nil {
+		t.Fatalf("suspend() = %v", err)
+	}
+	status := xsetController.status
+	if !conditions.IsTrue(status, api.XSetSuspended) || !conditions.IsObserved(status, api.XSetSuspended, 2) {
+		t.Errorf("got condition %v, want Suspended observed for generation 2", conditions.Get(status, api.XSetSuspended))
+	}
+	if _, matched := conditions.FindAndCompare(status, api.XSetProgressing, metav1.ConditionUnknown, conditions.ReasonSuspended); !matched {
+		t.Errorf("got condition %v, want Progressing unknown as suspended", conditions.Get(status, api.XSetProgressing))
+	}
+	// other fields of status are left as observed by the last reconcile
+	if status.ObservedGeneration != 1 || status.Replicas != 3 {
+		t.Errorf("got status %+v, want the one of last reconcile", status)
+	}
+	if got := testutil.ToFloat64(suspendedXSets.WithLabelValues("suspend-controller", "default", "foo")); got != 1 {
+		t.Errorf("got metric %v of suspended XSet, want 1", got)
+	}
+	if len(recorder.Events) != 1 {
+		t.Fatalf("got %d events, want Suspended event", len(recorder.Events))
+	}
+	<-recorder.Events
+
+	// event is only emitted once suspended
+	if err := r.suspend(ctx, instance); err != nil {
+		t.Fatalf("suspend() = %v", err)
+	}
+	if len(recorder.Events) != 0 {
+		t.Errorf("got event %s of XSet already suspended", <-recorder.Events)
+	}
+	suspendedXSets.DeleteLabelValues("suspend-controller", "default", "foo")
+
+	xset.Annotations[api.XSetSuspendAnnotationKey] = "false"
+	if api.IsXSetSuspended(xset) {
+		t.Errorf("expect XSet not suspended unless annotated with true")
+	}
+}
+
+type migrationController struct {
+	api.XSetController
+}
+
+func (c *migrationController) ControllerName() string { return "migration-controller" }
+
+func (c *migrationController) NewXSetObject() api.XSetObject { return &corev1.Pod{} }
+
+func (c *migrationController) GetXSetSpec(api.XSetObject) *api.XSetSpec { return &api.XSetSpec{} }
+
+func (c *migrationController) GetXSetStatus(api.XSetObject) *api.XSetStatus { return &api.XSetStatus{} }
+
+// satisfiedExpectations are always satisfied
+type satisfiedExpectations struct {
+	expectations.CacheExpectationsInterface
+}
+
+func (e *satisfiedExpectations) SatisfiedExpectations(string) bool { return true }
+
+// constructedRevisions fails constructing revisions, which tells reconcile goes beyond the migration skip
+type constructedRevisions struct {
+	history.HistoryManager
+	calls int
+}
+
+func (m *constructedRevisions) ConstructRevisions(context.Context, client.Object) (*appsv1.ControllerRevision, *appsv1.ControllerRevision, []*appsv1.ControllerRevision, int32, bool, error) {
+	m.calls++
+	return nil, nil, nil, 0, false, fmt.Errorf("revisions constructed")
+}
+
+func TestReconcileMigratingXSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		deleted bool
+		// wantSync means XSet is reconciled rather than skipped by migration
+		wantSync bool
+	}{
+		{name: "migrating", deleted: false},
+		{name: "deleted mid-migration", deleted: true, wantSync: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xset := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "default",
+				Name:        "foo",
+				Annotations: map[string]string{api.XSetMigrationAnnotationKey: string(api.XSetMigrationAdopted)},
+				Finalizers:  []string{"apps.kusionstack.io/xset"},
+			}}
+			if tt.deleted {
+				xset.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			}
+			c := clientfake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(xset).Build()
+			revisionManager := &constructedRevisions{}
+			r := &xSetCommonReconciler{
+				ReconcilerMixin:   mixin.ReconcilerMixin{Client: c, APIReader: c, Recorder: record.NewFakeRecorder(10), Logger: logr.Discard()},
+				XSetController:    &migrationController{},
+				finalizerName:     "apps.kusionstack.io/xset",
+				finalizerless:     true,
+				cacheExpectations: newExpectationTracker(&satisfiedExpectations{}),
+				revisionManager:   revisionManager,
+				templateRefs:      newTemplateRefIndex(),
+			}
+
+			_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(xset)})
+			if synced := revisionManager.calls > 0; synced != tt.wantSync {
+				t.Errorf("got XSet reconciled %v, want %v", synced, tt.wantSync)
+			}
+			if tt.wantSync != (err != nil) {
+				t.Errorf("Reconcile() = %v", err)
+			}
+		})
+	}
+}